@@ -0,0 +1,300 @@
+package anp_a2a
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/bytedance/sonic"
+	"github.com/openanp/anp-go/anp_auth"
+	"github.com/openanp/anp-go/anp_server"
+)
+
+const defaultTimeout = 30 * time.Second
+
+// Option configures a Client or ResolveCard.
+type Option func(*Client)
+
+// WithHTTPClient sets the HTTP client used for requests. Streaming calls last as
+// long as the task runs, so the client should not set a short Timeout.
+func WithHTTPClient(h *http.Client) Option {
+	return func(c *Client) {
+		if h != nil {
+			c.httpClient = h
+		}
+	}
+}
+
+// WithAuthenticator signs requests with DID-WBA, for A2A agents hosted behind
+// ANP authentication.
+func WithAuthenticator(auth *anp_auth.Authenticator) Option {
+	return func(c *Client) { c.authenticator = auth }
+}
+
+// WithHeader adds a static header to every request, e.g. an API key.
+func WithHeader(key, value string) Option {
+	return func(c *Client) { c.headers.Set(key, value) }
+}
+
+// WithLogger sets the logger used for diagnostics.
+func WithLogger(logger *slog.Logger) Option {
+	return func(c *Client) {
+		if logger != nil {
+			c.logger = logger
+		}
+	}
+}
+
+// Client calls an A2A agent over the JSON-RPC transport. It is safe for
+// concurrent use.
+type Client struct {
+	endpoint      string
+	httpClient    *http.Client
+	authenticator *anp_auth.Authenticator
+	headers       http.Header
+	logger        *slog.Logger
+
+	nextID atomic.Int64
+}
+
+// NewClient creates a client for the JSON-RPC endpoint of an agent, usually
+// AgentCard.URL.
+func NewClient(endpoint string, opts ...Option) *Client {
+	c := &Client{
+		endpoint:   endpoint,
+		httpClient: &http.Client{Timeout: defaultTimeout},
+		headers:    http.Header{},
+		logger:     slog.Default(),
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	if c.authenticator != nil {
+		c.httpClient = &http.Client{
+			Timeout:   c.httpClient.Timeout,
+			Transport: &anp_auth.Transport{Base: c.httpClient.Transport, Authenticator: c.authenticator},
+		}
+	}
+	return c
+}
+
+// Endpoint returns the agent's JSON-RPC URL.
+func (c *Client) Endpoint() string { return c.endpoint }
+
+// ResolveCard fetches the agent card published under baseURL, falling back to
+// the pre-0.3 location when the current one is missing.
+func ResolveCard(ctx context.Context, baseURL string, opts ...Option) (*AgentCard, error) {
+	c := NewClient(baseURL, opts...)
+	base := strings.TrimRight(baseURL, "/")
+
+	card, status, err := c.fetchCard(ctx, base+WellKnownCardPath)
+	if status == http.StatusNotFound {
+		card, _, err = c.fetchCard(ctx, base+LegacyCardPath)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return card, nil
+}
+
+func (c *Client) fetchCard(ctx context.Context, target string) (*AgentCard, int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return nil, 0, fmt.Errorf("create request: %w", err)
+	}
+	for k, v := range c.headers {
+		req.Header[k] = v
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, 0, fmt.Errorf("fetch agent card: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, resp.StatusCode, fmt.Errorf("fetch agent card %s: status %d", target, resp.StatusCode)
+	}
+
+	var card AgentCard
+	if err := sonic.ConfigDefault.NewDecoder(resp.Body).Decode(&card); err != nil {
+		return nil, resp.StatusCode, fmt.Errorf("decode agent card: %w", err)
+	}
+	if card.URL == "" {
+		return nil, resp.StatusCode, fmt.Errorf("agent card %s has no url", target)
+	}
+	return &card, resp.StatusCode, nil
+}
+
+// SendMessage sends msg and waits for the agent's reply, which is either a Task
+// or a direct Message.
+func (c *Client) SendMessage(ctx context.Context, msg *Message) (*Event, error) {
+	var ev Event
+	if err := c.call(ctx, "message/send", map[string]any{"message": msg}, &ev); err != nil {
+		return nil, fmt.Errorf("send message: %w", err)
+	}
+	return &ev, nil
+}
+
+// StreamMessage sends msg and calls fn for every event the agent streams back,
+// until a final event arrives, the stream ends or fn returns an error.
+func (c *Client) StreamMessage(ctx context.Context, msg *Message, fn func(*Event) error) error {
+	id := json.RawMessage(strconv.FormatInt(c.nextID.Add(1), 10))
+	resp, err := c.post(ctx, &rpcMessage{JSONRPC: "2.0", ID: id, Method: "message/stream", Params: map[string]any{"message": msg}}, "text/event-stream")
+	if err != nil {
+		return fmt.Errorf("stream message: %w", err)
+	}
+	defer resp.Body.Close()
+
+	// Agents without streaming support may answer with a single JSON response.
+	if !strings.HasPrefix(resp.Header.Get("Content-Type"), "text/event-stream") {
+		var reply rpcMessage
+		if err := sonic.ConfigDefault.NewDecoder(resp.Body).Decode(&reply); err != nil {
+			return fmt.Errorf("stream message: decode response: %w", err)
+		}
+		ev, err := reply.event()
+		if err != nil {
+			return fmt.Errorf("stream message: %w", err)
+		}
+		return fn(ev)
+	}
+
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 64<<10), 10<<20)
+	var data []string
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, "data:") {
+			data = append(data, strings.TrimPrefix(strings.TrimPrefix(line, "data:"), " "))
+			continue
+		}
+		if line != "" || len(data) == 0 {
+			continue
+		}
+
+		var reply rpcMessage
+		err := sonic.UnmarshalString(strings.Join(data, "\n"), &reply)
+		data = nil
+		if err != nil {
+			return fmt.Errorf("stream message: decode event: %w", err)
+		}
+		ev, err := reply.event()
+		if err != nil {
+			return fmt.Errorf("stream message: %w", err)
+		}
+		if err := fn(ev); err != nil {
+			return err
+		}
+		if ev.Final() {
+			return nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("stream message: read event stream: %w", err)
+	}
+	return nil
+}
+
+// GetTask returns the current state of a task. historyLength limits the number
+// of history messages returned; zero returns the agent's default.
+func (c *Client) GetTask(ctx context.Context, taskID string, historyLength int) (*Task, error) {
+	params := map[string]any{"id": taskID}
+	if historyLength > 0 {
+		params["historyLength"] = historyLength
+	}
+	var task Task
+	if err := c.call(ctx, "tasks/get", params, &task); err != nil {
+		return nil, fmt.Errorf("get task %s: %w", taskID, err)
+	}
+	return &task, nil
+}
+
+// CancelTask asks the agent to cancel a task.
+func (c *Client) CancelTask(ctx context.Context, taskID string) (*Task, error) {
+	var task Task
+	if err := c.call(ctx, "tasks/cancel", map[string]any{"id": taskID}, &task); err != nil {
+		return nil, fmt.Errorf("cancel task %s: %w", taskID, err)
+	}
+	return &task, nil
+}
+
+type rpcMessage struct {
+	JSONRPC string            `json:"jsonrpc"`
+	ID      json.RawMessage   `json:"id,omitempty"`
+	Method  string            `json:"method,omitempty"`
+	Params  any               `json:"params,omitempty"`
+	Result  json.RawMessage   `json:"result,omitempty"`
+	Error   *anp_server.Error `json:"error,omitempty"`
+}
+
+func (m *rpcMessage) event() (*Event, error) {
+	if m.Error != nil {
+		return nil, m.Error
+	}
+	var ev Event
+	if err := sonic.Unmarshal(m.Result, &ev); err != nil {
+		return nil, fmt.Errorf("decode event: %w", err)
+	}
+	return &ev, nil
+}
+
+func (c *Client) call(ctx context.Context, method string, params, result any) error {
+	id := json.RawMessage(strconv.FormatInt(c.nextID.Add(1), 10))
+	resp, err := c.post(ctx, &rpcMessage{JSONRPC: "2.0", ID: id, Method: method, Params: params}, "application/json")
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	var reply rpcMessage
+	if err := sonic.ConfigDefault.NewDecoder(resp.Body).Decode(&reply); err != nil {
+		return fmt.Errorf("decode response: %w", err)
+	}
+	if reply.Error != nil {
+		return reply.Error
+	}
+	if len(reply.Result) == 0 {
+		return errors.New("response has no result")
+	}
+	if err := sonic.Unmarshal(reply.Result, result); err != nil {
+		return fmt.Errorf("decode %s result: %w", method, err)
+	}
+	return nil
+}
+
+func (c *Client) post(ctx context.Context, msg *rpcMessage, accept string) (*http.Response, error) {
+	body, err := sonic.Marshal(msg)
+	if err != nil {
+		return nil, fmt.Errorf("encode request: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("create request: %w", err)
+	}
+	for k, v := range c.headers {
+		req.Header[k] = v
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", accept)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", msg.Method, err)
+	}
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		snippet, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		resp.Body.Close()
+		return nil, fmt.Errorf("%s: status %d: %s", msg.Method, resp.StatusCode, strings.TrimSpace(string(snippet)))
+	}
+	return resp, nil
+}
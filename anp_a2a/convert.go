@@ -0,0 +1,81 @@
+package anp_a2a
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/openanp/anp-go/anp_ad"
+	"github.com/openanp/anp-go/anp_crawler"
+)
+
+// ProtocolA2A is the interface protocol used for A2A endpoints in ANP agent
+// descriptions.
+const ProtocolA2A = "A2A"
+
+// CardFromAgentDescription derives an A2A agent card from an ANP agent
+// description. endpoint is the URL of the agent's A2A JSON-RPC endpoint; each
+// ANP interface becomes a skill.
+func CardFromAgentDescription(ad *anp_ad.AgentDescription, endpoint string) *AgentCard {
+	card := &AgentCard{
+		ProtocolVersion:    ProtocolVersion,
+		Name:               ad.Name,
+		Description:        ad.Description,
+		URL:                endpoint,
+		PreferredTransport: TransportJSONRPC,
+		Version:            ad.ProtocolVersion,
+		DefaultInputModes:  []string{"text/plain", "application/json"},
+		DefaultOutputModes: []string{"text/plain", "application/json"},
+		Skills:             []AgentSkill{},
+	}
+	if ad.Owner != nil {
+		card.Provider = &AgentProvider{Organization: ad.Owner.Name, URL: ad.Owner.URL}
+	}
+	for i, iface := range ad.Interfaces {
+		name := iface.Description
+		if name == "" {
+			name = iface.Type
+		}
+		card.Skills = append(card.Skills, AgentSkill{
+			ID:          fmt.Sprintf("%s-%d", strings.ToLower(iface.Protocol), i+1),
+			Name:        name,
+			Description: iface.Description,
+			Tags:        []string{iface.Type, iface.Protocol},
+		})
+	}
+	return card
+}
+
+// SkillsFromTools lists ANP tools, e.g. session.Document.Tools, as A2A skills.
+func SkillsFromTools(tools []*anp_crawler.ANPTool) []AgentSkill {
+	skills := make([]AgentSkill, 0, len(tools))
+	for _, tool := range tools {
+		skills = append(skills, AgentSkill{
+			ID:          tool.Function.Name,
+			Name:        tool.Function.Name,
+			Description: tool.Function.Description,
+			Tags:        []string{"anp", tool.Type},
+			InputModes:  []string{"application/json"},
+		})
+	}
+	return skills
+}
+
+// AgentDescriptionFromCard derives an unsigned ANP agent description that
+// advertises the A2A agent's endpoint as a natural language interface. did may
+// be empty for agents without a DID.
+func AgentDescriptionFromCard(card *AgentCard, did string) (*anp_ad.AgentDescription, error) {
+	b := anp_ad.NewBuilder(card.Name).
+		Description(card.Description).
+		AddInterface(anp_ad.InterfaceTypeNaturalLanguage, ProtocolA2A, card.URL, card.Description)
+	if did != "" {
+		b.DID(did)
+	}
+	if card.Provider != nil && card.Provider.Organization != "" {
+		b.Owner("Organization", card.Provider.Organization, card.Provider.URL)
+	}
+	ad, err := b.Build()
+	if err != nil {
+		return nil, fmt.Errorf("convert agent card %s: %w", card.Name, err)
+	}
+	return ad, nil
+}
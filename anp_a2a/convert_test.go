@@ -0,0 +1,41 @@
+package anp_a2a
+
+import (
+	"testing"
+
+	"github.com/openanp/anp-go/anp_ad"
+)
+
+func TestCardAgentDescriptionRoundTrip(t *testing.T) {
+	ad, err := anp_ad.NewBuilder("Hotel Assistant").
+		Description("Books hotel rooms").
+		Owner("Organization", "Example Inc.", "https://example.com").
+		AddInterface(anp_ad.InterfaceTypeStructured, anp_ad.ProtocolOpenRPC, "https://example.com/api.json", "Booking API").
+		Build()
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+
+	card := CardFromAgentDescription(ad, "https://example.com/a2a")
+	if card.Name != "Hotel Assistant" || card.URL != "https://example.com/a2a" || card.Provider.Organization != "Example Inc." {
+		t.Errorf("CardFromAgentDescription() = %+v", card)
+	}
+	if len(card.Skills) != 1 || card.Skills[0].ID != "openrpc-1" || card.Skills[0].Name != "Booking API" {
+		t.Errorf("skills = %+v", card.Skills)
+	}
+
+	back, err := AgentDescriptionFromCard(card, "did:wba:example.com:hotel")
+	if err != nil {
+		t.Fatalf("AgentDescriptionFromCard() error = %v", err)
+	}
+	if back.Name != ad.Name || back.DID != "did:wba:example.com:hotel" || back.Owner.Name != "Example Inc." {
+		t.Errorf("AgentDescriptionFromCard() = %+v", back)
+	}
+	if len(back.Interfaces) != 1 || back.Interfaces[0].Protocol != ProtocolA2A || back.Interfaces[0].URL != card.URL {
+		t.Errorf("interfaces = %+v", back.Interfaces)
+	}
+
+	if _, err := AgentDescriptionFromCard(&AgentCard{URL: "https://x"}, ""); err == nil {
+		t.Error("AgentDescriptionFromCard() without name succeeded")
+	}
+}
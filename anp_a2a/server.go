@@ -0,0 +1,493 @@
+package anp_a2a
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/bytedance/sonic"
+	"github.com/openanp/anp-go/anp_auth"
+	"github.com/openanp/anp-go/anp_server"
+)
+
+const defaultMaxTasks = 1000
+
+// Handler performs the work for an incoming message. It reports progress through
+// t; returning nil completes the task unless the handler left it in a terminal
+// or interrupted state, and returning an error fails it.
+type Handler func(ctx context.Context, t *TaskContext) error
+
+// ServerOption configures a Server.
+type ServerOption func(*Server)
+
+// WithVerifier requires DID-WBA authentication for JSON-RPC calls. The agent
+// card stays public. Handlers read the caller with anp_auth.DIDFromContext.
+func WithVerifier(verifier *anp_auth.DidWbaVerifier) ServerOption {
+	return func(s *Server) { s.verifier = verifier }
+}
+
+// WithMaxTasks bounds the number of tasks kept for tasks/get (default 1000).
+// The oldest finished tasks are forgotten first.
+func WithMaxTasks(n int) ServerOption {
+	return func(s *Server) {
+		if n > 0 {
+			s.maxTasks = n
+		}
+	}
+}
+
+// WithServerLogger sets the logger used for diagnostics.
+func WithServerLogger(logger *slog.Logger) ServerOption {
+	return func(s *Server) {
+		if logger != nil {
+			s.logger = logger
+		}
+	}
+}
+
+// Server serves an A2A agent: the agent card at the well-known paths and the
+// JSON-RPC methods message/send, message/stream, tasks/get and tasks/cancel on
+// every other path.
+type Server struct {
+	card     AgentCard
+	handler  Handler
+	verifier *anp_auth.DidWbaVerifier
+	maxTasks int
+	logger   *slog.Logger
+	rpc      http.Handler
+
+	mu    sync.Mutex
+	tasks map[string]*taskEntry
+	order []string
+}
+
+type taskEntry struct {
+	task     Task
+	running  bool
+	cancel   context.CancelFunc
+	canceled bool
+}
+
+// NewServer creates a Server. Missing card fields required by A2A are filled in
+// and streaming is advertised. An empty card URL is published as the root of the
+// host the card was requested from.
+func NewServer(card AgentCard, handler Handler, opts ...ServerOption) *Server {
+	if card.ProtocolVersion == "" {
+		card.ProtocolVersion = ProtocolVersion
+	}
+	if card.PreferredTransport == "" {
+		card.PreferredTransport = TransportJSONRPC
+	}
+	if card.Version == "" {
+		card.Version = "1.0.0"
+	}
+	if len(card.DefaultInputModes) == 0 {
+		card.DefaultInputModes = []string{"text/plain", "application/json"}
+	}
+	if len(card.DefaultOutputModes) == 0 {
+		card.DefaultOutputModes = []string{"text/plain", "application/json"}
+	}
+	if card.Skills == nil {
+		card.Skills = []AgentSkill{}
+	}
+	card.Capabilities.Streaming = true
+
+	s := &Server{
+		card:     card,
+		handler:  handler,
+		maxTasks: defaultMaxTasks,
+		logger:   slog.Default(),
+		tasks:    make(map[string]*taskEntry),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	s.rpc = http.HandlerFunc(s.serveRPC)
+	if s.verifier != nil {
+		s.rpc = anp_auth.Middleware(s.verifier)(s.rpc)
+	}
+	return s
+}
+
+// Card returns the published agent card.
+func (s *Server) Card() AgentCard { return s.card }
+
+// ServeHTTP implements http.Handler.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodGet && (r.URL.Path == WellKnownCardPath || r.URL.Path == LegacyCardPath) {
+		card := s.card
+		if card.URL == "" {
+			card.URL = requestOrigin(r) + "/"
+		}
+		writeJSON(w, card)
+		return
+	}
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	s.rpc.ServeHTTP(w, r)
+}
+
+type rpcRequest struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params"`
+}
+
+type rpcResponse struct {
+	JSONRPC string            `json:"jsonrpc"`
+	ID      json.RawMessage   `json:"id"`
+	Result  any               `json:"result,omitempty"`
+	Error   *anp_server.Error `json:"error,omitempty"`
+}
+
+func (s *Server) serveRPC(w http.ResponseWriter, r *http.Request) {
+	var req rpcRequest
+	if err := sonic.ConfigDefault.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, rpcResponse{JSONRPC: "2.0", ID: json.RawMessage("null"), Error: anp_server.NewError(anp_server.CodeParseError, "parse error")})
+		return
+	}
+	if req.ID == nil {
+		req.ID = json.RawMessage("null")
+	}
+
+	reply := func(result any, err error) {
+		resp := rpcResponse{JSONRPC: "2.0", ID: req.ID, Result: result}
+		if err != nil {
+			resp.Result = nil
+			resp.Error = toRPCError(err)
+		}
+		writeJSON(w, resp)
+	}
+
+	switch req.Method {
+	case "message/send":
+		msg, err := decodeMessage(req.Params)
+		if err != nil {
+			reply(nil, err)
+			return
+		}
+		task, err := s.run(r.Context(), msg, nil)
+		reply(task, err)
+	case "message/stream":
+		msg, err := decodeMessage(req.Params)
+		if err != nil {
+			reply(nil, err)
+			return
+		}
+		s.stream(w, r, req.ID, msg)
+	case "tasks/get":
+		var params struct {
+			ID            string `json:"id"`
+			HistoryLength int    `json:"historyLength"`
+		}
+		if err := sonic.Unmarshal(req.Params, &params); err != nil {
+			reply(nil, anp_server.InvalidParams("invalid params: %v", err))
+			return
+		}
+		reply(s.getTask(params.ID, params.HistoryLength))
+	case "tasks/cancel":
+		var params struct {
+			ID string `json:"id"`
+		}
+		if err := sonic.Unmarshal(req.Params, &params); err != nil {
+			reply(nil, anp_server.InvalidParams("invalid params: %v", err))
+			return
+		}
+		reply(s.cancelTask(params.ID))
+	default:
+		reply(nil, anp_server.NewError(anp_server.CodeMethodNotFound, "method not found: "+req.Method))
+	}
+}
+
+func decodeMessage(raw json.RawMessage) (*Message, error) {
+	var params struct {
+		Message *Message `json:"message"`
+	}
+	if err := sonic.Unmarshal(raw, &params); err != nil {
+		return nil, anp_server.InvalidParams("invalid params: %v", err)
+	}
+	if params.Message == nil || len(params.Message.Parts) == 0 {
+		return nil, anp_server.InvalidParams("message with at least one part is required")
+	}
+	if params.Message.MessageID == "" {
+		params.Message.MessageID = newID()
+	}
+	params.Message.Kind = "message"
+	params.Message.Role = RoleUser
+	return params.Message, nil
+}
+
+// stream runs the task and writes every event as a JSON-RPC response on an SSE stream.
+func (s *Server) stream(w http.ResponseWriter, r *http.Request, id json.RawMessage, msg *Message) {
+	rc := http.NewResponseController(w)
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+
+	var mu sync.Mutex
+	emit := func(ev Event) {
+		mu.Lock()
+		defer mu.Unlock()
+		data, err := sonic.Marshal(rpcResponse{JSONRPC: "2.0", ID: id, Result: ev})
+		if err != nil {
+			s.logger.Warn("encode a2a event failed", "error", err)
+			return
+		}
+		fmt.Fprintf(w, "data: %s\n\n", data)
+		rc.Flush()
+	}
+
+	if _, err := s.run(r.Context(), msg, emit); err != nil {
+		mu.Lock()
+		defer mu.Unlock()
+		data, _ := sonic.Marshal(rpcResponse{JSONRPC: "2.0", ID: id, Error: toRPCError(err)})
+		fmt.Fprintf(w, "data: %s\n\n", data)
+		rc.Flush()
+	}
+}
+
+// run creates or resumes the task for msg and runs the handler to completion.
+// emit, when set, receives every task event.
+func (s *Server) run(ctx context.Context, msg *Message, emit func(Event)) (*Task, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	entry, err := s.startTask(msg, cancel)
+	if err != nil {
+		return nil, err
+	}
+	tc := &TaskContext{Message: msg, server: s, entry: entry, emit: emit}
+
+	if emit != nil {
+		emit(Event{Task: s.snapshot(entry, 0)})
+	}
+	tc.SetStatus(TaskStateWorking, nil)
+
+	err = s.handler(ctx, tc)
+
+	s.mu.Lock()
+	entry.running = false
+	state := entry.task.Status.State
+	canceled := entry.canceled
+	s.mu.Unlock()
+
+	switch {
+	case canceled:
+		tc.finish(TaskStateCanceled, nil)
+	case err != nil:
+		s.logger.Debug("a2a task failed", "task", entry.task.ID, "error", err)
+		tc.finish(TaskStateFailed, NewAgentMessage(TextPart(err.Error())))
+	case state.Terminal() || state.Interrupted():
+		tc.finish(state, nil)
+	default:
+		tc.finish(TaskStateCompleted, nil)
+	}
+	return s.snapshot(entry, 0), nil
+}
+
+func (s *Server) startTask(msg *Message, cancel context.CancelFunc) (*taskEntry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if msg.TaskID != "" {
+		entry, ok := s.tasks[msg.TaskID]
+		if !ok {
+			return nil, anp_server.NewError(CodeTaskNotFound, "task not found")
+		}
+		if entry.running || !entry.task.Status.State.Interrupted() {
+			return nil, anp_server.InvalidParams("task %s is %s and cannot accept messages", msg.TaskID, entry.task.Status.State)
+		}
+		msg.ContextID = entry.task.ContextID
+		entry.running = true
+		entry.cancel = cancel
+		entry.task.History = append(entry.task.History, *msg)
+		return entry, nil
+	}
+
+	if msg.ContextID == "" {
+		msg.ContextID = newID()
+	}
+	msg.TaskID = newID()
+	entry := &taskEntry{
+		task: Task{
+			Kind:      "task",
+			ID:        msg.TaskID,
+			ContextID: msg.ContextID,
+			Status:    TaskStatus{State: TaskStateSubmitted, Timestamp: now()},
+			History:   []Message{*msg},
+		},
+		running: true,
+		cancel:  cancel,
+	}
+	s.tasks[entry.task.ID] = entry
+	s.order = append(s.order, entry.task.ID)
+	s.evictLocked()
+	return entry, nil
+}
+
+// evictLocked forgets the oldest finished tasks beyond maxTasks.
+func (s *Server) evictLocked() {
+	for i := 0; len(s.tasks) > s.maxTasks && i < len(s.order); {
+		id := s.order[i]
+		if entry, ok := s.tasks[id]; ok && entry.running {
+			i++
+			continue
+		}
+		delete(s.tasks, id)
+		s.order = append(s.order[:i], s.order[i+1:]...)
+	}
+}
+
+func (s *Server) snapshot(entry *taskEntry, historyLength int) *Task {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	task := entry.task
+	task.History = append([]Message(nil), task.History...)
+	task.Artifacts = append([]Artifact(nil), task.Artifacts...)
+	if historyLength > 0 && len(task.History) > historyLength {
+		task.History = task.History[len(task.History)-historyLength:]
+	}
+	return &task
+}
+
+func (s *Server) getTask(id string, historyLength int) (*Task, error) {
+	s.mu.Lock()
+	entry, ok := s.tasks[id]
+	s.mu.Unlock()
+	if !ok {
+		return nil, anp_server.NewError(CodeTaskNotFound, "task not found")
+	}
+	return s.snapshot(entry, historyLength), nil
+}
+
+func (s *Server) cancelTask(id string) (*Task, error) {
+	s.mu.Lock()
+	entry, ok := s.tasks[id]
+	if !ok {
+		s.mu.Unlock()
+		return nil, anp_server.NewError(CodeTaskNotFound, "task not found")
+	}
+	state := entry.task.Status.State
+	switch {
+	case entry.running:
+		entry.canceled = true
+		entry.cancel()
+	case state.Interrupted():
+		entry.task.Status = TaskStatus{State: TaskStateCanceled, Timestamp: now()}
+	default:
+		s.mu.Unlock()
+		return nil, anp_server.NewError(CodeTaskNotCancelable, "task cannot be canceled")
+	}
+	s.mu.Unlock()
+	return s.snapshot(entry, 0), nil
+}
+
+// TaskContext is the handler's view of the task being worked on.
+type TaskContext struct {
+	// Message is the incoming message. Its TaskID and ContextID are set.
+	Message *Message
+
+	server *Server
+	entry  *taskEntry
+	emit   func(Event)
+}
+
+// TaskID returns the task identifier.
+func (t *TaskContext) TaskID() string { return t.Message.TaskID }
+
+// ContextID returns the conversation identifier shared by related tasks.
+func (t *TaskContext) ContextID() string { return t.Message.ContextID }
+
+// Task returns a snapshot of the task, including earlier turns when resumed.
+func (t *TaskContext) Task() *Task { return t.server.snapshot(t.entry, 0) }
+
+// SetStatus records a new task state and streams it to the client. Use
+// TaskStateInputRequired to ask the client for more input; the handler runs
+// again when the client replies with a message carrying this task's ID.
+func (t *TaskContext) SetStatus(state TaskState, msg *Message) {
+	t.update(state, msg, false)
+}
+
+func (t *TaskContext) finish(state TaskState, msg *Message) {
+	t.update(state, msg, true)
+}
+
+func (t *TaskContext) update(state TaskState, msg *Message, final bool) {
+	if msg != nil {
+		msg.TaskID, msg.ContextID = t.TaskID(), t.ContextID()
+	}
+	status := TaskStatus{State: state, Message: msg, Timestamp: now()}
+
+	t.server.mu.Lock()
+	t.entry.task.Status = status
+	if msg != nil {
+		t.entry.task.History = append(t.entry.task.History, *msg)
+	}
+	t.server.mu.Unlock()
+
+	if t.emit != nil {
+		t.emit(Event{StatusUpdate: &TaskStatusUpdateEvent{
+			Kind:      "status-update",
+			TaskID:    t.TaskID(),
+			ContextID: t.ContextID(),
+			Status:    status,
+			Final:     final,
+		}})
+	}
+}
+
+// AddArtifact attaches an output to the task and streams it to the client.
+func (t *TaskContext) AddArtifact(artifact Artifact) {
+	if artifact.ArtifactID == "" {
+		artifact.ArtifactID = newID()
+	}
+	t.server.mu.Lock()
+	t.entry.task.Artifacts = append(t.entry.task.Artifacts, artifact)
+	t.server.mu.Unlock()
+
+	if t.emit != nil {
+		t.emit(Event{ArtifactUpdate: &TaskArtifactUpdateEvent{
+			Kind:      "artifact-update",
+			TaskID:    t.TaskID(),
+			ContextID: t.ContextID(),
+			Artifact:  artifact,
+			LastChunk: true,
+		}})
+	}
+}
+
+func toRPCError(err error) *anp_server.Error {
+	var rpcErr *anp_server.Error
+	if errors.As(err, &rpcErr) {
+		return rpcErr
+	}
+	return anp_server.NewError(anp_server.CodeInternalError, err.Error())
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	body, err := sonic.Marshal(v)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(body)
+}
+
+func requestOrigin(r *http.Request) string {
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	return scheme + "://" + r.Host
+}
+
+func now() string { return time.Now().UTC().Format(time.RFC3339Nano) }
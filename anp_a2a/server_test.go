@@ -0,0 +1,192 @@
+package anp_a2a
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/openanp/anp-go/anp_server"
+)
+
+// echoHandler echoes the message text as an artifact. "ask" pauses for input and
+// "block" waits for cancellation.
+func echoHandler(started chan<- string) Handler {
+	return func(ctx context.Context, t *TaskContext) error {
+		text := t.Message.Text()
+		switch text {
+		case "ask":
+			t.SetStatus(TaskStateInputRequired, NewAgentMessage(TextPart("which city?")))
+			return nil
+		case "block":
+			started <- t.TaskID()
+			<-ctx.Done()
+			return ctx.Err()
+		case "fail":
+			return errors.New("boom")
+		}
+		t.AddArtifact(Artifact{Name: "echo", Parts: []Part{TextPart("echo: " + text)}})
+		return nil
+	}
+}
+
+func newTestServer(t *testing.T, started chan<- string) (*httptest.Server, *Client) {
+	t.Helper()
+	srv := httptest.NewServer(NewServer(AgentCard{Name: "Echo", Description: "Echoes"}, echoHandler(started)))
+	t.Cleanup(srv.Close)
+
+	card, err := ResolveCard(context.Background(), srv.URL)
+	if err != nil {
+		t.Fatalf("ResolveCard() error = %v", err)
+	}
+	if card.Name != "Echo" || !card.Capabilities.Streaming || card.ProtocolVersion != ProtocolVersion {
+		t.Fatalf("ResolveCard() = %+v", card)
+	}
+	if card.URL != srv.URL+"/" {
+		t.Fatalf("card URL = %q, want %q", card.URL, srv.URL+"/")
+	}
+	return srv, NewClient(card.URL)
+}
+
+func TestServer_SendMessage(t *testing.T) {
+	_, client := newTestServer(t, nil)
+	ctx := context.Background()
+
+	tests := []struct {
+		text      string
+		wantState TaskState
+	}{
+		{"hello", TaskStateCompleted},
+		{"fail", TaskStateFailed},
+		{"ask", TaskStateInputRequired},
+	}
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			ev, err := client.SendMessage(ctx, NewUserMessage(TextPart(tt.text)))
+			if err != nil {
+				t.Fatalf("SendMessage() error = %v", err)
+			}
+			if ev.Task == nil || ev.Task.Status.State != tt.wantState {
+				t.Fatalf("SendMessage() = %+v, want state %s", ev.Task, tt.wantState)
+			}
+			got, err := client.GetTask(ctx, ev.Task.ID, 0)
+			if err != nil {
+				t.Fatalf("GetTask() error = %v", err)
+			}
+			if got.Status.State != tt.wantState {
+				t.Errorf("GetTask() state = %s, want %s", got.Status.State, tt.wantState)
+			}
+		})
+	}
+}
+
+func TestServer_ResumeInputRequired(t *testing.T) {
+	_, client := newTestServer(t, nil)
+	ctx := context.Background()
+
+	ev, err := client.SendMessage(ctx, NewUserMessage(TextPart("ask")))
+	if err != nil {
+		t.Fatalf("SendMessage() error = %v", err)
+	}
+	reply := NewUserMessage(TextPart("Hangzhou"))
+	reply.TaskID = ev.Task.ID
+	ev, err = client.SendMessage(ctx, reply)
+	if err != nil {
+		t.Fatalf("SendMessage() error = %v", err)
+	}
+	task := ev.Task
+	if task.Status.State != TaskStateCompleted || len(task.History) != 3 {
+		t.Fatalf("resumed task = %+v", task)
+	}
+	if len(task.Artifacts) != 1 || task.Artifacts[0].Parts[0].Text != "echo: Hangzhou" {
+		t.Errorf("artifacts = %+v", task.Artifacts)
+	}
+
+	// A completed task cannot be resumed.
+	if _, err := client.SendMessage(ctx, reply); err == nil {
+		t.Error("SendMessage() to completed task succeeded")
+	}
+}
+
+func TestServer_StreamMessage(t *testing.T) {
+	_, client := newTestServer(t, nil)
+
+	var kinds []string
+	err := client.StreamMessage(context.Background(), NewUserMessage(TextPart("hi")), func(ev *Event) error {
+		switch {
+		case ev.Task != nil:
+			kinds = append(kinds, "task:"+string(ev.Task.Status.State))
+		case ev.StatusUpdate != nil:
+			kinds = append(kinds, "status:"+string(ev.StatusUpdate.Status.State))
+		case ev.ArtifactUpdate != nil:
+			kinds = append(kinds, "artifact:"+ev.ArtifactUpdate.Artifact.Parts[0].Text)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("StreamMessage() error = %v", err)
+	}
+	want := "task:submitted,status:working,artifact:echo: hi,status:completed"
+	if got := strings.Join(kinds, ","); got != want {
+		t.Errorf("events = %s, want %s", got, want)
+	}
+}
+
+func TestServer_CancelTask(t *testing.T) {
+	started := make(chan string, 1)
+	_, client := newTestServer(t, started)
+	ctx := context.Background()
+
+	done := make(chan *Event, 1)
+	go func() {
+		ev, err := client.SendMessage(ctx, NewUserMessage(TextPart("block")))
+		if err != nil {
+			t.Errorf("SendMessage() error = %v", err)
+		}
+		done <- ev
+	}()
+
+	var id string
+	select {
+	case id = <-started:
+	case <-time.After(5 * time.Second):
+		t.Fatal("handler did not start")
+	}
+	if _, err := client.CancelTask(ctx, id); err != nil {
+		t.Fatalf("CancelTask() error = %v", err)
+	}
+	if ev := <-done; ev == nil || ev.Task.Status.State != TaskStateCanceled {
+		t.Errorf("canceled task = %+v", ev)
+	}
+
+	_, err := client.CancelTask(ctx, id)
+	var rpcErr *anp_server.Error
+	if !errors.As(err, &rpcErr) || rpcErr.Code != CodeTaskNotCancelable {
+		t.Errorf("CancelTask() twice error = %v, want not cancelable", err)
+	}
+	if _, err := client.GetTask(ctx, "missing", 0); !errors.As(err, &rpcErr) || rpcErr.Code != CodeTaskNotFound {
+		t.Errorf("GetTask() error = %v, want task not found", err)
+	}
+}
+
+func TestResolveCard_LegacyPath(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != LegacyCardPath {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(`{"name":"Old","url":"https://old.example.com/a2a","version":"0.1"}`))
+	}))
+	defer srv.Close()
+
+	card, err := ResolveCard(context.Background(), srv.URL+"/")
+	if err != nil {
+		t.Fatalf("ResolveCard() error = %v", err)
+	}
+	if card.Name != "Old" || card.URL != "https://old.example.com/a2a" {
+		t.Errorf("ResolveCard() = %+v", card)
+	}
+}
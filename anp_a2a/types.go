@@ -0,0 +1,296 @@
+// Package anp_a2a lets ANP agents interoperate with the Agent2Agent (A2A)
+// protocol.
+//
+// Client resolves A2A agent cards and drives tasks over A2A's JSON-RPC binding,
+// including streamed status updates. Server publishes an agent card and runs a
+// Handler for incoming A2A messages, so an agent built on this SDK can serve both
+// ecosystems from one process. CardFromAgentDescription and
+// AgentDescriptionFromCard translate between ANP agent descriptions and A2A cards.
+//
+//	card, _ := anp_a2a.ResolveCard(ctx, "https://travel.example.com")
+//	client := anp_a2a.NewClient(card.URL)
+//	err := client.StreamMessage(ctx, anp_a2a.NewUserMessage(anp_a2a.TextPart("book a room")), func(ev *anp_a2a.Event) error {
+//		if ev.StatusUpdate != nil {
+//			fmt.Println(ev.StatusUpdate.Status.State)
+//		}
+//		return nil
+//	})
+package anp_a2a
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+
+	"github.com/bytedance/sonic"
+)
+
+const (
+	// ProtocolVersion is the A2A specification version implemented here.
+	ProtocolVersion = "0.3.0"
+	// WellKnownCardPath is where agents publish their card.
+	WellKnownCardPath = "/.well-known/agent-card.json"
+	// LegacyCardPath is the card location used before A2A 0.3.
+	LegacyCardPath = "/.well-known/agent.json"
+	// TransportJSONRPC is the only transport implemented by this package.
+	TransportJSONRPC = "JSONRPC"
+)
+
+// A2A-specific JSON-RPC error codes.
+const (
+	CodeTaskNotFound      = -32001
+	CodeTaskNotCancelable = -32002
+)
+
+// AgentCard describes an A2A agent.
+type AgentCard struct {
+	ProtocolVersion    string            `json:"protocolVersion"`
+	Name               string            `json:"name"`
+	Description        string            `json:"description"`
+	URL                string            `json:"url"`
+	PreferredTransport string            `json:"preferredTransport,omitempty"`
+	Version            string            `json:"version"`
+	Provider           *AgentProvider    `json:"provider,omitempty"`
+	DocumentationURL   string            `json:"documentationUrl,omitempty"`
+	Capabilities       AgentCapabilities `json:"capabilities"`
+	DefaultInputModes  []string          `json:"defaultInputModes"`
+	DefaultOutputModes []string          `json:"defaultOutputModes"`
+	Skills             []AgentSkill      `json:"skills"`
+}
+
+// AgentProvider is the organization operating an agent.
+type AgentProvider struct {
+	Organization string `json:"organization"`
+	URL          string `json:"url"`
+}
+
+// AgentCapabilities lists optional protocol features an agent supports.
+type AgentCapabilities struct {
+	Streaming         bool `json:"streaming,omitempty"`
+	PushNotifications bool `json:"pushNotifications,omitempty"`
+}
+
+// AgentSkill is a capability advertised in an agent card.
+type AgentSkill struct {
+	ID          string   `json:"id"`
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Tags        []string `json:"tags"`
+	Examples    []string `json:"examples,omitempty"`
+	InputModes  []string `json:"inputModes,omitempty"`
+	OutputModes []string `json:"outputModes,omitempty"`
+}
+
+// Part kinds.
+const (
+	PartKindText = "text"
+	PartKindData = "data"
+	PartKindFile = "file"
+)
+
+// Part is one piece of message or artifact content. Kind selects which of Text,
+// Data or File is set.
+type Part struct {
+	Kind     string         `json:"kind"`
+	Text     string         `json:"text,omitempty"`
+	Data     map[string]any `json:"data,omitempty"`
+	File     *File          `json:"file,omitempty"`
+	Metadata map[string]any `json:"metadata,omitempty"`
+}
+
+// File is the payload of a file part, inline (Bytes, base64) or by URI.
+type File struct {
+	Name     string `json:"name,omitempty"`
+	MimeType string `json:"mimeType,omitempty"`
+	Bytes    string `json:"bytes,omitempty"`
+	URI      string `json:"uri,omitempty"`
+}
+
+// TextPart returns a text part.
+func TextPart(text string) Part { return Part{Kind: PartKindText, Text: text} }
+
+// DataPart returns a structured data part.
+func DataPart(data map[string]any) Part { return Part{Kind: PartKindData, Data: data} }
+
+// Message roles.
+const (
+	RoleUser  = "user"
+	RoleAgent = "agent"
+)
+
+// Message is a single turn between a client and an agent.
+type Message struct {
+	Kind      string         `json:"kind"`
+	Role      string         `json:"role"`
+	Parts     []Part         `json:"parts"`
+	MessageID string         `json:"messageId"`
+	TaskID    string         `json:"taskId,omitempty"`
+	ContextID string         `json:"contextId,omitempty"`
+	Metadata  map[string]any `json:"metadata,omitempty"`
+}
+
+// NewUserMessage returns a user message with a fresh ID.
+func NewUserMessage(parts ...Part) *Message {
+	return &Message{Kind: "message", Role: RoleUser, Parts: parts, MessageID: newID()}
+}
+
+// NewAgentMessage returns an agent message with a fresh ID.
+func NewAgentMessage(parts ...Part) *Message {
+	return &Message{Kind: "message", Role: RoleAgent, Parts: parts, MessageID: newID()}
+}
+
+// Text concatenates the message's text parts.
+func (m *Message) Text() string {
+	var out string
+	for _, p := range m.Parts {
+		if p.Kind == PartKindText {
+			out += p.Text
+		}
+	}
+	return out
+}
+
+// TaskState is the lifecycle state of a task.
+type TaskState string
+
+const (
+	TaskStateSubmitted     TaskState = "submitted"
+	TaskStateWorking       TaskState = "working"
+	TaskStateInputRequired TaskState = "input-required"
+	TaskStateAuthRequired  TaskState = "auth-required"
+	TaskStateCompleted     TaskState = "completed"
+	TaskStateCanceled      TaskState = "canceled"
+	TaskStateFailed        TaskState = "failed"
+	TaskStateRejected      TaskState = "rejected"
+	TaskStateUnknown       TaskState = "unknown"
+)
+
+// Terminal reports whether no further updates follow this state.
+func (s TaskState) Terminal() bool {
+	switch s {
+	case TaskStateCompleted, TaskStateCanceled, TaskStateFailed, TaskStateRejected:
+		return true
+	}
+	return false
+}
+
+// Interrupted reports whether the task is paused waiting on the client.
+func (s TaskState) Interrupted() bool {
+	return s == TaskStateInputRequired || s == TaskStateAuthRequired
+}
+
+// TaskStatus is a task's state with an optional explanatory message.
+type TaskStatus struct {
+	State     TaskState `json:"state"`
+	Message   *Message  `json:"message,omitempty"`
+	Timestamp string    `json:"timestamp,omitempty"`
+}
+
+// Artifact is an output produced by a task.
+type Artifact struct {
+	ArtifactID  string         `json:"artifactId"`
+	Name        string         `json:"name,omitempty"`
+	Description string         `json:"description,omitempty"`
+	Parts       []Part         `json:"parts"`
+	Metadata    map[string]any `json:"metadata,omitempty"`
+}
+
+// Task is a unit of work tracked by an agent.
+type Task struct {
+	Kind      string         `json:"kind"`
+	ID        string         `json:"id"`
+	ContextID string         `json:"contextId"`
+	Status    TaskStatus     `json:"status"`
+	History   []Message      `json:"history,omitempty"`
+	Artifacts []Artifact     `json:"artifacts,omitempty"`
+	Metadata  map[string]any `json:"metadata,omitempty"`
+}
+
+// TaskStatusUpdateEvent reports a task state change during streaming.
+type TaskStatusUpdateEvent struct {
+	Kind      string     `json:"kind"`
+	TaskID    string     `json:"taskId"`
+	ContextID string     `json:"contextId"`
+	Status    TaskStatus `json:"status"`
+	Final     bool       `json:"final"`
+}
+
+// TaskArtifactUpdateEvent delivers an artifact, or a chunk of one, during streaming.
+type TaskArtifactUpdateEvent struct {
+	Kind      string   `json:"kind"`
+	TaskID    string   `json:"taskId"`
+	ContextID string   `json:"contextId"`
+	Artifact  Artifact `json:"artifact"`
+	Append    bool     `json:"append,omitempty"`
+	LastChunk bool     `json:"lastChunk,omitempty"`
+}
+
+// Event is a message/send result or one message/stream item. Exactly one field
+// is set, selected by the payload's "kind".
+type Event struct {
+	Task           *Task
+	Message        *Message
+	StatusUpdate   *TaskStatusUpdateEvent
+	ArtifactUpdate *TaskArtifactUpdateEvent
+}
+
+// Final reports whether the event ends a stream.
+func (e *Event) Final() bool {
+	switch {
+	case e.Message != nil:
+		return true
+	case e.StatusUpdate != nil:
+		return e.StatusUpdate.Final
+	case e.Task != nil:
+		return e.Task.Status.State.Terminal() || e.Task.Status.State.Interrupted()
+	}
+	return false
+}
+
+// MarshalJSON encodes the populated field.
+func (e Event) MarshalJSON() ([]byte, error) {
+	switch {
+	case e.Task != nil:
+		return sonic.Marshal(e.Task)
+	case e.Message != nil:
+		return sonic.Marshal(e.Message)
+	case e.StatusUpdate != nil:
+		return sonic.Marshal(e.StatusUpdate)
+	case e.ArtifactUpdate != nil:
+		return sonic.Marshal(e.ArtifactUpdate)
+	}
+	return nil, errors.New("anp_a2a: empty event")
+}
+
+// UnmarshalJSON decodes a payload by its "kind".
+func (e *Event) UnmarshalJSON(data []byte) error {
+	var probe struct {
+		Kind string `json:"kind"`
+	}
+	if err := sonic.Unmarshal(data, &probe); err != nil {
+		return err
+	}
+	*e = Event{}
+	switch probe.Kind {
+	case "task":
+		e.Task = &Task{}
+		return sonic.Unmarshal(data, e.Task)
+	case "message":
+		e.Message = &Message{}
+		return sonic.Unmarshal(data, e.Message)
+	case "status-update":
+		e.StatusUpdate = &TaskStatusUpdateEvent{}
+		return sonic.Unmarshal(data, e.StatusUpdate)
+	case "artifact-update":
+		e.ArtifactUpdate = &TaskArtifactUpdateEvent{}
+		return sonic.Unmarshal(data, e.ArtifactUpdate)
+	}
+	return fmt.Errorf("anp_a2a: unknown event kind %q", probe.Kind)
+}
+
+func newID() string {
+	var b [16]byte
+	rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
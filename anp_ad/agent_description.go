@@ -0,0 +1,131 @@
+// Package anp_ad builds, serialises, signs, and verifies ANP Agent Description documents.
+package anp_ad
+
+import (
+	"fmt"
+
+	"github.com/bytedance/sonic"
+)
+
+// Agent Description constants.
+const (
+	// ProtocolType is the protocol identifier carried by every Agent Description.
+	ProtocolType = "ANP"
+
+	// DefaultProtocolVersion is the protocol version written when none is configured.
+	DefaultProtocolVersion = "1.0.0"
+
+	// DocumentType is the JSON-LD type of an Agent Description.
+	DocumentType = "AgentDescription"
+
+	// SecuritySchemeDIDWba is the default security definition name for DID-WBA.
+	SecuritySchemeDIDWba = "didwba_sc"
+)
+
+// Interface types and protocols commonly used in Agent Descriptions.
+const (
+	InterfaceTypeStructured      = "StructuredInterface"
+	InterfaceTypeNaturalLanguage = "NaturalLanguageInterface"
+
+	ProtocolOpenRPC = "openrpc"
+	ProtocolYAML    = "YAML"
+)
+
+// DefaultContext returns the JSON-LD context used by ANP Agent Descriptions.
+func DefaultContext() map[string]any {
+	return map[string]any{
+		"@vocab": "https://schema.org/",
+		"did":    "https://w3id.org/did#",
+		"ad":     "https://agent-network-protocol.com/ad#",
+	}
+}
+
+// AgentDescription is the top-level ANP Agent Description document (ad.json).
+type AgentDescription struct {
+	Context             map[string]any                `json:"@context,omitempty"`
+	ProtocolType        string                        `json:"protocolType"`
+	ProtocolVersion     string                        `json:"protocolVersion"`
+	Type                string                        `json:"type"`
+	URL                 string                        `json:"url,omitempty"`
+	Name                string                        `json:"name"`
+	DID                 string                        `json:"did,omitempty"`
+	Owner               *Owner                        `json:"owner,omitempty"`
+	Description         string                        `json:"description,omitempty"`
+	Created             string                        `json:"created,omitempty"`
+	SecurityDefinitions map[string]SecurityDefinition `json:"securityDefinitions,omitempty"`
+	Security            string                        `json:"security,omitempty"`
+	Informations        []Information                 `json:"informations,omitempty"`
+	Interfaces          []Interface                   `json:"interfaces"`
+	Proof               *Proof                        `json:"proof,omitempty"`
+}
+
+// Owner identifies the organisation or person operating the agent.
+type Owner struct {
+	Type string `json:"type"`
+	Name string `json:"name"`
+	URL  string `json:"url,omitempty"`
+}
+
+// SecurityDefinition describes how clients authenticate against the agent.
+type SecurityDefinition struct {
+	Scheme string `json:"scheme"`
+	In     string `json:"in"`
+	Name   string `json:"name"`
+}
+
+// Information links supplementary resources (products, services, media) about the agent.
+type Information struct {
+	Type        string `json:"type"`
+	Description string `json:"description,omitempty"`
+	URL         string `json:"url"`
+}
+
+// Interface declares an interface exposed by the agent, either by URL or inline content.
+type Interface struct {
+	Type        string `json:"type"`
+	Protocol    string `json:"protocol"`
+	URL         string `json:"url,omitempty"`
+	Description string `json:"description,omitempty"`
+	Content     any    `json:"content,omitempty"`
+}
+
+// Proof is the integrity proof signed with the agent's DID key.
+type Proof struct {
+	Type               string `json:"type"`
+	Created            string `json:"created"`
+	ProofPurpose       string `json:"proofPurpose"`
+	VerificationMethod string `json:"verificationMethod"`
+	Challenge          string `json:"challenge,omitempty"`
+	ProofValue         string `json:"proofValue,omitempty"`
+}
+
+// JSON serialises the document as plain JSON without the JSON-LD context.
+func (ad *AgentDescription) JSON() ([]byte, error) {
+	if ad == nil {
+		return nil, fmt.Errorf("agent description is nil")
+	}
+	plain := *ad
+	plain.Context = nil
+	return sonic.Marshal(&plain)
+}
+
+// JSONLD serialises the document with its JSON-LD context, using DefaultContext if none is set.
+func (ad *AgentDescription) JSONLD() ([]byte, error) {
+	if ad == nil {
+		return nil, fmt.Errorf("agent description is nil")
+	}
+	withContext := *ad
+	if withContext.Context == nil {
+		withContext.Context = DefaultContext()
+	}
+	return sonic.Marshal(&withContext)
+}
+
+// Parse decodes an Agent Description from JSON or JSON-LD bytes.
+func Parse(data []byte) (*AgentDescription, error) {
+	var ad AgentDescription
+	if err := sonic.Unmarshal(data, &ad); err != nil {
+		return nil, fmt.Errorf("decode agent description: %w", err)
+	}
+	return &ad, nil
+}
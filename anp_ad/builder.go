@@ -0,0 +1,172 @@
+package anp_ad
+
+import (
+	"crypto/ecdsa"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/openanp/anp-go/anp_auth"
+)
+
+// Builder assembles an AgentDescription through chained calls.
+// Validation errors are collected and reported by Build.
+//
+// Example usage:
+//
+//	ad, err := anp_ad.NewBuilder("Hotel Assistant").
+//	    DID("did:wba:example.com:hotel").
+//	    URL("https://example.com/hotel/ad.json").
+//	    Owner("Organization", "Example Inc.", "https://example.com").
+//	    AddInterface(anp_ad.InterfaceTypeStructured, anp_ad.ProtocolOpenRPC, "https://example.com/hotel/api.json", "Booking API").
+//	    SignWith(privateKey, "did:wba:example.com:hotel#key-1").
+//	    Build()
+type Builder struct {
+	ad   AgentDescription
+	errs []error
+
+	signingKey         *ecdsa.PrivateKey
+	verificationMethod string
+	now                func() time.Time
+}
+
+// NewBuilder starts a new Agent Description with the given agent name.
+func NewBuilder(name string) *Builder {
+	return &Builder{
+		ad: AgentDescription{
+			ProtocolType:    ProtocolType,
+			ProtocolVersion: DefaultProtocolVersion,
+			Type:            DocumentType,
+			Name:            name,
+		},
+		now: time.Now,
+	}
+}
+
+// ProtocolVersion overrides the ANP protocol version.
+func (b *Builder) ProtocolVersion(version string) *Builder {
+	b.ad.ProtocolVersion = version
+	return b
+}
+
+// DID sets the agent's DID and enables the DID-WBA security definition.
+func (b *Builder) DID(did string) *Builder {
+	if !strings.HasPrefix(did, anp_auth.DIDPrefix) {
+		b.errs = append(b.errs, fmt.Errorf("%w: %s", anp_auth.ErrInvalidDIDFormat, did))
+	}
+	b.ad.DID = did
+	b.ad.SecurityDefinitions = map[string]SecurityDefinition{
+		SecuritySchemeDIDWba: {Scheme: "didwba", In: "header", Name: anp_auth.AuthorizationHeader},
+	}
+	b.ad.Security = SecuritySchemeDIDWba
+	return b
+}
+
+// URL sets the canonical location of the published ad.json.
+func (b *Builder) URL(url string) *Builder {
+	b.ad.URL = url
+	return b
+}
+
+// Description sets the human-readable description of the agent.
+func (b *Builder) Description(description string) *Builder {
+	b.ad.Description = description
+	return b
+}
+
+// Owner sets the agent owner.
+func (b *Builder) Owner(ownerType, name, url string) *Builder {
+	if name == "" {
+		b.errs = append(b.errs, errors.New("owner name cannot be empty"))
+	}
+	b.ad.Owner = &Owner{Type: ownerType, Name: name, URL: url}
+	return b
+}
+
+// Created sets the creation timestamp. Build uses the current time if unset.
+func (b *Builder) Created(t time.Time) *Builder {
+	b.ad.Created = t.UTC().Format(time.RFC3339)
+	return b
+}
+
+// Context overrides the JSON-LD context written by AgentDescription.JSONLD.
+func (b *Builder) Context(ctx map[string]any) *Builder {
+	b.ad.Context = ctx
+	return b
+}
+
+// AddInformation links a supplementary resource.
+func (b *Builder) AddInformation(infoType, description, url string) *Builder {
+	if url == "" {
+		b.errs = append(b.errs, fmt.Errorf("information %q requires a url", infoType))
+	}
+	b.ad.Informations = append(b.ad.Informations, Information{Type: infoType, Description: description, URL: url})
+	return b
+}
+
+// AddInterface declares an interface published at url.
+func (b *Builder) AddInterface(ifaceType, protocol, url, description string) *Builder {
+	return b.addInterface(Interface{Type: ifaceType, Protocol: protocol, URL: url, Description: description})
+}
+
+// AddInlineInterface declares an interface whose definition (e.g. an OpenRPC document) is embedded.
+func (b *Builder) AddInlineInterface(ifaceType, protocol, description string, content any) *Builder {
+	if content == nil {
+		b.errs = append(b.errs, fmt.Errorf("inline %s interface requires content", protocol))
+	}
+	return b.addInterface(Interface{Type: ifaceType, Protocol: protocol, Description: description, Content: content})
+}
+
+func (b *Builder) addInterface(iface Interface) *Builder {
+	if iface.Type == "" || iface.Protocol == "" {
+		b.errs = append(b.errs, errors.New("interface type and protocol are required"))
+	}
+	if iface.URL == "" && iface.Content == nil {
+		b.errs = append(b.errs, fmt.Errorf("%s interface requires a url or inline content", iface.Protocol))
+	}
+	b.ad.Interfaces = append(b.ad.Interfaces, iface)
+	return b
+}
+
+// SignWith attaches a proof signed by the DID private key when Build is called.
+// verificationMethod is the full DID URL of the key, e.g. "did:wba:example.com#key-1".
+func (b *Builder) SignWith(privateKey *ecdsa.PrivateKey, verificationMethod string) *Builder {
+	b.signingKey = privateKey
+	b.verificationMethod = verificationMethod
+	return b
+}
+
+// Build validates the document and, if a signing key was configured, signs it.
+func (b *Builder) Build() (*AgentDescription, error) {
+	errs := append([]error(nil), b.errs...)
+	if strings.TrimSpace(b.ad.Name) == "" {
+		errs = append(errs, errors.New("agent name cannot be empty"))
+	}
+	if b.signingKey != nil && b.ad.DID == "" {
+		errs = append(errs, errors.New("DID is required to sign the agent description"))
+	}
+	if b.signingKey != nil && b.ad.DID != "" && !strings.HasPrefix(b.verificationMethod, b.ad.DID+"#") {
+		errs = append(errs, fmt.Errorf("verification method %q does not belong to %s", b.verificationMethod, b.ad.DID))
+	}
+	if err := errors.Join(errs...); err != nil {
+		return nil, fmt.Errorf("invalid agent description: %w", err)
+	}
+
+	ad := b.ad
+	ad.Informations = append([]Information(nil), b.ad.Informations...)
+	ad.Interfaces = append([]Interface{}, b.ad.Interfaces...)
+
+	now := b.now()
+	if ad.Created == "" {
+		ad.Created = now.UTC().Format(time.RFC3339)
+	}
+
+	if b.signingKey != nil {
+		if err := Sign(&ad, b.signingKey, b.verificationMethod, now); err != nil {
+			return nil, err
+		}
+	}
+
+	return &ad, nil
+}
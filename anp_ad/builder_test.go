@@ -0,0 +1,120 @@
+package anp_ad
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/bytedance/sonic"
+	"github.com/openanp/anp-go/anp_auth"
+)
+
+func TestBuilder_BuildAndVerify(t *testing.T) {
+	doc, privateKey, err := anp_auth.CreateDIDWBADocument("example.com", nil, []string{"hotel"}, nil)
+	if err != nil {
+		t.Fatalf("CreateDIDWBADocument() error = %v", err)
+	}
+
+	ad, err := NewBuilder("Hotel Assistant").
+		DID(doc.ID).
+		URL("https://example.com/hotel/ad.json").
+		Description("Books hotels").
+		Owner("Organization", "Example Inc.", "https://example.com").
+		AddInformation("Product", "Room catalogue", "https://example.com/hotel/rooms.json").
+		AddInterface(InterfaceTypeStructured, ProtocolOpenRPC, "https://example.com/hotel/api.json", "Booking API").
+		SignWith(privateKey, doc.ID+"#key-1").
+		Build()
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+
+	if ad.Proof == nil || ad.Proof.ProofValue == "" {
+		t.Fatal("Expected proof to be attached")
+	}
+	if ad.Security != SecuritySchemeDIDWba {
+		t.Errorf("Expected security %q, got %q", SecuritySchemeDIDWba, ad.Security)
+	}
+
+	if err := VerifyProof(ad, doc); err != nil {
+		t.Fatalf("VerifyProof() error = %v", err)
+	}
+
+	// The proof must survive a JSON-LD round trip.
+	data, err := ad.JSONLD()
+	if err != nil {
+		t.Fatalf("JSONLD() error = %v", err)
+	}
+	parsed, err := Parse(data)
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if parsed.Context == nil {
+		t.Error("Expected @context in JSON-LD output")
+	}
+	if err := VerifyProof(parsed, doc); err != nil {
+		t.Fatalf("VerifyProof() after round trip error = %v", err)
+	}
+
+	parsed.Description = "tampered"
+	if err := VerifyProof(parsed, doc); !errors.Is(err, ErrProofInvalid) {
+		t.Errorf("Expected ErrProofInvalid for tampered document, got %v", err)
+	}
+}
+
+func TestBuilder_JSONOmitsContext(t *testing.T) {
+	ad, err := NewBuilder("Plain").
+		AddInterface(InterfaceTypeNaturalLanguage, ProtocolYAML, "https://example.com/nl.yaml", "").
+		Build()
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+
+	data, err := ad.JSON()
+	if err != nil {
+		t.Fatalf("JSON() error = %v", err)
+	}
+
+	var raw map[string]any
+	if err := sonic.Unmarshal(data, &raw); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	if _, ok := raw["@context"]; ok {
+		t.Error("Expected plain JSON to omit @context")
+	}
+	if raw["protocolType"] != ProtocolType || raw["type"] != DocumentType {
+		t.Errorf("Unexpected header fields: %v", raw)
+	}
+}
+
+func TestBuilder_ValidationErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		builder *Builder
+		want    string
+	}{
+		{
+			name:    "empty name",
+			builder: NewBuilder(" "),
+			want:    "agent name cannot be empty",
+		},
+		{
+			name:    "invalid DID",
+			builder: NewBuilder("agent").DID("did:web:example.com"),
+			want:    "invalid DID format",
+		},
+		{
+			name:    "interface without url or content",
+			builder: NewBuilder("agent").AddInterface(InterfaceTypeStructured, ProtocolOpenRPC, "", ""),
+			want:    "requires a url or inline content",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tt.builder.Build()
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Build() error = %v, want containing %q", err, tt.want)
+			}
+		})
+	}
+}
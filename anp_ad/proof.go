@@ -0,0 +1,113 @@
+package anp_ad
+
+import (
+	"crypto/ecdsa"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/bytedance/sonic"
+	"github.com/cyberphone/json-canonicalization/go/src/webpki.org/jsoncanonicalizer"
+	"github.com/openanp/anp-go/anp_auth"
+)
+
+const (
+	// ProofTypeSecp256k1 is the proof type produced for secp256k1 DID keys.
+	ProofTypeSecp256k1 = "EcdsaSecp256k1Signature2019"
+
+	// ProofPurposeAssertion is the proof purpose used for Agent Descriptions.
+	ProofPurposeAssertion = "assertionMethod"
+)
+
+var (
+	// ErrProofMissing is returned when verifying a document without a proof.
+	ErrProofMissing = errors.New("agent description has no proof")
+
+	// ErrProofInvalid is returned when the proof signature does not verify.
+	ErrProofInvalid = errors.New("agent description proof verification failed")
+)
+
+// Sign attaches a proof to ad, signed with privateKey and referencing verificationMethod
+// (a full DID URL such as "did:wba:example.com#key-1").
+//
+// The signature covers the JCS-canonicalised document without its "@context" and
+// without proof.proofValue, so JSON and JSON-LD serialisations verify identically.
+func Sign(ad *AgentDescription, privateKey *ecdsa.PrivateKey, verificationMethod string, created time.Time) error {
+	if ad == nil {
+		return errors.New("agent description is nil")
+	}
+	if privateKey == nil {
+		return errors.New("private key is required")
+	}
+	if verificationMethod == "" {
+		return errors.New("verification method is required")
+	}
+
+	ad.Proof = &Proof{
+		Type:               ProofTypeSecp256k1,
+		Created:            created.UTC().Format(time.RFC3339),
+		ProofPurpose:       ProofPurposeAssertion,
+		VerificationMethod: verificationMethod,
+	}
+
+	content, err := signingInput(ad)
+	if err != nil {
+		return err
+	}
+
+	signature, err := anp_auth.SignContent(privateKey, content)
+	if err != nil {
+		return fmt.Errorf("sign agent description: %w", err)
+	}
+
+	ad.Proof.ProofValue = signature
+	return nil
+}
+
+// VerifyProof checks ad's proof against the verification method published in didDoc.
+func VerifyProof(ad *AgentDescription, didDoc *anp_auth.DIDWBADocument) error {
+	if ad == nil {
+		return errors.New("agent description is nil")
+	}
+	if ad.Proof == nil || ad.Proof.ProofValue == "" {
+		return ErrProofMissing
+	}
+	if didDoc == nil {
+		return errors.New("DID document is required")
+	}
+	if ad.DID != "" && ad.DID != didDoc.ID {
+		return fmt.Errorf("%w: agent DID %s does not match DID document %s", ErrProofInvalid, ad.DID, didDoc.ID)
+	}
+
+	content, err := signingInput(ad)
+	if err != nil {
+		return err
+	}
+
+	err = anp_auth.VerifyContent(didDoc, ad.Proof.VerificationMethod, content, ad.Proof.ProofValue)
+	if errors.Is(err, anp_auth.ErrInvalidSignature) {
+		return ErrProofInvalid
+	}
+	return err
+}
+
+// signingInput returns the canonical bytes covered by the proof signature.
+func signingInput(ad *AgentDescription) ([]byte, error) {
+	unsigned := *ad
+	unsigned.Context = nil
+	if ad.Proof != nil {
+		proof := *ad.Proof
+		proof.ProofValue = ""
+		unsigned.Proof = &proof
+	}
+
+	raw, err := sonic.Marshal(&unsigned)
+	if err != nil {
+		return nil, fmt.Errorf("marshal agent description: %w", err)
+	}
+	canonical, err := jsoncanonicalizer.Transform(raw)
+	if err != nil {
+		return nil, fmt.Errorf("canonicalize agent description: %w", err)
+	}
+	return canonical, nil
+}
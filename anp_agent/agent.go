@@ -0,0 +1,271 @@
+// Package anp_agent runs a minimal LLM tool-use loop over ANP interfaces.
+//
+// An Agent renders the tools of one or more session documents for a language
+// model, executes the tool calls the model selects with session.ExecuteTool, feeds
+// the results back and repeats until the model answers or a step or token budget
+// is exhausted.
+//
+//	llm := anp_agent.NewOpenAIClient(anp_agent.OpenAIConfig{APIKey: key, Model: "gpt-4o-mini"})
+//	agent, _ := anp_agent.New(anp_agent.Config{Session: sess, LLM: llm, URLs: []string{adURL}})
+//	result, err := agent.Run(ctx, "Find a hotel in Hangzhou for tomorrow night")
+package anp_agent
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/bytedance/sonic"
+	"github.com/openanp/anp-go/anp_crawler"
+	"github.com/openanp/anp-go/session"
+)
+
+const defaultMaxSteps = 10
+
+var (
+	// ErrMaxSteps is returned when the model has not answered within Config.MaxSteps.
+	ErrMaxSteps = errors.New("anp_agent: step limit reached")
+	// ErrBudgetExceeded is returned when token usage passes Config.MaxTokens.
+	ErrBudgetExceeded = errors.New("anp_agent: token budget exceeded")
+)
+
+// Role is the author of a Message.
+type Role string
+
+const (
+	RoleSystem    Role = "system"
+	RoleUser      Role = "user"
+	RoleAssistant Role = "assistant"
+	RoleTool      Role = "tool"
+)
+
+// Message is one turn of the conversation with the model.
+type Message struct {
+	Role    Role
+	Content string
+	// ToolCalls are the calls requested by an assistant message.
+	ToolCalls []ToolCall
+	// ToolCallID links a tool message to the call it answers.
+	ToolCallID string
+}
+
+// ToolCall is a tool invocation requested by the model.
+type ToolCall struct {
+	ID        string
+	Name      string
+	Arguments json.RawMessage
+}
+
+// Usage counts tokens consumed by model calls.
+type Usage struct {
+	PromptTokens     int
+	CompletionTokens int
+}
+
+// Total returns the sum of prompt and completion tokens.
+func (u Usage) Total() int { return u.PromptTokens + u.CompletionTokens }
+
+// Request is sent to the model on every step.
+type Request struct {
+	Messages []Message
+	Tools    []*anp_crawler.ANPTool
+}
+
+// Response is the model's reply.
+type Response struct {
+	Message Message
+	Usage   Usage
+}
+
+// LLM is a chat model that supports tool calling.
+type LLM interface {
+	Complete(ctx context.Context, req *Request) (*Response, error)
+}
+
+// Config describes an Agent.
+type Config struct {
+	// Session fetches URLs. Required when URLs is set.
+	Session *session.Session
+	// URLs are ANP documents (agent descriptions, OpenRPC documents) whose tools
+	// are offered to the model. They are fetched on the first Run.
+	URLs []string
+	// Documents are already-fetched tool sources, e.g. from anp_mcp.
+	Documents []*session.Document
+	LLM       LLM
+
+	SystemPrompt string
+	// MaxSteps bounds the number of model calls per Run (default 10).
+	MaxSteps int
+	// MaxTokens bounds total token usage per Run. Zero means unlimited.
+	MaxTokens int
+	Logger    *slog.Logger
+}
+
+// Result is the outcome of Run.
+type Result struct {
+	// Answer is the model's final message content.
+	Answer string
+	// Messages is the full transcript, including tool calls and results.
+	Messages []Message
+	Steps    int
+	Usage    Usage
+}
+
+// Agent runs goals against a fixed set of tools.
+type Agent struct {
+	cfg    Config
+	logger *slog.Logger
+
+	documents []*session.Document
+	loaded    bool
+}
+
+type boundTool struct {
+	doc    *session.Document
+	method string
+}
+
+// New creates an Agent.
+func New(cfg Config) (*Agent, error) {
+	if cfg.LLM == nil {
+		return nil, errors.New("anp_agent: LLM is required")
+	}
+	if len(cfg.URLs) > 0 && cfg.Session == nil {
+		return nil, errors.New("anp_agent: session is required to fetch URLs")
+	}
+	if cfg.MaxSteps <= 0 {
+		cfg.MaxSteps = defaultMaxSteps
+	}
+	logger := cfg.Logger
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return &Agent{
+		cfg:       cfg,
+		logger:    logger,
+		documents: append([]*session.Document(nil), cfg.Documents...),
+	}, nil
+}
+
+// Run pursues goal until the model answers without calling tools. On ErrMaxSteps
+// or ErrBudgetExceeded the partial Result is returned alongside the error.
+func (a *Agent) Run(ctx context.Context, goal string) (*Result, error) {
+	if err := a.load(ctx); err != nil {
+		return nil, err
+	}
+	tools, bound := a.tools()
+
+	result := &Result{}
+	if a.cfg.SystemPrompt != "" {
+		result.Messages = append(result.Messages, Message{Role: RoleSystem, Content: a.cfg.SystemPrompt})
+	}
+	result.Messages = append(result.Messages, Message{Role: RoleUser, Content: goal})
+
+	for result.Steps < a.cfg.MaxSteps {
+		result.Steps++
+		resp, err := a.cfg.LLM.Complete(ctx, &Request{Messages: result.Messages, Tools: tools})
+		if err != nil {
+			return result, fmt.Errorf("step %d: %w", result.Steps, err)
+		}
+		result.Usage.PromptTokens += resp.Usage.PromptTokens
+		result.Usage.CompletionTokens += resp.Usage.CompletionTokens
+
+		reply := resp.Message
+		reply.Role = RoleAssistant
+		result.Messages = append(result.Messages, reply)
+
+		if len(reply.ToolCalls) == 0 {
+			result.Answer = reply.Content
+			return result, nil
+		}
+		if a.cfg.MaxTokens > 0 && result.Usage.Total() > a.cfg.MaxTokens {
+			return result, ErrBudgetExceeded
+		}
+
+		for _, call := range reply.ToolCalls {
+			result.Messages = append(result.Messages, Message{
+				Role:       RoleTool,
+				ToolCallID: call.ID,
+				Content:    a.execute(ctx, bound, call),
+			})
+		}
+	}
+	return result, ErrMaxSteps
+}
+
+func (a *Agent) load(ctx context.Context) error {
+	if a.loaded || len(a.cfg.URLs) == 0 {
+		return nil
+	}
+	docs, err := a.cfg.Session.FetchBatch(ctx, a.cfg.URLs)
+	if err != nil {
+		return fmt.Errorf("anp_agent: fetch tools: %w", err)
+	}
+	a.documents = append(a.documents, docs...)
+	a.loaded = true
+	return nil
+}
+
+// tools collects the tool definitions and maps each tool name to the document
+// and method that implement it. The first document wins on name clashes.
+func (a *Agent) tools() ([]*anp_crawler.ANPTool, map[string]boundTool) {
+	var tools []*anp_crawler.ANPTool
+	bound := make(map[string]boundTool)
+	for _, doc := range a.documents {
+		defs := make(map[string]*anp_crawler.ANPTool, len(doc.Tools))
+		for _, tool := range doc.Tools {
+			defs[tool.Function.Name] = tool
+		}
+		for _, iface := range doc.Interfaces {
+			def, ok := defs[iface.ToolName]
+			if !ok {
+				continue
+			}
+			if _, dup := bound[iface.ToolName]; dup {
+				a.logger.Debug("skipping duplicate tool", "tool", iface.ToolName, "url", doc.URL)
+				continue
+			}
+			bound[iface.ToolName] = boundTool{doc: doc, method: iface.Method}
+			tools = append(tools, def)
+		}
+	}
+	return tools, bound
+}
+
+// execute runs one tool call and renders its outcome for the model. Failures are
+// reported to the model rather than aborting the run, so it can recover.
+func (a *Agent) execute(ctx context.Context, bound map[string]boundTool, call ToolCall) string {
+	tool, ok := bound[call.Name]
+	if !ok {
+		return renderError(fmt.Errorf("unknown tool %q", call.Name))
+	}
+
+	args := map[string]any{}
+	if len(call.Arguments) > 0 {
+		if err := sonic.Unmarshal(call.Arguments, &args); err != nil {
+			return renderError(fmt.Errorf("invalid arguments for %s: %v", call.Name, err))
+		}
+	}
+
+	a.logger.Debug("executing tool call", "tool", call.Name, "method", tool.method)
+	resp, err := session.ExecuteTool(ctx, tool.doc, tool.method, args)
+	if err != nil {
+		return renderError(err)
+	}
+	payload := any(resp)
+	if result, ok := resp["result"]; ok {
+		payload = result
+	}
+	encoded, err := sonic.MarshalString(payload)
+	if err != nil {
+		return renderError(fmt.Errorf("encode tool result: %v", err))
+	}
+	return encoded
+}
+
+func renderError(err error) string {
+	encoded, _ := sonic.MarshalString(map[string]string{"error": err.Error()})
+	return encoded
+}
@@ -0,0 +1,188 @@
+package anp_agent
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/bytedance/sonic"
+	"github.com/openanp/anp-go/anptest"
+	"github.com/openanp/anp-go/session"
+)
+
+type addParams struct {
+	A int `json:"a"`
+	B int `json:"b"`
+}
+
+// scriptedLLM replays responses in order and records every request.
+type scriptedLLM struct {
+	mu        sync.Mutex
+	responses []*Response
+	requests  []*Request
+}
+
+func (s *scriptedLLM) Complete(_ context.Context, req *Request) (*Response, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.requests = append(s.requests, req)
+	if len(s.responses) == 0 {
+		return nil, errors.New("script exhausted")
+	}
+	resp := s.responses[0]
+	if len(s.responses) > 1 {
+		s.responses = s.responses[1:]
+	}
+	return resp, nil
+}
+
+func toolCall(id, name, args string) *Response {
+	return &Response{
+		Message: Message{ToolCalls: []ToolCall{{ID: id, Name: name, Arguments: json.RawMessage(args)}}},
+		Usage:   Usage{PromptTokens: 10, CompletionTokens: 5},
+	}
+}
+
+func newAgentServer(t *testing.T) (*anptest.Server, *session.Session) {
+	t.Helper()
+	caller := anptest.NewIdentity(t, "client.example.com")
+	srv := anptest.NewServer(t,
+		anptest.WithMethod("add", func(p addParams) (int, error) { return p.A + p.B, nil }),
+		anptest.WithDIDAuth(caller),
+	)
+	sess, err := session.New(session.Config{Authenticator: caller.Authenticator})
+	if err != nil {
+		t.Fatalf("session.New() error = %v", err)
+	}
+	return srv, sess
+}
+
+func TestAgent_RunExecutesToolCalls(t *testing.T) {
+	srv, sess := newAgentServer(t)
+	llm := &scriptedLLM{responses: []*Response{
+		{Message: Message{ToolCalls: []ToolCall{
+			{ID: "call-1", Name: "add", Arguments: json.RawMessage(`{"a":1,"b":2}`)},
+			{ID: "call-2", Name: "missing", Arguments: json.RawMessage(`{}`)},
+		}}},
+		{Message: Message{Content: "1 + 2 = 3"}, Usage: Usage{PromptTokens: 7, CompletionTokens: 3}},
+	}}
+
+	agent, err := New(Config{Session: sess, URLs: []string{srv.OpenRPCURL()}, LLM: llm, SystemPrompt: "be brief"})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	result, err := agent.Run(context.Background(), "add 1 and 2")
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if result.Answer != "1 + 2 = 3" || result.Steps != 2 || result.Usage.Total() != 10 {
+		t.Errorf("Run() = %+v", result)
+	}
+
+	if len(llm.requests[0].Tools) != 1 || llm.requests[0].Tools[0].Function.Name != "add" {
+		t.Fatalf("tools = %+v", llm.requests[0].Tools)
+	}
+	// system, user, assistant, tool, tool, assistant
+	if len(result.Messages) != 6 {
+		t.Fatalf("len(Messages) = %d, want 6", len(result.Messages))
+	}
+	if got := result.Messages[3]; got.Role != RoleTool || got.ToolCallID != "call-1" || got.Content != "3" {
+		t.Errorf("tool result = %+v", got)
+	}
+	if got := result.Messages[4]; !strings.Contains(got.Content, `unknown tool \"missing\"`) {
+		t.Errorf("unknown tool result = %+v", got)
+	}
+}
+
+func TestAgent_Limits(t *testing.T) {
+	srv, sess := newAgentServer(t)
+
+	tests := []struct {
+		name      string
+		cfg       Config
+		wantErr   error
+		wantSteps int
+	}{
+		{name: "max steps", cfg: Config{MaxSteps: 3}, wantErr: ErrMaxSteps, wantSteps: 3},
+		{name: "token budget", cfg: Config{MaxTokens: 25}, wantErr: ErrBudgetExceeded, wantSteps: 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := tt.cfg
+			cfg.Session = sess
+			cfg.URLs = []string{srv.OpenRPCURL()}
+			cfg.LLM = &scriptedLLM{responses: []*Response{toolCall("c", "add", `{"a":1,"b":1}`)}}
+
+			agent, err := New(cfg)
+			if err != nil {
+				t.Fatalf("New() error = %v", err)
+			}
+			result, err := agent.Run(context.Background(), "loop forever")
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Run() error = %v, want %v", err, tt.wantErr)
+			}
+			if result == nil || result.Steps != tt.wantSteps {
+				t.Errorf("Run() = %+v, want %d steps", result, tt.wantSteps)
+			}
+		})
+	}
+}
+
+func TestOpenAIClient_Complete(t *testing.T) {
+	var got openAIRequest
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/chat/completions" || r.Header.Get("Authorization") != "Bearer sk-test" {
+			http.Error(w, `{"error":{"message":"bad request"}}`, http.StatusUnauthorized)
+			return
+		}
+		if err := sonic.ConfigDefault.NewDecoder(r.Body).Decode(&got); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{
+			"choices": [{"message": {"role": "assistant", "content": null, "tool_calls": [
+				{"id": "call-9", "type": "function", "function": {"name": "add", "arguments": "{\"a\":2,\"b\":3}"}}
+			]}}],
+			"usage": {"prompt_tokens": 12, "completion_tokens": 4}
+		}`))
+	}))
+	defer srv.Close()
+
+	client := NewOpenAIClient(OpenAIConfig{BaseURL: srv.URL + "/v1/", APIKey: "sk-test", Model: "test-model"})
+	resp, err := client.Complete(context.Background(), &Request{Messages: []Message{
+		{Role: RoleUser, Content: "add"},
+		{Role: RoleAssistant, ToolCalls: []ToolCall{{ID: "call-1", Name: "add", Arguments: json.RawMessage(`{"a":1}`)}}},
+		{Role: RoleTool, ToolCallID: "call-1", Content: "1"},
+	}})
+	if err != nil {
+		t.Fatalf("Complete() error = %v", err)
+	}
+
+	if got.Model != "test-model" || len(got.Messages) != 3 {
+		t.Fatalf("request = %+v", got)
+	}
+	if assistant := got.Messages[1]; assistant.Content != nil || assistant.ToolCalls[0].Function.Arguments != `{"a":1}` {
+		t.Errorf("assistant message = %+v", assistant)
+	}
+	if tool := got.Messages[2]; tool.ToolCallID != "call-1" {
+		t.Errorf("tool message = %+v", tool)
+	}
+
+	if len(resp.Message.ToolCalls) != 1 || resp.Message.ToolCalls[0].Name != "add" || string(resp.Message.ToolCalls[0].Arguments) != `{"a":2,"b":3}` {
+		t.Errorf("Complete() message = %+v", resp.Message)
+	}
+	if resp.Usage.Total() != 16 {
+		t.Errorf("Complete() usage = %+v", resp.Usage)
+	}
+
+	bad := NewOpenAIClient(OpenAIConfig{BaseURL: srv.URL + "/v1", Model: "test-model"})
+	if _, err := bad.Complete(context.Background(), &Request{}); err == nil || !strings.Contains(err.Error(), "bad request") {
+		t.Errorf("Complete() error = %v, want API error", err)
+	}
+}
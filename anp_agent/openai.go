@@ -0,0 +1,170 @@
+package anp_agent
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/bytedance/sonic"
+	"github.com/openanp/anp-go/anp_crawler"
+)
+
+// DefaultOpenAIBaseURL is the OpenAI API root used when OpenAIConfig.BaseURL is empty.
+const DefaultOpenAIBaseURL = "https://api.openai.com/v1"
+
+// OpenAIConfig configures an OpenAI-compatible chat completions client. Any
+// server implementing /chat/completions with function tools works, e.g. vLLM,
+// Ollama, DeepSeek or Qwen endpoints.
+type OpenAIConfig struct {
+	BaseURL     string
+	APIKey      string
+	Model       string
+	Temperature *float64
+	HTTPClient  *http.Client
+}
+
+// OpenAIClient implements LLM against an OpenAI-compatible API.
+type OpenAIClient struct {
+	cfg OpenAIConfig
+}
+
+// NewOpenAIClient creates an OpenAIClient.
+func NewOpenAIClient(cfg OpenAIConfig) *OpenAIClient {
+	if cfg.BaseURL == "" {
+		cfg.BaseURL = DefaultOpenAIBaseURL
+	}
+	cfg.BaseURL = strings.TrimRight(cfg.BaseURL, "/")
+	if cfg.HTTPClient == nil {
+		cfg.HTTPClient = &http.Client{Timeout: 120 * time.Second}
+	}
+	return &OpenAIClient{cfg: cfg}
+}
+
+type openAIToolCall struct {
+	ID       string `json:"id"`
+	Type     string `json:"type"`
+	Function struct {
+		Name      string `json:"name"`
+		Arguments string `json:"arguments"`
+	} `json:"function"`
+}
+
+type openAIMessage struct {
+	Role       string           `json:"role"`
+	Content    *string          `json:"content"`
+	ToolCalls  []openAIToolCall `json:"tool_calls,omitempty"`
+	ToolCallID string           `json:"tool_call_id,omitempty"`
+}
+
+type openAIRequest struct {
+	Model       string                 `json:"model"`
+	Messages    []openAIMessage        `json:"messages"`
+	Tools       []*anp_crawler.ANPTool `json:"tools,omitempty"`
+	Temperature *float64               `json:"temperature,omitempty"`
+}
+
+type openAIResponse struct {
+	Choices []struct {
+		Message openAIMessage `json:"message"`
+	} `json:"choices"`
+	Usage struct {
+		PromptTokens     int `json:"prompt_tokens"`
+		CompletionTokens int `json:"completion_tokens"`
+	} `json:"usage"`
+	Error *struct {
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// Complete implements LLM.
+func (c *OpenAIClient) Complete(ctx context.Context, req *Request) (*Response, error) {
+	body := openAIRequest{Model: c.cfg.Model, Tools: req.Tools, Temperature: c.cfg.Temperature}
+	for _, m := range req.Messages {
+		body.Messages = append(body.Messages, toOpenAIMessage(m))
+	}
+	encoded, err := sonic.Marshal(body)
+	if err != nil {
+		return nil, fmt.Errorf("encode chat request: %w", err)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, c.cfg.BaseURL+"/chat/completions", bytes.NewReader(encoded))
+	if err != nil {
+		return nil, fmt.Errorf("create chat request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	if c.cfg.APIKey != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+c.cfg.APIKey)
+	}
+
+	resp, err := c.cfg.HTTPClient.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("chat completion: %w", err)
+	}
+	defer resp.Body.Close()
+	raw, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("read chat completion: %w", err)
+	}
+
+	var out openAIResponse
+	if err := sonic.Unmarshal(raw, &out); err != nil {
+		if resp.StatusCode >= http.StatusBadRequest {
+			return nil, fmt.Errorf("chat completion: status %d", resp.StatusCode)
+		}
+		return nil, fmt.Errorf("decode chat completion: %w", err)
+	}
+	if out.Error != nil {
+		return nil, fmt.Errorf("chat completion: status %d: %s", resp.StatusCode, out.Error.Message)
+	}
+	if resp.StatusCode >= http.StatusBadRequest {
+		return nil, fmt.Errorf("chat completion: status %d", resp.StatusCode)
+	}
+	if len(out.Choices) == 0 {
+		return nil, errors.New("chat completion returned no choices")
+	}
+
+	return &Response{
+		Message: fromOpenAIMessage(out.Choices[0].Message),
+		Usage:   Usage{PromptTokens: out.Usage.PromptTokens, CompletionTokens: out.Usage.CompletionTokens},
+	}, nil
+}
+
+func toOpenAIMessage(m Message) openAIMessage {
+	out := openAIMessage{Role: string(m.Role), ToolCallID: m.ToolCallID}
+	content := m.Content
+	// Assistant messages that only call tools carry a null content.
+	if content != "" || len(m.ToolCalls) == 0 {
+		out.Content = &content
+	}
+	for _, call := range m.ToolCalls {
+		tc := openAIToolCall{ID: call.ID, Type: "function"}
+		tc.Function.Name = call.Name
+		tc.Function.Arguments = string(call.Arguments)
+		if tc.Function.Arguments == "" {
+			tc.Function.Arguments = "{}"
+		}
+		out.ToolCalls = append(out.ToolCalls, tc)
+	}
+	return out
+}
+
+func fromOpenAIMessage(m openAIMessage) Message {
+	out := Message{Role: Role(m.Role)}
+	if m.Content != nil {
+		out.Content = *m.Content
+	}
+	for _, tc := range m.ToolCalls {
+		out.ToolCalls = append(out.ToolCalls, ToolCall{
+			ID:        tc.ID,
+			Name:      tc.Function.Name,
+			Arguments: json.RawMessage(tc.Function.Arguments),
+		})
+	}
+	return out
+}
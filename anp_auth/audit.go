@@ -0,0 +1,116 @@
+package anp_auth
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// Audit outcomes.
+const (
+	AuditOutcomeSuccess = "success"
+	AuditOutcomeFailure = "failure"
+)
+
+// AuditRecord describes one authentication decision of the verifier.
+type AuditRecord struct {
+	Time time.Time
+	// DID is the authenticated DID on success. On failure it is the DID
+	// claimed by a DID-WBA header, if the header could be parsed.
+	DID string
+	// ActorDID is the DID acting on behalf of DID in delegated requests.
+	ActorDID string
+	Domain   string
+	// Scheme is one of the MetricsScheme constants.
+	Scheme  string
+	Outcome string
+	// ErrorCategory and Error describe the rejection on failure.
+	ErrorCategory ErrorCategory
+	Error         string
+	// RemoteAddr is the client address when the check was made by Middleware.
+	RemoteAddr string
+}
+
+// AuditSink receives a record of every authentication attempt, e.g. to feed
+// a SIEM pipeline. Audit is called synchronously after each decision, so it
+// must be safe for concurrent use and should hand records off quickly.
+type AuditSink interface {
+	Audit(ctx context.Context, record AuditRecord)
+}
+
+// AuditSinkFunc adapts a function to AuditSink.
+type AuditSinkFunc func(ctx context.Context, record AuditRecord)
+
+// Audit implements AuditSink.
+func (f AuditSinkFunc) Audit(ctx context.Context, record AuditRecord) { f(ctx, record) }
+
+// NewSlogAuditSink returns an AuditSink that logs each record at Info level,
+// timestamped with the decision time, with one attribute per field. With a
+// slog.JSONHandler this produces one JSON object per decision, ready for log
+// shippers.
+func NewSlogAuditSink(logger *slog.Logger) AuditSink {
+	return AuditSinkFunc(func(ctx context.Context, r AuditRecord) {
+		if !logger.Enabled(ctx, slog.LevelInfo) {
+			return
+		}
+		attrs := []slog.Attr{
+			slog.String("outcome", r.Outcome),
+			slog.String("scheme", r.Scheme),
+			slog.String("domain", r.Domain),
+			slog.String("did", r.DID),
+		}
+		if r.ActorDID != "" {
+			attrs = append(attrs, slog.String("actor_did", r.ActorDID))
+		}
+		if r.RemoteAddr != "" {
+			attrs = append(attrs, slog.String("remote_addr", r.RemoteAddr))
+		}
+		if r.Outcome == AuditOutcomeFailure {
+			attrs = append(attrs, slog.String("error_category", string(r.ErrorCategory)), slog.String("error", r.Error))
+		}
+		// The log entry carries the decision time rather than the time of logging.
+		entry := slog.NewRecord(r.Time, slog.LevelInfo, "ANP authentication", 0)
+		entry.AddAttrs(attrs...)
+		_ = logger.Handler().Handle(ctx, entry)
+	})
+}
+
+type remoteAddrKey struct{}
+
+// withRemoteAddr stores the client address for the audit record.
+func withRemoteAddr(ctx context.Context, addr string) context.Context {
+	return context.WithValue(ctx, remoteAddrKey{}, addr)
+}
+
+// audit sends the record of one verification to the configured AuditSink.
+func (v *DidWbaVerifier) audit(ctx context.Context, authorization, domain string, result map[string]any, err error) {
+	sink := v.config.AuditSink
+	if sink == nil {
+		return
+	}
+	now := time.Now
+	if v.now != nil {
+		now = v.now
+	}
+	record := AuditRecord{
+		Time:    now().UTC(),
+		Domain:  domain,
+		Scheme:  metricsScheme(authorization),
+		Outcome: AuditOutcomeSuccess,
+	}
+	record.RemoteAddr, _ = ctx.Value(remoteAddrKey{}).(string)
+	if err != nil {
+		record.Outcome = AuditOutcomeFailure
+		record.ErrorCategory = ErrorCategoryOf(err)
+		record.Error = err.Error()
+		if record.Scheme == MetricsSchemeDIDWBA {
+			if parts, perr := parseAuthHeader(authorization); perr == nil {
+				record.DID = parts.DID
+			}
+		}
+	} else {
+		record.DID, _ = result["did"].(string)
+		record.ActorDID, _ = result["actor_did"].(string)
+	}
+	sink.Audit(ctx, record)
+}
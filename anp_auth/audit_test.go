@@ -0,0 +1,117 @@
+package anp_auth
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bytedance/sonic"
+)
+
+func TestVerifierAuditSink(t *testing.T) {
+	caller := newDelegationParty(t, "caller.example.com")
+	auth, err := NewAuthenticator(WithDIDMaterial(caller.doc, caller.key))
+	if err != nil {
+		t.Fatalf("NewAuthenticator() error = %v", err)
+	}
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("GenerateKey() error = %v", err)
+	}
+	var (
+		mu      sync.Mutex
+		records []AuditRecord
+	)
+	verifier, err := NewDidWbaVerifier(DidWbaVerifierConfig{
+		JWTPrivateKey:      key,
+		JWTPublicKey:       &key.PublicKey,
+		NonceValidator:     NewMemoryNonceValidator(time.Minute),
+		ResolveDIDDocument: resolverFor(t, caller),
+		AuditSink: AuditSinkFunc(func(_ context.Context, r AuditRecord) {
+			mu.Lock()
+			defer mu.Unlock()
+			records = append(records, r)
+		}),
+	})
+	if err != nil {
+		t.Fatalf("NewDidWbaVerifier() error = %v", err)
+	}
+	handler := Middleware(verifier)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	headers, err := auth.GenerateHeader("https://api.example.com/rpc")
+	if err != nil {
+		t.Fatalf("GenerateHeader() error = %v", err)
+	}
+	serve := func(authorization string) {
+		req := httptest.NewRequest(http.MethodGet, "https://api.example.com/rpc", nil)
+		req.RemoteAddr = "203.0.113.7:4242"
+		if authorization != "" {
+			req.Header.Set(AuthorizationHeader, authorization)
+		}
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+	}
+	serve(headers[AuthorizationHeader])
+	serve(headers[AuthorizationHeader]) // replayed nonce
+	serve("")
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(records) != 3 {
+		t.Fatalf("got %d audit records, want 3", len(records))
+	}
+	ok, replay, missing := records[0], records[1], records[2]
+	if ok.Outcome != AuditOutcomeSuccess || ok.DID != caller.doc.ID || ok.Scheme != MetricsSchemeDIDWBA ||
+		ok.Domain != "api.example.com" || ok.RemoteAddr != "203.0.113.7:4242" || ok.Time.IsZero() {
+		t.Errorf("success record = %+v", ok)
+	}
+	if replay.Outcome != AuditOutcomeFailure || replay.DID != caller.doc.ID || replay.ErrorCategory != ErrorCategoryNonce || replay.Error == "" {
+		t.Errorf("replay record = %+v", replay)
+	}
+	if missing.Outcome != AuditOutcomeFailure || missing.Scheme != MetricsSchemeNone || missing.ErrorCategory != ErrorCategoryMissingHeader {
+		t.Errorf("missing header record = %+v", missing)
+	}
+}
+
+func TestSlogAuditSink(t *testing.T) {
+	var buf bytes.Buffer
+	sink := NewSlogAuditSink(slog.New(slog.NewJSONHandler(&buf, nil)))
+	sink.Audit(context.Background(), AuditRecord{
+		Time:          time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC),
+		DID:           "did:wba:example.com",
+		Domain:        "api.example.com",
+		Scheme:        MetricsSchemeDIDWBA,
+		Outcome:       AuditOutcomeFailure,
+		ErrorCategory: ErrorCategorySignature,
+		Error:         "signature verification failed",
+		RemoteAddr:    "203.0.113.7:4242",
+	})
+
+	var entry map[string]any
+	if err := sonic.Unmarshal(bytes.TrimSpace(buf.Bytes()), &entry); err != nil {
+		t.Fatalf("Unmarshal(%s) error = %v", buf.String(), err)
+	}
+	for field, want := range map[string]string{
+		"did":            "did:wba:example.com",
+		"outcome":        AuditOutcomeFailure,
+		"error_category": string(ErrorCategorySignature),
+		"remote_addr":    "203.0.113.7:4242",
+	} {
+		if got, _ := entry[field].(string); got != want {
+			t.Errorf("%s = %q, want %q", field, got, want)
+		}
+	}
+	if entry["time"] != "2025-01-02T03:04:05Z" {
+		t.Errorf("time = %v, want the record time", entry["time"])
+	}
+	if _, ok := entry["actor_did"]; ok || !strings.Contains(buf.String(), "ANP authentication") {
+		t.Errorf("unexpected log line %s", buf.String())
+	}
+}
@@ -1,16 +1,18 @@
 package anp_auth
 
 import (
-	"crypto/ecdsa"
+	"crypto"
 	"fmt"
+	"log/slog"
 	"net/http"
 	"net/url"
 	"os"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/bytedance/sonic"
-	"github.com/openanp/anp-go/crypto"
+	"github.com/golang-jwt/jwt/v5"
 	"golang.org/x/sync/singleflight"
 )
 
@@ -19,20 +21,32 @@ type Authenticator struct {
 	cfg cfg // internal config for lazy loading
 
 	didDocument *DIDWBADocument
-	privateKey  *ecdsa.PrivateKey
+	privateKey  crypto.Signer
 	loadOnce    sync.Once
 	loadErr     error
 
-	tokens      map[string]string
-	authHeaders map[string]string
+	tokens      *headerCache
+	authHeaders *headerCache
 	cacheMutex  sync.Mutex
 
+	// cache limits applied when NewAuthenticator builds the caches
+	cacheSize       int
+	maxCacheEntries int
+	cacheTTL        time.Duration
+	headerCacheTTL  time.Duration
+
+	// refreshWindow is how early a bearer token is dropped before its exp claim
+	refreshWindow time.Duration
+
 	// sf prevents thundering herd when multiple goroutines request headers
 	// for the same domain simultaneously
 	sf singleflight.Group
 
 	// logger is the injected logger instance
-	logger Logger
+	logger *slog.Logger
+
+	// delegation is the encoded DelegationChain sent with every header, if any
+	delegation string
 }
 
 // cfg holds internal configuration for lazy loading
@@ -59,12 +73,12 @@ func (a *Authenticator) header(target string, force bool) (map[string]string, er
 
 	if !force {
 		a.cacheMutex.Lock()
-		if token, ok := a.tokens[domain]; ok {
+		if token, ok := a.tokens.get(domain); ok {
 			a.cacheMutex.Unlock()
 			a.logger.Debug("using cached JWT", "domain", domain)
 			return map[string]string{AuthorizationHeader: BearerScheme + token}, nil
 		}
-		if header, ok := a.authHeaders[domain]; ok {
+		if header, ok := a.authHeaders.get(domain); ok {
 			a.cacheMutex.Unlock()
 			a.logger.Debug("using cached DIDWba header", "domain", domain)
 			return map[string]string{AuthorizationHeader: header}, nil
@@ -78,11 +92,11 @@ func (a *Authenticator) header(target string, force bool) (map[string]string, er
 		// Double-check cache inside singleflight
 		if !force {
 			a.cacheMutex.Lock()
-			if token, ok := a.tokens[domain]; ok {
+			if token, ok := a.tokens.get(domain); ok {
 				a.cacheMutex.Unlock()
 				return map[string]string{AuthorizationHeader: BearerScheme + token}, nil
 			}
-			if header, ok := a.authHeaders[domain]; ok {
+			if header, ok := a.authHeaders.get(domain); ok {
 				a.cacheMutex.Unlock()
 				return map[string]string{AuthorizationHeader: header}, nil
 			}
@@ -93,14 +107,14 @@ func (a *Authenticator) header(target string, force bool) (map[string]string, er
 			return nil, fmt.Errorf("load authentication material: %w", err)
 		}
 
-		header, err := GenerateAuthHeader(a.privateKey, a.didDocument, domain)
+		header, err := NewDelegatedAuthHeader(a.privateKey, a.didDocument, domain, newNonce(), time.Now().UTC().Format(time.RFC3339), a.delegation)
 		if err != nil {
 			return nil, fmt.Errorf("generate header: %w", err)
 		}
 
 		headerString := header.String()
 		a.cacheMutex.Lock()
-		a.authHeaders[domain] = headerString
+		a.authHeaders.add(domain, headerString)
 		a.cacheMutex.Unlock()
 
 		return map[string]string{AuthorizationHeader: headerString}, nil
@@ -113,6 +127,27 @@ func (a *Authenticator) header(target string, force bool) (map[string]string, er
 	return result.(map[string]string), nil
 }
 
+// GenerateHeaderWithNonce signs a DIDWba header over a server-issued nonce,
+// for verifiers with a ChallengeStore (see FetchChallenge). The header is
+// valid once and is not cached.
+func (a *Authenticator) GenerateHeaderWithNonce(target, nonce string) (map[string]string, error) {
+	domain, err := getDomain(target)
+	if err != nil {
+		return nil, err
+	}
+	if nonce == "" {
+		return nil, fmt.Errorf("generate header: nonce is empty")
+	}
+	if err := a.ensureMaterial(); err != nil {
+		return nil, fmt.Errorf("load authentication material: %w", err)
+	}
+	header, err := NewDelegatedAuthHeader(a.privateKey, a.didDocument, domain, nonce, time.Now().UTC().Format(time.RFC3339), a.delegation)
+	if err != nil {
+		return nil, fmt.Errorf("generate header: %w", err)
+	}
+	return map[string]string{AuthorizationHeader: header.String()}, nil
+}
+
 // GenerateJSON creates the DID-WBA JSON payload equivalent to the Authorization header.
 func (a *Authenticator) GenerateJSON(target string) (*AuthJSON, error) {
 	domain, err := getDomain(target)
@@ -122,15 +157,19 @@ func (a *Authenticator) GenerateJSON(target string) (*AuthJSON, error) {
 	if err := a.ensureMaterial(); err != nil {
 		return nil, fmt.Errorf("load authentication material: %w", err)
 	}
-	return GenerateAuthJSON(a.privateKey, a.didDocument, domain)
+	return generateAuthJSON(a.privateKey, a.didDocument, domain, a.delegation)
 }
 
-// UpdateFromResponse caches a bearer token returned by the server.
+// UpdateFromResponse caches a bearer token returned by the server. When the
+// token carries an exp claim it is only reused until shortly before it
+// expires, after which GenerateHeader signs a fresh DIDWba header instead of
+// waiting for the server to reject the token.
 func (a *Authenticator) UpdateFromResponse(target string, header http.Header) {
 	token := header.Get(AuthorizationHeader)
 	if !strings.HasPrefix(token, BearerScheme) {
 		return
 	}
+	token = strings.TrimPrefix(token, BearerScheme)
 
 	domain, err := getDomain(target)
 	if err != nil {
@@ -138,9 +177,36 @@ func (a *Authenticator) UpdateFromResponse(target string, header http.Header) {
 		return
 	}
 
+	var refreshAt time.Time
+	if exp, ok := tokenExpiry(token); ok {
+		refreshAt = exp.Add(-a.refreshWindow)
+	}
+
 	a.cacheMutex.Lock()
-	a.tokens[domain] = strings.TrimPrefix(token, BearerScheme)
-	a.cacheMutex.Unlock()
+	defer a.cacheMutex.Unlock()
+	// The DIDWba header this token replaces is stale by the time the token
+	// lapses, so drop it now and let the next request sign a new one.
+	a.authHeaders.remove(domain)
+	if !refreshAt.IsZero() && !a.tokens.now().Before(refreshAt) {
+		a.tokens.remove(domain)
+		a.logger.Debug("ignoring expiring JWT", "domain", domain, "refresh_at", refreshAt)
+		return
+	}
+	a.tokens.addUntil(domain, token, refreshAt)
+}
+
+// tokenExpiry reads the exp claim of a JWT without verifying it; the token is
+// only ever sent back to the server that issued it.
+func tokenExpiry(token string) (time.Time, bool) {
+	parsed, _, err := jwt.NewParser().ParseUnverified(token, jwt.MapClaims{})
+	if err != nil {
+		return time.Time{}, false
+	}
+	exp, err := parsed.Claims.GetExpirationTime()
+	if err != nil || exp == nil {
+		return time.Time{}, false
+	}
+	return exp.Time, true
 }
 
 // ClearToken removes any cached token/header for the target.
@@ -151,44 +217,46 @@ func (a *Authenticator) ClearToken(target string) {
 		return
 	}
 	a.cacheMutex.Lock()
-	delete(a.tokens, domain)
-	delete(a.authHeaders, domain)
+	a.tokens.remove(domain)
+	a.authHeaders.remove(domain)
 	a.cacheMutex.Unlock()
 }
 
 func (a *Authenticator) ensureMaterial() error {
 	a.loadOnce.Do(func() {
-		if a.didDocument != nil && a.privateKey != nil {
-			return
-		}
+		a.loadErr = a.loadMaterial()
+	})
+	return a.loadErr
+}
 
+// loadMaterial reads whichever of the DID document and private key were
+// configured as paths rather than given directly.
+func (a *Authenticator) loadMaterial() error {
+	if a.didDocument == nil {
 		docBytes, err := os.ReadFile(a.cfg.DIDDocumentPath)
 		if err != nil {
-			a.loadErr = fmt.Errorf("read DID document: %w", err)
-			return
+			return fmt.Errorf("read DID document: %w", err)
 		}
 
 		var doc DIDWBADocument
 		if err := sonic.Unmarshal(docBytes, &doc); err != nil {
-			a.loadErr = fmt.Errorf("decode DID document: %w", err)
-			return
+			return fmt.Errorf("decode DID document: %w", err)
 		}
+		a.didDocument = &doc
+	}
 
+	if a.privateKey == nil {
 		keyBytes, err := os.ReadFile(a.cfg.PrivateKeyPath)
 		if err != nil {
-			a.loadErr = fmt.Errorf("read private key: %w", err)
-			return
+			return fmt.Errorf("read private key: %w", err)
 		}
-		key, err := crypto.PrivateKeyFromPEM(keyBytes)
+		key, err := parseDIDPrivateKey(keyBytes)
 		if err != nil {
-			a.loadErr = fmt.Errorf("decode private key: %w", err)
-			return
+			return fmt.Errorf("decode private key: %w", err)
 		}
-
-		a.didDocument = &doc
 		a.privateKey = key
-	})
-	return a.loadErr
+	}
+	return nil
 }
 
 func getDomain(target string) (string, error) {
@@ -0,0 +1,79 @@
+package anp_auth
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestUpdateFromResponseTracksExpiry(t *testing.T) {
+	party := newDelegationParty(t, "client.example.com")
+	auth, err := NewAuthenticator(WithDIDMaterial(party.doc, party.key), WithTokenRefreshWindow(time.Minute))
+	if err != nil {
+		t.Fatalf("NewAuthenticator() error = %v", err)
+	}
+	jwtKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("GenerateKey() error = %v", err)
+	}
+	issue := func(expiration time.Duration) http.Header {
+		token, err := CreateAccessToken(party.doc.ID, jwtKey, DefaultJWTAlgorithm, expiration)
+		if err != nil {
+			t.Fatalf("CreateAccessToken() error = %v", err)
+		}
+		return http.Header{AuthorizationHeader: []string{BearerScheme + token}}
+	}
+	const target = "https://agent.example.com/ad.json"
+	scheme := func() string {
+		t.Helper()
+		headers, err := auth.GenerateHeader(target)
+		if err != nil {
+			t.Fatalf("GenerateHeader() error = %v", err)
+		}
+		value := headers[AuthorizationHeader]
+		return value[:strings.IndexByte(value, ' ')+1]
+	}
+
+	if got := scheme(); got != "DIDWba " {
+		t.Fatalf("first header scheme = %q, want DIDWba", got)
+	}
+	auth.UpdateFromResponse(target, issue(time.Hour))
+	if got := scheme(); got != BearerScheme {
+		t.Fatalf("header scheme after token = %q, want Bearer", got)
+	}
+
+	// Shortly before exp the token is dropped and a new DIDWba header signed.
+	now := time.Now()
+	auth.tokens.now = func() time.Time { return now.Add(59*time.Minute + time.Second) }
+	if got := scheme(); got != "DIDWba " {
+		t.Errorf("header scheme near expiry = %q, want DIDWba", got)
+	}
+
+	// Tokens already inside the refresh window are not cached at all.
+	auth.tokens.now = time.Now
+	auth.UpdateFromResponse(target, issue(30*time.Second))
+	if _, ok := auth.tokens.get("agent.example.com"); ok {
+		t.Error("token expiring within the refresh window was cached")
+	}
+}
+
+func TestTokenExpiry(t *testing.T) {
+	jwtKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("GenerateKey() error = %v", err)
+	}
+	token, err := CreateAccessToken("did:wba:example.com", jwtKey, DefaultJWTAlgorithm, time.Hour)
+	if err != nil {
+		t.Fatalf("CreateAccessToken() error = %v", err)
+	}
+	exp, ok := tokenExpiry(token)
+	if !ok || exp.Sub(time.Now()) < 59*time.Minute || exp.Sub(time.Now()) > time.Hour {
+		t.Errorf("tokenExpiry() = %v, %v, want about an hour from now", exp, ok)
+	}
+	if _, ok := tokenExpiry("opaque-token"); ok {
+		t.Error("tokenExpiry(opaque) reported an expiry")
+	}
+}
@@ -0,0 +1,95 @@
+// Package awskms provides a crypto.Signer backed by an asymmetric AWS KMS key,
+// so an agent signs DID-WBA headers without ever holding its private key.
+//
+// It is a separate module so the core SDK does not depend on the AWS SDK:
+//
+//	signer, _ := awskms.NewSigner(ctx, kms.NewFromConfig(cfg), "alias/agent-did")
+//	doc, _ := anp_auth.NewDIDDocumentBuilderForHost("agent.example.com", nil, nil).
+//		AddKey("key-1", signer.Public()).
+//		Authentication("key-1").
+//		Build()
+//	auth, _ := anp_auth.NewAuthenticator(anp_auth.WithDIDMaterial(doc, signer))
+//
+// KMS keys must have the ECC_SECG_P256K1 or ECC_NIST_P256 key spec and the
+// SIGN_VERIFY key usage.
+package awskms
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"fmt"
+	"io"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/kms"
+	"github.com/aws/aws-sdk-go-v2/service/kms/types"
+	anpcrypto "github.com/openanp/anp-go/crypto"
+)
+
+// Client is the part of the KMS API the signer uses; *kms.Client implements it.
+type Client interface {
+	GetPublicKey(ctx context.Context, params *kms.GetPublicKeyInput, optFns ...func(*kms.Options)) (*kms.GetPublicKeyOutput, error)
+	Sign(ctx context.Context, params *kms.SignInput, optFns ...func(*kms.Options)) (*kms.SignOutput, error)
+}
+
+var _ crypto.Signer = (*Signer)(nil)
+
+// Signer signs SHA-256 digests with a KMS key. Like *ecdsa.PrivateKey it
+// returns ASN.1 DER signatures, which anp_auth.SignContent converts to the
+// R||S encoding DID-WBA verifiers expect.
+type Signer struct {
+	client    Client
+	keyID     string
+	publicKey *ecdsa.PublicKey
+}
+
+// NewSigner fetches the public key of keyID, which may be a key ID, key ARN,
+// alias name or alias ARN, and checks that it can sign for a DID document.
+func NewSigner(ctx context.Context, client Client, keyID string) (*Signer, error) {
+	out, err := client.GetPublicKey(ctx, &kms.GetPublicKeyInput{KeyId: aws.String(keyID)})
+	if err != nil {
+		return nil, fmt.Errorf("get KMS public key: %w", err)
+	}
+	if out.KeyUsage != types.KeyUsageTypeSignVerify {
+		return nil, fmt.Errorf("KMS key %s has key usage %s, want %s", keyID, out.KeyUsage, types.KeyUsageTypeSignVerify)
+	}
+	switch out.KeySpec {
+	case types.KeySpecEccSecgP256k1, types.KeySpecEccNistP256:
+	default:
+		return nil, fmt.Errorf("unsupported KMS key spec for DID document: %s", out.KeySpec)
+	}
+	publicKey, err := anpcrypto.PublicKeyFromDER(out.PublicKey)
+	if err != nil {
+		return nil, fmt.Errorf("decode KMS public key: %w", err)
+	}
+	return &Signer{client: client, keyID: keyID, publicKey: publicKey}, nil
+}
+
+// Public returns the *ecdsa.PublicKey of the KMS key.
+func (s *Signer) Public() crypto.PublicKey {
+	return s.publicKey
+}
+
+// Sign signs a SHA-256 digest in KMS. rand is unused; KMS supplies its own
+// randomness.
+func (s *Signer) Sign(_ io.Reader, digest []byte, opts crypto.SignerOpts) ([]byte, error) {
+	return s.SignContext(context.Background(), digest, opts)
+}
+
+// SignContext is Sign with a context for the KMS request.
+func (s *Signer) SignContext(ctx context.Context, digest []byte, opts crypto.SignerOpts) ([]byte, error) {
+	if opts == nil || opts.HashFunc() != crypto.SHA256 || len(digest) != crypto.SHA256.Size() {
+		return nil, fmt.Errorf("KMS signer only signs SHA-256 digests")
+	}
+	out, err := s.client.Sign(ctx, &kms.SignInput{
+		KeyId:            aws.String(s.keyID),
+		Message:          digest,
+		MessageType:      types.MessageTypeDigest,
+		SigningAlgorithm: types.SigningAlgorithmSpecEcdsaSha256,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("KMS sign: %w", err)
+	}
+	return out.Signature, nil
+}
@@ -0,0 +1,105 @@
+package awskms
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/kms"
+	"github.com/aws/aws-sdk-go-v2/service/kms/types"
+	"github.com/openanp/anp-go/anp_auth"
+	anpcrypto "github.com/openanp/anp-go/crypto"
+)
+
+// fakeKMS signs with a local key the way KMS does.
+type fakeKMS struct {
+	key   *ecdsa.PrivateKey
+	spec  types.KeySpec
+	usage types.KeyUsageType
+}
+
+func (f *fakeKMS) GetPublicKey(_ context.Context, in *kms.GetPublicKeyInput, _ ...func(*kms.Options)) (*kms.GetPublicKeyOutput, error) {
+	der, err := anpcrypto.PublicKeyToDER(&f.key.PublicKey)
+	if err != nil {
+		return nil, err
+	}
+	return &kms.GetPublicKeyOutput{KeyId: in.KeyId, PublicKey: der, KeySpec: f.spec, KeyUsage: f.usage}, nil
+}
+
+func (f *fakeKMS) Sign(_ context.Context, in *kms.SignInput, _ ...func(*kms.Options)) (*kms.SignOutput, error) {
+	sig, err := ecdsa.SignASN1(rand.Reader, f.key, in.Message)
+	if err != nil {
+		return nil, err
+	}
+	return &kms.SignOutput{KeyId: in.KeyId, Signature: sig, SigningAlgorithm: in.SigningAlgorithm}, nil
+}
+
+func TestSigner(t *testing.T) {
+	tests := []struct {
+		name  string
+		curve func() *ecdsa.PrivateKey
+		spec  types.KeySpec
+	}{
+		{"secp256k1", func() *ecdsa.PrivateKey { k, _ := anpcrypto.GenerateECKeyPair(anpcrypto.Secp256k1()); return k }, types.KeySpecEccSecgP256k1},
+		{"P-256", func() *ecdsa.PrivateKey { k, _ := anpcrypto.GenerateECKeyPair(anpcrypto.P256()); return k }, types.KeySpecEccNistP256},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &fakeKMS{key: tt.curve(), spec: tt.spec, usage: types.KeyUsageTypeSignVerify}
+			signer, err := NewSigner(context.Background(), client, "alias/agent")
+			if err != nil {
+				t.Fatalf("NewSigner() error = %v", err)
+			}
+			if !client.key.PublicKey.Equal(signer.Public()) {
+				t.Fatal("Public() does not match the KMS key")
+			}
+
+			doc, err := anp_auth.NewDIDDocumentBuilderForHost("agent.example.com", nil, nil).
+				AddKey("key-1", signer.Public()).
+				Authentication("key-1").
+				Build()
+			if err != nil {
+				t.Fatalf("Build() error = %v", err)
+			}
+			content := []byte("payload")
+			sig, err := anp_auth.SignContent(signer, content)
+			if err != nil {
+				t.Fatalf("SignContent() error = %v", err)
+			}
+			if err := anp_auth.VerifyContent(doc, doc.ID+"#key-1", content, sig); err != nil {
+				t.Errorf("VerifyContent() error = %v", err)
+			}
+			if _, err := anp_auth.GenerateAuthHeader(signer, doc, "service.example.com"); err != nil {
+				t.Errorf("GenerateAuthHeader() error = %v", err)
+			}
+		})
+	}
+}
+
+func TestNewSigner_RejectsKey(t *testing.T) {
+	key, _ := anpcrypto.GenerateECKeyPair(anpcrypto.P256())
+	tests := []struct {
+		name   string
+		client *fakeKMS
+	}{
+		{"encryption key", &fakeKMS{key: key, spec: types.KeySpecEccNistP256, usage: types.KeyUsageTypeEncryptDecrypt}},
+		{"RSA key spec", &fakeKMS{key: key, spec: types.KeySpecRsa2048, usage: types.KeyUsageTypeSignVerify}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewSigner(context.Background(), tt.client, "alias/agent"); err == nil {
+				t.Error("NewSigner() error = nil, want error")
+			}
+		})
+	}
+
+	signer, err := NewSigner(context.Background(), &fakeKMS{key: key, spec: types.KeySpecEccNistP256, usage: types.KeyUsageTypeSignVerify}, "alias/agent")
+	if err != nil {
+		t.Fatalf("NewSigner() error = %v", err)
+	}
+	if _, err := signer.Sign(rand.Reader, []byte("not a digest"), nil); err == nil {
+		t.Error("Sign() without SHA-256 error = nil, want error")
+	}
+}
@@ -0,0 +1,185 @@
+// Package azurekv provides a crypto.Signer backed by an Azure Key Vault or
+// Managed HSM elliptic curve key, the Azure counterpart of anp_auth/awskms.
+//
+// It is a separate module so the core SDK does not depend on the Azure SDK.
+// Requests go to the Key Vault REST API, authenticated by any azcore
+// credential such as one from azidentity:
+//
+//	cred, _ := azidentity.NewDefaultAzureCredential(nil)
+//	signer, _ := azurekv.NewSigner(ctx, "https://agents.vault.azure.net", "did", "", cred, nil)
+//	auth, _ := anp_auth.NewAuthenticator(anp_auth.WithDIDSigner("did.json", signer))
+//
+// Keys must be EC or EC-HSM keys on P-256K (secp256k1) or P-256.
+package azurekv
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"encoding/asn1"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+	"github.com/bytedance/sonic"
+	anpcrypto "github.com/openanp/anp-go/crypto"
+)
+
+// APIVersion is the Key Vault REST API version the signer speaks.
+const APIVersion = "7.4"
+
+// Scope is the OAuth scope of Key Vault access tokens.
+const Scope = "https://vault.azure.net/.default"
+
+// maxResponseSize bounds Key Vault responses read by the signer.
+const maxResponseSize = 1 << 20
+
+// curves maps Key Vault curve names to their curve and signing algorithm.
+var curves = map[string]struct {
+	curve elliptic.Curve
+	alg   string
+}{
+	"P-256K": {anpcrypto.Secp256k1(), "ES256K"},
+	"P-256":  {anpcrypto.P256(), "ES256"},
+}
+
+var _ crypto.Signer = (*Signer)(nil)
+
+// Signer signs SHA-256 digests with a Key Vault key. Key Vault returns R||S
+// signatures; Sign re-encodes them as ASN.1 DER like *ecdsa.PrivateKey, and
+// anp_auth.SignContent converts them back for DID-WBA.
+type Signer struct {
+	keyURL    string
+	alg       string
+	cred      azcore.TokenCredential
+	client    *http.Client
+	publicKey *ecdsa.PublicKey
+}
+
+// NewSigner fetches the public key of key name in the vault at vaultURL and
+// checks that it can sign for a DID document. An empty version selects the
+// current version; a nil client uses http.DefaultClient.
+func NewSigner(ctx context.Context, vaultURL, name, version string, cred azcore.TokenCredential, client *http.Client) (*Signer, error) {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	s := &Signer{
+		keyURL: strings.TrimSuffix(vaultURL, "/") + "/keys/" + url.PathEscape(name),
+		cred:   cred,
+		client: client,
+	}
+	if version != "" {
+		s.keyURL += "/" + url.PathEscape(version)
+	}
+
+	var bundle struct {
+		Key struct {
+			Kid string `json:"kid"`
+			Kty string `json:"kty"`
+			Crv string `json:"crv"`
+			X   string `json:"x"`
+			Y   string `json:"y"`
+		} `json:"key"`
+	}
+	if err := s.do(ctx, http.MethodGet, s.keyURL, nil, &bundle); err != nil {
+		return nil, fmt.Errorf("get Key Vault key: %w", err)
+	}
+	key := bundle.Key
+	c, ok := curves[key.Crv]
+	if !ok || (key.Kty != "EC" && key.Kty != "EC-HSM") {
+		return nil, fmt.Errorf("unsupported Key Vault key for DID document: kty=%s, crv=%s", key.Kty, key.Crv)
+	}
+	x, errX := base64.RawURLEncoding.DecodeString(key.X)
+	y, errY := base64.RawURLEncoding.DecodeString(key.Y)
+	if errX != nil || errY != nil {
+		return nil, fmt.Errorf("decode Key Vault key: invalid coordinates")
+	}
+	pub := ecdsa.PublicKey{Curve: c.curve, X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}
+	if !c.curve.IsOnCurve(pub.X, pub.Y) {
+		return nil, fmt.Errorf("decode Key Vault key: point is not on %s", key.Crv)
+	}
+
+	// Pin the version so a rotation in the vault does not silently change the
+	// key behind a published DID document.
+	if key.Kid != "" {
+		s.keyURL = key.Kid
+	}
+	s.alg = c.alg
+	s.publicKey = &pub
+	return s, nil
+}
+
+// Public returns the *ecdsa.PublicKey of the Key Vault key.
+func (s *Signer) Public() crypto.PublicKey {
+	return s.publicKey
+}
+
+// Sign signs a SHA-256 digest in Key Vault. rand is unused.
+func (s *Signer) Sign(_ io.Reader, digest []byte, opts crypto.SignerOpts) ([]byte, error) {
+	return s.SignContext(context.Background(), digest, opts)
+}
+
+// SignContext is Sign with a context for the Key Vault request.
+func (s *Signer) SignContext(ctx context.Context, digest []byte, opts crypto.SignerOpts) ([]byte, error) {
+	if opts == nil || opts.HashFunc() != crypto.SHA256 || len(digest) != crypto.SHA256.Size() {
+		return nil, fmt.Errorf("Key Vault signer only signs SHA-256 digests")
+	}
+	body, err := sonic.Marshal(map[string]string{
+		"alg":   s.alg,
+		"value": base64.RawURLEncoding.EncodeToString(digest),
+	})
+	if err != nil {
+		return nil, err
+	}
+	var result struct {
+		Value string `json:"value"`
+	}
+	if err := s.do(ctx, http.MethodPost, s.keyURL+"/sign", body, &result); err != nil {
+		return nil, fmt.Errorf("Key Vault sign: %w", err)
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(result.Value)
+	if err != nil || len(raw) != 64 {
+		return nil, fmt.Errorf("Key Vault sign: invalid signature")
+	}
+	return asn1.Marshal(struct{ R, S *big.Int }{
+		new(big.Int).SetBytes(raw[:32]),
+		new(big.Int).SetBytes(raw[32:]),
+	})
+}
+
+// do sends an authenticated Key Vault request and decodes the JSON response.
+func (s *Signer) do(ctx context.Context, method, target string, body []byte, out any) error {
+	token, err := s.cred.GetToken(ctx, policy.TokenRequestOptions{Scopes: []string{Scope}})
+	if err != nil {
+		return fmt.Errorf("get access token: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, target+"?api-version="+APIVersion, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+token.Token)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseSize))
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %d: %s", resp.StatusCode, bytes.TrimSpace(data))
+	}
+	return sonic.Unmarshal(data, out)
+}
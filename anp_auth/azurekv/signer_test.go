@@ -0,0 +1,124 @@
+package azurekv
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+	"github.com/bytedance/sonic"
+	"github.com/openanp/anp-go/anp_auth"
+	anpcrypto "github.com/openanp/anp-go/crypto"
+)
+
+type staticCredential string
+
+func (c staticCredential) GetToken(context.Context, policy.TokenRequestOptions) (azcore.AccessToken, error) {
+	return azcore.AccessToken{Token: string(c)}, nil
+}
+
+// fakeVault serves the Key Vault get-key and sign operations for one key.
+func fakeVault(t *testing.T, key *ecdsa.PrivateKey, crv string) *httptest.Server {
+	t.Helper()
+	b64 := base64.RawURLEncoding.EncodeToString
+	var srv *httptest.Server
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /keys/did", func(w http.ResponseWriter, r *http.Request) {
+		w.Write(mustJSON(t, map[string]any{"key": map[string]string{
+			"kid": srv.URL + "/keys/did/v1",
+			"kty": "EC-HSM",
+			"crv": crv,
+			"x":   b64(key.X.FillBytes(make([]byte, 32))),
+			"y":   b64(key.Y.FillBytes(make([]byte, 32))),
+		}}))
+	})
+	mux.HandleFunc("POST /keys/did/v1/sign", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer token" || r.URL.Query().Get("api-version") != APIVersion {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		var req struct{ Alg, Value string }
+		if err := sonic.ConfigDefault.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		digest, _ := base64.RawURLEncoding.DecodeString(req.Value)
+		rr, ss, err := ecdsa.Sign(rand.Reader, key, digest)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		sig := append(rr.FillBytes(make([]byte, 32)), ss.FillBytes(make([]byte, 32))...)
+		w.Write(mustJSON(t, map[string]string{"kid": srv.URL + "/keys/did/v1", "value": b64(sig)}))
+	})
+	srv = httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func mustJSON(t *testing.T, v any) []byte {
+	data, err := sonic.Marshal(v)
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	return data
+}
+
+func TestSigner(t *testing.T) {
+	tests := []struct {
+		crv string
+		key func() (*ecdsa.PrivateKey, error)
+	}{
+		{"P-256K", func() (*ecdsa.PrivateKey, error) { return anpcrypto.GenerateECKeyPair(anpcrypto.Secp256k1()) }},
+		{"P-256", func() (*ecdsa.PrivateKey, error) { return anpcrypto.GenerateECKeyPair(anpcrypto.P256()) }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.crv, func(t *testing.T) {
+			key, err := tt.key()
+			if err != nil {
+				t.Fatalf("GenerateECKeyPair() error = %v", err)
+			}
+			srv := fakeVault(t, key, tt.crv)
+			signer, err := NewSigner(context.Background(), srv.URL, "did", "", staticCredential("token"), srv.Client())
+			if err != nil {
+				t.Fatalf("NewSigner() error = %v", err)
+			}
+			if !key.PublicKey.Equal(signer.Public()) {
+				t.Fatal("Public() does not match the vault key")
+			}
+
+			doc, err := anp_auth.NewDIDDocumentBuilderForHost("agent.example.com", nil, nil).
+				AddKey("key-1", signer.Public()).
+				Authentication("key-1").
+				Build()
+			if err != nil {
+				t.Fatalf("Build() error = %v", err)
+			}
+			content := []byte("payload")
+			sig, err := anp_auth.SignContent(signer, content)
+			if err != nil {
+				t.Fatalf("SignContent() error = %v", err)
+			}
+			if err := anp_auth.VerifyContent(doc, doc.ID+"#key-1", content, sig); err != nil {
+				t.Errorf("VerifyContent() error = %v", err)
+			}
+		})
+	}
+}
+
+func TestNewSigner_Errors(t *testing.T) {
+	key, _ := anpcrypto.GenerateECKeyPair(anpcrypto.P256())
+	srv := fakeVault(t, key, "P-384")
+	if _, err := NewSigner(context.Background(), srv.URL, "did", "", staticCredential("token"), srv.Client()); err == nil {
+		t.Error("NewSigner(P-384) error = nil, want error")
+	}
+	if _, err := NewSigner(context.Background(), srv.URL, "missing", "", staticCredential("token"), srv.Client()); err == nil {
+		t.Error("NewSigner(missing key) error = nil, want error")
+	}
+}
@@ -0,0 +1,97 @@
+package anp_auth
+
+import (
+	"container/list"
+	"time"
+)
+
+// headerCache is a least-recently-used map from domain to a cached token or
+// header value. Entries older than ttl are treated as absent, and the least
+// recently used entry is evicted once maxEntries is exceeded. A zero ttl or
+// maxEntries disables that limit. It is not safe for concurrent use; the
+// Authenticator guards it with cacheMutex.
+type headerCache struct {
+	maxEntries int
+	ttl        time.Duration
+	now        func() time.Time
+
+	order   *list.List // front is most recently used
+	entries map[string]*list.Element
+}
+
+type cacheEntry struct {
+	key       string
+	value     string
+	expiresAt time.Time // zero when the entry never expires
+}
+
+func newHeaderCache(size, maxEntries int, ttl time.Duration) *headerCache {
+	if maxEntries > 0 && size > maxEntries {
+		size = maxEntries
+	}
+	return &headerCache{
+		maxEntries: maxEntries,
+		ttl:        ttl,
+		now:        time.Now,
+		order:      list.New(),
+		entries:    make(map[string]*list.Element, size),
+	}
+}
+
+// get returns the live value for key and marks it recently used. Expired
+// entries are dropped.
+func (c *headerCache) get(key string) (string, bool) {
+	elem, ok := c.entries[key]
+	if !ok {
+		return "", false
+	}
+	entry := elem.Value.(*cacheEntry)
+	if !entry.expiresAt.IsZero() && !c.now().Before(entry.expiresAt) {
+		c.removeElement(elem)
+		return "", false
+	}
+	c.order.MoveToFront(elem)
+	return entry.value, true
+}
+
+// add stores value under key, expiring after the cache TTL.
+func (c *headerCache) add(key, value string) {
+	c.addUntil(key, value, time.Time{})
+}
+
+// addUntil stores value under key until expiresAt or the end of the cache
+// TTL, whichever comes first. A zero expiresAt leaves only the TTL.
+func (c *headerCache) addUntil(key, value string, expiresAt time.Time) {
+	if c.ttl > 0 {
+		if limit := c.now().Add(c.ttl); expiresAt.IsZero() || limit.Before(expiresAt) {
+			expiresAt = limit
+		}
+	}
+	if elem, ok := c.entries[key]; ok {
+		entry := elem.Value.(*cacheEntry)
+		entry.value, entry.expiresAt = value, expiresAt
+		c.order.MoveToFront(elem)
+		return
+	}
+	c.entries[key] = c.order.PushFront(&cacheEntry{key: key, value: value, expiresAt: expiresAt})
+	for c.maxEntries > 0 && c.order.Len() > c.maxEntries {
+		c.removeElement(c.order.Back())
+	}
+}
+
+func (c *headerCache) remove(key string) {
+	if elem, ok := c.entries[key]; ok {
+		c.removeElement(elem)
+	}
+}
+
+func (c *headerCache) removeElement(elem *list.Element) {
+	c.order.Remove(elem)
+	delete(c.entries, elem.Value.(*cacheEntry).key)
+}
+
+// len reports the number of stored entries, including any not yet found to
+// be expired.
+func (c *headerCache) len() int {
+	return c.order.Len()
+}
@@ -0,0 +1,57 @@
+package anp_auth
+
+import (
+	"testing"
+	"time"
+)
+
+func TestHeaderCache(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	c := newHeaderCache(0, 2, time.Minute)
+	c.now = func() time.Time { return now }
+
+	c.add("a.example.com", "A")
+	c.add("b.example.com", "B")
+	if _, ok := c.get("a.example.com"); !ok {
+		t.Fatal("get(a) missing")
+	}
+	// a was used more recently than b, so b is evicted.
+	c.add("c.example.com", "C")
+	if _, ok := c.get("b.example.com"); ok {
+		t.Error("get(b) found evicted entry")
+	}
+	if c.len() != 2 {
+		t.Errorf("len() = %d, want 2", c.len())
+	}
+
+	now = now.Add(59 * time.Second)
+	c.add("c.example.com", "C2")
+	now = now.Add(time.Second)
+	if _, ok := c.get("a.example.com"); ok {
+		t.Error("get(a) found expired entry")
+	}
+	if got, ok := c.get("c.example.com"); !ok || got != "C2" {
+		t.Errorf("get(c) = %q, %v, want C2 refreshed by add", got, ok)
+	}
+	if c.len() != 1 {
+		t.Errorf("len() = %d, want expired entry dropped", c.len())
+	}
+
+	c.remove("c.example.com")
+	if c.len() != 0 {
+		t.Errorf("len() = %d after remove, want 0", c.len())
+	}
+}
+
+func TestHeaderCacheUnbounded(t *testing.T) {
+	c := newHeaderCache(4, 0, 0)
+	for _, domain := range []string{"a", "b", "c", "d", "e", "f"} {
+		c.add(domain, domain)
+	}
+	if c.len() != 6 {
+		t.Errorf("len() = %d, want 6", c.len())
+	}
+	if got, ok := c.get("a"); !ok || got != "a" {
+		t.Errorf("get(a) = %q, %v", got, ok)
+	}
+}
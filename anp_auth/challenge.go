@@ -0,0 +1,165 @@
+package anp_auth
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/bytedance/sonic"
+)
+
+// ChallengeStore holds the nonces issued by IssueChallenge until they are
+// used. With a ChallengeStore configured the verifier only accepts DID-WBA
+// headers signed over one of its nonces, each exactly once, so a captured
+// header cannot be replayed against another verifier that shares the store.
+type ChallengeStore interface {
+	// Put records an issued nonce. It may be dropped after expiresAt.
+	Put(ctx context.Context, nonce string, expiresAt time.Time) error
+	// Consume removes nonce and reports whether it was issued and had not
+	// expired or been consumed before. It must be atomic: of concurrent
+	// calls with the same nonce at most one may return true.
+	Consume(ctx context.Context, nonce string) (bool, error)
+}
+
+// Challenge is a server-issued nonce for the client to sign.
+type Challenge struct {
+	Nonce     string    `json:"nonce"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// MemoryChallengeStore is an in-memory ChallengeStore. It only covers a
+// single process; use a shared store such as the one in anp_auth/redis when
+// several verifiers serve the same clients.
+type MemoryChallengeStore struct {
+	mu     sync.Mutex
+	issued map[string]time.Time
+	now    func() time.Time
+}
+
+// NewMemoryChallengeStore creates an empty in-memory challenge store.
+func NewMemoryChallengeStore() *MemoryChallengeStore {
+	return &MemoryChallengeStore{issued: make(map[string]time.Time), now: time.Now}
+}
+
+// Put implements ChallengeStore. Expired challenges are dropped.
+func (s *MemoryChallengeStore) Put(_ context.Context, nonce string, expiresAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.now()
+	for n, exp := range s.issued {
+		if now.After(exp) {
+			delete(s.issued, n)
+		}
+	}
+	s.issued[nonce] = expiresAt
+	return nil
+}
+
+// Consume implements ChallengeStore.
+func (s *MemoryChallengeStore) Consume(_ context.Context, nonce string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	exp, ok := s.issued[nonce]
+	if !ok {
+		return false, nil
+	}
+	delete(s.issued, nonce)
+	return !s.now().After(exp), nil
+}
+
+// IssueChallenge creates a nonce for a client to sign its next DID-WBA
+// header over. It fails with ErrChallengeStoreMissing unless the verifier
+// has a ChallengeStore.
+func (v *DidWbaVerifier) IssueChallenge(ctx context.Context) (*Challenge, error) {
+	if v.config.ChallengeStore == nil {
+		return nil, ErrChallengeStoreMissing
+	}
+	var b [24]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return nil, err
+	}
+	expiration := v.config.ChallengeExpiration
+	if expiration == 0 {
+		expiration = DefaultChallengeExpiration
+	}
+	challenge := &Challenge{
+		Nonce:     base64.RawURLEncoding.EncodeToString(b[:]),
+		ExpiresAt: v.now().Add(expiration).UTC(),
+	}
+	if err := v.config.ChallengeStore.Put(ctx, challenge.Nonce, challenge.ExpiresAt); err != nil {
+		return nil, WrapAuthError(ErrChallengeStoreFailure, "store challenge", err)
+	}
+	return challenge, nil
+}
+
+// ChallengeHandler serves IssueChallenge as JSON, e.g. on
+// "/auth/challenge". It must not sit behind Middleware, since clients call
+// it before they can authenticate.
+func (v *DidWbaVerifier) ChallengeHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodPost {
+			w.Header().Set("Allow", "GET, POST")
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		challenge, err := v.IssueChallenge(r.Context())
+		if err != nil {
+			v.config.Logger.ErrorContext(r.Context(), "issue challenge", "error", err)
+			http.Error(w, "challenge unavailable", http.StatusInternalServerError)
+			return
+		}
+		body, err := sonic.Marshal(challenge)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-store")
+		_, _ = w.Write(body)
+	})
+}
+
+// consumeChallenge accepts a header nonce only if it is an unused challenge.
+func (v *DidWbaVerifier) consumeChallenge(ctx context.Context, nonce string) error {
+	ok, err := v.config.ChallengeStore.Consume(ctx, nonce)
+	if err != nil {
+		return NewErrorWithStatus(WrapAuthError(ErrChallengeStoreFailure, "consume challenge", err), StatusInternalServerError)
+	}
+	if !ok {
+		return NewErrorWithStatus(ErrNonceInvalid, StatusUnauthorized)
+	}
+	return nil
+}
+
+// FetchChallenge requests a challenge from a verifier's ChallengeHandler at
+// url. A nil client uses http.DefaultClient.
+func FetchChallenge(ctx context.Context, client *http.Client, url string) (*Challenge, error) {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetch challenge: unexpected status %s", resp.Status)
+	}
+	var challenge Challenge
+	if err := sonic.ConfigDefault.NewDecoder(resp.Body).Decode(&challenge); err != nil {
+		return nil, fmt.Errorf("decode challenge: %w", err)
+	}
+	if challenge.Nonce == "" {
+		return nil, errors.New("challenge has no nonce")
+	}
+	return &challenge, nil
+}
@@ -0,0 +1,116 @@
+package anp_auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestVerifierChallenge(t *testing.T) {
+	party := newDelegationParty(t, "client.example.com")
+	auth, err := NewAuthenticator(WithDIDMaterial(party.doc, party.key))
+	if err != nil {
+		t.Fatalf("NewAuthenticator() error = %v", err)
+	}
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("GenerateKey() error = %v", err)
+	}
+	// No NonceValidator: the challenge store alone prevents replays.
+	verifier, err := NewDidWbaVerifier(DidWbaVerifierConfig{
+		JWTPrivateKey:      key,
+		JWTPublicKey:       &key.PublicKey,
+		ChallengeStore:     NewMemoryChallengeStore(),
+		ResolveDIDDocument: resolverFor(t, party),
+	})
+	if err != nil {
+		t.Fatalf("NewDidWbaVerifier() error = %v", err)
+	}
+	srv := httptest.NewServer(verifier.ChallengeHandler())
+	defer srv.Close()
+
+	const target, domain = "https://api.example.com/rpc", "api.example.com"
+	challenge, err := FetchChallenge(context.Background(), srv.Client(), srv.URL)
+	if err != nil {
+		t.Fatalf("FetchChallenge() error = %v", err)
+	}
+	if challenge.ExpiresAt.Before(time.Now()) {
+		t.Errorf("ExpiresAt = %v, want in the future", challenge.ExpiresAt)
+	}
+	headers, err := auth.GenerateHeaderWithNonce(target, challenge.Nonce)
+	if err != nil {
+		t.Fatalf("GenerateHeaderWithNonce() error = %v", err)
+	}
+	result, err := verifier.VerifyAuthHeader(headers[AuthorizationHeader], domain)
+	if err != nil {
+		t.Fatalf("VerifyAuthHeader() error = %v", err)
+	}
+	if result["did"] != party.doc.ID {
+		t.Errorf("did = %v, want %s", result["did"], party.doc.ID)
+	}
+
+	// Replaying the header, or signing over a nonce the server never issued,
+	// is rejected.
+	clientNonce, err := auth.GenerateHeader(target)
+	if err != nil {
+		t.Fatalf("GenerateHeader() error = %v", err)
+	}
+	for name, header := range map[string]string{
+		"replayed":     headers[AuthorizationHeader],
+		"client nonce": clientNonce[AuthorizationHeader],
+	} {
+		_, err := verifier.VerifyAuthHeader(header, domain)
+		var statusErr *ErrorWithStatus
+		if !errors.Is(err, ErrNonceInvalid) || !errors.As(err, &statusErr) || statusErr.StatusCode != StatusUnauthorized {
+			t.Errorf("VerifyAuthHeader(%s) error = %v, want 401 ErrNonceInvalid", name, err)
+		}
+	}
+}
+
+func TestIssueChallenge_NoStore(t *testing.T) {
+	verifier, err := NewDidWbaVerifier(DidWbaVerifierConfig{NonceValidator: NewMemoryNonceValidator(time.Minute)})
+	if err != nil {
+		t.Fatalf("NewDidWbaVerifier() error = %v", err)
+	}
+	if _, err := verifier.IssueChallenge(context.Background()); !errors.Is(err, ErrChallengeStoreMissing) {
+		t.Errorf("IssueChallenge() error = %v, want ErrChallengeStoreMissing", err)
+	}
+	rec := httptest.NewRecorder()
+	verifier.ChallengeHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/auth/challenge", nil))
+	if rec.Code != http.StatusInternalServerError {
+		t.Errorf("ChallengeHandler status = %d, want %d", rec.Code, http.StatusInternalServerError)
+	}
+}
+
+func TestMemoryChallengeStore(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	s := NewMemoryChallengeStore()
+	s.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	if err := s.Put(ctx, "a", now.Add(time.Minute)); err != nil {
+		t.Fatalf("Put() error = %v", err)
+	}
+	if err := s.Put(ctx, "b", now.Add(time.Minute)); err != nil {
+		t.Fatalf("Put() error = %v", err)
+	}
+	if ok, _ := s.Consume(ctx, "a"); !ok {
+		t.Error("Consume(a) = false, want true")
+	}
+	if ok, _ := s.Consume(ctx, "a"); ok {
+		t.Error("Consume(a) twice = true, want false")
+	}
+	if ok, _ := s.Consume(ctx, "unknown"); ok {
+		t.Error("Consume(unknown) = true, want false")
+	}
+
+	now = now.Add(2 * time.Minute)
+	if ok, _ := s.Consume(ctx, "b"); ok {
+		t.Error("Consume(b) after expiry = true, want false")
+	}
+}
@@ -0,0 +1,114 @@
+package anp_auth
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// ClaimsOption adds claims to tokens when creating them and, for aud, iss
+// and scopes, requires them when verifying.
+type ClaimsOption func(*claimsOptions)
+
+type claimsOptions struct {
+	audience []string
+	issuer   string
+	scopes   []string
+	extra    map[string]any
+}
+
+// WithAudience sets the aud claim. When verifying, the token must name at
+// least one of the audiences.
+func WithAudience(audience ...string) ClaimsOption {
+	return func(o *claimsOptions) { o.audience = append(o.audience, audience...) }
+}
+
+// WithIssuer sets the iss claim. When verifying, the token's issuer must match.
+func WithIssuer(issuer string) ClaimsOption {
+	return func(o *claimsOptions) { o.issuer = issuer }
+}
+
+// WithScopes sets the space-separated scope claim. When verifying, the token
+// must grant every listed scope.
+func WithScopes(scopes ...string) ClaimsOption {
+	return func(o *claimsOptions) { o.scopes = append(o.scopes, scopes...) }
+}
+
+// WithClaims adds arbitrary claims to created tokens; they are returned in
+// AccessTokenClaims.Extra. Registered claims set by this package (jti, sub,
+// act, iat, exp, aud, iss, scope) cannot be overridden. Ignored when verifying.
+func WithClaims(claims map[string]any) ClaimsOption {
+	return func(o *claimsOptions) {
+		if o.extra == nil {
+			o.extra = make(map[string]any, len(claims))
+		}
+		for k, v := range claims {
+			o.extra[k] = v
+		}
+	}
+}
+
+func newClaimsOptions(opts []ClaimsOption) claimsOptions {
+	var o claimsOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// reservedClaims are written by this package and never taken from WithClaims
+// or reported in AccessTokenClaims.Extra.
+var reservedClaims = []string{"jti", "sub", "act", "iat", "exp", "nbf", "aud", "iss", "scope", tokenUseClaim}
+
+// apply writes the options into claims.
+func (o claimsOptions) apply(claims jwt.MapClaims) {
+	for k, v := range o.extra {
+		if !slices.Contains(reservedClaims, k) {
+			claims[k] = v
+		}
+	}
+	switch len(o.audience) {
+	case 0:
+	case 1:
+		claims["aud"] = o.audience[0]
+	default:
+		claims["aud"] = o.audience
+	}
+	if o.issuer != "" {
+		claims["iss"] = o.issuer
+	}
+	if len(o.scopes) > 0 {
+		claims["scope"] = strings.Join(o.scopes, " ")
+	}
+}
+
+// parserOptions returns the jwt parser checks for the issuer.
+func (o claimsOptions) parserOptions() []jwt.ParserOption {
+	if o.issuer == "" {
+		return nil
+	}
+	return []jwt.ParserOption{jwt.WithIssuer(o.issuer)}
+}
+
+// check validates the audience and scopes of parsed claims. The jwt parser
+// accepts only a single expected audience, so audiences are matched here.
+func (o claimsOptions) check(claims *AccessTokenClaims) error {
+	if len(o.audience) > 0 && !slices.ContainsFunc(o.audience, func(aud string) bool {
+		return slices.Contains(claims.Audience, aud)
+	}) {
+		return fmt.Errorf("token audience %v does not include %v", claims.Audience, o.audience)
+	}
+	for _, scope := range o.scopes {
+		if !claims.HasScope(scope) {
+			return fmt.Errorf("token lacks scope %q", scope)
+		}
+	}
+	return nil
+}
+
+// HasScope reports whether the token grants scope.
+func (c *AccessTokenClaims) HasScope(scope string) bool {
+	return slices.Contains(c.Scopes, scope)
+}
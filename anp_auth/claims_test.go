@@ -0,0 +1,121 @@
+package anp_auth
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestAccessTokenClaimsOptions(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("GenerateKey() error = %v", err)
+	}
+	token, err := CreateAccessToken("did:wba:client.example.com", key, DefaultJWTAlgorithm, time.Hour,
+		WithIssuer("https://auth.example.com"),
+		WithAudience("hotel.example.com", "flight.example.com"),
+		WithScopes("booking:read", "booking:write"),
+		WithClaims(map[string]any{"tenant": "acme", "sub": "did:wba:forged.example.com"}),
+	)
+	if err != nil {
+		t.Fatalf("CreateAccessToken() error = %v", err)
+	}
+
+	claims, err := ParseAccessToken(token, &key.PublicKey, DefaultJWTAlgorithm)
+	if err != nil {
+		t.Fatalf("ParseAccessToken() error = %v", err)
+	}
+	if claims.Subject != "did:wba:client.example.com" {
+		t.Errorf("Subject = %q, custom claims must not override sub", claims.Subject)
+	}
+	if claims.Issuer != "https://auth.example.com" || !slices.Equal(claims.Audience, []string{"hotel.example.com", "flight.example.com"}) {
+		t.Errorf("Issuer = %q, Audience = %v", claims.Issuer, claims.Audience)
+	}
+	if !claims.HasScope("booking:write") || claims.HasScope("admin") {
+		t.Errorf("Scopes = %v", claims.Scopes)
+	}
+	if claims.Extra["tenant"] != "acme" || len(claims.Extra) != 1 {
+		t.Errorf("Extra = %v", claims.Extra)
+	}
+	if claims.IssuedAt.IsZero() || !claims.ExpiresAt.After(claims.IssuedAt) {
+		t.Errorf("IssuedAt = %v, ExpiresAt = %v", claims.IssuedAt, claims.ExpiresAt)
+	}
+
+	tests := []struct {
+		name    string
+		opts    []ClaimsOption
+		wantErr bool
+	}{
+		{"no requirements", nil, false},
+		{"matching audience", []ClaimsOption{WithAudience("flight.example.com")}, false},
+		{"any of several audiences", []ClaimsOption{WithAudience("car.example.com", "hotel.example.com")}, false},
+		{"other audience", []ClaimsOption{WithAudience("car.example.com")}, true},
+		{"matching issuer", []ClaimsOption{WithIssuer("https://auth.example.com")}, false},
+		{"other issuer", []ClaimsOption{WithIssuer("https://evil.example.com")}, true},
+		{"granted scope", []ClaimsOption{WithScopes("booking:read")}, false},
+		{"missing scope", []ClaimsOption{WithScopes("booking:read", "admin")}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := VerifyAccessToken(token, &key.PublicKey, DefaultJWTAlgorithm, tt.opts...)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("VerifyAccessToken() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestVerifierIssuerAudience(t *testing.T) {
+	party := newDelegationParty(t, "client.example.com")
+	auth, err := NewAuthenticator(WithDIDMaterial(party.doc, party.key))
+	if err != nil {
+		t.Fatalf("NewAuthenticator() error = %v", err)
+	}
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("GenerateKey() error = %v", err)
+	}
+	verifier, err := NewDidWbaVerifier(DidWbaVerifierConfig{
+		JWTPrivateKey:      key,
+		JWTPublicKey:       &key.PublicKey,
+		JWTIssuer:          "https://api.example.com",
+		JWTAudience:        []string{"api.example.com"},
+		NonceValidator:     NewMemoryNonceValidator(time.Minute),
+		ResolveDIDDocument: resolverFor(t, party),
+	})
+	if err != nil {
+		t.Fatalf("NewDidWbaVerifier() error = %v", err)
+	}
+
+	headers, err := auth.GenerateHeader("https://api.example.com/rpc")
+	if err != nil {
+		t.Fatalf("GenerateHeader() error = %v", err)
+	}
+	result, err := verifier.VerifyAuthHeader(headers[AuthorizationHeader], "api.example.com")
+	if err != nil {
+		t.Fatalf("VerifyAuthHeader() error = %v", err)
+	}
+	issued := result["access_token"].(string)
+	claims, err := ParseAccessToken(issued, &key.PublicKey, DefaultJWTAlgorithm)
+	if err != nil {
+		t.Fatalf("ParseAccessToken() error = %v", err)
+	}
+	if claims.Issuer != "https://api.example.com" || !slices.Equal(claims.Audience, []string{"api.example.com"}) {
+		t.Errorf("issued claims = %+v", claims)
+	}
+	if _, err := verifier.VerifyAuthHeader(BearerScheme+issued, "api.example.com"); err != nil {
+		t.Errorf("VerifyAuthHeader(bearer) error = %v", err)
+	}
+
+	// A token from the same key without the configured claims is rejected.
+	bare, err := CreateAccessToken(party.doc.ID, key, DefaultJWTAlgorithm, time.Hour)
+	if err != nil {
+		t.Fatalf("CreateAccessToken() error = %v", err)
+	}
+	if _, err := verifier.VerifyAuthHeader(BearerScheme+bare, "api.example.com"); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("VerifyAuthHeader(bare token) error = %v, want ErrInvalidToken", err)
+	}
+}
@@ -7,6 +7,10 @@ const (
 	// DIDPrefix is the standard prefix for DID-WBA identifiers
 	DIDPrefix = "did:wba:"
 
+	// DIDWebPrefix is the prefix of did:web identifiers, which are resolved
+	// with the same URL mapping as did:wba
+	DIDWebPrefix = "did:web:"
+
 	// DIDWbaScheme is the authentication scheme name
 	DIDWbaScheme = "DIDWba"
 
@@ -15,12 +19,31 @@ const (
 
 	// AuthorizationHeader is the HTTP header name for authentication
 	AuthorizationHeader = "Authorization"
+
+	// AuthenticationInfoHeader carries the server's DIDWba response proof in
+	// mutual authentication
+	AuthenticationInfoHeader = "Authentication-Info"
 )
 
 // Verification Method Types
 const (
 	// VerificationMethodEcdsaSecp256k1 is the ECDSA secp256k1 verification method type
 	VerificationMethodEcdsaSecp256k1 = "EcdsaSecp256k1VerificationKey2019"
+
+	// VerificationMethodEcdsaSecp256r1 is the ECDSA P-256 verification method type
+	VerificationMethodEcdsaSecp256r1 = "EcdsaSecp256r1VerificationKey2019"
+
+	// VerificationMethodJsonWebKey2020 is the generic JWK verification method
+	// type; the algorithm follows from the key's kty and crv
+	VerificationMethodJsonWebKey2020 = "JsonWebKey2020"
+
+	// VerificationMethodMultikey is the generic multibase verification method
+	// type; the algorithm follows from the key's multicodec prefix
+	VerificationMethodMultikey = "Multikey"
+
+	// VerificationMethodEd25519 is the Ed25519 publicKeyMultibase
+	// verification method type
+	VerificationMethodEd25519 = "Ed25519VerificationKey2020"
 )
 
 // DID Document Contexts
@@ -48,6 +71,22 @@ const (
 
 	// JWKCurveSecp256k1 is the secp256k1 curve name
 	JWKCurveSecp256k1 = "secp256k1"
+
+	// JWKCurveP256 is the NIST P-256 (secp256r1) curve name
+	JWKCurveP256 = "P-256"
+
+	// JWKTypeOKP is the octet key pair key type used by Ed25519
+	JWKTypeOKP = "OKP"
+
+	// JWKCurveEd25519 is the Ed25519 curve name
+	JWKCurveEd25519 = "Ed25519"
+
+	// JWKTypeRSA is the RSA key type used by RS256 and PS256 JWT keys
+	JWKTypeRSA = "RSA"
+
+	// JWKCurveP384 and JWKCurveP521 are the NIST curves used by ES384 and ES512
+	JWKCurveP384 = "P-384"
+	JWKCurveP521 = "P-521"
 )
 
 // Default Configuration Values
@@ -67,8 +106,28 @@ const (
 	// DefaultNonceExpiration is the default nonce expiration
 	DefaultNonceExpiration = 6 * time.Minute
 
+	// DefaultChallengeExpiration is how long a server-issued challenge nonce
+	// can be signed over
+	DefaultChallengeExpiration = 2 * time.Minute
+
 	// DefaultTimestampTolerance is the tolerance for future timestamps
 	DefaultTimestampTolerance = 1 * time.Minute
+
+	// DefaultHeaderCacheTTL is how long the Authenticator reuses a signed
+	// DIDWba header by default, inside DefaultTimestampExpiration so the
+	// verifier does not reject it as stale
+	DefaultHeaderCacheTTL = 4 * time.Minute
+
+	// DefaultMaxCacheEntries is the default number of domains the
+	// Authenticator keeps cached tokens and headers for
+	DefaultMaxCacheEntries = 1024
+
+	// DefaultTokenRefreshWindow is how long before a cached bearer token's
+	// expiry the Authenticator stops sending it and signs a new DIDWba header
+	DefaultTokenRefreshWindow = 30 * time.Second
+
+	// DefaultJWKSRefreshInterval is how often a remote JWKS is refetched
+	DefaultJWKSRefreshInterval = 15 * time.Minute
 )
 
 // Well-Known Paths
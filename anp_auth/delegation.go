@@ -0,0 +1,174 @@
+package anp_auth
+
+import (
+	"context"
+	"crypto"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/bytedance/sonic"
+	"github.com/cyberphone/json-canonicalization/go/src/webpki.org/jsoncanonicalizer"
+)
+
+// MaxDelegationDepth bounds the number of links in a delegation chain.
+const MaxDelegationDepth = 4
+
+// Delegation is a credential in which Issuer authorizes Delegate to act on its
+// behalf, optionally only towards one service domain (Audience).
+type Delegation struct {
+	Issuer             string `json:"issuer"`
+	Delegate           string `json:"delegate"`
+	Audience           string `json:"audience,omitempty"`
+	IssuedAt           string `json:"issued_at"`
+	ExpiresAt          string `json:"expires_at"`
+	VerificationMethod string `json:"verification_method"`
+	Signature          string `json:"signature,omitempty"`
+}
+
+// IssueDelegation signs a delegation from issuerDoc's DID to delegateDID that is
+// valid for ttl. An empty audience allows the delegate to use it with any service.
+func IssueDelegation(privateKey crypto.Signer, issuerDoc *DIDWBADocument, delegateDID, audience string, ttl time.Duration) (*Delegation, error) {
+	if issuerDoc == nil {
+		return nil, errors.New("DID document is required")
+	}
+	if delegateDID == "" {
+		return nil, errors.New("delegate DID is required")
+	}
+	if ttl <= 0 {
+		return nil, errors.New("delegation ttl must be positive")
+	}
+	_, fragment, err := selectVerificationMethod(issuerDoc)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	d := &Delegation{
+		Issuer:             issuerDoc.ID,
+		Delegate:           delegateDID,
+		Audience:           audience,
+		IssuedAt:           now.Format(time.RFC3339),
+		ExpiresAt:          now.Add(ttl).Format(time.RFC3339),
+		VerificationMethod: issuerDoc.ID + "#" + fragment,
+	}
+	content, err := d.signingInput()
+	if err != nil {
+		return nil, err
+	}
+	if d.Signature, err = SignContent(privateKey, content); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+func (d *Delegation) signingInput() ([]byte, error) {
+	unsigned := *d
+	unsigned.Signature = ""
+	raw, err := sonic.Marshal(&unsigned)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrPayloadMarshal, err)
+	}
+	return jsoncanonicalizer.Transform(raw)
+}
+
+// DelegationChain is an ordered list of delegations. The first issuer is the
+// subject on whose behalf the request is made; each delegate issues the next
+// link; the last delegate is the actor that signs the request.
+type DelegationChain []*Delegation
+
+// Subject returns the DID the chain grants authority for.
+func (c DelegationChain) Subject() string {
+	if len(c) == 0 {
+		return ""
+	}
+	return c[0].Issuer
+}
+
+// Actor returns the DID the chain grants authority to.
+func (c DelegationChain) Actor() string {
+	if len(c) == 0 {
+		return ""
+	}
+	return c[len(c)-1].Delegate
+}
+
+// Encode serializes the chain for the delegation parameter of the DIDWba header.
+func (c DelegationChain) Encode() (string, error) {
+	raw, err := sonic.Marshal(c)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrPayloadMarshal, err)
+	}
+	return base64.RawURLEncoding.EncodeToString(raw), nil
+}
+
+// DecodeDelegationChain parses a chain produced by DelegationChain.Encode.
+func DecodeDelegationChain(encoded string) (DelegationChain, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("%w: decode: %v", ErrDelegationInvalid, err)
+	}
+	var chain DelegationChain
+	if err := sonic.Unmarshal(raw, &chain); err != nil {
+		return nil, fmt.Errorf("%w: parse: %v", ErrDelegationInvalid, err)
+	}
+	return chain, nil
+}
+
+// Verify checks that the chain is well formed, unexpired, scoped to
+// serviceDomain, ends at actorDID and that every link is signed by its issuer.
+// It returns the earliest expiry in the chain.
+func (c DelegationChain) Verify(ctx context.Context, actorDID, serviceDomain string, now time.Time, resolve ResolveDIDDocumentFunc) (time.Time, error) {
+	if len(c) == 0 || len(c) > MaxDelegationDepth {
+		return time.Time{}, fmt.Errorf("%w: chain length %d", ErrDelegationInvalid, len(c))
+	}
+	if c.Actor() != actorDID {
+		return time.Time{}, fmt.Errorf("%w: chain delegates to %s, not %s", ErrDelegationInvalid, c.Actor(), actorDID)
+	}
+
+	var expiry time.Time
+	for i, d := range c {
+		if d == nil {
+			return time.Time{}, fmt.Errorf("%w: link %d is empty", ErrDelegationInvalid, i)
+		}
+		if i > 0 && c[i-1].Delegate != d.Issuer {
+			return time.Time{}, fmt.Errorf("%w: link %d issued by %s, expected %s", ErrDelegationInvalid, i, d.Issuer, c[i-1].Delegate)
+		}
+		if d.Audience != "" && !strings.EqualFold(d.Audience, serviceDomain) {
+			return time.Time{}, fmt.Errorf("%w: link %d is restricted to %s", ErrDelegationInvalid, i, d.Audience)
+		}
+
+		issued, err := time.Parse(time.RFC3339, d.IssuedAt)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("%w: link %d issued_at: %v", ErrDelegationInvalid, i, err)
+		}
+		expires, err := time.Parse(time.RFC3339, d.ExpiresAt)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("%w: link %d expires_at: %v", ErrDelegationInvalid, i, err)
+		}
+		if issued.After(now.Add(DefaultTimestampTolerance)) || !now.Before(expires) {
+			return time.Time{}, fmt.Errorf("%w: link %d is not valid at %s", ErrDelegationInvalid, i, now.UTC().Format(time.RFC3339))
+		}
+		if expiry.IsZero() || expires.Before(expiry) {
+			expiry = expires
+		}
+
+		if !strings.HasPrefix(d.VerificationMethod, d.Issuer+"#") {
+			return time.Time{}, fmt.Errorf("%w: link %d verification method does not belong to %s", ErrDelegationInvalid, i, d.Issuer)
+		}
+		doc, err := resolve(ctx, d.Issuer)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("%w: resolve %s: %v", ErrDelegationInvalid, d.Issuer, err)
+		}
+		content, err := d.signingInput()
+		if err != nil {
+			return time.Time{}, err
+		}
+		if err := VerifyContent(doc, d.VerificationMethod, content, d.Signature); err != nil {
+			return time.Time{}, fmt.Errorf("%w: link %d: %v", ErrDelegationInvalid, i, err)
+		}
+	}
+	return expiry, nil
+}
@@ -0,0 +1,331 @@
+package anp_auth
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/rsa"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bytedance/sonic"
+)
+
+type delegationParty struct {
+	doc *DIDWBADocument
+	key *ecdsa.PrivateKey
+}
+
+func newDelegationParty(t *testing.T, host string) delegationParty {
+	t.Helper()
+	doc, key, err := CreateDIDWBADocument(host, nil, nil, nil)
+	if err != nil {
+		t.Fatalf("CreateDIDWBADocument() error = %v", err)
+	}
+	return delegationParty{doc: doc, key: key}
+}
+
+// resolverFor serves JSON round-tripped documents, as a real resolver would.
+func resolverFor(t *testing.T, parties ...delegationParty) ResolveDIDDocumentFunc {
+	t.Helper()
+	docs := make(map[string]*DIDWBADocument)
+	for _, p := range parties {
+		raw, _ := sonic.Marshal(p.doc)
+		var doc DIDWBADocument
+		if err := sonic.Unmarshal(raw, &doc); err != nil {
+			t.Fatalf("round-trip DID document: %v", err)
+		}
+		docs[doc.ID] = &doc
+	}
+	return func(_ context.Context, did string) (*DIDWBADocument, error) {
+		if doc, ok := docs[did]; ok {
+			return doc, nil
+		}
+		return nil, fmt.Errorf("unknown DID %s", did)
+	}
+}
+
+func TestDelegation_VerifierExposesSubjectAndActor(t *testing.T) {
+	user := newDelegationParty(t, "user.example.com")
+	agent := newDelegationParty(t, "orchestrator.example.com")
+
+	grant, err := IssueDelegation(user.key, user.doc, agent.doc.ID, "tool.example.com", time.Hour)
+	if err != nil {
+		t.Fatalf("IssueDelegation() error = %v", err)
+	}
+	auth, err := NewAuthenticator(WithDIDMaterial(agent.doc, agent.key), WithDelegation(DelegationChain{grant}))
+	if err != nil {
+		t.Fatalf("NewAuthenticator() error = %v", err)
+	}
+
+	jwtKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("GenerateKey() error = %v", err)
+	}
+	verifier, err := NewDidWbaVerifier(DidWbaVerifierConfig{
+		JWTPrivateKey:      jwtKey,
+		JWTPublicKey:       &jwtKey.PublicKey,
+		NonceValidator:     NewMemoryNonceValidator(time.Minute),
+		ResolveDIDDocument: resolverFor(t, user, agent),
+	})
+	if err != nil {
+		t.Fatalf("NewDidWbaVerifier() error = %v", err)
+	}
+
+	headers, err := auth.GenerateHeader("https://tool.example.com/rpc")
+	if err != nil {
+		t.Fatalf("GenerateHeader() error = %v", err)
+	}
+	result, err := verifier.VerifyAuthHeader(headers[AuthorizationHeader], "tool.example.com")
+	if err != nil {
+		t.Fatalf("VerifyAuthHeader() error = %v", err)
+	}
+	if result["did"] != user.doc.ID || result["actor_did"] != agent.doc.ID {
+		t.Errorf("result = %v, want did=%s actor_did=%s", result, user.doc.ID, agent.doc.ID)
+	}
+
+	token := result["access_token"].(string)
+	claims, err := ParseAccessToken(token, &jwtKey.PublicKey, DefaultJWTAlgorithm)
+	if err != nil {
+		t.Fatalf("ParseAccessToken() error = %v", err)
+	}
+	if claims.Subject != user.doc.ID || claims.Actor != agent.doc.ID {
+		t.Errorf("claims = %+v", claims)
+	}
+	bearer, err := verifier.VerifyAuthHeader(BearerScheme+token, "tool.example.com")
+	if err != nil {
+		t.Fatalf("VerifyAuthHeader(bearer) error = %v", err)
+	}
+	if bearer["actor_did"] != agent.doc.ID {
+		t.Errorf("bearer result = %v", bearer)
+	}
+
+	// The same delegation is not valid for another service.
+	other, err := auth.GenerateHeader("https://other.example.com/rpc")
+	if err != nil {
+		t.Fatalf("GenerateHeader() error = %v", err)
+	}
+	if _, err := verifier.VerifyAuthHeader(other[AuthorizationHeader], "other.example.com"); !errors.Is(err, ErrDelegationInvalid) {
+		t.Errorf("VerifyAuthHeader(other audience) error = %v, want ErrDelegationInvalid", err)
+	}
+}
+
+// TestDelegation_SignatureCoversChain checks that a chain cannot be stripped
+// from, swapped into or added to a header signed by the actor.
+func TestDelegation_SignatureCoversChain(t *testing.T) {
+	user := newDelegationParty(t, "user.example.com")
+	other := newDelegationParty(t, "other-user.example.com")
+	agent := newDelegationParty(t, "orchestrator.example.com")
+
+	encode := func(issuer delegationParty) string {
+		t.Helper()
+		grant, err := IssueDelegation(issuer.key, issuer.doc, agent.doc.ID, "tool.example.com", time.Hour)
+		if err != nil {
+			t.Fatalf("IssueDelegation() error = %v", err)
+		}
+		encoded, err := DelegationChain{grant}.Encode()
+		if err != nil {
+			t.Fatalf("Encode() error = %v", err)
+		}
+		return encoded
+	}
+	userChain, otherChain := encode(user), encode(other)
+
+	jwtKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("GenerateKey() error = %v", err)
+	}
+	verifier, err := NewDidWbaVerifier(DidWbaVerifierConfig{
+		JWTPrivateKey:      jwtKey,
+		JWTPublicKey:       &jwtKey.PublicKey,
+		NonceValidator:     NewMemoryNonceValidator(time.Minute),
+		ResolveDIDDocument: resolverFor(t, user, other, agent),
+	})
+	if err != nil {
+		t.Fatalf("NewDidWbaVerifier() error = %v", err)
+	}
+	agentDoc, err := resolverFor(t, agent)(context.Background(), agent.doc.ID)
+	if err != nil {
+		t.Fatalf("resolve agent: %v", err)
+	}
+
+	tests := []struct {
+		name         string
+		signed, sent string
+		wantErr      error
+	}{
+		{"signed chain", userChain, userChain, nil},
+		{"stripped", userChain, "", ErrInvalidSignature},
+		{"swapped", userChain, otherChain, ErrInvalidSignature},
+		{"added", "", userChain, ErrInvalidSignature},
+	}
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			header, err := NewDelegatedAuthHeader(agent.key, agent.doc, "tool.example.com", fmt.Sprintf("nonce-%d", i), time.Now().UTC().Format(time.RFC3339), tt.signed)
+			if err != nil {
+				t.Fatalf("NewDelegatedAuthHeader() error = %v", err)
+			}
+			header.Delegation = tt.sent
+			_, err = verifier.VerifyAuthHeader(header.String(), "tool.example.com")
+			if tt.wantErr == nil && err != nil {
+				t.Fatalf("VerifyAuthHeader() error = %v", err)
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Errorf("VerifyAuthHeader() error = %v, want %v", err, tt.wantErr)
+			}
+
+			authJSON := &AuthJSON{
+				DID:                header.DID,
+				Nonce:              header.Nonce,
+				Timestamp:          header.Timestamp,
+				VerificationMethod: header.VerificationMethod,
+				Signature:          header.Signature,
+				Delegation:         header.Delegation,
+			}
+			if ok, message := VerifyAuthJSON(authJSON, agentDoc, "tool.example.com"); ok != (tt.wantErr == nil) {
+				t.Errorf("VerifyAuthJSON() = %v, %s", ok, message)
+			}
+		})
+	}
+}
+
+// TestDelegation_PolicyCoversChain checks that the allow and block lists
+// apply to every issuer and delegate in a chain, not only its subject.
+func TestDelegation_PolicyCoversChain(t *testing.T) {
+	user := newDelegationParty(t, "user.example.com")
+	agent := newDelegationParty(t, "orchestrator.example.com")
+	tool := newDelegationParty(t, "subagent.example.com")
+
+	issue := func(from delegationParty, to string) *Delegation {
+		d, err := IssueDelegation(from.key, from.doc, to, "svc.example.com", time.Hour)
+		if err != nil {
+			t.Fatalf("IssueDelegation() error = %v", err)
+		}
+		return d
+	}
+	chain := DelegationChain{issue(user, agent.doc.ID), issue(agent, tool.doc.ID)}
+	auth, err := NewAuthenticator(WithDIDMaterial(tool.doc, tool.key), WithDelegation(chain))
+	if err != nil {
+		t.Fatalf("NewAuthenticator() error = %v", err)
+	}
+	jwtKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("GenerateKey() error = %v", err)
+	}
+
+	tests := []struct {
+		name             string
+		allowed, blocked []string
+		wantErr          bool
+	}{
+		{"no policy", nil, nil, false},
+		{"all allowed", []string{user.doc.ID, agent.doc.ID, tool.doc.ID}, nil, false},
+		{"intermediary not allowed", []string{user.doc.ID, tool.doc.ID}, nil, true},
+		{"intermediary blocked", nil, []string{agent.doc.ID}, true},
+		{"subject blocked", nil, []string{user.doc.ID}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			verifier, err := NewDidWbaVerifier(DidWbaVerifierConfig{
+				JWTPrivateKey:      jwtKey,
+				JWTPublicKey:       &jwtKey.PublicKey,
+				NonceValidator:     NewMemoryNonceValidator(time.Minute),
+				ResolveDIDDocument: resolverFor(t, user, agent, tool),
+				AllowedDIDs:        tt.allowed,
+				BlockedDIDs:        tt.blocked,
+			})
+			if err != nil {
+				t.Fatalf("NewDidWbaVerifier() error = %v", err)
+			}
+			headers, err := auth.GenerateHeader("https://svc.example.com/rpc")
+			if err != nil {
+				t.Fatalf("GenerateHeader() error = %v", err)
+			}
+			_, err = verifier.VerifyAuthHeader(headers[AuthorizationHeader], "svc.example.com")
+			if tt.wantErr && !errors.Is(err, ErrDIDNotAllowed) {
+				t.Errorf("VerifyAuthHeader() error = %v, want ErrDIDNotAllowed", err)
+			}
+			if !tt.wantErr && err != nil {
+				t.Errorf("VerifyAuthHeader() error = %v", err)
+			}
+		})
+	}
+}
+
+func TestDelegationChain_Verify(t *testing.T) {
+	user := newDelegationParty(t, "user.example.com")
+	agent := newDelegationParty(t, "orchestrator.example.com")
+	tool := newDelegationParty(t, "subagent.example.com")
+	resolve := resolverFor(t, user, agent, tool)
+	now := time.Now()
+
+	issue := func(from delegationParty, to string) *Delegation {
+		d, err := IssueDelegation(from.key, from.doc, to, "", time.Hour)
+		if err != nil {
+			t.Fatalf("IssueDelegation() error = %v", err)
+		}
+		return d
+	}
+
+	chain := DelegationChain{issue(user, agent.doc.ID), issue(agent, tool.doc.ID)}
+	encoded, err := chain.Encode()
+	if err != nil {
+		t.Fatalf("Encode() error = %v", err)
+	}
+	decoded, err := DecodeDelegationChain(encoded)
+	if err != nil {
+		t.Fatalf("DecodeDelegationChain() error = %v", err)
+	}
+	if _, err := decoded.Verify(context.Background(), tool.doc.ID, "svc.example.com", now, resolve); err != nil {
+		t.Fatalf("Verify() error = %v", err)
+	}
+	if decoded.Subject() != user.doc.ID {
+		t.Errorf("Subject() = %s", decoded.Subject())
+	}
+
+	tampered := *chain[0]
+	tampered.Delegate = tool.doc.ID
+
+	tests := []struct {
+		name  string
+		chain DelegationChain
+		actor string
+		now   time.Time
+	}{
+		{"wrong actor", chain, agent.doc.ID, now},
+		{"expired", chain, tool.doc.ID, now.Add(2 * time.Hour)},
+		{"broken link", DelegationChain{chain[1], chain[0]}, agent.doc.ID, now},
+		{"tampered link", DelegationChain{&tampered}, tool.doc.ID, now},
+		{"self-issued by actor", DelegationChain{issue(tool, tool.doc.ID), chain[1]}, tool.doc.ID, now},
+		{"empty", nil, tool.doc.ID, now},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := tt.chain.Verify(context.Background(), tt.actor, "svc.example.com", tt.now, resolve); !errors.Is(err, ErrDelegationInvalid) {
+				t.Errorf("Verify() error = %v, want ErrDelegationInvalid", err)
+			}
+		})
+	}
+}
+
+func TestAuthHeader_DelegationRoundTrip(t *testing.T) {
+	h := &AuthHeader{DID: "did:wba:a.example.com", Nonce: "n", Timestamp: "t", VerificationMethod: "key-1", Signature: "sig", Delegation: "abc_-"}
+	if !strings.HasSuffix(h.String(), `, delegation="abc_-"`) {
+		t.Fatalf("String() = %s", h.String())
+	}
+	parsed, err := ParseAuthHeader(h.String())
+	if err != nil {
+		t.Fatalf("ParseAuthHeader() error = %v", err)
+	}
+	if *parsed != *h {
+		t.Errorf("ParseAuthHeader() = %+v, want %+v", parsed, h)
+	}
+
+	h.Delegation = ""
+	if strings.Contains(h.String(), "delegation") {
+		t.Errorf("String() without delegation = %s", h.String())
+	}
+}
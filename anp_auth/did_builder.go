@@ -0,0 +1,239 @@
+package anp_auth
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"encoding/base64"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/bytedance/sonic"
+)
+
+// DIDDocumentBuilder assembles DID documents with any number of verification
+// methods, authentication and assertion references, services and contexts.
+// Methods record the first error, which Build reports:
+//
+//	doc, err := anp_auth.NewDIDDocumentBuilder("did:wba:example.com:agents:alice").
+//		AddKey("key-1", signingKey.Public()).
+//		AddKey("key-2", rotationKey.Public()).
+//		Authentication("key-1", "key-2").
+//		AssertionMethod("key-1").
+//		AgentDescription("https://example.com/agents/alice/ad.json").
+//		Build()
+type DIDDocumentBuilder struct {
+	did            string
+	contexts       []string
+	methods        []map[string]any
+	authentication []string
+	assertion      []string
+	services       []Service
+	err            error
+}
+
+// NewDIDDocumentBuilder starts a document for did. The document carries the
+// same default contexts as CreateDIDWBADocument.
+func NewDIDDocumentBuilder(did string) *DIDDocumentBuilder {
+	b := &DIDDocumentBuilder{
+		did:      did,
+		contexts: []string{ContextDIDV1, ContextJWS2020, ContextSecp256k12019},
+	}
+	if _, err := DIDDocumentURL(did); err != nil {
+		b.err = err
+	}
+	return b
+}
+
+// NewDIDDocumentBuilderForHost starts a document for the did:wba identifier
+// built from hostname, port and path segments as by CreateDIDWBADocument.
+func NewDIDDocumentBuilderForHost(hostname string, port *int, pathSegments []string) *DIDDocumentBuilder {
+	if err := validateHostname(hostname); err != nil {
+		return &DIDDocumentBuilder{err: err}
+	}
+	did, err := buildDID(hostname, port, pathSegments)
+	if err != nil {
+		return &DIDDocumentBuilder{err: err}
+	}
+	return NewDIDDocumentBuilder(did)
+}
+
+// WithContext adds JSON-LD contexts after the defaults; duplicates are ignored.
+func (b *DIDDocumentBuilder) WithContext(contexts ...string) *DIDDocumentBuilder {
+	for _, c := range contexts {
+		if !slices.Contains(b.contexts, c) {
+			b.contexts = append(b.contexts, c)
+		}
+	}
+	return b
+}
+
+// AddKey adds a verification method for publicKey under fragment. secp256k1
+// and P-256 *ecdsa.PublicKey values get their EcdsaSecp256*VerificationKey2019
+// type; ed25519.PublicKey values are published as JsonWebKey2020.
+func (b *DIDDocumentBuilder) AddKey(fragment string, publicKey any) *DIDDocumentBuilder {
+	methodType, jwk, err := verificationKeyJWK(publicKey)
+	if err != nil {
+		b.fail(fmt.Errorf("verification method %q: %w", fragment, err))
+		return b
+	}
+	return b.AddVerificationMethod(map[string]any{
+		"id":           b.reference(fragment),
+		"type":         methodType,
+		"controller":   b.did,
+		"publicKeyJwk": jwk,
+	})
+}
+
+// AddVerificationMethod adds a verification method given as its JSON object,
+// for method types AddKey does not build. A relative "id" is resolved against
+// the DID and a missing "controller" defaults to it.
+func (b *DIDDocumentBuilder) AddVerificationMethod(method map[string]any) *DIDDocumentBuilder {
+	id, _ := method["id"].(string)
+	if id == "" {
+		b.fail(fmt.Errorf("verification method 'id' not found or not a string"))
+		return b
+	}
+	m := make(map[string]any, len(method)+1)
+	for k, v := range method {
+		m[k] = v
+	}
+	m["id"] = b.reference(id)
+	if _, ok := m["controller"]; !ok {
+		m["controller"] = b.did
+	}
+	b.methods = append(b.methods, m)
+	return b
+}
+
+// Authentication references verification methods, by fragment or full ID,
+// that may sign DID-WBA authentication headers.
+func (b *DIDDocumentBuilder) Authentication(refs ...string) *DIDDocumentBuilder {
+	for _, ref := range refs {
+		if ref = b.reference(ref); !slices.Contains(b.authentication, ref) {
+			b.authentication = append(b.authentication, ref)
+		}
+	}
+	return b
+}
+
+// AssertionMethod references verification methods, by fragment or full ID,
+// that may sign assertions such as content signatures.
+func (b *DIDDocumentBuilder) AssertionMethod(refs ...string) *DIDDocumentBuilder {
+	for _, ref := range refs {
+		if ref = b.reference(ref); !slices.Contains(b.assertion, ref) {
+			b.assertion = append(b.assertion, ref)
+		}
+	}
+	return b
+}
+
+// AddService adds a service; a relative ID is resolved against the DID.
+func (b *DIDDocumentBuilder) AddService(service Service) *DIDDocumentBuilder {
+	service.ID = b.reference(service.ID)
+	b.services = append(b.services, service)
+	return b
+}
+
+// AgentDescription adds the agent description service pointing at url.
+func (b *DIDDocumentBuilder) AgentDescription(url string) *DIDDocumentBuilder {
+	return b.AddService(Service{ID: AgentDescriptionFragment, Type: ServiceTypeAgentDescription, ServiceEndpoint: url})
+}
+
+// Build validates and returns the document. It requires at least one
+// verification method and one authentication reference, unique method and
+// service IDs, references that name methods of the document, and methods the
+// VerificationMethodFactory can load.
+func (b *DIDDocumentBuilder) Build() (*DIDWBADocument, error) {
+	if b.err != nil {
+		return nil, b.err
+	}
+	if len(b.methods) == 0 {
+		return nil, fmt.Errorf("DID document needs at least one verification method")
+	}
+	if len(b.authentication) == 0 {
+		return nil, fmt.Errorf("DID document needs at least one authentication method")
+	}
+
+	ids := make([]string, 0, len(b.methods))
+	for _, m := range b.methods {
+		id := m["id"].(string)
+		if slices.Contains(ids, id) {
+			return nil, fmt.Errorf("duplicate verification method %q", id)
+		}
+		var decoded map[string]any
+		raw, err := sonic.Marshal(m)
+		if err == nil {
+			err = sonic.Unmarshal(raw, &decoded)
+		}
+		if err == nil {
+			_, err = CreateVerificationMethod(decoded)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("verification method %q: %w", id, err)
+		}
+		ids = append(ids, id)
+	}
+	for _, ref := range slices.Concat(b.authentication, b.assertion) {
+		if !slices.Contains(ids, ref) {
+			return nil, fmt.Errorf("%w: %s", ErrVerificationMethodNotFound, ref)
+		}
+	}
+
+	serviceIDs := make([]string, 0, len(b.services))
+	for _, s := range b.services {
+		if s.Type == "" || s.ServiceEndpoint == "" {
+			return nil, fmt.Errorf("service %q needs a type and an endpoint", s.ID)
+		}
+		if slices.Contains(serviceIDs, s.ID) {
+			return nil, fmt.Errorf("duplicate service %q", s.ID)
+		}
+		serviceIDs = append(serviceIDs, s.ID)
+	}
+
+	return &DIDWBADocument{
+		Context:            slices.Clone(b.contexts),
+		ID:                 b.did,
+		VerificationMethod: slices.Clone(b.methods),
+		Authentication:     slices.Clone(b.authentication),
+		AssertionMethod:    slices.Clone(b.assertion),
+		Service:            slices.Clone(b.services),
+	}, nil
+}
+
+// reference resolves a fragment ("key-1" or "#key-1") against the DID; full
+// IDs are returned unchanged.
+func (b *DIDDocumentBuilder) reference(ref string) string {
+	if ref == "" || strings.HasPrefix(ref, "did:") {
+		return ref
+	}
+	return b.did + "#" + strings.TrimPrefix(ref, "#")
+}
+
+func (b *DIDDocumentBuilder) fail(err error) {
+	if b.err == nil {
+		b.err = err
+	}
+}
+
+// verificationKeyJWK returns the verification method type and publicKeyJwk
+// for a public key.
+func verificationKeyJWK(publicKey any) (string, map[string]any, error) {
+	switch key := publicKey.(type) {
+	case *ecdsa.PublicKey:
+		methodType, ok := ecdsaMethodTypes[key.Curve]
+		if !ok {
+			return "", nil, fmt.Errorf("unsupported curve for DID document: %s", key.Curve.Params().Name)
+		}
+		jwk := buildPublicKeyJWK(key)
+		return methodType, map[string]any{"kty": jwk.Kty, "crv": jwk.Crv, "x": jwk.X, "y": jwk.Y, "kid": jwk.Kid}, nil
+	case ed25519.PublicKey:
+		return VerificationMethodJsonWebKey2020, map[string]any{
+			"kty": JWKTypeOKP,
+			"crv": JWKCurveEd25519,
+			"x":   base64.RawURLEncoding.EncodeToString(key),
+			"kid": base64.RawURLEncoding.EncodeToString(hashSHA256(key)),
+		}, nil
+	}
+	return "", nil, fmt.Errorf("unsupported public key type %T", publicKey)
+}
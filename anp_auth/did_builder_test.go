@@ -0,0 +1,186 @@
+package anp_auth
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/bytedance/sonic"
+	"github.com/openanp/anp-go/crypto"
+)
+
+func TestDIDDocumentBuilder(t *testing.T) {
+	k1Key, err := crypto.GenerateECKeyPair(crypto.Secp256k1())
+	if err != nil {
+		t.Fatalf("GenerateECKeyPair() error = %v", err)
+	}
+	p256Key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey() error = %v", err)
+	}
+	edPub, edPriv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey() error = %v", err)
+	}
+
+	port := 8443
+	doc, err := NewDIDDocumentBuilderForHost("example.com", &port, []string{"agents", "alice"}).
+		WithContext("https://example.com/ns/v1", ContextDIDV1).
+		AddKey("key-1", &k1Key.PublicKey).
+		AddKey("#key-2", &p256Key.PublicKey).
+		AddKey("key-3", edPub).
+		Authentication("key-1", "key-2", "key-1").
+		AssertionMethod("key-3").
+		AgentDescription("https://example.com/agents/alice/ad.json").
+		AddService(Service{ID: "inbox", Type: "Inbox", ServiceEndpoint: "https://example.com/inbox"}).
+		Build()
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+
+	did := "did:wba:example.com:8443:agents:alice"
+	if doc.ID != did {
+		t.Errorf("ID = %q, want %q", doc.ID, did)
+	}
+	wantContexts := []string{ContextDIDV1, ContextJWS2020, ContextSecp256k12019, "https://example.com/ns/v1"}
+	if !slices.Equal(doc.Context, wantContexts) {
+		t.Errorf("Context = %v, want %v", doc.Context, wantContexts)
+	}
+	if want := []string{did + "#key-1", did + "#key-2"}; !slices.Equal(doc.Authentication, want) {
+		t.Errorf("Authentication = %v, want %v", doc.Authentication, want)
+	}
+	if want := []string{did + "#key-3"}; !slices.Equal(doc.AssertionMethod, want) {
+		t.Errorf("AssertionMethod = %v, want %v", doc.AssertionMethod, want)
+	}
+	if len(doc.Service) != 2 || doc.Service[1].ID != did+"#inbox" {
+		t.Errorf("Service = %+v", doc.Service)
+	}
+	wantTypes := []string{VerificationMethodEcdsaSecp256k1, VerificationMethodEcdsaSecp256r1, VerificationMethodJsonWebKey2020}
+	for i, m := range doc.VerificationMethod {
+		if m["type"] != wantTypes[i] || m["controller"] != did {
+			t.Errorf("VerificationMethod[%d] = %v", i, m)
+		}
+	}
+
+	// Every key verifies signatures, both in memory and after publishing.
+	data, err := sonic.Marshal(doc)
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	var published DIDWBADocument
+	if err := sonic.Unmarshal(data, &published); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	content := []byte("payload")
+	k1Sig, _ := SignContent(k1Key, content)
+	p256Sig, _ := SignContent(p256Key, content)
+	sigs := map[string]string{
+		"key-1": k1Sig,
+		"key-2": p256Sig,
+		"key-3": base64.RawURLEncoding.EncodeToString(ed25519.Sign(edPriv, content)),
+	}
+	for _, d := range []*DIDWBADocument{doc, &published} {
+		for fragment, sig := range sigs {
+			if err := VerifyContent(d, did+"#"+fragment, content, sig); err != nil {
+				t.Errorf("VerifyContent(%s) error = %v", fragment, err)
+			}
+		}
+	}
+
+	header, err := GenerateAuthHeader(k1Key, &published, "api.example.com")
+	if err != nil {
+		t.Fatalf("GenerateAuthHeader() error = %v", err)
+	}
+	if header.VerificationMethod != "key-1" {
+		t.Errorf("VerificationMethod = %q, want key-1", header.VerificationMethod)
+	}
+}
+
+func TestDIDDocumentBuilder_Errors(t *testing.T) {
+	key, err := crypto.GenerateECKeyPair(crypto.Secp256k1())
+	if err != nil {
+		t.Fatalf("GenerateECKeyPair() error = %v", err)
+	}
+	p384Key, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey() error = %v", err)
+	}
+	const did = "did:wba:example.com"
+
+	tests := []struct {
+		name    string
+		builder *DIDDocumentBuilder
+		wantErr error
+	}{
+		{
+			name:    "invalid DID",
+			builder: NewDIDDocumentBuilder("did:key:z6Mk").AddKey("key-1", &key.PublicKey).Authentication("key-1"),
+		},
+		{
+			name:    "empty hostname",
+			builder: NewDIDDocumentBuilderForHost("", nil, nil),
+		},
+		{
+			name:    "no verification method",
+			builder: NewDIDDocumentBuilder(did),
+		},
+		{
+			name:    "no authentication",
+			builder: NewDIDDocumentBuilder(did).AddKey("key-1", &key.PublicKey),
+		},
+		{
+			name:    "unsupported key",
+			builder: NewDIDDocumentBuilder(did).AddKey("key-1", &p384Key.PublicKey).Authentication("key-1"),
+		},
+		{
+			name: "duplicate method",
+			builder: NewDIDDocumentBuilder(did).
+				AddKey("key-1", &key.PublicKey).
+				AddKey("key-1", &key.PublicKey).
+				Authentication("key-1"),
+		},
+		{
+			name:    "dangling reference",
+			builder: NewDIDDocumentBuilder(did).AddKey("key-1", &key.PublicKey).Authentication("key-1").AssertionMethod("key-2"),
+			wantErr: ErrVerificationMethodNotFound,
+		},
+		{
+			name: "invalid custom method",
+			builder: NewDIDDocumentBuilder(did).
+				AddVerificationMethod(map[string]any{"id": "key-1", "type": "Multikey"}).
+				Authentication("key-1"),
+		},
+		{
+			name: "duplicate service",
+			builder: NewDIDDocumentBuilder(did).
+				AddKey("key-1", &key.PublicKey).
+				Authentication("key-1").
+				AgentDescription("https://example.com/a.json").
+				AgentDescription("https://example.com/b.json"),
+		},
+		{
+			name: "service without endpoint",
+			builder: NewDIDDocumentBuilder(did).
+				AddKey("key-1", &key.PublicKey).
+				Authentication("key-1").
+				AddService(Service{ID: "inbox", Type: "Inbox"}),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tt.builder.Build()
+			if err == nil {
+				t.Fatal("Build() error = nil, want error")
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Errorf("Build() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
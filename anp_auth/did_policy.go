@@ -0,0 +1,40 @@
+package anp_auth
+
+import (
+	"fmt"
+	"strings"
+)
+
+// matchDID reports whether did matches a policy entry: an exact DID, or a
+// prefix ending in "*" such as "did:wba:example.com:*".
+func matchDID(pattern, did string) bool {
+	if prefix, ok := strings.CutSuffix(pattern, "*"); ok {
+		return strings.HasPrefix(did, prefix)
+	}
+	return pattern == did
+}
+
+func matchesAnyDID(patterns []string, did string) bool {
+	for _, pattern := range patterns {
+		if matchDID(strings.TrimSpace(pattern), did) {
+			return true
+		}
+	}
+	return false
+}
+
+// ensureDIDAllowed applies BlockedDIDs and AllowedDIDs to every identity of a
+// request: the signer and, for delegated requests, the subject it acts for.
+// Blocked entries win over allowed ones.
+func (v *DidWbaVerifier) ensureDIDAllowed(dids ...string) error {
+	for _, did := range dids {
+		if did == "" {
+			continue
+		}
+		if matchesAnyDID(v.config.BlockedDIDs, did) ||
+			(len(v.config.AllowedDIDs) > 0 && !matchesAnyDID(v.config.AllowedDIDs, did)) {
+			return NewErrorWithStatus(fmt.Errorf("%w: %s", ErrDIDNotAllowed, did), StatusForbidden)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,105 @@
+package anp_auth
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestMatchDID(t *testing.T) {
+	tests := []struct {
+		pattern string
+		did     string
+		want    bool
+	}{
+		{"did:wba:example.com", "did:wba:example.com", true},
+		{"did:wba:example.com", "did:wba:example.com:user:alice", false},
+		{"did:wba:example.com:*", "did:wba:example.com:user:alice", true},
+		{"did:wba:example.com:*", "did:wba:example.com", false},
+		{"did:wba:example.com:*", "did:wba:example.com.evil.io", false},
+		{"did:wba:*", "did:web:example.com", false},
+		{"*", "did:web:example.com", true},
+	}
+	for _, tt := range tests {
+		if got := matchDID(tt.pattern, tt.did); got != tt.want {
+			t.Errorf("matchDID(%q, %q) = %v, want %v", tt.pattern, tt.did, got, tt.want)
+		}
+	}
+}
+
+func TestVerifierDIDPolicy(t *testing.T) {
+	allowed := newDelegationParty(t, "partner.example.com")
+	blocked := newDelegationParty(t, "partner.example.com:agents:rogue")
+	stranger := newDelegationParty(t, "stranger.example.com")
+
+	jwtKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("GenerateKey() error = %v", err)
+	}
+
+	newVerifier := func(t *testing.T, allowedDIDs, blockedDIDs []string) *DidWbaVerifier {
+		t.Helper()
+		v, err := NewDidWbaVerifier(DidWbaVerifierConfig{
+			JWTPrivateKey:      jwtKey,
+			JWTPublicKey:       &jwtKey.PublicKey,
+			AllowedDIDs:        allowedDIDs,
+			BlockedDIDs:        blockedDIDs,
+			NonceValidator:     NewMemoryNonceValidator(time.Minute),
+			ResolveDIDDocument: resolverFor(t, allowed, blocked, stranger),
+		})
+		if err != nil {
+			t.Fatalf("NewDidWbaVerifier() error = %v", err)
+		}
+		return v
+	}
+	verify := func(t *testing.T, v *DidWbaVerifier, party delegationParty) (map[string]any, error) {
+		t.Helper()
+		auth, err := NewAuthenticator(WithDIDMaterial(party.doc, party.key))
+		if err != nil {
+			t.Fatalf("NewAuthenticator() error = %v", err)
+		}
+		headers, err := auth.GenerateHeader("https://api.example.com/rpc")
+		if err != nil {
+			t.Fatalf("GenerateHeader() error = %v", err)
+		}
+		return v.VerifyAuthHeader(headers[AuthorizationHeader], "api.example.com")
+	}
+
+	v := newVerifier(t, []string{"did:wba:partner.example.com", "did:wba:partner.example.com:*"}, []string{blocked.doc.ID})
+	tests := []struct {
+		name    string
+		party   delegationParty
+		wantErr bool
+	}{
+		{"allowed", allowed, false},
+		{"blocked wins over allowed prefix", blocked, true},
+		{"not allowed", stranger, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := verify(t, v, tt.party)
+			if !tt.wantErr {
+				if err != nil {
+					t.Errorf("VerifyAuthHeader() error = %v", err)
+				}
+				return
+			}
+			var statusErr *ErrorWithStatus
+			if !errors.Is(err, ErrDIDNotAllowed) || !errors.As(err, &statusErr) || statusErr.StatusCode != StatusForbidden {
+				t.Errorf("VerifyAuthHeader() error = %v, want 403 ErrDIDNotAllowed", err)
+			}
+		})
+	}
+
+	// Blocking a DID also rejects tokens it was issued earlier.
+	result, err := verify(t, v, allowed)
+	if err != nil {
+		t.Fatalf("VerifyAuthHeader() error = %v", err)
+	}
+	later := newVerifier(t, nil, []string{"did:wba:partner.example.com"})
+	if _, err := later.VerifyAuthHeader(BearerScheme+result["access_token"].(string), "api.example.com"); !errors.Is(err, ErrDIDNotAllowed) {
+		t.Errorf("VerifyAuthHeader(bearer) error = %v, want ErrDIDNotAllowed", err)
+	}
+}
@@ -0,0 +1,30 @@
+package anp_auth
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// ResolveDIDWebDocument resolves a did:web identifier. Per the did:web
+// method, "did:web:example.com" maps to https://example.com/.well-known/did.json
+// and "did:web:example.com:user:alice" to https://example.com/user/alice/did.json;
+// a port is written percent-encoded, as in "did:web:example.com%3A8443".
+func ResolveDIDWebDocument(did string, httpClient ...*http.Client) (*DIDWBADocument, error) {
+	if !strings.HasPrefix(did, DIDWebPrefix) {
+		return nil, fmt.Errorf("invalid DID format: must start with '%s'", DIDWebPrefix)
+	}
+	return fetchDIDDocument(did, httpClient...)
+}
+
+// ResolveDIDDocument resolves a did:wba or did:web identifier. It is the
+// default resolver of DidWbaVerifier, so clients may present either method.
+func ResolveDIDDocument(did string, httpClient ...*http.Client) (*DIDWBADocument, error) {
+	switch {
+	case strings.HasPrefix(did, DIDPrefix):
+		return ResolveDIDWBADocument(did, httpClient...)
+	case strings.HasPrefix(did, DIDWebPrefix):
+		return ResolveDIDWebDocument(did, httpClient...)
+	}
+	return nil, fmt.Errorf("%w: unsupported DID method in %s", ErrInvalidDIDFormat, did)
+}
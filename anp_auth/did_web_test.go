@@ -0,0 +1,102 @@
+package anp_auth
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bytedance/sonic"
+)
+
+func TestDIDDocumentURL_DIDWeb(t *testing.T) {
+	tests := []struct {
+		did  string
+		want string
+	}{
+		{"did:web:example.com", "https://example.com/.well-known/did.json"},
+		{"did:web:example.com:user:alice", "https://example.com/user/alice/did.json"},
+		{"did:web:example.com%3A8443", "https://example.com:8443/.well-known/did.json"},
+	}
+	for _, tt := range tests {
+		got, err := DIDDocumentURL(tt.did)
+		if err != nil || got != tt.want {
+			t.Errorf("DIDDocumentURL(%q) = %q, %v, want %q", tt.did, got, err, tt.want)
+		}
+	}
+	if _, err := ResolveDIDDocument("did:key:z6Mk"); err == nil {
+		t.Error("ResolveDIDDocument(did:key) succeeded")
+	}
+}
+
+func TestVerifier_DIDWebClient(t *testing.T) {
+	var hits int
+	var served []byte
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/agents/alice/did.json" {
+			http.NotFound(w, r)
+			return
+		}
+		hits++
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(served)
+	}))
+	defer srv.Close()
+
+	// did:web documents have the same shape as did:wba ones; only the
+	// identifiers differ.
+	u, _ := url.Parse(srv.URL)
+	host := strings.ReplaceAll(u.Host, ":", "%3A")
+	wba, key, err := CreateDIDWBADocument("placeholder.example.com", nil, []string{"agents", "alice"}, nil)
+	if err != nil {
+		t.Fatalf("CreateDIDWBADocument() error = %v", err)
+	}
+	raw, _ := sonic.Marshal(wba)
+	served = []byte(strings.ReplaceAll(string(raw), "did:wba:placeholder.example.com", "did:web:"+host))
+	var doc DIDWBADocument
+	if err := sonic.Unmarshal(served, &doc); err != nil {
+		t.Fatalf("decode did:web document: %v", err)
+	}
+	if !strings.HasPrefix(doc.ID, DIDWebPrefix) {
+		t.Fatalf("doc.ID = %s", doc.ID)
+	}
+
+	auth, err := NewAuthenticator(WithDIDMaterial(&doc, key))
+	if err != nil {
+		t.Fatalf("NewAuthenticator() error = %v", err)
+	}
+	jwtKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("GenerateKey() error = %v", err)
+	}
+	verifier, err := NewDidWbaVerifier(DidWbaVerifierConfig{
+		JWTPrivateKey:  jwtKey,
+		JWTPublicKey:   &jwtKey.PublicKey,
+		NonceValidator: NewMemoryNonceValidator(time.Minute),
+		HTTPClient:     srv.Client(),
+	})
+	if err != nil {
+		t.Fatalf("NewDidWbaVerifier() error = %v", err)
+	}
+
+	for range 2 {
+		headers, err := auth.GenerateHeaderForce("https://api.example.com/rpc")
+		if err != nil {
+			t.Fatalf("GenerateHeaderForce() error = %v", err)
+		}
+		result, err := verifier.VerifyAuthHeader(headers[AuthorizationHeader], "api.example.com")
+		if err != nil {
+			t.Fatalf("VerifyAuthHeader() error = %v", err)
+		}
+		if result["did"] != doc.ID {
+			t.Errorf("did = %v, want %s", result["did"], doc.ID)
+		}
+	}
+	if hits != 1 {
+		t.Errorf("DID document fetched %d times, want 1 (cached)", hits)
+	}
+}
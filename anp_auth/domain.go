@@ -0,0 +1,32 @@
+package anp_auth
+
+import (
+	"net"
+	"strings"
+)
+
+// matchDomain reports whether the requested domain (host, optionally with a
+// port) matches an AllowedDomains pattern, case-insensitively. A leading "*."
+// matches any subdomain, but not the bare parent domain. A pattern without a
+// port matches any port; with a port, the port must match too, and "*"
+// accepts any port.
+func matchDomain(pattern, domain string) bool {
+	patternHost, patternPort := splitDomain(strings.TrimSpace(pattern))
+	host, port := splitDomain(domain)
+	if patternPort != "" && patternPort != "*" && patternPort != port {
+		return false
+	}
+	if suffix, ok := strings.CutPrefix(patternHost, "*."); ok {
+		return len(host) > len(suffix)+1 && strings.HasSuffix(host, "."+suffix)
+	}
+	return host == patternHost
+}
+
+// splitDomain lower-cases a domain and separates its port, if any.
+func splitDomain(domain string) (host, port string) {
+	domain = strings.ToLower(domain)
+	if h, p, err := net.SplitHostPort(domain); err == nil {
+		return h, p
+	}
+	return strings.Trim(domain, "[]"), ""
+}
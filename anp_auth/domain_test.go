@@ -0,0 +1,47 @@
+package anp_auth
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestMatchDomain(t *testing.T) {
+	tests := []struct {
+		pattern string
+		domain  string
+		want    bool
+	}{
+		{"example.com", "example.com", true},
+		{"Example.COM", "example.com", true},
+		{"example.com", "example.com:8443", true},
+		{"example.com", "api.example.com", false},
+		{"example.com:8443", "example.com:8443", true},
+		{"example.com:8443", "example.com:9443", false},
+		{"example.com:8443", "example.com", false},
+		{"example.com:*", "example.com:9443", true},
+		{"*.agent-connect.ai", "hotel.agent-connect.ai", true},
+		{"*.agent-connect.ai", "a.b.agent-connect.ai:443", true},
+		{"*.agent-connect.ai", "agent-connect.ai", false},
+		{"*.agent-connect.ai", "evilagent-connect.ai", false},
+		{"*.agent-connect.ai:443", "hotel.agent-connect.ai:8443", false},
+		{"[::1]:8080", "[::1]:8080", true},
+		{"::1", "[::1]:8080", true},
+	}
+	for _, tt := range tests {
+		if got := matchDomain(tt.pattern, tt.domain); got != tt.want {
+			t.Errorf("matchDomain(%q, %q) = %v, want %v", tt.pattern, tt.domain, got, tt.want)
+		}
+	}
+}
+
+func TestEnsureDomainAllowed(t *testing.T) {
+	tn := &tenant{allowedDomains: []string{"api.example.com", " *.agents.example.com "}}
+	for _, domain := range []string{"api.example.com:8443", "travel.agents.example.com"} {
+		if err := ensureDomainAllowed(tn, domain); err != nil {
+			t.Errorf("ensureDomainAllowed(%q) error = %v", domain, err)
+		}
+	}
+	if err := ensureDomainAllowed(tn, "agents.example.com"); !errors.Is(err, ErrDomainNotAllowed) {
+		t.Errorf("ensureDomainAllowed(parent) error = %v, want ErrDomainNotAllowed", err)
+	}
+}
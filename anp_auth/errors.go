@@ -39,6 +39,9 @@ var (
 	// ErrDomainNotAllowed is returned when the request domain is not in the allowed list
 	ErrDomainNotAllowed = errors.New("domain not allowed")
 
+	// ErrDIDNotAllowed is returned when the DID is blocked or not in the allowed list
+	ErrDIDNotAllowed = errors.New("DID not allowed")
+
 	// ErrDIDMismatch is returned when the DID in the signature doesn't match the document
 	ErrDIDMismatch = errors.New("DID mismatch")
 
@@ -80,6 +83,32 @@ var (
 
 	// ErrTokenCreation is returned when access token creation fails
 	ErrTokenCreation = errors.New("failed to create access token")
+
+	// ErrDelegationInvalid is returned when an on-behalf-of delegation chain is rejected
+	ErrDelegationInvalid = errors.New("invalid delegation")
+
+	// ErrRefreshDisabled is returned when a refresh token is presented to a
+	// verifier that does not issue them
+	ErrRefreshDisabled = errors.New("refresh tokens not enabled")
+
+	// ErrTokenRevoked is returned when a token has been revoked before its expiry
+	ErrTokenRevoked = errors.New("token revoked")
+
+	// ErrTokenRevokerMissing is returned by Revoke when no TokenRevoker is configured
+	ErrTokenRevokerMissing = errors.New("token revoker not configured")
+
+	// ErrTokenRevokerFailure is returned when the token revoker encounters an error
+	ErrTokenRevokerFailure = errors.New("token revoker error")
+
+	// ErrChallengeStoreMissing is returned by IssueChallenge when no ChallengeStore is configured
+	ErrChallengeStoreMissing = errors.New("challenge store not configured")
+
+	// ErrChallengeStoreFailure is returned when the challenge store encounters an error
+	ErrChallengeStoreFailure = errors.New("challenge store error")
+
+	// ErrResponseProofInvalid is returned by the client when a server's mutual
+	// authentication proof is missing or does not verify
+	ErrResponseProofInvalid = errors.New("invalid server response proof")
 )
 
 // Common error wrapping helpers
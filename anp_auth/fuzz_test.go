@@ -0,0 +1,56 @@
+package anp_auth
+
+import (
+	"testing"
+)
+
+const fuzzSeedHeader = `DIDWba did="did:wba:cross-verify.agent-network:agents:cross", nonce="30578cc7-d163-422a-adfc-721edb88151b", timestamp="2025-10-30T05:28:25Z", verification_method="key-1", signature="Es2LIe7JrwNdophpS64T9SroV7XCxrho2CWRCH43NO4Z0JiNdjr7a7tb2iwGz_F0P4-ZS45z_xlo2Zi0-3UbaQ"`
+
+// FuzzParseAuthHeader checks that any header the parser accepts survives a
+// String/parse round trip unchanged.
+func FuzzParseAuthHeader(f *testing.F) {
+	f.Add(fuzzSeedHeader)
+	f.Add(fuzzSeedHeader + `, delegation="eyJhIjoxfQ"`)
+	f.Add(`DIDWba did="", nonce="n"`)
+	f.Add(`Bearer abc.def.ghi`)
+
+	f.Fuzz(func(t *testing.T, header string) {
+		parsed, err := parseAuthHeader(header)
+		if err != nil {
+			return
+		}
+		again, err := parseAuthHeader(parsed.String())
+		if err != nil {
+			t.Fatalf("re-parse %q: %v", parsed.String(), err)
+		}
+		if *again != *parsed {
+			t.Fatalf("round trip changed header: %+v != %+v", again, parsed)
+		}
+	})
+}
+
+// FuzzParseAuthJSON checks that any accepted payload survives a
+// Marshal/ParseAuthJSON round trip unchanged.
+func FuzzParseAuthJSON(f *testing.F) {
+	f.Add([]byte(`{"did":"did:wba:a.example","nonce":"n","timestamp":"2025-10-30T05:28:25Z","verification_method":"key-1","signature":"c2ln"}`))
+	f.Add([]byte(`{"did":"did:wba:a.example"}`))
+	f.Add([]byte(`null`))
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		parsed, err := ParseAuthJSON(data)
+		if err != nil {
+			return
+		}
+		encoded, err := parsed.Marshal()
+		if err != nil {
+			t.Fatalf("Marshal() error = %v", err)
+		}
+		again, err := ParseAuthJSON(encoded)
+		if err != nil {
+			t.Fatalf("re-parse %s: %v", encoded, err)
+		}
+		if *again != *parsed {
+			t.Fatalf("round trip changed payload: %+v != %+v", again, parsed)
+		}
+	})
+}
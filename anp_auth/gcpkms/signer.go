@@ -0,0 +1,106 @@
+// Package gcpkms provides a crypto.Signer backed by a Google Cloud KMS
+// asymmetric signing key version, the Cloud KMS counterpart of
+// anp_auth/awskms.
+//
+// It is a separate module so the core SDK does not depend on the Cloud SDK:
+//
+//	client, _ := kms.NewKeyManagementClient(ctx)
+//	signer, _ := gcpkms.NewSigner(ctx, client,
+//		"projects/p/locations/global/keyRings/agents/cryptoKeys/did/cryptoKeyVersions/1")
+//	auth, _ := anp_auth.NewAuthenticator(anp_auth.WithDIDSigner("did.json", signer))
+//
+// Key versions must use EC_SIGN_SECP256K1_SHA256 or EC_SIGN_P256_SHA256.
+package gcpkms
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"encoding/pem"
+	"fmt"
+	"hash/crc32"
+	"io"
+
+	"cloud.google.com/go/kms/apiv1/kmspb"
+	"github.com/googleapis/gax-go/v2"
+	anpcrypto "github.com/openanp/anp-go/crypto"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+// Client is the part of the Cloud KMS API the signer uses;
+// *kms.KeyManagementClient implements it.
+type Client interface {
+	GetPublicKey(ctx context.Context, req *kmspb.GetPublicKeyRequest, opts ...gax.CallOption) (*kmspb.PublicKey, error)
+	AsymmetricSign(ctx context.Context, req *kmspb.AsymmetricSignRequest, opts ...gax.CallOption) (*kmspb.AsymmetricSignResponse, error)
+}
+
+var _ crypto.Signer = (*Signer)(nil)
+
+// Signer signs SHA-256 digests with a Cloud KMS key version. Cloud KMS returns
+// ASN.1 DER signatures, which anp_auth.SignContent converts to R||S.
+type Signer struct {
+	client    Client
+	name      string
+	publicKey *ecdsa.PublicKey
+}
+
+var crc32c = crc32.MakeTable(crc32.Castagnoli)
+
+// NewSigner fetches the public key of the key version resource name and checks
+// that it can sign for a DID document.
+func NewSigner(ctx context.Context, client Client, name string) (*Signer, error) {
+	out, err := client.GetPublicKey(ctx, &kmspb.GetPublicKeyRequest{Name: name})
+	if err != nil {
+		return nil, fmt.Errorf("get Cloud KMS public key: %w", err)
+	}
+	switch out.GetAlgorithm() {
+	case kmspb.CryptoKeyVersion_EC_SIGN_SECP256K1_SHA256, kmspb.CryptoKeyVersion_EC_SIGN_P256_SHA256:
+	default:
+		return nil, fmt.Errorf("unsupported Cloud KMS algorithm for DID document: %s", out.GetAlgorithm())
+	}
+	if crc := out.GetPemCrc32C(); crc != nil && int64(crc32.Checksum([]byte(out.GetPem()), crc32c)) != crc.GetValue() {
+		return nil, fmt.Errorf("Cloud KMS public key corrupted in transit")
+	}
+	block, _ := pem.Decode([]byte(out.GetPem()))
+	if block == nil {
+		return nil, fmt.Errorf("decode Cloud KMS public key: no PEM block")
+	}
+	publicKey, err := anpcrypto.PublicKeyFromDER(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("decode Cloud KMS public key: %w", err)
+	}
+	return &Signer{client: client, name: name, publicKey: publicKey}, nil
+}
+
+// Public returns the *ecdsa.PublicKey of the key version.
+func (s *Signer) Public() crypto.PublicKey {
+	return s.publicKey
+}
+
+// Sign signs a SHA-256 digest in Cloud KMS. rand is unused.
+func (s *Signer) Sign(_ io.Reader, digest []byte, opts crypto.SignerOpts) ([]byte, error) {
+	return s.SignContext(context.Background(), digest, opts)
+}
+
+// SignContext is Sign with a context for the Cloud KMS request. Checksums of
+// the digest and signature guard against corruption in transit.
+func (s *Signer) SignContext(ctx context.Context, digest []byte, opts crypto.SignerOpts) ([]byte, error) {
+	if opts == nil || opts.HashFunc() != crypto.SHA256 || len(digest) != crypto.SHA256.Size() {
+		return nil, fmt.Errorf("Cloud KMS signer only signs SHA-256 digests")
+	}
+	out, err := s.client.AsymmetricSign(ctx, &kmspb.AsymmetricSignRequest{
+		Name:         s.name,
+		Digest:       &kmspb.Digest{Digest: &kmspb.Digest_Sha256{Sha256: digest}},
+		DigestCrc32C: wrapperspb.Int64(int64(crc32.Checksum(digest, crc32c))),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("Cloud KMS sign: %w", err)
+	}
+	if !out.GetVerifiedDigestCrc32C() {
+		return nil, fmt.Errorf("Cloud KMS sign: digest corrupted in transit")
+	}
+	if int64(crc32.Checksum(out.GetSignature(), crc32c)) != out.GetSignatureCrc32C().GetValue() {
+		return nil, fmt.Errorf("Cloud KMS sign: signature corrupted in transit")
+	}
+	return out.GetSignature(), nil
+}
@@ -0,0 +1,107 @@
+package gcpkms
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"encoding/pem"
+	"hash/crc32"
+	"testing"
+
+	"cloud.google.com/go/kms/apiv1/kmspb"
+	"github.com/googleapis/gax-go/v2"
+	"github.com/openanp/anp-go/anp_auth"
+	anpcrypto "github.com/openanp/anp-go/crypto"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+// fakeKMS signs with a local key the way Cloud KMS does.
+type fakeKMS struct {
+	key       *ecdsa.PrivateKey
+	algorithm kmspb.CryptoKeyVersion_CryptoKeyVersionAlgorithm
+	corrupt   bool
+}
+
+func (f *fakeKMS) GetPublicKey(_ context.Context, req *kmspb.GetPublicKeyRequest, _ ...gax.CallOption) (*kmspb.PublicKey, error) {
+	der, err := anpcrypto.PublicKeyToDER(&f.key.PublicKey)
+	if err != nil {
+		return nil, err
+	}
+	pemBytes := pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})
+	return &kmspb.PublicKey{
+		Name:      req.GetName(),
+		Pem:       string(pemBytes),
+		PemCrc32C: wrapperspb.Int64(int64(crc32.Checksum(pemBytes, crc32c))),
+		Algorithm: f.algorithm,
+	}, nil
+}
+
+func (f *fakeKMS) AsymmetricSign(_ context.Context, req *kmspb.AsymmetricSignRequest, _ ...gax.CallOption) (*kmspb.AsymmetricSignResponse, error) {
+	digest := req.GetDigest().GetSha256()
+	sig, err := ecdsa.SignASN1(rand.Reader, f.key, digest)
+	if err != nil {
+		return nil, err
+	}
+	crc := int64(crc32.Checksum(sig, crc32c))
+	if f.corrupt {
+		crc++
+	}
+	return &kmspb.AsymmetricSignResponse{
+		Name:                 req.GetName(),
+		Signature:            sig,
+		SignatureCrc32C:      wrapperspb.Int64(crc),
+		VerifiedDigestCrc32C: req.GetDigestCrc32C().GetValue() == int64(crc32.Checksum(digest, crc32c)),
+	}, nil
+}
+
+const keyVersion = "projects/p/locations/global/keyRings/r/cryptoKeys/did/cryptoKeyVersions/1"
+
+func TestSigner(t *testing.T) {
+	tests := []struct {
+		name      string
+		curve     func() *ecdsa.PrivateKey
+		algorithm kmspb.CryptoKeyVersion_CryptoKeyVersionAlgorithm
+	}{
+		{"secp256k1", func() *ecdsa.PrivateKey { k, _ := anpcrypto.GenerateECKeyPair(anpcrypto.Secp256k1()); return k }, kmspb.CryptoKeyVersion_EC_SIGN_SECP256K1_SHA256},
+		{"P-256", func() *ecdsa.PrivateKey { k, _ := anpcrypto.GenerateECKeyPair(anpcrypto.P256()); return k }, kmspb.CryptoKeyVersion_EC_SIGN_P256_SHA256},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &fakeKMS{key: tt.curve(), algorithm: tt.algorithm}
+			signer, err := NewSigner(context.Background(), client, keyVersion)
+			if err != nil {
+				t.Fatalf("NewSigner() error = %v", err)
+			}
+
+			doc, err := anp_auth.NewDIDDocumentBuilderForHost("agent.example.com", nil, nil).
+				AddKey("key-1", signer.Public()).
+				Authentication("key-1").
+				Build()
+			if err != nil {
+				t.Fatalf("Build() error = %v", err)
+			}
+			content := []byte("payload")
+			sig, err := anp_auth.SignContent(signer, content)
+			if err != nil {
+				t.Fatalf("SignContent() error = %v", err)
+			}
+			if err := anp_auth.VerifyContent(doc, doc.ID+"#key-1", content, sig); err != nil {
+				t.Errorf("VerifyContent() error = %v", err)
+			}
+
+			client.corrupt = true
+			if _, err := anp_auth.SignContent(signer, content); err == nil {
+				t.Error("SignContent() with corrupted signature error = nil, want error")
+			}
+		})
+	}
+}
+
+func TestNewSigner_RejectsAlgorithm(t *testing.T) {
+	key, _ := anpcrypto.GenerateECKeyPair(anpcrypto.P256())
+	client := &fakeKMS{key: key, algorithm: kmspb.CryptoKeyVersion_RSA_SIGN_PSS_2048_SHA256}
+	if _, err := NewSigner(context.Background(), client, keyVersion); err == nil {
+		t.Error("NewSigner() error = nil, want error")
+	}
+}
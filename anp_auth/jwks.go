@@ -0,0 +1,243 @@
+package anp_auth
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/bytedance/sonic"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// maxJWKSSize bounds the JWKS document read from a remote URL.
+const maxJWKSSize = 1 << 20
+
+// jwksMinRefetch limits refetches triggered by tokens naming an unknown kid,
+// so forged kids cannot make every request hit the JWKS endpoint.
+const jwksMinRefetch = 30 * time.Second
+
+// JWKS is a set of JWT verification keys selected by key ID ("kid"). A JWKS
+// loaded from a URL is refetched every refresh interval, and early when a
+// token names a kid it does not know, so issuers can rotate keys without the
+// verifier restarting.
+type JWKS struct {
+	url      string
+	client   *http.Client
+	interval time.Duration
+	now      func() time.Time
+
+	mu        sync.Mutex
+	keys      map[string]jwksKey
+	fetchedAt time.Time
+}
+
+// jwksKey is one verification key and the algorithm it is restricted to.
+type jwksKey struct {
+	key any
+	alg string
+}
+
+// jsonWebKey is the subset of RFC 7517 members needed for JWT verification keys.
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid,omitempty"`
+	Use string `json:"use,omitempty"`
+	Alg string `json:"alg,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+	Y   string `json:"y,omitempty"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+}
+
+// ParseJWKS loads a static JWKS document ({"keys": [...]}). Keys of
+// unsupported types and encryption keys are skipped.
+func ParseJWKS(data []byte) (*JWKS, error) {
+	keys, err := parseJWKSKeys(data)
+	if err != nil {
+		return nil, err
+	}
+	return &JWKS{keys: keys, now: time.Now}, nil
+}
+
+// NewRemoteJWKS returns a JWKS fetched from url on first use and refetched
+// every refresh interval (DefaultJWKSRefreshInterval when zero). A nil client
+// uses http.DefaultClient.
+func NewRemoteJWKS(url string, client *http.Client, refresh time.Duration) *JWKS {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	if refresh <= 0 {
+		refresh = DefaultJWKSRefreshInterval
+	}
+	return &JWKS{url: url, client: client, interval: refresh, now: time.Now}
+}
+
+// Key returns the verification key with the given kid and the algorithm the
+// key is restricted to, empty if the JWK has no "alg". An empty kid selects
+// the only key of a single-key set.
+func (s *JWKS) Key(ctx context.Context, kid string) (any, string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var fetchErr error
+	if s.url != "" {
+		age := s.now().Sub(s.fetchedAt)
+		_, known := s.lookup(kid)
+		if s.keys == nil || age >= s.interval || (!known && age >= jwksMinRefetch) {
+			fetchErr = s.fetch(ctx)
+		}
+	}
+
+	entry, ok := s.lookup(kid)
+	if !ok {
+		if fetchErr != nil {
+			return nil, "", fetchErr
+		}
+		return nil, "", fmt.Errorf("no JWKS key with kid %q", kid)
+	}
+	return entry.key, entry.alg, nil
+}
+
+func (s *JWKS) lookup(kid string) (jwksKey, bool) {
+	if kid == "" && len(s.keys) == 1 {
+		for _, entry := range s.keys {
+			return entry, true
+		}
+	}
+	entry, ok := s.keys[kid]
+	return entry, ok
+}
+
+// fetch replaces the keys with the current remote document. On failure the
+// previous keys are kept so a JWKS outage does not reject valid tokens.
+func (s *JWKS) fetch(ctx context.Context) error {
+	// Whatever the outcome, wait for the next interval before trying again.
+	s.fetchedAt = s.now()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.url, nil)
+	if err != nil {
+		return fmt.Errorf("create JWKS request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("fetch JWKS: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("fetch JWKS: unexpected status %d", resp.StatusCode)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxJWKSSize))
+	if err != nil {
+		return fmt.Errorf("read JWKS: %w", err)
+	}
+	keys, err := parseJWKSKeys(data)
+	if err != nil {
+		return err
+	}
+	s.keys = keys
+	return nil
+}
+
+// keyfunc selects the key for a token from the set, falling back to the static
+// key for tokens without a kid. Tokens must use the algorithm named by their
+// JWK, or algorithm when the JWK names none.
+func (s *JWKS) keyfunc(ctx context.Context, fallback any, algorithm string) jwt.Keyfunc {
+	return func(token *jwt.Token) (any, error) {
+		kid, _ := token.Header["kid"].(string)
+		key, alg := fallback, algorithm
+		if kid != "" || fallback == nil {
+			var err error
+			if key, alg, err = s.Key(ctx, kid); err != nil {
+				return nil, err
+			}
+			if alg == "" {
+				alg = algorithm
+			}
+		}
+		if jwt.GetSigningMethod(alg) != token.Method {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+		return key, nil
+	}
+}
+
+func parseJWKSKeys(data []byte) (map[string]jwksKey, error) {
+	var doc struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if err := sonic.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("decode JWKS: %w", err)
+	}
+	keys := make(map[string]jwksKey, len(doc.Keys))
+	for _, jwk := range doc.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		key, err := jwk.publicKey()
+		if err != nil {
+			return nil, fmt.Errorf("JWKS key %q: %w", jwk.Kid, err)
+		}
+		if key != nil {
+			keys[jwk.Kid] = jwksKey{key: key, alg: jwk.Alg}
+		}
+	}
+	return keys, nil
+}
+
+// publicKey decodes the JWK, returning nil for key types JWTs cannot use.
+func (k jsonWebKey) publicKey() (any, error) {
+	switch k.Kty {
+	case JWKTypeRSA:
+		n, err := base64.RawURLEncoding.DecodeString(k.N)
+		if err != nil {
+			return nil, fmt.Errorf("invalid JWK 'n': %w", err)
+		}
+		e, err := base64.RawURLEncoding.DecodeString(k.E)
+		if err != nil {
+			return nil, fmt.Errorf("invalid JWK 'e': %w", err)
+		}
+		exponent := new(big.Int).SetBytes(e)
+		if !exponent.IsInt64() || exponent.Int64() > 1<<31-1 || exponent.Int64() < 3 {
+			return nil, fmt.Errorf("invalid RSA exponent")
+		}
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(exponent.Int64())}, nil
+	case JWKTypeEC:
+		curves := map[string]elliptic.Curve{
+			JWKCurveP256: elliptic.P256(),
+			JWKCurveP384: elliptic.P384(),
+			JWKCurveP521: elliptic.P521(),
+		}
+		curve, ok := curves[k.Crv]
+		if !ok {
+			return nil, nil
+		}
+		key, err := ecdsaKeyFromJWK(JWK{Kty: k.Kty, Crv: k.Crv, X: k.X, Y: k.Y}, curve)
+		if err != nil {
+			return nil, err
+		}
+		return key, nil
+	case JWKTypeOKP:
+		if k.Crv != JWKCurveEd25519 {
+			return nil, nil
+		}
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil {
+			return nil, fmt.Errorf("invalid JWK 'x' coordinate: %w", err)
+		}
+		if len(x) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("invalid Ed25519 public key length: %d", len(x))
+		}
+		return ed25519.PublicKey(x), nil
+	}
+	return nil, nil
+}
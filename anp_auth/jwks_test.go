@@ -0,0 +1,175 @@
+package anp_auth
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/bytedance/sonic"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func rsaJWK(kid string, key *rsa.PublicKey) map[string]any {
+	return map[string]any{
+		"kty": JWKTypeRSA, "kid": kid, "alg": "RS256", "use": "sig",
+		"n": base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+		"e": base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+	}
+}
+
+func jwksDocument(t *testing.T, keys ...map[string]any) []byte {
+	t.Helper()
+	data, err := sonic.Marshal(map[string]any{"keys": keys})
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	return data
+}
+
+func signWithKid(t *testing.T, method jwt.SigningMethod, kid string, key any) string {
+	t.Helper()
+	token := jwt.NewWithClaims(method, jwt.MapClaims{"sub": "did:wba:client.example.com", "exp": time.Now().Add(time.Hour).Unix()})
+	if kid != "" {
+		token.Header["kid"] = kid
+	}
+	signed, err := token.SignedString(key)
+	if err != nil {
+		t.Fatalf("SignedString() error = %v", err)
+	}
+	return signed
+}
+
+func TestVerifierStaticJWKS(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("GenerateKey() error = %v", err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey() error = %v", err)
+	}
+	ecJWK := map[string]any{
+		"kty": JWKTypeEC, "kid": "ec-1", "alg": "ES256", "crv": JWKCurveP256,
+		"x": base64.RawURLEncoding.EncodeToString(ecKey.X.FillBytes(make([]byte, 32))),
+		"y": base64.RawURLEncoding.EncodeToString(ecKey.Y.FillBytes(make([]byte, 32))),
+	}
+	encJWK := rsaJWK("enc-1", &rsaKey.PublicKey)
+	encJWK["use"] = "enc"
+
+	verifier, err := NewDidWbaVerifier(DidWbaVerifierConfig{
+		JWKS:           jwksDocument(t, rsaJWK("rsa-1", &rsaKey.PublicKey), ecJWK, encJWK),
+		NonceValidator: NewMemoryNonceValidator(time.Minute),
+	})
+	if err != nil {
+		t.Fatalf("NewDidWbaVerifier() error = %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		token   string
+		wantErr bool
+	}{
+		{"rsa kid", signWithKid(t, jwt.SigningMethodRS256, "rsa-1", rsaKey), false},
+		{"ec kid", signWithKid(t, jwt.SigningMethodES256, "ec-1", ecKey), false},
+		{"unknown kid", signWithKid(t, jwt.SigningMethodRS256, "rsa-2", rsaKey), true},
+		{"encryption key", signWithKid(t, jwt.SigningMethodRS256, "enc-1", rsaKey), true},
+		{"algorithm mismatch", signWithKid(t, jwt.SigningMethodRS384, "rsa-1", rsaKey), true},
+		{"no kid with several keys", signWithKid(t, jwt.SigningMethodRS256, "", rsaKey), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := verifier.VerifyAuthHeader(BearerScheme+tt.token, "api.example.com")
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidToken) {
+					t.Errorf("VerifyAuthHeader() error = %v, want ErrInvalidToken", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("VerifyAuthHeader() error = %v", err)
+			}
+			if result["did"] != "did:wba:client.example.com" {
+				t.Errorf("result = %v", result)
+			}
+		})
+	}
+}
+
+func TestRemoteJWKSRotation(t *testing.T) {
+	oldKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("GenerateKey() error = %v", err)
+	}
+	newKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("GenerateKey() error = %v", err)
+	}
+
+	var (
+		document atomic.Value
+		fetches  atomic.Int32
+		down     atomic.Bool
+	)
+	document.Store(jwksDocument(t, rsaJWK("old", &oldKey.PublicKey)))
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetches.Add(1)
+		if down.Load() {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(document.Load().([]byte))
+	}))
+	defer srv.Close()
+
+	now := time.Now()
+	jwks := NewRemoteJWKS(srv.URL, srv.Client(), time.Hour)
+	jwks.now = func() time.Time { return now }
+	parse := func(token string) error {
+		_, err := parseToken(token, jwks.keyfunc(t.Context(), nil, DefaultJWTAlgorithm), false)
+		return err
+	}
+
+	if err := parse(signWithKid(t, jwt.SigningMethodRS256, "old", oldKey)); err != nil {
+		t.Fatalf("parse(old) error = %v", err)
+	}
+
+	// The issuer rotates; the first token with the new kid triggers a refetch.
+	document.Store(jwksDocument(t, rsaJWK("old", &oldKey.PublicKey), rsaJWK("new", &newKey.PublicKey)))
+	now = now.Add(jwksMinRefetch)
+	if err := parse(signWithKid(t, jwt.SigningMethodRS256, "new", newKey)); err != nil {
+		t.Fatalf("parse(new) error = %v", err)
+	}
+	if got := fetches.Load(); got != 2 {
+		t.Errorf("fetches = %d, want 2", got)
+	}
+
+	// Unknown kids do not refetch again within jwksMinRefetch.
+	for range 3 {
+		if err := parse(signWithKid(t, jwt.SigningMethodRS256, "forged", newKey)); err == nil {
+			t.Error("parse(forged) succeeded")
+		}
+	}
+	if got := fetches.Load(); got != 2 {
+		t.Errorf("fetches after forged kids = %d, want 2", got)
+	}
+
+	// An outage at the refresh interval keeps the last known keys.
+	down.Store(true)
+	now = now.Add(time.Hour)
+	if err := parse(signWithKid(t, jwt.SigningMethodRS256, "new", newKey)); err != nil {
+		t.Errorf("parse(new) during outage error = %v", err)
+	}
+	if got := fetches.Load(); got != 3 {
+		t.Errorf("fetches = %d, want 3", got)
+	}
+}
@@ -4,23 +4,68 @@ import (
 	"crypto/ecdsa"
 	"crypto/rsa"
 	"fmt"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
 )
 
-// CreateAccessToken creates a new JWT access token.
-func CreateAccessToken(did string, privateKey any, algorithm string, expiration time.Duration) (string, error) {
+// CreateAccessToken creates a new JWT access token. Options add audience,
+// issuer, scopes or custom claims.
+func CreateAccessToken(did string, privateKey any, algorithm string, expiration time.Duration, opts ...ClaimsOption) (string, error) {
+	return createAccessToken(tokenClaims(did, "", false, opts), signingKey{key: privateKey, algorithm: algorithm}, expiration)
+}
+
+// CreateDelegatedAccessToken creates an access token for actor acting on behalf of
+// subject. The actor is carried in the RFC 8693 "act" claim.
+func CreateDelegatedAccessToken(subject, actor string, privateKey any, algorithm string, expiration time.Duration, opts ...ClaimsOption) (string, error) {
+	return createAccessToken(tokenClaims(subject, actor, false, opts), signingKey{key: privateKey, algorithm: algorithm}, expiration)
+}
+
+// CreateRefreshToken creates a refresh token for subject, and for delegated
+// sessions actor, which RefreshAccessToken exchanges for new access tokens.
+// It is signed like an access token but marked so it is never accepted as one.
+func CreateRefreshToken(subject, actor string, privateKey any, algorithm string, expiration time.Duration, opts ...ClaimsOption) (string, error) {
+	return createAccessToken(tokenClaims(subject, actor, true, opts), signingKey{key: privateKey, algorithm: algorithm}, expiration)
+}
+
+// signingKey is a JWT signing key and the kid header naming it, if any.
+type signingKey struct {
+	key       any
+	algorithm string
+	kid       string
+}
+
+func tokenClaims(subject, actor string, refresh bool, opts []ClaimsOption) jwt.MapClaims {
+	claims := jwt.MapClaims{}
+	newClaimsOptions(opts).apply(claims)
+	claims["sub"] = subject
+	if actor != "" {
+		claims["act"] = map[string]any{"sub": actor}
+	}
+	if refresh {
+		claims[tokenUseClaim] = tokenUseRefresh
+	}
+	return claims
+}
+
+func createAccessToken(claims jwt.MapClaims, key signingKey, expiration time.Duration) (string, error) {
 	now := time.Now()
-	claims := jwt.MapClaims{
-		"sub": did,
-		"iat": now.Unix(),
-		"exp": now.Add(expiration).Unix(),
+	claims["iat"] = now.Unix()
+	claims["exp"] = now.Add(expiration).Unix()
+	id, err := newTokenID()
+	if err != nil {
+		return "", fmt.Errorf("failed to generate token ID: %w", err)
 	}
+	claims["jti"] = id
 
-	token := jwt.NewWithClaims(jwt.GetSigningMethod(algorithm), claims)
+	token := jwt.NewWithClaims(jwt.GetSigningMethod(key.algorithm), claims)
+	if key.kid != "" {
+		token.Header["kid"] = key.kid
+	}
 
-	signedToken, err := token.SignedString(privateKey)
+	signedToken, err := token.SignedString(key.key)
 	if err != nil {
 		return "", fmt.Errorf("failed to sign token: %w", err)
 	}
@@ -28,34 +73,124 @@ func CreateAccessToken(did string, privateKey any, algorithm string, expiration
 	return signedToken, nil
 }
 
+// tokenUseClaim distinguishes refresh tokens from access tokens.
+const (
+	tokenUseClaim   = "token_use"
+	tokenUseRefresh = "refresh"
+)
+
+// AccessTokenClaims are the claims carried by an access token.
+type AccessTokenClaims struct {
+	// ID is the jti claim, which DidWbaVerifier.Revoke takes.
+	ID string
+	// Subject is the DID the token was issued for.
+	Subject string
+	// Actor is set on delegated tokens to the DID acting on behalf of Subject.
+	Actor string
+	// Issuer, Audience and Scopes are the iss, aud and scope claims.
+	Issuer   string
+	Audience []string
+	Scopes   []string
+	// IssuedAt and ExpiresAt are the iat and exp claims, zero if absent.
+	IssuedAt  time.Time
+	ExpiresAt time.Time
+	// Extra holds the remaining claims, such as those set with WithClaims.
+	Extra map[string]any
+}
+
 // VerifyAccessToken verifies a JWT access token and returns the DID (subject).
-func VerifyAccessToken(tokenString string, publicKey any, algorithm string) (string, error) {
-	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
+// Options require an audience, issuer or scopes; see ParseAccessToken for
+// the full claims.
+func VerifyAccessToken(tokenString string, publicKey any, algorithm string, opts ...ClaimsOption) (string, error) {
+	claims, err := ParseAccessToken(tokenString, publicKey, algorithm, opts...)
+	if err != nil {
+		return "", err
+	}
+	return claims.Subject, nil
+}
+
+// ParseAccessToken verifies a JWT access token and returns its claims.
+// WithAudience, WithIssuer and WithScopes make the corresponding claims
+// required. Refresh tokens are rejected.
+func ParseAccessToken(tokenString string, publicKey any, algorithm string, opts ...ClaimsOption) (*AccessTokenClaims, error) {
+	return parseToken(tokenString, staticKey(publicKey, algorithm), false, opts...)
+}
+
+// ParseRefreshToken verifies a refresh token created by CreateRefreshToken.
+// Access tokens are rejected.
+func ParseRefreshToken(tokenString string, publicKey any, algorithm string, opts ...ClaimsOption) (*AccessTokenClaims, error) {
+	return parseToken(tokenString, staticKey(publicKey, algorithm), true, opts...)
+}
+
+// staticKey verifies tokens with a single key and algorithm.
+func staticKey(publicKey any, algorithm string) jwt.Keyfunc {
+	return func(token *jwt.Token) (interface{}, error) {
 		if jwt.GetSigningMethod(algorithm) != token.Method {
 			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
 		}
 		return publicKey, nil
-	})
+	}
+}
+
+func parseToken(tokenString string, keyfunc jwt.Keyfunc, refresh bool, opts ...ClaimsOption) (*AccessTokenClaims, error) {
+	options := newClaimsOptions(opts)
+	token, err := jwt.Parse(tokenString, keyfunc, options.parserOptions()...)
 
 	if err != nil {
-		return "", fmt.Errorf("failed to parse token: %w", err)
+		return nil, fmt.Errorf("failed to parse token: %w", err)
 	}
 
 	if !token.Valid {
-		return "", fmt.Errorf("token is invalid")
+		return nil, fmt.Errorf("token is invalid")
 	}
 
 	claims, ok := token.Claims.(jwt.MapClaims)
 	if !ok {
-		return "", fmt.Errorf("invalid token claims")
+		return nil, fmt.Errorf("invalid token claims")
+	}
+
+	if isRefresh := claims[tokenUseClaim] == tokenUseRefresh; isRefresh != refresh {
+		if refresh {
+			return nil, fmt.Errorf("not a refresh token")
+		}
+		return nil, fmt.Errorf("refresh token used as access token")
 	}
 
 	did, ok := claims["sub"].(string)
 	if !ok {
-		return "", fmt.Errorf("'sub' claim is missing or not a string")
+		return nil, fmt.Errorf("'sub' claim is missing or not a string")
 	}
 
-	return did, nil
+	result := &AccessTokenClaims{Subject: did}
+	if act, ok := claims["act"].(map[string]any); ok {
+		if result.Actor, ok = act["sub"].(string); !ok {
+			return nil, fmt.Errorf("'act' claim has no subject")
+		}
+	}
+	result.ID, _ = claims["jti"].(string)
+	result.Issuer, _ = claims.GetIssuer()
+	result.Audience, _ = claims.GetAudience()
+	if scope, ok := claims["scope"].(string); ok {
+		result.Scopes = strings.Fields(scope)
+	}
+	if iat, err := claims.GetIssuedAt(); err == nil && iat != nil {
+		result.IssuedAt = iat.Time
+	}
+	if exp, err := claims.GetExpirationTime(); err == nil && exp != nil {
+		result.ExpiresAt = exp.Time
+	}
+	for k, v := range claims {
+		if !slices.Contains(reservedClaims, k) {
+			if result.Extra == nil {
+				result.Extra = make(map[string]any)
+			}
+			result.Extra[k] = v
+		}
+	}
+	if err := options.check(result); err != nil {
+		return nil, err
+	}
+	return result, nil
 }
 
 // Utility function to parse RSA private key from PEM bytes (example)
@@ -0,0 +1,211 @@
+package anp_auth
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"encoding/base64"
+	"fmt"
+	"math/big"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/bytedance/sonic"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// JWTKey is one key of a JWTKeySet.
+type JWTKey struct {
+	// ID is the key ID written to the "kid" header of tokens it signs.
+	ID string
+	// PrivateKey signs tokens. Keys kept only to verify tokens issued before a
+	// rotation may leave it nil.
+	PrivateKey any
+	// PublicKey verifies tokens; derived from PrivateKey when nil.
+	PublicKey any
+	// Algorithm is the JWT signing algorithm, DefaultJWTAlgorithm when empty.
+	Algorithm string
+}
+
+// JWTKeySet holds the keys of a token issuer: one active key that signs new
+// tokens and older keys that still verify the tokens they signed. Tokens name
+// their key in the "kid" header, so rotating the active key does not
+// invalidate tokens already issued. It is safe for concurrent use.
+type JWTKeySet struct {
+	mu     sync.RWMutex
+	active string
+	keys   map[string]JWTKey
+}
+
+// NewJWTKeySet creates a key set signing with active and also verifying
+// tokens signed by previous.
+func NewJWTKeySet(active JWTKey, previous ...JWTKey) (*JWTKeySet, error) {
+	s := &JWTKeySet{keys: make(map[string]JWTKey, 1+len(previous))}
+	for _, key := range previous {
+		if err := s.add(key); err != nil {
+			return nil, err
+		}
+	}
+	if err := s.Rotate(active); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Rotate makes next the signing key. The previous active key stays in the set
+// to verify tokens it issued until Remove is called for it.
+func (s *JWTKeySet) Rotate(next JWTKey) error {
+	if next.PrivateKey == nil {
+		return fmt.Errorf("%w: active key %q has no private key", ErrJWTConfigMissing, next.ID)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.add(next); err != nil {
+		return err
+	}
+	s.active = next.ID
+	return nil
+}
+
+// Remove drops a retired key; tokens it signed are rejected from then on. The
+// active key cannot be removed.
+func (s *JWTKeySet) Remove(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if id == s.active {
+		return fmt.Errorf("cannot remove active key %q", id)
+	}
+	delete(s.keys, id)
+	return nil
+}
+
+// ActiveKeyID returns the ID of the key signing new tokens.
+func (s *JWTKeySet) ActiveKeyID() string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.active
+}
+
+// add validates key and stores it, replacing any key with the same ID.
+func (s *JWTKeySet) add(key JWTKey) error {
+	if key.ID == "" {
+		return fmt.Errorf("JWT key ID cannot be empty")
+	}
+	if key.Algorithm == "" {
+		key.Algorithm = DefaultJWTAlgorithm
+	}
+	if jwt.GetSigningMethod(key.Algorithm) == nil {
+		return fmt.Errorf("JWT key %q: unsupported algorithm %q", key.ID, key.Algorithm)
+	}
+	if key.PublicKey == nil {
+		signer, ok := key.PrivateKey.(crypto.Signer)
+		if !ok {
+			return fmt.Errorf("%w: key %q has no public key", ErrJWTConfigMissing, key.ID)
+		}
+		key.PublicKey = signer.Public()
+	}
+	s.keys[key.ID] = key
+	return nil
+}
+
+// signingKey returns the active key.
+func (s *JWTKeySet) signingKey() signingKey {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	key := s.keys[s.active]
+	return signingKey{key: key.PrivateKey, algorithm: key.Algorithm, kid: key.ID}
+}
+
+// lookup returns the key with the given ID.
+func (s *JWTKeySet) lookup(kid string) (JWTKey, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	key, ok := s.keys[kid]
+	return key, ok
+}
+
+// keyfunc verifies tokens with the key named by their kid header.
+func (s *JWTKeySet) keyfunc(token *jwt.Token) (any, error) {
+	kid, _ := token.Header["kid"].(string)
+	key, ok := s.lookup(kid)
+	if !ok {
+		return nil, fmt.Errorf("no JWT key with kid %q", kid)
+	}
+	if jwt.GetSigningMethod(key.Algorithm) != token.Method {
+		return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+	}
+	return key.PublicKey, nil
+}
+
+// CreateAccessToken creates an access token for did signed by the active key.
+func (s *JWTKeySet) CreateAccessToken(did string, expiration time.Duration, opts ...ClaimsOption) (string, error) {
+	return createAccessToken(tokenClaims(did, "", false, opts), s.signingKey(), expiration)
+}
+
+// CreateDelegatedAccessToken creates an access token for actor acting on
+// behalf of subject, signed by the active key.
+func (s *JWTKeySet) CreateDelegatedAccessToken(subject, actor string, expiration time.Duration, opts ...ClaimsOption) (string, error) {
+	return createAccessToken(tokenClaims(subject, actor, false, opts), s.signingKey(), expiration)
+}
+
+// VerifyAccessToken verifies an access token signed by any key of the set and
+// returns the DID (subject).
+func (s *JWTKeySet) VerifyAccessToken(tokenString string, opts ...ClaimsOption) (string, error) {
+	claims, err := s.ParseAccessToken(tokenString, opts...)
+	if err != nil {
+		return "", err
+	}
+	return claims.Subject, nil
+}
+
+// ParseAccessToken verifies an access token signed by any key of the set and
+// returns its claims.
+func (s *JWTKeySet) ParseAccessToken(tokenString string, opts ...ClaimsOption) (*AccessTokenClaims, error) {
+	return parseToken(tokenString, s.keyfunc, false, opts...)
+}
+
+// JWKS returns the public keys of the set as a JWKS document, for serving to
+// verifiers configured with DidWbaVerifierConfig.JWKSURL.
+func (s *JWTKeySet) JWKS() ([]byte, error) {
+	s.mu.RLock()
+	ids := make([]string, 0, len(s.keys))
+	for id := range s.keys {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	keys := make([]jsonWebKey, 0, len(ids))
+	for _, id := range ids {
+		key := s.keys[id]
+		jwk, err := publicJWK(key.PublicKey)
+		if err != nil {
+			s.mu.RUnlock()
+			return nil, fmt.Errorf("JWT key %q: %w", id, err)
+		}
+		jwk.Kid, jwk.Alg, jwk.Use = id, key.Algorithm, "sig"
+		keys = append(keys, jwk)
+	}
+	s.mu.RUnlock()
+	return sonic.Marshal(map[string]any{"keys": keys})
+}
+
+// publicJWK encodes a JWT verification key as a JWK.
+func publicJWK(key any) (jsonWebKey, error) {
+	b64 := base64.RawURLEncoding.EncodeToString
+	switch k := key.(type) {
+	case *rsa.PublicKey:
+		return jsonWebKey{Kty: JWKTypeRSA, N: b64(k.N.Bytes()), E: b64(big.NewInt(int64(k.E)).Bytes())}, nil
+	case *ecdsa.PublicKey:
+		size := (k.Curve.Params().BitSize + 7) / 8
+		return jsonWebKey{
+			Kty: JWKTypeEC,
+			Crv: k.Curve.Params().Name,
+			X:   b64(k.X.FillBytes(make([]byte, size))),
+			Y:   b64(k.Y.FillBytes(make([]byte, size))),
+		}, nil
+	case ed25519.PublicKey:
+		return jsonWebKey{Kty: JWKTypeOKP, Crv: JWKCurveEd25519, X: b64(k)}, nil
+	}
+	return jsonWebKey{}, fmt.Errorf("unsupported public key type %T", key)
+}
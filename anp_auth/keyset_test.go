@@ -0,0 +1,166 @@
+package anp_auth
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func TestJWTKeySetRotation(t *testing.T) {
+	first, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("GenerateKey() error = %v", err)
+	}
+	second, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey() error = %v", err)
+	}
+
+	keys, err := NewJWTKeySet(JWTKey{ID: "2024-01", PrivateKey: first})
+	if err != nil {
+		t.Fatalf("NewJWTKeySet() error = %v", err)
+	}
+	oldToken, err := keys.CreateAccessToken("did:wba:a.example.com", time.Hour)
+	if err != nil {
+		t.Fatalf("CreateAccessToken() error = %v", err)
+	}
+
+	if err := keys.Rotate(JWTKey{ID: "2024-02", PrivateKey: second, Algorithm: "ES256"}); err != nil {
+		t.Fatalf("Rotate() error = %v", err)
+	}
+	newToken, err := keys.CreateAccessToken("did:wba:b.example.com", time.Hour)
+	if err != nil {
+		t.Fatalf("CreateAccessToken() error = %v", err)
+	}
+	parsed, _, err := jwt.NewParser().ParseUnverified(newToken, jwt.MapClaims{})
+	if err != nil {
+		t.Fatalf("ParseUnverified() error = %v", err)
+	}
+	if parsed.Header["kid"] != "2024-02" || parsed.Method != jwt.SigningMethodES256 {
+		t.Errorf("new token header = %v, want kid 2024-02 signed ES256", parsed.Header)
+	}
+
+	for token, want := range map[string]string{oldToken: "did:wba:a.example.com", newToken: "did:wba:b.example.com"} {
+		if did, err := keys.VerifyAccessToken(token); err != nil || did != want {
+			t.Errorf("VerifyAccessToken() = %q, %v, want %q", did, err, want)
+		}
+	}
+
+	// Published keys verify tokens through a JWKS as well.
+	doc, err := keys.JWKS()
+	if err != nil {
+		t.Fatalf("JWKS() error = %v", err)
+	}
+	jwks, err := ParseJWKS(doc)
+	if err != nil {
+		t.Fatalf("ParseJWKS() error = %v", err)
+	}
+	for _, token := range []string{oldToken, newToken} {
+		if _, err := parseToken(token, jwks.keyfunc(t.Context(), nil, DefaultJWTAlgorithm), false); err != nil {
+			t.Errorf("parse with published JWKS error = %v", err)
+		}
+	}
+
+	if err := keys.Remove("2024-02"); err == nil {
+		t.Error("Remove(active) succeeded")
+	}
+	if err := keys.Remove("2024-01"); err != nil {
+		t.Fatalf("Remove() error = %v", err)
+	}
+	if _, err := keys.VerifyAccessToken(oldToken); err == nil {
+		t.Error("VerifyAccessToken(removed key) succeeded")
+	}
+}
+
+func TestJWTKeySetInvalidKeys(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("GenerateKey() error = %v", err)
+	}
+	tests := []struct {
+		name     string
+		active   JWTKey
+		previous []JWTKey
+	}{
+		{"missing id", JWTKey{PrivateKey: key}, nil},
+		{"unknown algorithm", JWTKey{ID: "a", PrivateKey: key, Algorithm: "XS999"}, nil},
+		{"active without private key", JWTKey{ID: "a", PublicKey: &key.PublicKey}, nil},
+		{"previous without any key", JWTKey{ID: "a", PrivateKey: key}, []JWTKey{{ID: "b"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewJWTKeySet(tt.active, tt.previous...); err == nil {
+				t.Error("NewJWTKeySet() succeeded")
+			}
+		})
+	}
+}
+
+func TestVerifierJWTKeySet(t *testing.T) {
+	party := newDelegationParty(t, "client.example.com")
+	auth, err := NewAuthenticator(WithDIDMaterial(party.doc, party.key))
+	if err != nil {
+		t.Fatalf("NewAuthenticator() error = %v", err)
+	}
+	oldKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("GenerateKey() error = %v", err)
+	}
+	newKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("GenerateKey() error = %v", err)
+	}
+	keys, err := NewJWTKeySet(JWTKey{ID: "old", PrivateKey: oldKey})
+	if err != nil {
+		t.Fatalf("NewJWTKeySet() error = %v", err)
+	}
+	verifier, err := NewDidWbaVerifier(DidWbaVerifierConfig{
+		JWTKeySet:          keys,
+		NonceValidator:     NewMemoryNonceValidator(time.Minute),
+		ResolveDIDDocument: resolverFor(t, party),
+	})
+	if err != nil {
+		t.Fatalf("NewDidWbaVerifier() error = %v", err)
+	}
+
+	login := func() string {
+		t.Helper()
+		headers, err := auth.GenerateHeaderForce("https://api.example.com/rpc")
+		if err != nil {
+			t.Fatalf("GenerateHeader() error = %v", err)
+		}
+		result, err := verifier.VerifyAuthHeader(headers[AuthorizationHeader], "api.example.com")
+		if err != nil {
+			t.Fatalf("VerifyAuthHeader() error = %v", err)
+		}
+		return result["access_token"].(string)
+	}
+
+	before := login()
+	if err := keys.Rotate(JWTKey{ID: "new", PrivateKey: newKey}); err != nil {
+		t.Fatalf("Rotate() error = %v", err)
+	}
+	after := login()
+	if _, err := ParseAccessToken(after, &newKey.PublicKey, DefaultJWTAlgorithm); err != nil {
+		t.Errorf("token after rotation not signed by new key: %v", err)
+	}
+	for _, token := range []string{before, after} {
+		if _, err := verifier.VerifyAuthHeader(BearerScheme+token, "api.example.com"); err != nil {
+			t.Errorf("VerifyAuthHeader(bearer) error = %v", err)
+		}
+	}
+
+	forged, err := CreateAccessToken(party.doc.ID, newKey, DefaultJWTAlgorithm, time.Hour)
+	if err != nil {
+		t.Fatalf("CreateAccessToken() error = %v", err)
+	}
+	if _, err := verifier.VerifyAuthHeader(BearerScheme+forged, "api.example.com"); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("VerifyAuthHeader(token without kid) error = %v, want ErrInvalidToken", err)
+	}
+}
@@ -1,7 +1,15 @@
 package anp_auth
 
-// Logger is an interface for structured logging.
-// This allows users to inject their own logger implementation.
+import (
+	"context"
+	"log/slog"
+)
+
+// Logger is the key-value logging interface accepted before anp_auth moved to
+// log/slog.
+//
+// Deprecated: pass a *slog.Logger to WithLogger. Wrap an existing Logger
+// implementation with NewLegacyLogger.
 type Logger interface {
 	Debug(msg string, keysAndValues ...interface{})
 	Info(msg string, keysAndValues ...interface{})
@@ -10,7 +18,8 @@ type Logger interface {
 }
 
 // NoOpLogger is a logger that does nothing.
-// Used as the default logger if none is provided.
+//
+// Deprecated: the default logger already discards output.
 type NoOpLogger struct{}
 
 func (NoOpLogger) Debug(msg string, keysAndValues ...interface{}) {}
@@ -18,5 +27,77 @@ func (NoOpLogger) Info(msg string, keysAndValues ...interface{})  {}
 func (NoOpLogger) Warn(msg string, keysAndValues ...interface{})  {}
 func (NoOpLogger) Error(msg string, keysAndValues ...interface{}) {}
 
-// defaultLogger is used when no logger is injected
-var defaultLogger Logger = NoOpLogger{}
+// defaultLogger keeps the package silent unless a logger is injected.
+var defaultLogger = slog.New(slog.DiscardHandler)
+
+// NewLegacyLogger returns a *slog.Logger that forwards every record to l, so
+// zap, logrus or hand-written adapters built for Logger keep working.
+// Attributes added with With are passed first; groups prefix keys with
+// "group.".
+func NewLegacyLogger(l Logger) *slog.Logger {
+	if l == nil {
+		return defaultLogger
+	}
+	return slog.New(&legacyHandler{logger: l})
+}
+
+type legacyHandler struct {
+	logger Logger
+	attrs  []any
+	prefix string
+}
+
+func (h *legacyHandler) Enabled(context.Context, slog.Level) bool { return true }
+
+func (h *legacyHandler) Handle(_ context.Context, r slog.Record) error {
+	kv := append(make([]any, 0, len(h.attrs)+2*r.NumAttrs()), h.attrs...)
+	r.Attrs(func(a slog.Attr) bool {
+		kv = appendAttr(kv, h.prefix, a)
+		return true
+	})
+
+	switch {
+	case r.Level >= slog.LevelError:
+		h.logger.Error(r.Message, kv...)
+	case r.Level >= slog.LevelWarn:
+		h.logger.Warn(r.Message, kv...)
+	case r.Level >= slog.LevelInfo:
+		h.logger.Info(r.Message, kv...)
+	default:
+		h.logger.Debug(r.Message, kv...)
+	}
+	return nil
+}
+
+func (h *legacyHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	next := *h
+	next.attrs = append([]any(nil), h.attrs...)
+	for _, a := range attrs {
+		next.attrs = appendAttr(next.attrs, h.prefix, a)
+	}
+	return &next
+}
+
+func (h *legacyHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	next := *h
+	next.prefix = h.prefix + name + "."
+	return &next
+}
+
+// appendAttr flattens a, expanding group values into dotted keys.
+func appendAttr(kv []any, prefix string, a slog.Attr) []any {
+	v := a.Value.Resolve()
+	if v.Kind() == slog.KindGroup {
+		if a.Key != "" {
+			prefix += a.Key + "."
+		}
+		for _, ga := range v.Group() {
+			kv = appendAttr(kv, prefix, ga)
+		}
+		return kv
+	}
+	return append(kv, prefix+a.Key, v.Any())
+}
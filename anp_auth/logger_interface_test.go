@@ -0,0 +1,66 @@
+package anp_auth
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"testing"
+)
+
+type recordingLogger struct {
+	lines []string
+}
+
+func (r *recordingLogger) record(level, msg string, kv []interface{}) {
+	r.lines = append(r.lines, fmt.Sprintf("%s %s %v", level, msg, kv))
+}
+
+func (r *recordingLogger) Debug(msg string, kv ...interface{}) { r.record("DEBUG", msg, kv) }
+func (r *recordingLogger) Info(msg string, kv ...interface{})  { r.record("INFO", msg, kv) }
+func (r *recordingLogger) Warn(msg string, kv ...interface{})  { r.record("WARN", msg, kv) }
+func (r *recordingLogger) Error(msg string, kv ...interface{}) { r.record("ERROR", msg, kv) }
+
+func TestNewLegacyLogger(t *testing.T) {
+	legacy := &recordingLogger{}
+	logger := NewLegacyLogger(legacy).With("tenant", "a")
+
+	logger.Debug("cache hit", "domain", "example.com")
+	logger.WithGroup("req").Warn("slow", "ms", 120, slog.Group("peer", "did", "did:wba:x"))
+	logger.Error("failed")
+	logger.Log(context.Background(), slog.LevelInfo+2, "notice")
+
+	want := []string{
+		"DEBUG cache hit [tenant a domain example.com]",
+		"WARN slow [tenant a req.ms 120 req.peer.did did:wba:x]",
+		"ERROR failed [tenant a]",
+		"INFO notice [tenant a]",
+	}
+	if len(legacy.lines) != len(want) {
+		t.Fatalf("lines = %q, want %q", legacy.lines, want)
+	}
+	for i := range want {
+		if legacy.lines[i] != want[i] {
+			t.Errorf("line %d = %q, want %q", i, legacy.lines[i], want[i])
+		}
+	}
+}
+
+func TestWithLogger_RoutesAuthenticatorLogs(t *testing.T) {
+	doc, privateKey, err := CreateDIDWBADocument("example.com", nil, nil, nil)
+	if err != nil {
+		t.Fatalf("CreateDIDWBADocument() error = %v", err)
+	}
+	legacy := &recordingLogger{}
+	auth, err := NewAuthenticator(WithDIDMaterial(doc, privateKey), WithLogger(NewLegacyLogger(legacy)))
+	if err != nil {
+		t.Fatalf("NewAuthenticator() error = %v", err)
+	}
+	auth.ClearToken("::bad url")
+	if len(legacy.lines) != 1 || legacy.lines[0][:4] != "WARN" {
+		t.Errorf("lines = %q, want one warning", legacy.lines)
+	}
+
+	if _, err := NewAuthenticator(WithLogger(nil)); err == nil {
+		t.Error("WithLogger(nil) succeeded")
+	}
+}
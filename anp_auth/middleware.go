@@ -13,26 +13,28 @@ const (
 	ContextKeyDID contextKey = "authenticated_did"
 	// ContextKeyAccessToken is the context key for storing the access token
 	ContextKeyAccessToken contextKey = "access_token"
+	// ContextKeyActorDID is the context key for the DID acting on behalf of the
+	// authenticated DID in delegated requests
+	ContextKeyActorDID contextKey = "actor_did"
 )
 
 // Middleware returns an HTTP middleware that authenticates requests using DID-WBA.
 // Successful authentication injects the DID and access token into the request context.
+// For delegated requests the DID is the subject and the actor is available via
+// ActorDIDFromContext.
 // Failed authentication returns an appropriate HTTP error response.
 func Middleware(verifier *DidWbaVerifier) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			authHeader := r.Header.Get(AuthorizationHeader)
-			if authHeader == "" {
-				http.Error(w, "missing authorization header", StatusUnauthorized)
-				return
-			}
-
 			domain := r.Host
 			if domain == "" {
 				domain = r.URL.Host
 			}
 
-			result, err := verifier.VerifyAuthHeaderContext(r.Context(), authHeader, domain)
+			// A missing header is rejected by the verifier too, so it is
+			// recorded in its metrics and audit log.
+			result, err := verifier.VerifyAuthHeaderContext(withRemoteAddr(r.Context(), r.RemoteAddr), authHeader, domain)
 			if err != nil {
 				handleAuthError(w, err)
 				return
@@ -42,6 +44,9 @@ func Middleware(verifier *DidWbaVerifier) func(http.Handler) http.Handler {
 			if did, ok := result["did"].(string); ok {
 				ctx = context.WithValue(ctx, ContextKeyDID, did)
 			}
+			if actor, ok := result["actor_did"].(string); ok {
+				ctx = context.WithValue(ctx, ContextKeyActorDID, actor)
+			}
 			if token, ok := result["access_token"].(string); ok {
 				ctx = context.WithValue(ctx, ContextKeyAccessToken, token)
 				w.Header().Set(AuthorizationHeader, BearerScheme+token)
@@ -63,6 +68,13 @@ func DIDFromContext(ctx context.Context) (string, bool) {
 	return did, ok
 }
 
+// ActorDIDFromContext returns the DID acting on behalf of DIDFromContext when the
+// request was authenticated with a delegation.
+func ActorDIDFromContext(ctx context.Context) (string, bool) {
+	actor, ok := ctx.Value(ContextKeyActorDID).(string)
+	return actor, ok
+}
+
 // AccessTokenFromContext extracts the access token from the request context.
 func AccessTokenFromContext(ctx context.Context) (string, bool) {
 	token, ok := ctx.Value(ContextKeyAccessToken).(string)
@@ -0,0 +1,108 @@
+package anp_auth
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/openanp/anp-go/crypto"
+)
+
+// Multicodec codes of the public key types found in publicKeyMultibase.
+const (
+	multicodecSecp256k1Pub = 0xe7
+	multicodecEd25519Pub   = 0xed
+	multicodecP256Pub      = 0x1200
+)
+
+const base58Alphabet = "123456789ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnopqrstuvwxyz"
+
+// decodeMultibase decodes a base58btc ('z') or base64url ('u') multibase
+// string, the encodings used by DID documents.
+func decodeMultibase(s string) ([]byte, error) {
+	if s == "" {
+		return nil, fmt.Errorf("empty multibase value")
+	}
+	switch s[0] {
+	case 'z':
+		return decodeBase58(s[1:])
+	case 'u':
+		return base64.RawURLEncoding.DecodeString(s[1:])
+	}
+	return nil, fmt.Errorf("unsupported multibase encoding %q", s[0])
+}
+
+func decodeBase58(s string) ([]byte, error) {
+	n := new(big.Int)
+	radix := big.NewInt(58)
+	for i := 0; i < len(s); i++ {
+		digit := strings.IndexByte(base58Alphabet, s[i])
+		if digit < 0 {
+			return nil, fmt.Errorf("invalid base58 character %q", s[i])
+		}
+		n.Mul(n, radix).Add(n, big.NewInt(int64(digit)))
+	}
+	zeros := len(s) - len(strings.TrimLeft(s, "1"))
+	return append(make([]byte, zeros), n.Bytes()...), nil
+}
+
+// publicKeyMultibase decodes the publicKeyMultibase of a verification method
+// into its multicodec code and key bytes. A value without a known multicodec
+// prefix is returned with code 0, as older documents publish bare keys.
+func publicKeyMultibase(methodMap map[string]any) (uint64, []byte, error) {
+	value, ok := methodMap["publicKeyMultibase"].(string)
+	if !ok {
+		return 0, nil, fmt.Errorf("publicKeyMultibase not found or not a string")
+	}
+	data, err := decodeMultibase(value)
+	if err != nil {
+		return 0, nil, fmt.Errorf("invalid publicKeyMultibase: %w", err)
+	}
+	code, n := binary.Uvarint(data)
+	switch {
+	case n > 0 && code == multicodecEd25519Pub && len(data)-n == ed25519.PublicKeySize,
+		n > 0 && (code == multicodecSecp256k1Pub || code == multicodecP256Pub) && (len(data)-n == 33 || len(data)-n == 65):
+		return code, data[n:], nil
+	}
+	return 0, data, nil
+}
+
+// multikeyPublicKey decodes a multicodec-tagged publicKeyMultibase into an
+// *ecdsa.PublicKey or an ed25519.PublicKey.
+func multikeyPublicKey(methodMap map[string]any) (any, error) {
+	code, key, err := publicKeyMultibase(methodMap)
+	if err != nil {
+		return nil, err
+	}
+	switch code {
+	case multicodecSecp256k1Pub:
+		return crypto.PublicKeyFromBytes(crypto.Secp256k1(), key)
+	case multicodecP256Pub:
+		return crypto.PublicKeyFromBytes(elliptic.P256(), key)
+	case multicodecEd25519Pub:
+		return ed25519.PublicKey(key), nil
+	}
+	return nil, fmt.Errorf("publicKeyMultibase has no supported multicodec key type")
+}
+
+// ecdsaKeyFromMultibase decodes publicKeyMultibase as a point on curve. The
+// value may carry the curve's multicodec prefix or be a bare SEC 1 point.
+func ecdsaKeyFromMultibase(methodMap map[string]any, curve elliptic.Curve) (*ecdsa.PublicKey, error) {
+	code, key, err := publicKeyMultibase(methodMap)
+	if err != nil {
+		return nil, err
+	}
+	want := uint64(multicodecSecp256k1Pub)
+	if curve == elliptic.P256() {
+		want = multicodecP256Pub
+	}
+	if code != 0 && code != want {
+		return nil, fmt.Errorf("publicKeyMultibase key type 0x%x does not match the %s curve", code, curve.Params().Name)
+	}
+	return crypto.PublicKeyFromBytes(curve, key)
+}
@@ -0,0 +1,120 @@
+package anp_auth
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// SignResponseProof signs a DIDWba payload with the authenticator's own DID
+// over a client's nonce, proving the server's identity to that client. The
+// result is sent in the AuthenticationInfoHeader of the response; domain is
+// the host the client addressed.
+func (a *Authenticator) SignResponseProof(clientNonce, domain string) (string, error) {
+	if err := a.ensureMaterial(); err != nil {
+		return "", fmt.Errorf("load authentication material: %w", err)
+	}
+	proof, err := NewAuthHeader(a.privateKey, a.didDocument, domain, clientNonce, time.Now().UTC().Format(time.RFC3339))
+	if err != nil {
+		return "", fmt.Errorf("sign response proof: %w", err)
+	}
+	return proof.String(), nil
+}
+
+// MutualAuthMiddleware is Middleware that also proves the server's identity:
+// every response to a DIDWba request carries a proof signed by server over
+// the client's nonce. Requests authenticated with a bearer token get no
+// proof; the client verified the server when it obtained the token.
+func MutualAuthMiddleware(verifier *DidWbaVerifier, server *Authenticator) func(http.Handler) http.Handler {
+	authenticate := Middleware(verifier)
+	return func(next http.Handler) http.Handler {
+		prove := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			authorization := r.Header.Get(AuthorizationHeader)
+			if !strings.HasPrefix(authorization, BearerScheme) {
+				parts, err := parseAuthHeader(authorization)
+				if err != nil {
+					// Unreachable: Middleware has accepted the header.
+					handleAuthError(w, err)
+					return
+				}
+				domain := r.Host
+				if domain == "" {
+					domain = r.URL.Host
+				}
+				proof, err := server.SignResponseProof(parts.Nonce, domain)
+				if err != nil {
+					http.Error(w, "sign response proof", http.StatusInternalServerError)
+					return
+				}
+				w.Header().Set(AuthenticationInfoHeader, proof)
+			}
+			next.ServeHTTP(w, r)
+		})
+		return authenticate(prove)
+	}
+}
+
+// VerifyResponseProof checks a server's response proof against the nonce
+// the client signed for domain. When serverDID is empty the proof may come
+// from any DID whose document is hosted on domain's host, which is what
+// did:wba ties a server's identity to. A nil resolve uses ResolveDIDDocument.
+func VerifyResponseProof(ctx context.Context, proof, nonce, domain, serverDID string, resolve ResolveDIDDocumentFunc) error {
+	if proof == "" {
+		return fmt.Errorf("%w: missing %s header", ErrResponseProofInvalid, AuthenticationInfoHeader)
+	}
+	parts, err := parseAuthHeader(proof)
+	if err != nil {
+		return WrapAuthError(ErrResponseProofInvalid, "parse proof", err)
+	}
+	if parts.Nonce != nonce {
+		return fmt.Errorf("%w: proof is not over the request nonce", ErrResponseProofInvalid)
+	}
+	if serverDID != "" && parts.DID != serverDID {
+		return fmt.Errorf("%w: signed by %s, want %s", ErrResponseProofInvalid, parts.DID, serverDID)
+	}
+	if serverDID == "" {
+		if err := checkDIDHost(parts.DID, domain); err != nil {
+			return err
+		}
+	}
+
+	if resolve == nil {
+		resolve = func(_ context.Context, did string) (*DIDWBADocument, error) {
+			return ResolveDIDDocument(did)
+		}
+	}
+	doc, err := resolve(ctx, parts.DID)
+	if err != nil {
+		return WrapAuthError(ErrDIDResolution, "resolve server DID", err)
+	}
+	ok, message := VerifyAuthJSON(&AuthJSON{
+		DID:                parts.DID,
+		Nonce:              parts.Nonce,
+		Timestamp:          parts.Timestamp,
+		VerificationMethod: parts.VerificationMethod,
+		Signature:          parts.Signature,
+	}, doc, domain)
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrResponseProofInvalid, message)
+	}
+	return nil
+}
+
+// checkDIDHost requires did to be published on the host of domain.
+func checkDIDHost(did, domain string) error {
+	docURL, err := DIDDocumentURL(did)
+	if err != nil {
+		return WrapAuthError(ErrResponseProofInvalid, "server DID", err)
+	}
+	u, err := url.Parse(docURL)
+	if err != nil {
+		return WrapAuthError(ErrResponseProofInvalid, "server DID", err)
+	}
+	if !strings.EqualFold(u.Hostname(), (&url.URL{Host: domain}).Hostname()) {
+		return fmt.Errorf("%w: %s is not hosted on %s", ErrResponseProofInvalid, did, domain)
+	}
+	return nil
+}
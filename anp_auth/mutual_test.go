@@ -0,0 +1,138 @@
+package anp_auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+)
+
+// redirectTransport sends every request to addr while keeping its URL host,
+// so tests can address httptest servers by DID-WBA hostnames.
+type redirectTransport struct{ addr string }
+
+func (rt redirectTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	out := req.Clone(req.Context())
+	out.URL = &url.URL{Scheme: "http", Host: rt.addr, Path: req.URL.Path}
+	out.Host = req.URL.Host
+	return http.DefaultTransport.RoundTrip(out)
+}
+
+func TestMutualAuth(t *testing.T) {
+	client := newDelegationParty(t, "client.example.com")
+	server := newDelegationParty(t, "api.example.com")
+	impostor := newDelegationParty(t, "evil.example.com")
+	resolve := resolverFor(t, client, server, impostor)
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("GenerateKey() error = %v", err)
+	}
+	verifier, err := NewDidWbaVerifier(DidWbaVerifierConfig{
+		JWTPrivateKey:      key,
+		JWTPublicKey:       &key.PublicKey,
+		NonceValidator:     NewMemoryNonceValidator(time.Minute),
+		ResolveDIDDocument: resolve,
+	})
+	if err != nil {
+		t.Fatalf("NewDidWbaVerifier() error = %v", err)
+	}
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) })
+
+	tests := []struct {
+		name      string
+		identity  delegationParty
+		serverDID string
+		wantErr   bool
+	}{
+		{"server hosted on the requested host", server, "", false},
+		{"pinned server DID", server, server.doc.ID, false},
+		{"DID hosted elsewhere", impostor, "", true},
+		{"pinned DID mismatch", server, impostor.doc.ID, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			identity, err := NewAuthenticator(WithDIDMaterial(tt.identity.doc, tt.identity.key))
+			if err != nil {
+				t.Fatalf("NewAuthenticator() error = %v", err)
+			}
+			srv := httptest.NewServer(MutualAuthMiddleware(verifier, identity)(ok))
+			defer srv.Close()
+
+			auth, err := NewAuthenticator(WithDIDMaterial(client.doc, client.key))
+			if err != nil {
+				t.Fatalf("NewAuthenticator() error = %v", err)
+			}
+			httpClient := &http.Client{Transport: &Transport{
+				Base:               redirectTransport{addr: srv.Listener.Addr().String()},
+				Authenticator:      auth,
+				MutualAuth:         true,
+				ServerDID:          tt.serverDID,
+				ResolveDIDDocument: resolve,
+			}}
+			resp, err := httpClient.Get("http://api.example.com/rpc")
+			if tt.wantErr {
+				if !errors.Is(err, ErrResponseProofInvalid) {
+					t.Fatalf("Get() error = %v, want ErrResponseProofInvalid", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Get() error = %v", err)
+			}
+			resp.Body.Close()
+
+			// The bearer token from the verified exchange is reused without a proof.
+			resp, err = httpClient.Get("http://api.example.com/rpc")
+			if err != nil {
+				t.Fatalf("Get(bearer) error = %v", err)
+			}
+			resp.Body.Close()
+			if resp.StatusCode != http.StatusOK {
+				t.Errorf("Get(bearer) status = %d, want %d", resp.StatusCode, http.StatusOK)
+			}
+		})
+	}
+}
+
+func TestVerifyResponseProof(t *testing.T) {
+	server := newDelegationParty(t, "api.example.com")
+	resolve := resolverFor(t, server)
+	identity, err := NewAuthenticator(WithDIDMaterial(server.doc, server.key))
+	if err != nil {
+		t.Fatalf("NewAuthenticator() error = %v", err)
+	}
+	proof, err := identity.SignResponseProof("client-nonce", "api.example.com")
+	if err != nil {
+		t.Fatalf("SignResponseProof() error = %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		proof   string
+		nonce   string
+		domain  string
+		wantErr bool
+	}{
+		{"valid", proof, "client-nonce", "api.example.com", false},
+		{"missing", "", "client-nonce", "api.example.com", true},
+		{"other nonce", proof, "other-nonce", "api.example.com", true},
+		{"other domain", proof, "client-nonce", "api.example.com:8443", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := VerifyResponseProof(context.Background(), tt.proof, tt.nonce, tt.domain, "", resolve)
+			if tt.wantErr != (err != nil) {
+				t.Fatalf("VerifyResponseProof() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, ErrResponseProofInvalid) {
+				t.Errorf("VerifyResponseProof() error = %v, want ErrResponseProofInvalid", err)
+			}
+		})
+	}
+}
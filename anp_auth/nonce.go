@@ -17,10 +17,21 @@ type NonceValidator interface {
 // WARNING: This implementation is NOT safe for production use in distributed
 // systems as it only stores nonces locally. Use a distributed cache (Redis, etc.)
 // for production deployments.
+//
+// Nonces expire in the order they were seen, since they share one lifetime,
+// so expiry pops from the front of a queue instead of scanning every entry.
 type MemoryNonceValidator struct {
 	used       map[string]time.Time
+	queue      []usedNonce // in insertion (and so expiry) order
+	head       int
 	mu         sync.Mutex
 	expiration time.Duration
+	now        func() time.Time
+}
+
+type usedNonce struct {
+	key    string
+	usedAt time.Time
 }
 
 // NewMemoryNonceValidator creates a new in-memory nonce validator.
@@ -28,6 +39,7 @@ func NewMemoryNonceValidator(expiration time.Duration) *MemoryNonceValidator {
 	return &MemoryNonceValidator{
 		used:       make(map[string]time.Time),
 		expiration: expiration,
+		now:        time.Now,
 	}
 }
 
@@ -37,14 +49,8 @@ func (v *MemoryNonceValidator) Validate(ctx context.Context, did, nonce string)
 	defer v.mu.Unlock()
 
 	key := did + ":" + nonce
-	now := time.Now().UTC()
-
-	// Clean expired nonces
-	for k, t := range v.used {
-		if now.Sub(t) > v.expiration {
-			delete(v.used, k)
-		}
-	}
+	now := v.now()
+	v.expire(now)
 
 	// Check if nonce was already used
 	if _, exists := v.used[key]; exists {
@@ -52,5 +58,24 @@ func (v *MemoryNonceValidator) Validate(ctx context.Context, did, nonce string)
 	}
 
 	v.used[key] = now
+	v.queue = append(v.queue, usedNonce{key: key, usedAt: now})
 	return true, nil
 }
+
+// expire drops nonces older than the expiration, in amortised constant time.
+func (v *MemoryNonceValidator) expire(now time.Time) {
+	for v.head < len(v.queue) && now.Sub(v.queue[v.head].usedAt) > v.expiration {
+		entry := v.queue[v.head]
+		// A nonce may have been re-added after expiring; keep the newer use.
+		if v.used[entry.key].Equal(entry.usedAt) {
+			delete(v.used, entry.key)
+		}
+		v.queue[v.head] = usedNonce{}
+		v.head++
+	}
+	// Reclaim the consumed prefix once it dominates the queue.
+	if v.head > 0 && v.head >= len(v.queue)/2 {
+		v.queue = append(v.queue[:0], v.queue[v.head:]...)
+		v.head = 0
+	}
+}
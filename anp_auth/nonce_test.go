@@ -2,6 +2,8 @@ package anp_auth
 
 import (
 	"context"
+	"fmt"
+	"strconv"
 	"testing"
 	"time"
 )
@@ -83,3 +85,42 @@ func TestMemoryNonceValidator_Expiration(t *testing.T) {
 		t.Error("Nonce should be valid again after expiration")
 	}
 }
+
+func TestMemoryNonceValidator_ExpiresInOrder(t *testing.T) {
+	validator := NewMemoryNonceValidator(time.Minute)
+	now := time.Unix(1700000000, 0)
+	validator.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	for i := range 1000 {
+		if ok, _ := validator.Validate(ctx, "did:wba:example.com", fmt.Sprint("n", i)); !ok {
+			t.Fatalf("Validate(n%d) = false", i)
+		}
+		now = now.Add(100 * time.Millisecond)
+	}
+	// Nonces from the first 40 seconds (n0-n399) have now expired.
+	validator.Validate(ctx, "did:wba:example.com", "fresh")
+	if got := len(validator.used); got != 600+1 {
+		t.Errorf("tracked nonces = %d, want 601", got)
+	}
+	if ok, _ := validator.Validate(ctx, "did:wba:example.com", "n0"); !ok {
+		t.Error("expired nonce n0 rejected")
+	}
+	if ok, _ := validator.Validate(ctx, "did:wba:example.com", "n999"); ok {
+		t.Error("live nonce n999 accepted twice")
+	}
+	if len(validator.queue)-validator.head != len(validator.used) {
+		t.Errorf("queue holds %d live entries, map %d", len(validator.queue)-validator.head, len(validator.used))
+	}
+}
+
+func BenchmarkMemoryNonceValidator(b *testing.B) {
+	validator := NewMemoryNonceValidator(5 * time.Minute)
+	ctx := context.Background()
+	b.ReportAllocs()
+	i := 0
+	for b.Loop() {
+		validator.Validate(ctx, "did:wba:example.com", strconv.Itoa(i))
+		i++
+	}
+}
@@ -1,12 +1,12 @@
 package anp_auth
 
 import (
-	"crypto/ecdsa"
+	"crypto"
 	"fmt"
-	"os"
+	"log/slog"
+	"time"
 
-	"github.com/bytedance/sonic"
-	"github.com/openanp/anp-go/crypto"
+	"github.com/openanp/anp-go/keystore"
 )
 
 // AuthenticatorOption configures an Authenticator.
@@ -14,12 +14,12 @@ type AuthenticatorOption func(*Authenticator) error
 
 // WithDIDMaterial configures the Authenticator with a DID document and private key directly.
 // This is the preferred method when you already have the DID material loaded.
-func WithDIDMaterial(doc *DIDWBADocument, privateKey *ecdsa.PrivateKey) AuthenticatorOption {
+func WithDIDMaterial(doc *DIDWBADocument, privateKey crypto.Signer) AuthenticatorOption {
 	return func(a *Authenticator) error {
 		if doc == nil {
 			return fmt.Errorf("DID document cannot be nil")
 		}
-		if privateKey == nil {
+		if isNilSigner(privateKey) {
 			return fmt.Errorf("private key cannot be nil")
 		}
 		a.didDocument = doc
@@ -46,40 +46,50 @@ func WithDIDCfgPaths(didDocPath, privateKeyPath string) AuthenticatorOption {
 	}
 }
 
-// WithEagerLoading loads the DID material immediately instead of lazily.
-// This is useful if you want to catch configuration errors at startup.
-// Should be used in combination with WithDIDPaths.
-func WithEagerLoading() AuthenticatorOption {
+// WithDIDSigner configures the Authenticator with a DID document loaded
+// lazily from didDocPath and a signer for its key, for keys held in a KMS or
+// an HSM rather than a PEM file. See the anp_auth/awskms, anp_auth/gcpkms and
+// anp_auth/azurekv modules.
+func WithDIDSigner(didDocPath string, signer crypto.Signer) AuthenticatorOption {
 	return func(a *Authenticator) error {
-		if a.cfg.DIDDocumentPath == "" || a.cfg.PrivateKeyPath == "" {
-			return fmt.Errorf("DID paths must be set before eager loading")
+		if didDocPath == "" {
+			return fmt.Errorf("DID document path cannot be empty")
 		}
-
-		// Load DID document
-		docBytes, err := os.ReadFile(a.cfg.DIDDocumentPath)
-		if err != nil {
-			return fmt.Errorf("read DID document: %w", err)
+		if isNilSigner(signer) {
+			return fmt.Errorf("signer cannot be nil")
 		}
+		a.cfg.DIDDocumentPath = didDocPath
+		a.privateKey = signer
+		return nil
+	}
+}
 
-		var doc DIDWBADocument
-		if err := sonic.Unmarshal(docBytes, &doc); err != nil {
-			return fmt.Errorf("decode DID document: %w", err)
+// WithKeystore configures the Authenticator with a DID document loaded lazily
+// from didDocPath and the key stored as name in ks, decrypted with passphrase.
+// The key is unlocked when the option is applied so a wrong passphrase fails
+// NewAuthenticator.
+func WithKeystore(didDocPath string, ks *keystore.Keystore, name, passphrase string) AuthenticatorOption {
+	return func(a *Authenticator) error {
+		if ks == nil {
+			return fmt.Errorf("keystore cannot be nil")
 		}
-
-		// Load private key
-		keyBytes, err := os.ReadFile(a.cfg.PrivateKeyPath)
+		signer, err := ks.Unlock(name, passphrase)
 		if err != nil {
-			return fmt.Errorf("read private key: %w", err)
+			return fmt.Errorf("unlock DID key: %w", err)
 		}
+		return WithDIDSigner(didDocPath, signer)(a)
+	}
+}
 
-		key, err := crypto.PrivateKeyFromPEM(keyBytes)
-		if err != nil {
-			return fmt.Errorf("decode private key: %w", err)
+// WithEagerLoading loads the DID material immediately instead of lazily.
+// This is useful if you want to catch configuration errors at startup.
+// Should be used in combination with WithDIDPaths.
+func WithEagerLoading() AuthenticatorOption {
+	return func(a *Authenticator) error {
+		if a.cfg.DIDDocumentPath == "" || (a.cfg.PrivateKeyPath == "" && a.privateKey == nil) {
+			return fmt.Errorf("DID paths must be set before eager loading")
 		}
-
-		a.didDocument = &doc
-		a.privateKey = key
-		return nil
+		return a.loadMaterial()
 	}
 }
 
@@ -90,15 +100,56 @@ func WithCacheSize(size int) AuthenticatorOption {
 		if size < 0 {
 			return fmt.Errorf("cache size must be non-negative")
 		}
-		a.tokens = make(map[string]string, size)
-		a.authHeaders = make(map[string]string, size)
+		a.cacheSize = size
 		return nil
 	}
 }
 
-// WithLogger sets a custom logger for the Authenticator.
-// If not provided, a no-op logger is used by default.
-func WithLogger(logger Logger) AuthenticatorOption {
+// WithMaxCacheEntries bounds the token and header caches to n domains each,
+// evicting the least recently used domain when full. Zero removes the bound;
+// the default is DefaultMaxCacheEntries.
+func WithMaxCacheEntries(n int) AuthenticatorOption {
+	return func(a *Authenticator) error {
+		if n < 0 {
+			return fmt.Errorf("max cache entries must be non-negative")
+		}
+		a.maxCacheEntries = n
+		return nil
+	}
+}
+
+// WithCacheTTL expires cached tokens and headers ttl after they were stored,
+// after which a fresh DIDWba header is generated. Keep it below the server's
+// timestamp window (DefaultTimestampExpiration) to never resend a header the
+// server would reject. Zero disables expiry, keeping entries until evicted.
+// Without this option headers expire after DefaultHeaderCacheTTL and tokens
+// are kept until shortly before their exp claim.
+func WithCacheTTL(ttl time.Duration) AuthenticatorOption {
+	return func(a *Authenticator) error {
+		if ttl < 0 {
+			return fmt.Errorf("cache TTL must be non-negative")
+		}
+		a.cacheTTL, a.headerCacheTTL = ttl, ttl
+		return nil
+	}
+}
+
+// WithTokenRefreshWindow sets how long before a cached bearer token's exp
+// claim the Authenticator stops sending it and signs a new DIDWba header. The
+// default is DefaultTokenRefreshWindow.
+func WithTokenRefreshWindow(window time.Duration) AuthenticatorOption {
+	return func(a *Authenticator) error {
+		if window < 0 {
+			return fmt.Errorf("token refresh window must be non-negative")
+		}
+		a.refreshWindow = window
+		return nil
+	}
+}
+
+// WithLogger sets the logger used by the Authenticator. Without it the
+// Authenticator logs nothing. Use NewLegacyLogger to pass a Logger.
+func WithLogger(logger *slog.Logger) AuthenticatorOption {
 	return func(a *Authenticator) error {
 		if logger == nil {
 			return fmt.Errorf("logger cannot be nil")
@@ -108,6 +159,23 @@ func WithLogger(logger Logger) AuthenticatorOption {
 	}
 }
 
+// WithDelegation attaches a delegation chain to every DID-WBA header, so the
+// Authenticator acts on behalf of the chain's subject. The chain must end at the
+// Authenticator's own DID.
+func WithDelegation(chain DelegationChain) AuthenticatorOption {
+	return func(a *Authenticator) error {
+		if len(chain) == 0 {
+			return fmt.Errorf("delegation chain cannot be empty")
+		}
+		encoded, err := chain.Encode()
+		if err != nil {
+			return err
+		}
+		a.delegation = encoded
+		return nil
+	}
+}
+
 // NewAuthenticator creates a new Authenticator using the functional options pattern.
 //
 // Example usage:
@@ -129,9 +197,10 @@ func WithLogger(logger Logger) AuthenticatorOption {
 //	)
 func NewAuthenticator(opts ...AuthenticatorOption) (*Authenticator, error) {
 	a := &Authenticator{
-		tokens:      make(map[string]string),
-		authHeaders: make(map[string]string),
-		logger:      defaultLogger, // Use no-op logger by default
+		maxCacheEntries: DefaultMaxCacheEntries,
+		headerCacheTTL:  DefaultHeaderCacheTTL,
+		refreshWindow:   DefaultTokenRefreshWindow,
+		logger:          defaultLogger,
 	}
 
 	for _, opt := range opts {
@@ -140,12 +209,15 @@ func NewAuthenticator(opts ...AuthenticatorOption) (*Authenticator, error) {
 		}
 	}
 
+	a.tokens = newHeaderCache(a.cacheSize, a.maxCacheEntries, a.cacheTTL)
+	a.authHeaders = newHeaderCache(a.cacheSize, a.maxCacheEntries, a.headerCacheTTL)
+
 	// Validate that we have either direct material or paths
 	hasDirectMaterial := a.didDocument != nil && a.privateKey != nil
-	hasPaths := a.cfg.DIDDocumentPath != "" && a.cfg.PrivateKeyPath != ""
+	hasPaths := a.cfg.DIDDocumentPath != "" && (a.cfg.PrivateKeyPath != "" || a.privateKey != nil)
 
 	if !hasDirectMaterial && !hasPaths {
-		return nil, fmt.Errorf("must provide either DID material (WithDIDMaterial) or paths (WithDIDCfgPaths)")
+		return nil, fmt.Errorf("must provide either DID material (WithDIDMaterial), paths (WithDIDCfgPaths) or a signer (WithDIDSigner)")
 	}
 
 	return a, nil
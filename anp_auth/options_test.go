@@ -1,13 +1,17 @@
 package anp_auth
 
 import (
+	gocrypto "crypto"
 	"crypto/ecdsa"
+	"errors"
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/bytedance/sonic"
 	"github.com/openanp/anp-go/crypto"
+	"github.com/openanp/anp-go/keystore"
 )
 
 func TestNewAuthenticator_DirectMaterial(t *testing.T) {
@@ -156,6 +160,76 @@ func TestNewAuthenticator_EagerLoading(t *testing.T) {
 	}
 }
 
+// remoteSigner hides the concrete key type, like a KMS or HSM signer.
+type remoteSigner struct{ gocrypto.Signer }
+
+func TestNewAuthenticator_Signer(t *testing.T) {
+	doc, privateKey, err := CreateDIDWBADocument("example.com", nil, nil, nil)
+	if err != nil {
+		t.Fatalf("CreateDIDWBADocument() error = %v", err)
+	}
+	didPath := filepath.Join(t.TempDir(), "did.json")
+	docBytes, _ := doc.Marshal()
+	if err := os.WriteFile(didPath, docBytes, 0600); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+	signer := remoteSigner{privateKey}
+
+	for _, eager := range []bool{false, true} {
+		opts := []AuthenticatorOption{WithDIDSigner(didPath, signer)}
+		if eager {
+			opts = append(opts, WithEagerLoading())
+		}
+		auth, err := NewAuthenticator(opts...)
+		if err != nil {
+			t.Fatalf("NewAuthenticator(eager=%v) error = %v", eager, err)
+		}
+		headers, err := auth.GenerateHeader("https://api.example.com/data")
+		if err != nil {
+			t.Fatalf("GenerateHeader(eager=%v) error = %v", eager, err)
+		}
+		if headers[AuthorizationHeader] == "" {
+			t.Errorf("GenerateHeader(eager=%v) returned no Authorization header", eager)
+		}
+	}
+
+	if _, err := NewAuthenticator(WithDIDSigner(didPath, nil)); err == nil {
+		t.Error("NewAuthenticator(nil signer) error = nil, want error")
+	}
+	if _, err := NewAuthenticator(WithDIDSigner("", signer)); err == nil {
+		t.Error("NewAuthenticator(empty path) error = nil, want error")
+	}
+}
+
+func TestNewAuthenticator_Keystore(t *testing.T) {
+	doc, privateKey, err := CreateDIDWBADocument("example.com", nil, nil, nil)
+	if err != nil {
+		t.Fatalf("CreateDIDWBADocument() error = %v", err)
+	}
+	dir := t.TempDir()
+	didPath := filepath.Join(dir, "did.json")
+	docBytes, _ := doc.Marshal()
+	if err := os.WriteFile(didPath, docBytes, 0600); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+	ks := keystore.New(filepath.Join(dir, "keys"), keystore.WithScrypt(keystore.LightScryptN, keystore.LightScryptP))
+	if _, err := ks.Create("agent", privateKey, "secret"); err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+
+	auth, err := NewAuthenticator(WithKeystore(didPath, ks, "agent", "secret"), WithEagerLoading())
+	if err != nil {
+		t.Fatalf("NewAuthenticator() error = %v", err)
+	}
+	if _, err := auth.GenerateHeader("https://api.example.com/data"); err != nil {
+		t.Errorf("GenerateHeader() error = %v", err)
+	}
+
+	if _, err := NewAuthenticator(WithKeystore(didPath, ks, "agent", "wrong")); !errors.Is(err, keystore.ErrWrongPassphrase) {
+		t.Errorf("NewAuthenticator(wrong passphrase) error = %v, want ErrWrongPassphrase", err)
+	}
+}
+
 func TestNewAuthenticator_InvalidPaths(t *testing.T) {
 	tests := []struct {
 		name    string
@@ -231,3 +305,57 @@ func TestNewAuthenticator_InvalidCacheSize(t *testing.T) {
 func (d *DIDWBADocument) Marshal() ([]byte, error) {
 	return sonic.Marshal(d)
 }
+
+func TestNewAuthenticator_CacheLimits(t *testing.T) {
+	doc, privateKey, err := CreateDIDWBADocument("example.com", nil, nil, nil)
+	if err != nil {
+		t.Fatalf("CreateDIDWBADocument() error = %v", err)
+	}
+
+	auth, err := NewAuthenticator(
+		WithDIDMaterial(doc, privateKey),
+		WithMaxCacheEntries(2),
+		WithCacheTTL(time.Minute),
+	)
+	if err != nil {
+		t.Fatalf("NewAuthenticator() error = %v", err)
+	}
+	for _, host := range []string{"a.example.com", "b.example.com", "c.example.com"} {
+		if _, err := auth.GenerateHeader("https://" + host + "/ad.json"); err != nil {
+			t.Fatalf("GenerateHeader(%s) error = %v", host, err)
+		}
+	}
+	if n := auth.authHeaders.len(); n != 2 {
+		t.Errorf("cached headers = %d, want 2", n)
+	}
+	if auth.authHeaders.ttl != time.Minute || auth.tokens.maxEntries != 2 {
+		t.Errorf("cache limits not applied: ttl = %v, max = %d", auth.authHeaders.ttl, auth.tokens.maxEntries)
+	}
+
+	defaults, err := NewAuthenticator(WithDIDMaterial(doc, privateKey))
+	if err != nil {
+		t.Fatalf("NewAuthenticator() error = %v", err)
+	}
+	if defaults.tokens.maxEntries != DefaultMaxCacheEntries {
+		t.Errorf("default max entries = %d, want %d", defaults.tokens.maxEntries, DefaultMaxCacheEntries)
+	}
+	if defaults.authHeaders.ttl != DefaultHeaderCacheTTL || defaults.tokens.ttl != 0 {
+		t.Errorf("default ttl = %v for headers, %v for tokens", defaults.authHeaders.ttl, defaults.tokens.ttl)
+	}
+	if DefaultHeaderCacheTTL >= DefaultTimestampExpiration {
+		t.Errorf("DefaultHeaderCacheTTL %v outlives the verifier's timestamp window %v", DefaultHeaderCacheTTL, DefaultTimestampExpiration)
+	}
+	forever, err := NewAuthenticator(WithDIDMaterial(doc, privateKey), WithCacheTTL(0))
+	if err != nil {
+		t.Fatalf("NewAuthenticator() error = %v", err)
+	}
+	if forever.authHeaders.ttl != 0 {
+		t.Errorf("WithCacheTTL(0) header ttl = %v, want no expiry", forever.authHeaders.ttl)
+	}
+
+	for _, opt := range []AuthenticatorOption{WithMaxCacheEntries(-1), WithCacheTTL(-time.Second)} {
+		if _, err := NewAuthenticator(WithDIDMaterial(doc, privateKey), opt); err == nil {
+			t.Error("NewAuthenticator() with negative cache limit succeeded")
+		}
+	}
+}
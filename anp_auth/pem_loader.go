@@ -1,6 +1,8 @@
 package anp_auth
 
 import (
+	"bytes"
+	"crypto"
 	"crypto/ecdsa"
 	"crypto/ed25519"
 	"crypto/rsa"
@@ -33,6 +35,24 @@ func LoadJWTPrivateKeyFromPEM(pemBytes []byte) (any, error) {
 	}
 }
 
+// parseDIDPrivateKey parses the private key of a DID document: an ECDSA key
+// on secp256k1 or P-256 as PEM or JWK, or an Ed25519 key as PKCS#8 PEM or JWK.
+func parseDIDPrivateKey(pemBytes []byte) (crypto.Signer, error) {
+	if trimmed := bytes.TrimSpace(pemBytes); len(trimmed) > 0 && trimmed[0] == '{' {
+		return anpcrypto.PrivateKeyFromJWK(trimmed)
+	}
+	key, err := anpcrypto.PrivateKeyFromPEM(pemBytes)
+	if err == nil {
+		return key, nil
+	}
+	if edKey, edErr := jwt.ParseEdPrivateKeyFromPEM(pemBytes); edErr == nil {
+		if signer, ok := edKey.(ed25519.PrivateKey); ok {
+			return signer, nil
+		}
+	}
+	return nil, err
+}
+
 // LoadJWTPublicKeyFromPEM parses a PEM-encoded public key for JWT verification.
 // It supports RSA, ECDSA (including secp256k1), and Ed25519 keys.
 func LoadJWTPublicKeyFromPEM(pemBytes []byte) (any, error) {
@@ -0,0 +1,282 @@
+// Package pkcs11 provides a crypto.Signer for a DID key kept on a PKCS#11
+// token such as an HSM, SoftHSM or a YubiKey (through ykcs11), so the key
+// never leaves the device while Authenticator and Transport work as usual.
+//
+// It is a separate module because it needs cgo to load the PKCS#11 library:
+//
+//	signer, _ := pkcs11.Open(pkcs11.Config{
+//		Module:     "/usr/lib/softhsm/libsofthsm2.so",
+//		TokenLabel: "agents",
+//		PIN:        os.Getenv("HSM_PIN"),
+//		KeyLabel:   "did-key",
+//	})
+//	defer signer.Close()
+//	auth, _ := anp_auth.NewAuthenticator(anp_auth.WithDIDSigner("did.json", signer))
+//
+// The key must be a secp256k1 or P-256 EC key whose public key object shares
+// the private key's label or ID.
+package pkcs11
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"encoding/asn1"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"sync"
+
+	p11 "github.com/miekg/pkcs11"
+	anpcrypto "github.com/openanp/anp-go/crypto"
+)
+
+// Config selects the PKCS#11 library, token and key.
+type Config struct {
+	// Module is the path of the PKCS#11 library.
+	Module string
+	// TokenLabel selects the token by label; when empty, Slot is used.
+	TokenLabel string
+	// Slot is the slot ID used when TokenLabel is empty.
+	Slot uint
+	// PIN logs in as the token's user.
+	PIN string
+	// KeyLabel and KeyID select the key by CKA_LABEL and CKA_ID; at least
+	// one must be set.
+	KeyLabel string
+	KeyID    []byte
+}
+
+// module is the part of *pkcs11.Ctx used once a session is open.
+type module interface {
+	FindObjectsInit(sh p11.SessionHandle, temp []*p11.Attribute) error
+	FindObjects(sh p11.SessionHandle, max int) ([]p11.ObjectHandle, bool, error)
+	FindObjectsFinal(sh p11.SessionHandle) error
+	GetAttributeValue(sh p11.SessionHandle, o p11.ObjectHandle, a []*p11.Attribute) ([]*p11.Attribute, error)
+	SignInit(sh p11.SessionHandle, m []*p11.Mechanism, o p11.ObjectHandle) error
+	Sign(sh p11.SessionHandle, message []byte) ([]byte, error)
+}
+
+var _ crypto.Signer = (*Signer)(nil)
+
+// Signer signs SHA-256 digests on the token with CKM_ECDSA. Tokens return
+// R||S signatures; Sign re-encodes them as ASN.1 DER like *ecdsa.PrivateKey,
+// and anp_auth.SignContent converts them back for DID-WBA. A Signer uses one
+// session, so signatures are serialized.
+type Signer struct {
+	mu        sync.Mutex
+	mod       module
+	session   p11.SessionHandle
+	key       p11.ObjectHandle
+	publicKey *ecdsa.PublicKey
+	close     func() error
+}
+
+// Open loads the PKCS#11 library, logs in to the token and finds the key.
+// Close releases the session and the library.
+func Open(cfg Config) (*Signer, error) {
+	if cfg.KeyLabel == "" && len(cfg.KeyID) == 0 {
+		return nil, errors.New("PKCS#11 key label or ID is required")
+	}
+	ctx := p11.New(cfg.Module)
+	if ctx == nil {
+		return nil, fmt.Errorf("load PKCS#11 module %s", cfg.Module)
+	}
+	if err := ctx.Initialize(); err != nil {
+		ctx.Destroy()
+		return nil, fmt.Errorf("initialize PKCS#11 module: %w", err)
+	}
+	finalize := func() {
+		ctx.Finalize()
+		ctx.Destroy()
+	}
+
+	slot, err := findSlot(ctx, cfg)
+	if err != nil {
+		finalize()
+		return nil, err
+	}
+	session, err := ctx.OpenSession(slot, p11.CKF_SERIAL_SESSION)
+	if err != nil {
+		finalize()
+		return nil, fmt.Errorf("open PKCS#11 session: %w", err)
+	}
+	closeSession := func() error {
+		err := ctx.CloseSession(session)
+		finalize()
+		return err
+	}
+	if err := ctx.Login(session, p11.CKU_USER, cfg.PIN); err != nil && !errors.Is(err, p11.Error(p11.CKR_USER_ALREADY_LOGGED_IN)) {
+		closeSession()
+		return nil, fmt.Errorf("PKCS#11 login: %w", err)
+	}
+
+	s, err := newSigner(ctx, session, cfg.KeyLabel, cfg.KeyID)
+	if err != nil {
+		closeSession()
+		return nil, err
+	}
+	s.close = func() error {
+		ctx.Logout(session)
+		return closeSession()
+	}
+	return s, nil
+}
+
+func findSlot(ctx *p11.Ctx, cfg Config) (uint, error) {
+	if cfg.TokenLabel == "" {
+		return cfg.Slot, nil
+	}
+	slots, err := ctx.GetSlotList(true)
+	if err != nil {
+		return 0, fmt.Errorf("list PKCS#11 slots: %w", err)
+	}
+	for _, slot := range slots {
+		info, err := ctx.GetTokenInfo(slot)
+		if err == nil && info.Label == cfg.TokenLabel {
+			return slot, nil
+		}
+	}
+	return 0, fmt.Errorf("no PKCS#11 token labelled %q", cfg.TokenLabel)
+}
+
+// newSigner finds the private key and reads the curve and point of its
+// public key object.
+func newSigner(mod module, session p11.SessionHandle, label string, id []byte) (*Signer, error) {
+	key, err := findObject(mod, session, p11.CKO_PRIVATE_KEY, label, id)
+	if err != nil {
+		return nil, err
+	}
+	pub, err := findObject(mod, session, p11.CKO_PUBLIC_KEY, label, id)
+	if err != nil {
+		return nil, err
+	}
+	attrs, err := mod.GetAttributeValue(session, pub, []*p11.Attribute{
+		p11.NewAttribute(p11.CKA_EC_PARAMS, nil),
+		p11.NewAttribute(p11.CKA_EC_POINT, nil),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("read PKCS#11 public key: %w", err)
+	}
+	var params, point []byte
+	for _, a := range attrs {
+		switch a.Type {
+		case p11.CKA_EC_PARAMS:
+			params = a.Value
+		case p11.CKA_EC_POINT:
+			point = a.Value
+		}
+	}
+	publicKey, err := parseECPublicKey(params, point)
+	if err != nil {
+		return nil, err
+	}
+	return &Signer{mod: mod, session: session, key: key, publicKey: publicKey}, nil
+}
+
+func findObject(mod module, session p11.SessionHandle, class uint, label string, id []byte) (p11.ObjectHandle, error) {
+	template := []*p11.Attribute{
+		p11.NewAttribute(p11.CKA_CLASS, class),
+		p11.NewAttribute(p11.CKA_KEY_TYPE, p11.CKK_EC),
+	}
+	if label != "" {
+		template = append(template, p11.NewAttribute(p11.CKA_LABEL, label))
+	}
+	if len(id) > 0 {
+		template = append(template, p11.NewAttribute(p11.CKA_ID, id))
+	}
+	if err := mod.FindObjectsInit(session, template); err != nil {
+		return 0, fmt.Errorf("find PKCS#11 key: %w", err)
+	}
+	objects, _, err := mod.FindObjects(session, 2)
+	if finalErr := mod.FindObjectsFinal(session); err == nil {
+		err = finalErr
+	}
+	if err != nil {
+		return 0, fmt.Errorf("find PKCS#11 key: %w", err)
+	}
+	kind := "private"
+	if class == p11.CKO_PUBLIC_KEY {
+		kind = "public"
+	}
+	switch len(objects) {
+	case 0:
+		return 0, fmt.Errorf("no PKCS#11 EC %s key with label %q and ID %x", kind, label, id)
+	case 1:
+		return objects[0], nil
+	}
+	return 0, fmt.Errorf("several PKCS#11 EC %s keys with label %q and ID %x", kind, label, id)
+}
+
+var oidPublicKeyECDSA = asn1.ObjectIdentifier{1, 2, 840, 10045, 2, 1}
+
+type algorithmIdentifier struct {
+	Algorithm  asn1.ObjectIdentifier
+	Parameters asn1.RawValue
+}
+
+type subjectPublicKeyInfo struct {
+	Algo      algorithmIdentifier
+	PublicKey asn1.BitString
+}
+
+// parseECPublicKey decodes CKA_EC_PARAMS (the curve OID) and CKA_EC_POINT (a
+// DER OCTET STRING holding the point, or the bare point on some tokens).
+func parseECPublicKey(params, point []byte) (*ecdsa.PublicKey, error) {
+	var wrapped []byte
+	if rest, err := asn1.Unmarshal(point, &wrapped); err == nil && len(rest) == 0 && (len(wrapped) == 65 || len(wrapped) == 33) {
+		point = wrapped
+	}
+	spki, err := asn1.Marshal(subjectPublicKeyInfo{
+		Algo:      algorithmIdentifier{Algorithm: oidPublicKeyECDSA, Parameters: asn1.RawValue{FullBytes: params}},
+		PublicKey: asn1.BitString{Bytes: point, BitLength: len(point) * 8},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("encode PKCS#11 public key: %w", err)
+	}
+	key, err := anpcrypto.PublicKeyFromDER(spki)
+	if err != nil {
+		return nil, fmt.Errorf("decode PKCS#11 public key: %w", err)
+	}
+	return key, nil
+}
+
+// Public returns the *ecdsa.PublicKey of the token key.
+func (s *Signer) Public() crypto.PublicKey {
+	return s.publicKey
+}
+
+// Sign signs a SHA-256 digest on the token. rand is unused.
+func (s *Signer) Sign(_ io.Reader, digest []byte, opts crypto.SignerOpts) ([]byte, error) {
+	if opts == nil || opts.HashFunc() != crypto.SHA256 || len(digest) != crypto.SHA256.Size() {
+		return nil, fmt.Errorf("PKCS#11 signer only signs SHA-256 digests")
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.mod.SignInit(s.session, []*p11.Mechanism{p11.NewMechanism(p11.CKM_ECDSA, nil)}, s.key); err != nil {
+		return nil, fmt.Errorf("PKCS#11 sign: %w", err)
+	}
+	raw, err := s.mod.Sign(s.session, digest)
+	if err != nil {
+		return nil, fmt.Errorf("PKCS#11 sign: %w", err)
+	}
+	if len(raw) != 64 {
+		return nil, fmt.Errorf("PKCS#11 sign: unexpected signature length %d", len(raw))
+	}
+	return asn1.Marshal(struct{ R, S *big.Int }{
+		new(big.Int).SetBytes(raw[:32]),
+		new(big.Int).SetBytes(raw[32:]),
+	})
+}
+
+// Close logs out and releases the session and the PKCS#11 library.
+func (s *Signer) Close() error {
+	if s.close == nil {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	err := s.close()
+	s.close = nil
+	return err
+}
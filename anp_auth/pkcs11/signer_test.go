@@ -0,0 +1,137 @@
+package pkcs11
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/asn1"
+	"testing"
+
+	p11 "github.com/miekg/pkcs11"
+	"github.com/openanp/anp-go/anp_auth"
+	anpcrypto "github.com/openanp/anp-go/crypto"
+)
+
+// fakeToken holds one EC key pair the way a PKCS#11 token exposes it.
+type fakeToken struct {
+	key      *ecdsa.PrivateKey
+	curveOID asn1.ObjectIdentifier
+	label    string
+	bare     bool // return CKA_EC_POINT without the OCTET STRING wrapper
+
+	found []p11.ObjectHandle
+}
+
+const (
+	privateHandle p11.ObjectHandle = 1
+	publicHandle  p11.ObjectHandle = 2
+)
+
+func (f *fakeToken) FindObjectsInit(_ p11.SessionHandle, temp []*p11.Attribute) error {
+	var class p11.ObjectHandle
+	f.found = nil
+	for _, a := range temp {
+		switch a.Type {
+		case p11.CKA_LABEL:
+			if string(a.Value) != f.label {
+				return nil
+			}
+		case p11.CKA_CLASS:
+			class = privateHandle
+			if bytes.Equal(a.Value, p11.NewAttribute(p11.CKA_CLASS, p11.CKO_PUBLIC_KEY).Value) {
+				class = publicHandle
+			}
+		}
+	}
+	f.found = []p11.ObjectHandle{class}
+	return nil
+}
+
+func (f *fakeToken) FindObjects(p11.SessionHandle, int) ([]p11.ObjectHandle, bool, error) {
+	return f.found, false, nil
+}
+
+func (f *fakeToken) FindObjectsFinal(p11.SessionHandle) error { return nil }
+
+func (f *fakeToken) GetAttributeValue(_ p11.SessionHandle, _ p11.ObjectHandle, _ []*p11.Attribute) ([]*p11.Attribute, error) {
+	params, _ := asn1.Marshal(f.curveOID)
+	point := elliptic.Marshal(f.key.Curve, f.key.X, f.key.Y)
+	if !f.bare {
+		point, _ = asn1.Marshal(point)
+	}
+	return []*p11.Attribute{
+		p11.NewAttribute(p11.CKA_EC_PARAMS, params),
+		p11.NewAttribute(p11.CKA_EC_POINT, point),
+	}, nil
+}
+
+func (f *fakeToken) SignInit(_ p11.SessionHandle, m []*p11.Mechanism, o p11.ObjectHandle) error {
+	if len(m) != 1 || m[0].Mechanism != p11.CKM_ECDSA || o != privateHandle {
+		return p11.Error(p11.CKR_MECHANISM_INVALID)
+	}
+	return nil
+}
+
+func (f *fakeToken) Sign(_ p11.SessionHandle, digest []byte) ([]byte, error) {
+	r, s, err := ecdsa.Sign(rand.Reader, f.key, digest)
+	if err != nil {
+		return nil, err
+	}
+	return append(r.FillBytes(make([]byte, 32)), s.FillBytes(make([]byte, 32))...), nil
+}
+
+func TestSigner(t *testing.T) {
+	k1, _ := anpcrypto.GenerateECKeyPair(anpcrypto.Secp256k1())
+	p256, _ := anpcrypto.GenerateECKeyPair(anpcrypto.P256())
+	tests := []struct {
+		name  string
+		token *fakeToken
+	}{
+		{"secp256k1", &fakeToken{key: k1, curveOID: asn1.ObjectIdentifier{1, 3, 132, 0, 10}, label: "did-key"}},
+		{"P-256", &fakeToken{key: p256, curveOID: asn1.ObjectIdentifier{1, 2, 840, 10045, 3, 1, 7}, label: "did-key"}},
+		{"bare point", &fakeToken{key: p256, curveOID: asn1.ObjectIdentifier{1, 2, 840, 10045, 3, 1, 7}, label: "did-key", bare: true}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			signer, err := newSigner(tt.token, 1, "did-key", nil)
+			if err != nil {
+				t.Fatalf("newSigner() error = %v", err)
+			}
+			if !tt.token.key.PublicKey.Equal(signer.Public()) {
+				t.Fatal("Public() does not match the token key")
+			}
+
+			doc, err := anp_auth.NewDIDDocumentBuilderForHost("agent.example.com", nil, nil).
+				AddKey("key-1", signer.Public()).
+				Authentication("key-1").
+				Build()
+			if err != nil {
+				t.Fatalf("Build() error = %v", err)
+			}
+			content := []byte("payload")
+			sig, err := anp_auth.SignContent(signer, content)
+			if err != nil {
+				t.Fatalf("SignContent() error = %v", err)
+			}
+			if err := anp_auth.VerifyContent(doc, doc.ID+"#key-1", content, sig); err != nil {
+				t.Errorf("VerifyContent() error = %v", err)
+			}
+			if err := signer.Close(); err != nil {
+				t.Errorf("Close() error = %v", err)
+			}
+		})
+	}
+}
+
+func TestNewSigner_KeyNotFound(t *testing.T) {
+	key, _ := anpcrypto.GenerateECKeyPair(anpcrypto.P256())
+	token := &fakeToken{key: key, curveOID: asn1.ObjectIdentifier{1, 2, 840, 10045, 3, 1, 7}, label: "did-key"}
+	if _, err := newSigner(token, 1, "other", nil); err == nil {
+		t.Error("newSigner() error = nil, want error")
+	}
+	if _, err := Open(Config{Module: "/nonexistent.so"}); err == nil {
+		t.Error("Open() without key label or ID error = nil, want error")
+	}
+}
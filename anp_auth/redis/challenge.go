@@ -0,0 +1,55 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/openanp/anp-go/anp_auth"
+	goredis "github.com/redis/go-redis/v9"
+)
+
+// DefaultChallengePrefix namespaces challenge keys when NewChallengeStore is
+// given none.
+const DefaultChallengePrefix = "anp:challenge:"
+
+var _ anp_auth.ChallengeStore = (*ChallengeStore)(nil)
+
+// ChallengeStore keeps each issued nonce as a key that expires with the
+// challenge. Consume deletes the key, which Redis does atomically, so a nonce
+// is accepted by at most one verifier.
+type ChallengeStore struct {
+	client goredis.UniversalClient
+	prefix string
+	now    func() time.Time
+}
+
+// NewChallengeStore stores challenges in client under keys starting with
+// prefix (DefaultChallengePrefix when empty).
+func NewChallengeStore(client goredis.UniversalClient, prefix string) *ChallengeStore {
+	if prefix == "" {
+		prefix = DefaultChallengePrefix
+	}
+	return &ChallengeStore{client: client, prefix: prefix, now: time.Now}
+}
+
+// Put implements anp_auth.ChallengeStore.
+func (s *ChallengeStore) Put(ctx context.Context, nonce string, expiresAt time.Time) error {
+	ttl := expiresAt.Sub(s.now())
+	if ttl <= 0 {
+		return nil
+	}
+	if err := s.client.Set(ctx, s.prefix+nonce, 1, ttl).Err(); err != nil {
+		return fmt.Errorf("redis: store challenge: %w", err)
+	}
+	return nil
+}
+
+// Consume implements anp_auth.ChallengeStore.
+func (s *ChallengeStore) Consume(ctx context.Context, nonce string) (bool, error) {
+	n, err := s.client.Del(ctx, s.prefix+nonce).Result()
+	if err != nil {
+		return false, fmt.Errorf("redis: consume challenge: %w", err)
+	}
+	return n > 0, nil
+}
@@ -0,0 +1,39 @@
+package redis
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	goredis "github.com/redis/go-redis/v9"
+)
+
+func TestChallengeStore(t *testing.T) {
+	srv := miniredis.RunT(t)
+	client := goredis.NewClient(&goredis.Options{Addr: srv.Addr()})
+	defer client.Close()
+
+	s := NewChallengeStore(client, "")
+	ctx := context.Background()
+	for _, nonce := range []string{"n1", "n2"} {
+		if err := s.Put(ctx, nonce, time.Now().Add(time.Minute)); err != nil {
+			t.Fatalf("Put(%s) error = %v", nonce, err)
+		}
+	}
+
+	if ok, err := s.Consume(ctx, "n1"); err != nil || !ok {
+		t.Fatalf("Consume(n1) = %v, %v, want true", ok, err)
+	}
+	if ok, _ := s.Consume(ctx, "n1"); ok {
+		t.Error("Consume(n1) twice = true, want false")
+	}
+	if ok, _ := s.Consume(ctx, "unknown"); ok {
+		t.Error("Consume(unknown) = true, want false")
+	}
+
+	srv.FastForward(2 * time.Minute)
+	if ok, _ := s.Consume(ctx, "n2"); ok {
+		t.Error("Consume(n2) after expiry = true, want false")
+	}
+}
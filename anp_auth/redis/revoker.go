@@ -0,0 +1,66 @@
+// Package redis provides a Redis-backed anp_auth.TokenRevoker, so a token
+// revoked on one verifier instance is rejected by all of them, and an
+// anp_auth.ChallengeStore, so a challenge issued by one instance can be
+// redeemed at any of them exactly once.
+//
+// It is a separate module so the core SDK does not depend on a Redis client:
+//
+//	client := goredis.NewClient(&goredis.Options{Addr: "localhost:6379"})
+//	verifier, _ := anp_auth.NewDidWbaVerifier(anp_auth.DidWbaVerifierConfig{
+//		TokenRevoker: redis.NewTokenRevoker(client, ""),
+//		// ...
+//	})
+package redis
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/openanp/anp-go/anp_auth"
+	goredis "github.com/redis/go-redis/v9"
+)
+
+// DefaultPrefix namespaces revocation keys when NewTokenRevoker is given none.
+const DefaultPrefix = "anp:revoked:"
+
+var _ anp_auth.TokenRevoker = (*TokenRevoker)(nil)
+
+// TokenRevoker stores each revoked token ID as a key that expires with the
+// token, so the revocation list never outgrows the set of live tokens.
+type TokenRevoker struct {
+	client goredis.UniversalClient
+	prefix string
+	now    func() time.Time
+}
+
+// NewTokenRevoker stores revocations in client under keys starting with
+// prefix (DefaultPrefix when empty).
+func NewTokenRevoker(client goredis.UniversalClient, prefix string) *TokenRevoker {
+	if prefix == "" {
+		prefix = DefaultPrefix
+	}
+	return &TokenRevoker{client: client, prefix: prefix, now: time.Now}
+}
+
+// Revoke implements anp_auth.TokenRevoker.
+func (r *TokenRevoker) Revoke(ctx context.Context, tokenID string, expiresAt time.Time) error {
+	ttl := expiresAt.Sub(r.now())
+	if ttl <= 0 {
+		// Already expired; verifiers reject it without consulting the list.
+		return nil
+	}
+	if err := r.client.Set(ctx, r.prefix+tokenID, 1, ttl).Err(); err != nil {
+		return fmt.Errorf("redis: revoke token: %w", err)
+	}
+	return nil
+}
+
+// IsRevoked implements anp_auth.TokenRevoker.
+func (r *TokenRevoker) IsRevoked(ctx context.Context, tokenID string) (bool, error) {
+	n, err := r.client.Exists(ctx, r.prefix+tokenID).Result()
+	if err != nil {
+		return false, fmt.Errorf("redis: check token: %w", err)
+	}
+	return n > 0, nil
+}
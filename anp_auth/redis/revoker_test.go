@@ -0,0 +1,51 @@
+package redis
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	goredis "github.com/redis/go-redis/v9"
+)
+
+func TestTokenRevoker(t *testing.T) {
+	srv := miniredis.RunT(t)
+	client := goredis.NewClient(&goredis.Options{Addr: srv.Addr()})
+	defer client.Close()
+
+	r := NewTokenRevoker(client, "")
+	ctx := context.Background()
+	if err := r.Revoke(ctx, "jti-1", time.Now().Add(time.Minute)); err != nil {
+		t.Fatalf("Revoke() error = %v", err)
+	}
+	if err := r.Revoke(ctx, "jti-2", time.Now().Add(-time.Minute)); err != nil {
+		t.Fatalf("Revoke(expired) error = %v", err)
+	}
+
+	tests := []struct {
+		id   string
+		want bool
+	}{
+		{"jti-1", true},
+		{"jti-2", false},
+		{"jti-3", false},
+	}
+	for _, tt := range tests {
+		got, err := r.IsRevoked(ctx, tt.id)
+		if err != nil {
+			t.Fatalf("IsRevoked(%s) error = %v", tt.id, err)
+		}
+		if got != tt.want {
+			t.Errorf("IsRevoked(%s) = %v, want %v", tt.id, got, tt.want)
+		}
+	}
+	if ttl := srv.TTL(DefaultPrefix + "jti-1"); ttl <= 0 || ttl > time.Minute {
+		t.Errorf("TTL = %v, want expiry with the token", ttl)
+	}
+
+	srv.FastForward(2 * time.Minute)
+	if got, _ := r.IsRevoked(ctx, "jti-1"); got {
+		t.Error("IsRevoked(jti-1) after expiry = true")
+	}
+}
@@ -0,0 +1,116 @@
+package anp_auth
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestRefreshAccessToken(t *testing.T) {
+	user := newDelegationParty(t, "user.example.com")
+	agent := newDelegationParty(t, "agent.example.com")
+	grant, err := IssueDelegation(user.key, user.doc, agent.doc.ID, "tool.example.com", 10*time.Minute)
+	if err != nil {
+		t.Fatalf("IssueDelegation() error = %v", err)
+	}
+
+	jwtKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("GenerateKey() error = %v", err)
+	}
+	verifier, err := NewDidWbaVerifier(DidWbaVerifierConfig{
+		JWTPrivateKey:          jwtKey,
+		JWTPublicKey:           &jwtKey.PublicKey,
+		RefreshTokenExpiration: 24 * time.Hour,
+		NonceValidator:         NewMemoryNonceValidator(time.Minute),
+		ResolveDIDDocument:     resolverFor(t, user, agent),
+	})
+	if err != nil {
+		t.Fatalf("NewDidWbaVerifier() error = %v", err)
+	}
+
+	login := func(opts ...AuthenticatorOption) map[string]any {
+		t.Helper()
+		auth, err := NewAuthenticator(append([]AuthenticatorOption{WithDIDMaterial(agent.doc, agent.key)}, opts...)...)
+		if err != nil {
+			t.Fatalf("NewAuthenticator() error = %v", err)
+		}
+		headers, err := auth.GenerateHeader("https://tool.example.com/rpc")
+		if err != nil {
+			t.Fatalf("GenerateHeader() error = %v", err)
+		}
+		result, err := verifier.VerifyAuthHeader(headers[AuthorizationHeader], "tool.example.com")
+		if err != nil {
+			t.Fatalf("VerifyAuthHeader() error = %v", err)
+		}
+		return result
+	}
+
+	result := login()
+	refreshToken, ok := result["refresh_token"].(string)
+	if !ok {
+		t.Fatalf("result = %v, want refresh_token", result)
+	}
+	refreshed, err := verifier.RefreshAccessToken(refreshToken, "tool.example.com")
+	if err != nil {
+		t.Fatalf("RefreshAccessToken() error = %v", err)
+	}
+	if refreshed["did"] != agent.doc.ID || refreshed["refresh_token"] != nil {
+		t.Errorf("refreshed = %v", refreshed)
+	}
+	if _, err := verifier.VerifyAuthHeader(BearerScheme+refreshed["access_token"].(string), "tool.example.com"); err != nil {
+		t.Errorf("VerifyAuthHeader(refreshed token) error = %v", err)
+	}
+
+	// Neither token type is accepted in place of the other.
+	if _, err := verifier.VerifyAuthHeader(BearerScheme+refreshToken, "tool.example.com"); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("VerifyAuthHeader(refresh token) error = %v, want ErrInvalidToken", err)
+	}
+	if _, err := verifier.RefreshAccessToken(result["access_token"].(string), "tool.example.com"); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("RefreshAccessToken(access token) error = %v, want ErrInvalidToken", err)
+	}
+
+	// Delegated sessions cannot be refreshed past the delegation's expiry.
+	delegated := login(WithDelegation(DelegationChain{grant}))
+	claims, err := ParseRefreshToken(delegated["refresh_token"].(string), &jwtKey.PublicKey, DefaultJWTAlgorithm)
+	if err != nil {
+		t.Fatalf("ParseRefreshToken() error = %v", err)
+	}
+	if claims.Subject != user.doc.ID || claims.Actor != agent.doc.ID {
+		t.Errorf("refresh claims = %+v", claims)
+	}
+	if expires, _ := time.Parse(time.RFC3339, grant.ExpiresAt); claims.ExpiresAt.After(expires.Add(time.Second)) {
+		t.Errorf("refresh token expires %v, after delegation %v", claims.ExpiresAt, expires)
+	}
+	refreshed, err = verifier.RefreshAccessToken(delegated["refresh_token"].(string), "tool.example.com")
+	if err != nil {
+		t.Fatalf("RefreshAccessToken(delegated) error = %v", err)
+	}
+	if refreshed["actor_did"] != agent.doc.ID {
+		t.Errorf("refreshed delegated = %v", refreshed)
+	}
+}
+
+func TestRefreshAccessTokenDisabled(t *testing.T) {
+	jwtKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("GenerateKey() error = %v", err)
+	}
+	verifier, err := NewDidWbaVerifier(DidWbaVerifierConfig{
+		JWTPrivateKey:  jwtKey,
+		JWTPublicKey:   &jwtKey.PublicKey,
+		NonceValidator: NewMemoryNonceValidator(time.Minute),
+	})
+	if err != nil {
+		t.Fatalf("NewDidWbaVerifier() error = %v", err)
+	}
+	token, err := CreateRefreshToken("did:wba:example.com", "", jwtKey, DefaultJWTAlgorithm, time.Hour)
+	if err != nil {
+		t.Fatalf("CreateRefreshToken() error = %v", err)
+	}
+	if _, err := verifier.RefreshAccessToken(token, "example.com"); !errors.Is(err, ErrRefreshDisabled) {
+		t.Errorf("RefreshAccessToken() error = %v, want ErrRefreshDisabled", err)
+	}
+}
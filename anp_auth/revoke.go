@@ -0,0 +1,94 @@
+package anp_auth
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"sync"
+	"time"
+)
+
+// TokenRevoker records revoked token IDs (the jti claim), so compromised
+// access and refresh tokens can be rejected before they expire.
+type TokenRevoker interface {
+	// Revoke marks tokenID as revoked. The record may be dropped after
+	// expiresAt, when the token is rejected as expired anyway.
+	Revoke(ctx context.Context, tokenID string, expiresAt time.Time) error
+	// IsRevoked reports whether tokenID has been revoked.
+	IsRevoked(ctx context.Context, tokenID string) (bool, error)
+}
+
+// MemoryTokenRevoker is an in-memory TokenRevoker. Like MemoryNonceValidator
+// it only covers a single process; use a shared store such as the one in
+// anp_auth/redis when several verifiers accept the same tokens.
+type MemoryTokenRevoker struct {
+	mu      sync.Mutex
+	revoked map[string]time.Time
+	now     func() time.Time
+}
+
+// NewMemoryTokenRevoker creates an empty in-memory revocation list.
+func NewMemoryTokenRevoker() *MemoryTokenRevoker {
+	return &MemoryTokenRevoker{revoked: make(map[string]time.Time), now: time.Now}
+}
+
+// Revoke implements TokenRevoker. Records past their expiry are dropped.
+func (r *MemoryTokenRevoker) Revoke(_ context.Context, tokenID string, expiresAt time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := r.now()
+	for id, exp := range r.revoked {
+		if now.After(exp) {
+			delete(r.revoked, id)
+		}
+	}
+	r.revoked[tokenID] = expiresAt
+	return nil
+}
+
+// IsRevoked implements TokenRevoker.
+func (r *MemoryTokenRevoker) IsRevoked(_ context.Context, tokenID string) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	_, ok := r.revoked[tokenID]
+	return ok, nil
+}
+
+// Revoke revokes the token with the given ID (its jti claim, reported as
+// AccessTokenClaims.ID). The revocation is kept for the longest lifetime of
+// a token this verifier issues.
+func (v *DidWbaVerifier) Revoke(ctx context.Context, tokenID string) error {
+	if v.config.TokenRevoker == nil {
+		return ErrTokenRevokerMissing
+	}
+	lifetime := max(v.config.AccessTokenExpiration, v.config.RefreshTokenExpiration)
+	for _, t := range v.tenants {
+		lifetime = max(lifetime, t.tokenExpiration, t.refreshExpiration)
+	}
+	return v.config.TokenRevoker.Revoke(ctx, tokenID, v.now().Add(lifetime))
+}
+
+// checkRevoked rejects tokens whose ID has been revoked. Tokens without an ID,
+// issued before revocation was configured, cannot be revoked and pass.
+func (v *DidWbaVerifier) checkRevoked(ctx context.Context, claims *AccessTokenClaims) error {
+	if v.config.TokenRevoker == nil || claims.ID == "" {
+		return nil
+	}
+	revoked, err := v.config.TokenRevoker.IsRevoked(ctx, claims.ID)
+	if err != nil {
+		return NewErrorWithStatus(WrapAuthError(ErrTokenRevokerFailure, "check token revocation", err), StatusInternalServerError)
+	}
+	if revoked {
+		return NewErrorWithStatus(ErrTokenRevoked, StatusUnauthorized)
+	}
+	return nil
+}
+
+// newTokenID returns a random jti for an issued token.
+func newTokenID() (string, error) {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b[:]), nil
+}
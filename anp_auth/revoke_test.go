@@ -0,0 +1,104 @@
+package anp_auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestVerifierRevoke(t *testing.T) {
+	party := newDelegationParty(t, "client.example.com")
+	auth, err := NewAuthenticator(WithDIDMaterial(party.doc, party.key))
+	if err != nil {
+		t.Fatalf("NewAuthenticator() error = %v", err)
+	}
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("GenerateKey() error = %v", err)
+	}
+	verifier, err := NewDidWbaVerifier(DidWbaVerifierConfig{
+		JWTPrivateKey:          key,
+		JWTPublicKey:           &key.PublicKey,
+		RefreshTokenExpiration: 24 * time.Hour,
+		NonceValidator:         NewMemoryNonceValidator(time.Minute),
+		TokenRevoker:           NewMemoryTokenRevoker(),
+		ResolveDIDDocument:     resolverFor(t, party),
+	})
+	if err != nil {
+		t.Fatalf("NewDidWbaVerifier() error = %v", err)
+	}
+
+	headers, err := auth.GenerateHeader("https://api.example.com/rpc")
+	if err != nil {
+		t.Fatalf("GenerateHeader() error = %v", err)
+	}
+	result, err := verifier.VerifyAuthHeader(headers[AuthorizationHeader], "api.example.com")
+	if err != nil {
+		t.Fatalf("VerifyAuthHeader() error = %v", err)
+	}
+	access, refresh := result["access_token"].(string), result["refresh_token"].(string)
+	if _, err := verifier.VerifyAuthHeader(BearerScheme+access, "api.example.com"); err != nil {
+		t.Fatalf("VerifyAuthHeader(bearer) error = %v", err)
+	}
+
+	for _, token := range []string{access, refresh} {
+		claims, err := parseToken(token, staticKey(&key.PublicKey, DefaultJWTAlgorithm), token == refresh)
+		if err != nil {
+			t.Fatalf("parseToken() error = %v", err)
+		}
+		if claims.ID == "" {
+			t.Fatal("issued token has no jti")
+		}
+		if err := verifier.Revoke(context.Background(), claims.ID); err != nil {
+			t.Fatalf("Revoke() error = %v", err)
+		}
+	}
+
+	_, err = verifier.VerifyAuthHeader(BearerScheme+access, "api.example.com")
+	var statusErr *ErrorWithStatus
+	if !errors.Is(err, ErrTokenRevoked) || !errors.As(err, &statusErr) || statusErr.StatusCode != StatusUnauthorized {
+		t.Errorf("VerifyAuthHeader(revoked) error = %v, want 401 ErrTokenRevoked", err)
+	}
+	if _, err := verifier.RefreshAccessToken(refresh, "api.example.com"); !errors.Is(err, ErrTokenRevoked) {
+		t.Errorf("RefreshAccessToken(revoked) error = %v, want ErrTokenRevoked", err)
+	}
+}
+
+func TestMemoryTokenRevoker(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	r := NewMemoryTokenRevoker()
+	r.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	if err := r.Revoke(ctx, "a", now.Add(time.Minute)); err != nil {
+		t.Fatalf("Revoke() error = %v", err)
+	}
+	if revoked, _ := r.IsRevoked(ctx, "a"); !revoked {
+		t.Error("IsRevoked(a) = false")
+	}
+	if revoked, _ := r.IsRevoked(ctx, "b"); revoked {
+		t.Error("IsRevoked(b) = true")
+	}
+
+	// Records of expired tokens are dropped on the next revocation.
+	now = now.Add(2 * time.Minute)
+	if err := r.Revoke(ctx, "b", now.Add(time.Minute)); err != nil {
+		t.Fatalf("Revoke() error = %v", err)
+	}
+	if len(r.revoked) != 1 {
+		t.Errorf("revoked = %v, want only b", r.revoked)
+	}
+}
+
+func TestVerifierRevokeWithoutRevoker(t *testing.T) {
+	verifier, err := NewDidWbaVerifier(DidWbaVerifierConfig{NonceValidator: NewMemoryNonceValidator(time.Minute)})
+	if err != nil {
+		t.Fatalf("NewDidWbaVerifier() error = %v", err)
+	}
+	if err := verifier.Revoke(context.Background(), "id"); !errors.Is(err, ErrTokenRevokerMissing) {
+		t.Errorf("Revoke() error = %v, want ErrTokenRevokerMissing", err)
+	}
+}
@@ -55,7 +55,7 @@ func TestAuthenticator_Singleflight_ThunderingHerd(t *testing.T) {
 	// With singleflight, all goroutines should receive the same cached result
 	// after the first one completes. Verify the cache was populated.
 	auth.cacheMutex.Lock()
-	if len(auth.authHeaders) == 0 {
+	if auth.authHeaders.len() == 0 {
 		t.Error("Expected auth headers to be cached")
 	}
 	auth.cacheMutex.Unlock()
@@ -115,7 +115,7 @@ func TestAuthenticator_Singleflight_DifferentDomains(t *testing.T) {
 
 	// Verify all domains were cached
 	auth.cacheMutex.Lock()
-	cachedCount := len(auth.authHeaders)
+	cachedCount := auth.authHeaders.len()
 	auth.cacheMutex.Unlock()
 
 	if cachedCount != len(domains) {
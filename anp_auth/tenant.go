@@ -0,0 +1,185 @@
+package anp_auth
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// TenantConfig configures the verifier for one virtual agent host, so a single
+// gateway can protect many domains with isolated token issuers. Tokens issued
+// for one tenant are signed with its own key and rejected by the others.
+type TenantConfig struct {
+	// JWT keys of the tenant's token issuer, as in DidWbaVerifierConfig. At
+	// least one key is required; keys are never inherited from the top level.
+	JWTPrivateKey    any
+	JWTPublicKey     any
+	JWTPrivateKeyPEM []byte
+	JWTPublicKeyPEM  []byte
+	// JWTKeySet signs with its active key and verifies by kid, as in
+	// DidWbaVerifierConfig.
+	JWTKeySet *JWTKeySet
+	// JWTAlgorithm, JWTIssuer, JWTAudience, AccessTokenExpiration and
+	// RefreshTokenExpiration default to the top-level values.
+	JWTAlgorithm           string
+	JWTIssuer              string
+	JWTAudience            []string
+	AccessTokenExpiration  time.Duration
+	RefreshTokenExpiration time.Duration
+	// AllowedDomains restricts the service domains signatures may name; empty
+	// accepts any domain routed to this tenant.
+	AllowedDomains []string
+	// NonceNamespace prefixes nonces passed to the NonceValidator, so tenants
+	// sharing a nonce store cannot collide. Defaults to the tenant host.
+	NonceNamespace string
+}
+
+// tenant is the issuer configuration applied to one request.
+type tenant struct {
+	privateKey        any
+	publicKey         any
+	keySet            *JWKS      // verifies tokens that carry a kid, if set
+	signer            *JWTKeySet // signs and verifies by kid instead of privateKey, if set
+	algorithm         string
+	tokenExpiration   time.Duration
+	refreshExpiration time.Duration // zero when no refresh tokens are issued
+	allowedDomains    []string
+	nonceNamespace    string
+	claims            []ClaimsOption // iss and aud of issued and accepted tokens
+}
+
+// newTenants loads the tenant keys of config, keyed by lower-cased host.
+func newTenants(config DidWbaVerifierConfig) (map[string]*tenant, error) {
+	if len(config.Tenants) == 0 {
+		return nil, nil
+	}
+	tenants := make(map[string]*tenant, len(config.Tenants))
+	for host, tc := range config.Tenants {
+		host = strings.ToLower(strings.TrimSpace(host))
+		t := &tenant{
+			privateKey:        tc.JWTPrivateKey,
+			publicKey:         tc.JWTPublicKey,
+			signer:            tc.JWTKeySet,
+			algorithm:         tc.JWTAlgorithm,
+			tokenExpiration:   tc.AccessTokenExpiration,
+			refreshExpiration: tc.RefreshTokenExpiration,
+			allowedDomains:    tc.AllowedDomains,
+			nonceNamespace:    tc.NonceNamespace,
+		}
+		if t.privateKey == nil && len(tc.JWTPrivateKeyPEM) > 0 {
+			key, err := LoadJWTPrivateKeyFromPEM(tc.JWTPrivateKeyPEM)
+			if err != nil {
+				return nil, fmt.Errorf("loading JWT private key for tenant %s: %w", host, err)
+			}
+			t.privateKey = key
+		}
+		if t.publicKey == nil && len(tc.JWTPublicKeyPEM) > 0 {
+			key, err := LoadJWTPublicKeyFromPEM(tc.JWTPublicKeyPEM)
+			if err != nil {
+				return nil, fmt.Errorf("loading JWT public key for tenant %s: %w", host, err)
+			}
+			t.publicKey = key
+		}
+		if t.privateKey == nil && t.publicKey == nil && t.signer == nil {
+			return nil, fmt.Errorf("%w: tenant %s", ErrJWTConfigMissing, host)
+		}
+		if t.algorithm == "" {
+			t.algorithm = config.JWTAlgorithm
+		}
+		issuer, audience := tc.JWTIssuer, tc.JWTAudience
+		if issuer == "" {
+			issuer = config.JWTIssuer
+		}
+		if len(audience) == 0 {
+			audience = config.JWTAudience
+		}
+		t.claims = tokenClaimsOptions(issuer, audience)
+		if t.tokenExpiration == 0 {
+			t.tokenExpiration = config.AccessTokenExpiration
+		}
+		if t.refreshExpiration == 0 {
+			t.refreshExpiration = config.RefreshTokenExpiration
+		}
+		if t.nonceNamespace == "" {
+			t.nonceNamespace = host
+		}
+		tenants[host] = t
+	}
+	return tenants, nil
+}
+
+// tenantFor selects the configuration for the requested host, matching it with
+// and without its port. Hosts without a tenant use the top-level configuration.
+func (v *DidWbaVerifier) tenantFor(domain string) *tenant {
+	if len(v.tenants) > 0 {
+		host := strings.ToLower(domain)
+		if t, ok := v.tenants[host]; ok {
+			return t
+		}
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			if t, ok := v.tenants[h]; ok {
+				return t
+			}
+		}
+	}
+	return &tenant{
+		privateKey:        v.config.JWTPrivateKey,
+		publicKey:         v.config.JWTPublicKey,
+		keySet:            v.jwks,
+		signer:            v.config.JWTKeySet,
+		algorithm:         v.config.JWTAlgorithm,
+		tokenExpiration:   v.config.AccessTokenExpiration,
+		refreshExpiration: v.config.RefreshTokenExpiration,
+		allowedDomains:    v.config.AllowedDomains,
+		claims:            tokenClaimsOptions(v.config.JWTIssuer, v.config.JWTAudience),
+	}
+}
+
+// tokenClaimsOptions returns the claims options for a configured issuer and
+// audience.
+func tokenClaimsOptions(issuer string, audience []string) []ClaimsOption {
+	var opts []ClaimsOption
+	if issuer != "" {
+		opts = append(opts, WithIssuer(issuer))
+	}
+	if len(audience) > 0 {
+		opts = append(opts, WithAudience(audience...))
+	}
+	return opts
+}
+
+// canVerify reports whether the tenant has any key to verify tokens with.
+func (t *tenant) canVerify() bool {
+	return t.publicKey != nil || t.keySet != nil || t.signer != nil
+}
+
+// signingKey returns the key new tokens are signed with.
+func (t *tenant) signingKey() (signingKey, bool) {
+	if t.signer != nil {
+		return t.signer.signingKey(), true
+	}
+	return signingKey{key: t.privateKey, algorithm: t.algorithm}, t.privateKey != nil
+}
+
+// keyfunc selects the key that verifies tokens issued by the tenant: the key
+// set entry named by the token's kid, then the JWKS, then the static key.
+func (t *tenant) keyfunc(ctx context.Context) jwt.Keyfunc {
+	fallback := staticKey(t.publicKey, t.algorithm)
+	if t.keySet != nil {
+		fallback = t.keySet.keyfunc(ctx, t.publicKey, t.algorithm)
+	}
+	if t.signer == nil {
+		return fallback
+	}
+	return func(token *jwt.Token) (any, error) {
+		kid, _ := token.Header["kid"].(string)
+		if _, ok := t.signer.lookup(kid); ok || (t.keySet == nil && t.publicKey == nil) {
+			return t.signer.keyfunc(token)
+		}
+		return fallback(token)
+	}
+}
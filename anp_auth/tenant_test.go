@@ -0,0 +1,112 @@
+package anp_auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// recordingNonceValidator accepts every nonce and records what it was given.
+type recordingNonceValidator struct {
+	mu     sync.Mutex
+	nonces []string
+}
+
+func (r *recordingNonceValidator) Validate(_ context.Context, _, nonce string) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.nonces = append(r.nonces, nonce)
+	return true, nil
+}
+
+func TestVerifierTenants(t *testing.T) {
+	caller := newDelegationParty(t, "caller.example.com")
+	auth, err := NewAuthenticator(WithDIDMaterial(caller.doc, caller.key))
+	if err != nil {
+		t.Fatalf("NewAuthenticator() error = %v", err)
+	}
+	newKey := func() *rsa.PrivateKey {
+		key, err := rsa.GenerateKey(rand.Reader, 2048)
+		if err != nil {
+			t.Fatalf("GenerateKey() error = %v", err)
+		}
+		return key
+	}
+	hotelKey, flightKey := newKey(), newKey()
+	nonces := &recordingNonceValidator{}
+
+	verifier, err := NewDidWbaVerifier(DidWbaVerifierConfig{
+		NonceValidator:     nonces,
+		ResolveDIDDocument: resolverFor(t, caller),
+		Tenants: map[string]TenantConfig{
+			"Hotel.example.com": {JWTPrivateKey: hotelKey, JWTPublicKey: &hotelKey.PublicKey},
+			"flight.example.com": {
+				JWTPrivateKey:  flightKey,
+				JWTPublicKey:   &flightKey.PublicKey,
+				AllowedDomains: []string{"flight.example.com"},
+				NonceNamespace: "flights",
+			},
+		},
+	})
+	if err != nil {
+		t.Fatalf("NewDidWbaVerifier() error = %v", err)
+	}
+
+	login := func(host, domain string) (string, error) {
+		t.Helper()
+		headers, err := auth.GenerateHeader("https://" + host + "/rpc")
+		if err != nil {
+			t.Fatalf("GenerateHeader() error = %v", err)
+		}
+		result, err := verifier.VerifyAuthHeader(headers[AuthorizationHeader], domain)
+		if err != nil {
+			return "", err
+		}
+		return result["access_token"].(string), nil
+	}
+
+	hotelToken, err := login("hotel.example.com:8443", "hotel.example.com:8443")
+	if err != nil {
+		t.Fatalf("VerifyAuthHeader(hotel) error = %v", err)
+	}
+	if _, err := ParseAccessToken(hotelToken, &hotelKey.PublicKey, DefaultJWTAlgorithm); err != nil {
+		t.Errorf("hotel token not issued by hotel key: %v", err)
+	}
+	if _, err := verifier.VerifyAuthHeader(BearerScheme+hotelToken, "hotel.example.com"); err != nil {
+		t.Errorf("VerifyAuthHeader(hotel bearer) error = %v", err)
+	}
+	if _, err := verifier.VerifyAuthHeader(BearerScheme+hotelToken, "flight.example.com"); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("VerifyAuthHeader(hotel token at flight) error = %v, want ErrInvalidToken", err)
+	}
+	if _, err := login("flight.example.com", "flight.example.com"); err != nil {
+		t.Fatalf("VerifyAuthHeader(flight) error = %v", err)
+	}
+
+	// Hosts without a tenant fall back to the top level, which has no key here.
+	if _, err := login("other.example.com", "other.example.com"); !errors.Is(err, ErrJWTConfigMissing) {
+		t.Errorf("VerifyAuthHeader(other) error = %v, want ErrJWTConfigMissing", err)
+	}
+
+	if len(nonces.nonces) != 3 {
+		t.Fatalf("nonces = %v", nonces.nonces)
+	}
+	for i, prefix := range []string{"hotel.example.com:", "flights:"} {
+		if got := nonces.nonces[i]; len(got) <= len(prefix) || got[:len(prefix)] != prefix {
+			t.Errorf("nonce %d = %q, want prefix %q", i, got, prefix)
+		}
+	}
+}
+
+func TestVerifierTenantRequiresKey(t *testing.T) {
+	_, err := NewDidWbaVerifier(DidWbaVerifierConfig{
+		NonceValidator: NewMemoryNonceValidator(time.Minute),
+		Tenants:        map[string]TenantConfig{"a.example.com": {}},
+	})
+	if !errors.Is(err, ErrJWTConfigMissing) {
+		t.Errorf("NewDidWbaVerifier() error = %v, want ErrJWTConfigMissing", err)
+	}
+}
@@ -0,0 +1,104 @@
+package anp_auth
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/bytedance/sonic"
+)
+
+// tokenResponse is the OAuth 2.0 style body returned by TokenHandler.
+type tokenResponse struct {
+	AccessToken  string `json:"access_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int64  `json:"expires_in"`
+	RefreshToken string `json:"refresh_token,omitempty"`
+}
+
+// tokenError is the body of a rejected token request.
+type tokenError struct {
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description,omitempty"`
+}
+
+// TokenHandler returns an http.Handler implementing the DID-WBA login
+// exchange. A POST with a DIDWba Authorization header is verified and
+// answered with {"access_token", "token_type", "expires_in"} JSON, plus a
+// "refresh_token" when the verifier issues them. A form-encoded
+// grant_type=refresh_token request exchanges a refresh token for a new
+// access token instead. Mount it outside Middleware:
+//
+//	mux.Handle("/auth/token", anp_auth.TokenHandler(verifier))
+func TokenHandler(verifier *DidWbaVerifier) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			writeTokenError(w, http.StatusMethodNotAllowed, "invalid_request", "method not allowed")
+			return
+		}
+		domain := r.Host
+		if domain == "" {
+			domain = r.URL.Host
+		}
+
+		var (
+			grant  = r.PostFormValue("grant_type")
+			result map[string]any
+			err    error
+		)
+		switch grant {
+		case "refresh_token":
+			result, err = verifier.RefreshAccessToken(r.PostFormValue("refresh_token"), domain)
+		case "":
+			authorization := r.Header.Get(AuthorizationHeader)
+			if strings.HasPrefix(authorization, BearerScheme) {
+				writeTokenError(w, http.StatusBadRequest, "invalid_request", "a DIDWba Authorization header is required")
+				return
+			}
+			result, err = verifier.VerifyAuthHeaderContext(withRemoteAddr(r.Context(), r.RemoteAddr), authorization, domain)
+		default:
+			writeTokenError(w, http.StatusBadRequest, "unsupported_grant_type", grant)
+			return
+		}
+		if err != nil {
+			status := GetStatusCode(err, StatusUnauthorized)
+			code := "invalid_grant"
+			switch {
+			case status >= http.StatusInternalServerError:
+				code = "server_error"
+			case errors.Is(err, ErrRefreshDisabled):
+				code = "unsupported_grant_type"
+			case status == http.StatusUnauthorized && grant == "":
+				code = "invalid_client"
+				w.Header().Set("WWW-Authenticate", DIDWbaScheme)
+			}
+			writeTokenError(w, status, code, err.Error())
+			return
+		}
+
+		resp := tokenResponse{}
+		resp.AccessToken, _ = result["access_token"].(string)
+		resp.TokenType, _ = result["token_type"].(string)
+		resp.ExpiresIn, _ = result["expires_in"].(int64)
+		resp.RefreshToken, _ = result["refresh_token"].(string)
+		writeTokenJSON(w, http.StatusOK, resp)
+	})
+}
+
+func writeTokenError(w http.ResponseWriter, status int, code, description string) {
+	writeTokenJSON(w, status, tokenError{Error: code, ErrorDescription: description})
+}
+
+func writeTokenJSON(w http.ResponseWriter, status int, v any) {
+	body, err := sonic.Marshal(v)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	// Token responses must not be cached (RFC 6749 section 5.1).
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	_, _ = w.Write(body)
+}
@@ -0,0 +1,110 @@
+package anp_auth
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bytedance/sonic"
+)
+
+func TestTokenHandler(t *testing.T) {
+	party := newDelegationParty(t, "client.example.com")
+	auth, err := NewAuthenticator(WithDIDMaterial(party.doc, party.key))
+	if err != nil {
+		t.Fatalf("NewAuthenticator() error = %v", err)
+	}
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("GenerateKey() error = %v", err)
+	}
+	verifier, err := NewDidWbaVerifier(DidWbaVerifierConfig{
+		JWTPrivateKey:          key,
+		JWTPublicKey:           &key.PublicKey,
+		AccessTokenExpiration:  10 * time.Minute,
+		RefreshTokenExpiration: time.Hour,
+		NonceValidator:         NewMemoryNonceValidator(time.Minute),
+		ResolveDIDDocument:     resolverFor(t, party),
+	})
+	if err != nil {
+		t.Fatalf("NewDidWbaVerifier() error = %v", err)
+	}
+	handler := TokenHandler(verifier)
+
+	serve := func(req *http.Request) (*httptest.ResponseRecorder, map[string]any) {
+		t.Helper()
+		req.Host = "api.example.com"
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		var body map[string]any
+		if err := sonic.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+			t.Fatalf("decode response %q: %v", rec.Body.String(), err)
+		}
+		if got := rec.Header().Get("Cache-Control"); got != "no-store" {
+			t.Errorf("Cache-Control = %q, want no-store", got)
+		}
+		return rec, body
+	}
+
+	headers, err := auth.GenerateHeader("https://api.example.com/auth/token")
+	if err != nil {
+		t.Fatalf("GenerateHeader() error = %v", err)
+	}
+	req := httptest.NewRequest(http.MethodPost, "/auth/token", nil)
+	req.Header.Set(AuthorizationHeader, headers[AuthorizationHeader])
+	rec, body := serve(req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("login status = %d, body %v", rec.Code, body)
+	}
+	access, _ := body["access_token"].(string)
+	refresh, _ := body["refresh_token"].(string)
+	if access == "" || refresh == "" || body["token_type"] != "bearer" || body["expires_in"] != float64(600) {
+		t.Fatalf("login body = %v, want access and refresh tokens expiring in 600s", body)
+	}
+	if _, err := verifier.VerifyAuthHeader(BearerScheme+access, "api.example.com"); err != nil {
+		t.Errorf("VerifyAuthHeader(issued token) error = %v", err)
+	}
+
+	form := url.Values{"grant_type": {"refresh_token"}, "refresh_token": {refresh}}
+	req = httptest.NewRequest(http.MethodPost, "/auth/token", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	if rec, body := serve(req); rec.Code != http.StatusOK || body["access_token"] == "" {
+		t.Errorf("refresh status = %d, body %v", rec.Code, body)
+	}
+
+	tests := []struct {
+		name       string
+		method     string
+		header     string
+		form       url.Values
+		wantStatus int
+		wantError  string
+	}{
+		{"replayed header", http.MethodPost, headers[AuthorizationHeader], nil, http.StatusUnauthorized, "invalid_client"},
+		{"missing header", http.MethodPost, "", nil, http.StatusUnauthorized, "invalid_client"},
+		{"bearer header", http.MethodPost, BearerScheme + access, nil, http.StatusBadRequest, "invalid_request"},
+		{"bad refresh token", http.MethodPost, "", url.Values{"grant_type": {"refresh_token"}, "refresh_token": {"x"}}, http.StatusUnauthorized, "invalid_grant"},
+		{"unknown grant", http.MethodPost, "", url.Values{"grant_type": {"password"}}, http.StatusBadRequest, "unsupported_grant_type"},
+		{"GET", http.MethodGet, "", nil, http.StatusMethodNotAllowed, "invalid_request"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "/auth/token", strings.NewReader(tt.form.Encode()))
+			if tt.form != nil {
+				req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			}
+			if tt.header != "" {
+				req.Header.Set(AuthorizationHeader, tt.header)
+			}
+			rec, body := serve(req)
+			if rec.Code != tt.wantStatus || body["error"] != tt.wantError {
+				t.Errorf("status = %d, body %v, want %d %s", rec.Code, body, tt.wantStatus, tt.wantError)
+			}
+		})
+	}
+}
@@ -3,12 +3,24 @@ package anp_auth
 import (
 	"fmt"
 	"net/http"
+	"strings"
 )
 
 // Transport wraps an http.RoundTripper and automatically adds DID-WBA authentication.
 type Transport struct {
 	Base          http.RoundTripper
 	Authenticator *Authenticator
+	// MutualAuth requires responses to DIDWba-signed requests to prove the
+	// server's identity (see MutualAuthMiddleware). A response without a
+	// valid proof fails with ErrResponseProofInvalid, except 401 and 403
+	// responses, which reject the client and carry no proof.
+	MutualAuth bool
+	// ServerDID pins the DID the server must prove. Empty accepts any DID
+	// whose document is hosted on the request's host.
+	ServerDID string
+	// ResolveDIDDocument resolves the server's DID document (default
+	// ResolveDIDDocument).
+	ResolveDIDDocument ResolveDIDDocumentFunc
 }
 
 // RoundTrip implements http.RoundTripper by adding authentication headers.
@@ -37,10 +49,36 @@ func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
 		return nil, err
 	}
 
+	if t.MutualAuth {
+		if err := t.verifyServer(clonedReq, resp); err != nil {
+			resp.Body.Close()
+			return nil, err
+		}
+	}
+
+	// Tokens are cached only once the server that issued them is verified.
 	t.Authenticator.UpdateFromResponse(req.URL.String(), resp.Header)
 	return resp, nil
 }
 
+// verifyServer checks the response proof for a DIDWba-signed request.
+func (t *Transport) verifyServer(req *http.Request, resp *http.Response) error {
+	authorization := req.Header.Get(AuthorizationHeader)
+	if strings.HasPrefix(authorization, BearerScheme) ||
+		resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden {
+		return nil
+	}
+	parts, err := parseAuthHeader(authorization)
+	if err != nil {
+		return fmt.Errorf("parse sent auth header: %w", err)
+	}
+	domain, err := getDomain(req.URL.String())
+	if err != nil {
+		return err
+	}
+	return VerifyResponseProof(req.Context(), resp.Header.Get(AuthenticationInfoHeader), parts.Nonce, domain, t.ServerDID, t.ResolveDIDDocument)
+}
+
 // NewClient creates an HTTP client with automatic DID-WBA authentication.
 func NewClient(authenticator *Authenticator) *http.Client {
 	return &http.Client{
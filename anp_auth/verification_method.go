@@ -2,6 +2,8 @@ package anp_auth
 
 import (
 	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
 	"crypto/sha256"
 	"encoding/base64"
 	"fmt"
@@ -33,42 +35,242 @@ func (v *EcdsaSecp256k1VerificationKey2019) GetPublicKey() any {
 // VerifySignature verifies a SHA-256 digest of the content against the provided signature.
 // The signature is expected to be in base64url format, representing the R and S values concatenated.
 func (v *EcdsaSecp256k1VerificationKey2019) VerifySignature(content []byte, signature string) bool {
+	return verifyECDSA(v.PublicKey, content, signature)
+}
+
+func verifyECDSA(publicKey *ecdsa.PublicKey, content []byte, signature string) bool {
 	sigBytes, err := base64.RawURLEncoding.DecodeString(signature)
 	if err != nil {
 		// Signature decode failed, verification fails
 		return false
 	}
 
-	r, s, err := unmarshalSignature(v.PublicKey.Curve, sigBytes)
+	r, s, err := unmarshalSignature(publicKey.Curve, sigBytes)
 	if err != nil {
 		// Signature unmarshal failed, verification fails
 		return false
 	}
 
 	digest := sha256.Sum256(content)
-	return ecdsa.Verify(v.PublicKey, digest[:], r, s)
+	return ecdsa.Verify(publicKey, digest[:], r, s)
 }
 
 // NewEcdsaSecp256k1VerificationKey2019 creates an instance from a verification method map.
+// The key is read from publicKeyJwk or, failing that, publicKeyMultibase.
 func NewEcdsaSecp256k1VerificationKey2019(methodMap map[string]any) (VerificationMethod, error) {
+	if _, ok := methodMap["publicKeyJwk"]; !ok && methodMap["publicKeyMultibase"] != nil {
+		publicKey, err := ecdsaKeyFromMultibase(methodMap, crypto.Secp256k1())
+		if err != nil {
+			return nil, err
+		}
+		return &EcdsaSecp256k1VerificationKey2019{PublicKey: publicKey}, nil
+	}
+
+	jwk, err := publicKeyJWK(methodMap)
+	if err != nil {
+		return nil, err
+	}
+
+	if jwk.Kty != JWKTypeEC || jwk.Crv != JWKCurveSecp256k1 {
+		return nil, fmt.Errorf("unsupported JWK parameters for secp256k1: kty=%s, crv=%s", jwk.Kty, jwk.Crv)
+	}
+
+	publicKey, err := ecdsaKeyFromJWK(jwk, crypto.Secp256k1())
+	if err != nil {
+		return nil, err
+	}
+	return &EcdsaSecp256k1VerificationKey2019{PublicKey: publicKey}, nil
+}
+
+// EcdsaSecp256r1VerificationKey2019 implements VerificationMethod for P-256
+// keys, with the same signature encoding as the secp256k1 type.
+type EcdsaSecp256r1VerificationKey2019 struct {
+	PublicKey *ecdsa.PublicKey
+}
+
+// GetPublicKey returns the public key.
+func (v *EcdsaSecp256r1VerificationKey2019) GetPublicKey() any {
+	return v.PublicKey
+}
+
+// VerifySignature verifies the base64url R||S signature over the SHA-256
+// digest of content.
+func (v *EcdsaSecp256r1VerificationKey2019) VerifySignature(content []byte, signature string) bool {
+	return verifyECDSA(v.PublicKey, content, signature)
+}
+
+// NewEcdsaSecp256r1VerificationKey2019 creates an instance from a verification method map.
+// The key is read from publicKeyJwk or, failing that, publicKeyMultibase.
+func NewEcdsaSecp256r1VerificationKey2019(methodMap map[string]any) (VerificationMethod, error) {
+	if _, ok := methodMap["publicKeyJwk"]; !ok && methodMap["publicKeyMultibase"] != nil {
+		publicKey, err := ecdsaKeyFromMultibase(methodMap, elliptic.P256())
+		if err != nil {
+			return nil, err
+		}
+		return &EcdsaSecp256r1VerificationKey2019{PublicKey: publicKey}, nil
+	}
+
+	jwk, err := publicKeyJWK(methodMap)
+	if err != nil {
+		return nil, err
+	}
+
+	if jwk.Kty != JWKTypeEC || jwk.Crv != JWKCurveP256 {
+		return nil, fmt.Errorf("unsupported JWK parameters for P-256: kty=%s, crv=%s", jwk.Kty, jwk.Crv)
+	}
+
+	publicKey, err := ecdsaKeyFromJWK(jwk, elliptic.P256())
+	if err != nil {
+		return nil, err
+	}
+	return &EcdsaSecp256r1VerificationKey2019{PublicKey: publicKey}, nil
+}
+
+// JsonWebKey2020 implements VerificationMethod for the generic JsonWebKey2020
+// type, whose algorithm is given by the JWK itself. EC keys on secp256k1 and
+// P-256 use the same signature encoding as EcdsaSecp256k1VerificationKey2019;
+// Ed25519 (OKP) keys sign the content directly.
+type JsonWebKey2020 struct {
+	// PublicKey is an *ecdsa.PublicKey or an ed25519.PublicKey.
+	PublicKey any
+}
+
+// GetPublicKey returns the public key.
+func (v *JsonWebKey2020) GetPublicKey() any {
+	return v.PublicKey
+}
+
+// VerifySignature verifies signature over content with the key's algorithm.
+func (v *JsonWebKey2020) VerifySignature(content []byte, signature string) bool {
+	return verifyWithKey(v.PublicKey, content, signature)
+}
+
+// verifyWithKey verifies an ECDSA R||S signature over the SHA-256 digest of
+// content, or an Ed25519 signature over content itself.
+func verifyWithKey(publicKey any, content []byte, signature string) bool {
+	switch key := publicKey.(type) {
+	case *ecdsa.PublicKey:
+		return verifyECDSA(key, content, signature)
+	case ed25519.PublicKey:
+		sigBytes, err := base64.RawURLEncoding.DecodeString(signature)
+		if err != nil {
+			return false
+		}
+		return ed25519.Verify(key, content, sigBytes)
+	}
+	return false
+}
+
+// NewJsonWebKey2020 creates an instance from a verification method map,
+// selecting the algorithm from the JWK's kty and crv.
+func NewJsonWebKey2020(methodMap map[string]any) (VerificationMethod, error) {
+	jwk, err := publicKeyJWK(methodMap)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case jwk.Kty == JWKTypeEC && jwk.Crv == JWKCurveSecp256k1:
+		key, err := ecdsaKeyFromJWK(jwk, crypto.Secp256k1())
+		if err != nil {
+			return nil, err
+		}
+		return &JsonWebKey2020{PublicKey: key}, nil
+	case jwk.Kty == JWKTypeEC && jwk.Crv == JWKCurveP256:
+		key, err := ecdsaKeyFromJWK(jwk, elliptic.P256())
+		if err != nil {
+			return nil, err
+		}
+		return &JsonWebKey2020{PublicKey: key}, nil
+	case jwk.Kty == JWKTypeOKP && jwk.Crv == JWKCurveEd25519:
+		x, err := base64.RawURLEncoding.DecodeString(jwk.X)
+		if err != nil {
+			return nil, fmt.Errorf("invalid JWK 'x' coordinate: %w", err)
+		}
+		if len(x) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("invalid Ed25519 public key length: %d", len(x))
+		}
+		return &JsonWebKey2020{PublicKey: ed25519.PublicKey(x)}, nil
+	}
+	return nil, fmt.Errorf("unsupported JWK parameters for JsonWebKey2020: kty=%s, crv=%s", jwk.Kty, jwk.Crv)
+}
+
+// Multikey implements VerificationMethod for the Multikey type, whose
+// publicKeyMultibase carries a multicodec prefix naming the algorithm:
+// secp256k1 and P-256 keys verify like EcdsaSecp256k1VerificationKey2019,
+// Ed25519 keys like JsonWebKey2020.
+type Multikey struct {
+	// PublicKey is an *ecdsa.PublicKey or an ed25519.PublicKey.
+	PublicKey any
+}
+
+// GetPublicKey returns the public key.
+func (v *Multikey) GetPublicKey() any {
+	return v.PublicKey
+}
+
+// VerifySignature verifies signature over content with the key's algorithm.
+func (v *Multikey) VerifySignature(content []byte, signature string) bool {
+	return verifyWithKey(v.PublicKey, content, signature)
+}
+
+// NewMultikey creates an instance from a verification method map.
+func NewMultikey(methodMap map[string]any) (VerificationMethod, error) {
+	publicKey, err := multikeyPublicKey(methodMap)
+	if err != nil {
+		return nil, err
+	}
+	return &Multikey{PublicKey: publicKey}, nil
+}
+
+// Ed25519VerificationKey2020 implements VerificationMethod for Ed25519 keys
+// published as publicKeyMultibase.
+type Ed25519VerificationKey2020 struct {
+	PublicKey ed25519.PublicKey
+}
+
+// GetPublicKey returns the public key.
+func (v *Ed25519VerificationKey2020) GetPublicKey() any {
+	return v.PublicKey
+}
+
+// VerifySignature verifies the base64url Ed25519 signature over content.
+func (v *Ed25519VerificationKey2020) VerifySignature(content []byte, signature string) bool {
+	return verifyWithKey(v.PublicKey, content, signature)
+}
+
+// NewEd25519VerificationKey2020 creates an instance from a verification method map.
+func NewEd25519VerificationKey2020(methodMap map[string]any) (VerificationMethod, error) {
+	code, key, err := publicKeyMultibase(methodMap)
+	if err != nil {
+		return nil, err
+	}
+	if (code != 0 && code != multicodecEd25519Pub) || len(key) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("publicKeyMultibase is not an Ed25519 public key")
+	}
+	return &Ed25519VerificationKey2020{PublicKey: ed25519.PublicKey(key)}, nil
+}
+
+// publicKeyJWK decodes the publicKeyJwk of a verification method.
+func publicKeyJWK(methodMap map[string]any) (JWK, error) {
+	var jwk JWK
 	jwkMap, ok := methodMap["publicKeyJwk"].(map[string]any)
 	if !ok {
-		return nil, fmt.Errorf("publicKeyJwk not found or not a map")
+		return jwk, fmt.Errorf("publicKeyJwk not found or not a map")
 	}
 
-	var jwk JWK
 	jwkBytes, err := sonic.Marshal(jwkMap)
 	if err != nil {
-		return nil, fmt.Errorf("failed to marshal publicKeyJwk: %w", err)
+		return jwk, fmt.Errorf("failed to marshal publicKeyJwk: %w", err)
 	}
 	if err := sonic.Unmarshal(jwkBytes, &jwk); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal publicKeyJwk: %w", err)
-	}
-
-	if jwk.Kty != JWKTypeEC || jwk.Crv != JWKCurveSecp256k1 {
-		return nil, fmt.Errorf("unsupported JWK parameters for secp256k1: kty=%s, crv=%s", jwk.Kty, jwk.Crv)
+		return jwk, fmt.Errorf("failed to unmarshal publicKeyJwk: %w", err)
 	}
+	return jwk, nil
+}
 
+// ecdsaKeyFromJWK builds a public key on curve from the JWK coordinates.
+func ecdsaKeyFromJWK(jwk JWK, curve elliptic.Curve) (*ecdsa.PublicKey, error) {
 	xBytes, err := base64.RawURLEncoding.DecodeString(jwk.X)
 	if err != nil {
 		return nil, fmt.Errorf("invalid JWK 'x' coordinate: %w", err)
@@ -80,19 +282,19 @@ func NewEcdsaSecp256k1VerificationKey2019(methodMap map[string]any) (Verificatio
 
 	x := new(big.Int).SetBytes(xBytes)
 	y := new(big.Int).SetBytes(yBytes)
-
-	curve := crypto.Secp256k1()
 	if !curve.IsOnCurve(x, y) {
-		return nil, fmt.Errorf("public key is not on the secp256k1 curve")
+		return nil, fmt.Errorf("public key is not on the %s curve", curve.Params().Name)
 	}
-
-	publicKey := &ecdsa.PublicKey{Curve: curve, X: x, Y: y}
-	return &EcdsaSecp256k1VerificationKey2019{PublicKey: publicKey}, nil
+	return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
 }
 
 // VerificationMethodFactory is a map of verification method types to their constructor functions.
 var VerificationMethodFactory = map[string]func(map[string]any) (VerificationMethod, error){
 	VerificationMethodEcdsaSecp256k1: NewEcdsaSecp256k1VerificationKey2019,
+	VerificationMethodEcdsaSecp256r1: NewEcdsaSecp256r1VerificationKey2019,
+	VerificationMethodJsonWebKey2020: NewJsonWebKey2020,
+	VerificationMethodMultikey:       NewMultikey,
+	VerificationMethodEd25519:        NewEd25519VerificationKey2020,
 }
 
 // CreateVerificationMethod creates a VerificationMethod instance based on the method type.
@@ -0,0 +1,342 @@
+package anp_auth
+
+import (
+	"context"
+	gocrypto "crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"math/big"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/openanp/anp-go/crypto"
+)
+
+func TestJsonWebKey2020(t *testing.T) {
+	content := []byte("payload")
+	b64 := base64.RawURLEncoding.EncodeToString
+
+	_, k1Key, err := CreateDIDWBADocument("example.com", nil, nil, nil)
+	if err != nil {
+		t.Fatalf("CreateDIDWBADocument() error = %v", err)
+	}
+	p256Key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey() error = %v", err)
+	}
+	edPub, edPriv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey() error = %v", err)
+	}
+	k1Sig, _ := SignContent(k1Key, content)
+	p256Sig, _ := SignContent(p256Key, content)
+
+	tests := []struct {
+		name string
+		jwk  map[string]any
+		sig  string
+	}{
+		{
+			name: "secp256k1",
+			jwk: map[string]any{
+				"kty": "EC", "crv": "secp256k1",
+				"x": b64(k1Key.X.FillBytes(make([]byte, 32))),
+				"y": b64(k1Key.Y.FillBytes(make([]byte, 32))),
+			},
+			sig: k1Sig,
+		},
+		{
+			name: "P-256",
+			jwk: map[string]any{
+				"kty": "EC", "crv": "P-256",
+				"x": b64(p256Key.X.FillBytes(make([]byte, 32))),
+				"y": b64(p256Key.Y.FillBytes(make([]byte, 32))),
+			},
+			sig: p256Sig,
+		},
+		{
+			name: "Ed25519",
+			jwk:  map[string]any{"kty": "OKP", "crv": "Ed25519", "x": b64(edPub)},
+			sig:  b64(ed25519.Sign(edPriv, content)),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			method, err := CreateVerificationMethod(map[string]any{
+				"id":           "did:wba:example.com#key-1",
+				"type":         VerificationMethodJsonWebKey2020,
+				"publicKeyJwk": tt.jwk,
+			})
+			if err != nil {
+				t.Fatalf("CreateVerificationMethod() error = %v", err)
+			}
+			if !method.VerifySignature(content, tt.sig) {
+				t.Error("VerifySignature() = false, want true")
+			}
+			if method.VerifySignature([]byte("tampered"), tt.sig) {
+				t.Error("VerifySignature(tampered) = true")
+			}
+		})
+	}
+
+	_, err = CreateVerificationMethod(map[string]any{
+		"type":         VerificationMethodJsonWebKey2020,
+		"publicKeyJwk": map[string]any{"kty": "RSA", "n": "AQAB", "e": "AQAB"},
+	})
+	if err == nil {
+		t.Error("CreateVerificationMethod(RSA) succeeded, want unsupported")
+	}
+}
+
+func TestP256DIDDocument(t *testing.T) {
+	doc, key, err := CreateDIDWBADocumentWithCurve(crypto.P256(), "p256.example.com", nil, nil, nil)
+	if err != nil {
+		t.Fatalf("CreateDIDWBADocumentWithCurve() error = %v", err)
+	}
+	if doc.VerificationMethod[0]["type"] != VerificationMethodEcdsaSecp256r1 {
+		t.Errorf("type = %v", doc.VerificationMethod[0]["type"])
+	}
+	if jwk := doc.VerificationMethod[0]["publicKeyJwk"].(JWK); jwk.Crv != JWKCurveP256 {
+		t.Errorf("crv = %s", jwk.Crv)
+	}
+
+	// The key survives a PEM round trip, as when loaded from a key file.
+	keyPEM, err := crypto.PrivateKeyToPEM(key)
+	if err != nil {
+		t.Fatalf("PrivateKeyToPEM() error = %v", err)
+	}
+	loaded, err := crypto.PrivateKeyFromPEM(keyPEM)
+	if err != nil || !loaded.Equal(key) {
+		t.Fatalf("PrivateKeyFromPEM() = %v, %v", loaded, err)
+	}
+
+	header, err := GenerateAuthHeader(loaded, doc, "service.example.com")
+	if err != nil {
+		t.Fatalf("GenerateAuthHeader() error = %v", err)
+	}
+	verifier, err := NewDidWbaVerifier(DidWbaVerifierConfig{
+		JWTPrivateKey:      key,
+		JWTPublicKey:       &key.PublicKey,
+		JWTAlgorithm:       "ES256",
+		NonceValidator:     NewMemoryNonceValidator(time.Minute),
+		ResolveDIDDocument: resolverFor(t, delegationParty{doc: doc, key: key}),
+	})
+	if err != nil {
+		t.Fatalf("NewDidWbaVerifier() error = %v", err)
+	}
+	if _, err := verifier.VerifyAuthHeader(header.String(), "service.example.com"); err != nil {
+		t.Errorf("VerifyAuthHeader() error = %v", err)
+	}
+
+	// A secp256k1 key cannot sign for a P-256 method.
+	_, k1, _ := CreateDIDWBADocument("k1.example.com", nil, nil, nil)
+	if _, err := GenerateAuthHeader(k1, doc, "service.example.com"); err == nil {
+		t.Error("GenerateAuthHeader() with mismatched curve succeeded")
+	}
+}
+
+func TestCreateDIDWBADocumentWithAlgorithm(t *testing.T) {
+	jwtKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey() error = %v", err)
+	}
+
+	tests := []struct {
+		algorithm  KeyAlgorithm
+		methodType string
+	}{
+		{KeyAlgorithmSecp256k1, VerificationMethodEcdsaSecp256k1},
+		{KeyAlgorithmP256, VerificationMethodEcdsaSecp256r1},
+		{KeyAlgorithmEd25519, VerificationMethodJsonWebKey2020},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.algorithm), func(t *testing.T) {
+			doc, key, err := CreateDIDWBADocumentWithAlgorithm(tt.algorithm, "agent.example.com", nil, []string{"alice"}, nil)
+			if err != nil {
+				t.Fatalf("CreateDIDWBADocumentWithAlgorithm() error = %v", err)
+			}
+			if got := doc.VerificationMethod[0]["type"]; got != tt.methodType {
+				t.Errorf("type = %v, want %s", got, tt.methodType)
+			}
+
+			header, err := GenerateAuthHeader(key, doc, "service.example.com")
+			if err != nil {
+				t.Fatalf("GenerateAuthHeader() error = %v", err)
+			}
+			resolve := resolverFor(t, delegationParty{doc: doc})
+			verifier, err := NewDidWbaVerifier(DidWbaVerifierConfig{
+				JWTPrivateKey:      jwtKey,
+				JWTPublicKey:       &jwtKey.PublicKey,
+				JWTAlgorithm:       "ES256",
+				NonceValidator:     NewMemoryNonceValidator(time.Minute),
+				ResolveDIDDocument: resolve,
+			})
+			if err != nil {
+				t.Fatalf("NewDidWbaVerifier() error = %v", err)
+			}
+			if _, err := verifier.VerifyAuthHeader(header.String(), "service.example.com"); err != nil {
+				t.Errorf("VerifyAuthHeader() error = %v", err)
+			}
+
+			authJSON, err := GenerateAuthJSON(key, doc, "service.example.com")
+			if err != nil {
+				t.Fatalf("GenerateAuthJSON() error = %v", err)
+			}
+			published, _ := resolve(context.Background(), doc.ID)
+			if ok, msg := VerifyAuthJSON(authJSON, published, "service.example.com"); !ok {
+				t.Errorf("VerifyAuthJSON() = %s", msg)
+			}
+		})
+	}
+
+	if _, _, err := CreateDIDWBADocumentWithAlgorithm("RSA", "agent.example.com", nil, nil, nil); err == nil {
+		t.Error("CreateDIDWBADocumentWithAlgorithm(RSA) error = nil, want error")
+	}
+}
+
+func TestEd25519DIDKeyFile(t *testing.T) {
+	_, key, err := CreateDIDWBADocumentWithAlgorithm(KeyAlgorithmEd25519, "ed.example.com", nil, nil, nil)
+	if err != nil {
+		t.Fatalf("CreateDIDWBADocumentWithAlgorithm() error = %v", err)
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatalf("MarshalPKCS8PrivateKey() error = %v", err)
+	}
+	loaded, err := parseDIDPrivateKey(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}))
+	if err != nil {
+		t.Fatalf("parseDIDPrivateKey() error = %v", err)
+	}
+	if !key.(ed25519.PrivateKey).Equal(loaded) {
+		t.Fatal("parseDIDPrivateKey() returned a different key")
+	}
+
+	// An Ed25519 key cannot sign for a secp256k1 method.
+	k1Doc, _, _ := CreateDIDWBADocument("k1.example.com", nil, nil, nil)
+	if _, err := GenerateAuthHeader(loaded, k1Doc, "service.example.com"); err == nil {
+		t.Error("GenerateAuthHeader() with Ed25519 key for secp256k1 method succeeded")
+	}
+}
+
+func TestParseDIDPrivateKey_JWK(t *testing.T) {
+	_, key, err := CreateDIDWBADocument("jwk.example.com", nil, nil, nil)
+	if err != nil {
+		t.Fatalf("CreateDIDWBADocument() error = %v", err)
+	}
+	jwk, err := crypto.PrivateKeyToJWK(key)
+	if err != nil {
+		t.Fatalf("PrivateKeyToJWK() error = %v", err)
+	}
+	loaded, err := parseDIDPrivateKey(append(jwk, '\n'))
+	if err != nil {
+		t.Fatalf("parseDIDPrivateKey() error = %v", err)
+	}
+	if !key.Equal(loaded) {
+		t.Error("parseDIDPrivateKey() returned a different key")
+	}
+
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey() error = %v", err)
+	}
+	if jwk, err = crypto.PrivateKeyToJWK(edKey); err != nil {
+		t.Fatalf("PrivateKeyToJWK(Ed25519) error = %v", err)
+	}
+	if loaded, err = parseDIDPrivateKey(jwk); err != nil {
+		t.Fatalf("parseDIDPrivateKey(Ed25519) error = %v", err)
+	}
+	if !edKey.Equal(loaded) {
+		t.Error("parseDIDPrivateKey(Ed25519) returned a different key")
+	}
+}
+
+// encodeBase58 is the inverse of decodeBase58.
+func encodeBase58(b []byte) string {
+	n := new(big.Int).SetBytes(b)
+	var out []byte
+	for mod := new(big.Int); n.Sign() > 0; {
+		n.DivMod(n, big.NewInt(58), mod)
+		out = append(out, base58Alphabet[mod.Int64()])
+	}
+	for _, c := range b {
+		if c != 0 {
+			break
+		}
+		out = append(out, '1')
+	}
+	slices.Reverse(out)
+	return string(out)
+}
+
+func TestPublicKeyMultibase(t *testing.T) {
+	content := []byte("payload")
+	k1, _ := crypto.GenerateECKeyPair(crypto.Secp256k1())
+	p256, _ := crypto.GenerateECKeyPair(crypto.P256())
+	edPub, edKey, _ := ed25519.GenerateKey(rand.Reader)
+	compress := func(k *ecdsa.PrivateKey) []byte { return compressPoint(&k.PublicKey) }
+	multibase := func(prefix []byte, key []byte) string { return "z" + encodeBase58(append(prefix, key...)) }
+
+	tests := []struct {
+		name   string
+		method map[string]any
+		signer gocrypto.Signer
+	}{
+		{"secp256k1 multicodec", map[string]any{"type": VerificationMethodEcdsaSecp256k1, "publicKeyMultibase": multibase([]byte{0xe7, 0x01}, compress(k1))}, k1},
+		{"secp256k1 bare", map[string]any{"type": VerificationMethodEcdsaSecp256k1, "publicKeyMultibase": multibase(nil, compress(k1))}, k1},
+		{"P-256 uncompressed", map[string]any{"type": VerificationMethodEcdsaSecp256r1, "publicKeyMultibase": multibase([]byte{0x80, 0x24}, elliptic.Marshal(p256.Curve, p256.X, p256.Y))}, p256},
+		{"Multikey secp256k1", map[string]any{"type": VerificationMethodMultikey, "publicKeyMultibase": multibase([]byte{0xe7, 0x01}, compress(k1))}, k1},
+		{"Multikey P-256", map[string]any{"type": VerificationMethodMultikey, "publicKeyMultibase": multibase([]byte{0x80, 0x24}, compress(p256))}, p256},
+		{"Multikey Ed25519", map[string]any{"type": VerificationMethodMultikey, "publicKeyMultibase": multibase([]byte{0xed, 0x01}, edPub)}, edKey},
+		{"Ed25519VerificationKey2020", map[string]any{"type": VerificationMethodEd25519, "publicKeyMultibase": multibase([]byte{0xed, 0x01}, edPub)}, edKey},
+		{"base64url", map[string]any{"type": VerificationMethodMultikey, "publicKeyMultibase": "u" + base64.RawURLEncoding.EncodeToString(append([]byte{0xed, 0x01}, edPub...))}, edKey},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			method, err := CreateVerificationMethod(tt.method)
+			if err != nil {
+				t.Fatalf("CreateVerificationMethod() error = %v", err)
+			}
+			sig, err := SignContent(tt.signer, content)
+			if err != nil {
+				t.Fatalf("SignContent() error = %v", err)
+			}
+			if !method.VerifySignature(content, sig) {
+				t.Error("VerifySignature() = false, want true")
+			}
+			if method.VerifySignature([]byte("tampered"), sig) {
+				t.Error("VerifySignature(tampered) = true, want false")
+			}
+		})
+	}
+
+	invalid := []map[string]any{
+		{"type": VerificationMethodEcdsaSecp256k1, "publicKeyMultibase": multibase([]byte{0x80, 0x24}, compress(p256))},
+		{"type": VerificationMethodEcdsaSecp256k1, "publicKeyMultibase": "z0OIl"},
+		{"type": VerificationMethodMultikey, "publicKeyMultibase": multibase(nil, compress(k1))},
+		{"type": VerificationMethodMultikey, "publicKeyMultibase": "mAAAA"},
+		{"type": VerificationMethodEd25519, "publicKeyMultibase": multibase([]byte{0xe7, 0x01}, compress(k1))},
+	}
+	for _, m := range invalid {
+		if _, err := CreateVerificationMethod(m); err == nil {
+			t.Errorf("CreateVerificationMethod(%v) error = nil, want error", m["publicKeyMultibase"])
+		}
+	}
+}
+
+func TestDecodeBase58_DIDKey(t *testing.T) {
+	// Ed25519 did:key from the did:key method specification.
+	data, err := decodeMultibase("z6MkhaXgBZDvotDkL5257faiztiGiC2QtKLGpbnnEGta2doK")
+	if err != nil {
+		t.Fatalf("decodeMultibase() error = %v", err)
+	}
+	if len(data) != 34 || data[0] != 0xed || data[1] != 0x01 {
+		t.Errorf("decodeMultibase() = %x, want ed01 prefix and 32-byte key", data)
+	}
+}
@@ -3,6 +3,7 @@ package anp_auth
 import (
 	"context"
 	"fmt"
+	"log/slog"
 	"net/http"
 	"strings"
 	"sync"
@@ -13,19 +14,71 @@ import (
 
 // DidWbaVerifierConfig holds the configuration for the DidWbaVerifier.
 type DidWbaVerifierConfig struct {
-	JWTPrivateKey         any
-	JWTPublicKey          any
-	JWTPrivateKeyPEM      []byte
-	JWTPublicKeyPEM       []byte
+	JWTPrivateKey    any
+	JWTPublicKey     any
+	JWTPrivateKeyPEM []byte
+	JWTPublicKeyPEM  []byte
+	// JWKSURL or JWKS (a static JWKS document) supply bearer verification
+	// keys selected by the token's kid, so issuers can rotate keys. A remote
+	// JWKS is refetched every JWKSRefreshInterval (DefaultJWKSRefreshInterval
+	// when zero). Tokens without a kid are still checked with JWTPublicKey.
+	JWKSURL             string
+	JWKS                []byte
+	JWKSRefreshInterval time.Duration
+	// JWTKeySet, when set, signs access tokens with its active key (naming it
+	// in the kid header) and verifies tokens by kid, for zero-downtime key
+	// rotation. It replaces JWTPrivateKey for signing.
+	JWTKeySet             *JWTKeySet
 	JWTAlgorithm          string
 	AccessTokenExpiration time.Duration
-	TimestampExpiration   time.Duration
-	DIDCacheExpiration    time.Duration
-	AllowedDomains        []string
-	NonceValidator        NonceValidator
-	ResolveDIDDocument    ResolveDIDDocumentFunc
-	Now                   func() time.Time
-	HTTPClient            *http.Client
+	// JWTIssuer and JWTAudience, when set, are written to the iss and aud
+	// claims of issued tokens and required of bearer tokens.
+	JWTIssuer   string
+	JWTAudience []string
+	// RefreshTokenExpiration enables refresh tokens: when set, successful
+	// DID-WBA authentication also returns a refresh_token valid this long,
+	// which RefreshAccessToken exchanges for new access tokens. Zero disables.
+	RefreshTokenExpiration time.Duration
+	TimestampExpiration    time.Duration
+	DIDCacheExpiration     time.Duration
+	// AllowedDomains restricts the service domains DID-WBA signatures may be
+	// issued for. Entries match case-insensitively; "*.example.com" matches
+	// any subdomain of example.com, an entry without a port matches any port
+	// and "host:*" is explicit about it.
+	AllowedDomains []string
+	// AllowedDIDs, when non-empty, limits which agent DIDs may authenticate;
+	// BlockedDIDs rejects DIDs even if allowed. Entries are exact DIDs or
+	// prefixes ending in "*", such as "did:wba:example.com:*". For delegated
+	// requests every issuer and delegate in the chain is checked. Rejected
+	// requests fail with ErrDIDNotAllowed (403).
+	AllowedDIDs []string
+	BlockedDIDs []string
+	// NonceValidator rejects reused client-generated nonces. It is required
+	// unless ChallengeStore is set.
+	NonceValidator NonceValidator
+	// ChallengeStore switches DID-WBA to server-issued nonces: headers must be
+	// signed over a nonce from IssueChallenge (see ChallengeHandler), which is
+	// consumed on first use. Share the store between verifiers behind a load
+	// balancer. ChallengeExpiration bounds how long a nonce stays valid
+	// (DefaultChallengeExpiration when zero).
+	ChallengeStore      ChallengeStore
+	ChallengeExpiration time.Duration
+	// TokenRevoker, when set, is consulted for every bearer and refresh
+	// token, and records the tokens passed to Revoke.
+	TokenRevoker       TokenRevoker
+	ResolveDIDDocument ResolveDIDDocumentFunc
+	Now                func() time.Time
+	HTTPClient         *http.Client
+	// Logger receives diagnostics about rejected requests. Nil discards them.
+	Logger *slog.Logger
+	// Tenants selects per-host issuer configuration by the requested domain
+	// (host, optionally with port). Hosts not listed use the fields above.
+	Tenants map[string]TenantConfig
+	// Metrics receives verification outcomes, DID resolution latency and DID
+	// cache hits and misses. Nil records nothing.
+	Metrics MetricsRecorder
+	// AuditSink, when set, receives a record of every authentication attempt.
+	AuditSink AuditSink
 }
 
 // ResolveDIDDocumentFunc resolves a DID document for a given DID identifier.
@@ -40,15 +93,17 @@ type didCacheEntry struct {
 // DidWbaVerifier verifies Authorization headers for DID WBA and Bearer JWT.
 type DidWbaVerifier struct {
 	config        DidWbaVerifierConfig
+	tenants       map[string]*tenant
+	jwks          *JWKS
 	didCache      map[string]didCacheEntry
 	didCacheMutex sync.Mutex
 	now           func() time.Time
 }
 
 // NewDidWbaVerifier creates a new verifier with the given configuration.
-// NonceValidator is required to prevent replay attacks.
+// A NonceValidator or ChallengeStore is required to prevent replay attacks.
 func NewDidWbaVerifier(config DidWbaVerifierConfig) (*DidWbaVerifier, error) {
-	if config.NonceValidator == nil {
+	if config.NonceValidator == nil && config.ChallengeStore == nil {
 		return nil, ErrNonceValidatorMissing
 	}
 
@@ -84,21 +139,42 @@ func NewDidWbaVerifier(config DidWbaVerifierConfig) (*DidWbaVerifier, error) {
 	if config.Now == nil {
 		config.Now = time.Now
 	}
+	if config.Logger == nil {
+		config.Logger = defaultLogger
+	}
+
+	var jwks *JWKS
+	if len(config.JWKS) > 0 {
+		keys, err := ParseJWKS(config.JWKS)
+		if err != nil {
+			return nil, fmt.Errorf("loading JWKS: %w", err)
+		}
+		jwks = keys
+	} else if config.JWKSURL != "" {
+		jwks = NewRemoteJWKS(config.JWKSURL, config.HTTPClient, config.JWKSRefreshInterval)
+	}
+
+	tenants, err := newTenants(config)
+	if err != nil {
+		return nil, err
+	}
 
 	return &DidWbaVerifier{
 		config:   config,
+		tenants:  tenants,
+		jwks:     jwks,
 		didCache: make(map[string]didCacheEntry),
 		now:      config.Now,
 	}, nil
 }
 
-func (v *DidWbaVerifier) ensureDomainAllowed(domain string) error {
-	if len(v.config.AllowedDomains) == 0 {
+func ensureDomainAllowed(t *tenant, domain string) error {
+	if len(t.allowedDomains) == 0 {
 		return nil
 	}
 
-	for _, allowed := range v.config.AllowedDomains {
-		if strings.EqualFold(strings.TrimSpace(allowed), domain) {
+	for _, allowed := range t.allowedDomains {
+		if matchDomain(allowed, domain) {
 			return nil
 		}
 	}
@@ -114,33 +190,66 @@ func (v *DidWbaVerifier) VerifyAuthHeader(authorization, domain string) (map[str
 
 // VerifyAuthHeaderContext is the context-aware variant of VerifyAuthHeader.
 func (v *DidWbaVerifier) VerifyAuthHeaderContext(ctx context.Context, authorization, domain string) (map[string]any, error) {
+	start := time.Now()
+	result, err := v.verifyAuthHeader(ctx, authorization, domain)
+	if scheme := metricsScheme(authorization); err != nil {
+		v.metrics().VerificationFailed(scheme, ErrorCategoryOf(err), time.Since(start))
+	} else {
+		v.metrics().VerificationSucceeded(scheme, time.Since(start))
+	}
+	v.audit(ctx, authorization, domain, result, err)
+	return result, err
+}
+
+func (v *DidWbaVerifier) verifyAuthHeader(ctx context.Context, authorization, domain string) (map[string]any, error) {
 	if authorization == "" {
 		return nil, NewErrorWithStatus(ErrMissingAuthHeader, StatusUnauthorized)
 	}
 
+	var (
+		t      = v.tenantFor(domain)
+		result map[string]any
+		err    error
+	)
 	if strings.HasPrefix(authorization, BearerScheme) {
-		return v.handleBearerAuth(authorization)
+		result, err = v.handleBearerAuth(ctx, t, authorization)
+	} else {
+		result, err = v.handleDidAuth(ctx, t, authorization, domain)
 	}
-
-	return v.handleDidAuth(ctx, authorization, domain)
+	if err != nil {
+		v.config.Logger.DebugContext(ctx, "authorization rejected", "domain", domain, "error", err)
+	}
+	return result, err
 }
 
-func (v *DidWbaVerifier) handleBearerAuth(authorization string) (map[string]any, error) {
+func (v *DidWbaVerifier) handleBearerAuth(ctx context.Context, t *tenant, authorization string) (map[string]any, error) {
 	tokenString := strings.TrimPrefix(authorization, BearerScheme)
-	if v.config.JWTPublicKey == nil {
+	if !t.canVerify() {
 		return nil, NewErrorWithStatus(ErrJWTConfigMissing, StatusInternalServerError)
 	}
 
-	did, err := VerifyAccessToken(tokenString, v.config.JWTPublicKey, v.config.JWTAlgorithm)
+	claims, err := parseToken(tokenString, t.keyfunc(ctx), false, t.claims...)
 	if err != nil {
 		return nil, NewErrorWithStatus(WrapAuthError(ErrInvalidToken, "verify access token", err), StatusUnauthorized)
 	}
+	if err := v.checkRevoked(ctx, claims); err != nil {
+		return nil, err
+	}
+
+	// Re-checked so a blocked DID loses access before its token expires.
+	if err := v.ensureDIDAllowed(claims.Subject, claims.Actor); err != nil {
+		return nil, err
+	}
 
-	return map[string]any{"did": did}, nil
+	result := map[string]any{"did": claims.Subject}
+	if claims.Actor != "" {
+		result["actor_did"] = claims.Actor
+	}
+	return result, nil
 }
 
-func (v *DidWbaVerifier) handleDidAuth(ctx context.Context, authorization, domain string) (map[string]any, error) {
-	if err := v.ensureDomainAllowed(domain); err != nil {
+func (v *DidWbaVerifier) handleDidAuth(ctx context.Context, t *tenant, authorization, domain string) (map[string]any, error) {
+	if err := ensureDomainAllowed(t, domain); err != nil {
 		return nil, err
 	}
 
@@ -149,14 +258,29 @@ func (v *DidWbaVerifier) handleDidAuth(ctx context.Context, authorization, domai
 		return nil, NewErrorWithStatus(WrapAuthError(ErrInvalidAuthHeader, "parse auth header", err), StatusUnauthorized)
 	}
 
-	if err := v.verifyTimestamp(headerParts.Timestamp); err != nil {
+	// Checked before any nonce, resolution or signature work is spent.
+	if err := v.ensureDIDAllowed(headerParts.DID); err != nil {
 		return nil, err
 	}
 
-	if err := v.verifyNonce(ctx, headerParts.DID, headerParts.Nonce); err != nil {
+	if err := v.verifyTimestamp(headerParts.Timestamp); err != nil {
 		return nil, err
 	}
 
+	if v.config.ChallengeStore != nil {
+		if err := v.consumeChallenge(ctx, headerParts.Nonce); err != nil {
+			return nil, err
+		}
+	} else {
+		nonce := headerParts.Nonce
+		if t.nonceNamespace != "" {
+			nonce = t.nonceNamespace + ":" + nonce
+		}
+		if err := v.verifyNonce(ctx, headerParts.DID, nonce); err != nil {
+			return nil, err
+		}
+	}
+
 	didDocument, err := v.resolveAndCacheDID(ctx, headerParts.DID)
 	if err != nil {
 		return nil, err
@@ -167,20 +291,106 @@ func (v *DidWbaVerifier) handleDidAuth(ctx context.Context, authorization, domai
 		return nil, NewErrorWithStatus(fmt.Errorf("%w: %s", ErrInvalidSignature, message), StatusForbidden)
 	}
 
-	if v.config.JWTPrivateKey == nil {
+	subject, actor := headerParts.DID, ""
+	expiration, refreshExpiration := t.tokenExpiration, t.refreshExpiration
+	if headerParts.Delegation != "" {
+		chain, err := DecodeDelegationChain(headerParts.Delegation)
+		if err != nil {
+			return nil, NewErrorWithStatus(err, StatusForbidden)
+		}
+		expiry, err := chain.Verify(ctx, headerParts.DID, domain, v.now().UTC(), v.resolveAndCacheDID)
+		if err != nil {
+			return nil, NewErrorWithStatus(err, StatusForbidden)
+		}
+		// Every link must pass the DID policy, not just the ends: a blocked
+		// intermediary must not be able to pass authority along.
+		for _, link := range chain {
+			if err := v.ensureDIDAllowed(link.Issuer, link.Delegate); err != nil {
+				return nil, err
+			}
+		}
+		subject, actor = chain.Subject(), headerParts.DID
+		// A token must not outlive the delegation it was issued under.
+		remaining := expiry.Sub(v.now())
+		expiration, refreshExpiration = min(expiration, remaining), min(refreshExpiration, remaining)
+	}
+
+	result, err := issueAccessToken(t, subject, actor, expiration)
+	if err != nil {
+		return nil, err
+	}
+	if refreshExpiration > 0 {
+		key, _ := t.signingKey()
+		refreshToken, err := createAccessToken(tokenClaims(subject, actor, true, t.claims), key, refreshExpiration)
+		if err != nil {
+			return nil, NewErrorWithStatus(WrapAuthError(ErrTokenCreation, "create refresh token", err), StatusInternalServerError)
+		}
+		result["refresh_token"] = refreshToken
+	}
+	return result, nil
+}
+
+// RefreshAccessToken exchanges a refresh token issued by this verifier for a
+// new access token, without another DID-WBA signature. The access token never
+// outlives the refresh token, which keeps delegated sessions within their
+// delegation's expiry. It fails with ErrRefreshDisabled unless the tenant
+// serving domain has a RefreshTokenExpiration.
+func (v *DidWbaVerifier) RefreshAccessToken(refreshToken, domain string) (map[string]any, error) {
+	t := v.tenantFor(domain)
+	result, err := v.refresh(t, refreshToken)
+	if err != nil {
+		v.config.Logger.Debug("refresh rejected", "domain", domain, "error", err)
+	}
+	return result, err
+}
+
+func (v *DidWbaVerifier) refresh(t *tenant, refreshToken string) (map[string]any, error) {
+	if t.refreshExpiration <= 0 {
+		return nil, NewErrorWithStatus(ErrRefreshDisabled, StatusBadRequest)
+	}
+	if !t.canVerify() {
+		return nil, NewErrorWithStatus(ErrJWTConfigMissing, StatusInternalServerError)
+	}
+	claims, err := parseToken(refreshToken, t.keyfunc(context.Background()), true, t.claims...)
+	if err != nil {
+		return nil, NewErrorWithStatus(WrapAuthError(ErrInvalidToken, "verify refresh token", err), StatusUnauthorized)
+	}
+	if err := v.checkRevoked(context.Background(), claims); err != nil {
+		return nil, err
+	}
+	if err := v.ensureDIDAllowed(claims.Subject, claims.Actor); err != nil {
+		return nil, err
+	}
+	expiration := t.tokenExpiration
+	if !claims.ExpiresAt.IsZero() {
+		expiration = min(expiration, claims.ExpiresAt.Sub(v.now()))
+	}
+	return issueAccessToken(t, claims.Subject, claims.Actor, expiration)
+}
+
+// issueAccessToken signs an access token with the tenant key and returns the
+// verification result describing it.
+func issueAccessToken(t *tenant, subject, actor string, expiration time.Duration) (map[string]any, error) {
+	key, ok := t.signingKey()
+	if !ok {
 		return nil, NewErrorWithStatus(ErrJWTConfigMissing, StatusInternalServerError)
 	}
 
-	accessToken, err := CreateAccessToken(headerParts.DID, v.config.JWTPrivateKey, v.config.JWTAlgorithm, v.config.AccessTokenExpiration)
+	accessToken, err := createAccessToken(tokenClaims(subject, actor, false, t.claims), key, expiration)
 	if err != nil {
 		return nil, NewErrorWithStatus(WrapAuthError(ErrTokenCreation, "create access token", err), StatusInternalServerError)
 	}
 
-	return map[string]any{
+	result := map[string]any{
 		"access_token": accessToken,
 		"token_type":   "bearer",
-		"did":          headerParts.DID,
-	}, nil
+		"expires_in":   int64(expiration / time.Second),
+		"did":          subject,
+	}
+	if actor != "" {
+		result["actor_did"] = actor
+	}
+	return result, nil
 }
 
 // resolveAndCacheDID retrieves a DID document, using a cache to avoid repeated lookups.
@@ -188,18 +398,22 @@ func (v *DidWbaVerifier) resolveAndCacheDID(ctx context.Context, did string) (*D
 	v.didCacheMutex.Lock()
 	if entry, exists := v.didCache[did]; exists && v.now().UTC().Before(entry.expiresAt) {
 		v.didCacheMutex.Unlock()
+		v.metrics().DIDCacheLookup(true)
 		return entry.doc, nil
 	}
 	v.didCacheMutex.Unlock()
+	v.metrics().DIDCacheLookup(false)
 
 	resolver := v.config.ResolveDIDDocument
 	var doc *DIDWBADocument
 	var err error
+	start := time.Now()
 	if resolver != nil {
 		doc, err = resolver(ctx, did)
 	} else {
-		doc, err = ResolveDIDWBADocument(did, v.config.HTTPClient)
+		doc, err = ResolveDIDDocument(did, v.config.HTTPClient)
 	}
+	v.metrics().DIDResolved(did, time.Since(start), err)
 	if err != nil {
 		return nil, NewErrorWithStatus(WrapAuthError(ErrDIDResolution, "resolve DID document", err), StatusUnauthorized)
 	}
@@ -272,10 +486,11 @@ func (v *DidWbaVerifier) verifySignature(authHeader string, doc *DIDWBADocument,
 
 	// Prepare the payload to be verified
 	payload := authPayload{
-		Nonce:   parts.Nonce,
-		Time:    parts.Timestamp,
-		Service: serviceDomain,
-		DID:     parts.DID,
+		Nonce:      parts.Nonce,
+		Time:       parts.Timestamp,
+		Service:    serviceDomain,
+		DID:        parts.DID,
+		Delegation: delegationDigest(parts.Delegation),
 	}
 	payloadBytes, err := payload.marshal()
 	if err != nil {
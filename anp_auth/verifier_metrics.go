@@ -0,0 +1,112 @@
+package anp_auth
+
+import (
+	"errors"
+	"strings"
+	"time"
+)
+
+// MetricsRecorder receives the verifier's measurements, for wiring the
+// verifier into Prometheus, statsd or another metrics system. Methods are
+// called synchronously on the request path, so implementations must be safe
+// for concurrent use and should not block.
+type MetricsRecorder interface {
+	// VerificationSucceeded is called when an Authorization header is
+	// accepted. scheme is one of the MetricsScheme constants.
+	VerificationSucceeded(scheme string, elapsed time.Duration)
+	// VerificationFailed is called when an Authorization header is rejected,
+	// with the category of the error.
+	VerificationFailed(scheme string, category ErrorCategory, elapsed time.Duration)
+	// DIDResolved is called after each DID document resolution, that is on
+	// every DID cache miss, with the resolver's error if any.
+	DIDResolved(did string, elapsed time.Duration, err error)
+	// DIDCacheLookup is called for every DID document cache lookup.
+	DIDCacheLookup(hit bool)
+}
+
+// Scheme labels passed to MetricsRecorder.
+const (
+	MetricsSchemeNone   = "none"
+	MetricsSchemeBearer = "bearer"
+	MetricsSchemeDIDWBA = "didwba"
+)
+
+// ErrorCategory groups verification errors into a small set of values
+// suitable for metric labels.
+type ErrorCategory string
+
+// Error categories reported by ErrorCategoryOf.
+const (
+	ErrorCategoryMissingHeader ErrorCategory = "missing_header"
+	ErrorCategoryInvalidHeader ErrorCategory = "invalid_header"
+	ErrorCategoryInvalidToken  ErrorCategory = "invalid_token"
+	ErrorCategoryTokenRevoked  ErrorCategory = "token_revoked"
+	ErrorCategoryTimestamp     ErrorCategory = "timestamp"
+	ErrorCategoryNonce         ErrorCategory = "nonce"
+	ErrorCategoryNotAllowed    ErrorCategory = "not_allowed"
+	ErrorCategoryDIDResolution ErrorCategory = "did_resolution"
+	ErrorCategorySignature     ErrorCategory = "signature"
+	ErrorCategoryDelegation    ErrorCategory = "delegation"
+	ErrorCategoryInternal      ErrorCategory = "internal"
+	ErrorCategoryOther         ErrorCategory = "other"
+)
+
+// errorCategories maps sentinel errors to their category, checked in order.
+var errorCategories = []struct {
+	category ErrorCategory
+	errs     []error
+}{
+	{ErrorCategoryMissingHeader, []error{ErrMissingAuthHeader}},
+	{ErrorCategoryInvalidHeader, []error{ErrInvalidAuthHeader}},
+	{ErrorCategoryTokenRevoked, []error{ErrTokenRevoked}},
+	{ErrorCategoryInvalidToken, []error{ErrInvalidToken, ErrTokenExpired, ErrRefreshDisabled}},
+	{ErrorCategoryTimestamp, []error{ErrTimestampExpired, ErrTimestampFuture, ErrTimestampInvalid}},
+	{ErrorCategoryNonce, []error{ErrNonceInvalid, ErrNonceReused}},
+	{ErrorCategoryNotAllowed, []error{ErrDomainNotAllowed, ErrDIDNotAllowed}},
+	{ErrorCategoryDIDResolution, []error{ErrDIDResolution}},
+	{ErrorCategorySignature, []error{ErrInvalidSignature, ErrDIDMismatch, ErrVerificationMethodNotFound, ErrUnsupportedVerificationMethod}},
+	{ErrorCategoryDelegation, []error{ErrDelegationInvalid}},
+	{ErrorCategoryInternal, []error{ErrJWTConfigMissing, ErrNonceValidatorFailure, ErrTokenRevokerFailure, ErrChallengeStoreFailure, ErrTokenCreation}},
+}
+
+// ErrorCategoryOf returns the category of a verification error, or
+// ErrorCategoryOther if it matches none of the package's sentinel errors.
+func ErrorCategoryOf(err error) ErrorCategory {
+	for _, c := range errorCategories {
+		for _, sentinel := range c.errs {
+			if errors.Is(err, sentinel) {
+				return c.category
+			}
+		}
+	}
+	return ErrorCategoryOther
+}
+
+// metricsScheme returns the scheme label of an Authorization header as the
+// verifier dispatches it.
+func metricsScheme(authorization string) string {
+	switch {
+	case authorization == "":
+		return MetricsSchemeNone
+	case strings.HasPrefix(authorization, BearerScheme):
+		return MetricsSchemeBearer
+	}
+	return MetricsSchemeDIDWBA
+}
+
+// metrics returns the configured MetricsRecorder, or one that discards
+// everything.
+func (v *DidWbaVerifier) metrics() MetricsRecorder {
+	if v.config.Metrics == nil {
+		return nopMetricsRecorder{}
+	}
+	return v.config.Metrics
+}
+
+// nopMetricsRecorder is used when no MetricsRecorder is configured.
+type nopMetricsRecorder struct{}
+
+func (nopMetricsRecorder) VerificationSucceeded(string, time.Duration)             {}
+func (nopMetricsRecorder) VerificationFailed(string, ErrorCategory, time.Duration) {}
+func (nopMetricsRecorder) DIDResolved(string, time.Duration, error)                {}
+func (nopMetricsRecorder) DIDCacheLookup(bool)                                     {}
@@ -0,0 +1,134 @@
+package anp_auth
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+// countingRecorder counts the events it receives.
+type countingRecorder struct {
+	mu         sync.Mutex
+	succeeded  map[string]int
+	failed     map[ErrorCategory]int
+	resolved   int
+	resolveErr int
+	hits       int
+	misses     int
+}
+
+func newCountingRecorder() *countingRecorder {
+	return &countingRecorder{succeeded: map[string]int{}, failed: map[ErrorCategory]int{}}
+}
+
+func (r *countingRecorder) VerificationSucceeded(scheme string, _ time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.succeeded[scheme]++
+}
+
+func (r *countingRecorder) VerificationFailed(_ string, category ErrorCategory, _ time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.failed[category]++
+}
+
+func (r *countingRecorder) DIDResolved(_ string, _ time.Duration, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.resolved++
+	if err != nil {
+		r.resolveErr++
+	}
+}
+
+func (r *countingRecorder) DIDCacheLookup(hit bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if hit {
+		r.hits++
+	} else {
+		r.misses++
+	}
+}
+
+func TestVerifierMetrics(t *testing.T) {
+	caller := newDelegationParty(t, "caller.example.com")
+	auth, err := NewAuthenticator(WithDIDMaterial(caller.doc, caller.key))
+	if err != nil {
+		t.Fatalf("NewAuthenticator() error = %v", err)
+	}
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("GenerateKey() error = %v", err)
+	}
+	rec := newCountingRecorder()
+	verifier, err := NewDidWbaVerifier(DidWbaVerifierConfig{
+		JWTPrivateKey:      key,
+		JWTPublicKey:       &key.PublicKey,
+		NonceValidator:     NewMemoryNonceValidator(time.Minute),
+		ResolveDIDDocument: resolverFor(t, caller),
+		Metrics:            rec,
+	})
+	if err != nil {
+		t.Fatalf("NewDidWbaVerifier() error = %v", err)
+	}
+
+	var token string
+	for range 2 {
+		headers, err := auth.GenerateHeaderForce("https://api.example.com/rpc")
+		if err != nil {
+			t.Fatalf("GenerateHeaderForce() error = %v", err)
+		}
+		result, err := verifier.VerifyAuthHeader(headers[AuthorizationHeader], "api.example.com")
+		if err != nil {
+			t.Fatalf("VerifyAuthHeader() error = %v", err)
+		}
+		token = result["access_token"].(string)
+	}
+	if _, err := verifier.VerifyAuthHeader(BearerScheme+token, "api.example.com"); err != nil {
+		t.Fatalf("VerifyAuthHeader(bearer) error = %v", err)
+	}
+	verifier.VerifyAuthHeader("", "api.example.com")
+	verifier.VerifyAuthHeader(BearerScheme+"garbage", "api.example.com")
+
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	if rec.succeeded[MetricsSchemeDIDWBA] != 2 || rec.succeeded[MetricsSchemeBearer] != 1 {
+		t.Errorf("succeeded = %v, want 2 didwba and 1 bearer", rec.succeeded)
+	}
+	if rec.failed[ErrorCategoryMissingHeader] != 1 || rec.failed[ErrorCategoryInvalidToken] != 1 {
+		t.Errorf("failed = %v, want one missing_header and one invalid_token", rec.failed)
+	}
+	if rec.misses != 1 || rec.hits != 1 || rec.resolved != 1 || rec.resolveErr != 0 {
+		t.Errorf("cache misses=%d hits=%d resolved=%d errors=%d, want 1, 1, 1, 0", rec.misses, rec.hits, rec.resolved, rec.resolveErr)
+	}
+}
+
+func TestErrorCategoryOf(t *testing.T) {
+	tests := []struct {
+		err  error
+		want ErrorCategory
+	}{
+		{NewErrorWithStatus(ErrMissingAuthHeader, StatusUnauthorized), ErrorCategoryMissingHeader},
+		{NewErrorWithStatus(WrapAuthError(ErrInvalidToken, "verify", errors.New("bad")), StatusUnauthorized), ErrorCategoryInvalidToken},
+		{NewErrorWithStatus(ErrTokenRevoked, StatusUnauthorized), ErrorCategoryTokenRevoked},
+		{ErrTimestampFuture, ErrorCategoryTimestamp},
+		{ErrNonceInvalid, ErrorCategoryNonce},
+		{fmt.Errorf("%w: did:wba:x", ErrDIDNotAllowed), ErrorCategoryNotAllowed},
+		{WrapAuthError(ErrDIDResolution, "resolve", errors.New("404")), ErrorCategoryDIDResolution},
+		{fmt.Errorf("%w: DID mismatch", ErrInvalidSignature), ErrorCategorySignature},
+		{ErrDelegationInvalid, ErrorCategoryDelegation},
+		{ErrNonceValidatorFailure, ErrorCategoryInternal},
+		{errors.New("boom"), ErrorCategoryOther},
+	}
+	for _, tt := range tests {
+		if got := ErrorCategoryOf(tt.err); got != tt.want {
+			t.Errorf("ErrorCategoryOf(%v) = %q, want %q", tt.err, got, tt.want)
+		}
+	}
+}
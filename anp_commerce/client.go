@@ -0,0 +1,261 @@
+// Package anp_commerce drives commerce-style ANP interfaces — order creation,
+// payment links and receipts — such as those published by hotel booking and
+// e-commerce agents.
+//
+// Agents name these methods differently, so a Client discovers them in a
+// fetched interface document and normalises their responses into typed
+// orders:
+//
+//	doc, _ := sess.Fetch(ctx, "https://hotel.example.com/api/interface.json")
+//	shop, err := anp_commerce.NewClient(doc)
+//	order, _ := shop.CreateOrder(ctx, map[string]any{"hotelID": 10044523, "roomType": "deluxe"})
+//	link, _ := shop.PaymentLink(ctx, order)
+//	fmt.Println("pay at", link.URL)
+//	order, _ = shop.WaitForStatus(ctx, order.ID, anp_commerce.StatusPaid)
+//	receipt, _ := shop.Receipt(ctx, order.ID)
+package anp_commerce
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/openanp/anp-go/session"
+)
+
+const defaultPollInterval = 2 * time.Second
+
+var (
+	// ErrNotCommerce is returned by NewClient for documents without an order
+	// creation method.
+	ErrNotCommerce = errors.New("anp_commerce: document has no order creation method")
+	// ErrUnsupported is returned when the agent does not offer the operation.
+	ErrUnsupported = errors.New("anp_commerce: operation not offered by agent")
+	// ErrOrderEnded is returned by WaitForStatus when the order reaches a
+	// terminal status other than the awaited ones.
+	ErrOrderEnded = errors.New("anp_commerce: order ended")
+)
+
+// Methods names the JSON-RPC methods implementing each commerce operation.
+// Empty fields are unsupported.
+type Methods struct {
+	CreateOrder   string
+	GetOrder      string
+	CancelOrder   string
+	CreatePayment string
+	GetReceipt    string
+}
+
+// knownMethods lists method names recognised for each operation, in order of
+// preference.
+var knownMethods = struct {
+	createOrder, getOrder, cancelOrder, createPayment, getReceipt []string
+}{
+	createOrder:   []string{"createOrder", "placeOrder", "submitOrder", "bookHotel", "createBooking", "book"},
+	getOrder:      []string{"getOrder", "queryOrder", "getOrderStatus", "queryOrderStatus", "queryOrderDetail", "getBooking"},
+	cancelOrder:   []string{"cancelOrder", "cancelBooking"},
+	createPayment: []string{"createPayment", "getPaymentLink", "getPaymentUrl", "payOrder", "pay"},
+	getReceipt:    []string{"getReceipt", "queryReceipt", "getPaymentReceipt", "getInvoice"},
+}
+
+// Option configures a Client.
+type Option func(*Client)
+
+// WithMethods overrides discovered method names with the non-empty fields of m.
+func WithMethods(m Methods) Option {
+	return func(c *Client) {
+		for _, f := range []struct {
+			dst *string
+			src string
+		}{
+			{&c.methods.CreateOrder, m.CreateOrder},
+			{&c.methods.GetOrder, m.GetOrder},
+			{&c.methods.CancelOrder, m.CancelOrder},
+			{&c.methods.CreatePayment, m.CreatePayment},
+			{&c.methods.GetReceipt, m.GetReceipt},
+		} {
+			if f.src != "" {
+				*f.dst = f.src
+			}
+		}
+	}
+}
+
+// WithOrderIDParam sets the parameter name carrying the order ID (default
+// "orderId").
+func WithOrderIDParam(name string) Option {
+	return func(c *Client) { c.orderIDParam = name }
+}
+
+// WithPollInterval sets how often WaitForStatus polls (default 2s).
+func WithPollInterval(d time.Duration) Option {
+	return func(c *Client) {
+		if d > 0 {
+			c.poll = d
+		}
+	}
+}
+
+// Client executes commerce operations against one agent's interface document.
+type Client struct {
+	doc          *session.Document
+	methods      Methods
+	orderIDParam string
+	poll         time.Duration
+}
+
+// NewClient discovers the commerce methods in doc.
+func NewClient(doc *session.Document, opts ...Option) (*Client, error) {
+	if doc == nil {
+		return nil, errors.New("anp_commerce: document is nil")
+	}
+	available := make([]string, 0, len(doc.Interfaces))
+	for _, iface := range doc.Interfaces {
+		available = append(available, iface.Method)
+	}
+	find := func(candidates []string) string {
+		for _, name := range candidates {
+			if slices.Contains(available, name) {
+				return name
+			}
+		}
+		return ""
+	}
+
+	c := &Client{
+		doc: doc,
+		methods: Methods{
+			CreateOrder:   find(knownMethods.createOrder),
+			GetOrder:      find(knownMethods.getOrder),
+			CancelOrder:   find(knownMethods.cancelOrder),
+			CreatePayment: find(knownMethods.createPayment),
+			GetReceipt:    find(knownMethods.getReceipt),
+		},
+		orderIDParam: "orderId",
+		poll:         defaultPollInterval,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	if c.methods.CreateOrder == "" {
+		return nil, ErrNotCommerce
+	}
+	return c, nil
+}
+
+// Methods returns the method names the client uses.
+func (c *Client) Methods() Methods {
+	return c.methods
+}
+
+// CreateOrder places an order with params as the method arguments.
+func (c *Client) CreateOrder(ctx context.Context, params map[string]any) (*Order, error) {
+	result, err := c.call(ctx, c.methods.CreateOrder, params)
+	if err != nil {
+		return nil, err
+	}
+	return ParseOrder(result)
+}
+
+// GetOrder fetches the current state of an order.
+func (c *Client) GetOrder(ctx context.Context, orderID string) (*Order, error) {
+	result, err := c.call(ctx, c.methods.GetOrder, c.orderParams(orderID))
+	if err != nil {
+		return nil, err
+	}
+	return ParseOrder(result)
+}
+
+// CancelOrder cancels an order and returns its new state. Agents that reply
+// without the order yield an order with only ID and StatusCancelled set.
+func (c *Client) CancelOrder(ctx context.Context, orderID string) (*Order, error) {
+	result, err := c.call(ctx, c.methods.CancelOrder, c.orderParams(orderID))
+	if err != nil {
+		return nil, err
+	}
+	order, err := ParseOrder(result)
+	if errors.Is(err, ErrNoOrder) {
+		return &Order{ID: orderID, Status: StatusCancelled}, nil
+	}
+	return order, err
+}
+
+// PaymentLink returns where to pay for order, using the link included in the
+// order itself when the agent returned one at creation.
+func (c *Client) PaymentLink(ctx context.Context, order *Order) (*PaymentLink, error) {
+	if order == nil {
+		return nil, ErrNoOrder
+	}
+	if order.Payment != nil {
+		return order.Payment, nil
+	}
+	result, err := c.call(ctx, c.methods.CreatePayment, c.orderParams(order.ID))
+	if err != nil {
+		return nil, err
+	}
+	return ParsePaymentLink(result)
+}
+
+// Receipt fetches the receipt of a paid order, falling back to the receipt
+// embedded in the order when the agent has no receipt method.
+func (c *Client) Receipt(ctx context.Context, orderID string) (*Receipt, error) {
+	if c.methods.GetReceipt == "" && c.methods.GetOrder != "" {
+		order, err := c.GetOrder(ctx, orderID)
+		if err != nil {
+			return nil, err
+		}
+		if order.Receipt == nil {
+			return nil, fmt.Errorf("%w: receipt for order %s", ErrUnsupported, orderID)
+		}
+		return order.Receipt, nil
+	}
+	result, err := c.call(ctx, c.methods.GetReceipt, c.orderParams(orderID))
+	if err != nil {
+		return nil, err
+	}
+	receipt, err := ParseReceipt(result)
+	if err != nil {
+		return nil, err
+	}
+	if receipt.OrderID == "" {
+		receipt.OrderID = orderID
+	}
+	return receipt, nil
+}
+
+// WaitForStatus polls the order until its status is one of want, returning
+// ErrOrderEnded if it reaches another terminal status first.
+func (c *Client) WaitForStatus(ctx context.Context, orderID string, want ...OrderStatus) (*Order, error) {
+	ticker := time.NewTicker(c.poll)
+	defer ticker.Stop()
+	for {
+		order, err := c.GetOrder(ctx, orderID)
+		if err != nil {
+			return nil, err
+		}
+		if slices.Contains(want, order.Status) {
+			return order, nil
+		}
+		if order.Status.Terminal() {
+			return order, fmt.Errorf("%w: order %s is %s", ErrOrderEnded, orderID, order.Status)
+		}
+		select {
+		case <-ctx.Done():
+			return order, ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+func (c *Client) orderParams(orderID string) map[string]any {
+	return map[string]any{c.orderIDParam: orderID}
+}
+
+func (c *Client) call(ctx context.Context, method string, params map[string]any) (map[string]any, error) {
+	if method == "" {
+		return nil, ErrUnsupported
+	}
+	return session.ExecuteTool(ctx, c.doc, method, params)
+}
@@ -0,0 +1,209 @@
+package anp_commerce
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/openanp/anp-go/anptest"
+	"github.com/openanp/anp-go/session"
+)
+
+type bookParams struct {
+	HotelID  int    `json:"hotelID"`
+	RoomType string `json:"roomType"`
+}
+
+type orderParams struct {
+	OrderID string `json:"orderId"`
+}
+
+// hotelShop is a booking agent whose order becomes paid after two status polls.
+type hotelShop struct {
+	mu    sync.Mutex
+	polls int
+}
+
+func (h *hotelShop) BookHotel(p bookParams) (map[string]any, error) {
+	return map[string]any{"order": map[string]any{
+		"orderNo":     "HB-1001",
+		"orderStatus": "WAIT_PAY",
+		"totalPrice":  "688.00",
+		"currency":    "CNY",
+		"items":       []any{map[string]any{"roomType": p.RoomType, "qty": 1, "unitPrice": 688}},
+	}}, nil
+}
+
+func (h *hotelShop) QueryOrder(p orderParams) (map[string]any, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.polls++
+	status := "unpaid"
+	if h.polls > 2 {
+		status = "SUCCESS"
+	}
+	return map[string]any{"orderNo": p.OrderID, "state": status, "totalPrice": 688, "currency": "CNY"}, nil
+}
+
+func (h *hotelShop) GetPaymentUrl(p orderParams) (map[string]any, error) {
+	return map[string]any{"payUrl": "https://pay.example.com/" + p.OrderID, "expireTime": "2025-11-03T12:00:00Z"}, nil
+}
+
+func (h *hotelShop) GetInvoice(p orderParams) (map[string]any, error) {
+	return map[string]any{"transactionId": "TX-9", "paidAmount": map[string]any{"value": "688.00", "currency": "CNY"}, "payTime": 1762171200}, nil
+}
+
+func newShopDocument(t *testing.T, service any) *session.Document {
+	t.Helper()
+	caller := anptest.NewIdentity(t, "client.example.com")
+	srv := anptest.NewServer(t, anptest.WithService(service), anptest.WithDIDAuth(caller))
+	sess, err := session.New(session.Config{Authenticator: caller.Authenticator})
+	if err != nil {
+		t.Fatalf("session.New() error = %v", err)
+	}
+	doc, err := sess.Fetch(context.Background(), srv.OpenRPCURL())
+	if err != nil {
+		t.Fatalf("Fetch() error = %v", err)
+	}
+	return doc
+}
+
+func TestBookingFlow(t *testing.T) {
+	doc := newShopDocument(t, &hotelShop{})
+	shop, err := NewClient(doc, WithPollInterval(10*time.Millisecond))
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+	want := Methods{CreateOrder: "bookHotel", GetOrder: "queryOrder", CreatePayment: "getPaymentUrl", GetReceipt: "getInvoice"}
+	if got := shop.Methods(); got != want {
+		t.Errorf("Methods() = %+v, want %+v", got, want)
+	}
+	ctx := context.Background()
+
+	order, err := shop.CreateOrder(ctx, map[string]any{"hotelID": 10044523, "roomType": "deluxe"})
+	if err != nil {
+		t.Fatalf("CreateOrder() error = %v", err)
+	}
+	if order.ID != "HB-1001" || order.Status != StatusPendingPayment || order.Total.String() != "688.00 CNY" {
+		t.Errorf("CreateOrder() = %+v", order)
+	}
+	if len(order.Items) != 1 || order.Items[0].Name != "deluxe" || order.Items[0].Price.Amount != "688" {
+		t.Errorf("items = %+v", order.Items)
+	}
+
+	link, err := shop.PaymentLink(ctx, order)
+	if err != nil {
+		t.Fatalf("PaymentLink() error = %v", err)
+	}
+	if link.URL != "https://pay.example.com/HB-1001" || link.ExpiresAt.IsZero() {
+		t.Errorf("PaymentLink() = %+v", link)
+	}
+
+	paid, err := shop.WaitForStatus(ctx, order.ID, StatusPaid)
+	if err != nil {
+		t.Fatalf("WaitForStatus() error = %v", err)
+	}
+	if !paid.Status.Paid() {
+		t.Errorf("WaitForStatus() status = %s", paid.Status)
+	}
+
+	receipt, err := shop.Receipt(ctx, order.ID)
+	if err != nil {
+		t.Fatalf("Receipt() error = %v", err)
+	}
+	if receipt.ID != "TX-9" || receipt.OrderID != "HB-1001" || receipt.Amount.String() != "688.00 CNY" || receipt.PaidAt.Year() != 2025 {
+		t.Errorf("Receipt() = %+v", receipt)
+	}
+
+	if _, err := shop.CancelOrder(ctx, order.ID); !errors.Is(err, ErrUnsupported) {
+		t.Errorf("CancelOrder() error = %v, want ErrUnsupported", err)
+	}
+}
+
+type pingService struct{}
+
+func (pingService) Ping() error { return nil }
+
+func TestNewClientNotCommerce(t *testing.T) {
+	doc := newShopDocument(t, pingService{})
+	if _, err := NewClient(doc); !errors.Is(err, ErrNotCommerce) {
+		t.Errorf("NewClient() error = %v, want ErrNotCommerce", err)
+	}
+	if _, err := NewClient(doc, WithMethods(Methods{CreateOrder: "ping"})); err != nil {
+		t.Errorf("NewClient(WithMethods) error = %v", err)
+	}
+}
+
+func TestParseStatus(t *testing.T) {
+	tests := map[string]OrderStatus{
+		"PENDING-PAYMENT": StatusPendingPayment,
+		"pendingPayment":  StatusPendingPayment,
+		"WAIT_PAY":        StatusPendingPayment,
+		"Canceled":        StatusCancelled,
+		"confirmed":       StatusConfirmed,
+		"on hold":         "on_hold",
+	}
+	for in, want := range tests {
+		if got := ParseStatus(in); got != want {
+			t.Errorf("ParseStatus(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestParseOrder(t *testing.T) {
+	tests := []struct {
+		name    string
+		in      any
+		id      string
+		status  OrderStatus
+		total   string
+		payment string
+		err     error
+	}{
+		{
+			name:    "json-rpc envelope",
+			in:      `{"jsonrpc": "2.0", "id": "1", "result": {"data": {"order_id": 42, "status": "paid", "total_amount": {"amount": "12.50", "currency": "USD"}, "payment": {"url": "https://pay.example.com/42"}}}}`,
+			id:      "42",
+			status:  StatusPaid,
+			total:   "12.50 USD",
+			payment: "https://pay.example.com/42",
+		},
+		{
+			name:   "bare url is not a payment link",
+			in:     map[string]any{"id": "A1", "status": "created", "url": "https://shop.example.com/orders/A1"},
+			id:     "A1",
+			status: StatusCreated,
+		},
+		{
+			name: "no order",
+			in:   []byte(`{"message": "ok"}`),
+			err:  ErrNoOrder,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			order, err := ParseOrder(tt.in)
+			if tt.err != nil {
+				if !errors.Is(err, tt.err) {
+					t.Fatalf("ParseOrder() error = %v, want %v", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseOrder() error = %v", err)
+			}
+			if order.ID != tt.id || order.Status != tt.status || order.Total.String() != tt.total {
+				t.Errorf("ParseOrder() = %+v", order)
+			}
+			var payment string
+			if order.Payment != nil {
+				payment = order.Payment.URL
+			}
+			if payment != tt.payment {
+				t.Errorf("payment = %q, want %q", payment, tt.payment)
+			}
+		})
+	}
+}
@@ -0,0 +1,353 @@
+package anp_commerce
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/bytedance/sonic"
+)
+
+// OrderStatus is the normalised state of an order.
+type OrderStatus string
+
+const (
+	StatusCreated        OrderStatus = "created"
+	StatusPendingPayment OrderStatus = "pending_payment"
+	StatusPaid           OrderStatus = "paid"
+	StatusConfirmed      OrderStatus = "confirmed"
+	StatusCompleted      OrderStatus = "completed"
+	StatusCancelled      OrderStatus = "cancelled"
+	StatusRefunded       OrderStatus = "refunded"
+	StatusFailed         OrderStatus = "failed"
+)
+
+// statusAliases maps spellings seen in agent responses to a normalised status.
+var statusAliases = map[string]OrderStatus{
+	"new":              StatusCreated,
+	"pending":          StatusCreated,
+	"unpaid":           StatusPendingPayment,
+	"wait_pay":         StatusPendingPayment,
+	"waiting_payment":  StatusPendingPayment,
+	"awaiting_payment": StatusPendingPayment,
+	"success":          StatusPaid,
+	"succeeded":        StatusPaid,
+	"booked":           StatusConfirmed,
+	"complete":         StatusCompleted,
+	"finished":         StatusCompleted,
+	"done":             StatusCompleted,
+	"canceled":         StatusCancelled,
+	"closed":           StatusCancelled,
+	"error":            StatusFailed,
+}
+
+// ParseStatus normalises s, accepting case and separator variants
+// ("PENDING-PAYMENT", "pendingPayment") and common aliases ("unpaid",
+// "canceled"). Unknown values are returned lower-cased.
+func ParseStatus(s string) OrderStatus {
+	var (
+		b    strings.Builder
+		prev rune
+	)
+	for _, r := range strings.TrimSpace(s) {
+		switch {
+		case r == '-' || r == ' ':
+			b.WriteByte('_')
+		case unicode.IsUpper(r):
+			if unicode.IsLower(prev) {
+				b.WriteByte('_')
+			}
+			b.WriteRune(unicode.ToLower(r))
+		default:
+			b.WriteRune(r)
+		}
+		prev = r
+	}
+	norm := b.String()
+	if alias, ok := statusAliases[norm]; ok {
+		return alias
+	}
+	return OrderStatus(norm)
+}
+
+// Paid reports whether payment has been received.
+func (s OrderStatus) Paid() bool {
+	return s == StatusPaid || s == StatusConfirmed || s == StatusCompleted
+}
+
+// Terminal reports whether the order can no longer change, other than by a
+// refund of a completed order.
+func (s OrderStatus) Terminal() bool {
+	switch s {
+	case StatusCompleted, StatusCancelled, StatusRefunded, StatusFailed:
+		return true
+	}
+	return false
+}
+
+// Money is an amount in a currency. Amount keeps the decimal string the agent
+// sent so no precision is lost.
+type Money struct {
+	Amount   string `json:"amount"`
+	Currency string `json:"currency,omitempty"`
+}
+
+func (m Money) String() string {
+	return strings.TrimSpace(m.Amount + " " + m.Currency)
+}
+
+// LineItem is one product or room in an order.
+type LineItem struct {
+	ID       string `json:"id,omitempty"`
+	Name     string `json:"name,omitempty"`
+	Quantity int    `json:"quantity,omitempty"`
+	Price    Money  `json:"price"`
+}
+
+// PaymentLink is where the buyer completes payment for an order.
+type PaymentLink struct {
+	URL       string    `json:"url"`
+	Method    string    `json:"method,omitempty"`
+	ExpiresAt time.Time `json:"expires_at,omitzero"`
+}
+
+// Receipt is the agent's proof of a completed payment.
+type Receipt struct {
+	ID      string    `json:"id,omitempty"`
+	OrderID string    `json:"order_id,omitempty"`
+	Amount  Money     `json:"amount"`
+	PaidAt  time.Time `json:"paid_at,omitzero"`
+	URL     string    `json:"url,omitempty"`
+	// Raw is the receipt object as returned by the agent.
+	Raw map[string]any `json:"-"`
+}
+
+// Order is an order created through a commerce interface.
+type Order struct {
+	ID      string       `json:"id"`
+	Status  OrderStatus  `json:"status"`
+	Total   Money        `json:"total"`
+	Items   []LineItem   `json:"items,omitempty"`
+	Payment *PaymentLink `json:"payment,omitempty"`
+	Receipt *Receipt     `json:"receipt,omitempty"`
+	// Raw is the order object as returned by the agent, for fields this
+	// package does not model.
+	Raw map[string]any `json:"-"`
+}
+
+// ErrNoOrder is returned when a response does not contain an order.
+var ErrNoOrder = errors.New("anp_commerce: response does not contain an order")
+
+// ParseOrder extracts an Order from a JSON-RPC response, its result, or an
+// order object, as raw JSON or decoded values. Field names are matched
+// leniently ("orderId", "order_id", "id"; "paymentUrl", "payUrl", ...) since
+// agents differ in their spelling.
+func ParseOrder(v any) (*Order, error) {
+	m, err := object(v)
+	if err != nil {
+		return nil, err
+	}
+	m = unwrap(m, "order")
+	id := str(m, "orderId", "order_id", "orderNo", "order_no", "id")
+	if id == "" {
+		return nil, ErrNoOrder
+	}
+
+	o := &Order{ID: id, Status: ParseStatus(str(m, "status", "orderStatus", "order_status", "state")), Raw: m}
+	o.Total = money(m, "total", "totalAmount", "total_amount", "totalPrice", "total_price", "amount", "price")
+	if items, ok := pick(m, "items", "lineItems", "line_items").([]any); ok {
+		for _, it := range items {
+			im, ok := it.(map[string]any)
+			if !ok {
+				continue
+			}
+			o.Items = append(o.Items, LineItem{
+				ID:       str(im, "id", "sku", "productId", "roomId"),
+				Name:     str(im, "name", "title", "roomType"),
+				Quantity: int(num(im, "quantity", "qty", "count")),
+				Price:    money(im, "price", "unitPrice", "unit_price", "amount"),
+			})
+		}
+	}
+	if p, err := parsePayment(m, false); err == nil {
+		o.Payment = p
+	}
+	if r, ok := pick(m, "receipt").(map[string]any); ok {
+		o.Receipt = parseReceipt(r)
+		if o.Receipt.OrderID == "" {
+			o.Receipt.OrderID = o.ID
+		}
+	}
+	return o, nil
+}
+
+// ParsePaymentLink extracts a PaymentLink from a response, result, or payment
+// object.
+func ParsePaymentLink(v any) (*PaymentLink, error) {
+	m, err := object(v)
+	if err != nil {
+		return nil, err
+	}
+	return parsePayment(m, true)
+}
+
+// ParseReceipt extracts a Receipt from a response, result, or receipt object.
+func ParseReceipt(v any) (*Receipt, error) {
+	m, err := object(v)
+	if err != nil {
+		return nil, err
+	}
+	m = unwrap(m, "receipt")
+	r := parseReceipt(m)
+	if r.ID == "" && r.OrderID == "" && r.Amount.Amount == "" {
+		return nil, errors.New("anp_commerce: response does not contain a receipt")
+	}
+	return r, nil
+}
+
+// parsePayment reads a payment link from m or its nested payment object. A
+// bare "url" field only counts inside a payment object or when bareURL is set,
+// since orders often carry unrelated URLs.
+func parsePayment(m map[string]any, bareURL bool) (*PaymentLink, error) {
+	if nested, ok := pick(m, "payment", "paymentInfo", "payment_info").(map[string]any); ok {
+		m, bareURL = nested, true
+	}
+	keys := []string{"paymentUrl", "payment_url", "payUrl", "pay_url", "paymentLink", "payment_link"}
+	if bareURL {
+		keys = append(keys, "url")
+	}
+	url := str(m, keys...)
+	if url == "" {
+		return nil, errors.New("anp_commerce: response does not contain a payment link")
+	}
+	return &PaymentLink{
+		URL:       url,
+		Method:    str(m, "paymentMethod", "payment_method", "method"),
+		ExpiresAt: timestamp(m, "expiresAt", "expires_at", "expireTime", "expire_time"),
+	}, nil
+}
+
+func parseReceipt(m map[string]any) *Receipt {
+	return &Receipt{
+		ID:      str(m, "receiptId", "receipt_id", "receiptNo", "transactionId", "transaction_id", "id"),
+		OrderID: str(m, "orderId", "order_id", "orderNo"),
+		Amount:  money(m, "amount", "paidAmount", "paid_amount", "total"),
+		PaidAt:  timestamp(m, "paidAt", "paid_at", "payTime", "pay_time", "createdAt"),
+		URL:     str(m, "receiptUrl", "receipt_url", "url"),
+		Raw:     m,
+	}
+}
+
+// object decodes v to a JSON object and strips a JSON-RPC envelope.
+func object(v any) (map[string]any, error) {
+	var m map[string]any
+	switch val := v.(type) {
+	case map[string]any:
+		m = val
+	case []byte:
+		if err := sonic.Unmarshal(val, &m); err != nil {
+			return nil, fmt.Errorf("anp_commerce: decode response: %w", err)
+		}
+	case string:
+		if err := sonic.UnmarshalString(val, &m); err != nil {
+			return nil, fmt.Errorf("anp_commerce: decode response: %w", err)
+		}
+	default:
+		raw, err := sonic.Marshal(v)
+		if err != nil {
+			return nil, fmt.Errorf("anp_commerce: encode response: %w", err)
+		}
+		if err := sonic.Unmarshal(raw, &m); err != nil {
+			return nil, fmt.Errorf("anp_commerce: decode response: %w", err)
+		}
+	}
+	if m == nil {
+		return nil, ErrNoOrder
+	}
+	if result, ok := m["result"].(map[string]any); ok {
+		if _, rpc := m["jsonrpc"]; rpc {
+			m = result
+		}
+	}
+	return unwrap(m, "data"), nil
+}
+
+// unwrap descends into m[key] when it holds an object.
+func unwrap(m map[string]any, key string) map[string]any {
+	if nested, ok := m[key].(map[string]any); ok {
+		return nested
+	}
+	return m
+}
+
+func pick(m map[string]any, keys ...string) any {
+	for _, k := range keys {
+		if v, ok := m[k]; ok && v != nil {
+			return v
+		}
+	}
+	return nil
+}
+
+func str(m map[string]any, keys ...string) string {
+	return scalar(pick(m, keys...))
+}
+
+func scalar(v any) string {
+	switch v := v.(type) {
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case nil:
+		return ""
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+func num(m map[string]any, keys ...string) float64 {
+	switch v := pick(m, keys...).(type) {
+	case float64:
+		return v
+	case string:
+		f, _ := strconv.ParseFloat(v, 64)
+		return f
+	}
+	return 0
+}
+
+// money reads an amount given as a number, a decimal string, or an object
+// with amount/value and currency fields. A sibling "currency" field applies
+// to bare amounts.
+func money(m map[string]any, keys ...string) Money {
+	switch v := pick(m, keys...).(type) {
+	case map[string]any:
+		return Money{Amount: str(v, "amount", "value"), Currency: str(v, "currency", "currencyCode")}
+	case nil:
+		return Money{}
+	default:
+		return Money{Amount: scalar(v), Currency: str(m, "currency", "currencyCode")}
+	}
+}
+
+func timestamp(m map[string]any, keys ...string) time.Time {
+	switch v := pick(m, keys...).(type) {
+	case string:
+		for _, layout := range []string{time.RFC3339, "2006-01-02 15:04:05"} {
+			if t, err := time.Parse(layout, v); err == nil {
+				return t
+			}
+		}
+	case float64:
+		// Unix seconds, or milliseconds when too large to be seconds.
+		if v > 1e12 {
+			return time.UnixMilli(int64(v)).UTC()
+		}
+		return time.Unix(int64(v), 0).UTC()
+	}
+	return time.Time{}
+}
@@ -0,0 +1,121 @@
+package anp_config
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/openanp/anp-go/anp_auth"
+	"github.com/openanp/anp-go/anp_debug"
+	"github.com/openanp/anp-go/session"
+)
+
+// ErrNoIdentity is returned when an authenticator is requested but the config
+// names no DID material.
+var ErrNoIdentity = errors.New("anp_config: identity.did_document and identity.private_key are not set")
+
+// ErrNoVerifier is returned when a verifier is requested but the config names
+// no JWT keys.
+var ErrNoVerifier = errors.New("anp_config: verifier.jwt_private_key and verifier.jwt_public_key are not set")
+
+// AuthenticatorOptions returns the anp_auth options described by the identity
+// section, for callers that add options of their own.
+func (c *Config) AuthenticatorOptions() ([]anp_auth.AuthenticatorOption, error) {
+	if c.Identity.DIDDocument == "" {
+		return nil, ErrNoIdentity
+	}
+	opts := []anp_auth.AuthenticatorOption{
+		anp_auth.WithDIDCfgPaths(c.Identity.DIDDocument, c.Identity.PrivateKey),
+	}
+	if c.Identity.Eager {
+		opts = append(opts, anp_auth.WithEagerLoading())
+	}
+	if c.Identity.CacheSize > 0 {
+		opts = append(opts, anp_auth.WithCacheSize(c.Identity.CacheSize))
+	}
+	return opts, nil
+}
+
+// NewAuthenticator builds the authenticator described by the identity section.
+func (c *Config) NewAuthenticator(extra ...anp_auth.AuthenticatorOption) (*anp_auth.Authenticator, error) {
+	opts, err := c.AuthenticatorOptions()
+	if err != nil {
+		return nil, err
+	}
+	return anp_auth.NewAuthenticator(append(opts, extra...)...)
+}
+
+// SessionConfig returns the session.Config described by the identity and
+// session sections. Logger and custom parsers can be set on the result before
+// passing it to session.New.
+func (c *Config) SessionConfig() (session.Config, error) {
+	auth, err := c.NewAuthenticator()
+	if err != nil {
+		return session.Config{}, err
+	}
+	cfg := session.Config{
+		Authenticator: auth,
+		HTTP:          session.HTTPConfig{Timeout: time.Duration(c.Session.Timeout)},
+		MaxConcurrent: c.Session.MaxConcurrent,
+	}
+	if c.Session.DebugDir != "" {
+		rec, err := anp_debug.NewRecorder(c.Session.DebugDir)
+		if err != nil {
+			return session.Config{}, err
+		}
+		cfg.Debug = rec
+	}
+	return cfg, nil
+}
+
+// NewSession builds a session from SessionConfig.
+func (c *Config) NewSession() (*session.Session, error) {
+	cfg, err := c.SessionConfig()
+	if err != nil {
+		return nil, err
+	}
+	return session.New(cfg)
+}
+
+// VerifierConfig returns the anp_auth.DidWbaVerifierConfig described by the
+// verifier section, reading the JWT keys and using an in-memory nonce
+// validator. Replace NonceValidator with a shared store when several instances
+// verify the same callers.
+func (c *Config) VerifierConfig() (anp_auth.DidWbaVerifierConfig, error) {
+	v := c.Verifier
+	if v.JWTPrivateKey == "" {
+		return anp_auth.DidWbaVerifierConfig{}, ErrNoVerifier
+	}
+	privatePEM, err := os.ReadFile(v.JWTPrivateKey)
+	if err != nil {
+		return anp_auth.DidWbaVerifierConfig{}, fmt.Errorf("read JWT private key: %w", err)
+	}
+	publicPEM, err := os.ReadFile(v.JWTPublicKey)
+	if err != nil {
+		return anp_auth.DidWbaVerifierConfig{}, fmt.Errorf("read JWT public key: %w", err)
+	}
+	return anp_auth.DidWbaVerifierConfig{
+		JWTPrivateKeyPEM:      privatePEM,
+		JWTPublicKeyPEM:       publicPEM,
+		JWTAlgorithm:          v.JWTAlgorithm,
+		AccessTokenExpiration: time.Duration(v.AccessTokenExpiration),
+		TimestampExpiration:   time.Duration(v.TimestampExpiration),
+		DIDCacheExpiration:    time.Duration(v.DIDCacheExpiration),
+		AllowedDomains:        v.AllowedDomains,
+		AllowedDIDs:           v.AllowedDIDs,
+		BlockedDIDs:           v.BlockedDIDs,
+		NonceValidator:        anp_auth.NewMemoryNonceValidator(time.Duration(v.NonceExpiration)),
+		HTTPClient:            &http.Client{Timeout: time.Duration(c.Session.Timeout)},
+	}, nil
+}
+
+// NewVerifier builds a verifier from VerifierConfig.
+func (c *Config) NewVerifier() (*anp_auth.DidWbaVerifier, error) {
+	cfg, err := c.VerifierConfig()
+	if err != nil {
+		return nil, err
+	}
+	return anp_auth.NewDidWbaVerifier(cfg)
+}
@@ -0,0 +1,281 @@
+// Package anp_config loads session, authenticator and verifier settings from a
+// YAML or JSON file and the environment, so services do not wire
+// session.Config and anp_auth.DidWbaVerifierConfig by hand.
+//
+//	identity:
+//	  did_document: /etc/anp/did.json
+//	  private_key: /etc/anp/key.pem
+//	session:
+//	  timeout: 10s
+//	verifier:
+//	  jwt_private_key: /etc/anp/jwt.pem
+//	  jwt_public_key: /etc/anp/jwt.pub
+//	  allowed_domains: [agent.example.com]
+//
+// Every field can be overridden by the environment variable named in its env
+// tag, e.g. ANP_PRIVATE_KEY or ANP_ALLOWED_DOMAINS (comma-separated).
+//
+//	cfg, err := anp_config.Load("anp.yaml")
+//	sess, err := cfg.NewSession()
+//	verifier, err := cfg.NewVerifier()
+package anp_config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bytedance/sonic"
+	"github.com/openanp/anp-go/anp_auth"
+	"gopkg.in/yaml.v3"
+)
+
+// Defaults applied to fields left empty.
+const (
+	DefaultTimeout       = 30 * time.Second
+	DefaultMaxConcurrent = 5
+)
+
+// Config is the file layout understood by Load.
+type Config struct {
+	Identity IdentityConfig `json:"identity" yaml:"identity"`
+	Session  SessionConfig  `json:"session" yaml:"session"`
+	Verifier VerifierConfig `json:"verifier" yaml:"verifier"`
+}
+
+// IdentityConfig locates the DID material used to sign outbound requests.
+type IdentityConfig struct {
+	DIDDocument string `json:"did_document" yaml:"did_document" env:"ANP_DID_DOCUMENT"`
+	PrivateKey  string `json:"private_key" yaml:"private_key" env:"ANP_PRIVATE_KEY"`
+	// Eager loads the DID material when the authenticator is built instead of
+	// on first use, so a broken deployment fails at startup.
+	Eager     bool `json:"eager" yaml:"eager" env:"ANP_IDENTITY_EAGER"`
+	CacheSize int  `json:"cache_size" yaml:"cache_size" env:"ANP_AUTH_CACHE_SIZE"`
+}
+
+// SessionConfig mirrors the tunables of session.Config.
+type SessionConfig struct {
+	Timeout       Duration `json:"timeout" yaml:"timeout" env:"ANP_HTTP_TIMEOUT"`
+	MaxConcurrent int      `json:"max_concurrent" yaml:"max_concurrent" env:"ANP_MAX_CONCURRENT"`
+	DebugDir      string   `json:"debug_dir" yaml:"debug_dir" env:"ANP_DEBUG_DIR"`
+}
+
+// VerifierConfig mirrors anp_auth.DidWbaVerifierConfig. The JWT keys are PEM
+// file paths. The section is optional; it is validated only when a key is set.
+type VerifierConfig struct {
+	JWTPrivateKey         string   `json:"jwt_private_key" yaml:"jwt_private_key" env:"ANP_JWT_PRIVATE_KEY"`
+	JWTPublicKey          string   `json:"jwt_public_key" yaml:"jwt_public_key" env:"ANP_JWT_PUBLIC_KEY"`
+	JWTAlgorithm          string   `json:"jwt_algorithm" yaml:"jwt_algorithm" env:"ANP_JWT_ALGORITHM"`
+	AccessTokenExpiration Duration `json:"access_token_expiration" yaml:"access_token_expiration" env:"ANP_ACCESS_TOKEN_EXPIRATION"`
+	TimestampExpiration   Duration `json:"timestamp_expiration" yaml:"timestamp_expiration" env:"ANP_TIMESTAMP_EXPIRATION"`
+	DIDCacheExpiration    Duration `json:"did_cache_expiration" yaml:"did_cache_expiration" env:"ANP_DID_CACHE_EXPIRATION"`
+	NonceExpiration       Duration `json:"nonce_expiration" yaml:"nonce_expiration" env:"ANP_NONCE_EXPIRATION"`
+	AllowedDomains        []string `json:"allowed_domains" yaml:"allowed_domains" env:"ANP_ALLOWED_DOMAINS"`
+	AllowedDIDs           []string `json:"allowed_dids" yaml:"allowed_dids" env:"ANP_ALLOWED_DIDS"`
+	BlockedDIDs           []string `json:"blocked_dids" yaml:"blocked_dids" env:"ANP_BLOCKED_DIDS"`
+}
+
+// Duration is a time.Duration written as a Go duration string ("30s", "5m").
+type Duration time.Duration
+
+// UnmarshalText parses a duration string.
+func (d *Duration) UnmarshalText(text []byte) error {
+	v, err := time.ParseDuration(string(text))
+	if err != nil {
+		return err
+	}
+	*d = Duration(v)
+	return nil
+}
+
+// MarshalText formats the duration like time.Duration.String.
+func (d Duration) MarshalText() ([]byte, error) {
+	return []byte(time.Duration(d).String()), nil
+}
+
+// Load reads path, applies environment overrides and defaults, and validates
+// the result. Files ending in .yaml or .yml are decoded as YAML, anything else
+// as JSON.
+func Load(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read config: %w", err)
+	}
+	cfg := &Config{}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		dec := yaml.NewDecoder(bytes.NewReader(data))
+		dec.KnownFields(true)
+		if err := dec.Decode(cfg); err != nil && !errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("decode %s: %w", path, err)
+		}
+	default:
+		if err := sonic.ConfigStd.Unmarshal(data, cfg); err != nil {
+			return nil, fmt.Errorf("decode %s: %w", path, err)
+		}
+	}
+	return finish(cfg)
+}
+
+// LoadEnv builds a Config from environment variables alone.
+func LoadEnv() (*Config, error) {
+	return finish(&Config{})
+}
+
+func finish(cfg *Config) (*Config, error) {
+	if err := cfg.ApplyEnv(os.LookupEnv); err != nil {
+		return nil, err
+	}
+	cfg.SetDefaults()
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// ApplyEnv overrides every field whose env variable is reported by lookup.
+func (c *Config) ApplyEnv(lookup func(string) (string, bool)) error {
+	var errs []error
+	applyEnv(reflect.ValueOf(c).Elem(), lookup, &errs)
+	return errors.Join(errs...)
+}
+
+var durationType = reflect.TypeFor[Duration]()
+
+func applyEnv(v reflect.Value, lookup func(string) (string, bool), errs *[]error) {
+	for i := 0; i < v.NumField(); i++ {
+		field, sf := v.Field(i), v.Type().Field(i)
+		name := sf.Tag.Get("env")
+		if name == "" {
+			if field.Kind() == reflect.Struct && field.Type() != durationType {
+				applyEnv(field, lookup, errs)
+			}
+			continue
+		}
+		raw, ok := lookup(name)
+		if !ok {
+			continue
+		}
+		if err := setField(field, raw); err != nil {
+			*errs = append(*errs, fmt.Errorf("%s: %w", name, err))
+		}
+	}
+}
+
+func setField(field reflect.Value, raw string) error {
+	if field.Type() == durationType {
+		return field.Addr().Interface().(*Duration).UnmarshalText([]byte(raw))
+	}
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(raw)
+	case reflect.Int:
+		n, err := strconv.Atoi(raw)
+		if err != nil {
+			return err
+		}
+		field.SetInt(int64(n))
+	case reflect.Bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return err
+		}
+		field.SetBool(b)
+	case reflect.Slice:
+		var items []string
+		for _, item := range strings.Split(raw, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		field.Set(reflect.ValueOf(items))
+	default:
+		return fmt.Errorf("unsupported field kind %s", field.Kind())
+	}
+	return nil
+}
+
+// SetDefaults fills empty fields with the defaults used by session.New and
+// anp_auth.NewDidWbaVerifier, so a loaded Config shows the effective values.
+func (c *Config) SetDefaults() {
+	if c.Session.Timeout == 0 {
+		c.Session.Timeout = Duration(DefaultTimeout)
+	}
+	if c.Session.MaxConcurrent == 0 {
+		c.Session.MaxConcurrent = DefaultMaxConcurrent
+	}
+	v := &c.Verifier
+	if v.JWTAlgorithm == "" {
+		v.JWTAlgorithm = anp_auth.DefaultJWTAlgorithm
+	}
+	if v.AccessTokenExpiration == 0 {
+		v.AccessTokenExpiration = Duration(anp_auth.DefaultAccessTokenExpiration)
+	}
+	if v.TimestampExpiration == 0 {
+		v.TimestampExpiration = Duration(anp_auth.DefaultTimestampExpiration)
+	}
+	if v.DIDCacheExpiration == 0 {
+		v.DIDCacheExpiration = Duration(anp_auth.DefaultDIDCacheExpiration)
+	}
+	if v.NonceExpiration == 0 {
+		v.NonceExpiration = Duration(anp_auth.DefaultNonceExpiration)
+	}
+}
+
+var jwtAlgorithms = map[string]bool{
+	"RS256": true, "RS384": true, "RS512": true,
+	"PS256": true, "PS384": true, "PS512": true,
+	"ES256": true, "ES384": true, "ES512": true,
+}
+
+// Validate reports every invalid setting at once.
+func (c *Config) Validate() error {
+	var errs []error
+	id := c.Identity
+	if (id.DIDDocument == "") != (id.PrivateKey == "") {
+		errs = append(errs, errors.New("identity: did_document and private_key must be set together"))
+	}
+	if id.Eager && id.DIDDocument == "" {
+		errs = append(errs, errors.New("identity: eager requires did_document and private_key"))
+	}
+	if id.CacheSize < 0 {
+		errs = append(errs, errors.New("identity: cache_size must be non-negative"))
+	}
+	if c.Session.Timeout < 0 {
+		errs = append(errs, errors.New("session: timeout must be positive"))
+	}
+	if c.Session.MaxConcurrent < 0 {
+		errs = append(errs, errors.New("session: max_concurrent must be positive"))
+	}
+
+	v := c.Verifier
+	if v.JWTPrivateKey != "" || v.JWTPublicKey != "" {
+		if v.JWTPrivateKey == "" || v.JWTPublicKey == "" {
+			errs = append(errs, errors.New("verifier: jwt_private_key and jwt_public_key must be set together"))
+		}
+		if !jwtAlgorithms[v.JWTAlgorithm] {
+			errs = append(errs, fmt.Errorf("verifier: unsupported jwt_algorithm %q", v.JWTAlgorithm))
+		}
+	}
+	for _, d := range []struct {
+		name  string
+		value Duration
+	}{
+		{"access_token_expiration", v.AccessTokenExpiration},
+		{"timestamp_expiration", v.TimestampExpiration},
+		{"did_cache_expiration", v.DIDCacheExpiration},
+		{"nonce_expiration", v.NonceExpiration},
+	} {
+		if d.value < 0 {
+			errs = append(errs, fmt.Errorf("verifier: %s must be positive", d.name))
+		}
+	}
+	return errors.Join(errs...)
+}
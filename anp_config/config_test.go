@@ -0,0 +1,223 @@
+package anp_config
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bytedance/sonic"
+	"github.com/openanp/anp-go/anp_auth"
+	"github.com/openanp/anp-go/crypto"
+)
+
+type files struct {
+	dir, didDoc, key, jwtPrivate, jwtPublic string
+	doc                                     *anp_auth.DIDWBADocument
+}
+
+func writeFiles(t *testing.T) *files {
+	t.Helper()
+	dir := t.TempDir()
+	f := &files{
+		dir:        dir,
+		didDoc:     filepath.Join(dir, "did.json"),
+		key:        filepath.Join(dir, "key.pem"),
+		jwtPrivate: filepath.Join(dir, "jwt.pem"),
+		jwtPublic:  filepath.Join(dir, "jwt.pub"),
+	}
+
+	doc, key, err := anp_auth.CreateDIDWBADocument("agent.example.com", nil, nil, nil)
+	if err != nil {
+		t.Fatalf("CreateDIDWBADocument() error = %v", err)
+	}
+	docJSON, _ := sonic.Marshal(doc)
+	// The verifier expects the document as decoded from JSON.
+	f.doc = &anp_auth.DIDWBADocument{}
+	if err := sonic.Unmarshal(docJSON, f.doc); err != nil {
+		t.Fatalf("round-trip DID document: %v", err)
+	}
+	keyPEM, err := crypto.PrivateKeyToPEM(key)
+	if err != nil {
+		t.Fatalf("PrivateKeyToPEM() error = %v", err)
+	}
+
+	jwtKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("GenerateKey() error = %v", err)
+	}
+	pubDER, _ := x509.MarshalPKIXPublicKey(&jwtKey.PublicKey)
+
+	for path, data := range map[string][]byte{
+		f.didDoc:     docJSON,
+		f.key:        keyPEM,
+		f.jwtPrivate: pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(jwtKey)}),
+		f.jwtPublic:  pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: pubDER}),
+	} {
+		if err := os.WriteFile(path, data, 0o600); err != nil {
+			t.Fatalf("WriteFile() error = %v", err)
+		}
+	}
+	return f
+}
+
+func writeConfig(t *testing.T, dir, name, content string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+	return path
+}
+
+func TestLoadYAMLWithEnv(t *testing.T) {
+	f := writeFiles(t)
+	path := writeConfig(t, f.dir, "anp.yaml", `
+identity:
+  did_document: `+f.didDoc+`
+  private_key: `+f.key+`
+  eager: true
+session:
+  timeout: 10s
+  max_concurrent: 2
+verifier:
+  jwt_private_key: `+f.jwtPrivate+`
+  jwt_public_key: `+f.jwtPublic+`
+  allowed_domains: [ignored.example.com]
+`)
+	t.Setenv("ANP_ALLOWED_DOMAINS", "agent.example.com, api.example.com")
+	t.Setenv("ANP_NONCE_EXPIRATION", "2m")
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if got := time.Duration(cfg.Session.Timeout); got != 10*time.Second {
+		t.Errorf("Session.Timeout = %v, want 10s", got)
+	}
+	if got := cfg.Verifier.AllowedDomains; len(got) != 2 || got[1] != "api.example.com" {
+		t.Errorf("AllowedDomains = %v, want the env override", got)
+	}
+	if got := time.Duration(cfg.Verifier.NonceExpiration); got != 2*time.Minute {
+		t.Errorf("NonceExpiration = %v, want 2m", got)
+	}
+	if cfg.Verifier.JWTAlgorithm != anp_auth.DefaultJWTAlgorithm {
+		t.Errorf("JWTAlgorithm = %q, want default", cfg.Verifier.JWTAlgorithm)
+	}
+
+	sessCfg, err := cfg.SessionConfig()
+	if err != nil {
+		t.Fatalf("SessionConfig() error = %v", err)
+	}
+	if sessCfg.MaxConcurrent != 2 || sessCfg.HTTP.Timeout != 10*time.Second {
+		t.Errorf("SessionConfig() = %+v", sessCfg)
+	}
+	header, err := sessCfg.Authenticator.GenerateHeader("https://agent.example.com/rpc")
+	if err != nil {
+		t.Fatalf("GenerateHeader() error = %v", err)
+	}
+
+	verifierCfg, err := cfg.VerifierConfig()
+	if err != nil {
+		t.Fatalf("VerifierConfig() error = %v", err)
+	}
+	verifierCfg.ResolveDIDDocument = func(context.Context, string) (*anp_auth.DIDWBADocument, error) {
+		return f.doc, nil
+	}
+	verifier, err := anp_auth.NewDidWbaVerifier(verifierCfg)
+	if err != nil {
+		t.Fatalf("NewDidWbaVerifier() error = %v", err)
+	}
+	result, err := verifier.VerifyAuthHeader(header[anp_auth.AuthorizationHeader], "agent.example.com")
+	if err != nil {
+		t.Fatalf("VerifyAuthHeader() error = %v", err)
+	}
+	if result["did"] != f.doc.ID {
+		t.Errorf("did = %v, want %s", result["did"], f.doc.ID)
+	}
+	if _, err := verifier.VerifyAuthHeader(header[anp_auth.AuthorizationHeader], "other.example.com"); err == nil {
+		t.Error("VerifyAuthHeader() accepted a domain outside allowed_domains")
+	}
+}
+
+func TestLoadJSON(t *testing.T) {
+	f := writeFiles(t)
+	path := writeConfig(t, f.dir, "anp.json", `{
+		"identity": {"did_document": "`+f.didDoc+`", "private_key": "`+f.key+`"},
+		"session": {"timeout": "1m30s", "debug_dir": "`+filepath.Join(f.dir, "dump")+`"}
+	}`)
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if got := time.Duration(cfg.Session.Timeout); got != 90*time.Second {
+		t.Errorf("Session.Timeout = %v, want 1m30s", got)
+	}
+	if cfg.Session.MaxConcurrent != DefaultMaxConcurrent {
+		t.Errorf("MaxConcurrent = %d, want default", cfg.Session.MaxConcurrent)
+	}
+	sess, err := cfg.NewSession()
+	if err != nil || sess == nil {
+		t.Fatalf("NewSession() = %v, %v", sess, err)
+	}
+	if _, err := os.Stat(filepath.Join(f.dir, "dump")); err != nil {
+		t.Errorf("debug_dir not created: %v", err)
+	}
+	if _, err := cfg.NewVerifier(); !errors.Is(err, ErrNoVerifier) {
+		t.Errorf("NewVerifier() error = %v, want ErrNoVerifier", err)
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	dir := t.TempDir()
+	tests := []struct {
+		name, file, content, want string
+	}{
+		{"unknown field", "a.yaml", "sesion:\n  timeout: 1s\n", "field sesion not found"},
+		{"bad duration", "b.yaml", "session:\n  timeout: soon\n", "soon"},
+		{"half identity", "c.yaml", "identity:\n  did_document: did.json\n", "must be set together"},
+		{"bad algorithm", "d.json", `{"verifier": {"jwt_private_key": "a", "jwt_public_key": "b", "jwt_algorithm": "none"}}`, `unsupported jwt_algorithm "none"`},
+		{"negative", "e.json", `{"session": {"max_concurrent": -1}, "verifier": {"nonce_expiration": "-1s"}}`, "nonce_expiration must be positive"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Load(writeConfig(t, dir, tt.file, tt.content))
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Load() error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadEnv(t *testing.T) {
+	t.Setenv("ANP_MAX_CONCURRENT", "many")
+	if _, err := LoadEnv(); err == nil || !strings.Contains(err.Error(), "ANP_MAX_CONCURRENT") {
+		t.Errorf("LoadEnv() error = %v, want ANP_MAX_CONCURRENT parse error", err)
+	}
+
+	t.Setenv("ANP_MAX_CONCURRENT", "8")
+	t.Setenv("ANP_IDENTITY_EAGER", "true")
+	cfg, err := LoadEnv()
+	if err == nil || !strings.Contains(err.Error(), "eager requires") {
+		t.Fatalf("LoadEnv() = %+v, %v, want eager validation error", cfg, err)
+	}
+
+	t.Setenv("ANP_IDENTITY_EAGER", "false")
+	cfg, err = LoadEnv()
+	if err != nil {
+		t.Fatalf("LoadEnv() error = %v", err)
+	}
+	if cfg.Session.MaxConcurrent != 8 {
+		t.Errorf("MaxConcurrent = %d, want 8", cfg.Session.MaxConcurrent)
+	}
+	if _, err := cfg.NewSession(); !errors.Is(err, ErrNoIdentity) {
+		t.Errorf("NewSession() error = %v, want ErrNoIdentity", err)
+	}
+}
@@ -3,16 +3,24 @@ package anp_crawler
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"io"
+	"log/slog"
 	"maps"
 	"net/http"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/bytedance/sonic"
 	"github.com/openanp/anp-go/anp_auth"
 )
 
+// ErrBodyTooLarge is returned by the default Client when a response body is
+// longer than the WithMaxBodySize limit.
+var ErrBodyTooLarge = errors.New("response body too large")
+
 // Client describes the capabilities required by the crawler to retrieve ANP documents.
 type Client interface {
 	Fetch(ctx context.Context, method, target string, headers map[string]string, body any) (*Response, error)
@@ -26,12 +34,43 @@ type Response struct {
 	Encoding    string
 	Header      http.Header
 	Body        []byte
+
+	// buf is the pooled buffer backing Body, recycled by Release.
+	buf *bytes.Buffer
+}
+
+// maxPooledBody bounds the buffers kept for reuse so one huge document does
+// not pin its memory for the life of the process.
+const maxPooledBody = 4 << 20
+
+var bodyPool = sync.Pool{New: func() any { return new(bytes.Buffer) }}
+
+// Release returns the buffer backing Body for reuse by later fetches and sets
+// Body to nil. It is optional: without it the buffer is garbage collected as
+// usual. After Release, Body and anything aliasing it must not be used.
+func (r *Response) Release() {
+	if r == nil || r.buf == nil {
+		return
+	}
+	if r.buf.Cap() <= maxPooledBody {
+		r.buf.Reset()
+		bodyPool.Put(r.buf)
+	}
+	r.buf, r.Body = nil, nil
 }
 
 // httpClient is the default Client implementation that performs DID-authenticated HTTP requests.
 type httpClient struct {
 	httpClient    *http.Client
 	authenticator *anp_auth.Authenticator
+	logger        *slog.Logger
+	metrics       MetricsRecorder
+	limiter       *hostLimiter
+	maxBodySize   int64
+	timeout       time.Duration
+	cache         ResponseCache
+	proxy         ProxyFunc
+	redirect      RedirectPolicy
 }
 
 // ClientOption customises the behaviour of httpClient.
@@ -46,6 +85,37 @@ func WithHTTPClient(h *http.Client) ClientOption {
 	}
 }
 
+// WithLogger sets the logger used by the client. Without it the package
+// fallback logger is used.
+func WithLogger(l *slog.Logger) ClientOption {
+	return func(c *httpClient) { c.logger = l }
+}
+
+// WithMaxBodySize makes Fetch fail with ErrBodyTooLarge instead of reading
+// response bodies longer than n bytes. Zero or negative means no limit.
+func WithMaxBodySize(n int64) ClientOption {
+	return func(c *httpClient) { c.maxBodySize = n }
+}
+
+// WithRequestTimeout bounds each Fetch, including reading the body and any
+// authentication retry, to d. ContextWithRequestTimeout overrides it for
+// individual requests. The http.Client timeout still applies on top, except
+// to requests ContextWithRequestTimeout gives a positive timeout.
+func WithRequestTimeout(d time.Duration) ClientOption {
+	return func(c *httpClient) { c.timeout = d }
+}
+
+type requestTimeoutKey struct{}
+
+// ContextWithRequestTimeout returns a context that makes the default Client
+// use d instead of its WithRequestTimeout setting for requests made with it,
+// e.g. to give one slow tool call more time than document fetches. A
+// positive d also replaces the http.Client timeout, so it alone bounds those
+// requests; zero or negative disables the WithRequestTimeout setting only.
+func ContextWithRequestTimeout(ctx context.Context, d time.Duration) context.Context {
+	return context.WithValue(ctx, requestTimeoutKey{}, d)
+}
+
 // NewClient constructs a DID-authenticated HTTP client.
 func NewClient(authenticator *anp_auth.Authenticator, opts ...ClientOption) Client {
 	c := &httpClient{
@@ -58,6 +128,15 @@ func NewClient(authenticator *anp_auth.Authenticator, opts ...ClientOption) Clie
 	for _, opt := range opts {
 		opt(c)
 	}
+	if c.proxy != nil {
+		proxied, err := ProxyClient(c.httpClient, c.proxy)
+		if err != nil {
+			loggerOr(c.logger).Error("proxy not applied", "error", err)
+		} else {
+			c.httpClient = proxied
+		}
+	}
+	c.httpClient = c.redirectClient(c.httpClient)
 
 	return c
 }
@@ -67,31 +146,123 @@ func (c *httpClient) Fetch(ctx context.Context, method, target string, headers m
 		method = http.MethodGet
 	}
 
-	reqHeaders := make(map[string]string)
+	hc := c.httpClient
+	timeout := c.timeout
+	if d, ok := ctx.Value(requestTimeoutKey{}).(time.Duration); ok {
+		timeout = d
+		if d > 0 && hc.Timeout != 0 {
+			override := *hc
+			override.Timeout = 0
+			hc = &override
+		}
+	}
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	reqHeaders, newBody, err := requestParts(headers, body)
+	if err != nil {
+		return nil, err
+	}
+	cached := c.lookupCache(ctx, method, target, reqHeaders)
+
+	resp, err := c.send(ctx, hc, method, target, reqHeaders, newBody)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if cached != nil && resp.StatusCode == http.StatusNotModified {
+		return cachedResponse(target, cached), nil
+	}
+
+	var respBody io.Reader = resp.Body
+	encoding := resp.Header.Get("Content-Encoding")
+	if method != http.MethodHead && resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusNotModified {
+		// Decode before limiting so the limit applies to what callers get.
+		decoded, remaining, err := decodeBody(resp, respBody)
+		if err != nil {
+			return nil, err
+		}
+		defer decoded.Close()
+		respBody, encoding = decoded, remaining
+	}
+	if c.maxBodySize > 0 {
+		if resp.ContentLength > c.maxBodySize {
+			return nil, fmt.Errorf("%w: %s declares %d bytes, limit is %d", ErrBodyTooLarge, target, resp.ContentLength, c.maxBodySize)
+		}
+		// One byte past the limit tells an oversized body from one that fits exactly.
+		respBody = io.LimitReader(respBody, c.maxBodySize+1)
+	}
+
+	// Read into a pooled buffer sized from Content-Length when known, so a
+	// crawler that releases its responses allocates no new body memory.
+	buf := bodyPool.Get().(*bytes.Buffer)
+	if resp.ContentLength > 0 && resp.ContentLength <= maxPooledBody {
+		buf.Grow(int(resp.ContentLength))
+	}
+	if _, err := buf.ReadFrom(respBody); err != nil {
+		buf.Reset()
+		bodyPool.Put(buf)
+		return nil, fmt.Errorf("read response body: %w", err)
+	}
+	if c.maxBodySize > 0 && int64(buf.Len()) > c.maxBodySize {
+		buf.Reset()
+		bodyPool.Put(buf)
+		return nil, fmt.Errorf("%w: %s exceeds %d bytes", ErrBodyTooLarge, target, c.maxBodySize)
+	}
+	if c.cache != nil && method == http.MethodGet && resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		c.storeCache(ctx, target, resp, buf.Bytes())
+	}
+
+	return &Response{
+		StatusCode:  resp.StatusCode,
+		URL:         target,
+		ContentType: resp.Header.Get("Content-Type"),
+		Encoding:    encoding,
+		Header:      resp.Header.Clone(),
+		Body:        buf.Bytes(),
+		buf:         buf,
+	}, nil
+}
+
+// requestParts copies headers and encodes body, defaulting Content-Type to
+// JSON for encoded bodies. newBody returns a fresh reader for every attempt
+// so a retried request resends the whole body.
+func requestParts(headers map[string]string, body any) (reqHeaders map[string]string, newBody func() io.Reader, err error) {
+	reqHeaders = make(map[string]string)
 	if headers != nil {
 		maps.Copy(reqHeaders, headers)
 	}
 
-	var bodyReader io.Reader
+	var payload []byte
 	switch v := body.(type) {
 	case nil:
+		return reqHeaders, func() io.Reader { return nil }, nil
 	case []byte:
-		bodyReader = bytes.NewReader(v)
-		if _, ok := reqHeaders["Content-Type"]; !ok {
-			reqHeaders["Content-Type"] = "application/json"
-		}
+		payload = v
 	case io.Reader:
-		bodyReader = v
+		return reqHeaders, func() io.Reader { return v }, nil
 	default:
-		jsonBody, err := sonic.Marshal(v)
-		if err != nil {
-			return nil, fmt.Errorf("marshal request body: %w", err)
-		}
-		bodyReader = bytes.NewReader(jsonBody)
-		if _, ok := reqHeaders["Content-Type"]; !ok {
-			reqHeaders["Content-Type"] = "application/json"
+		if payload, err = sonic.Marshal(v); err != nil {
+			return nil, nil, fmt.Errorf("marshal request body: %w", err)
 		}
 	}
+	if _, ok := reqHeaders["Content-Type"]; !ok {
+		reqHeaders["Content-Type"] = "application/json"
+	}
+	return reqHeaders, func() io.Reader { return bytes.NewReader(payload) }, nil
+}
+
+// send performs a DIDWba-authenticated request with hc, retrying once with a
+// freshly signed header when the server answers 401. The caller closes the
+// response body.
+func (c *httpClient) send(ctx context.Context, hc *http.Client, method, target string, reqHeaders map[string]string, newBody func() io.Reader) (*http.Response, error) {
+	if !hasHeader(reqHeaders, "Accept-Encoding") {
+		reqHeaders["Accept-Encoding"] = acceptEncoding
+	}
 
 	// Get auth header from the new authenticator
 	authHeader, err := c.authenticator.GenerateHeader(target)
@@ -101,7 +272,7 @@ func (c *httpClient) Fetch(ctx context.Context, method, target string, headers m
 	maps.Copy(reqHeaders, authHeader)
 
 	performRequest := func() (*http.Response, error) {
-		req, err := http.NewRequestWithContext(ctx, method, target, bodyReader)
+		req, err := http.NewRequestWithContext(ctx, method, target, newBody())
 		if err != nil {
 			return nil, fmt.Errorf("create request: %w", err)
 		}
@@ -110,7 +281,23 @@ func (c *httpClient) Fetch(ctx context.Context, method, target string, headers m
 			req.Header.Set(k, v)
 		}
 
-		return c.httpClient.Do(req)
+		if c.limiter != nil {
+			if err := c.limiter.wait(ctx, req.URL.Hostname()); err != nil {
+				return nil, fmt.Errorf("wait for rate limit: %w", err)
+			}
+		}
+
+		if c.metrics == nil {
+			return hc.Do(req)
+		}
+		start := time.Now()
+		resp, err := hc.Do(req)
+		code := 0
+		if err == nil {
+			code = resp.StatusCode
+		}
+		c.metrics.ObserveRequest(req.URL.Hostname(), method, code, time.Since(start))
+		return resp, err
 	}
 
 	resp, err := performRequest()
@@ -121,7 +308,7 @@ func (c *httpClient) Fetch(ctx context.Context, method, target string, headers m
 	// Handle unauthorized status: clear token and retry
 	if resp.StatusCode == http.StatusUnauthorized {
 		resp.Body.Close()
-		logger.Debug("authentication failed, refreshing token", "url", target)
+		loggerOr(c.logger).Debug("authentication failed, refreshing token", "url", target)
 		c.authenticator.ClearToken(target)
 
 		refreshedAuthHeader, err := c.authenticator.GenerateHeaderForce(target)
@@ -130,6 +317,9 @@ func (c *httpClient) Fetch(ctx context.Context, method, target string, headers m
 		}
 		// Update the headers map for the retry
 		maps.Copy(reqHeaders, refreshedAuthHeader)
+		if c.metrics != nil {
+			c.metrics.ObserveRetry(hostname(target), RetryReasonUnauthorized)
+		}
 
 		// Retry the request
 		resp, err = performRequest()
@@ -137,24 +327,26 @@ func (c *httpClient) Fetch(ctx context.Context, method, target string, headers m
 			return nil, fmt.Errorf("retry request: %w", err)
 		}
 	}
-	defer resp.Body.Close()
 
 	// On success, check for a new JWT in the response
 	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
-		c.authenticator.UpdateFromResponse(target, resp.Header)
+		// Tokens belong to the host that issued them, which differs from
+		// target after a redirect.
+		issuer := target
+		if resp.Request != nil {
+			issuer = resp.Request.URL.String()
+		}
+		c.authenticator.UpdateFromResponse(issuer, resp.Header)
 	}
+	return resp, nil
+}
 
-	bodyBytes, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("read response body: %w", err)
+// hasHeader reports whether headers sets name, in any letter case.
+func hasHeader(headers map[string]string, name string) bool {
+	for k := range headers {
+		if strings.EqualFold(k, name) {
+			return true
+		}
 	}
-
-	return &Response{
-		StatusCode:  resp.StatusCode,
-		URL:         target,
-		ContentType: resp.Header.Get("Content-Type"),
-		Encoding:    resp.Header.Get("Content-Encoding"),
-		Header:      resp.Header.Clone(),
-		Body:        bodyBytes,
-	}, nil
+	return false
 }
@@ -0,0 +1,226 @@
+package anp_crawler
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/andybalholm/brotli"
+	"github.com/openanp/anp-go/anptest"
+)
+
+func TestClient_MaxBodySize(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n, _ := strconv.Atoi(r.URL.Query().Get("n"))
+		if r.URL.Query().Has("chunked") {
+			w.(http.Flusher).Flush() // no Content-Length
+		}
+		w.Write([]byte(strings.Repeat("x", n)))
+	}))
+	defer srv.Close()
+	client := NewClient(anptest.NewIdentity(t, "client.example.com").Authenticator, WithMaxBodySize(16))
+
+	tests := []struct {
+		name    string
+		query   string
+		wantErr bool
+	}{
+		{"fits exactly", "n=16", false},
+		{"declared too large", "n=17", true},
+		{"streamed too large", "n=4096&chunked", true},
+		{"streamed within limit", "n=8&chunked", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := client.Fetch(context.Background(), http.MethodGet, srv.URL+"/?"+tt.query, nil, nil)
+			if tt.wantErr {
+				if !errors.Is(err, ErrBodyTooLarge) {
+					t.Fatalf("Fetch() error = %v, want ErrBodyTooLarge", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Fetch() error = %v", err)
+			}
+			if len(resp.Body) > 16 {
+				t.Errorf("body length = %d, want <= 16", len(resp.Body))
+			}
+		})
+	}
+}
+
+func TestClient_RequestTimeout(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-time.After(100 * time.Millisecond):
+		case <-r.Context().Done():
+		}
+	}))
+	defer srv.Close()
+	client := NewClient(anptest.NewIdentity(t, "client.example.com").Authenticator, WithRequestTimeout(20*time.Millisecond))
+
+	if _, err := client.Fetch(context.Background(), http.MethodGet, srv.URL, nil, nil); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Fetch() error = %v, want context.DeadlineExceeded", err)
+	}
+	ctx := ContextWithRequestTimeout(context.Background(), time.Second)
+	if _, err := client.Fetch(ctx, http.MethodGet, srv.URL, nil, nil); err != nil {
+		t.Errorf("Fetch(longer override) error = %v", err)
+	}
+
+	// A positive override outlasts the http.Client timeout too.
+	short := NewClient(anptest.NewIdentity(t, "client.example.com").Authenticator, WithHTTPClient(&http.Client{Timeout: 20 * time.Millisecond}))
+	if _, err := short.Fetch(context.Background(), http.MethodGet, srv.URL, nil, nil); err == nil {
+		t.Error("Fetch() error = nil, want http.Client timeout")
+	}
+	if _, err := short.Fetch(ctx, http.MethodGet, srv.URL, nil, nil); err != nil {
+		t.Errorf("Fetch(override past http.Client timeout) error = %v", err)
+	}
+}
+
+func TestClient_ResponseCache(t *testing.T) {
+	var full, notModified int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/ad.json":
+			w.Header().Set("ETag", `"v1"`)
+			if r.Header.Get("If-None-Match") == `"v1"` {
+				notModified++
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+		case "/dated.json":
+			w.Header().Set("Last-Modified", "Mon, 02 Jan 2006 15:04:05 GMT")
+			if r.Header.Get("If-Modified-Since") != "" {
+				notModified++
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+		case "/private.json":
+			w.Header().Set("ETag", `"p"`)
+			w.Header().Set("Cache-Control", "no-store")
+		}
+		full++
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"path":"` + r.URL.Path + `"}`))
+	}))
+	defer srv.Close()
+	cache := NewMemoryCache(CacheLimits{})
+	client := NewClient(anptest.NewIdentity(t, "client.example.com").Authenticator, WithResponseCache(cache))
+	ctx := context.Background()
+
+	for _, path := range []string{"/ad.json", "/dated.json", "/private.json", "/plain.json"} {
+		full, notModified = 0, 0
+		for range 2 {
+			resp, err := client.Fetch(ctx, http.MethodGet, srv.URL+path, nil, nil)
+			if err != nil {
+				t.Fatalf("Fetch(%s) error = %v", path, err)
+			}
+			if resp.StatusCode != http.StatusOK || string(resp.Body) != `{"path":"`+path+`"}` || resp.ContentType != "application/json" {
+				t.Errorf("Fetch(%s) = %d %q %q", path, resp.StatusCode, resp.ContentType, resp.Body)
+			}
+		}
+		wantFull, wantNotModified := 1, 1
+		if path == "/private.json" || path == "/plain.json" {
+			wantFull, wantNotModified = 2, 0
+		}
+		if full != wantFull || notModified != wantNotModified {
+			t.Errorf("%s: full responses = %d, 304s = %d, want %d and %d", path, full, notModified, wantFull, wantNotModified)
+		}
+	}
+
+	// POST requests bypass the cache.
+	full = 0
+	for range 2 {
+		if _, err := client.Fetch(ctx, http.MethodPost, srv.URL+"/ad.json", nil, map[string]any{}); err != nil {
+			t.Fatalf("Fetch(POST) error = %v", err)
+		}
+	}
+	if full != 2 {
+		t.Errorf("POST full responses = %d, want 2", full)
+	}
+}
+
+func TestClient_Decompression(t *testing.T) {
+	const doc = `{"name":"compressed agent"}`
+	encode := func(t *testing.T, coding string) []byte {
+		t.Helper()
+		var buf bytes.Buffer
+		var w io.WriteCloser
+		switch coding {
+		case "gzip":
+			w = gzip.NewWriter(&buf)
+		case "deflate":
+			w = zlib.NewWriter(&buf)
+		case "raw-deflate":
+			w, _ = flate.NewWriter(&buf, flate.DefaultCompression)
+		case "br":
+			w = brotli.NewWriter(&buf)
+		}
+		w.Write([]byte(doc))
+		w.Close()
+		return buf.Bytes()
+	}
+
+	var acceptEncoding string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		acceptEncoding = r.Header.Get("Accept-Encoding")
+		coding := r.URL.Query().Get("coding")
+		body := []byte(doc)
+		switch coding {
+		case "", "identity":
+		case "x-custom":
+			w.Header().Set("Content-Encoding", coding)
+		case "corrupt":
+			w.Header().Set("Content-Encoding", "gzip")
+			body = []byte("not gzip")
+		default:
+			body = encode(t, coding)
+			if coding == "raw-deflate" {
+				coding = "deflate"
+			}
+			w.Header().Set("Content-Encoding", coding)
+		}
+		w.Write(body)
+	}))
+	defer srv.Close()
+	client := NewClient(anptest.NewIdentity(t, "client.example.com").Authenticator)
+
+	for _, coding := range []string{"identity", "gzip", "deflate", "raw-deflate", "br"} {
+		t.Run(coding, func(t *testing.T) {
+			resp, err := client.Fetch(context.Background(), http.MethodGet, srv.URL+"/?coding="+coding, nil, nil)
+			if err != nil {
+				t.Fatalf("Fetch() error = %v", err)
+			}
+			if string(resp.Body) != doc || resp.Encoding != "" || resp.Header.Get("Content-Encoding") != "" {
+				t.Errorf("Fetch() body = %q, encoding %q", resp.Body, resp.Encoding)
+			}
+			if acceptEncoding != "gzip, deflate, br" {
+				t.Errorf("Accept-Encoding = %q", acceptEncoding)
+			}
+		})
+	}
+
+	resp, err := client.Fetch(context.Background(), http.MethodGet, srv.URL+"/?coding=x-custom", nil, nil)
+	if err != nil || resp.Encoding != "x-custom" || string(resp.Body) != doc {
+		t.Errorf("Fetch(unknown coding) = %v, %v, want body passed through", resp, err)
+	}
+	if _, err := client.Fetch(context.Background(), http.MethodGet, srv.URL+"/?coding=corrupt", nil, nil); err == nil {
+		t.Error("Fetch(corrupt gzip) error = nil, want error")
+	}
+
+	// The size limit applies to the decoded body, not the compressed one.
+	limited := NewClient(anptest.NewIdentity(t, "client.example.com").Authenticator, WithMaxBodySize(int64(len(doc)-1)))
+	if _, err := limited.Fetch(context.Background(), http.MethodGet, srv.URL+"/?coding=gzip", nil, nil); !errors.Is(err, ErrBodyTooLarge) {
+		t.Errorf("Fetch(gzip over limit) error = %v, want ErrBodyTooLarge", err)
+	}
+}
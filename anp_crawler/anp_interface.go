@@ -3,9 +3,11 @@ package anp_crawler
 import (
 	"context"
 	"fmt"
+	"log/slog"
 	"net/http"
-	"regexp"
 	"strings"
+	"unicode"
+	"unicode/utf8"
 
 	"github.com/bytedance/sonic"
 	"github.com/google/uuid"
@@ -18,8 +20,21 @@ type ANPInterface struct {
 	Client   Client
 	Method   string
 	Servers  []Server
+	// Logger receives diagnostics; nil uses the package fallback.
+	Logger *slog.Logger
+	// SkipValidation sends arguments without checking them against the
+	// entry's params schema first.
+	SkipValidation bool
+	// Intercept, if set, runs every Execute and ExecuteStream call in place
+	// of the request: it may inspect or rewrite arguments, call next to
+	// send the request, and inspect or replace the outcome, or return
+	// without calling next to block the call.
+	Intercept func(ctx context.Context, iface *ANPInterface, arguments map[string]any, next ExecuteFunc) (map[string]any, error)
 }
 
+// ExecuteFunc sends a call to an interface with the given arguments.
+type ExecuteFunc func(ctx context.Context, arguments map[string]any) (map[string]any, error)
+
 // NewANPInterface creates a new ANPInterface wrapper around an InterfaceEntry.
 func NewANPInterface(toolName string, entry InterfaceEntry, client Client) *ANPInterface {
 	servers := entry.Servers
@@ -35,43 +50,26 @@ func NewANPInterface(toolName string, entry InterfaceEntry, client Client) *ANPI
 	}
 }
 
-// Execute executes the interface with the given arguments.
+// Execute executes the interface with the given arguments. JSON-RPC methods
+// receive them as params of a JSON-RPC request; OpenAPI operations are called
+// as REST requests, see InterfaceEntry.ParamLocations.
 func (i *ANPInterface) Execute(ctx context.Context, arguments map[string]any) (map[string]any, error) {
-	if len(i.Servers) == 0 {
-		return nil, fmt.Errorf("no servers defined for tool: %s", i.ToolName)
-	}
-
-	serverURL := i.Servers[0].URL
-	if serverURL == "" {
-		return nil, fmt.Errorf("no server URL found for tool: %s", i.ToolName)
+	if i.Intercept != nil {
+		return i.Intercept(ctx, i, arguments, i.execute)
 	}
+	return i.execute(ctx, arguments)
+}
 
-	if strings.TrimSpace(i.Method) == "" {
-		return nil, fmt.Errorf("no method name found for tool: %s", i.ToolName)
+func (i *ANPInterface) execute(ctx context.Context, arguments map[string]any) (map[string]any, error) {
+	if i.Entry.Type == "openapi_operation" {
+		return i.executeREST(ctx, arguments)
 	}
-
-	processedArgs := make(map[string]any)
-	for key, value := range arguments {
-		if strVal, ok := value.(string); ok {
-			if (strings.HasPrefix(strVal, "{") && strings.HasSuffix(strVal, "}")) || (strings.HasPrefix(strVal, "[") && strings.HasSuffix(strVal, "]")) {
-				var jsonData any
-				if err := sonic.Unmarshal([]byte(strVal), &jsonData); err == nil {
-					processedArgs[key] = jsonData
-					continue
-				}
-			}
-		}
-		processedArgs[key] = value
-	}
-
-	rpcRequest := map[string]any{
-		"jsonrpc": "2.0",
-		"id":      uuid.NewString(),
-		"method":  i.Method,
-		"params":  processedArgs,
+	serverURL, rpcRequest, err := i.request(arguments)
+	if err != nil {
+		return nil, err
 	}
 
-	logger.Debug("executing tool call", "tool", i.ToolName, "method", i.Method, "url", serverURL)
+	loggerOr(i.Logger).Debug("executing tool call", "tool", i.ToolName, "method", i.Method, "url", serverURL)
 
 	resp, err := i.Client.Fetch(ctx, "POST", serverURL, map[string]string{"Content-Type": "application/json"}, rpcRequest)
 	if err != nil {
@@ -93,8 +91,137 @@ func (i *ANPInterface) Execute(ctx context.Context, arguments map[string]any) (m
 	return rpcResponse, nil
 }
 
+// ExecuteStream calls the interface like Execute but accepts a streamed reply:
+// every message the server sends before the JSON-RPC response to the call,
+// such as progress notifications or partial results, is passed to fn as it
+// arrives, and the response itself is returned. A reply that is a single JSON
+// body yields no chunks, as do OpenAPI operations and Clients that are not
+// StreamClients, which fall back to Execute. An error from fn stops the call and is returned.
+func (i *ANPInterface) ExecuteStream(ctx context.Context, arguments map[string]any, fn func(chunk map[string]any) error) (map[string]any, error) {
+	next := func(ctx context.Context, arguments map[string]any) (map[string]any, error) {
+		return i.executeStream(ctx, arguments, fn)
+	}
+	if i.Intercept != nil {
+		return i.Intercept(ctx, i, arguments, next)
+	}
+	return next(ctx, arguments)
+}
+
+func (i *ANPInterface) executeStream(ctx context.Context, arguments map[string]any, fn func(chunk map[string]any) error) (map[string]any, error) {
+	client, ok := i.Client.(StreamClient)
+	if !ok || i.Entry.Type == "openapi_operation" {
+		return i.execute(ctx, arguments)
+	}
+	serverURL, rpcRequest, err := i.request(arguments)
+	if err != nil {
+		return nil, err
+	}
+
+	loggerOr(i.Logger).Debug("executing streaming tool call", "tool", i.ToolName, "method", i.Method, "url", serverURL)
+
+	headers := map[string]string{"Content-Type": "application/json", "Accept": "application/json, " + EventStreamType}
+	stream, err := client.FetchStream(ctx, "POST", serverURL, headers, rpcRequest)
+	if err != nil {
+		return nil, fmt.Errorf("HTTP request failed for tool %s to %s: %w", i.ToolName, serverURL, err)
+	}
+	defer stream.Close()
+	if stream.StatusCode < 200 || stream.StatusCode >= 300 {
+		return nil, fmt.Errorf("HTTP %d: %s", stream.StatusCode, http.StatusText(stream.StatusCode))
+	}
+
+	for ev, err := range stream.Events() {
+		if err != nil {
+			return nil, fmt.Errorf("read stream for tool %s from %s: %w", i.ToolName, serverURL, err)
+		}
+		var msg map[string]any
+		if err := sonic.UnmarshalString(ev.Data, &msg); err != nil {
+			return nil, fmt.Errorf("failed to parse streamed message for tool %s from %s: %w", i.ToolName, serverURL, err)
+		}
+		if isResponseTo(msg, rpcRequest["id"]) {
+			if errVal, ok := msg["error"]; ok {
+				return nil, fmt.Errorf("JSON-RPC error for tool %s from %s: %v", i.ToolName, serverURL, errVal)
+			}
+			return msg, nil
+		}
+		if fn != nil {
+			if err := fn(msg); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return nil, fmt.Errorf("stream for tool %s from %s ended without a response", i.ToolName, serverURL)
+}
+
+// isResponseTo reports whether msg is the JSON-RPC response to request id.
+func isResponseTo(msg map[string]any, id any) bool {
+	if msg["id"] != id {
+		return false
+	}
+	_, hasResult := msg["result"]
+	_, hasError := msg["error"]
+	return hasResult || hasError
+}
+
+// request validates arguments and builds the JSON-RPC request for a call,
+// returning the server URL it is sent to.
+func (i *ANPInterface) request(arguments map[string]any) (string, map[string]any, error) {
+	serverURL, processedArgs, err := i.prepare(arguments)
+	if err != nil {
+		return "", nil, err
+	}
+
+	if strings.TrimSpace(i.Method) == "" {
+		return "", nil, fmt.Errorf("no method name found for tool: %s", i.ToolName)
+	}
+
+	return serverURL, map[string]any{
+		"jsonrpc": "2.0",
+		"id":      uuid.NewString(),
+		"method":  i.Method,
+		"params":  processedArgs,
+	}, nil
+}
+
+// prepare returns the server URL for a call and its arguments, with JSON
+// strings decoded and, unless SkipValidation is set, checked against the
+// params schema.
+func (i *ANPInterface) prepare(arguments map[string]any) (string, map[string]any, error) {
+	if len(i.Servers) == 0 {
+		return "", nil, fmt.Errorf("no servers defined for tool: %s", i.ToolName)
+	}
+
+	serverURL := i.Servers[0].URL
+	if serverURL == "" {
+		return "", nil, fmt.Errorf("no server URL found for tool: %s", i.ToolName)
+	}
+
+	processedArgs := make(map[string]any)
+	for key, value := range arguments {
+		if strVal, ok := value.(string); ok {
+			if (strings.HasPrefix(strVal, "{") && strings.HasSuffix(strVal, "}")) || (strings.HasPrefix(strVal, "[") && strings.HasSuffix(strVal, "]")) {
+				var jsonData any
+				if err := sonic.Unmarshal([]byte(strVal), &jsonData); err == nil {
+					processedArgs[key] = jsonData
+					continue
+				}
+			}
+		}
+		processedArgs[key] = value
+	}
+
+	if !i.SkipValidation {
+		if err := ValidateArguments(i.Entry, processedArgs); err != nil {
+			return "", nil, err
+		}
+	}
+	return serverURL, processedArgs, nil
+}
+
 // ANPInterfaceConverter converts interface entries to generic tool definitions.
-type ANPInterfaceConverter struct{}
+type ANPInterfaceConverter struct {
+	// Logger receives diagnostics; nil uses the package fallback.
+	Logger *slog.Logger
+}
 
 // NewANPInterfaceConverter creates a new ANPInterfaceConverter.
 func NewANPInterfaceConverter() *ANPInterfaceConverter {
@@ -128,8 +255,12 @@ func (c *ANPInterfaceConverter) ConvertToANPTool(entry InterfaceEntry) (*ANPTool
 		return c.convertOpenRPCMethod(entry)
 	case "jsonrpc_method":
 		return c.convertJSONRPCMethod(entry)
+	case "mcp_tool":
+		return c.convertMCPTool(entry)
+	case "openapi_operation":
+		return c.convertOpenAPIOperation(entry)
 	default:
-		logger.Debug("skipping unsupported interface type", "type", entry.Type)
+		loggerOr(c.Logger).Debug("skipping unsupported interface type", "type", entry.Type)
 		return nil, nil
 	}
 }
@@ -196,6 +327,27 @@ func (c *ANPInterfaceConverter) convertJSONRPCMethod(entry InterfaceEntry) (*ANP
 	}, nil
 }
 
+// convertMCPTool converts an MCP tool whose Params hold its JSON Schema inputSchema.
+func (c *ANPInterfaceConverter) convertMCPTool(entry InterfaceEntry) (*ANPTool, error) {
+	schema := map[string]any{}
+	if len(entry.Params) > 0 {
+		if err := sonic.Unmarshal(entry.Params, &schema); err != nil {
+			return nil, fmt.Errorf("failed to parse mcp input schema for tool %s: %w", entry.MethodName, err)
+		}
+	}
+	return c.buildANPTool(entry, convertSchemaToParameters(schema)), nil
+}
+
+// convertOpenAPIOperation converts an OpenAPI operation whose Params hold the
+// object schema of its parameters and request body.
+func (c *ANPInterfaceConverter) convertOpenAPIOperation(entry InterfaceEntry) (*ANPTool, error) {
+	var schema map[string]any
+	if err := sonic.Unmarshal(entry.Params, &schema); err != nil {
+		return nil, fmt.Errorf("failed to parse openapi params for operation %s: %w", entry.MethodName, err)
+	}
+	return c.buildANPTool(entry, convertSchemaToParameters(schema)), nil
+}
+
 func (c *ANPInterfaceConverter) buildANPTool(entry InterfaceEntry, params Parameters) *ANPTool {
 	description := entry.Description
 	if description == "" {
@@ -243,8 +395,13 @@ func sanitizeFunctionName(name string) string {
 	if strings.TrimSpace(name) == "" {
 		return "unknown_function"
 	}
-	re := regexp.MustCompile(`[^a-zA-Z0-9_]`)
-	sanitized := re.ReplaceAllString(name, "_")
+	// strings.Map returns name itself, without allocating, when it is valid.
+	sanitized := strings.Map(func(r rune) rune {
+		if r == '_' || r < utf8.RuneSelf && (unicode.IsLetter(r) || unicode.IsDigit(r)) {
+			return r
+		}
+		return '_'
+	}, name)
 	if len(sanitized) > 64 {
 		sanitized = sanitized[:64]
 	}
@@ -3,6 +3,7 @@ package anp_crawler
 import (
 	"context"
 	"fmt"
+	"log/slog"
 	"strings"
 
 	"github.com/bytedance/sonic"
@@ -17,6 +18,12 @@ type Parser interface {
 type ParseResult struct {
 	Interfaces []InterfaceEntry
 	Agents     []AgentEntry
+	// Warnings lists problems found in the document when the parser
+	// validates; see JSONParser.Validate.
+	Warnings []Warning
+	// Pagination is set when the document is one page of a paged agent
+	// directory that has further pages.
+	Pagination *Pagination
 }
 
 // InterfaceEntry captures the metadata for a single interface definition.
@@ -34,6 +41,17 @@ type InterfaceEntry struct {
 	ParentServers []Server `json:"parent_servers,omitempty"`
 	Source        string   `json:"source"`
 	URL           string   `json:"url,omitempty"`
+	// Streaming marks interfaces declared with a streaming protocol ("sse"
+	// or "streamable"), whose calls answer with incremental results; see
+	// ANPInterface.ExecuteStream.
+	Streaming bool `json:"streaming,omitempty"`
+	// HTTPMethod and Path locate an OpenAPI operation ("openapi_operation"
+	// entries); Path may hold {name} templates filled from the arguments.
+	HTTPMethod string `json:"http_method,omitempty"`
+	Path       string `json:"path,omitempty"`
+	// ParamLocations maps each argument of an OpenAPI operation to where it
+	// is sent: "path", "query", "header" or "body".
+	ParamLocations map[string]string `json:"param_locations,omitempty"`
 }
 
 // AgentEntry describes an agent in an agent directory document.
@@ -53,43 +71,95 @@ type Server struct {
 	Description string `json:"description"`
 }
 
-// JSONParser is the default parser that understands JSON Agent Description documents.
-type JSONParser struct{}
+// parseAPI copies decoded strings out of the document so parse results never
+// alias the response buffer, which may be released after parsing.
+var parseAPI = sonic.Config{CopyString: true}.Froze()
+
+// JSONParser is the default parser that understands JSON Agent Description
+// documents. OpenRPC and other documents published as YAML, recognised by
+// content type, URL extension or content, are converted to JSON first.
+type JSONParser struct {
+	// Logger receives diagnostics; nil uses the package fallback.
+	Logger *slog.Logger
+	// JSONLD makes the parser apply the @context of JSON-LD documents, so
+	// terms written with another prefix, an alias or a full IRI (e.g.
+	// "ad:interfaces") are read as the Agent Description terms they stand
+	// for. Remote contexts are not fetched.
+	JSONLD bool
+	// Validate checks agent descriptions and directories against the ANP
+	// schemas and reports violations, unknown interface protocols and
+	// unrecognised documents in ParseResult.Warnings. Extraction is
+	// best-effort either way; warnings never fail Parse.
+	Validate bool
+}
 
 // NewJSONParser constructs a JSONParser.
 func NewJSONParser() Parser {
 	return &JSONParser{}
 }
 
+func (p *JSONParser) log() *slog.Logger { return loggerOr(p.Logger) }
+
 // Parse implements the Parser interface.
 func (p *JSONParser) Parse(_ context.Context, content []byte, contentType, sourceURL string) (*ParseResult, error) {
-	if !strings.Contains(strings.ToLower(contentType), "json") && contentType != "" {
-		logger.Debug("content type not recognised as JSON", "content_type", contentType)
+	if isYAML(content, contentType, sourceURL) {
+		converted, err := yamlToJSON(content)
+		if err != nil {
+			return nil, fmt.Errorf("parse YAML content from %s: %w", sourceURL, err)
+		}
+		content = converted
+	} else if !strings.Contains(strings.ToLower(contentType), "json") && contentType != "" {
+		p.log().Debug("content type not recognised as JSON", "content_type", contentType)
 	}
 
 	var data map[string]any
-	if err := sonic.Unmarshal(content, &data); err != nil {
+	if err := parseAPI.Unmarshal(content, &data); err != nil {
 		return nil, fmt.Errorf("parse JSON content from %s: %w", sourceURL, err)
 	}
 
+	if _, ok := data["@context"]; ok && p.JSONLD {
+		data = compactJSONLD(data)
+	}
+
+	result, err := p.extract(data, sourceURL)
+	if err != nil {
+		return nil, err
+	}
+	if p.Validate {
+		result.Warnings = diagnose(data)
+	}
+	return result, nil
+}
+
+// extract collects the interfaces and agents of a decoded document.
+func (p *JSONParser) extract(data map[string]any, sourceURL string) (*ParseResult, error) {
 	result := &ParseResult{}
 
 	if isOpenRPC(data) {
-		result.Interfaces = append(result.Interfaces, extractOpenRPCInterfaces(data)...)
+		result.Interfaces = append(result.Interfaces, p.extractOpenRPCInterfaces(data)...)
 		return result, nil
 	}
 
-	if agents := extractAgentList(data); len(agents) > 0 {
+	if isOpenAPI(data) {
+		result.Interfaces = append(result.Interfaces, p.extractOpenAPIOperations(data, sourceURL)...)
+		return result, nil
+	}
+
+	if agents := p.extractAgentList(data); len(agents) > 0 {
 		result.Agents = agents
 	}
+	// A page may list no agents yet still link to the next one.
+	if _, ok := data["agentList"]; ok {
+		result.Pagination = p.extractPagination(data, sourceURL)
+	}
 
 	if isAgentDescription(data) {
-		result.Interfaces = append(result.Interfaces, extractInterfacesFromAgentDescription(data)...)
+		result.Interfaces = append(result.Interfaces, p.extractInterfacesFromAgentDescription(data, sourceURL)...)
 		return result, nil
 	}
 
 	if isJSONRPC(data) {
-		if iface, err := extractJSONRPCInterface(data); err == nil {
+		if iface, err := p.extractJSONRPCInterface(data); err == nil {
 			result.Interfaces = append(result.Interfaces, iface)
 		} else {
 			return nil, fmt.Errorf("extract JSON-RPC interface from %s: %w", sourceURL, err)
@@ -97,7 +167,7 @@ func (p *JSONParser) Parse(_ context.Context, content []byte, contentType, sourc
 		return result, nil
 	}
 
-	logger.Debug("unsupported document structure", "source", sourceURL)
+	p.log().Debug("unsupported document structure", "source", sourceURL)
 	return result, nil
 }
 
@@ -112,6 +182,15 @@ func isAgentDescription(data map[string]any) bool {
 	return hasInterfaces
 }
 
+// isStreamingProtocol reports whether an interface protocol streams results.
+func isStreamingProtocol(protocol string) bool {
+	switch strings.ToLower(protocol) {
+	case "sse", "streamable", "streamable-http":
+		return true
+	}
+	return false
+}
+
 func isJSONRPC(data map[string]any) bool {
 	_, hasJSONRPC := data["jsonrpc"]
 	_, hasMethod := data["method"]
@@ -120,7 +199,7 @@ func isJSONRPC(data map[string]any) bool {
 	return hasJSONRPC || (hasMethod && hasID) || hasMethodsArray
 }
 
-func extractOpenRPCInterfaces(data map[string]any) []InterfaceEntry {
+func (p *JSONParser) extractOpenRPCInterfaces(data map[string]any) []InterfaceEntry {
 	methodsRaw, ok := data["methods"]
 	if !ok || methodsRaw == nil {
 		return nil
@@ -128,7 +207,7 @@ func extractOpenRPCInterfaces(data map[string]any) []InterfaceEntry {
 
 	methods, ok := methodsRaw.([]any)
 	if !ok {
-		logger.Debug("OpenRPC methods field is not an array")
+		p.log().Debug("OpenRPC methods field is not an array")
 		return nil
 	}
 
@@ -167,7 +246,7 @@ func extractOpenRPCInterfaces(data map[string]any) []InterfaceEntry {
 	return interfaces
 }
 
-func extractInterfacesFromAgentDescription(data map[string]any) []InterfaceEntry {
+func (p *JSONParser) extractInterfacesFromAgentDescription(data map[string]any, sourceURL string) []InterfaceEntry {
 	interfacesListRaw, ok := data["interfaces"]
 	if !ok || interfacesListRaw == nil {
 		return nil
@@ -175,7 +254,7 @@ func extractInterfacesFromAgentDescription(data map[string]any) []InterfaceEntry
 
 	interfacesList, ok := interfacesListRaw.([]any)
 	if !ok {
-		logger.Debug("AgentDescription interfaces field is not an array")
+		p.log().Debug("AgentDescription interfaces field is not an array")
 		return nil
 	}
 
@@ -194,23 +273,32 @@ func extractInterfacesFromAgentDescription(data map[string]any) []InterfaceEntry
 
 		ifaceType := getString(ifaceMap, "type")
 		ifaceProtocol := getString(ifaceMap, "protocol")
-
-		if strings.EqualFold(ifaceType, "StructuredInterface") && strings.EqualFold(ifaceProtocol, "openrpc") && ifaceMap["content"] != nil {
-			content, ok := ifaceMap["content"].(map[string]any)
-			if !ok || !isOpenRPC(content) {
-				logger.Debug("invalid OpenRPC content in StructuredInterface")
+		streaming := isStreamingProtocol(ifaceProtocol)
+
+		// Streaming interfaces may embed the OpenRPC methods they stream.
+		content, _ := ifaceMap["content"].(map[string]any)
+		if strings.EqualFold(ifaceType, "StructuredInterface") && ifaceMap["content"] != nil &&
+			(strings.EqualFold(ifaceProtocol, "openrpc") || streaming && isOpenRPC(content)) {
+			if !isOpenRPC(content) {
+				p.log().Debug("invalid OpenRPC content in StructuredInterface")
 				continue
 			}
-			embedded := extractOpenRPCInterfaces(content)
+			embedded := p.extractOpenRPCInterfaces(content)
 			for idx := range embedded {
 				if len(embedded[idx].Servers) == 0 {
 					embedded[idx].ParentServers = globalServers
 				}
+				embedded[idx].Streaming = streaming
 			}
 			interfaces = append(interfaces, embedded...)
 			continue
 		}
 
+		if strings.EqualFold(ifaceType, "StructuredInterface") && strings.EqualFold(ifaceProtocol, "openapi") && isOpenAPI(content) {
+			interfaces = append(interfaces, p.extractOpenAPIOperations(content, sourceURL)...)
+			continue
+		}
+
 		var inlineContent []byte
 		if rawContent, ok := ifaceMap["content"]; ok {
 			inlineContent, _ = sonic.Marshal(rawContent)
@@ -224,13 +312,14 @@ func extractInterfacesFromAgentDescription(data map[string]any) []InterfaceEntry
 			Source:        "agent_description",
 			ParentServers: globalServers,
 			Content:       inlineContent,
+			Streaming:     streaming,
 		})
 	}
 
 	return interfaces
 }
 
-func extractJSONRPCInterface(data map[string]any) (InterfaceEntry, error) {
+func (p *JSONParser) extractJSONRPCInterface(data map[string]any) (InterfaceEntry, error) {
 	methodName := getString(data, "method")
 	if methodName == "" {
 		methodName = getString(data, "name")
@@ -253,7 +342,7 @@ func extractJSONRPCInterface(data map[string]any) (InterfaceEntry, error) {
 	}, nil
 }
 
-func extractAgentList(data map[string]any) []AgentEntry {
+func (p *JSONParser) extractAgentList(data map[string]any) []AgentEntry {
 	rawAgents, ok := data["agentList"].([]any)
 	if !ok {
 		return nil
@@ -270,9 +359,9 @@ func extractAgentList(data map[string]any) []AgentEntry {
 			Name:        getString(agentMap, "name"),
 			Description: getString(agentMap, "description"),
 			URL:         getString(agentMap, "url"),
-			Rating:      getFloat(agentMap, "rating"),
-			UsageCount:  getInt(agentMap, "usage_count"),
-			ReviewCount: getInt(agentMap, "review_count"),
+			Rating:      p.getFloat(agentMap, "rating"),
+			UsageCount:  p.getInt(agentMap, "usage_count"),
+			ReviewCount: p.getInt(agentMap, "review_count"),
 		}
 		entries = append(entries, entry)
 	}
@@ -287,7 +376,7 @@ func getString(data map[string]any, key string) string {
 	return ""
 }
 
-func getFloat(data map[string]any, key string) float64 {
+func (p *JSONParser) getFloat(data map[string]any, key string) float64 {
 	if val, ok := data[key]; ok {
 		switch v := val.(type) {
 		case float64:
@@ -299,13 +388,13 @@ func getFloat(data map[string]any, key string) float64 {
 		case int64:
 			return float64(v)
 		default:
-			logger.Debug("unexpected type for key", "key", key, "type", fmt.Sprintf("%T", v))
+			p.log().Debug("unexpected type for key", "key", key, "type", fmt.Sprintf("%T", v))
 		}
 	}
 	return 0
 }
 
-func getInt(data map[string]any, key string) int64 {
+func (p *JSONParser) getInt(data map[string]any, key string) int64 {
 	if val, ok := data[key]; ok {
 		switch v := val.(type) {
 		case float64:
@@ -317,7 +406,7 @@ func getInt(data map[string]any, key string) int64 {
 		case int64:
 			return v
 		default:
-			logger.Debug("unexpected type for key", "key", key, "type", fmt.Sprintf("%T", v))
+			p.log().Debug("unexpected type for key", "key", key, "type", fmt.Sprintf("%T", v))
 		}
 	}
 	return 0
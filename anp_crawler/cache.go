@@ -0,0 +1,200 @@
+package anp_crawler
+
+import (
+	"container/list"
+	"context"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// CachedResponse is a response kept by a ResponseCache together with the
+// validators used to revalidate it.
+type CachedResponse struct {
+	StatusCode   int         `json:"status_code"`
+	ContentType  string      `json:"content_type,omitempty"`
+	Encoding     string      `json:"encoding,omitempty"`
+	Header       http.Header `json:"header,omitempty"`
+	Body         []byte      `json:"body"`
+	ETag         string      `json:"etag,omitempty"`
+	LastModified string      `json:"last_modified,omitempty"`
+	StoredAt     time.Time   `json:"stored_at"`
+}
+
+// ResponseCache stores GET responses by URL for the default Client.
+// Implementations must be safe for concurrent use.
+type ResponseCache interface {
+	// Get returns the response stored for url, if any.
+	Get(ctx context.Context, url string) (*CachedResponse, bool, error)
+	// Put stores resp for url, replacing any previous entry.
+	Put(ctx context.Context, url string, resp *CachedResponse) error
+}
+
+// WithResponseCache makes the client remember the ETag and Last-Modified
+// validators of successful GET responses and revalidate them with
+// If-None-Match and If-Modified-Since; a 304 answer is served from cache.
+// Responses marked Cache-Control: no-store, or without validators, are not
+// cached. Entries are keyed by URL only, so a cache must not be shared
+// between clients whose identities see different documents.
+func WithResponseCache(cache ResponseCache) ClientOption {
+	return func(c *httpClient) { c.cache = cache }
+}
+
+// CacheLimits bound a ResponseCache. Zero values mean no limit.
+type CacheLimits struct {
+	// TTL is how long an entry is kept. Expired entries are dropped, so the
+	// next fetch downloads the document in full even if the server would
+	// have answered 304.
+	TTL time.Duration
+	// MaxEntries caps the number of entries; the least recently used are
+	// evicted first.
+	MaxEntries int
+	// MaxBytes caps the total size of the cache: the bodies for MemoryCache,
+	// the entry files for DiskCache. An entry larger than MaxBytes on its own
+	// is not cached at all.
+	MaxBytes int64
+}
+
+func (l CacheLimits) expired(resp *CachedResponse, now time.Time) bool {
+	return l.TTL > 0 && now.Sub(resp.StoredAt) > l.TTL
+}
+
+var (
+	_ ResponseCache = (*MemoryCache)(nil)
+	_ ResponseCache = (*DiskCache)(nil)
+)
+
+// MemoryCache is an in-memory ResponseCache with LRU eviction.
+type MemoryCache struct {
+	limits CacheLimits
+	now    func() time.Time
+
+	mu      sync.Mutex
+	entries map[string]*list.Element // of *memoryEntry, most recent first
+	lru     list.List
+	size    int64
+}
+
+type memoryEntry struct {
+	url  string
+	resp *CachedResponse
+}
+
+// NewMemoryCache creates an empty MemoryCache bounded by limits.
+func NewMemoryCache(limits CacheLimits) *MemoryCache {
+	return &MemoryCache{limits: limits, now: time.Now, entries: make(map[string]*list.Element)}
+}
+
+// Get implements ResponseCache.
+func (m *MemoryCache) Get(_ context.Context, url string) (*CachedResponse, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	el, ok := m.entries[url]
+	if !ok {
+		return nil, false, nil
+	}
+	entry := el.Value.(*memoryEntry)
+	if m.limits.expired(entry.resp, m.now()) {
+		m.removeLocked(el)
+		return nil, false, nil
+	}
+	m.lru.MoveToFront(el)
+	return entry.resp, true, nil
+}
+
+// Put implements ResponseCache.
+func (m *MemoryCache) Put(_ context.Context, url string, resp *CachedResponse) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if el, ok := m.entries[url]; ok {
+		m.removeLocked(el)
+	}
+	size := int64(len(resp.Body))
+	if m.limits.MaxBytes > 0 && size > m.limits.MaxBytes {
+		return nil
+	}
+	m.entries[url] = m.lru.PushFront(&memoryEntry{url: url, resp: resp})
+	m.size += size
+	for (m.limits.MaxEntries > 0 && m.lru.Len() > m.limits.MaxEntries) ||
+		(m.limits.MaxBytes > 0 && m.size > m.limits.MaxBytes) {
+		m.removeLocked(m.lru.Back())
+	}
+	return nil
+}
+
+// Len returns the number of cached entries, including expired ones not yet
+// dropped.
+func (m *MemoryCache) Len() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.lru.Len()
+}
+
+func (m *MemoryCache) removeLocked(el *list.Element) {
+	entry := m.lru.Remove(el).(*memoryEntry)
+	delete(m.entries, entry.url)
+	m.size -= int64(len(entry.resp.Body))
+}
+
+// lookupCache returns the cached entry for a GET of target and adds its
+// validators to headers, unless the caller set conditional headers itself.
+func (c *httpClient) lookupCache(ctx context.Context, method, target string, headers map[string]string) *CachedResponse {
+	if c.cache == nil || method != http.MethodGet {
+		return nil
+	}
+	if hasHeader(headers, "If-None-Match") || hasHeader(headers, "If-Modified-Since") {
+		return nil
+	}
+	cached, ok, err := c.cache.Get(ctx, target)
+	if err != nil {
+		loggerOr(c.logger).Warn("response cache lookup failed", "url", target, "error", err)
+		return nil
+	}
+	if !ok || cached == nil {
+		return nil
+	}
+	if cached.ETag != "" {
+		headers["If-None-Match"] = cached.ETag
+	}
+	if cached.LastModified != "" {
+		headers["If-Modified-Since"] = cached.LastModified
+	}
+	return cached
+}
+
+// storeCache remembers a successful GET response that carries validators.
+func (c *httpClient) storeCache(ctx context.Context, target string, resp *http.Response, body []byte) {
+	etag, lastModified := resp.Header.Get("ETag"), resp.Header.Get("Last-Modified")
+	if etag == "" && lastModified == "" {
+		return
+	}
+	if strings.Contains(strings.ToLower(resp.Header.Get("Cache-Control")), "no-store") {
+		return
+	}
+	entry := &CachedResponse{
+		StatusCode:   resp.StatusCode,
+		ContentType:  resp.Header.Get("Content-Type"),
+		Encoding:     resp.Header.Get("Content-Encoding"),
+		Header:       resp.Header.Clone(),
+		Body:         append([]byte(nil), body...),
+		ETag:         etag,
+		LastModified: lastModified,
+		StoredAt:     time.Now(),
+	}
+	if err := c.cache.Put(ctx, target, entry); err != nil {
+		loggerOr(c.logger).Warn("response cache store failed", "url", target, "error", err)
+	}
+}
+
+// cachedResponse builds the Response served for a 304 answer.
+func cachedResponse(target string, cached *CachedResponse) *Response {
+	return &Response{
+		StatusCode:  cached.StatusCode,
+		URL:         target,
+		ContentType: cached.ContentType,
+		Encoding:    cached.Encoding,
+		Header:      cached.Header.Clone(),
+		Body:        append([]byte(nil), cached.Body...),
+	}
+}
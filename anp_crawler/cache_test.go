@@ -0,0 +1,128 @@
+package anp_crawler
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/openanp/anp-go/anptest"
+)
+
+func TestResponseCaches(t *testing.T) {
+	ctx := context.Background()
+	clock := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	entry := func(body string) *CachedResponse {
+		return &CachedResponse{StatusCode: http.StatusOK, Body: []byte(body), ETag: `"x"`, StoredAt: clock}
+	}
+	newCaches := func(t *testing.T, limits CacheLimits) map[string]ResponseCache {
+		mem := NewMemoryCache(limits)
+		mem.now = func() time.Time { return clock }
+		disk, err := NewDiskCache(t.TempDir(), limits)
+		if err != nil {
+			t.Fatalf("NewDiskCache() error = %v", err)
+		}
+		disk.now = func() time.Time { return clock }
+		return map[string]ResponseCache{"memory": mem, "disk": disk}
+	}
+	has := func(t *testing.T, cache ResponseCache, url string) bool {
+		t.Helper()
+		_, ok, err := cache.Get(ctx, url)
+		if err != nil {
+			t.Fatalf("Get(%s) error = %v", url, err)
+		}
+		return ok
+	}
+
+	t.Run("ttl", func(t *testing.T) {
+		for name, cache := range newCaches(t, CacheLimits{TTL: time.Hour}) {
+			t.Run(name, func(t *testing.T) {
+				cache.Put(ctx, "https://a.example.com/ad.json", entry("a"))
+				got, ok, err := cache.Get(ctx, "https://a.example.com/ad.json")
+				if err != nil || !ok || string(got.Body) != "a" || got.ETag != `"x"` {
+					t.Fatalf("Get() = %+v, %v, %v", got, ok, err)
+				}
+				clock = clock.Add(2 * time.Hour)
+				defer func() { clock = clock.Add(-2 * time.Hour) }()
+				if has(t, cache, "https://a.example.com/ad.json") {
+					t.Error("Get() after TTL found the entry")
+				}
+			})
+		}
+	})
+
+	t.Run("max entries", func(t *testing.T) {
+		for name, cache := range newCaches(t, CacheLimits{MaxEntries: 2}) {
+			t.Run(name, func(t *testing.T) {
+				for i := range 2 {
+					cache.Put(ctx, fmt.Sprintf("https://a.example.com/%d", i), entry("x"))
+					clock = clock.Add(time.Second)
+				}
+				// Reading /0 makes /1 the least recently used.
+				has(t, cache, "https://a.example.com/0")
+				clock = clock.Add(time.Second)
+				cache.Put(ctx, "https://a.example.com/2", entry("x"))
+				for url, want := range map[string]bool{"https://a.example.com/0": true, "https://a.example.com/1": false, "https://a.example.com/2": true} {
+					if got := has(t, cache, url); got != want {
+						t.Errorf("Get(%s) found = %v, want %v", url, got, want)
+					}
+				}
+			})
+		}
+	})
+
+	t.Run("max bytes", func(t *testing.T) {
+		for name, cache := range newCaches(t, CacheLimits{MaxBytes: 1500}) {
+			t.Run(name, func(t *testing.T) {
+				cache.Put(ctx, "https://a.example.com/big", entry(strings.Repeat("x", 2000)))
+				if has(t, cache, "https://a.example.com/big") {
+					t.Error("entry larger than MaxBytes was cached")
+				}
+				cache.Put(ctx, "https://a.example.com/1", entry(strings.Repeat("x", 600)))
+				clock = clock.Add(time.Second)
+				cache.Put(ctx, "https://a.example.com/2", entry(strings.Repeat("x", 600)))
+				clock = clock.Add(time.Second)
+				cache.Put(ctx, "https://a.example.com/3", entry(strings.Repeat("x", 600)))
+				if has(t, cache, "https://a.example.com/1") || !has(t, cache, "https://a.example.com/3") {
+					t.Error("oldest entry was not evicted to stay within MaxBytes")
+				}
+			})
+		}
+	})
+}
+
+func TestDiskCache_SurvivesRestart(t *testing.T) {
+	var full int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("ETag", `"v1"`)
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		full++
+		w.Write([]byte(`{"name":"agent"}`))
+	}))
+	defer srv.Close()
+	dir := t.TempDir()
+	auth := anptest.NewIdentity(t, "client.example.com").Authenticator
+
+	for run := range 2 {
+		cache, err := NewDiskCache(dir, CacheLimits{TTL: time.Hour})
+		if err != nil {
+			t.Fatalf("NewDiskCache() error = %v", err)
+		}
+		resp, err := NewClient(auth, WithResponseCache(cache)).Fetch(context.Background(), http.MethodGet, srv.URL, nil, nil)
+		if err != nil {
+			t.Fatalf("run %d: Fetch() error = %v", run, err)
+		}
+		if string(resp.Body) != `{"name":"agent"}` {
+			t.Errorf("run %d: body = %q", run, resp.Body)
+		}
+	}
+	if full != 1 {
+		t.Errorf("full downloads = %d, want 1", full)
+	}
+}
@@ -0,0 +1,84 @@
+package anp_crawler
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/openanp/anp-go/anp_schema"
+)
+
+// Warning is a problem found in a document by a validating JSONParser.
+type Warning struct {
+	// Path is a JSON Pointer to the offending value, "" for the document.
+	Path    string `json:"path"`
+	Message string `json:"message"`
+}
+
+func (w Warning) String() string {
+	if w.Path == "" {
+		return w.Message
+	}
+	return w.Path + ": " + w.Message
+}
+
+// knownProtocols are the interface protocols the parser and ANPInterface
+// know how to use or link to.
+var knownProtocols = map[string]bool{
+	"openrpc":         true,
+	"openapi":         true,
+	"jsonrpc":         true,
+	"json-rpc 2.0":    true,
+	"yaml":            true,
+	"mcp":             true,
+	"sse":             true,
+	"streamable":      true,
+	"streamable-http": true,
+}
+
+// diagnose checks a decoded document against the schema for its kind.
+// OpenRPC, OpenAPI and JSON-RPC documents are not checked.
+func diagnose(data map[string]any) []Warning {
+	if isOpenRPC(data) || isOpenAPI(data) {
+		return nil
+	}
+	_, hasInterfaces := data["interfaces"]
+	_, hasAgents := data["agentList"]
+	switch {
+	case hasInterfaces || data["type"] == "AgentDescription" || data["protocolType"] == "ANP":
+		warnings := schemaWarnings(anp_schema.AgentDescription, data)
+		interfaces, _ := data["interfaces"].([]any)
+		for i, item := range interfaces {
+			iface, _ := item.(map[string]any)
+			if protocol, ok := iface["protocol"].(string); ok && protocol != "" && !knownProtocols[strings.ToLower(protocol)] {
+				warnings = append(warnings, Warning{
+					Path:    fmt.Sprintf("/interfaces/%d/protocol", i),
+					Message: fmt.Sprintf("unknown protocol %q", protocol),
+				})
+			}
+		}
+		return warnings
+	case hasAgents:
+		return schemaWarnings(anp_schema.Directory, data)
+	case isJSONRPC(data):
+		return nil
+	default:
+		return []Warning{{Message: "unrecognised document: expected an agent description, agent directory, OpenRPC or OpenAPI document"}}
+	}
+}
+
+func schemaWarnings(schema string, data map[string]any) []Warning {
+	err := anp_schema.ValidateValue(schema, data)
+	if err == nil {
+		return nil
+	}
+	var violations anp_schema.Errors
+	if !errors.As(err, &violations) {
+		return []Warning{{Message: err.Error()}}
+	}
+	warnings := make([]Warning, len(violations))
+	for i, v := range violations {
+		warnings[i] = Warning{Path: v.Path, Message: v.Message}
+	}
+	return warnings
+}
@@ -0,0 +1,51 @@
+package anp_crawler
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestJSONParser_Validate(t *testing.T) {
+	const valid = `{"protocolType":"ANP","protocolVersion":"1.0.0","type":"AgentDescription","name":"hotel",
+		"interfaces":[{"type":"StructuredInterface","protocol":"openrpc","url":"https://agent.example.com/api.json"}]}`
+
+	tests := []struct {
+		name     string
+		doc      string
+		wantPath []string
+		wantMsg  string
+	}{
+		{"valid agent description", valid, nil, ""},
+		{"missing interfaces", `{"protocolType":"ANP","protocolVersion":"1.0.0","type":"AgentDescription","name":"hotel"}`, []string{""}, "interfaces"},
+		{"wrong field type", strings.Replace(valid, `"name":"hotel"`, `"name":7`, 1), []string{"/name"}, "string"},
+		{"unknown protocol", strings.Replace(valid, `"openrpc"`, `"grpc"`, 1), []string{"/interfaces/0/protocol"}, `unknown protocol "grpc"`},
+		{"directory", `{"agentList":[{"name":"a"}]}`, []string{"/agentList/0"}, "url"},
+		{"openrpc is not checked", `{"openrpc":"1.3.2","methods":[]}`, nil, ""},
+		{"unrecognised", `{"hello":"world"}`, []string{""}, "unrecognised document"},
+	}
+	parser := &JSONParser{Validate: true}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := parser.Parse(context.Background(), []byte(tt.doc), "application/json", "https://agent.example.com/ad.json")
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			var paths []string
+			for _, w := range result.Warnings {
+				paths = append(paths, w.Path)
+			}
+			if strings.Join(paths, ",") != strings.Join(tt.wantPath, ",") {
+				t.Fatalf("Parse() warnings = %v, want paths %q", result.Warnings, tt.wantPath)
+			}
+			if tt.wantMsg != "" && !strings.Contains(result.Warnings[0].Message, tt.wantMsg) {
+				t.Errorf("warning = %q, want it to mention %q", result.Warnings[0], tt.wantMsg)
+			}
+		})
+	}
+
+	result, _ := NewJSONParser().Parse(context.Background(), []byte(`{"hello":"world"}`), "application/json", "")
+	if len(result.Warnings) != 0 {
+		t.Errorf("Parse() without Validate warnings = %v, want none", result.Warnings)
+	}
+}
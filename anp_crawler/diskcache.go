@@ -0,0 +1,152 @@
+package anp_crawler
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bytedance/sonic"
+)
+
+// DiskCache is a ResponseCache keeping one JSON file per URL in a directory,
+// so a restarted crawler can revalidate what it fetched before instead of
+// downloading it again. Recency for eviction is tracked with file
+// modification times; enforcing MaxEntries or MaxBytes scans the directory on
+// every Put.
+type DiskCache struct {
+	dir    string
+	limits CacheLimits
+	now    func() time.Time
+
+	mu sync.Mutex // serialises writes and eviction
+}
+
+type diskEntry struct {
+	URL string `json:"url"`
+	*CachedResponse
+}
+
+// NewDiskCache creates a DiskCache in dir, creating the directory if needed.
+func NewDiskCache(dir string, limits CacheLimits) (*DiskCache, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("create cache directory: %w", err)
+	}
+	return &DiskCache{dir: dir, limits: limits, now: time.Now}, nil
+}
+
+func (d *DiskCache) path(url string) string {
+	sum := sha256.Sum256([]byte(url))
+	return filepath.Join(d.dir, hex.EncodeToString(sum[:])+".json")
+}
+
+// Get implements ResponseCache.
+func (d *DiskCache) Get(_ context.Context, url string) (*CachedResponse, bool, error) {
+	path := d.path(url)
+	raw, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("read cache entry: %w", err)
+	}
+	var entry diskEntry
+	if err := sonic.Unmarshal(raw, &entry); err != nil || entry.CachedResponse == nil || entry.URL != url {
+		// Corrupt or colliding entries are treated as misses and overwritten.
+		return nil, false, nil
+	}
+	now := d.now()
+	if d.limits.expired(entry.CachedResponse, now) {
+		os.Remove(path)
+		return nil, false, nil
+	}
+	// Mark the entry as recently used.
+	os.Chtimes(path, now, now)
+	return entry.CachedResponse, true, nil
+}
+
+// Put implements ResponseCache. The entry is written atomically.
+func (d *DiskCache) Put(_ context.Context, url string, resp *CachedResponse) error {
+	path := d.path(url)
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	raw, err := sonic.Marshal(diskEntry{URL: url, CachedResponse: resp})
+	if err != nil {
+		return fmt.Errorf("encode cache entry: %w", err)
+	}
+	if d.limits.MaxBytes > 0 && int64(len(raw)) > d.limits.MaxBytes {
+		os.Remove(path)
+		return nil
+	}
+	tmp, err := os.CreateTemp(d.dir, ".entry-*")
+	if err != nil {
+		return fmt.Errorf("write cache entry: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(raw); err != nil {
+		tmp.Close()
+		return fmt.Errorf("write cache entry: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("write cache entry: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("write cache entry: %w", err)
+	}
+	now := d.now()
+	os.Chtimes(path, now, now)
+	return d.evictLocked()
+}
+
+// evictLocked removes the least recently used entries until the cache is
+// within its limits. MaxBytes is measured against the entry files, which
+// are somewhat larger than the bodies they hold.
+func (d *DiskCache) evictLocked() error {
+	if d.limits.MaxEntries <= 0 && d.limits.MaxBytes <= 0 {
+		return nil
+	}
+	dirEntries, err := os.ReadDir(d.dir)
+	if err != nil {
+		return fmt.Errorf("list cache entries: %w", err)
+	}
+	type file struct {
+		name    string
+		size    int64
+		modTime time.Time
+	}
+	var files []file
+	var total int64
+	for _, de := range dirEntries {
+		if de.IsDir() || strings.HasPrefix(de.Name(), ".") || !strings.HasSuffix(de.Name(), ".json") {
+			continue
+		}
+		info, err := de.Info()
+		if err != nil {
+			continue
+		}
+		files = append(files, file{name: de.Name(), size: info.Size(), modTime: info.ModTime()})
+		total += info.Size()
+	}
+	slices.SortFunc(files, func(a, b file) int { return a.modTime.Compare(b.modTime) })
+	over := func() bool {
+		return (d.limits.MaxEntries > 0 && len(files) > d.limits.MaxEntries) ||
+			(d.limits.MaxBytes > 0 && total > d.limits.MaxBytes)
+	}
+	for len(files) > 0 && over() {
+		f := files[0]
+		if err := os.Remove(filepath.Join(d.dir, f.name)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return fmt.Errorf("evict cache entry: %w", err)
+		}
+		files = files[1:]
+		total -= f.size
+	}
+	return nil
+}
@@ -0,0 +1,97 @@
+package anp_crawler
+
+import (
+	"bufio"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/andybalholm/brotli"
+)
+
+// acceptEncoding is sent unless the caller sets Accept-Encoding itself.
+const acceptEncoding = "gzip, deflate, br"
+
+// decodeBody wraps body with decoders for the Content-Encoding of resp, in
+// reverse order of application. It returns the encodings it could not undo,
+// in which case the body is left encoded from that point on. Decoded
+// responses lose their Content-Encoding and Content-Length headers, as with
+// net/http's own transparent gzip handling. Closing the returned reader
+// releases the decoders but not body.
+func decodeBody(resp *http.Response, body io.Reader) (io.ReadCloser, string, error) {
+	header := resp.Header.Get("Content-Encoding")
+	if header == "" || resp.Uncompressed {
+		return io.NopCloser(body), header, nil
+	}
+	var codings []string
+	for _, c := range strings.Split(header, ",") {
+		if c = strings.ToLower(strings.TrimSpace(c)); c != "" && c != "identity" {
+			codings = append(codings, c)
+		}
+	}
+	decoded := &decodedBody{Reader: body}
+	for i := len(codings) - 1; i >= 0; i-- {
+		var dec io.ReadCloser
+		var err error
+		switch codings[i] {
+		case "gzip", "x-gzip":
+			dec, err = gzip.NewReader(decoded.Reader)
+		case "deflate":
+			dec, err = newDeflateReader(decoded.Reader)
+		case "br":
+			dec = io.NopCloser(brotli.NewReader(decoded.Reader))
+		default:
+			remaining := strings.Join(codings[:i+1], ", ")
+			if i < len(codings)-1 {
+				resp.Header.Set("Content-Encoding", remaining)
+				resp.Header.Del("Content-Length")
+				resp.ContentLength = -1
+			}
+			return decoded, remaining, nil
+		}
+		if err != nil {
+			decoded.Close()
+			return nil, header, fmt.Errorf("decode %s body: %w", codings[i], err)
+		}
+		decoded.Reader = dec
+		decoded.closers = append(decoded.closers, dec)
+	}
+	resp.Header.Del("Content-Encoding")
+	resp.Header.Del("Content-Length")
+	resp.ContentLength = -1
+	return decoded, "", nil
+}
+
+// decodedBody reads through a stack of decoders and closes every one of
+// them.
+type decodedBody struct {
+	io.Reader
+	closers []io.Closer
+}
+
+func (d *decodedBody) Close() error {
+	var errs []error
+	for i := len(d.closers) - 1; i >= 0; i-- {
+		errs = append(errs, d.closers[i].Close())
+	}
+	return errors.Join(errs...)
+}
+
+// newDeflateReader reads "deflate" bodies, which should be zlib streams but
+// are raw DEFLATE from some servers.
+func newDeflateReader(r io.Reader) (io.ReadCloser, error) {
+	br := bufio.NewReader(r)
+	head, err := br.Peek(2)
+	if err != nil {
+		return nil, err
+	}
+	if head[0]&0x0f == 8 && (uint16(head[0])<<8|uint16(head[1]))%31 == 0 {
+		return zlib.NewReader(br)
+	}
+	return flate.NewReader(br), nil
+}
@@ -0,0 +1,32 @@
+package anp_crawler
+
+import (
+	"context"
+	"testing"
+)
+
+// FuzzJSONParserParse feeds arbitrary documents through the parser and the
+// tool converter, which both consume whatever a remote agent serves.
+// Run with: go test -fuzz=FuzzJSONParserParse ./anp_crawler
+func FuzzJSONParserParse(f *testing.F) {
+	f.Add([]byte(benchmarkOpenRPCDoc), "application/json")
+	f.Add([]byte(`{"protocolType":"ANP","type":"AgentDescription","name":"a","interfaces":[{"type":"StructuredInterface","protocol":"openrpc","url":"https://a.example/api.json"}]}`), "application/ld+json")
+	f.Add([]byte(`{"agentList":[{"name":"a","url":"https://a.example/ad.json","rating":4.5,"usage_count":3}]}`), "application/json")
+	f.Add([]byte(`{"jsonrpc":"2.0","method":"add","params":{"a":{"type":"integer"}}}`), "")
+	f.Add([]byte(`[]`), "text/plain")
+
+	parser := &JSONParser{}
+	converter := &ANPInterfaceConverter{}
+	f.Fuzz(func(t *testing.T, content []byte, contentType string) {
+		result, err := parser.Parse(context.Background(), content, contentType, "https://fuzz.example/doc.json")
+		if err != nil {
+			return
+		}
+		if result == nil {
+			t.Fatal("Parse() returned nil result without error")
+		}
+		for _, entry := range result.Interfaces {
+			converter.ConvertToANPTool(entry)
+		}
+	})
+}
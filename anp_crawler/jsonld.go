@@ -0,0 +1,172 @@
+package anp_crawler
+
+import (
+	"maps"
+	"strings"
+)
+
+// canonicalNamespaces are the vocabularies whose terms the parser reads as
+// bare keys: the ANP agent description vocabulary and schema.org, which the
+// default Agent Description context uses as @vocab.
+var canonicalNamespaces = []string{
+	"https://agent-network-protocol.com/ad#",
+	"https://schema.org/",
+	"http://schema.org/",
+}
+
+// jsonldContext is the part of an active JSON-LD context the parser needs
+// to map keys to IRIs: the vocabulary and term and prefix definitions.
+// Remote contexts are not fetched.
+type jsonldContext struct {
+	vocab string
+	terms map[string]string
+}
+
+// with returns c extended by a @context value: an object, or an array of
+// objects and remote context URLs, which are skipped.
+func (c jsonldContext) with(raw any) jsonldContext {
+	switch raw := raw.(type) {
+	case []any:
+		for _, item := range raw {
+			c = c.with(item)
+		}
+	case map[string]any:
+		c.terms = maps.Clone(c.terms)
+		if c.terms == nil {
+			c.terms = make(map[string]string, len(raw))
+		}
+		for term, def := range raw {
+			var iri string
+			switch def := def.(type) {
+			case string:
+				iri = def
+			case map[string]any:
+				iri, _ = def["@id"].(string)
+			}
+			if term == "@vocab" {
+				c.vocab = iri
+			} else if iri != "" && !strings.HasPrefix(term, "@") {
+				c.terms[term] = iri
+			}
+		}
+	}
+	return c
+}
+
+// expand maps a key or type value to an IRI, resolving terms, compact IRIs
+// ("prefix:suffix") and the vocabulary. Keys it cannot resolve are returned
+// unchanged.
+func (c jsonldContext) expand(key string) string {
+	// Bound alias chains such as a term defined as a compact IRI.
+	for range 4 {
+		if strings.HasPrefix(key, "@") {
+			return key
+		}
+		if iri, ok := c.terms[key]; ok && iri != key {
+			key = iri
+			continue
+		}
+		prefix, suffix, ok := strings.Cut(key, ":")
+		if !ok {
+			if c.vocab != "" {
+				return c.vocab + key
+			}
+			return key
+		}
+		if strings.HasPrefix(suffix, "//") {
+			return key
+		}
+		base, ok := c.terms[prefix]
+		if !ok {
+			return key
+		}
+		key = base + suffix
+	}
+	return key
+}
+
+// compactKey returns the bare key the parser expects for key, or key itself
+// when it does not belong to a canonical namespace.
+func (c jsonldContext) compactKey(key string) string {
+	if key == "@context" {
+		return key
+	}
+	iri := c.expand(key)
+	if iri == "@type" {
+		return "type"
+	}
+	for _, ns := range canonicalNamespaces {
+		if term, ok := strings.CutPrefix(iri, ns); ok && term != "" {
+			return term
+		}
+	}
+	return key
+}
+
+// compactJSONLD rewrites a JSON-LD document so that keys and type values
+// written with other prefixes, aliases or full IRIs use the bare terms of
+// the Agent Description vocabulary, e.g. "ad:interfaces" or an alias
+// defined as {"apis": "ad:interfaces"} become "interfaces". Nested @context
+// values apply to their own objects. Interface "content", which embeds
+// plain JSON documents such as OpenRPC, is left as is.
+func compactJSONLD(data map[string]any) map[string]any {
+	return compactObject(data, jsonldContext{})
+}
+
+func compactObject(obj map[string]any, ctx jsonldContext) map[string]any {
+	if raw, ok := obj["@context"]; ok {
+		ctx = ctx.with(raw)
+	}
+	out := make(map[string]any, len(obj))
+	for key, value := range obj {
+		compact := ctx.compactKey(key)
+		switch {
+		case compact == "@context" || compact == "content":
+		case compact == "type":
+			value = compactTypes(value, ctx)
+		default:
+			value = compactValue(value, ctx)
+		}
+		// A bare key takes precedence over a prefixed duplicate.
+		if _, exists := out[compact]; exists && compact != key {
+			continue
+		}
+		out[compact] = value
+	}
+	return out
+}
+
+func compactValue(value any, ctx jsonldContext) any {
+	switch value := value.(type) {
+	case map[string]any:
+		return compactObject(value, ctx)
+	case []any:
+		items := make([]any, len(value))
+		for i, item := range value {
+			items[i] = compactValue(item, ctx)
+		}
+		return items
+	default:
+		return value
+	}
+}
+
+// compactTypes compacts type values, reducing a single-element type array
+// to a string as plain JSON documents write it.
+func compactTypes(value any, ctx jsonldContext) any {
+	switch value := value.(type) {
+	case string:
+		return ctx.compactKey(value)
+	case []any:
+		if len(value) == 1 {
+			return compactTypes(value[0], ctx)
+		}
+		items := make([]any, len(value))
+		for i, item := range value {
+			items[i] = compactTypes(item, ctx)
+		}
+		return items
+	default:
+		return value
+	}
+}
@@ -0,0 +1,101 @@
+package anp_crawler
+
+import (
+	"context"
+	"testing"
+)
+
+func TestJSONParser_JSONLD(t *testing.T) {
+	const doc = `{
+		"@context": [
+			"https://agent-network-protocol.com/context.jsonld",
+			{
+				"@vocab": "https://schema.org/",
+				"anp": "https://agent-network-protocol.com/ad#",
+				"apis": {"@id": "anp:interfaces", "@container": "@set"},
+				"kind": "@type"
+			}
+		],
+		"@type": "anp:AgentDescription",
+		"name": "aliased agent",
+		"https://schema.org/description": "full IRI key",
+		"anp:agentList": [{"name": "peer", "url": "https://peer.example.com/ad.json"}],
+		"apis": [
+			{
+				"@type": ["anp:StructuredInterface"],
+				"anp:protocol": "openrpc",
+				"url": "https://agent.example.com/api.json"
+			},
+			{
+				"@context": {"p": "https://agent-network-protocol.com/ad#"},
+				"type": "StructuredInterface",
+				"p:protocol": "openrpc",
+				"content": {"openrpc": "1.3.2", "methods": [{"name": "search", "params": []}]}
+			}
+		]
+	}`
+
+	result, err := (&JSONParser{JSONLD: true}).Parse(context.Background(), []byte(doc), "application/ld+json", "https://agent.example.com/ad.json")
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if len(result.Agents) != 1 || result.Agents[0].Name != "peer" {
+		t.Errorf("Parse() agents = %+v", result.Agents)
+	}
+	if len(result.Interfaces) != 2 {
+		t.Fatalf("Parse() = %d interfaces, want 2", len(result.Interfaces))
+	}
+	linked, embedded := result.Interfaces[0], result.Interfaces[1]
+	if linked.Type != "StructuredInterface" || linked.Protocol != "openrpc" || linked.URL != "https://agent.example.com/api.json" {
+		t.Errorf("linked interface = %+v", linked)
+	}
+	if embedded.MethodName != "search" {
+		t.Errorf("embedded interface = %+v", embedded)
+	}
+
+	// Without the option the aliased keys are not recognised.
+	plain, err := NewJSONParser().Parse(context.Background(), []byte(doc), "application/ld+json", "https://agent.example.com/ad.json")
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if len(plain.Interfaces) != 0 {
+		t.Errorf("Parse() without JSONLD = %d interfaces, want 0", len(plain.Interfaces))
+	}
+}
+
+func TestCompactJSONLD(t *testing.T) {
+	ctx := jsonldContext{}.with(map[string]any{
+		"@vocab": "https://example.org/vocab#",
+		"ad":     "https://agent-network-protocol.com/ad#",
+		"alias":  "ad:interfaces",
+		"chain":  "alias",
+		"kind":   "@type",
+	})
+	tests := []struct {
+		key  string
+		want string
+	}{
+		{"ad:interfaces", "interfaces"},
+		{"alias", "interfaces"},
+		{"chain", "interfaces"},
+		{"https://agent-network-protocol.com/ad#protocol", "protocol"},
+		{"name", "name"}, // custom @vocab: kept as written
+		{"unknown:term", "unknown:term"},
+		{"@type", "type"},
+		{"kind", "type"},
+	}
+	for _, tt := range tests {
+		if got := ctx.compactKey(tt.key); got != tt.want {
+			t.Errorf("compactKey(%q) = %q, want %q", tt.key, got, tt.want)
+		}
+	}
+
+	got := compactJSONLD(map[string]any{
+		"@context":      map[string]any{"ad": "https://agent-network-protocol.com/ad#"},
+		"interfaces":    "bare",
+		"ad:interfaces": "prefixed",
+	})
+	if got["interfaces"] != "bare" {
+		t.Errorf("compactJSONLD() interfaces = %v, want the bare key to win", got["interfaces"])
+	}
+}
@@ -4,7 +4,11 @@ import "log/slog"
 
 var logger = slog.Default()
 
-// SetLogger allows callers to provide a custom slog.Logger. Passing nil resets to slog.Default().
+// SetLogger sets the fallback logger used by clients, parsers, converters and
+// interfaces constructed without one. Passing nil resets to slog.Default().
+//
+// Deprecated: inject a logger per instance with WithLogger, JSONParser.Logger,
+// ANPInterfaceConverter.Logger or ANPInterface.Logger instead.
 func SetLogger(l *slog.Logger) {
 	if l == nil {
 		logger = slog.Default()
@@ -13,7 +17,15 @@ func SetLogger(l *slog.Logger) {
 	logger = l
 }
 
-// Logger returns the logger used within the anp_crawler package.
+// Logger returns the fallback logger of the anp_crawler package.
 func Logger() *slog.Logger {
 	return logger
 }
+
+// loggerOr returns l, or the package fallback when l is nil.
+func loggerOr(l *slog.Logger) *slog.Logger {
+	if l != nil {
+		return l
+	}
+	return logger
+}
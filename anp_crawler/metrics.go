@@ -0,0 +1,35 @@
+package anp_crawler
+
+import (
+	"net/url"
+	"time"
+)
+
+// MetricsRecorder receives measurements of the requests made by the default
+// Client. It keeps the crawler free of any metrics library; the metrics
+// module provides a Prometheus implementation. Methods are called on the
+// request path and must be safe for concurrent use.
+type MetricsRecorder interface {
+	// ObserveRequest is called once per HTTP attempt, with the response
+	// status code or 0 when the request failed before a response arrived.
+	ObserveRequest(host, method string, code int, elapsed time.Duration)
+	// ObserveRetry is called when the client repeats a request, with the
+	// reason for the retry.
+	ObserveRetry(host, reason string)
+}
+
+// RetryReasonUnauthorized is reported when a request is retried with a fresh
+// DID-WBA header after a 401 response.
+const RetryReasonUnauthorized = "unauthorized"
+
+// WithMetrics records every request and retry in r.
+func WithMetrics(r MetricsRecorder) ClientOption {
+	return func(c *httpClient) { c.metrics = r }
+}
+
+func hostname(target string) string {
+	if u, err := url.Parse(target); err == nil {
+		return u.Hostname()
+	}
+	return ""
+}
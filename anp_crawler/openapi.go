@@ -0,0 +1,263 @@
+package anp_crawler
+
+import (
+	"maps"
+	"net/url"
+	"slices"
+	"strings"
+
+	"github.com/bytedance/sonic"
+)
+
+// openAPIMethods are the operation keys of an OpenAPI path item, in the
+// order operations are emitted.
+var openAPIMethods = []string{"get", "put", "post", "delete", "options", "head", "patch", "trace"}
+
+// openAPIBodyParam is the argument that carries an operation's JSON request
+// body.
+const openAPIBodyParam = "body"
+
+func isOpenAPI(data map[string]any) bool {
+	version, _ := data["openapi"].(string)
+	_, hasPaths := data["paths"].(map[string]any)
+	return strings.HasPrefix(version, "3.") && hasPaths
+}
+
+// extractOpenAPIOperations returns one "openapi_operation" entry per
+// operation. Path, query and header parameters become top-level arguments
+// and a JSON request body the "body" argument; ParamLocations records which
+// is which. Relative server URLs are resolved against sourceURL.
+func (p *JSONParser) extractOpenAPIOperations(data map[string]any, sourceURL string) []InterfaceEntry {
+	paths, _ := data["paths"].(map[string]any)
+	components, _ := data["components"].(map[string]any)
+	var componentsJSON []byte
+	if components != nil {
+		componentsJSON, _ = sonic.Marshal(components)
+	}
+
+	servers := openAPIServers(data["servers"], sourceURL)
+	if len(servers) == 0 {
+		// OpenAPI's default server is "/", relative to the document.
+		servers = openAPIServers([]any{map[string]any{"url": "/"}}, sourceURL)
+	}
+
+	var interfaces []InterfaceEntry
+	for _, path := range slices.Sorted(maps.Keys(paths)) {
+		item, ok := paths[path].(map[string]any)
+		if !ok {
+			continue
+		}
+		pathServers := servers
+		if s := openAPIServers(item["servers"], sourceURL); len(s) > 0 {
+			pathServers = s
+		}
+		for _, method := range openAPIMethods {
+			op, ok := item[method].(map[string]any)
+			if !ok {
+				continue
+			}
+			opServers := pathServers
+			if s := openAPIServers(op["servers"], sourceURL); len(s) > 0 {
+				opServers = s
+			}
+
+			params, locations := openAPIParams(components, item["parameters"], op)
+			paramsJSON, _ := sonic.Marshal(params)
+			var result []byte
+			if schema := openAPIResultSchema(components, op["responses"]); schema != nil {
+				result, _ = sonic.Marshal(schema)
+			}
+
+			name := getString(op, "operationId")
+			if name == "" {
+				name = operationName(method, path)
+			}
+			interfaces = append(interfaces, InterfaceEntry{
+				Type:           "openapi_operation",
+				Protocol:       "openapi",
+				MethodName:     name,
+				Summary:        getString(op, "summary"),
+				Description:    getString(op, "description"),
+				Params:         paramsJSON,
+				Result:         result,
+				Components:     componentsJSON,
+				Servers:        opServers,
+				Source:         "openapi_interface",
+				HTTPMethod:     strings.ToUpper(method),
+				Path:           path,
+				ParamLocations: locations,
+			})
+		}
+	}
+	if len(interfaces) == 0 {
+		p.log().Debug("OpenAPI document declares no operations", "source", sourceURL)
+	}
+	return interfaces
+}
+
+// openAPIParams builds the object schema for an operation's arguments from
+// the path item's and the operation's parameters, the latter overriding the
+// former, and its JSON request body.
+func openAPIParams(components map[string]any, pathParams any, op map[string]any) (map[string]any, map[string]string) {
+	type key struct{ name, in string }
+	var order []key
+	byKey := make(map[key]map[string]any)
+	for _, list := range []any{pathParams, op["parameters"]} {
+		items, _ := list.([]any)
+		for _, item := range items {
+			param := resolveRef(components, item)
+			k := key{getString(param, "name"), getString(param, "in")}
+			// Cookies are left to the HTTP client.
+			if k.name == "" || k.in == "" || k.in == "cookie" {
+				continue
+			}
+			if _, seen := byKey[k]; !seen {
+				order = append(order, k)
+			}
+			byKey[k] = param
+		}
+	}
+
+	properties := make(map[string]any)
+	locations := make(map[string]string)
+	required := []string{}
+	for _, k := range order {
+		param := byKey[k]
+		schema := map[string]any{"type": "string"}
+		if s, ok := param["schema"].(map[string]any); ok {
+			schema = maps.Clone(s)
+		}
+		if desc := getString(param, "description"); desc != "" && schema["description"] == nil {
+			schema["description"] = desc
+		}
+		properties[k.name] = schema
+		locations[k.name] = k.in
+		if req, _ := param["required"].(bool); req || k.in == "path" {
+			required = append(required, k.name)
+		}
+	}
+
+	if body := resolveRef(components, op["requestBody"]); body != nil {
+		if schema := jsonMediaSchema(body["content"]); schema != nil {
+			if desc := getString(body, "description"); desc != "" && schema["description"] == nil {
+				schema = maps.Clone(schema)
+				schema["description"] = desc
+			}
+			properties[openAPIBodyParam] = schema
+			locations[openAPIBodyParam] = "body"
+			if req, _ := body["required"].(bool); req {
+				required = append(required, openAPIBodyParam)
+			}
+		}
+	}
+
+	return map[string]any{"type": "object", "properties": properties, "required": required}, locations
+}
+
+// openAPIResultSchema returns the JSON schema of an operation's success
+// response: 200, 201, the first other 2XX code, or default.
+func openAPIResultSchema(components map[string]any, raw any) map[string]any {
+	responses, _ := raw.(map[string]any)
+	codes := []string{"200", "201"}
+	for _, code := range slices.Sorted(maps.Keys(responses)) {
+		if strings.HasPrefix(code, "2") {
+			codes = append(codes, code)
+		}
+	}
+	codes = append(codes, "default")
+	for _, code := range codes {
+		if resp := resolveRef(components, responses[code]); resp != nil {
+			if schema := jsonMediaSchema(resp["content"]); schema != nil {
+				return schema
+			}
+		}
+	}
+	return nil
+}
+
+// jsonMediaSchema returns the schema of the JSON entry of an OpenAPI content
+// map, preferring application/json over other JSON media types.
+func jsonMediaSchema(raw any) map[string]any {
+	content, _ := raw.(map[string]any)
+	media, _ := content["application/json"].(map[string]any)
+	if media == nil {
+		for _, mediaType := range slices.Sorted(maps.Keys(content)) {
+			if strings.Contains(mediaType, "json") {
+				media, _ = content[mediaType].(map[string]any)
+				break
+			}
+		}
+	}
+	schema, _ := media["schema"].(map[string]any)
+	return schema
+}
+
+// resolveRef follows a local "#/components/..." $ref, as used for shared
+// parameters, request bodies and responses, and returns the object it names.
+func resolveRef(components map[string]any, raw any) map[string]any {
+	obj, _ := raw.(map[string]any)
+	// Bound the chain so a cyclic reference cannot loop forever.
+	for range 8 {
+		ref, ok := obj["$ref"].(string)
+		if !ok {
+			return obj
+		}
+		pointer, ok := strings.CutPrefix(ref, "#/components/")
+		if !ok {
+			return nil
+		}
+		var node any = components
+		for _, part := range strings.Split(pointer, "/") {
+			part = strings.ReplaceAll(strings.ReplaceAll(part, "~1", "/"), "~0", "~")
+			m, _ := node.(map[string]any)
+			node = m[part]
+		}
+		obj, _ = node.(map[string]any)
+	}
+	return nil
+}
+
+// openAPIServers converts an OpenAPI servers list, filling server variables
+// with their defaults and resolving relative URLs against sourceURL.
+func openAPIServers(raw any, sourceURL string) []Server {
+	items, _ := raw.([]any)
+	base, _ := url.Parse(sourceURL)
+	var servers []Server
+	for _, item := range items {
+		server, ok := item.(map[string]any)
+		if !ok {
+			continue
+		}
+		serverURL := getString(server, "url")
+		variables, _ := server["variables"].(map[string]any)
+		for name, v := range variables {
+			if variable, ok := v.(map[string]any); ok {
+				serverURL = strings.ReplaceAll(serverURL, "{"+name+"}", getString(variable, "default"))
+			}
+		}
+		if u, err := url.Parse(serverURL); err == nil && !u.IsAbs() && base != nil && base.IsAbs() {
+			serverURL = base.ResolveReference(u).String()
+		}
+		if serverURL == "" {
+			continue
+		}
+		servers = append(servers, Server{
+			Name:        getString(server, "name"),
+			URL:         strings.TrimSuffix(serverURL, "/"),
+			Description: getString(server, "description"),
+		})
+	}
+	return servers
+}
+
+// operationName names an operation without an operationId after its method
+// and path, e.g. "get_hotels_id" for GET /hotels/{id}.
+func operationName(method, path string) string {
+	name := method
+	for _, segment := range strings.FieldsFunc(path, func(r rune) bool {
+		return r == '/' || r == '{' || r == '}' || r == '-' || r == '.'
+	}) {
+		name += "_" + segment
+	}
+	return name
+}
@@ -0,0 +1,146 @@
+package anp_crawler
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"slices"
+	"testing"
+
+	"github.com/bytedance/sonic"
+)
+
+const testOpenAPIDoc = `
+openapi: 3.0.3
+info: {title: Hotels, version: "1.0"}
+servers:
+  - url: /v1
+paths:
+  /hotels/{hotelId}:
+    parameters:
+      - $ref: '#/components/parameters/HotelId'
+    get:
+      operationId: getHotel
+      summary: Get a hotel
+      parameters:
+        - {name: lang, in: query, schema: {type: string}, description: Response language}
+        - {name: session, in: cookie, schema: {type: string}}
+      responses:
+        "200":
+          description: The hotel
+          content:
+            application/json:
+              schema: {$ref: '#/components/schemas/Hotel'}
+  /hotels/{hotelId}/bookings:
+    parameters:
+      - $ref: '#/components/parameters/HotelId'
+    post:
+      servers:
+        - url: https://{region}.booking.example.com
+          variables:
+            region: {default: eu}
+      parameters:
+        - {name: X-Request-Id, in: header, schema: {type: string}}
+      requestBody:
+        required: true
+        content:
+          application/json:
+            schema:
+              type: object
+              properties:
+                nights: {type: integer}
+              required: [nights]
+      responses:
+        "201":
+          $ref: '#/components/responses/Booking'
+components:
+  parameters:
+    HotelId: {name: hotelId, in: path, schema: {type: string}}
+  schemas:
+    Hotel:
+      type: object
+      properties:
+        name: {type: string}
+  responses:
+    Booking:
+      description: Created
+      content:
+        application/json:
+          schema: {type: object, properties: {id: {type: string}}}
+`
+
+func TestJSONParser_OpenAPI(t *testing.T) {
+	result, err := NewJSONParser().Parse(context.Background(), []byte(testOpenAPIDoc), "application/yaml", "https://agent.example.com/docs/openapi.yaml")
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if len(result.Interfaces) != 2 {
+		t.Fatalf("Parse() = %d interfaces, want 2", len(result.Interfaces))
+	}
+	get, post := result.Interfaces[0], result.Interfaces[1]
+
+	if get.Type != "openapi_operation" || get.MethodName != "getHotel" || get.HTTPMethod != "GET" || get.Path != "/hotels/{hotelId}" {
+		t.Errorf("GET operation = %+v", get)
+	}
+	if len(get.Servers) != 1 || get.Servers[0].URL != "https://agent.example.com/v1" {
+		t.Errorf("GET servers = %+v, want the document's relative server resolved", get.Servers)
+	}
+	wantLocations := map[string]string{"hotelId": "path", "lang": "query"}
+	if !maps.Equal(get.ParamLocations, wantLocations) {
+		t.Errorf("GET ParamLocations = %v, want %v", get.ParamLocations, wantLocations)
+	}
+	if string(get.Result) != `{"$ref":"#/components/schemas/Hotel"}` {
+		t.Errorf("GET Result = %s", get.Result)
+	}
+
+	if post.MethodName != "post_hotels_hotelId_bookings" || post.HTTPMethod != "POST" {
+		t.Errorf("POST operation = %+v", post)
+	}
+	if len(post.Servers) != 1 || post.Servers[0].URL != "https://eu.booking.example.com" {
+		t.Errorf("POST servers = %+v, want operation server with variable default", post.Servers)
+	}
+	if post.ParamLocations["body"] != "body" || post.ParamLocations["X-Request-Id"] != "header" {
+		t.Errorf("POST ParamLocations = %v", post.ParamLocations)
+	}
+
+	tool, err := NewANPInterfaceConverter().ConvertToANPTool(post)
+	if err != nil || tool == nil {
+		t.Fatalf("ConvertToANPTool() = %v, %v", tool, err)
+	}
+	params := tool.Function.Parameters
+	slices.Sort(params.Required)
+	if !slices.Equal(params.Required, []string{"body", "hotelId"}) || len(params.Properties) != 3 {
+		t.Errorf("tool parameters = %+v", params)
+	}
+	if err := ValidateArguments(post, map[string]any{"hotelId": "h1", "body": map[string]any{}}); !errors.Is(err, ErrInvalidArguments) {
+		t.Errorf("ValidateArguments(missing nights) error = %v, want ErrInvalidArguments", err)
+	}
+	if err := ValidateArguments(post, map[string]any{"hotelId": "h1", "body": map[string]any{"nights": 2}}); err != nil {
+		t.Errorf("ValidateArguments() error = %v", err)
+	}
+
+	var schema map[string]any
+	sonic.Unmarshal(get.Params, &schema)
+	lang, _ := schema["properties"].(map[string]any)["lang"].(map[string]any)
+	if lang["description"] != "Response language" {
+		t.Errorf("lang schema = %v, want the parameter description", lang)
+	}
+}
+
+func TestJSONParser_OpenAPIInAgentDescription(t *testing.T) {
+	ad := `{
+		"type": "AgentDescription",
+		"interfaces": [{
+			"type": "StructuredInterface",
+			"protocol": "OpenAPI",
+			"content": {"openapi": "3.1.0", "paths": {"/ping": {"get": {"operationId": "ping"}}}}
+		}]
+	}`
+	result, err := NewJSONParser().Parse(context.Background(), []byte(ad), "application/json", "https://agent.example.com/ad.json")
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if len(result.Interfaces) != 1 || result.Interfaces[0].MethodName != "ping" || result.Interfaces[0].Servers[0].URL != "https://agent.example.com" {
+		t.Errorf("Parse() interfaces = %+v", result.Interfaces)
+	}
+}
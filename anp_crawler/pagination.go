@@ -0,0 +1,93 @@
+package anp_crawler
+
+import "net/url"
+
+// CursorParam is the query parameter Pagination.NextURL uses to request the
+// page after a cursor.
+const CursorParam = "cursor"
+
+// Pagination describes how to fetch the page after a paged agent directory.
+type Pagination struct {
+	// Next is the absolute URL of the next page, when the directory links it.
+	Next string `json:"next,omitempty"`
+	// Cursor is an opaque token for the next page, when the directory gives
+	// one instead of a link.
+	Cursor string `json:"cursor,omitempty"`
+	// Total is the number of agents across all pages, if stated.
+	Total int64 `json:"total,omitempty"`
+}
+
+// NextURL returns the URL of the page after the one at current: Next, or
+// current with its cursor parameter set to Cursor. It returns "" on the last
+// page.
+func (p *Pagination) NextURL(current string) string {
+	if p == nil {
+		return ""
+	}
+	if p.Next != "" {
+		return p.Next
+	}
+	if p.Cursor == "" {
+		return ""
+	}
+	u, err := url.Parse(current)
+	if err != nil {
+		return ""
+	}
+	q := u.Query()
+	q.Set(CursorParam, p.Cursor)
+	u.RawQuery = q.Encode()
+	return u.String()
+}
+
+// extractPagination reads the paging metadata of a directory page. It
+// accepts the fields at the top level or in a "pagination" object, as
+// "next", "nextCursor"/"next_cursor" and "total"/"totalCount", and a next
+// link in "links" or "_links", either as a URL or as {"href": url}. Relative
+// links are resolved against sourceURL. It returns nil when there is no next
+// page.
+func (p *JSONParser) extractPagination(data map[string]any, sourceURL string) *Pagination {
+	page := &Pagination{}
+	for _, obj := range []map[string]any{data, asObject(data["pagination"])} {
+		if obj == nil {
+			continue
+		}
+		if next := getString(obj, "next"); next != "" {
+			page.Next = next
+		}
+		for _, key := range []string{"nextCursor", "next_cursor"} {
+			if cursor := getString(obj, key); cursor != "" {
+				page.Cursor = cursor
+			}
+		}
+		for _, key := range []string{"total", "totalCount", "total_count"} {
+			if total := p.getInt(obj, key); total > 0 {
+				page.Total = total
+			}
+		}
+		for _, key := range []string{"links", "_links"} {
+			links := asObject(obj[key])
+			if href := getString(links, "next"); href != "" {
+				page.Next = href
+			} else if href := getString(asObject(links["next"]), "href"); href != "" {
+				page.Next = href
+			}
+		}
+	}
+	if page.Next == "" && page.Cursor == "" {
+		return nil
+	}
+	if page.Next != "" {
+		if base, err := url.Parse(sourceURL); err == nil {
+			if ref, err := url.Parse(page.Next); err == nil {
+				page.Next = base.ResolveReference(ref).String()
+			}
+		}
+	}
+	return page
+}
+
+func asObject(v any) map[string]any {
+	obj, _ := v.(map[string]any)
+	return obj
+}
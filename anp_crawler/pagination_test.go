@@ -0,0 +1,54 @@
+package anp_crawler
+
+import (
+	"context"
+	"testing"
+)
+
+func TestJSONParser_Pagination(t *testing.T) {
+	const source = "https://dir.example.com/agents?page=1"
+	tests := []struct {
+		name    string
+		doc     string
+		want    *Pagination
+		nextURL string
+	}{
+		{
+			name:    "next link",
+			doc:     `{"agentList": [{"name": "a", "url": "a.json"}], "next": "/agents?page=2", "total": 3}`,
+			want:    &Pagination{Next: "https://dir.example.com/agents?page=2", Total: 3},
+			nextURL: "https://dir.example.com/agents?page=2",
+		},
+		{
+			name:    "cursor in pagination object",
+			doc:     `{"agentList": [{"name": "a"}], "pagination": {"nextCursor": "abc", "totalCount": 10}}`,
+			want:    &Pagination{Cursor: "abc", Total: 10},
+			nextURL: "https://dir.example.com/agents?cursor=abc&page=1",
+		},
+		{
+			name:    "HAL link on an empty page",
+			doc:     `{"agentList": [], "_links": {"next": {"href": "https://dir.example.com/p/2"}}}`,
+			want:    &Pagination{Next: "https://dir.example.com/p/2"},
+			nextURL: "https://dir.example.com/p/2",
+		},
+		{
+			name: "last page",
+			doc:  `{"agentList": [{"name": "a"}], "total": 1}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := NewJSONParser().Parse(context.Background(), []byte(tt.doc), "application/json", source)
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			got := result.Pagination
+			if (got == nil) != (tt.want == nil) || got != nil && *got != *tt.want {
+				t.Fatalf("Parse() pagination = %+v, want %+v", got, tt.want)
+			}
+			if next := got.NextURL(source); next != tt.nextURL {
+				t.Errorf("NextURL() = %q, want %q", next, tt.nextURL)
+			}
+		})
+	}
+}
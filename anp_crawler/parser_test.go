@@ -1,6 +1,11 @@
 package anp_crawler
 
 import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
 	"testing"
 )
 
@@ -9,3 +14,107 @@ import (
 func TestParserPlaceholder(t *testing.T) {
 	t.Log("Parser tests to be implemented")
 }
+
+func TestJSONParser_UsesInstanceLogger(t *testing.T) {
+	var buf bytes.Buffer
+	p := &JSONParser{Logger: slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))}
+
+	if _, err := p.Parse(context.Background(), []byte(`{"hello":"world"}`), "application/json", "https://example.com/x.json"); err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if !strings.Contains(buf.String(), "unsupported document structure") {
+		t.Errorf("instance logger output = %q", buf.String())
+	}
+}
+
+func TestJSONParser_StreamingInterfaces(t *testing.T) {
+	doc := `{
+		"name": "streaming agent",
+		"servers": [{"url": "https://agent.example.com/rpc"}],
+		"interfaces": [
+			{"type": "StructuredInterface", "protocol": "SSE", "content": {
+				"openrpc": "1.3.2",
+				"methods": [{"name": "search", "params": []}]
+			}},
+			{"type": "StructuredInterface", "protocol": "streamable", "url": "https://agent.example.com/stream.json"},
+			{"type": "StructuredInterface", "protocol": "openrpc", "url": "https://agent.example.com/api.json"}
+		]
+	}`
+	result, err := NewJSONParser().Parse(context.Background(), []byte(doc), "application/json", "https://agent.example.com/ad.json")
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if len(result.Interfaces) != 3 {
+		t.Fatalf("Parse() = %d interfaces, want 3", len(result.Interfaces))
+	}
+	embedded, linked, plain := result.Interfaces[0], result.Interfaces[1], result.Interfaces[2]
+	if embedded.MethodName != "search" || !embedded.Streaming || len(embedded.ParentServers) != 1 {
+		t.Errorf("embedded SSE method = %+v", embedded)
+	}
+	if linked.URL == "" || !linked.Streaming {
+		t.Errorf("linked streamable interface = %+v", linked)
+	}
+	if plain.Streaming {
+		t.Errorf("openrpc interface marked streaming")
+	}
+}
+
+func TestJSONParser_YAML(t *testing.T) {
+	const openrpc = `# Hotel booking API
+openrpc: 1.3.2
+info: {title: hotels, version: "1.0"}
+servers:
+  - url: https://agent.example.com/rpc
+methods:
+  - name: searchHotels
+    summary: Search hotels
+    params:
+      - name: city
+        required: true
+        schema: {type: string}
+    result:
+      name: hotels
+      schema:
+        type: array
+        x-codes: {200: ok}
+`
+	tests := []struct {
+		name        string
+		contentType string
+		url         string
+		content     string
+		wantMethods int
+		wantErr     bool
+	}{
+		{"yaml content type", "application/yaml", "https://agent.example.com/api", openrpc, 1, false},
+		{"yml extension", "application/octet-stream", "https://agent.example.com/api.yml", openrpc, 1, false},
+		{"sniffed", "text/plain", "https://agent.example.com/api", openrpc, 1, false},
+		{"json", "", "https://agent.example.com/api", `{"openrpc":"1.3.2","methods":[{"name":"a"}]}`, 1, false},
+		{"html is not sniffed", "text/html", "https://agent.example.com/", "title: not yaml", 0, true},
+		{"yaml scalar", "application/yaml", "https://agent.example.com/api.yaml", "just text", 0, true},
+		{"invalid yaml", "application/yaml", "https://agent.example.com/api.yaml", "a: [b", 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := NewJSONParser().Parse(context.Background(), []byte(tt.content), tt.contentType, tt.url)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Parse() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if len(result.Interfaces) != tt.wantMethods {
+				t.Fatalf("Parse() = %d interfaces, want %d", len(result.Interfaces), tt.wantMethods)
+			}
+		})
+	}
+
+	result, _ := NewJSONParser().Parse(context.Background(), []byte(openrpc), "application/yaml", "https://agent.example.com/api")
+	entry := result.Interfaces[0]
+	if entry.MethodName != "searchHotels" || len(entry.Servers) != 1 || !strings.Contains(string(entry.Result), `"200":"ok"`) {
+		t.Errorf("Parse() entry = %+v, result %s", entry, entry.Result)
+	}
+	if err := ValidateArguments(entry, map[string]any{"city": 1}); !errors.Is(err, ErrInvalidArguments) {
+		t.Errorf("ValidateArguments() error = %v, want ErrInvalidArguments", err)
+	}
+}
@@ -0,0 +1,77 @@
+package anp_crawler
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// ProxyFunc selects the proxy for a request, as http.Transport.Proxy does: a
+// nil URL sends the request directly. http, https and socks5 proxy URLs are
+// supported.
+type ProxyFunc func(*http.Request) (*url.URL, error)
+
+// WithProxy sends requests through proxy, e.g. http.ProxyURL(u) for a single
+// proxy, http.ProxyFromEnvironment, or ProxyByHost for per-host selection.
+// The DIDWba header is still signed for the target URL, not the proxy. The
+// option applies to the client's http.Client transport, which must be nil or
+// an *http.Transport; see ProxyClient.
+func WithProxy(proxy ProxyFunc) ClientOption {
+	return func(c *httpClient) { c.proxy = proxy }
+}
+
+// ProxyByHost routes requests to the proxy configured for their hostname and
+// all others to fallback, which may be nil for a direct connection. Keys are
+// hostnames without port; a key starting with "*." also matches every
+// subdomain, with exact and longer keys taking precedence. A nil proxy value
+// forces a direct connection for that host.
+func ProxyByHost(proxies map[string]*url.URL, fallback ProxyFunc) ProxyFunc {
+	normalized := make(map[string]*url.URL, len(proxies))
+	for host, proxy := range proxies {
+		normalized[strings.ToLower(host)] = proxy
+	}
+	return func(req *http.Request) (*url.URL, error) {
+		host := strings.ToLower(req.URL.Hostname())
+		if proxy, ok := normalized[host]; ok {
+			return proxy, nil
+		}
+		for suffix := host; ; {
+			_, rest, ok := strings.Cut(suffix, ".")
+			if !ok {
+				break
+			}
+			if proxy, ok := normalized["*."+rest]; ok {
+				return proxy, nil
+			}
+			suffix = rest
+		}
+		if fallback == nil {
+			return nil, nil
+		}
+		return fallback(req)
+	}
+}
+
+// ProxyClient returns a copy of client whose transport sends requests through
+// proxy. A nil transport is replaced by a clone of http.DefaultTransport.
+// Transports other than *http.Transport cannot be configured and yield an
+// error; wrap the proxied client's transport instead.
+func ProxyClient(client *http.Client, proxy ProxyFunc) (*http.Client, error) {
+	if client == nil {
+		client = &http.Client{}
+	}
+	var transport *http.Transport
+	switch t := client.Transport.(type) {
+	case nil:
+		transport = http.DefaultTransport.(*http.Transport).Clone()
+	case *http.Transport:
+		transport = t.Clone()
+	default:
+		return nil, errors.New("proxy requires an *http.Transport")
+	}
+	transport.Proxy = proxy
+	proxied := *client
+	proxied.Transport = transport
+	return &proxied, nil
+}
@@ -0,0 +1,86 @@
+package anp_crawler
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/openanp/anp-go/anp_auth"
+	"github.com/openanp/anp-go/anptest"
+)
+
+func TestProxyByHost(t *testing.T) {
+	corp, _ := url.Parse("http://corp-proxy:3128")
+	tor, _ := url.Parse("socks5://127.0.0.1:9050")
+	fallback, _ := url.Parse("http://default-proxy:8080")
+	proxy := ProxyByHost(map[string]*url.URL{
+		"*.onion":                 tor,
+		"*.corp.example.com":      corp,
+		"direct.corp.example.com": nil,
+	}, http.ProxyURL(fallback))
+
+	tests := []struct {
+		target string
+		want   *url.URL
+	}{
+		{"http://agent.onion/ad.json", tor},
+		{"https://a.b.corp.example.com/ad.json", corp},
+		{"https://DIRECT.corp.example.com:8443/ad.json", nil},
+		{"https://corp.example.com/ad.json", fallback},
+		{"https://agent.example.org/ad.json", fallback},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, tt.target, nil)
+		got, err := proxy(req)
+		if err != nil || got != tt.want {
+			t.Errorf("proxy(%s) = %v, %v, want %v", tt.target, got, err, tt.want)
+		}
+	}
+
+	if got, _ := ProxyByHost(nil, nil)(httptest.NewRequest(http.MethodGet, "https://a.example.com/", nil)); got != nil {
+		t.Errorf("proxy without fallback = %v, want direct", got)
+	}
+}
+
+func TestClient_Proxy(t *testing.T) {
+	identity := anptest.NewIdentity(t, "client.example.com")
+	var gotURL, gotAuth string
+	proxySrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotURL, gotAuth = r.URL.String(), r.Header.Get(anp_auth.AuthorizationHeader)
+		w.Write([]byte(`{}`))
+	}))
+	defer proxySrv.Close()
+	proxyURL, _ := url.Parse(proxySrv.URL)
+
+	client := NewClient(identity.Authenticator, WithProxy(http.ProxyURL(proxyURL)))
+	if _, err := client.Fetch(context.Background(), http.MethodGet, "http://agent.example.com/ad.json", nil, nil); err != nil {
+		t.Fatalf("Fetch() error = %v", err)
+	}
+	if gotURL != "http://agent.example.com/ad.json" {
+		t.Errorf("proxy saw %q, want the absolute target URL", gotURL)
+	}
+	verifyAuthHeader(t, identity.Document, gotAuth, "agent.example.com")
+}
+
+func TestProxyClient(t *testing.T) {
+	proxy := http.ProxyURL(&url.URL{Scheme: "http", Host: "proxy:3128"})
+	base := &http.Client{Transport: &http.Transport{MaxIdleConns: 7}}
+	proxied, err := ProxyClient(base, proxy)
+	if err != nil {
+		t.Fatalf("ProxyClient() error = %v", err)
+	}
+	transport := proxied.Transport.(*http.Transport)
+	if transport.Proxy == nil || transport.MaxIdleConns != 7 || base.Transport.(*http.Transport).Proxy != nil {
+		t.Errorf("ProxyClient() did not configure a copy of the transport")
+	}
+	custom := &http.Client{Transport: roundTripFunc(http.DefaultTransport.RoundTrip)}
+	if _, err := ProxyClient(custom, proxy); err == nil {
+		t.Error("ProxyClient(custom transport) error = nil, want error")
+	}
+}
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) { return f(req) }
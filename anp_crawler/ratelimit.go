@@ -0,0 +1,103 @@
+package anp_crawler
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// maxIdleBuckets bounds how many per-host buckets are kept before idle ones
+// are dropped, so crawling many hosts does not grow the limiter forever.
+const maxIdleBuckets = 1024
+
+// WithHostRateLimit limits requests to each host to rps per second on
+// average, allowing bursts of up to burst requests (a token bucket per host).
+// Requests over the limit wait for a token or until their context ends.
+// Retries count against the limit like any other request. A non-positive rps
+// disables limiting.
+func WithHostRateLimit(rps float64, burst int) ClientOption {
+	return func(c *httpClient) {
+		if rps <= 0 {
+			c.limiter = nil
+			return
+		}
+		c.limiter = newHostLimiter(rps, burst)
+	}
+}
+
+type hostLimiter struct {
+	rate  float64 // tokens per second
+	burst float64
+	now   func() time.Time
+
+	mu      sync.Mutex
+	buckets map[string]*tokenBucket
+}
+
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+func newHostLimiter(rps float64, burst int) *hostLimiter {
+	if burst < 1 {
+		burst = 1
+	}
+	return &hostLimiter{rate: rps, burst: float64(burst), now: time.Now, buckets: make(map[string]*tokenBucket)}
+}
+
+// wait blocks until a request to host may be sent. Tokens are reserved in
+// arrival order, so concurrent callers are released one interval apart.
+func (l *hostLimiter) wait(ctx context.Context, host string) error {
+	l.mu.Lock()
+	now := l.now()
+	b, ok := l.buckets[host]
+	if !ok {
+		if len(l.buckets) >= maxIdleBuckets {
+			l.pruneLocked(now)
+		}
+		b = &tokenBucket{tokens: l.burst, last: now}
+		l.buckets[host] = b
+	}
+	l.refillLocked(b, now)
+	b.tokens--
+	delay := time.Duration(0)
+	if b.tokens < 0 {
+		delay = time.Duration(-b.tokens / l.rate * float64(time.Second))
+	}
+	l.mu.Unlock()
+
+	if delay <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		// Give the reservation back so later requests are not delayed for it.
+		l.mu.Lock()
+		b.tokens++
+		l.mu.Unlock()
+		return ctx.Err()
+	}
+}
+
+func (l *hostLimiter) refillLocked(b *tokenBucket, now time.Time) {
+	if elapsed := now.Sub(b.last); elapsed > 0 {
+		b.tokens = min(l.burst, b.tokens+elapsed.Seconds()*l.rate)
+		b.last = now
+	}
+}
+
+// pruneLocked drops buckets that have refilled completely; they behave
+// exactly like a fresh bucket.
+func (l *hostLimiter) pruneLocked(now time.Time) {
+	for host, b := range l.buckets {
+		l.refillLocked(b, now)
+		if b.tokens >= l.burst {
+			delete(l.buckets, host)
+		}
+	}
+}
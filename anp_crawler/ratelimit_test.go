@@ -0,0 +1,62 @@
+package anp_crawler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestHostLimiter(t *testing.T) {
+	l := newHostLimiter(20, 2) // one token every 50ms
+	ctx := context.Background()
+
+	start := time.Now()
+	for i := range 2 {
+		if err := l.wait(ctx, "a.example.com"); err != nil {
+			t.Fatalf("wait(%d) error = %v", i, err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed > 25*time.Millisecond {
+		t.Errorf("burst took %v, want immediate", elapsed)
+	}
+
+	if err := l.wait(ctx, "b.example.com"); err != nil || time.Since(start) > 25*time.Millisecond {
+		t.Errorf("other host waited %v (error %v), want immediate", time.Since(start), err)
+	}
+
+	if err := l.wait(ctx, "a.example.com"); err != nil {
+		t.Fatalf("wait() error = %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < 40*time.Millisecond {
+		t.Errorf("request over the burst took %v, want about 50ms", elapsed)
+	}
+
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	if err := l.wait(cancelled, "a.example.com"); !errors.Is(err, context.Canceled) {
+		t.Errorf("wait(cancelled) error = %v, want context.Canceled", err)
+	}
+	l.mu.Lock()
+	tokens := l.buckets["a.example.com"].tokens
+	l.mu.Unlock()
+	if tokens < -0.01 {
+		t.Errorf("tokens after cancelled wait = %v, want reservation returned", tokens)
+	}
+}
+
+func TestHostLimiter_PrunesIdleBuckets(t *testing.T) {
+	l := newHostLimiter(1000, 1)
+	now := time.Now()
+	l.now = func() time.Time { return now }
+	for i := range maxIdleBuckets {
+		l.buckets[fmt.Sprintf("host%d.example.com", i)] = &tokenBucket{tokens: 1, last: now}
+	}
+	if err := l.wait(context.Background(), "new.example.com"); err != nil {
+		t.Fatalf("wait() error = %v", err)
+	}
+	if len(l.buckets) != 1 {
+		t.Errorf("buckets = %d, want idle buckets pruned", len(l.buckets))
+	}
+}
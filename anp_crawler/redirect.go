@@ -0,0 +1,95 @@
+package anp_crawler
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/openanp/anp-go/anp_auth"
+)
+
+// defaultMaxRedirects matches net/http's own redirect limit.
+const defaultMaxRedirects = 10
+
+// RedirectPolicy controls how the default Client follows redirects. DIDWba
+// headers are signed for one domain, so the client signs a new header for
+// every host it is redirected to instead of replaying the original one.
+type RedirectPolicy struct {
+	// MaxRedirects stops following redirects after this many hops. Zero uses
+	// the default of 10; a negative value disables redirects, returning the
+	// redirect response itself.
+	MaxRedirects int
+	// StripCrossOrigin sends no Authorization header after a redirect to a
+	// different origin (scheme, host or port), so the caller's DID is only
+	// disclosed to the origin it asked for.
+	StripCrossOrigin bool
+}
+
+// WithRedirectPolicy sets how redirects are followed. Without it the client
+// follows up to 10 redirects and re-authenticates to each new host. A
+// CheckRedirect set on the http.Client still runs after the policy.
+func WithRedirectPolicy(p RedirectPolicy) ClientOption {
+	return func(c *httpClient) { c.redirect = p }
+}
+
+// redirectClient returns a copy of client whose redirects are handled by
+// checkRedirect, keeping any CheckRedirect the caller configured.
+func (c *httpClient) redirectClient(client *http.Client) *http.Client {
+	next := client.CheckRedirect
+	wrapped := *client
+	wrapped.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		if err := c.checkRedirect(req, via); err != nil {
+			return err
+		}
+		if next != nil {
+			return next(req, via)
+		}
+		return nil
+	}
+	return &wrapped
+}
+
+func (c *httpClient) checkRedirect(req *http.Request, via []*http.Request) error {
+	maxRedirects := c.redirect.MaxRedirects
+	if maxRedirects == 0 {
+		maxRedirects = defaultMaxRedirects
+	}
+	if maxRedirects < 0 {
+		return http.ErrUseLastResponse
+	}
+	if len(via) > maxRedirects {
+		return fmt.Errorf("stopped after %d redirects", maxRedirects)
+	}
+
+	// net/http copies the original headers onto the redirect, dropping
+	// Authorization only for hosts outside the original domain.
+	req.Header.Del(anp_auth.AuthorizationHeader)
+	if !sameOrigin(via[0], req) && c.redirect.StripCrossOrigin {
+		return nil
+	}
+	authHeader, err := c.authenticator.GenerateHeader(req.URL.String())
+	if err != nil {
+		return fmt.Errorf("auth header for redirect to %s: %w", req.URL.Host, err)
+	}
+	for k, v := range authHeader {
+		req.Header.Set(k, v)
+	}
+	return nil
+}
+
+// sameOrigin reports whether a and b share scheme, host and port.
+func sameOrigin(a, b *http.Request) bool {
+	return strings.EqualFold(a.URL.Scheme, b.URL.Scheme) &&
+		strings.EqualFold(a.URL.Hostname(), b.URL.Hostname()) &&
+		effectivePort(a) == effectivePort(b)
+}
+
+func effectivePort(req *http.Request) string {
+	if port := req.URL.Port(); port != "" {
+		return port
+	}
+	if strings.EqualFold(req.URL.Scheme, "https") {
+		return "443"
+	}
+	return "80"
+}
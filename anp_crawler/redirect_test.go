@@ -0,0 +1,112 @@
+package anp_crawler
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/bytedance/sonic"
+	"github.com/openanp/anp-go/anp_auth"
+	"github.com/openanp/anp-go/anptest"
+)
+
+func TestClient_Redirect(t *testing.T) {
+	identity := anptest.NewIdentity(t, "client.example.com")
+	var finalAuth string
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		finalAuth = r.Header.Get(anp_auth.AuthorizationHeader)
+		w.Write([]byte(`{}`))
+	}))
+	defer target.Close()
+	// Serve the redirect from 127.0.0.1 and the target from localhost so the
+	// redirect changes domain.
+	crossOrigin := strings.Replace(target.URL, "127.0.0.1", "localhost", 1) + "/ad.json"
+
+	var firstAuth string
+	origin := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/moved":
+			firstAuth = r.Header.Get(anp_auth.AuthorizationHeader)
+			http.Redirect(w, r, crossOrigin, http.StatusFound)
+		case "/renamed":
+			firstAuth = r.Header.Get(anp_auth.AuthorizationHeader)
+			http.Redirect(w, r, "/ad.json", http.StatusMovedPermanently)
+		default:
+			finalAuth = r.Header.Get(anp_auth.AuthorizationHeader)
+			w.Write([]byte(`{}`))
+		}
+	}))
+	defer origin.Close()
+	// DIDWba headers are signed for the host including its port.
+	targetURL, _ := url.Parse(crossOrigin)
+	originURL, _ := url.Parse(origin.URL)
+	targetHost, originHost := targetURL.Host, originURL.Host
+
+	tests := []struct {
+		name       string
+		policy     RedirectPolicy
+		path       string
+		wantStatus int
+		wantDomain string
+	}{
+		{"cross origin re-signed", RedirectPolicy{}, "/moved", http.StatusOK, targetHost},
+		{"cross origin stripped", RedirectPolicy{StripCrossOrigin: true}, "/moved", http.StatusOK, ""},
+		{"same origin re-signed", RedirectPolicy{StripCrossOrigin: true}, "/renamed", http.StatusOK, originHost},
+		{"redirects disabled", RedirectPolicy{MaxRedirects: -1}, "/moved", http.StatusFound, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			firstAuth, finalAuth = "", ""
+			client := NewClient(identity.Authenticator, WithRedirectPolicy(tt.policy))
+			resp, err := client.Fetch(context.Background(), http.MethodGet, origin.URL+tt.path, nil, nil)
+			if err != nil {
+				t.Fatalf("Fetch() error = %v", err)
+			}
+			if resp.StatusCode != tt.wantStatus {
+				t.Fatalf("Fetch() status = %d, want %d", resp.StatusCode, tt.wantStatus)
+			}
+			if tt.wantDomain == "" {
+				if finalAuth != "" {
+					t.Errorf("redirect target got Authorization %q, want none", finalAuth)
+				}
+				return
+			}
+			if tt.wantDomain != originHost && finalAuth == firstAuth {
+				t.Error("redirect replayed the original Authorization header")
+			}
+			verifyAuthHeader(t, identity.Document, finalAuth, tt.wantDomain)
+		})
+	}
+
+	client := NewClient(identity.Authenticator, WithRedirectPolicy(RedirectPolicy{MaxRedirects: 1}))
+	loop := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/again", http.StatusFound)
+	}))
+	defer loop.Close()
+	if _, err := client.Fetch(context.Background(), http.MethodGet, loop.URL, nil, nil); err == nil {
+		t.Error("Fetch(redirect loop) error = nil, want error")
+	}
+}
+
+// verifyAuthHeader checks that authorization is a DIDWba header from doc
+// signed for domain, as the receiving server would.
+func verifyAuthHeader(t *testing.T, doc *anp_auth.DIDWBADocument, authorization, domain string) {
+	t.Helper()
+	header, err := anp_auth.ParseAuthHeader(authorization)
+	if err != nil {
+		t.Fatalf("ParseAuthHeader(%q) error = %v", authorization, err)
+	}
+	// Verify against the published form of the document.
+	raw, _ := sonic.Marshal(doc)
+	var published anp_auth.DIDWBADocument
+	if err := sonic.Unmarshal(raw, &published); err != nil {
+		t.Fatalf("decode DID document: %v", err)
+	}
+	authJSON := &anp_auth.AuthJSON{DID: header.DID, Nonce: header.Nonce, Timestamp: header.Timestamp, VerificationMethod: header.VerificationMethod, Signature: header.Signature}
+	if ok, msg := anp_auth.VerifyAuthJSON(authJSON, &published, domain); !ok {
+		t.Errorf("DIDWba header not signed for %s: %s", domain, msg)
+	}
+}
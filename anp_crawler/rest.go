@@ -0,0 +1,113 @@
+package anp_crawler
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"mime"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/bytedance/sonic"
+)
+
+// executeREST calls an OpenAPI operation: path arguments fill the {name}
+// templates of Entry.Path, query and header arguments are sent as such, and
+// the "body" argument becomes the JSON request body. Arguments without a
+// declared location are sent in the query. The response body is returned
+// under "result", as for JSON-RPC calls, decoded when it is JSON, and the
+// HTTP status code under "status".
+func (i *ANPInterface) executeREST(ctx context.Context, arguments map[string]any) (map[string]any, error) {
+	serverURL, args, err := i.prepare(arguments)
+	if err != nil {
+		return nil, err
+	}
+	method := i.Entry.HTTPMethod
+	if method == "" {
+		method = http.MethodGet
+	}
+
+	path := i.Entry.Path
+	query := url.Values{}
+	headers := map[string]string{"Accept": "application/json"}
+	var body any
+	for _, name := range slices.Sorted(maps.Keys(args)) {
+		value := args[name]
+		switch i.Entry.ParamLocations[name] {
+		case "path":
+			path = strings.ReplaceAll(path, "{"+name+"}", url.PathEscape(paramString(value)))
+		case "header":
+			headers[name] = paramString(value)
+		case "body":
+			body = value
+		default:
+			if items, ok := value.([]any); ok {
+				for _, item := range items {
+					query.Add(name, paramString(item))
+				}
+				continue
+			}
+			query.Set(name, paramString(value))
+		}
+	}
+	if strings.Contains(path, "{") {
+		return nil, fmt.Errorf("missing path parameter for tool %s: %s", i.ToolName, path)
+	}
+
+	target := strings.TrimSuffix(serverURL, "/") + path
+	if len(query) > 0 {
+		target += "?" + query.Encode()
+	}
+	if body != nil {
+		headers["Content-Type"] = "application/json"
+	}
+
+	loggerOr(i.Logger).Debug("executing REST tool call", "tool", i.ToolName, "method", method, "url", target)
+
+	resp, err := i.Client.Fetch(ctx, method, target, headers, body)
+	if err != nil {
+		return nil, fmt.Errorf("HTTP request failed for tool %s to %s: %w", i.ToolName, target, err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("HTTP %d: %s", resp.StatusCode, http.StatusText(resp.StatusCode))
+	}
+
+	var result any
+	if len(resp.Body) > 0 {
+		result = string(resp.Body)
+		mediaType, _, _ := mime.ParseMediaType(resp.ContentType)
+		if resp.ContentType == "" || strings.Contains(mediaType, "json") {
+			var decoded any
+			if err := sonic.Unmarshal(resp.Body, &decoded); err == nil {
+				result = decoded
+			} else if resp.ContentType != "" {
+				return nil, fmt.Errorf("failed to parse JSON response for tool %s from %s: %w", i.ToolName, target, err)
+			}
+		}
+	}
+	return map[string]any{"result": result, "status": resp.StatusCode}, nil
+}
+
+// paramString formats an argument for a URL or header, keeping integers
+// free of exponents and encoding objects as JSON.
+func paramString(v any) string {
+	switch v := v.(type) {
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case nil:
+		return ""
+	case bool, int, int64:
+		return fmt.Sprint(v)
+	default:
+		data, err := sonic.MarshalString(v)
+		if err != nil {
+			return fmt.Sprint(v)
+		}
+		return data
+	}
+}
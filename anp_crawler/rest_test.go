@@ -0,0 +1,94 @@
+package anp_crawler
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/openanp/anp-go/anptest"
+)
+
+func TestANPInterface_ExecuteREST(t *testing.T) {
+	var got []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		got = append(got, fmt.Sprintf("%s %s %s %s", r.Method, r.URL.RequestURI(), r.Header.Get("X-Request-Id"), body))
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/missing"):
+			w.WriteHeader(http.StatusNotFound)
+		case r.Method == http.MethodPost:
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte(`{"id":"b1"}`))
+		default:
+			w.Header().Set("Content-Type", "application/json; charset=utf-8")
+			w.Write([]byte(`{"name":"Grand"}`))
+		}
+	}))
+	defer srv.Close()
+
+	result, err := NewJSONParser().Parse(context.Background(), []byte(testOpenAPIDoc), "application/yaml", srv.URL+"/openapi.yaml")
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	client := NewClient(anptest.NewIdentity(t, "client.example.com").Authenticator)
+	get := NewANPInterface("getHotel", result.Interfaces[0], client)
+	post := NewANPInterface("book", result.Interfaces[1], client)
+	post.Servers = []Server{{URL: srv.URL + "/v1/"}}
+
+	tests := []struct {
+		name       string
+		iface      *ANPInterface
+		args       map[string]any
+		wantReq    string
+		wantResult string
+		wantErr    bool
+	}{
+		{
+			name:       "path and query",
+			iface:      get,
+			args:       map[string]any{"hotelId": "a b", "lang": "en", "extra": []any{1.0, 2.5}},
+			wantReq:    "GET /v1/hotels/a%20b?extra=1&extra=2.5&lang=en  ",
+			wantResult: "map[name:Grand]",
+		},
+		{
+			name:       "header and body",
+			iface:      post,
+			args:       map[string]any{"hotelId": "h1", "X-Request-Id": "r-1", "body": `{"nights":2}`},
+			wantReq:    `POST /v1/hotels/h1/bookings r-1 {"nights":2}`,
+			wantResult: "map[id:b1]",
+		},
+		{
+			name:    "invalid arguments",
+			iface:   post,
+			args:    map[string]any{"hotelId": "h1"},
+			wantErr: true,
+		},
+		{
+			name:    "error status",
+			iface:   get,
+			args:    map[string]any{"hotelId": "missing"},
+			wantReq: "GET /v1/hotels/missing  ",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got = nil
+			resp, err := tt.iface.Execute(context.Background(), tt.args)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Execute() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantReq != "" && (len(got) != 1 || got[0] != tt.wantReq) {
+				t.Errorf("server saw %q, want %q", got, tt.wantReq)
+			}
+			if !tt.wantErr && fmt.Sprint(resp["result"]) != tt.wantResult {
+				t.Errorf("Execute() = %v, want result %s", resp, tt.wantResult)
+			}
+		})
+	}
+}
@@ -0,0 +1,219 @@
+package anp_crawler
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// EventStreamType is the media type of Server-Sent Event responses.
+const EventStreamType = "text/event-stream"
+
+// StreamClient is a Client that can also consume responses incrementally.
+// The Client returned by NewClient implements it.
+type StreamClient interface {
+	Client
+	// FetchStream sends a request like Fetch but returns as soon as the
+	// response headers arrive, so events can be handled while the server is
+	// still producing them.
+	FetchStream(ctx context.Context, method, target string, headers map[string]string, body any) (*EventStream, error)
+}
+
+var _ StreamClient = (*httpClient)(nil)
+
+// Event is one server-sent event.
+type Event struct {
+	// ID is the event's id field, or the last ID seen on the stream.
+	ID string
+	// Event is the event type; empty means "message".
+	Event string
+	Data  string
+	// Retry is the reconnection delay the server asked for, if any.
+	Retry time.Duration
+}
+
+// EventStream reads the events of one streaming response. A response that is
+// not text/event-stream, such as a plain JSON reply from a server without
+// streaming support, is read whole and yielded as a single event. It is not
+// safe for concurrent use, and must be closed.
+type EventStream struct {
+	StatusCode  int
+	URL         string
+	ContentType string
+	Header      http.Header
+
+	body     io.ReadCloser
+	decoded  io.Closer
+	reader   *bufio.Reader
+	sse      bool
+	maxEvent int64
+	lastID   string
+	cancel   context.CancelFunc
+	done     bool
+}
+
+// FetchStream implements StreamClient. The http.Client timeout and
+// WithRequestTimeout do not apply to streams, which may legitimately stay
+// open for a long time; bound them with ctx instead. With WithMaxBodySize
+// the limit applies to each event rather than the whole stream.
+func (c *httpClient) FetchStream(ctx context.Context, method, target string, headers map[string]string, body any) (*EventStream, error) {
+	if method == "" {
+		method = http.MethodGet
+	}
+	reqHeaders, newBody, err := requestParts(headers, body)
+	if err != nil {
+		return nil, err
+	}
+	if !hasHeader(reqHeaders, "Accept") {
+		reqHeaders["Accept"] = EventStreamType
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	streaming := *c.httpClient
+	streaming.Timeout = 0
+	resp, err := c.send(ctx, &streaming, method, target, reqHeaders, newBody)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	decoded, _, err := decodeBody(resp, resp.Body)
+	if err != nil {
+		resp.Body.Close()
+		cancel()
+		return nil, err
+	}
+
+	contentType := resp.Header.Get("Content-Type")
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	return &EventStream{
+		StatusCode:  resp.StatusCode,
+		URL:         target,
+		ContentType: contentType,
+		Header:      resp.Header.Clone(),
+		body:        resp.Body,
+		decoded:     decoded,
+		reader:      bufio.NewReader(decoded),
+		sse:         mediaType == EventStreamType,
+		maxEvent:    c.maxBodySize,
+		cancel:      cancel,
+	}, nil
+}
+
+// Next returns the next event, or io.EOF once the stream has ended.
+func (s *EventStream) Next() (*Event, error) {
+	if s.done {
+		return nil, io.EOF
+	}
+	if !s.sse {
+		s.done = true
+		data, err := s.readAll()
+		if err != nil {
+			return nil, err
+		}
+		return &Event{Data: string(data)}, nil
+	}
+
+	var (
+		ev      Event
+		data    strings.Builder
+		hasData bool
+	)
+	for {
+		line, err := s.reader.ReadString('\n')
+		if err != nil && !(errors.Is(err, io.EOF) && line != "") {
+			if errors.Is(err, io.EOF) {
+				s.done = true
+				return nil, io.EOF
+			}
+			return nil, fmt.Errorf("read event stream: %w", err)
+		}
+		line = strings.TrimRight(line, "\r\n")
+		if line == "" {
+			if !hasData {
+				// Blank lines without data dispatch nothing.
+				ev = Event{}
+				continue
+			}
+			ev.ID = s.lastID
+			ev.Data = data.String()
+			return &ev, nil
+		}
+
+		field, value, _ := strings.Cut(line, ":")
+		value = strings.TrimPrefix(value, " ")
+		switch field {
+		case "":
+			// Comment, e.g. a heartbeat.
+		case "data":
+			if hasData {
+				data.WriteByte('\n')
+			}
+			data.WriteString(value)
+			hasData = true
+			if s.maxEvent > 0 && int64(data.Len()) > s.maxEvent {
+				s.done = true
+				return nil, fmt.Errorf("%w: %s event exceeds %d bytes", ErrBodyTooLarge, s.URL, s.maxEvent)
+			}
+		case "event":
+			ev.Event = value
+		case "id":
+			if !strings.ContainsRune(value, 0) {
+				s.lastID = value
+			}
+		case "retry":
+			if ms, err := strconv.Atoi(value); err == nil && ms >= 0 {
+				ev.Retry = time.Duration(ms) * time.Millisecond
+			}
+		}
+	}
+}
+
+// Events iterates over the remaining events. Iteration stops at the end of the
+// stream or after yielding the first read error.
+func (s *EventStream) Events() iter.Seq2[*Event, error] {
+	return func(yield func(*Event, error) bool) {
+		for {
+			ev, err := s.Next()
+			if errors.Is(err, io.EOF) {
+				return
+			}
+			if !yield(ev, err) || err != nil {
+				return
+			}
+		}
+	}
+}
+
+// LastEventID returns the last event ID the server sent, for resuming the
+// stream with a Last-Event-ID header.
+func (s *EventStream) LastEventID() string { return s.lastID }
+
+// Close releases the connection; pending reads fail.
+func (s *EventStream) Close() error {
+	s.done = true
+	s.cancel()
+	return errors.Join(s.decoded.Close(), s.body.Close())
+}
+
+func (s *EventStream) readAll() ([]byte, error) {
+	r := io.Reader(s.reader)
+	if s.maxEvent > 0 {
+		r = io.LimitReader(r, s.maxEvent+1)
+	}
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("read response body: %w", err)
+	}
+	if s.maxEvent > 0 && int64(len(data)) > s.maxEvent {
+		return nil, fmt.Errorf("%w: %s exceeds %d bytes", ErrBodyTooLarge, s.URL, s.maxEvent)
+	}
+	return data, nil
+}
@@ -0,0 +1,181 @@
+package anp_crawler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bytedance/sonic"
+	"github.com/openanp/anp-go/anp_auth"
+	"github.com/openanp/anp-go/anptest"
+)
+
+func TestClient_FetchStream(t *testing.T) {
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get(anp_auth.AuthorizationHeader) == "" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if r.URL.Path == "/plain" {
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"result":"done"}`))
+			return
+		}
+		if got := r.Header.Get("Accept"); got != EventStreamType {
+			t.Errorf("Accept = %q, want %q", got, EventStreamType)
+		}
+		w.Header().Set("Content-Type", "text/event-stream; charset=utf-8")
+		fmt.Fprint(w, ": connected\n\nid: 1\nevent: progress\ndata: {\"step\":1}\n\n")
+		w.(http.Flusher).Flush()
+		// The first event must reach the client before the body is complete.
+		<-release
+		fmt.Fprint(w, "retry: 1500\ndata: line one\r\ndata: line two\n\nid: 2\ndata: last\n\n")
+	}))
+	defer srv.Close()
+	client := NewClient(anptest.NewIdentity(t, "client.example.com").Authenticator).(StreamClient)
+
+	stream, err := client.FetchStream(context.Background(), http.MethodGet, srv.URL+"/events", nil, nil)
+	if err != nil {
+		t.Fatalf("FetchStream() error = %v", err)
+	}
+	defer stream.Close()
+	if stream.StatusCode != http.StatusOK {
+		t.Fatalf("FetchStream() status = %d", stream.StatusCode)
+	}
+	first, err := stream.Next()
+	if err != nil {
+		t.Fatalf("Next() error = %v", err)
+	}
+	close(release)
+	want := Event{ID: "1", Event: "progress", Data: `{"step":1}`}
+	if *first != want {
+		t.Errorf("Next() = %+v, want %+v", *first, want)
+	}
+
+	var rest []Event
+	for ev, err := range stream.Events() {
+		if err != nil {
+			t.Fatalf("Events() error = %v", err)
+		}
+		rest = append(rest, *ev)
+	}
+	wantRest := []Event{
+		{ID: "1", Data: "line one\nline two", Retry: 1500 * time.Millisecond},
+		{ID: "2", Data: "last"},
+	}
+	if fmt.Sprint(rest) != fmt.Sprint(wantRest) {
+		t.Errorf("Events() = %+v, want %+v", rest, wantRest)
+	}
+	if _, err := stream.Next(); !errors.Is(err, io.EOF) {
+		t.Errorf("Next() after end error = %v, want io.EOF", err)
+	}
+	if stream.LastEventID() != "2" {
+		t.Errorf("LastEventID() = %q, want 2", stream.LastEventID())
+	}
+
+	plain, err := client.FetchStream(context.Background(), http.MethodPost, srv.URL+"/plain", nil, map[string]any{"q": 1})
+	if err != nil {
+		t.Fatalf("FetchStream(plain) error = %v", err)
+	}
+	defer plain.Close()
+	ev, err := plain.Next()
+	if err != nil || ev.Data != `{"result":"done"}` {
+		t.Errorf("Next() on JSON response = %+v, %v", ev, err)
+	}
+	if _, err := plain.Next(); !errors.Is(err, io.EOF) {
+		t.Errorf("second Next() on JSON response error = %v, want io.EOF", err)
+	}
+}
+
+func TestClient_FetchStreamEventLimit(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", EventStreamType)
+		fmt.Fprint(w, "data: small\n\ndata: this event is too large\n\n")
+	}))
+	defer srv.Close()
+	client := NewClient(anptest.NewIdentity(t, "client.example.com").Authenticator, WithMaxBodySize(10)).(StreamClient)
+
+	stream, err := client.FetchStream(context.Background(), "", srv.URL, nil, nil)
+	if err != nil {
+		t.Fatalf("FetchStream() error = %v", err)
+	}
+	defer stream.Close()
+	if ev, err := stream.Next(); err != nil || ev.Data != "small" {
+		t.Fatalf("Next() = %+v, %v", ev, err)
+	}
+	if _, err := stream.Next(); !errors.Is(err, ErrBodyTooLarge) {
+		t.Errorf("Next() error = %v, want ErrBodyTooLarge", err)
+	}
+}
+
+// fetchOnly hides FetchStream from the client it wraps.
+type fetchOnly struct{ Client }
+
+func TestANPInterface_ExecuteStream(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req map[string]any
+		if err := sonic.ConfigDefault.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("decode request: %v", err)
+		}
+		id, _ := sonic.MarshalString(req["id"])
+		if r.URL.Query().Has("json") || !strings.Contains(r.Header.Get("Accept"), EventStreamType) {
+			fmt.Fprintf(w, `{"jsonrpc":"2.0","id":%s,"result":{"hotels":2}}`, id)
+			return
+		}
+		w.Header().Set("Content-Type", EventStreamType)
+		fmt.Fprint(w, `data: {"jsonrpc":"2.0","method":"progress","params":{"done":1}}`+"\n\n")
+		fmt.Fprint(w, `data: {"jsonrpc":"2.0","method":"progress","params":{"done":2}}`+"\n\n")
+		if r.URL.Query().Has("fail") {
+			fmt.Fprintf(w, `data: {"jsonrpc":"2.0","id":%s,"error":{"code":-32000,"message":"sold out"}}`+"\n\n", id)
+			return
+		}
+		fmt.Fprintf(w, `data: {"jsonrpc":"2.0","id":%s,"result":{"hotels":2}}`+"\n\n", id)
+	}))
+	defer srv.Close()
+	client := NewClient(anptest.NewIdentity(t, "client.example.com").Authenticator)
+
+	tests := []struct {
+		name       string
+		query      string
+		client     Client
+		wantChunks int
+		wantErr    bool
+	}{
+		{"streamed", "", client, 2, false},
+		{"json reply", "?json", client, 0, false},
+		{"error response", "?fail", client, 2, true},
+		{"non-streaming client", "", fetchOnly{client}, 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entry := InterfaceEntry{Type: "openrpc_method", MethodName: "search", Streaming: true, Servers: []Server{{URL: srv.URL + tt.query}}}
+			var chunks []map[string]any
+			resp, err := NewANPInterface("search", entry, tt.client).ExecuteStream(context.Background(), nil, func(chunk map[string]any) error {
+				chunks = append(chunks, chunk)
+				return nil
+			})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ExecuteStream() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if len(chunks) != tt.wantChunks {
+				t.Errorf("ExecuteStream() chunks = %v, want %d", chunks, tt.wantChunks)
+			}
+			if !tt.wantErr && fmt.Sprint(resp["result"]) != "map[hotels:2]" {
+				t.Errorf("ExecuteStream() = %v", resp)
+			}
+		})
+	}
+
+	stop := errors.New("stop")
+	entry := InterfaceEntry{MethodName: "search", Servers: []Server{{URL: srv.URL}}}
+	if _, err := NewANPInterface("search", entry, client).ExecuteStream(context.Background(), nil, func(map[string]any) error { return stop }); !errors.Is(err, stop) {
+		t.Errorf("ExecuteStream() error = %v, want callback error", err)
+	}
+}
@@ -0,0 +1,62 @@
+package anp_crawler
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/bytedance/sonic"
+	"github.com/openanp/anp-go/anp_schema"
+)
+
+// ErrInvalidArguments is returned by ValidateArguments and Execute when tool
+// arguments do not match the params schema the interface declares.
+var ErrInvalidArguments = errors.New("invalid tool arguments")
+
+// ValidateArguments checks arguments against the params declared by entry and
+// lists every missing or mistyped field, so bad arguments (typically from an
+// LLM) are caught before a network call. Entries that declare no params
+// schema accept any arguments.
+func ValidateArguments(entry InterfaceEntry, arguments map[string]any) error {
+	schema, err := paramsSchema(entry)
+	if err != nil || schema == nil {
+		return nil
+	}
+	if arguments == nil {
+		arguments = map[string]any{}
+	}
+	data, err := sonic.Marshal(arguments)
+	if err != nil {
+		return fmt.Errorf("%w for %s: %v", ErrInvalidArguments, entry.MethodName, err)
+	}
+	if err := anp_schema.ValidateAgainst(schema, data); err != nil {
+		return fmt.Errorf("%w for %s: %w", ErrInvalidArguments, entry.MethodName, err)
+	}
+	return nil
+}
+
+// paramsSchema returns entry's params as one object schema, carrying the
+// OpenRPC components so that $ref pointers into them resolve. It is nil when
+// entry declares nothing to validate against.
+func paramsSchema(entry InterfaceEntry) ([]byte, error) {
+	if len(entry.Params) == 0 {
+		return nil, nil
+	}
+	tool, err := NewANPInterfaceConverter().ConvertToANPTool(entry)
+	if err != nil || tool == nil {
+		return nil, err
+	}
+	params := tool.Function.Parameters
+	if len(params.Properties) == 0 && len(params.Required) == 0 {
+		return nil, nil
+	}
+	schema := map[string]any{
+		"type":       params.Type,
+		"properties": params.Properties,
+		"required":   params.Required,
+	}
+	var components any
+	if len(entry.Components) > 0 && sonic.Unmarshal(entry.Components, &components) == nil && components != nil {
+		schema["components"] = components
+	}
+	return sonic.Marshal(schema)
+}
@@ -0,0 +1,93 @@
+package anp_crawler
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+type countingClient struct{ calls int }
+
+func (c *countingClient) Fetch(context.Context, string, string, map[string]string, any) (*Response, error) {
+	c.calls++
+	return &Response{StatusCode: 200, Body: []byte(`{"jsonrpc":"2.0","id":"1","result":true}`)}, nil
+}
+
+func TestValidateArguments(t *testing.T) {
+	openrpc := InterfaceEntry{
+		Type:       "openrpc_method",
+		MethodName: "bookRoom",
+		Params: []byte(`[
+			{"name": "city", "required": true, "schema": {"type": "string"}},
+			{"name": "nights", "required": true, "schema": {"type": "integer", "minimum": 1}},
+			{"name": "room", "schema": {"$ref": "#/components/schemas/Room"}}
+		]`),
+		Components: []byte(`{"schemas": {"Room": {"type": "string", "enum": ["single", "double"]}}}`),
+	}
+	mcp := InterfaceEntry{
+		Type:       "mcp_tool",
+		MethodName: "web.search",
+		Params:     []byte(`{"type": "object", "properties": {"q": {"type": "string"}}, "required": ["q"]}`),
+	}
+
+	tests := []struct {
+		name      string
+		entry     InterfaceEntry
+		args      map[string]any
+		wantError []string
+	}{
+		{"valid", openrpc, map[string]any{"city": "Paris", "nights": 2, "room": "double"}, nil},
+		{"missing and mistyped", openrpc, map[string]any{"nights": "two"}, []string{`missing required property "city"`, "/nights: expected integer"}},
+		{"below minimum", openrpc, map[string]any{"city": "Paris", "nights": 0}, []string{"/nights: must be >= 1"}},
+		{"component ref", openrpc, map[string]any{"city": "Paris", "nights": 1, "room": "suite"}, []string{"/room: must be one of"}},
+		{"nil arguments", mcp, nil, []string{`missing required property "q"`}},
+		{"mcp valid", mcp, map[string]any{"q": "hotels"}, nil},
+		{"no params schema", InterfaceEntry{Type: "openrpc_method", MethodName: "ping"}, map[string]any{"x": 1}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateArguments(tt.entry, tt.args)
+			if len(tt.wantError) == 0 {
+				if err != nil {
+					t.Fatalf("ValidateArguments() error = %v", err)
+				}
+				return
+			}
+			if !errors.Is(err, ErrInvalidArguments) {
+				t.Fatalf("ValidateArguments() error = %v, want ErrInvalidArguments", err)
+			}
+			for _, want := range tt.wantError {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("ValidateArguments() error = %v, want %q", err, want)
+				}
+			}
+		})
+	}
+}
+
+func TestExecuteValidatesArguments(t *testing.T) {
+	client := &countingClient{}
+	entry := InterfaceEntry{
+		Type:       "openrpc_method",
+		MethodName: "bookRoom",
+		Params:     []byte(`[{"name": "city", "required": true, "schema": {"type": "string"}}]`),
+		Servers:    []Server{{URL: "https://hotel.example.com/rpc"}},
+	}
+	iface := NewANPInterface("bookRoom", entry, client)
+
+	if _, err := iface.Execute(context.Background(), map[string]any{"city": 42}); !errors.Is(err, ErrInvalidArguments) {
+		t.Fatalf("Execute() error = %v, want ErrInvalidArguments", err)
+	}
+	if client.calls != 0 {
+		t.Fatalf("client calls = %d, want 0", client.calls)
+	}
+
+	iface.SkipValidation = true
+	if _, err := iface.Execute(context.Background(), map[string]any{"city": 42}); err != nil {
+		t.Fatalf("Execute(SkipValidation) error = %v", err)
+	}
+	if client.calls != 1 {
+		t.Errorf("client calls = %d, want 1", client.calls)
+	}
+}
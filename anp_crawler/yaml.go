@@ -0,0 +1,93 @@
+package anp_crawler
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/url"
+	"path"
+	"strings"
+
+	"github.com/bytedance/sonic"
+	"gopkg.in/yaml.v3"
+)
+
+// isYAML reports whether content is a YAML document: its content type or
+// URL says so, or it is served without a JSON or HTML content type and starts
+// like a YAML mapping rather than a JSON value.
+func isYAML(content []byte, contentType, sourceURL string) bool {
+	ct := strings.ToLower(contentType)
+	if strings.Contains(ct, "yaml") {
+		return true
+	}
+	if strings.Contains(ct, "json") || strings.Contains(ct, "html") {
+		return false
+	}
+	if u, err := url.Parse(sourceURL); err == nil {
+		switch strings.ToLower(path.Ext(u.Path)) {
+		case ".yaml", ".yml":
+			return true
+		case ".json":
+			return false
+		}
+	}
+
+	content = bytes.TrimLeft(bytes.TrimPrefix(content, []byte("\xef\xbb\xbf")), " \t\r\n")
+	if len(content) == 0 || content[0] == '{' || content[0] == '[' {
+		return false
+	}
+	if bytes.HasPrefix(content, []byte("---")) || bytes.HasPrefix(content, []byte("%YAML")) {
+		return true
+	}
+	// Skip leading comments, then look for a "key:" line.
+	for len(content) > 0 {
+		line, rest, _ := bytes.Cut(content, []byte("\n"))
+		line = bytes.TrimSpace(line)
+		if len(line) > 0 && line[0] != '#' {
+			key, _, found := bytes.Cut(line, []byte(":"))
+			return found && len(key) > 0 && !bytes.ContainsAny(key, "{}[]<>")
+		}
+		content = rest
+	}
+	return false
+}
+
+// yamlToJSON decodes a YAML document, such as an OpenRPC or OpenAPI document,
+// and re-encodes it as JSON for the JSON extraction path.
+func yamlToJSON(content []byte) ([]byte, error) {
+	var doc any
+	if err := yaml.Unmarshal(content, &doc); err != nil {
+		return nil, err
+	}
+	if _, ok := doc.(map[string]any); !ok {
+		if _, ok := doc.(map[any]any); !ok {
+			return nil, errors.New("document is not a YAML mapping")
+		}
+	}
+	return sonic.Marshal(jsonValue(doc))
+}
+
+// jsonValue converts mappings with non-string keys, which YAML allows and
+// JSON does not, into string-keyed maps.
+func jsonValue(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		for k, item := range v {
+			v[k] = jsonValue(item)
+		}
+		return v
+	case map[any]any:
+		m := make(map[string]any, len(v))
+		for k, item := range v {
+			m[fmt.Sprint(k)] = jsonValue(item)
+		}
+		return m
+	case []any:
+		for i, item := range v {
+			v[i] = jsonValue(item)
+		}
+		return v
+	default:
+		return v
+	}
+}
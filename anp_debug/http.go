@@ -0,0 +1,317 @@
+package anp_debug
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/openanp/anp-go/anp_auth"
+)
+
+const redacted = "[REDACTED]"
+
+// Exchange is one recorded HTTP request and its response.
+type Exchange struct {
+	Seq        uint64       `json:"seq"`
+	Kind       string       `json:"kind"`
+	Time       time.Time    `json:"time"`
+	DurationMS float64      `json:"duration_ms"`
+	Request    *Message     `json:"request"`
+	Response   *Message     `json:"response,omitempty"`
+	Auth       *AuthSummary `json:"auth,omitempty"`
+	Error      string       `json:"error,omitempty"`
+}
+
+// Message is a recorded HTTP request or response. Body holds the payload as
+// JSON when it parses as JSON and as a string otherwise.
+type Message struct {
+	Method    string      `json:"method,omitempty"`
+	URL       string      `json:"url,omitempty"`
+	Status    int         `json:"status,omitempty"`
+	Header    http.Header `json:"header,omitempty"`
+	Body      any         `json:"body,omitempty"`
+	Truncated bool        `json:"truncated,omitempty"`
+}
+
+// AuthSummary lists the fields of the Authorization header sent with a
+// request, without its signature or token.
+type AuthSummary struct {
+	Scheme             string `json:"scheme"`
+	DID                string `json:"did,omitempty"`
+	Nonce              string `json:"nonce,omitempty"`
+	Timestamp          string `json:"timestamp,omitempty"`
+	VerificationMethod string `json:"verification_method,omitempty"`
+	Delegated          bool   `json:"delegated,omitempty"`
+}
+
+// RedactAuthorization hides the secret parts of an Authorization header value:
+// the signature and delegation of a DIDWba header, or the whole credential of
+// any other scheme.
+func RedactAuthorization(value string) string {
+	if value == "" {
+		return ""
+	}
+	if h, err := anp_auth.ParseAuthHeader(value); err == nil {
+		h.Signature = redacted
+		if h.Delegation != "" {
+			h.Delegation = redacted
+		}
+		return h.String()
+	}
+	if scheme, _, ok := strings.Cut(value, " "); ok {
+		return scheme + " " + redacted
+	}
+	return redacted
+}
+
+func summarizeAuth(value string) *AuthSummary {
+	switch {
+	case value == "":
+		return nil
+	case strings.HasPrefix(value, anp_auth.BearerScheme):
+		return &AuthSummary{Scheme: strings.TrimSpace(anp_auth.BearerScheme)}
+	}
+	h, err := anp_auth.ParseAuthHeader(value)
+	if err != nil {
+		scheme, _, _ := strings.Cut(value, " ")
+		return &AuthSummary{Scheme: scheme}
+	}
+	return &AuthSummary{
+		Scheme:             anp_auth.DIDWbaScheme,
+		DID:                h.DID,
+		Nonce:              h.Nonce,
+		Timestamp:          h.Timestamp,
+		VerificationMethod: h.VerificationMethod,
+		Delegated:          h.Delegation != "",
+	}
+}
+
+// RedactHeader returns a copy of h with the Authorization header redacted by
+// RedactAuthorization and cookies and proxy credentials hidden, as recorded
+// by Recorder and the default Transcript.
+func RedactHeader(h http.Header) http.Header {
+	return redactHeader(h)
+}
+
+func redactHeader(h http.Header) http.Header {
+	if len(h) == 0 {
+		return nil
+	}
+	out := h.Clone()
+	if v := out.Get(anp_auth.AuthorizationHeader); v != "" {
+		out.Set(anp_auth.AuthorizationHeader, RedactAuthorization(v))
+	}
+	for _, k := range []string{"Cookie", "Set-Cookie", "Proxy-Authorization"} {
+		if _, ok := out[k]; ok {
+			out.Set(k, redacted)
+		}
+	}
+	return out
+}
+
+// capture keeps the first max bytes written to it.
+type capture struct {
+	mu        sync.Mutex
+	buf       bytes.Buffer
+	max       int
+	truncated bool
+}
+
+func (c *capture) Write(p []byte) (int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if room := c.max - c.buf.Len(); room < len(p) {
+		c.truncated = true
+		if room > 0 {
+			c.buf.Write(p[:room])
+		}
+		return len(p), nil
+	}
+	c.buf.Write(p)
+	return len(p), nil
+}
+
+func (c *capture) fill(m *Message) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	m.Truncated = c.truncated
+	if c.buf.Len() == 0 {
+		return
+	}
+	data := bytes.Clone(c.buf.Bytes())
+	if !c.truncated && json.Valid(data) {
+		m.Body = json.RawMessage(data)
+	} else {
+		m.Body = string(data)
+	}
+}
+
+// teeBody copies everything read from an HTTP body into a capture and calls
+// done once, when the body is closed.
+type teeBody struct {
+	io.ReadCloser
+	tee  io.Reader
+	once sync.Once
+	done func()
+}
+
+func newTeeBody(body io.ReadCloser, c *capture, done func()) *teeBody {
+	return &teeBody{ReadCloser: body, tee: io.TeeReader(body, c), done: done}
+}
+
+func (b *teeBody) Read(p []byte) (int, error) { return b.tee.Read(p) }
+
+func (b *teeBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.once.Do(b.done)
+	return err
+}
+
+// Transport returns an http.RoundTripper that records every request sent
+// through base (http.DefaultTransport when nil). An exchange is written once
+// its response body has been closed, so streamed responses are recorded as far
+// as the caller read them.
+func (r *Recorder) Transport(base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	if r == nil {
+		return base
+	}
+	return &transport{next: base, maxBody: r.maxBody, redact: redactHeader, record: r.writeExchange}
+}
+
+// transport records the exchanges sent through next with record, headers
+// passed through redact.
+type transport struct {
+	next    http.RoundTripper
+	maxBody int
+	redact  func(http.Header) http.Header
+	record  func(*Exchange)
+}
+
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+	ex := &Exchange{
+		Kind: KindOutbound,
+		Time: start,
+		Request: &Message{
+			Method: req.Method,
+			URL:    req.URL.String(),
+			Header: t.redact(req.Header),
+		},
+		Auth: summarizeAuth(req.Header.Get(anp_auth.AuthorizationHeader)),
+	}
+
+	reqBody := &capture{max: t.maxBody}
+	if req.Body != nil && req.Body != http.NoBody {
+		req = req.Clone(req.Context())
+		req.Body = newTeeBody(req.Body, reqBody, func() {})
+	}
+
+	resp, err := t.next.RoundTrip(req)
+	reqBody.fill(ex.Request)
+	if err != nil {
+		ex.DurationMS = msSince(start)
+		ex.Error = err.Error()
+		t.record(ex)
+		return nil, err
+	}
+
+	ex.Response = &Message{Status: resp.StatusCode, Header: t.redact(resp.Header)}
+	respBody := &capture{max: t.maxBody}
+	resp.Body = newTeeBody(resp.Body, respBody, func() {
+		ex.DurationMS = msSince(start)
+		respBody.fill(ex.Response)
+		t.record(ex)
+	})
+	return resp, nil
+}
+
+// Middleware records every request served by next together with the response
+// it wrote. A nil Recorder returns next unchanged.
+func (r *Recorder) Middleware(next http.Handler) http.Handler {
+	if r == nil {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		start := time.Now()
+		ex := &Exchange{
+			Kind: KindInbound,
+			Time: start,
+			Request: &Message{
+				Method: req.Method,
+				URL:    req.URL.String(),
+				Header: redactHeader(req.Header),
+			},
+			Auth: summarizeAuth(req.Header.Get(anp_auth.AuthorizationHeader)),
+		}
+
+		reqBody := &capture{max: r.maxBody}
+		if req.Body != nil && req.Body != http.NoBody {
+			req.Body = newTeeBody(req.Body, reqBody, func() {})
+		}
+		rw := &responseRecorder{ResponseWriter: w, body: &capture{max: r.maxBody}}
+
+		defer func() {
+			ex.DurationMS = msSince(start)
+			reqBody.fill(ex.Request)
+			ex.Response = &Message{Status: rw.statusCode(), Header: redactHeader(w.Header())}
+			rw.body.fill(ex.Response)
+			r.writeExchange(ex)
+		}()
+		next.ServeHTTP(rw, req)
+	})
+}
+
+type responseRecorder struct {
+	http.ResponseWriter
+	status int
+	body   *capture
+}
+
+func (w *responseRecorder) WriteHeader(code int) {
+	if w.status == 0 {
+		w.status = code
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *responseRecorder) Write(p []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	w.body.Write(p)
+	return w.ResponseWriter.Write(p)
+}
+
+// Flush keeps streaming handlers such as anp_sse working behind Middleware.
+func (w *responseRecorder) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (w *responseRecorder) Unwrap() http.ResponseWriter { return w.ResponseWriter }
+
+func (w *responseRecorder) statusCode() int {
+	if w.status == 0 {
+		return http.StatusOK
+	}
+	return w.status
+}
+
+func (r *Recorder) writeExchange(ex *Exchange) {
+	r.write(ex.Kind, func(seq uint64) any {
+		ex.Seq = seq
+		return ex
+	})
+}
+
+func msSince(t time.Time) float64 {
+	return float64(time.Since(t).Microseconds()) / 1000
+}
@@ -0,0 +1,159 @@
+// Package anp_debug records ANP traffic to a dump directory for interop bug
+// reports.
+//
+// A Recorder writes one JSON file per event into a run directory below its
+// root: outbound and inbound HTTP exchanges, including the Authorization header
+// with the signature and tokens redacted, and the result of parsing fetched
+// documents. Files are numbered in the order events complete, so a directory
+// can be attached to an issue and replayed by eye:
+//
+//	anp-debug/20261015T091500-4242/000001-outbound.json
+//	anp-debug/20261015T091500-4242/000002-parse.json
+//
+// Setting ANP_DEBUG_DIR enables recording in session.New and anp_server.New
+// without code changes; Config.Debug enables it explicitly.
+package anp_debug
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/bytedance/sonic"
+)
+
+// EnvDir names the environment variable holding the dump root directory.
+const EnvDir = "ANP_DEBUG_DIR"
+
+const defaultMaxBodyBytes = 1 << 20
+
+// Event kinds, used in file names and the Kind field of each record.
+const (
+	KindOutbound = "outbound"
+	KindInbound  = "inbound"
+	KindParse    = "parse"
+)
+
+// Option configures a Recorder.
+type Option func(*Recorder)
+
+// WithMaxBodyBytes limits how much of each request and response body is
+// stored (default 1 MiB). Longer bodies are truncated and flagged.
+func WithMaxBodyBytes(n int) Option {
+	return func(r *Recorder) {
+		if n > 0 {
+			r.maxBody = n
+		}
+	}
+}
+
+// WithLogger sets the logger used to report dump failures.
+func WithLogger(l *slog.Logger) Option {
+	return func(r *Recorder) {
+		if l != nil {
+			r.logger = l
+		}
+	}
+}
+
+// Recorder writes debug records to a run directory. Recording failures are
+// logged and never affect the traffic being recorded. A nil *Recorder records
+// nothing, so callers need not check whether debugging is enabled.
+type Recorder struct {
+	dir     string
+	maxBody int
+	logger  *slog.Logger
+	seq     atomic.Uint64
+}
+
+// NewRecorder creates a run directory below root and returns a Recorder that
+// writes into it.
+func NewRecorder(root string, opts ...Option) (*Recorder, error) {
+	if root == "" {
+		return nil, fmt.Errorf("anp_debug: dump directory is empty")
+	}
+	run := time.Now().Format("20060102T150405") + "-" + strconv.Itoa(os.Getpid())
+	dir := filepath.Join(root, run)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("anp_debug: create dump directory: %w", err)
+	}
+
+	r := &Recorder{dir: dir, maxBody: defaultMaxBodyBytes, logger: slog.Default()}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r, nil
+}
+
+var (
+	envOnce     sync.Once
+	envRecorder *Recorder
+	envErr      error
+)
+
+// FromEnv returns the process-wide Recorder for $ANP_DEBUG_DIR, creating it on
+// first use. It returns nil, nil when the variable is unset.
+func FromEnv() (*Recorder, error) {
+	root := os.Getenv(EnvDir)
+	if root == "" {
+		return nil, nil
+	}
+	envOnce.Do(func() {
+		envRecorder, envErr = NewRecorder(root)
+	})
+	return envRecorder, envErr
+}
+
+// Dir returns the run directory records are written to.
+func (r *Recorder) Dir() string {
+	if r == nil {
+		return ""
+	}
+	return r.dir
+}
+
+// ParseRecord describes the outcome of parsing a fetched document.
+type ParseRecord struct {
+	Seq         uint64    `json:"seq"`
+	Kind        string    `json:"kind"`
+	Time        time.Time `json:"time"`
+	URL         string    `json:"url"`
+	ContentType string    `json:"content_type,omitempty"`
+	Result      any       `json:"result,omitempty"`
+	Error       string    `json:"error,omitempty"`
+}
+
+// RecordParse stores the result of parsing the document at url, or the error
+// that prevented it.
+func (r *Recorder) RecordParse(url, contentType string, result any, err error) {
+	if r == nil {
+		return
+	}
+	rec := &ParseRecord{Kind: KindParse, Time: time.Now(), URL: url, ContentType: contentType, Result: result}
+	if err != nil {
+		rec.Error = err.Error()
+	}
+	r.write(KindParse, func(seq uint64) any {
+		rec.Seq = seq
+		return rec
+	})
+}
+
+// write assigns the next sequence number and stores the record built for it.
+func (r *Recorder) write(kind string, build func(seq uint64) any) {
+	seq := r.seq.Add(1)
+	data, err := sonic.ConfigStd.MarshalIndent(build(seq), "", "  ")
+	if err != nil {
+		r.logger.Warn("anp_debug: encode record", "kind", kind, "error", err)
+		return
+	}
+	name := filepath.Join(r.dir, fmt.Sprintf("%06d-%s.json", seq, kind))
+	if err := os.WriteFile(name, data, 0o644); err != nil {
+		r.logger.Warn("anp_debug: write record", "file", name, "error", err)
+	}
+}
@@ -0,0 +1,159 @@
+package anp_debug
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/bytedance/sonic"
+	"github.com/openanp/anp-go/anp_auth"
+)
+
+func readRecords(t *testing.T, r *Recorder) map[string][]byte {
+	t.Helper()
+	entries, err := os.ReadDir(r.Dir())
+	if err != nil {
+		t.Fatalf("ReadDir() error = %v", err)
+	}
+	out := make(map[string][]byte, len(entries))
+	for _, e := range entries {
+		data, err := os.ReadFile(filepath.Join(r.Dir(), e.Name()))
+		if err != nil {
+			t.Fatalf("ReadFile() error = %v", err)
+		}
+		out[e.Name()] = data
+	}
+	return out
+}
+
+func TestTransportAndMiddleware(t *testing.T) {
+	rec, err := NewRecorder(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewRecorder() error = %v", err)
+	}
+
+	srv := httptest.NewServer(rec.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(io.Discard, r.Body)
+		w.Header().Set("Authorization", "Bearer server-issued-token")
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, `{"result":3}`)
+	})))
+	defer srv.Close()
+
+	doc, key, err := anp_auth.CreateDIDWBADocument("client.example.com", nil, nil, nil)
+	if err != nil {
+		t.Fatalf("CreateDIDWBADocument() error = %v", err)
+	}
+	header, err := anp_auth.GenerateAuthHeader(key, doc, "127.0.0.1")
+	if err != nil {
+		t.Fatalf("GenerateAuthHeader() error = %v", err)
+	}
+
+	req, _ := http.NewRequest(http.MethodPost, srv.URL+"/rpc", strings.NewReader(`{"method":"add"}`))
+	req.Header.Set(anp_auth.AuthorizationHeader, header.String())
+	client := &http.Client{Transport: rec.Transport(nil)}
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("Do() error = %v", err)
+	}
+	io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+	rec.RecordParse(srv.URL+"/rpc", "application/json", map[string]int{"interfaces": 0}, nil)
+	srv.Close() // waits for the inbound record
+
+	records := readRecords(t, rec)
+	if len(records) != 3 {
+		t.Fatalf("records = %d, want 3", len(records))
+	}
+	for name, data := range records {
+		if strings.Contains(string(data), header.Signature) || strings.Contains(string(data), "server-issued-token") {
+			t.Errorf("%s leaks a credential", name)
+		}
+	}
+
+	for _, kind := range []string{KindOutbound, KindInbound, KindParse} {
+		var ex Exchange
+		var raw []byte
+		for name, data := range records {
+			if strings.HasSuffix(name, "-"+kind+".json") {
+				raw = data
+			}
+		}
+		if raw == nil {
+			t.Fatalf("no %s record in %v", kind, records)
+		}
+		if kind == KindParse {
+			continue
+		}
+		if err := sonic.Unmarshal(raw, &ex); err != nil {
+			t.Fatalf("decode %s record: %v", kind, err)
+		}
+		if ex.Auth == nil || ex.Auth.DID != doc.ID || ex.Auth.Nonce != header.Nonce {
+			t.Errorf("%s auth = %+v, want DID %s", kind, ex.Auth, doc.ID)
+		}
+		if ex.Request.Method != http.MethodPost || ex.Response == nil || ex.Response.Status != http.StatusOK {
+			t.Errorf("%s exchange = %+v", kind, ex)
+		}
+		if !strings.Contains(string(raw), `"result": 3`) || !strings.Contains(string(raw), `"method": "add"`) {
+			t.Errorf("%s record missing JSON bodies:\n%s", kind, raw)
+		}
+	}
+}
+
+func TestTruncatedBody(t *testing.T) {
+	rec, err := NewRecorder(t.TempDir(), WithMaxBodyBytes(4))
+	if err != nil {
+		t.Fatalf("NewRecorder() error = %v", err)
+	}
+	h := rec.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "hello world")
+	}))
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	if w.Body.String() != "hello world" {
+		t.Fatalf("body = %q, recording must not alter the response", w.Body.String())
+	}
+
+	var ex Exchange
+	if err := sonic.Unmarshal(readRecords(t, rec)["000001-inbound.json"], &ex); err != nil {
+		t.Fatalf("decode record: %v", err)
+	}
+	if ex.Response.Body != "hell" || !ex.Response.Truncated {
+		t.Errorf("response = %+v, want truncated body", ex.Response)
+	}
+}
+
+func TestRedactAuthorization(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"", ""},
+		{"Bearer abc.def.ghi", "Bearer [REDACTED]"},
+		{"Basic dXNlcjpwYXNz", "Basic [REDACTED]"},
+		{"opaque", "[REDACTED]"},
+		{
+			`DIDWba did="did:wba:a.example", nonce="n", timestamp="t", verification_method="key-1", signature="sig"`,
+			`DIDWba did="did:wba:a.example", nonce="n", timestamp="t", verification_method="key-1", signature="[REDACTED]"`,
+		},
+	}
+	for _, tt := range tests {
+		if got := RedactAuthorization(tt.in); got != tt.want {
+			t.Errorf("RedactAuthorization(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestNilRecorder(t *testing.T) {
+	var rec *Recorder
+	rec.RecordParse("https://a.example/ad.json", "", nil, nil)
+	if rec.Transport(nil) != http.DefaultTransport {
+		t.Error("nil Recorder Transport() should return the base transport")
+	}
+	if rec.Dir() != "" {
+		t.Error("nil Recorder Dir() should be empty")
+	}
+}
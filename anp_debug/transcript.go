@@ -0,0 +1,315 @@
+package anp_debug
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bytedance/sonic"
+)
+
+// Transcript keeps the HTTP exchanges sent through its Transport in memory,
+// in the order they complete, and writes them as JSONL or HAR. Unlike a
+// Recorder it touches no files until asked to, which suits capturing one
+// agent interaction for debugging or as a test fixture (see ReadJSONL and
+// ReplayTransport). The zero value is ready to use and safe for concurrent
+// use.
+type Transcript struct {
+	// Redact returns the headers to record for a request or response; nil
+	// uses RedactHeader. Authorization headers carry signatures and tokens
+	// that let anyone holding the transcript replay the caller's identity.
+	Redact func(http.Header) http.Header
+	// MaxBodyBytes limits how much of each body is kept (default 1 MiB).
+	MaxBodyBytes int
+
+	mu        sync.Mutex
+	seq       uint64
+	exchanges []*Exchange
+}
+
+// Transport returns an http.RoundTripper that records every request sent
+// through base (http.DefaultTransport when nil) once its response body has
+// been closed.
+func (t *Transcript) Transport(base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	redact := t.Redact
+	if redact == nil {
+		redact = redactHeader
+	}
+	maxBody := t.MaxBodyBytes
+	if maxBody <= 0 {
+		maxBody = defaultMaxBodyBytes
+	}
+	return &transport{next: base, maxBody: maxBody, redact: redact, record: t.add}
+}
+
+func (t *Transcript) add(ex *Exchange) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.seq++
+	ex.Seq = t.seq
+	t.exchanges = append(t.exchanges, ex)
+}
+
+// Exchanges returns the exchanges recorded so far.
+func (t *Transcript) Exchanges() []*Exchange {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]*Exchange(nil), t.exchanges...)
+}
+
+// Reset discards the recorded exchanges.
+func (t *Transcript) Reset() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.seq = 0
+	t.exchanges = nil
+}
+
+// WriteJSONL writes one Exchange per line.
+func (t *Transcript) WriteJSONL(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	for _, ex := range t.Exchanges() {
+		data, err := sonic.Marshal(ex)
+		if err != nil {
+			return fmt.Errorf("anp_debug: encode exchange %d: %w", ex.Seq, err)
+		}
+		bw.Write(data)
+		bw.WriteByte('\n')
+	}
+	return bw.Flush()
+}
+
+// ReadJSONL reads exchanges written by WriteJSONL.
+func ReadJSONL(r io.Reader) ([]*Exchange, error) {
+	var out []*Exchange
+	sc := bufio.NewScanner(r)
+	sc.Buffer(nil, 64<<20)
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" {
+			continue
+		}
+		var ex Exchange
+		if err := sonic.UnmarshalString(line, &ex); err != nil {
+			return nil, fmt.Errorf("anp_debug: decode exchange %d: %w", len(out)+1, err)
+		}
+		out = append(out, &ex)
+	}
+	return out, sc.Err()
+}
+
+// WriteHAR writes the transcript as an HTTP Archive (HAR 1.2) for browser
+// developer tools and HAR viewers. Failed requests have status 0 and their
+// error in the entry's _error field.
+func (t *Transcript) WriteHAR(w io.Writer) error {
+	exchanges := t.Exchanges()
+	entries := make([]harEntry, 0, len(exchanges))
+	for _, ex := range exchanges {
+		entries = append(entries, newHAREntry(ex))
+	}
+	data, err := sonic.ConfigStd.MarshalIndent(map[string]any{"log": harLog{
+		Version: "1.2",
+		Creator: harCreator{Name: "anp-go"},
+		Entries: entries,
+	}}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("anp_debug: encode HAR: %w", err)
+	}
+	_, err = w.Write(data)
+	return err
+}
+
+type harLog struct {
+	Version string     `json:"version"`
+	Creator harCreator `json:"creator"`
+	Entries []harEntry `json:"entries"`
+}
+
+type harCreator struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+type harEntry struct {
+	StartedDateTime string         `json:"startedDateTime"`
+	Time            float64        `json:"time"`
+	Request         harRequest     `json:"request"`
+	Response        harResponse    `json:"response"`
+	Cache           struct{}       `json:"cache"`
+	Timings         map[string]any `json:"timings"`
+	Error           string         `json:"_error,omitempty"`
+}
+
+type harRequest struct {
+	Method      string       `json:"method"`
+	URL         string       `json:"url"`
+	HTTPVersion string       `json:"httpVersion"`
+	Headers     []harNameVal `json:"headers"`
+	QueryString []harNameVal `json:"queryString"`
+	Cookies     []harNameVal `json:"cookies"`
+	HeadersSize int          `json:"headersSize"`
+	BodySize    int          `json:"bodySize"`
+	PostData    *harPostData `json:"postData,omitempty"`
+}
+
+type harPostData struct {
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text"`
+}
+
+type harResponse struct {
+	Status      int          `json:"status"`
+	StatusText  string       `json:"statusText"`
+	HTTPVersion string       `json:"httpVersion"`
+	Headers     []harNameVal `json:"headers"`
+	Cookies     []harNameVal `json:"cookies"`
+	Content     harContent   `json:"content"`
+	RedirectURL string       `json:"redirectURL"`
+	HeadersSize int          `json:"headersSize"`
+	BodySize    int          `json:"bodySize"`
+}
+
+type harContent struct {
+	Size     int    `json:"size"`
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text,omitempty"`
+}
+
+type harNameVal struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+func newHAREntry(ex *Exchange) harEntry {
+	e := harEntry{
+		StartedDateTime: ex.Time.Format(time.RFC3339Nano),
+		Time:            ex.DurationMS,
+		Request: harRequest{
+			Method:      ex.Request.Method,
+			URL:         ex.Request.URL,
+			HTTPVersion: "HTTP/1.1",
+			Headers:     harHeaders(ex.Request.Header),
+			QueryString: []harNameVal{},
+			Cookies:     []harNameVal{},
+			HeadersSize: -1,
+			BodySize:    -1,
+		},
+		Response: harResponse{
+			HTTPVersion: "HTTP/1.1",
+			Headers:     []harNameVal{},
+			Cookies:     []harNameVal{},
+			HeadersSize: -1,
+			BodySize:    -1,
+		},
+		Timings: map[string]any{"send": 0, "wait": ex.DurationMS, "receive": 0},
+		Error:   ex.Error,
+	}
+	if text := bodyText(ex.Request.Body); text != "" {
+		e.Request.PostData = &harPostData{MimeType: ex.Request.Header.Get("Content-Type"), Text: text}
+		e.Request.BodySize = len(text)
+	}
+	if resp := ex.Response; resp != nil {
+		text := bodyText(resp.Body)
+		e.Response.Status = resp.Status
+		e.Response.StatusText = http.StatusText(resp.Status)
+		e.Response.Headers = harHeaders(resp.Header)
+		e.Response.RedirectURL = resp.Header.Get("Location")
+		e.Response.Content = harContent{Size: len(text), MimeType: resp.Header.Get("Content-Type"), Text: text}
+		e.Response.BodySize = len(text)
+	}
+	return e
+}
+
+func harHeaders(h http.Header) []harNameVal {
+	out := []harNameVal{}
+	keys := make([]string, 0, len(h))
+	for k := range h {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		for _, v := range h[k] {
+			out = append(out, harNameVal{Name: k, Value: v})
+		}
+	}
+	return out
+}
+
+// bodyText returns a recorded body as text: a string as is, anything else,
+// such as JSON decoded from a transcript, encoded as JSON.
+func bodyText(body any) string {
+	switch b := body.(type) {
+	case nil:
+		return ""
+	case string:
+		return b
+	case json.RawMessage:
+		return string(b)
+	default:
+		data, err := sonic.Marshal(b)
+		if err != nil {
+			return ""
+		}
+		return string(data)
+	}
+}
+
+// ReplayTransport answers requests with the responses of exchanges, e.g. read
+// from a fixture with ReadJSONL, instead of sending them. A request is matched
+// by method and URL to the first recorded exchange not yet replayed; one with
+// no match fails. Redacted headers are replayed redacted.
+func ReplayTransport(exchanges []*Exchange) http.RoundTripper {
+	return &replayTransport{pending: append([]*Exchange(nil), exchanges...)}
+}
+
+type replayTransport struct {
+	mu      sync.Mutex
+	pending []*Exchange
+}
+
+func (t *replayTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Body != nil {
+		req.Body.Close()
+	}
+	t.mu.Lock()
+	var ex *Exchange
+	for i, candidate := range t.pending {
+		if candidate.Request != nil && candidate.Request.Method == req.Method && candidate.Request.URL == req.URL.String() {
+			ex = candidate
+			t.pending = append(t.pending[:i], t.pending[i+1:]...)
+			break
+		}
+	}
+	t.mu.Unlock()
+	switch {
+	case ex == nil:
+		return nil, fmt.Errorf("anp_debug: no recorded response for %s %s", req.Method, req.URL)
+	case ex.Response == nil:
+		return nil, fmt.Errorf("anp_debug: recorded %s %s failed: %s", req.Method, req.URL, ex.Error)
+	}
+	body := bodyText(ex.Response.Body)
+	header := ex.Response.Header.Clone()
+	if header == nil {
+		header = http.Header{}
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", ex.Response.Status, http.StatusText(ex.Response.Status)),
+		StatusCode:    ex.Response.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(strings.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}, nil
+}
@@ -0,0 +1,110 @@
+package anp_debug
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/bytedance/sonic"
+)
+
+func TestTranscript(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(io.Discard, r.Body)
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Path == "/missing" {
+			w.WriteHeader(http.StatusNotFound)
+		}
+		io.WriteString(w, `{"result":3}`)
+	}))
+	defer srv.Close()
+
+	var transcript Transcript
+	client := &http.Client{Transport: transcript.Transport(nil)}
+	do := func(method, path, body string) {
+		t.Helper()
+		req, _ := http.NewRequest(method, srv.URL+path, strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer secret-token")
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatalf("Do() error = %v", err)
+		}
+		io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+	}
+	do(http.MethodPost, "/rpc", `{"method":"add"}`)
+	do(http.MethodGet, "/missing", "")
+
+	var jsonl bytes.Buffer
+	if err := transcript.WriteJSONL(&jsonl); err != nil {
+		t.Fatalf("WriteJSONL() error = %v", err)
+	}
+	if strings.Contains(jsonl.String(), "secret-token") {
+		t.Errorf("WriteJSONL() leaks the Authorization token: %s", jsonl.String())
+	}
+	exchanges, err := ReadJSONL(&jsonl)
+	if err != nil {
+		t.Fatalf("ReadJSONL() error = %v", err)
+	}
+	if len(exchanges) != 2 || exchanges[0].Seq != 1 || exchanges[0].Request.Method != http.MethodPost || exchanges[1].Response.Status != http.StatusNotFound {
+		t.Fatalf("ReadJSONL() = %+v", exchanges)
+	}
+
+	var har bytes.Buffer
+	if err := transcript.WriteHAR(&har); err != nil {
+		t.Fatalf("WriteHAR() error = %v", err)
+	}
+	var doc struct {
+		Log struct {
+			Version string `json:"version"`
+			Entries []struct {
+				Request struct {
+					Method   string `json:"method"`
+					PostData *struct {
+						Text string `json:"text"`
+					} `json:"postData"`
+				} `json:"request"`
+				Response struct {
+					Status  int `json:"status"`
+					Content struct {
+						Text string `json:"text"`
+					} `json:"content"`
+				} `json:"response"`
+			} `json:"entries"`
+		} `json:"log"`
+	}
+	if err := sonic.Unmarshal(har.Bytes(), &doc); err != nil {
+		t.Fatalf("Unmarshal(HAR) error = %v", err)
+	}
+	if doc.Log.Version != "1.2" || len(doc.Log.Entries) != 2 {
+		t.Fatalf("WriteHAR() = %s", har.String())
+	}
+	if e := doc.Log.Entries[0]; e.Request.PostData == nil || e.Request.PostData.Text != `{"method":"add"}` || e.Response.Status != 200 || e.Response.Content.Text != `{"result":3}` {
+		t.Errorf("HAR entry = %+v", e)
+	}
+
+	// The fixture answers the same requests without the server.
+	srv.Close()
+	replay := &http.Client{Transport: ReplayTransport(exchanges)}
+	resp, err := replay.Post(srv.URL+"/rpc", "application/json", strings.NewReader(`{}`))
+	if err != nil {
+		t.Fatalf("replay Post() error = %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || string(body) != `{"result":3}` {
+		t.Errorf("replay = %d %s", resp.StatusCode, body)
+	}
+	if _, err := replay.Post(srv.URL+"/rpc", "application/json", nil); err == nil {
+		t.Error("replay of an exhausted request error = nil")
+	}
+
+	transcript.Reset()
+	if n := len(transcript.Exchanges()); n != 0 {
+		t.Errorf("Exchanges() after Reset = %d", n)
+	}
+}
@@ -0,0 +1,177 @@
+// Package anp_didhost serves DID documents over HTTPS at the locations
+// did:wba and did:web resolvers fetch them from, so agent identities can be
+// hosted without a separate web server.
+//
+// A Host maps each request to the DID published at that URL, e.g.
+// https://example.com/.well-known/did.json to did:wba:example.com and
+// https://example.com/user/alice/did.json to did:wba:example.com:user:alice,
+// and serves the document from a pluggable Store:
+//
+//	host := anp_didhost.NewHost(anp_didhost.Config{Store: anp_didhost.NewDirStore("/var/lib/anp/did")})
+//	host.Publish(ctx, doc)
+//	mux.Handle("/", host)
+package anp_didhost
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bytedance/sonic"
+	"github.com/openanp/anp-go/anp_auth"
+)
+
+// DefaultMaxAge is the Cache-Control max-age used when Config.MaxAge is zero.
+const DefaultMaxAge = 5 * time.Minute
+
+// ErrNotFound is returned by a Store when it holds no document for a DID.
+var ErrNotFound = errors.New("anp_didhost: DID document not found")
+
+// Record is a stored DID document.
+type Record struct {
+	// Document is the JSON document, served byte for byte.
+	Document []byte
+	// UpdatedAt is reported as Last-Modified when set.
+	UpdatedAt time.Time
+}
+
+// Store holds DID documents by DID. Implementations must be safe for
+// concurrent use; see MemoryStore, DirStore and the anp_didhost/sqlite module.
+type Store interface {
+	// Get returns the document of did, or ErrNotFound.
+	Get(ctx context.Context, did string) (Record, error)
+	// Put inserts or replaces the document of did.
+	Put(ctx context.Context, did string, record Record) error
+	// Delete removes the document of did. Deleting a missing DID is not an
+	// error.
+	Delete(ctx context.Context, did string) error
+}
+
+// Config configures a Host.
+type Config struct {
+	// Store holds the documents (default a new MemoryStore).
+	Store Store
+	// MaxAge is how long resolvers may cache a document (default
+	// DefaultMaxAge). Negative disables caching.
+	MaxAge time.Duration
+	// Logger receives store failures. Nil uses slog.Default.
+	Logger *slog.Logger
+	// Now stamps published documents (default time.Now).
+	Now func() time.Time
+}
+
+// Host publishes DID documents and serves them over HTTP.
+type Host struct {
+	store  Store
+	maxAge time.Duration
+	logger *slog.Logger
+	now    func() time.Time
+}
+
+// NewHost creates a Host.
+func NewHost(cfg Config) *Host {
+	if cfg.Store == nil {
+		cfg.Store = NewMemoryStore()
+	}
+	if cfg.MaxAge == 0 {
+		cfg.MaxAge = DefaultMaxAge
+	}
+	if cfg.Logger == nil {
+		cfg.Logger = slog.Default()
+	}
+	if cfg.Now == nil {
+		cfg.Now = time.Now
+	}
+	return &Host{store: cfg.Store, maxAge: cfg.MaxAge, logger: cfg.Logger, now: cfg.Now}
+}
+
+// Publish stores doc under its ID, which must be a did:wba or did:web DID.
+func (h *Host) Publish(ctx context.Context, doc *anp_auth.DIDWBADocument) error {
+	if doc == nil {
+		return errors.New("anp_didhost: DID document is nil")
+	}
+	if _, err := anp_auth.DIDDocumentURL(doc.ID); err != nil {
+		return fmt.Errorf("anp_didhost: %w", err)
+	}
+	raw, err := sonic.Marshal(doc)
+	if err != nil {
+		return fmt.Errorf("anp_didhost: encode DID document: %w", err)
+	}
+	return h.store.Put(ctx, doc.ID, Record{Document: raw, UpdatedAt: h.now().UTC()})
+}
+
+// Remove stops serving the document of did.
+func (h *Host) Remove(ctx context.Context, did string) error {
+	return h.store.Delete(ctx, did)
+}
+
+// ServeHTTP serves the document published at the request URL. Conditional
+// requests are answered from its ETag and Last-Modified.
+func (h *Host) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	candidates, ok := didsForURL(r.Host, r.URL.EscapedPath())
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	for _, did := range candidates {
+		record, err := h.store.Get(r.Context(), did)
+		if errors.Is(err, ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			h.logger.ErrorContext(r.Context(), "load DID document", "did", did, "error", err)
+			http.Error(w, "DID document unavailable", http.StatusInternalServerError)
+			return
+		}
+		h.serve(w, r, record)
+		return
+	}
+	http.NotFound(w, r)
+}
+
+func (h *Host) serve(w http.ResponseWriter, r *http.Request, record Record) {
+	sum := sha256.Sum256(record.Document)
+	header := w.Header()
+	// application/json rather than application/did+json: several resolvers
+	// reject any other media type.
+	header.Set("Content-Type", "application/json")
+	header.Set("ETag", `"`+hex.EncodeToString(sum[:16])+`"`)
+	header.Set("Access-Control-Allow-Origin", "*")
+	if h.maxAge > 0 {
+		header.Set("Cache-Control", "public, max-age="+strconv.Itoa(int(h.maxAge/time.Second)))
+	} else {
+		header.Set("Cache-Control", "no-cache")
+	}
+	http.ServeContent(w, r, anp_auth.DIDDocumentFilename, record.UpdatedAt, bytes.NewReader(record.Document))
+}
+
+// didsForURL returns the did:wba and did:web DIDs whose documents are
+// published at host and path, the inverse of anp_auth.DIDDocumentURL.
+func didsForURL(host, path string) ([]string, bool) {
+	if host == "" {
+		return nil, false
+	}
+	id := strings.ReplaceAll(host, ":", "%3A")
+	if path != anp_auth.WellKnownDIDPath {
+		rest, ok := strings.CutSuffix(path, "/"+anp_auth.DIDDocumentFilename)
+		rest = strings.TrimPrefix(rest, "/")
+		if !ok || rest == "" || strings.Contains(rest, "//") {
+			return nil, false
+		}
+		id += ":" + strings.ReplaceAll(rest, "/", ":")
+	}
+	return []string{anp_auth.DIDPrefix + id, anp_auth.DIDWebPrefix + id}, true
+}
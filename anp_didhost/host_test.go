@@ -0,0 +1,135 @@
+package anp_didhost
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/openanp/anp-go/anp_auth"
+)
+
+func newDocument(t *testing.T, hostname string, path ...string) *anp_auth.DIDWBADocument {
+	t.Helper()
+	doc, _, err := anp_auth.CreateDIDWBADocument(hostname, nil, path, nil)
+	if err != nil {
+		t.Fatalf("CreateDIDWBADocument() error = %v", err)
+	}
+	return doc
+}
+
+func TestHost_ServeHTTP(t *testing.T) {
+	ctx := context.Background()
+	updated := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	root := newDocument(t, "example.com")
+	alice := newDocument(t, "example.com", "user", "alice")
+	withPort := newDocument(t, "example.com")
+	withPort.ID = "did:wba:example.com%3A8443"
+
+	for name, store := range map[string]Store{
+		"memory": NewMemoryStore(),
+		"dir":    NewDirStore(t.TempDir()),
+	} {
+		t.Run(name, func(t *testing.T) {
+			host := NewHost(Config{Store: store, Now: func() time.Time { return updated }})
+			for _, doc := range []*anp_auth.DIDWBADocument{root, alice, withPort} {
+				if err := host.Publish(ctx, doc); err != nil {
+					t.Fatalf("Publish(%s) error = %v", doc.ID, err)
+				}
+			}
+
+			tests := []struct {
+				name       string
+				method     string
+				url        string
+				wantStatus int
+				wantID     string
+			}{
+				{"well-known", http.MethodGet, "https://example.com/.well-known/did.json", http.StatusOK, root.ID},
+				{"path-based", http.MethodGet, "https://example.com/user/alice/did.json", http.StatusOK, alice.ID},
+				{"with port", http.MethodGet, "https://example.com:8443/.well-known/did.json", http.StatusOK, withPort.ID},
+				{"head", http.MethodHead, "https://example.com/.well-known/did.json", http.StatusOK, ""},
+				{"unknown path", http.MethodGet, "https://example.com/user/bob/did.json", http.StatusNotFound, ""},
+				{"unknown host", http.MethodGet, "https://other.example.com/.well-known/did.json", http.StatusNotFound, ""},
+				{"not a DID path", http.MethodGet, "https://example.com/user/alice", http.StatusNotFound, ""},
+				{"post", http.MethodPost, "https://example.com/.well-known/did.json", http.StatusMethodNotAllowed, ""},
+			}
+			for _, tt := range tests {
+				t.Run(tt.name, func(t *testing.T) {
+					rec := httptest.NewRecorder()
+					host.ServeHTTP(rec, httptest.NewRequest(tt.method, tt.url, nil))
+					if rec.Code != tt.wantStatus {
+						t.Fatalf("status = %d, want %d", rec.Code, tt.wantStatus)
+					}
+					if tt.wantStatus != http.StatusOK {
+						return
+					}
+					if got := rec.Header().Get("Content-Type"); got != "application/json" {
+						t.Errorf("Content-Type = %q, want application/json", got)
+					}
+					if got := rec.Header().Get("Cache-Control"); got != "public, max-age=300" {
+						t.Errorf("Cache-Control = %q", got)
+					}
+					if got := rec.Header().Get("Last-Modified"); got != updated.Format(http.TimeFormat) {
+						t.Errorf("Last-Modified = %q, want %q", got, updated.Format(http.TimeFormat))
+					}
+					if tt.wantID != "" && !strings.Contains(rec.Body.String(), `"id":"`+tt.wantID+`"`) {
+						t.Errorf("body = %s, want document %s", rec.Body.String(), tt.wantID)
+					}
+				})
+			}
+
+			// Conditional requests are answered with 304.
+			rec := httptest.NewRecorder()
+			host.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "https://example.com/.well-known/did.json", nil))
+			req := httptest.NewRequest(http.MethodGet, "https://example.com/.well-known/did.json", nil)
+			req.Header.Set("If-None-Match", rec.Header().Get("ETag"))
+			rec = httptest.NewRecorder()
+			host.ServeHTTP(rec, req)
+			if rec.Code != http.StatusNotModified {
+				t.Errorf("If-None-Match status = %d, want %d", rec.Code, http.StatusNotModified)
+			}
+
+			if err := host.Remove(ctx, alice.ID); err != nil {
+				t.Fatalf("Remove() error = %v", err)
+			}
+			if err := host.Remove(ctx, alice.ID); err != nil {
+				t.Fatalf("Remove() twice error = %v", err)
+			}
+			rec = httptest.NewRecorder()
+			host.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "https://example.com/user/alice/did.json", nil))
+			if rec.Code != http.StatusNotFound {
+				t.Errorf("status after Remove = %d, want %d", rec.Code, http.StatusNotFound)
+			}
+		})
+	}
+}
+
+func TestHost_PublishInvalid(t *testing.T) {
+	host := NewHost(Config{})
+	if err := host.Publish(context.Background(), nil); err == nil {
+		t.Error("Publish(nil) error = nil, want error")
+	}
+	if err := host.Publish(context.Background(), &anp_auth.DIDWBADocument{ID: "did:key:z6Mk"}); err == nil {
+		t.Error("Publish(did:key) error = nil, want error")
+	}
+}
+
+func TestDirStore_RejectsEscapingDIDs(t *testing.T) {
+	store := NewDirStore(t.TempDir())
+	for _, did := range []string{
+		"did:wba:example.com:..:..:etc",
+		"did:wba:example.com:..:other.example.com",
+	} {
+		err := store.Put(context.Background(), did, Record{Document: []byte(`{"id":"` + did + `"}`)})
+		if err == nil {
+			t.Errorf("Put(%s) error = nil, want error", did)
+		}
+	}
+	if _, err := store.Get(context.Background(), "did:wba:example.com"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get(missing) error = %v, want ErrNotFound", err)
+	}
+}
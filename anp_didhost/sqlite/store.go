@@ -0,0 +1,111 @@
+// Package sqlite provides a SQLite-backed anp_didhost.Store, so hosted DID
+// documents survive restarts and can be shared by several Host instances
+// reading the same database.
+//
+// It is a separate module so the core SDK does not depend on a SQLite driver.
+// It uses the pure-Go modernc.org/sqlite driver and needs no cgo:
+//
+//	store, err := sqlite.Open("did.db")
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	defer store.Close()
+//	host := anp_didhost.NewHost(anp_didhost.Config{Store: store})
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/openanp/anp-go/anp_didhost"
+	_ "modernc.org/sqlite"
+)
+
+const schema = `CREATE TABLE IF NOT EXISTS anp_did_documents (
+	did        TEXT PRIMARY KEY,
+	document   BLOB NOT NULL,
+	updated_at INTEGER NOT NULL DEFAULT 0
+)`
+
+var _ anp_didhost.Store = (*Store)(nil)
+
+// Store keeps DID documents in a SQLite database.
+type Store struct {
+	db    *sql.DB
+	owned bool
+}
+
+// Open opens (creating if needed) the SQLite database at path.
+func Open(path string) (*Store, error) {
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return nil, err
+	}
+	s, err := New(db)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	s.owned = true
+	return s, nil
+}
+
+// New uses an already opened SQLite database, creating the documents table if
+// it does not exist.
+func New(db *sql.DB) (*Store, error) {
+	if _, err := db.Exec(schema); err != nil {
+		return nil, fmt.Errorf("sqlite: create schema: %w", err)
+	}
+	return &Store{db: db}, nil
+}
+
+// Close closes the database if it was opened by Open.
+func (s *Store) Close() error {
+	if !s.owned {
+		return nil
+	}
+	return s.db.Close()
+}
+
+// Get returns the document of did, or anp_didhost.ErrNotFound.
+func (s *Store) Get(ctx context.Context, did string) (anp_didhost.Record, error) {
+	var (
+		record  anp_didhost.Record
+		updated int64
+	)
+	err := s.db.QueryRowContext(ctx,
+		`SELECT document, updated_at FROM anp_did_documents WHERE did = ?`, did).
+		Scan(&record.Document, &updated)
+	if errors.Is(err, sql.ErrNoRows) {
+		return anp_didhost.Record{}, anp_didhost.ErrNotFound
+	}
+	if err != nil {
+		return anp_didhost.Record{}, err
+	}
+	if updated != 0 {
+		record.UpdatedAt = time.Unix(0, updated).UTC()
+	}
+	return record, nil
+}
+
+// Put inserts or replaces the document of did.
+func (s *Store) Put(ctx context.Context, did string, record anp_didhost.Record) error {
+	var updated int64
+	if !record.UpdatedAt.IsZero() {
+		updated = record.UpdatedAt.UnixNano()
+	}
+	_, err := s.db.ExecContext(ctx,
+		`INSERT INTO anp_did_documents (did, document, updated_at) VALUES (?, ?, ?)
+		ON CONFLICT (did) DO UPDATE SET document = excluded.document, updated_at = excluded.updated_at`,
+		did, record.Document, updated)
+	return err
+}
+
+// Delete removes the document of did.
+func (s *Store) Delete(ctx context.Context, did string) error {
+	_, err := s.db.ExecContext(ctx, `DELETE FROM anp_did_documents WHERE did = ?`, did)
+	return err
+}
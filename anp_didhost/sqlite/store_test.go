@@ -0,0 +1,53 @@
+package sqlite
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/openanp/anp-go/anp_didhost"
+)
+
+func TestStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "did.db")
+	store, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	ctx := context.Background()
+	const did = "did:wba:example.com:user:alice"
+	updated := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	if err := store.Put(ctx, did, anp_didhost.Record{Document: []byte(`{"id":"old"}`)}); err != nil {
+		t.Fatalf("Put() error = %v", err)
+	}
+	want := anp_didhost.Record{Document: []byte(`{"id":"` + did + `"}`), UpdatedAt: updated}
+	if err := store.Put(ctx, did, want); err != nil {
+		t.Fatalf("Put(update) error = %v", err)
+	}
+	if err := store.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	store, err = Open(path)
+	if err != nil {
+		t.Fatalf("reopen error = %v", err)
+	}
+	defer store.Close()
+	got, err := store.Get(ctx, did)
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if string(got.Document) != string(want.Document) || !got.UpdatedAt.Equal(updated) {
+		t.Errorf("Get() = %s at %v, want %s at %v", got.Document, got.UpdatedAt, want.Document, updated)
+	}
+
+	if err := store.Delete(ctx, did); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if _, err := store.Get(ctx, did); !errors.Is(err, anp_didhost.ErrNotFound) {
+		t.Errorf("Get(deleted) error = %v, want ErrNotFound", err)
+	}
+}
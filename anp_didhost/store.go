@@ -0,0 +1,183 @@
+package anp_didhost
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/bytedance/sonic"
+	"github.com/openanp/anp-go/anp_auth"
+)
+
+var (
+	_ Store = (*MemoryStore)(nil)
+	_ Store = (*DirStore)(nil)
+)
+
+// MemoryStore is an in-memory Store.
+type MemoryStore struct {
+	mu      sync.RWMutex
+	records map[string]Record
+}
+
+// NewMemoryStore creates an empty MemoryStore.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{records: make(map[string]Record)}
+}
+
+// Get implements Store.
+func (s *MemoryStore) Get(_ context.Context, did string) (Record, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	record, ok := s.records[did]
+	if !ok {
+		return Record{}, ErrNotFound
+	}
+	return record, nil
+}
+
+// Put implements Store.
+func (s *MemoryStore) Put(_ context.Context, did string, record Record) error {
+	record.Document = append([]byte(nil), record.Document...)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.records[did] = record
+	return nil
+}
+
+// Delete implements Store.
+func (s *MemoryStore) Delete(_ context.Context, did string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.records, did)
+	return nil
+}
+
+// DirStore keeps documents as files under a directory, laid out like the
+// URLs they are served at: did:wba:example.com:user:alice is stored in
+// <dir>/example.com/user/alice/did.json. The tree can equally be served by a
+// static web server.
+type DirStore struct {
+	dir string
+	mu  sync.Mutex // serialises writers
+}
+
+// NewDirStore stores documents under dir, which is created on first Put.
+func NewDirStore(dir string) *DirStore {
+	return &DirStore{dir: dir}
+}
+
+// path returns the file holding the document of did.
+func (s *DirStore) path(did string) (string, error) {
+	docURL, err := anp_auth.DIDDocumentURL(did)
+	if err != nil {
+		return "", fmt.Errorf("anp_didhost: %w", err)
+	}
+	u, err := url.Parse(docURL)
+	if err != nil {
+		return "", fmt.Errorf("anp_didhost: %w", err)
+	}
+	host := strings.ReplaceAll(u.Host, ":", "%3A")
+	if host == "" || host == "." || host == ".." || strings.ContainsAny(host, `/\`) {
+		return "", fmt.Errorf("anp_didhost: invalid host in %s", did)
+	}
+	rel := filepath.Join(host, filepath.FromSlash(u.Path))
+	if !filepath.IsLocal(rel) || !strings.HasPrefix(rel, host+string(filepath.Separator)) {
+		return "", fmt.Errorf("anp_didhost: %s escapes the store directory", did)
+	}
+	return filepath.Join(s.dir, rel), nil
+}
+
+// Get implements Store. Since did:wba and did:web DIDs with the same suffix
+// share a file, the document's id must match did.
+func (s *DirStore) Get(_ context.Context, did string) (Record, error) {
+	path, err := s.path(did)
+	if err != nil {
+		return Record{}, err
+	}
+	raw, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return Record{}, ErrNotFound
+	}
+	if err != nil {
+		return Record{}, fmt.Errorf("anp_didhost: read DID document: %w", err)
+	}
+	var doc struct {
+		ID string `json:"id"`
+	}
+	if err := sonic.Unmarshal(raw, &doc); err != nil {
+		return Record{}, fmt.Errorf("anp_didhost: decode %s: %w", path, err)
+	}
+	if doc.ID != did {
+		return Record{}, ErrNotFound
+	}
+	record := Record{Document: raw}
+	if info, err := os.Stat(path); err == nil {
+		record.UpdatedAt = info.ModTime().UTC()
+	}
+	return record, nil
+}
+
+// Put implements Store. The file is replaced atomically.
+func (s *DirStore) Put(_ context.Context, did string, record Record) error {
+	path, err := s.path(did)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("anp_didhost: create directory: %w", err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".did-*.json")
+	if err != nil {
+		return fmt.Errorf("anp_didhost: write DID document: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(record.Document); err != nil {
+		tmp.Close()
+		return fmt.Errorf("anp_didhost: write DID document: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("anp_didhost: write DID document: %w", err)
+	}
+	// DID documents are public.
+	if err := os.Chmod(tmp.Name(), 0o644); err != nil {
+		return fmt.Errorf("anp_didhost: write DID document: %w", err)
+	}
+	if !record.UpdatedAt.IsZero() {
+		if err := os.Chtimes(tmp.Name(), record.UpdatedAt, record.UpdatedAt); err != nil {
+			return fmt.Errorf("anp_didhost: write DID document: %w", err)
+		}
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("anp_didhost: write DID document: %w", err)
+	}
+	return nil
+}
+
+// Delete implements Store.
+func (s *DirStore) Delete(ctx context.Context, did string) error {
+	if _, err := s.Get(ctx, did); err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return nil
+		}
+		return err
+	}
+	path, err := s.path(did)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("anp_didhost: delete DID document: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,312 @@
+// Package anp_discovery keeps a local, searchable index of agents learned from
+// crawled directory documents and Agent Descriptions, so planners can choose an
+// agent by capability without crawling again.
+package anp_discovery
+
+import (
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bytedance/sonic"
+	"github.com/openanp/anp-go/anp_crawler"
+)
+
+// Field weights used when scoring keyword matches.
+const (
+	weightName        = 3
+	weightMethod      = 2
+	weightDescription = 1
+)
+
+// Agent is an indexed agent and the interfaces it is known to expose.
+type Agent struct {
+	URL         string      `json:"url"`
+	Name        string      `json:"name,omitempty"`
+	Description string      `json:"description,omitempty"`
+	Rating      float64     `json:"rating,omitempty"`
+	UsageCount  int64       `json:"usage_count,omitempty"`
+	ReviewCount int64       `json:"review_count,omitempty"`
+	Source      string      `json:"source,omitempty"`
+	Interfaces  []Interface `json:"interfaces,omitempty"`
+	UpdatedAt   time.Time   `json:"updated_at"`
+}
+
+// Interface summarises an interface entry for search purposes.
+type Interface struct {
+	Type        string   `json:"type,omitempty"`
+	Protocol    string   `json:"protocol,omitempty"`
+	Method      string   `json:"method,omitempty"`
+	Description string   `json:"description,omitempty"`
+	Servers     []string `json:"servers,omitempty"`
+}
+
+// Query filters and ranks agents. Zero-valued fields do not filter.
+type Query struct {
+	// Text holds capability keywords matched against names, method names and descriptions.
+	Text string
+	// Protocol matches an interface protocol or type, case-insensitively (e.g. "openrpc").
+	Protocol  string
+	MinRating float64
+	Limit     int
+}
+
+// Result is a ranked search hit.
+type Result struct {
+	Agent Agent
+	Score float64
+}
+
+// Index is an in-memory inverted index of agents, safe for concurrent use.
+type Index struct {
+	mu       sync.RWMutex
+	agents   map[string]*Agent
+	postings map[string]map[string]float64
+	now      func() time.Time
+}
+
+// NewIndex creates an empty Index.
+func NewIndex() *Index {
+	return &Index{
+		agents:   make(map[string]*Agent),
+		postings: make(map[string]map[string]float64),
+		now:      time.Now,
+	}
+}
+
+// AddAgents ingests the agentList entries of a directory document fetched from source.
+// Existing agents keep their known interfaces.
+func (ix *Index) AddAgents(source string, entries []anp_crawler.AgentEntry) {
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+
+	for _, entry := range entries {
+		if entry.URL == "" {
+			continue
+		}
+		agent := ix.take(entry.URL)
+		agent.Name = entry.Name
+		agent.Description = entry.Description
+		agent.Rating = entry.Rating
+		agent.UsageCount = entry.UsageCount
+		agent.ReviewCount = entry.ReviewCount
+		agent.Source = source
+		agent.UpdatedAt = ix.now()
+		ix.put(agent)
+	}
+}
+
+// AddInterfaces replaces the interfaces known for the agent described at agentURL.
+func (ix *Index) AddInterfaces(agentURL string, entries []anp_crawler.InterfaceEntry) {
+	if agentURL == "" {
+		return
+	}
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+
+	agent := ix.take(agentURL)
+	agent.Interfaces = make([]Interface, 0, len(entries))
+	for _, entry := range entries {
+		iface := Interface{
+			Type:        entry.Type,
+			Protocol:    entry.Protocol,
+			Method:      entry.MethodName,
+			Description: entry.Description,
+		}
+		if iface.Description == "" {
+			iface.Description = entry.Summary
+		}
+		for _, server := range entry.Servers {
+			iface.Servers = append(iface.Servers, server.URL)
+		}
+		agent.Interfaces = append(agent.Interfaces, iface)
+	}
+	agent.UpdatedAt = ix.now()
+	ix.put(agent)
+}
+
+// AddParseResult ingests a parsed document fetched from url: its agentList entries
+// and, when it describes an agent, its interfaces.
+func (ix *Index) AddParseResult(url string, result *anp_crawler.ParseResult) {
+	if result == nil {
+		return
+	}
+	if len(result.Agents) > 0 {
+		ix.AddAgents(url, result.Agents)
+	}
+	if len(result.Interfaces) > 0 {
+		ix.AddInterfaces(url, result.Interfaces)
+	}
+}
+
+// Remove drops the agent at url from the index.
+func (ix *Index) Remove(url string) {
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+	ix.take(url)
+}
+
+// Get returns the agent indexed at url.
+func (ix *Index) Get(url string) (Agent, bool) {
+	ix.mu.RLock()
+	defer ix.mu.RUnlock()
+	agent, ok := ix.agents[url]
+	if !ok {
+		return Agent{}, false
+	}
+	return *agent, true
+}
+
+// Len returns the number of indexed agents.
+func (ix *Index) Len() int {
+	ix.mu.RLock()
+	defer ix.mu.RUnlock()
+	return len(ix.agents)
+}
+
+// Search returns agents matching q, best first. Without Text every agent passing
+// the filters matches and results are ordered by rating.
+func (ix *Index) Search(q Query) []Result {
+	ix.mu.RLock()
+	defer ix.mu.RUnlock()
+
+	scores := make(map[string]float64)
+	if terms := tokenize(q.Text); len(terms) > 0 {
+		for _, term := range terms {
+			for url, weight := range ix.postings[term] {
+				scores[url] += weight
+			}
+		}
+	} else {
+		for url := range ix.agents {
+			scores[url] = 0
+		}
+	}
+
+	results := make([]Result, 0, len(scores))
+	for url, score := range scores {
+		agent := ix.agents[url]
+		if agent.Rating < q.MinRating || !agent.supports(q.Protocol) {
+			continue
+		}
+		results = append(results, Result{Agent: *agent, Score: score})
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		a, b := results[i], results[j]
+		if a.Score != b.Score {
+			return a.Score > b.Score
+		}
+		if a.Agent.Rating != b.Agent.Rating {
+			return a.Agent.Rating > b.Agent.Rating
+		}
+		return a.Agent.URL < b.Agent.URL
+	})
+
+	if q.Limit > 0 && len(results) > q.Limit {
+		results = results[:q.Limit]
+	}
+	return results
+}
+
+// snapshot is the persisted form of an Index.
+type snapshot struct {
+	Agents []*Agent `json:"agents"`
+}
+
+// Save writes the indexed agents to w as JSON.
+func (ix *Index) Save(w io.Writer) error {
+	ix.mu.RLock()
+	defer ix.mu.RUnlock()
+
+	snap := snapshot{Agents: make([]*Agent, 0, len(ix.agents))}
+	for _, agent := range ix.agents {
+		snap.Agents = append(snap.Agents, agent)
+	}
+	sort.Slice(snap.Agents, func(i, j int) bool { return snap.Agents[i].URL < snap.Agents[j].URL })
+	return sonic.ConfigDefault.NewEncoder(w).Encode(&snap)
+}
+
+// Load replaces the index contents with agents previously written by Save.
+func (ix *Index) Load(r io.Reader) error {
+	var snap snapshot
+	if err := sonic.ConfigDefault.NewDecoder(r).Decode(&snap); err != nil {
+		return err
+	}
+
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+	ix.agents = make(map[string]*Agent, len(snap.Agents))
+	ix.postings = make(map[string]map[string]float64)
+	for _, agent := range snap.Agents {
+		if agent != nil && agent.URL != "" {
+			ix.put(agent)
+		}
+	}
+	return nil
+}
+
+// take removes the agent at url from the postings and returns it (or a new record)
+// for modification. The caller must hold the write lock and call put afterwards.
+func (ix *Index) take(url string) *Agent {
+	agent, ok := ix.agents[url]
+	if !ok {
+		return &Agent{URL: url}
+	}
+	for term := range agent.terms() {
+		delete(ix.postings[term], url)
+		if len(ix.postings[term]) == 0 {
+			delete(ix.postings, term)
+		}
+	}
+	delete(ix.agents, url)
+	return agent
+}
+
+// put stores agent and indexes its terms. The caller must hold the write lock.
+func (ix *Index) put(agent *Agent) {
+	ix.agents[agent.URL] = agent
+	for term, weight := range agent.terms() {
+		posting, ok := ix.postings[term]
+		if !ok {
+			posting = make(map[string]float64)
+			ix.postings[term] = posting
+		}
+		posting[agent.URL] = weight
+	}
+}
+
+// terms returns the agent's index terms with the highest weight each term earned.
+func (a *Agent) terms() map[string]float64 {
+	terms := make(map[string]float64)
+	add := func(text string, weight float64) {
+		for _, term := range tokenize(text) {
+			if weight > terms[term] {
+				terms[term] = weight
+			}
+		}
+	}
+
+	add(a.Name, weightName)
+	add(a.Description, weightDescription)
+	for _, iface := range a.Interfaces {
+		add(iface.Method, weightMethod)
+		add(iface.Description, weightDescription)
+	}
+	return terms
+}
+
+// supports reports whether any interface uses protocol (empty matches all).
+func (a *Agent) supports(protocol string) bool {
+	if protocol == "" {
+		return true
+	}
+	for _, iface := range a.Interfaces {
+		if strings.EqualFold(iface.Protocol, protocol) || strings.EqualFold(iface.Type, protocol) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,127 @@
+package anp_discovery
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/openanp/anp-go/anp_crawler"
+)
+
+func newTestIndex() *Index {
+	ix := NewIndex()
+	ix.AddAgents("https://dir.example.com/agents.json", []anp_crawler.AgentEntry{
+		{Name: "Hotel Booking", Description: "Find and book hotel rooms", URL: "https://hotel.example.com/ad.json", Rating: 4.5},
+		{Name: "Weather", Description: "Forecasts for any city", URL: "https://weather.example.com/ad.json", Rating: 4.8},
+		{Name: "高德地图", Description: "地图搜索与路线规划", URL: "https://amap.example.com/ad.json", Rating: 3.9},
+	})
+	ix.AddInterfaces("https://hotel.example.com/ad.json", []anp_crawler.InterfaceEntry{
+		{Type: "structured", Protocol: "openrpc", MethodName: "searchHotels", Description: "Search available rooms",
+			Servers: []anp_crawler.Server{{URL: "https://hotel.example.com/rpc"}}},
+	})
+	ix.AddInterfaces("https://weather.example.com/ad.json", []anp_crawler.InterfaceEntry{
+		{Type: "natural_language", Protocol: "YAML", MethodName: "", Description: "Ask about the weather"},
+	})
+	return ix
+}
+
+func urls(results []Result) []string {
+	out := make([]string, len(results))
+	for i, r := range results {
+		out[i] = r.Agent.URL
+	}
+	return out
+}
+
+func TestIndex_Search(t *testing.T) {
+	ix := newTestIndex()
+
+	tests := []struct {
+		name  string
+		query Query
+		want  []string
+	}{
+		{"keyword in name", Query{Text: "hotel"}, []string{"https://hotel.example.com/ad.json"}},
+		{"camelCase method part", Query{Text: "search"}, []string{"https://hotel.example.com/ad.json"}},
+		{"han characters", Query{Text: "路线"}, []string{"https://amap.example.com/ad.json"}},
+		{"protocol filter", Query{Protocol: "OpenRPC"}, []string{"https://hotel.example.com/ad.json"}},
+		{"min rating orders by rating", Query{MinRating: 4}, []string{"https://weather.example.com/ad.json", "https://hotel.example.com/ad.json"}},
+		{"limit", Query{Limit: 1}, []string{"https://weather.example.com/ad.json"}},
+		{"no match", Query{Text: "flights"}, []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := urls(ix.Search(tt.query))
+			if len(got) != len(tt.want) {
+				t.Fatalf("Search() = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("Search()[%d] = %s, want %s", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestIndex_ReplaceAndRemove(t *testing.T) {
+	ix := newTestIndex()
+
+	ix.AddInterfaces("https://hotel.example.com/ad.json", []anp_crawler.InterfaceEntry{
+		{Protocol: "openrpc", MethodName: "cancelBooking"},
+	})
+	if got := ix.Search(Query{Text: "searchHotels"}); len(got) != 0 {
+		t.Errorf("Expected stale method to be unindexed, got %v", urls(got))
+	}
+	if got := ix.Search(Query{Text: "cancel"}); len(got) != 1 {
+		t.Errorf("Expected new method to be indexed, got %v", urls(got))
+	}
+	if agent, _ := ix.Get("https://hotel.example.com/ad.json"); agent.Name != "Hotel Booking" {
+		t.Errorf("Expected directory metadata to survive interface update, got %+v", agent)
+	}
+
+	ix.Remove("https://hotel.example.com/ad.json")
+	if ix.Len() != 2 {
+		t.Errorf("Len() = %d, want 2", ix.Len())
+	}
+	if got := ix.Search(Query{Text: "hotel"}); len(got) != 0 {
+		t.Errorf("Expected removed agent to be unsearchable, got %v", urls(got))
+	}
+}
+
+func TestIndex_SaveLoad(t *testing.T) {
+	ix := newTestIndex()
+
+	var buf bytes.Buffer
+	if err := ix.Save(&buf); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	restored := NewIndex()
+	if err := restored.Load(&buf); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if restored.Len() != ix.Len() {
+		t.Fatalf("Len() = %d, want %d", restored.Len(), ix.Len())
+	}
+	got := restored.Search(Query{Text: "rooms", Protocol: "openrpc"})
+	if len(got) != 1 || got[0].Agent.URL != "https://hotel.example.com/ad.json" {
+		t.Errorf("unexpected search after Load: %v", urls(got))
+	}
+	if got[0].Agent.UpdatedAt.IsZero() {
+		t.Error("Expected UpdatedAt to be preserved")
+	}
+}
+
+func TestTokenize(t *testing.T) {
+	got := tokenize("searchHotels, 北京 API-v2")
+	want := []string{"searchhotels", "search", "hotels", "北", "京", "api", "v2"}
+	if len(got) != len(want) {
+		t.Fatalf("tokenize() = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("tokenize()[%d] = %q, want %q", i, got[i], want[i])
+		}
+	}
+}
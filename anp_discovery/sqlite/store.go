@@ -0,0 +1,117 @@
+// Package sqlite provides a SQLite-backed anp_discovery.Store, so directory
+// sync state survives restarts.
+//
+// It is a separate module so the core SDK does not depend on a SQLite driver.
+// It uses the pure-Go modernc.org/sqlite driver and needs no cgo:
+//
+//	store, err := sqlite.Open("agents.db")
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	defer store.Close()
+//	syncer, _ := anp_discovery.NewSyncer(anp_discovery.SyncConfig{
+//		Fetcher:     sess,
+//		Directories: []string{"https://agent-search.ai/agents.json"},
+//		Store:       store,
+//	})
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/openanp/anp-go/anp_crawler"
+	"github.com/openanp/anp-go/anp_discovery"
+	_ "modernc.org/sqlite"
+)
+
+const schema = `CREATE TABLE IF NOT EXISTS anp_directory_entries (
+	source       TEXT NOT NULL,
+	url          TEXT NOT NULL,
+	name         TEXT NOT NULL DEFAULT '',
+	description  TEXT NOT NULL DEFAULT '',
+	rating       REAL NOT NULL DEFAULT 0,
+	usage_count  INTEGER NOT NULL DEFAULT 0,
+	review_count INTEGER NOT NULL DEFAULT 0,
+	PRIMARY KEY (source, url)
+)`
+
+var _ anp_discovery.Store = (*Store)(nil)
+
+// Store keeps directory entries in a SQLite database.
+type Store struct {
+	db    *sql.DB
+	owned bool
+}
+
+// Open opens (creating if needed) the SQLite database at path.
+func Open(path string) (*Store, error) {
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return nil, err
+	}
+	s, err := New(db)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	s.owned = true
+	return s, nil
+}
+
+// New uses an already opened SQLite database, creating the entries table if it
+// does not exist.
+func New(db *sql.DB) (*Store, error) {
+	if _, err := db.Exec(schema); err != nil {
+		return nil, fmt.Errorf("sqlite: create schema: %w", err)
+	}
+	return &Store{db: db}, nil
+}
+
+// Close closes the database if it was opened by Open.
+func (s *Store) Close() error {
+	if !s.owned {
+		return nil
+	}
+	return s.db.Close()
+}
+
+// Entries returns the entries stored for source, ordered by URL.
+func (s *Store) Entries(ctx context.Context, source string) ([]anp_crawler.AgentEntry, error) {
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT url, name, description, rating, usage_count, review_count
+		FROM anp_directory_entries WHERE source = ? ORDER BY url`, source)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []anp_crawler.AgentEntry
+	for rows.Next() {
+		var e anp_crawler.AgentEntry
+		if err := rows.Scan(&e.URL, &e.Name, &e.Description, &e.Rating, &e.UsageCount, &e.ReviewCount); err != nil {
+			return nil, err
+		}
+		entries = append(entries, e)
+	}
+	return entries, rows.Err()
+}
+
+// Put inserts or replaces the entry for entry.URL within source.
+func (s *Store) Put(ctx context.Context, source string, entry anp_crawler.AgentEntry) error {
+	_, err := s.db.ExecContext(ctx,
+		`INSERT INTO anp_directory_entries (source, url, name, description, rating, usage_count, review_count)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (source, url) DO UPDATE SET
+			name = excluded.name, description = excluded.description, rating = excluded.rating,
+			usage_count = excluded.usage_count, review_count = excluded.review_count`,
+		source, entry.URL, entry.Name, entry.Description, entry.Rating, entry.UsageCount, entry.ReviewCount)
+	return err
+}
+
+// Delete removes the entry for url within source.
+func (s *Store) Delete(ctx context.Context, source, url string) error {
+	_, err := s.db.ExecContext(ctx, `DELETE FROM anp_directory_entries WHERE source = ? AND url = ?`, source, url)
+	return err
+}
@@ -0,0 +1,60 @@
+package sqlite
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/openanp/anp-go/anp_crawler"
+)
+
+func TestStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "agents.db")
+	store, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	ctx := context.Background()
+	const dir = "https://dir.example.com/agents.json"
+	hotel := anp_crawler.AgentEntry{Name: "Hotel", Description: "Book rooms", URL: "https://hotel.example.com/ad.json", Rating: 4.5, UsageCount: 7}
+	weather := anp_crawler.AgentEntry{Name: "Weather", URL: "https://weather.example.com/ad.json"}
+
+	for _, e := range []anp_crawler.AgentEntry{weather, hotel} {
+		if err := store.Put(ctx, dir, e); err != nil {
+			t.Fatalf("Put() error = %v", err)
+		}
+	}
+	hotel.Rating = 4.9
+	if err := store.Put(ctx, dir, hotel); err != nil {
+		t.Fatalf("Put(update) error = %v", err)
+	}
+	if err := store.Put(ctx, "https://other.example.com/agents.json", weather); err != nil {
+		t.Fatalf("Put(other source) error = %v", err)
+	}
+	if err := store.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	store, err = Open(path)
+	if err != nil {
+		t.Fatalf("reopen error = %v", err)
+	}
+	defer store.Close()
+	entries, err := store.Entries(ctx, dir)
+	if err != nil {
+		t.Fatalf("Entries() error = %v", err)
+	}
+	if len(entries) != 2 || entries[0] != hotel || entries[1] != weather {
+		t.Fatalf("Entries() = %+v", entries)
+	}
+
+	if err := store.Delete(ctx, dir, weather.URL); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if entries, _ := store.Entries(ctx, dir); len(entries) != 1 {
+		t.Errorf("Entries() after delete = %+v", entries)
+	}
+	if entries, _ := store.Entries(ctx, "https://other.example.com/agents.json"); len(entries) != 1 {
+		t.Errorf("other source entries = %+v", entries)
+	}
+}
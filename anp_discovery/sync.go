@@ -0,0 +1,257 @@
+package anp_discovery
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/openanp/anp-go/anp_crawler"
+	"github.com/openanp/anp-go/session"
+)
+
+const defaultSyncInterval = time.Hour
+
+// EventKind classifies a directory change.
+type EventKind string
+
+const (
+	EntryAdded   EventKind = "added"
+	EntryUpdated EventKind = "updated"
+	EntryRemoved EventKind = "removed"
+)
+
+// Event reports one agentList entry that changed between two syncs of a
+// directory. Previous is set for updates and removals.
+type Event struct {
+	Kind     EventKind
+	Source   string
+	Entry    anp_crawler.AgentEntry
+	Previous *anp_crawler.AgentEntry
+}
+
+// Store persists the entries last seen in each directory. Implementations must
+// be safe for concurrent use; see MemoryStore and the anp_discovery/sqlite
+// module.
+type Store interface {
+	// Entries returns the entries stored for the directory at source.
+	Entries(ctx context.Context, source string) ([]anp_crawler.AgentEntry, error)
+	// Put inserts or replaces the entry for entry.URL within source.
+	Put(ctx context.Context, source string, entry anp_crawler.AgentEntry) error
+	// Delete removes the entry for url within source.
+	Delete(ctx context.Context, source, url string) error
+}
+
+// Fetcher retrieves and parses directory documents; *session.Session
+// implements it.
+type Fetcher interface {
+	Fetch(ctx context.Context, url string) (*session.Document, error)
+}
+
+var _ Fetcher = (*session.Session)(nil)
+
+// SyncConfig configures a Syncer.
+type SyncConfig struct {
+	// Fetcher fetches the directories, usually an authenticated session.
+	Fetcher Fetcher
+	// Directories lists the directory document URLs to keep in sync.
+	Directories []string
+	// Store holds the last known entries (default a new MemoryStore).
+	Store Store
+	// Index, when set, is updated with every change.
+	Index *Index
+	// Interval between syncs in Run (default 1h).
+	Interval time.Duration
+	// OnEvent receives every change, in directory order.
+	OnEvent func(Event)
+	Logger  *slog.Logger
+}
+
+// Syncer periodically re-crawls agent directories and diffs their agentList
+// against a Store, so applications keep a fresh local view of the network.
+type Syncer struct {
+	cfg    SyncConfig
+	logger *slog.Logger
+	mu     sync.Mutex // serialises syncs
+}
+
+// NewSyncer creates a Syncer.
+func NewSyncer(cfg SyncConfig) (*Syncer, error) {
+	if cfg.Fetcher == nil {
+		return nil, errors.New("anp_discovery: fetcher is nil")
+	}
+	if cfg.Store == nil {
+		cfg.Store = NewMemoryStore()
+	}
+	if cfg.Interval <= 0 {
+		cfg.Interval = defaultSyncInterval
+	}
+	logger := cfg.Logger
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return &Syncer{cfg: cfg, logger: logger}, nil
+}
+
+// Run syncs immediately and then every Interval until ctx is done. Failed
+// syncs are logged and retried on the next tick.
+func (s *Syncer) Run(ctx context.Context) error {
+	ticker := time.NewTicker(s.cfg.Interval)
+	defer ticker.Stop()
+	for {
+		if _, err := s.SyncOnce(ctx); err != nil && ctx.Err() == nil {
+			s.logger.Warn("directory sync failed", "error", err)
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// SyncOnce fetches every directory, applies the differences to the Store and
+// Index, and returns the resulting events. A directory that cannot be fetched
+// keeps its stored entries; its error is joined into the returned error.
+func (s *Syncer) SyncOnce(ctx context.Context) ([]Event, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var (
+		events []Event
+		errs   []error
+	)
+	for _, dir := range s.cfg.Directories {
+		dirEvents, err := s.syncDirectory(ctx, dir)
+		events = append(events, dirEvents...)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("sync %s: %w", dir, err))
+		}
+	}
+	return events, errors.Join(errs...)
+}
+
+func (s *Syncer) syncDirectory(ctx context.Context, dir string) ([]Event, error) {
+	doc, err := s.cfg.Fetcher.Fetch(ctx, dir)
+	if err != nil {
+		return nil, err
+	}
+	var current []anp_crawler.AgentEntry
+	if doc.Result != nil {
+		current = doc.Result.Agents
+	}
+	stored, err := s.cfg.Store.Entries(ctx, dir)
+	if err != nil {
+		return nil, fmt.Errorf("load entries: %w", err)
+	}
+
+	known := make(map[string]anp_crawler.AgentEntry, len(stored))
+	for _, entry := range stored {
+		known[entry.URL] = entry
+	}
+
+	var events []Event
+	seen := make(map[string]bool, len(current))
+	for _, entry := range current {
+		if entry.URL == "" || seen[entry.URL] {
+			continue
+		}
+		seen[entry.URL] = true
+		prev, ok := known[entry.URL]
+		if ok && prev == entry {
+			continue
+		}
+		if err := s.cfg.Store.Put(ctx, dir, entry); err != nil {
+			return events, fmt.Errorf("store %s: %w", entry.URL, err)
+		}
+		ev := Event{Kind: EntryAdded, Source: dir, Entry: entry}
+		if ok {
+			ev.Kind, ev.Previous = EntryUpdated, &prev
+		}
+		events = append(events, s.emit(ev))
+	}
+
+	var removed []string
+	for url := range known {
+		if !seen[url] {
+			removed = append(removed, url)
+		}
+	}
+	sort.Strings(removed)
+	for _, url := range removed {
+		if err := s.cfg.Store.Delete(ctx, dir, url); err != nil {
+			return events, fmt.Errorf("delete %s: %w", url, err)
+		}
+		prev := known[url]
+		events = append(events, s.emit(Event{Kind: EntryRemoved, Source: dir, Entry: prev, Previous: &prev}))
+	}
+
+	if len(events) > 0 {
+		s.logger.Debug("directory synced", "url", dir, "changes", len(events))
+	}
+	return events, nil
+}
+
+func (s *Syncer) emit(ev Event) Event {
+	if ix := s.cfg.Index; ix != nil {
+		if ev.Kind == EntryRemoved {
+			ix.Remove(ev.Entry.URL)
+		} else {
+			ix.AddAgents(ev.Source, []anp_crawler.AgentEntry{ev.Entry})
+		}
+	}
+	if s.cfg.OnEvent != nil {
+		s.cfg.OnEvent(ev)
+	}
+	return ev
+}
+
+// MemoryStore is an in-process Store.
+type MemoryStore struct {
+	mu      sync.RWMutex
+	entries map[string]map[string]anp_crawler.AgentEntry
+}
+
+// NewMemoryStore creates an empty MemoryStore.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{entries: make(map[string]map[string]anp_crawler.AgentEntry)}
+}
+
+// Entries returns the entries stored for source, ordered by URL.
+func (m *MemoryStore) Entries(_ context.Context, source string) ([]anp_crawler.AgentEntry, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	out := make([]anp_crawler.AgentEntry, 0, len(m.entries[source]))
+	for _, entry := range m.entries[source] {
+		out = append(out, entry)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].URL < out[j].URL })
+	return out, nil
+}
+
+// Put stores entry under source.
+func (m *MemoryStore) Put(_ context.Context, source string, entry anp_crawler.AgentEntry) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	dir, ok := m.entries[source]
+	if !ok {
+		dir = make(map[string]anp_crawler.AgentEntry)
+		m.entries[source] = dir
+	}
+	dir[entry.URL] = entry
+	return nil
+}
+
+// Delete removes url from source.
+func (m *MemoryStore) Delete(_ context.Context, source, url string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.entries[source], url)
+	if len(m.entries[source]) == 0 {
+		delete(m.entries, source)
+	}
+	return nil
+}
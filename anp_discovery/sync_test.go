@@ -0,0 +1,121 @@
+package anp_discovery
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/openanp/anp-go/anp_crawler"
+	"github.com/openanp/anp-go/session"
+)
+
+// fakeFetcher serves directory documents from memory.
+type fakeFetcher map[string][]anp_crawler.AgentEntry
+
+func (f fakeFetcher) Fetch(_ context.Context, url string) (*session.Document, error) {
+	agents, ok := f[url]
+	if !ok {
+		return nil, errors.New("not found")
+	}
+	return &session.Document{URL: url, Result: &anp_crawler.ParseResult{Agents: agents}}, nil
+}
+
+func kinds(events []Event) map[string]EventKind {
+	out := make(map[string]EventKind, len(events))
+	for _, ev := range events {
+		out[ev.Entry.URL] = ev.Kind
+	}
+	return out
+}
+
+func TestSyncer_SyncOnce(t *testing.T) {
+	const dir = "https://dir.example.com/agents.json"
+	hotel := anp_crawler.AgentEntry{Name: "Hotel", URL: "https://hotel.example.com/ad.json", Rating: 4.5}
+	weather := anp_crawler.AgentEntry{Name: "Weather", URL: "https://weather.example.com/ad.json", Rating: 4.8}
+	fetcher := fakeFetcher{dir: {hotel, weather}}
+
+	ix := NewIndex()
+	var seen []Event
+	s, err := NewSyncer(SyncConfig{
+		Fetcher:     fetcher,
+		Directories: []string{dir},
+		Index:       ix,
+		OnEvent:     func(ev Event) { seen = append(seen, ev) },
+	})
+	if err != nil {
+		t.Fatalf("NewSyncer() error = %v", err)
+	}
+	ctx := context.Background()
+
+	events, err := s.SyncOnce(ctx)
+	if err != nil {
+		t.Fatalf("SyncOnce() error = %v", err)
+	}
+	if got := kinds(events); len(got) != 2 || got[hotel.URL] != EntryAdded || got[weather.URL] != EntryAdded {
+		t.Errorf("first sync events = %v", got)
+	}
+	if ix.Len() != 2 {
+		t.Errorf("index len = %d, want 2", ix.Len())
+	}
+
+	if events, _ := s.SyncOnce(ctx); len(events) != 0 {
+		t.Errorf("unchanged sync events = %v, want none", kinds(events))
+	}
+
+	renamed := hotel
+	renamed.Name = "Grand Hotel"
+	maps := anp_crawler.AgentEntry{Name: "Maps", URL: "https://maps.example.com/ad.json"}
+	fetcher[dir] = []anp_crawler.AgentEntry{renamed, maps}
+	events, err = s.SyncOnce(ctx)
+	if err != nil {
+		t.Fatalf("SyncOnce() error = %v", err)
+	}
+	want := map[string]EventKind{hotel.URL: EntryUpdated, maps.URL: EntryAdded, weather.URL: EntryRemoved}
+	got := kinds(events)
+	for url, kind := range want {
+		if got[url] != kind {
+			t.Errorf("event for %s = %q, want %q", url, got[url], kind)
+		}
+	}
+	for _, ev := range events {
+		if ev.Kind == EntryUpdated && (ev.Previous == nil || ev.Previous.Name != "Hotel") {
+			t.Errorf("update previous = %+v", ev.Previous)
+		}
+	}
+	if _, ok := ix.Get(weather.URL); ok {
+		t.Error("removed agent still indexed")
+	}
+	if agent, _ := ix.Get(hotel.URL); agent.Name != "Grand Hotel" {
+		t.Errorf("indexed name = %q, want update", agent.Name)
+	}
+	if len(seen) != 5 {
+		t.Errorf("OnEvent calls = %d, want 5", len(seen))
+	}
+}
+
+func TestSyncer_FetchFailureKeepsEntries(t *testing.T) {
+	const dir = "https://dir.example.com/agents.json"
+	fetcher := fakeFetcher{dir: {{Name: "Hotel", URL: "https://hotel.example.com/ad.json"}}}
+	store := NewMemoryStore()
+	s, err := NewSyncer(SyncConfig{Fetcher: fetcher, Directories: []string{dir, "https://down.example.com/agents.json"}, Store: store})
+	if err != nil {
+		t.Fatalf("NewSyncer() error = %v", err)
+	}
+	ctx := context.Background()
+	if _, err := s.SyncOnce(ctx); err == nil {
+		t.Error("SyncOnce() succeeded with an unreachable directory")
+	}
+
+	delete(fetcher, dir)
+	events, err := s.SyncOnce(ctx)
+	if err == nil || len(events) != 0 {
+		t.Errorf("SyncOnce() = %v, %v, want error and no events", events, err)
+	}
+	if entries, _ := store.Entries(ctx, dir); len(entries) != 1 {
+		t.Errorf("stored entries = %d, want 1 kept", len(entries))
+	}
+
+	if _, err := NewSyncer(SyncConfig{}); err == nil {
+		t.Error("NewSyncer() without fetcher succeeded")
+	}
+}
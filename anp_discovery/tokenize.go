@@ -0,0 +1,63 @@
+package anp_discovery
+
+import (
+	"strings"
+	"unicode"
+)
+
+// tokenize splits text into lower-case search terms. Words are split on
+// non-alphanumeric runes and camelCase boundaries (keeping the joined word too, so
+// "searchHotels" yields "searchhotels", "search" and "hotels"). Han characters have
+// no word separators and are indexed one rune per term.
+func tokenize(text string) []string {
+	var (
+		terms []string
+		word  []rune
+	)
+	seen := make(map[string]bool)
+	emit := func(term string) {
+		if term != "" && !seen[term] {
+			seen[term] = true
+			terms = append(terms, term)
+		}
+	}
+	flush := func() {
+		if len(word) == 0 {
+			return
+		}
+		emit(strings.ToLower(string(word)))
+		if parts := splitCamel(word); len(parts) > 1 {
+			for _, part := range parts {
+				emit(strings.ToLower(part))
+			}
+		}
+		word = word[:0]
+	}
+
+	for _, r := range text {
+		switch {
+		case unicode.Is(unicode.Han, r):
+			flush()
+			emit(string(r))
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			word = append(word, r)
+		default:
+			flush()
+		}
+	}
+	flush()
+	return terms
+}
+
+// splitCamel splits word at lower-to-upper transitions.
+func splitCamel(word []rune) []string {
+	var parts []string
+	start := 0
+	for i := 1; i < len(word); i++ {
+		if unicode.IsUpper(word[i]) && unicode.IsLower(word[i-1]) {
+			parts = append(parts, string(word[start:i]))
+			start = i
+		}
+	}
+	return append(parts, string(word[start:]))
+}
@@ -0,0 +1,182 @@
+// Package anp_e2e provides end-to-end encryption between two DID-identified agents,
+// so messages stay confidential when they traverse untrusted relays.
+//
+// Peers agree on keys with an ephemeral X25519 exchange (ECDHE). Each side signs its
+// ephemeral key with its DID key, so a relay can neither read nor substitute keys.
+// The shared secret is expanded with HKDF-SHA256 into one AES-256-GCM key per
+// direction, identified by a key ID that travels with every Envelope.
+//
+//	alice, init, _ := anp_e2e.Initiate(aliceID, bobDoc)
+//	bob, accept, _ := anp_e2e.Accept(bobID, init, aliceDoc)
+//	alice.HandleHandshake(accept)
+//	env, _ := alice.Encrypt([]byte("hello"))
+//	plaintext, _ := bob.Decrypt(env)
+//
+// Either side may call Rekey at any time to rotate keys; recently replaced keys
+// remain valid for decryption so in-flight messages are not lost.
+package anp_e2e
+
+import (
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/bytedance/sonic"
+	"github.com/cyberphone/json-canonicalization/go/src/webpki.org/jsoncanonicalizer"
+	"github.com/google/uuid"
+	"github.com/openanp/anp-go/anp_auth"
+)
+
+// HandshakeType distinguishes the two handshake messages.
+type HandshakeType string
+
+const (
+	// HandshakeInit starts a key agreement (or a rotation).
+	HandshakeInit HandshakeType = "e2e.init"
+	// HandshakeAccept answers a HandshakeInit.
+	HandshakeAccept HandshakeType = "e2e.accept"
+)
+
+// Handshake is a signed key-agreement message.
+type Handshake struct {
+	Type         HandshakeType `json:"type"`
+	KeyID        string        `json:"key_id"`
+	From         string        `json:"from"`
+	To           string        `json:"to"`
+	EphemeralKey string        `json:"ephemeral_key"`
+	// PeerEphemeralKey echoes the initiator's key in HandshakeAccept, binding the
+	// answer to one specific HandshakeInit.
+	PeerEphemeralKey   string `json:"peer_ephemeral_key,omitempty"`
+	Timestamp          string `json:"timestamp"`
+	VerificationMethod string `json:"verification_method"`
+	Signature          string `json:"signature,omitempty"`
+}
+
+// Identity is the local agent's DID and signing key.
+type Identity struct {
+	Document   *anp_auth.DIDWBADocument
+	PrivateKey *ecdsa.PrivateKey
+	// VerificationMethod is the full DID URL of the signing key. Defaults to the
+	// document's first authentication method.
+	VerificationMethod string
+}
+
+func (id Identity) validate() error {
+	if id.Document == nil {
+		return errors.New("DID document is required")
+	}
+	if id.PrivateKey == nil {
+		return errors.New("private key is required")
+	}
+	return nil
+}
+
+func (id Identity) verificationMethod() (string, error) {
+	vm := id.VerificationMethod
+	if vm == "" {
+		if len(id.Document.Authentication) == 0 {
+			return "", fmt.Errorf("%w: DID document has no authentication method", anp_auth.ErrVerificationMethodNotFound)
+		}
+		vm = id.Document.Authentication[0]
+	}
+	if strings.HasPrefix(vm, "#") {
+		vm = id.Document.ID + vm
+	}
+	return vm, nil
+}
+
+// newHandshake creates and signs a handshake carrying a fresh ephemeral key.
+func newHandshake(local Identity, typ HandshakeType, keyID, to, peerEphemeral string, now time.Time) (*Handshake, *ecdh.PrivateKey, error) {
+	vm, err := local.verificationMethod()
+	if err != nil {
+		return nil, nil, err
+	}
+	ephemeral, err := ecdh.X25519().GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, nil, fmt.Errorf("generate ephemeral key: %w", err)
+	}
+	if keyID == "" {
+		keyID = uuid.NewString()
+	}
+
+	h := &Handshake{
+		Type:               typ,
+		KeyID:              keyID,
+		From:               local.Document.ID,
+		To:                 to,
+		EphemeralKey:       base64.RawURLEncoding.EncodeToString(ephemeral.PublicKey().Bytes()),
+		PeerEphemeralKey:   peerEphemeral,
+		Timestamp:          now.UTC().Format(time.RFC3339),
+		VerificationMethod: vm,
+	}
+	content, err := h.signingInput()
+	if err != nil {
+		return nil, nil, err
+	}
+	h.Signature, err = anp_auth.SignContent(local.PrivateKey, content)
+	if err != nil {
+		return nil, nil, fmt.Errorf("sign handshake: %w", err)
+	}
+	return h, ephemeral, nil
+}
+
+// verify checks that h was signed by peerDoc's DID, is addressed to localDID and is fresh.
+func (h *Handshake) verify(peerDoc *anp_auth.DIDWBADocument, localDID string, now time.Time, maxSkew time.Duration) error {
+	if h == nil {
+		return fmt.Errorf("%w: handshake is nil", ErrHandshakeInvalid)
+	}
+	if h.From != peerDoc.ID || h.To != localDID {
+		return fmt.Errorf("%w: handshake from %s to %s, expected %s to %s", ErrHandshakeInvalid, h.From, h.To, peerDoc.ID, localDID)
+	}
+	if !strings.HasPrefix(h.VerificationMethod, peerDoc.ID+"#") {
+		return fmt.Errorf("%w: verification method %s does not belong to %s", ErrHandshakeInvalid, h.VerificationMethod, peerDoc.ID)
+	}
+	ts, err := time.Parse(time.RFC3339, h.Timestamp)
+	if err != nil {
+		return fmt.Errorf("%w: parse timestamp: %v", ErrHandshakeInvalid, err)
+	}
+	if d := now.Sub(ts); d > maxSkew || d < -maxSkew {
+		return fmt.Errorf("%w: timestamp %s outside allowed skew", ErrHandshakeInvalid, h.Timestamp)
+	}
+
+	content, err := h.signingInput()
+	if err != nil {
+		return err
+	}
+	if err := anp_auth.VerifyContent(peerDoc, h.VerificationMethod, content, h.Signature); err != nil {
+		return fmt.Errorf("%w: %v", ErrHandshakeInvalid, err)
+	}
+	return nil
+}
+
+func (h *Handshake) ephemeralKey() (*ecdh.PublicKey, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(h.EphemeralKey)
+	if err != nil {
+		return nil, fmt.Errorf("%w: decode ephemeral key: %v", ErrHandshakeInvalid, err)
+	}
+	key, err := ecdh.X25519().NewPublicKey(raw)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrHandshakeInvalid, err)
+	}
+	return key, nil
+}
+
+// signingInput returns the JCS-canonical handshake without its signature.
+func (h *Handshake) signingInput() ([]byte, error) {
+	unsigned := *h
+	unsigned.Signature = ""
+	raw, err := sonic.Marshal(&unsigned)
+	if err != nil {
+		return nil, fmt.Errorf("marshal handshake: %w", err)
+	}
+	canonical, err := jsoncanonicalizer.Transform(raw)
+	if err != nil {
+		return nil, fmt.Errorf("canonicalize handshake: %w", err)
+	}
+	return canonical, nil
+}
@@ -0,0 +1,371 @@
+package anp_e2e
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/hkdf"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/openanp/anp-go/anp_auth"
+)
+
+const (
+	// DefaultMaxSkew bounds the age of accepted handshakes.
+	DefaultMaxSkew = 5 * time.Minute
+	// DefaultRetainedKeys is how many replaced keys stay usable for decryption.
+	DefaultRetainedKeys = 2
+	// DefaultRekeyMessages and DefaultRekeyAge drive NeedsRekey.
+	DefaultRekeyMessages = 1 << 24
+	DefaultRekeyAge      = 24 * time.Hour
+
+	protocolLabel = "anp-e2e/v1"
+	keySize       = 32
+	noncePrefix   = 4
+)
+
+var (
+	// ErrHandshakeInvalid is returned for handshakes that fail validation.
+	ErrHandshakeInvalid = errors.New("invalid e2e handshake")
+	// ErrNoSessionKey is returned by Encrypt before key agreement completes.
+	ErrNoSessionKey = errors.New("e2e session has no active key")
+	// ErrUnknownKey is returned when an envelope references a key this session lacks.
+	ErrUnknownKey = errors.New("unknown e2e key id")
+	// ErrDecrypt is returned when an envelope fails authentication.
+	ErrDecrypt = errors.New("e2e message authentication failed")
+)
+
+// Envelope is an encrypted message. From, To and KeyID are authenticated as
+// associated data.
+type Envelope struct {
+	From       string `json:"from"`
+	To         string `json:"to"`
+	KeyID      string `json:"key_id"`
+	Nonce      string `json:"nonce"`
+	Ciphertext string `json:"ciphertext"`
+}
+
+// Option configures a Session.
+type Option func(*Session)
+
+// WithClock overrides the time source.
+func WithClock(now func() time.Time) Option {
+	return func(s *Session) {
+		if now != nil {
+			s.now = now
+		}
+	}
+}
+
+// WithMaxSkew sets how old (or early) a handshake timestamp may be.
+func WithMaxSkew(d time.Duration) Option {
+	return func(s *Session) {
+		if d > 0 {
+			s.maxSkew = d
+		}
+	}
+}
+
+// WithRetainedKeys sets how many replaced keys remain usable for decryption.
+func WithRetainedKeys(n int) Option {
+	return func(s *Session) {
+		if n >= 0 {
+			s.retained = n
+		}
+	}
+}
+
+// WithRekeyPolicy sets the message count and key age after which NeedsRekey
+// reports true. Zero values keep the defaults.
+func WithRekeyPolicy(messages uint64, age time.Duration) Option {
+	return func(s *Session) {
+		if messages > 0 {
+			s.rekeyMessages = messages
+		}
+		if age > 0 {
+			s.rekeyAge = age
+		}
+	}
+}
+
+// Session holds the keys shared with one peer DID. It is safe for concurrent use.
+type Session struct {
+	local         Identity
+	peer          *anp_auth.DIDWBADocument
+	now           func() time.Time
+	maxSkew       time.Duration
+	retained      int
+	rekeyMessages uint64
+	rekeyAge      time.Duration
+
+	mu      sync.Mutex
+	current *keySet
+	keys    map[string]*keySet
+	order   []string
+	pending map[string]*ecdh.PrivateKey
+}
+
+type keySet struct {
+	id      string
+	send    cipher.AEAD
+	recv    cipher.AEAD
+	prefix  [noncePrefix]byte
+	counter uint64
+	created time.Time
+}
+
+func newSession(local Identity, peerDoc *anp_auth.DIDWBADocument, opts []Option) (*Session, error) {
+	if err := local.validate(); err != nil {
+		return nil, err
+	}
+	if peerDoc == nil {
+		return nil, errors.New("peer DID document is required")
+	}
+
+	s := &Session{
+		local:         local,
+		peer:          peerDoc,
+		now:           time.Now,
+		maxSkew:       DefaultMaxSkew,
+		retained:      DefaultRetainedKeys,
+		rekeyMessages: DefaultRekeyMessages,
+		rekeyAge:      DefaultRekeyAge,
+		keys:          make(map[string]*keySet),
+		pending:       make(map[string]*ecdh.PrivateKey),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s, nil
+}
+
+// Initiate starts a session with the peer described by peerDoc. Send the returned
+// handshake to the peer and pass its answer to HandleHandshake.
+func Initiate(local Identity, peerDoc *anp_auth.DIDWBADocument, opts ...Option) (*Session, *Handshake, error) {
+	s, err := newSession(local, peerDoc, opts)
+	if err != nil {
+		return nil, nil, err
+	}
+	init, err := s.Rekey()
+	if err != nil {
+		return nil, nil, err
+	}
+	return s, init, nil
+}
+
+// Accept answers a peer's HandshakeInit. The session is usable immediately; send
+// the returned handshake back to the initiator.
+func Accept(local Identity, init *Handshake, peerDoc *anp_auth.DIDWBADocument, opts ...Option) (*Session, *Handshake, error) {
+	s, err := newSession(local, peerDoc, opts)
+	if err != nil {
+		return nil, nil, err
+	}
+	if init == nil || init.Type != HandshakeInit {
+		return nil, nil, fmt.Errorf("%w: expected %s", ErrHandshakeInvalid, HandshakeInit)
+	}
+	reply, err := s.HandleHandshake(init)
+	if err != nil {
+		return nil, nil, err
+	}
+	return s, reply, nil
+}
+
+// PeerDID returns the DID of the remote agent.
+func (s *Session) PeerDID() string { return s.peer.ID }
+
+// KeyID returns the ID of the key used by Encrypt, or "" before key agreement.
+func (s *Session) KeyID() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.current == nil {
+		return ""
+	}
+	return s.current.id
+}
+
+// NeedsRekey reports whether the current key has exceeded the rekey policy.
+func (s *Session) NeedsRekey() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.current == nil {
+		return false
+	}
+	return s.current.counter >= s.rekeyMessages || s.now().Sub(s.current.created) >= s.rekeyAge
+}
+
+// Rekey starts a key rotation. The current key stays in use until the peer's
+// HandshakeAccept is passed to HandleHandshake.
+func (s *Session) Rekey() (*Handshake, error) {
+	h, ephemeral, err := newHandshake(s.local, HandshakeInit, "", s.peer.ID, "", s.now())
+	if err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	s.pending[h.KeyID] = ephemeral
+	s.mu.Unlock()
+	return h, nil
+}
+
+// HandleHandshake processes a handshake from the peer. For a HandshakeInit it
+// installs the new key and returns the HandshakeAccept to send back; for a
+// HandshakeAccept it completes a pending Initiate or Rekey and returns nil.
+func (s *Session) HandleHandshake(h *Handshake) (*Handshake, error) {
+	if err := h.verify(s.peer, s.local.Document.ID, s.now(), s.maxSkew); err != nil {
+		return nil, err
+	}
+	peerKey, err := h.ephemeralKey()
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch h.Type {
+	case HandshakeInit:
+		if _, exists := s.keys[h.KeyID]; exists {
+			return nil, fmt.Errorf("%w: key %s already established", ErrHandshakeInvalid, h.KeyID)
+		}
+		reply, ephemeral, err := newHandshake(s.local, HandshakeAccept, h.KeyID, s.peer.ID, h.EphemeralKey, s.now())
+		if err != nil {
+			return nil, err
+		}
+		if err := s.install(h.KeyID, ephemeral, peerKey, false); err != nil {
+			return nil, err
+		}
+		return reply, nil
+
+	case HandshakeAccept:
+		ephemeral, ok := s.pending[h.KeyID]
+		if !ok {
+			return nil, fmt.Errorf("%w: no pending handshake for key %s", ErrHandshakeInvalid, h.KeyID)
+		}
+		if h.PeerEphemeralKey != base64.RawURLEncoding.EncodeToString(ephemeral.PublicKey().Bytes()) {
+			return nil, fmt.Errorf("%w: accept does not answer our handshake", ErrHandshakeInvalid)
+		}
+		delete(s.pending, h.KeyID)
+		return nil, s.install(h.KeyID, ephemeral, peerKey, true)
+
+	default:
+		return nil, fmt.Errorf("%w: unknown type %q", ErrHandshakeInvalid, h.Type)
+	}
+}
+
+// install derives the directional keys for keyID and makes them current. The
+// caller must hold s.mu.
+func (s *Session) install(keyID string, ephemeral *ecdh.PrivateKey, peerKey *ecdh.PublicKey, initiator bool) error {
+	secret, err := ephemeral.ECDH(peerKey)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrHandshakeInvalid, err)
+	}
+
+	initiatorDID, responderDID := s.local.Document.ID, s.peer.ID
+	if !initiator {
+		initiatorDID, responderDID = responderDID, initiatorDID
+	}
+	material, err := hkdf.Key(sha256.New, secret, []byte(keyID), protocolLabel+"|"+initiatorDID+"|"+responderDID, 2*keySize)
+	if err != nil {
+		return fmt.Errorf("derive keys: %w", err)
+	}
+
+	forward, err := newAEAD(material[:keySize])
+	if err != nil {
+		return err
+	}
+	backward, err := newAEAD(material[keySize:])
+	if err != nil {
+		return err
+	}
+
+	ks := &keySet{id: keyID, send: forward, recv: backward, created: s.now()}
+	if !initiator {
+		ks.send, ks.recv = backward, forward
+	}
+	if _, err := rand.Read(ks.prefix[:]); err != nil {
+		return fmt.Errorf("generate nonce prefix: %w", err)
+	}
+
+	s.keys[keyID] = ks
+	s.order = append(s.order, keyID)
+	s.current = ks
+	for len(s.order) > s.retained+1 {
+		delete(s.keys, s.order[0])
+		s.order = s.order[1:]
+	}
+	return nil
+}
+
+// Encrypt seals plaintext with the current key.
+func (s *Session) Encrypt(plaintext []byte) (*Envelope, error) {
+	s.mu.Lock()
+	ks := s.current
+	if ks == nil {
+		s.mu.Unlock()
+		return nil, ErrNoSessionKey
+	}
+	nonce := make([]byte, ks.send.NonceSize())
+	copy(nonce, ks.prefix[:])
+	binary.BigEndian.PutUint64(nonce[noncePrefix:], ks.counter)
+	ks.counter++
+	s.mu.Unlock()
+
+	env := &Envelope{
+		From:  s.local.Document.ID,
+		To:    s.peer.ID,
+		KeyID: ks.id,
+		Nonce: base64.RawURLEncoding.EncodeToString(nonce),
+	}
+	ciphertext := ks.send.Seal(nil, nonce, plaintext, env.associatedData())
+	env.Ciphertext = base64.RawURLEncoding.EncodeToString(ciphertext)
+	return env, nil
+}
+
+// Decrypt opens an envelope sent by the peer.
+func (s *Session) Decrypt(env *Envelope) ([]byte, error) {
+	if env == nil {
+		return nil, errors.New("envelope is nil")
+	}
+	if env.From != s.peer.ID || env.To != s.local.Document.ID {
+		return nil, fmt.Errorf("%w: envelope from %s to %s does not belong to this session", ErrDecrypt, env.From, env.To)
+	}
+
+	s.mu.Lock()
+	ks, ok := s.keys[env.KeyID]
+	s.mu.Unlock()
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownKey, env.KeyID)
+	}
+
+	nonce, err := base64.RawURLEncoding.DecodeString(env.Nonce)
+	if err != nil || len(nonce) != ks.recv.NonceSize() {
+		return nil, fmt.Errorf("%w: invalid nonce", ErrDecrypt)
+	}
+	ciphertext, err := base64.RawURLEncoding.DecodeString(env.Ciphertext)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid ciphertext encoding", ErrDecrypt)
+	}
+	plaintext, err := ks.recv.Open(nil, nonce, ciphertext, env.associatedData())
+	if err != nil {
+		return nil, ErrDecrypt
+	}
+	return plaintext, nil
+}
+
+func (e *Envelope) associatedData() []byte {
+	return []byte(protocolLabel + "\x00" + e.From + "\x00" + e.To + "\x00" + e.KeyID)
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("create cipher: %w", err)
+	}
+	return cipher.NewGCM(block)
+}
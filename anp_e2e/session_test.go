@@ -0,0 +1,152 @@
+package anp_e2e
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/openanp/anp-go/anp_auth"
+)
+
+func newIdentity(t *testing.T, host string) Identity {
+	t.Helper()
+	doc, key, err := anp_auth.CreateDIDWBADocument(host, nil, nil, nil)
+	if err != nil {
+		t.Fatalf("CreateDIDWBADocument() error = %v", err)
+	}
+	return Identity{Document: doc, PrivateKey: key}
+}
+
+func establish(t *testing.T, alice, bob Identity, opts ...Option) (*Session, *Session) {
+	t.Helper()
+	a, init, err := Initiate(alice, bob.Document, opts...)
+	if err != nil {
+		t.Fatalf("Initiate() error = %v", err)
+	}
+	b, accept, err := Accept(bob, init, alice.Document, opts...)
+	if err != nil {
+		t.Fatalf("Accept() error = %v", err)
+	}
+	if _, err := a.HandleHandshake(accept); err != nil {
+		t.Fatalf("HandleHandshake() error = %v", err)
+	}
+	return a, b
+}
+
+func TestSession_RoundTrip(t *testing.T) {
+	alice, bob := newIdentity(t, "alice.example.com"), newIdentity(t, "bob.example.com")
+	a, b := establish(t, alice, bob)
+
+	if a.KeyID() == "" || a.KeyID() != b.KeyID() {
+		t.Fatalf("key IDs = %q / %q, want equal and non-empty", a.KeyID(), b.KeyID())
+	}
+
+	env, err := a.Encrypt([]byte("book a room"))
+	if err != nil {
+		t.Fatalf("Encrypt() error = %v", err)
+	}
+	got, err := b.Decrypt(env)
+	if err != nil {
+		t.Fatalf("Decrypt() error = %v", err)
+	}
+	if string(got) != "book a room" {
+		t.Errorf("Decrypt() = %q", got)
+	}
+
+	reply, err := b.Encrypt([]byte("confirmed"))
+	if err != nil {
+		t.Fatalf("Encrypt() error = %v", err)
+	}
+	if got, err := a.Decrypt(reply); err != nil || string(got) != "confirmed" {
+		t.Errorf("Decrypt() = %q, %v", got, err)
+	}
+
+	// A session cannot open its own messages: directions use different keys.
+	if _, err := a.Decrypt(env); !errors.Is(err, ErrDecrypt) {
+		t.Errorf("Expected ErrDecrypt for reflected envelope, got %v", err)
+	}
+
+	tampered := *env
+	tampered.Ciphertext = reply.Ciphertext
+	if _, err := b.Decrypt(&tampered); !errors.Is(err, ErrDecrypt) {
+		t.Errorf("Expected ErrDecrypt for tampered envelope, got %v", err)
+	}
+}
+
+func TestSession_Rekey(t *testing.T) {
+	alice, bob := newIdentity(t, "alice.example.com"), newIdentity(t, "bob.example.com")
+	a, b := establish(t, alice, bob, WithRetainedKeys(1))
+
+	inFlight, err := a.Encrypt([]byte("old key"))
+	if err != nil {
+		t.Fatalf("Encrypt() error = %v", err)
+	}
+	firstKey := a.KeyID()
+
+	// Bob rotates; Alice answers.
+	for i := 0; i < 2; i++ {
+		init, err := b.Rekey()
+		if err != nil {
+			t.Fatalf("Rekey() error = %v", err)
+		}
+		accept, err := a.HandleHandshake(init)
+		if err != nil {
+			t.Fatalf("HandleHandshake(init) error = %v", err)
+		}
+		if _, err := b.HandleHandshake(accept); err != nil {
+			t.Fatalf("HandleHandshake(accept) error = %v", err)
+		}
+		if i == 0 {
+			if got, err := b.Decrypt(inFlight); err != nil || string(got) != "old key" {
+				t.Errorf("Expected retained key to decrypt in-flight message, got %q, %v", got, err)
+			}
+		}
+	}
+
+	if a.KeyID() == firstKey || a.KeyID() != b.KeyID() {
+		t.Errorf("unexpected key IDs after rotation: %s / %s", a.KeyID(), b.KeyID())
+	}
+	if _, err := b.Decrypt(inFlight); !errors.Is(err, ErrUnknownKey) {
+		t.Errorf("Expected ErrUnknownKey once the key is evicted, got %v", err)
+	}
+}
+
+func TestHandshake_Rejections(t *testing.T) {
+	alice, bob, mallory := newIdentity(t, "alice.example.com"), newIdentity(t, "bob.example.com"), newIdentity(t, "mallory.example.com")
+	now := time.Now()
+
+	tests := []struct {
+		name   string
+		mutate func(init *Handshake)
+		peer   Identity
+		opts   []Option
+	}{
+		{"substituted ephemeral key", func(h *Handshake) { h.EphemeralKey = "AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA" }, alice, nil},
+		{"wrong peer document", func(h *Handshake) {}, mallory, nil},
+		{"stale timestamp", func(h *Handshake) {}, alice, []Option{WithClock(func() time.Time { return now.Add(time.Hour) })}},
+		{"wrong recipient", func(h *Handshake) { h.To = mallory.Document.ID }, alice, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, init, err := Initiate(alice, bob.Document)
+			if err != nil {
+				t.Fatalf("Initiate() error = %v", err)
+			}
+			tt.mutate(init)
+			if _, _, err := Accept(bob, init, tt.peer.Document, tt.opts...); !errors.Is(err, ErrHandshakeInvalid) {
+				t.Errorf("Accept() error = %v, want ErrHandshakeInvalid", err)
+			}
+		})
+	}
+}
+
+func TestSession_EncryptBeforeAgreement(t *testing.T) {
+	alice, bob := newIdentity(t, "alice.example.com"), newIdentity(t, "bob.example.com")
+	a, _, err := Initiate(alice, bob.Document)
+	if err != nil {
+		t.Fatalf("Initiate() error = %v", err)
+	}
+	if _, err := a.Encrypt([]byte("x")); !errors.Is(err, ErrNoSessionKey) {
+		t.Errorf("Encrypt() error = %v, want ErrNoSessionKey", err)
+	}
+}
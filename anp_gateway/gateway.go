@@ -0,0 +1,161 @@
+// Package anp_gateway provides reverse proxies that let existing HTTP services
+// adopt DID-WBA incrementally.
+//
+// NewOutbound sits between a legacy client and an ANP service: requests are
+// forwarded to the upstream with a DID-WBA Authorization header signed by the
+// gateway's identity. NewInbound sits in front of a legacy service: it terminates
+// DID-WBA, then forwards plain HTTP with the caller's DID in an identity header
+// (X-ANP-DID by default) that the backend can trust. Delegated callers also get
+// the acting agent's DID in X-ANP-Actor-DID.
+package anp_gateway
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+
+	"github.com/openanp/anp-go/anp_auth"
+)
+
+// Identity headers set by NewInbound.
+const (
+	// DefaultDIDHeader carries the authenticated caller DID to inbound upstreams.
+	// For delegated requests this is the DID the agent acts on behalf of.
+	DefaultDIDHeader = "X-ANP-DID"
+	// DefaultActorDIDHeader carries the DID of the agent acting on behalf of
+	// DefaultDIDHeader. It is only set for delegated requests.
+	DefaultActorDIDHeader = "X-ANP-Actor-DID"
+)
+
+// Config describes a gateway.
+type Config struct {
+	// Upstream is the base URL requests are forwarded to. The incoming path is
+	// appended to the upstream path.
+	Upstream string
+	// Authenticator signs outbound requests. Required by NewOutbound.
+	Authenticator *anp_auth.Authenticator
+	// Verifier authenticates inbound requests. Required by NewInbound.
+	Verifier *anp_auth.DidWbaVerifier
+	// DIDHeader names the identity header set by NewInbound (default X-ANP-DID).
+	DIDHeader string
+	// ActorDIDHeader names the header carrying the acting agent's DID on
+	// delegated requests (default X-ANP-Actor-DID).
+	ActorDIDHeader string
+	// Transport performs upstream requests (default http.DefaultTransport).
+	Transport http.RoundTripper
+	Logger    *slog.Logger
+}
+
+func (cfg *Config) upstream() (*url.URL, error) {
+	if cfg.Upstream == "" {
+		return nil, errors.New("anp_gateway: upstream is required")
+	}
+	u, err := url.Parse(cfg.Upstream)
+	if err != nil {
+		return nil, fmt.Errorf("anp_gateway: parse upstream: %w", err)
+	}
+	if u.Scheme == "" || u.Host == "" {
+		return nil, fmt.Errorf("anp_gateway: upstream %q must be an absolute URL", cfg.Upstream)
+	}
+	return u, nil
+}
+
+func (cfg *Config) logger() *slog.Logger {
+	if cfg.Logger != nil {
+		return cfg.Logger
+	}
+	return slog.Default()
+}
+
+func (cfg *Config) transport() http.RoundTripper {
+	if cfg.Transport != nil {
+		return cfg.Transport
+	}
+	return http.DefaultTransport
+}
+
+// NewOutbound returns a handler that forwards requests to an ANP upstream,
+// authenticating as cfg.Authenticator. Any Authorization header sent by the local
+// client is replaced, and bearer tokens issued by the upstream are cached by the
+// authenticator rather than passed back to the client.
+func NewOutbound(cfg Config) (http.Handler, error) {
+	target, err := cfg.upstream()
+	if err != nil {
+		return nil, err
+	}
+	if cfg.Authenticator == nil {
+		return nil, errors.New("anp_gateway: authenticator is required for outbound mode")
+	}
+	logger := cfg.logger()
+
+	return &httputil.ReverseProxy{
+		Rewrite: func(pr *httputil.ProxyRequest) {
+			pr.SetURL(target)
+			pr.SetXForwarded()
+			pr.Out.Header.Del(anp_auth.AuthorizationHeader)
+		},
+		Transport: &anp_auth.Transport{Base: cfg.transport(), Authenticator: cfg.Authenticator},
+		ModifyResponse: func(resp *http.Response) error {
+			if resp.StatusCode == http.StatusUnauthorized {
+				// The cached token was rejected; sign the next request afresh.
+				cfg.Authenticator.ClearToken(resp.Request.URL.String())
+			}
+			resp.Header.Del(anp_auth.AuthorizationHeader)
+			return nil
+		},
+		ErrorHandler: errorHandler(logger),
+	}, nil
+}
+
+// NewInbound returns a handler that authenticates callers with DID-WBA and
+// forwards them to a legacy upstream. The upstream receives the caller's DID in
+// cfg.DIDHeader and, for delegated requests, the acting agent's DID in
+// cfg.ActorDIDHeader. The Authorization header and any client-supplied identity
+// headers are removed so the values cannot be spoofed.
+func NewInbound(cfg Config) (http.Handler, error) {
+	target, err := cfg.upstream()
+	if err != nil {
+		return nil, err
+	}
+	if cfg.Verifier == nil {
+		return nil, errors.New("anp_gateway: verifier is required for inbound mode")
+	}
+	didHeader := cfg.DIDHeader
+	if didHeader == "" {
+		didHeader = DefaultDIDHeader
+	}
+	actorHeader := cfg.ActorDIDHeader
+	if actorHeader == "" {
+		actorHeader = DefaultActorDIDHeader
+	}
+	logger := cfg.logger()
+
+	proxy := &httputil.ReverseProxy{
+		Rewrite: func(pr *httputil.ProxyRequest) {
+			pr.SetURL(target)
+			pr.SetXForwarded()
+			pr.Out.Header.Del(anp_auth.AuthorizationHeader)
+			pr.Out.Header.Del(didHeader)
+			pr.Out.Header.Del(actorHeader)
+			if did, ok := anp_auth.DIDFromContext(pr.In.Context()); ok {
+				pr.Out.Header.Set(didHeader, did)
+			}
+			if actor, ok := anp_auth.ActorDIDFromContext(pr.In.Context()); ok {
+				pr.Out.Header.Set(actorHeader, actor)
+			}
+		},
+		Transport:    cfg.transport(),
+		ErrorHandler: errorHandler(logger),
+	}
+	return anp_auth.Middleware(cfg.Verifier)(proxy), nil
+}
+
+func errorHandler(logger *slog.Logger) func(http.ResponseWriter, *http.Request, error) {
+	return func(w http.ResponseWriter, r *http.Request, err error) {
+		logger.Warn("gateway upstream request failed", "method", r.Method, "path", r.URL.Path, "error", err)
+		http.Error(w, "bad gateway", http.StatusBadGateway)
+	}
+}
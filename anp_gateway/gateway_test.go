@@ -0,0 +1,170 @@
+package anp_gateway
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/openanp/anp-go/anp_auth"
+	"github.com/openanp/anp-go/anptest"
+)
+
+func TestNewOutbound(t *testing.T) {
+	caller := anptest.NewIdentity(t, "gateway.example.com")
+	agent := anptest.NewServer(t, anptest.WithDIDAuth(caller))
+
+	gw, err := NewOutbound(Config{Upstream: agent.URL, Authenticator: caller.Authenticator})
+	if err != nil {
+		t.Fatalf("NewOutbound() error = %v", err)
+	}
+	front := httptest.NewServer(gw)
+	defer front.Close()
+
+	for i := 0; i < 2; i++ {
+		req, _ := http.NewRequest(http.MethodGet, front.URL+anptest.PathAgentDescription, nil)
+		req.Header.Set(anp_auth.AuthorizationHeader, "Basic bGVnYWN5OmNsaWVudA==")
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("Do() error = %v", err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("status = %d, want 200", resp.StatusCode)
+		}
+		if h := resp.Header.Get(anp_auth.AuthorizationHeader); h != "" {
+			t.Errorf("upstream token leaked to client: %q", h)
+		}
+	}
+
+	requests := agent.Requests()
+	if len(requests) != 2 {
+		t.Fatalf("upstream saw %d requests, want 2", len(requests))
+	}
+	if requests[0].DID != caller.Document.ID {
+		t.Errorf("upstream DID = %q, want %q", requests[0].DID, caller.Document.ID)
+	}
+	if got := requests[1].Header.Get(anp_auth.AuthorizationHeader); !strings.HasPrefix(got, anp_auth.BearerScheme) {
+		t.Errorf("second request Authorization = %q, want cached bearer token", got)
+	}
+}
+
+func TestNewInbound(t *testing.T) {
+	caller := anptest.NewIdentity(t, "client.example.com")
+
+	var seen http.Header
+	legacy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = r.Header.Clone()
+		io.WriteString(w, "hello "+r.Header.Get(DefaultDIDHeader)+" at "+r.URL.Path)
+	}))
+	defer legacy.Close()
+
+	gw, err := NewInbound(Config{Upstream: legacy.URL + "/api", Verifier: anptest.NewVerifier(t, caller)})
+	if err != nil {
+		t.Fatalf("NewInbound() error = %v", err)
+	}
+	front := httptest.NewServer(gw)
+	defer front.Close()
+
+	client := anp_auth.NewClient(caller.Authenticator)
+	req, _ := http.NewRequest(http.MethodGet, front.URL+"/orders", nil)
+	req.Header.Set(DefaultDIDHeader, "did:wba:spoofed.example.com")
+	req.Header.Set(DefaultActorDIDHeader, "did:wba:spoofed.example.com")
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("Do() error = %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+
+	if want := "hello " + caller.Document.ID + " at /api/orders"; string(body) != want {
+		t.Errorf("body = %q, want %q", body, want)
+	}
+	if seen.Get(anp_auth.AuthorizationHeader) != "" {
+		t.Error("Authorization header forwarded to legacy upstream")
+	}
+	if got := seen.Values(DefaultDIDHeader); len(got) != 1 {
+		t.Errorf("identity header values = %v, want exactly one", got)
+	}
+	if got := seen.Get(DefaultActorDIDHeader); got != "" {
+		t.Errorf("actor header = %q on an undelegated request, want none", got)
+	}
+
+	anon, err := http.Get(front.URL + "/orders")
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	anon.Body.Close()
+	if anon.StatusCode != http.StatusUnauthorized {
+		t.Errorf("anonymous status = %d, want 401", anon.StatusCode)
+	}
+}
+
+func TestNewInbound_Delegated(t *testing.T) {
+	user := anptest.NewIdentity(t, "user.example.com")
+	agent := anptest.NewIdentity(t, "orchestrator.example.com")
+	grant, err := anp_auth.IssueDelegation(user.PrivateKey, user.Document, agent.Document.ID, "", time.Hour)
+	if err != nil {
+		t.Fatalf("IssueDelegation() error = %v", err)
+	}
+	auth, err := anp_auth.NewAuthenticator(
+		anp_auth.WithDIDMaterial(agent.Document, agent.PrivateKey),
+		anp_auth.WithDelegation(anp_auth.DelegationChain{grant}),
+	)
+	if err != nil {
+		t.Fatalf("NewAuthenticator() error = %v", err)
+	}
+
+	var seen http.Header
+	legacy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = r.Header.Clone()
+	}))
+	defer legacy.Close()
+
+	gw, err := NewInbound(Config{Upstream: legacy.URL, Verifier: anptest.NewVerifier(t, user, agent), ActorDIDHeader: "X-Acting-Agent"})
+	if err != nil {
+		t.Fatalf("NewInbound() error = %v", err)
+	}
+	front := httptest.NewServer(gw)
+	defer front.Close()
+
+	req, _ := http.NewRequest(http.MethodGet, front.URL+"/orders", nil)
+	req.Header.Set("X-Acting-Agent", "did:wba:spoofed.example.com")
+	resp, err := anp_auth.NewClient(auth).Do(req)
+	if err != nil {
+		t.Fatalf("Do() error = %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status = %d, want 200", resp.StatusCode)
+	}
+
+	if got := seen.Get(DefaultDIDHeader); got != user.Document.ID {
+		t.Errorf("identity header = %q, want %q", got, user.Document.ID)
+	}
+	if got := seen.Values("X-Acting-Agent"); len(got) != 1 || got[0] != agent.Document.ID {
+		t.Errorf("actor header values = %v, want [%s]", got, agent.Document.ID)
+	}
+}
+
+func TestConfigValidation(t *testing.T) {
+	tests := []struct {
+		name string
+		new  func(Config) (http.Handler, error)
+		cfg  Config
+	}{
+		{"outbound without upstream", NewOutbound, Config{Authenticator: &anp_auth.Authenticator{}}},
+		{"outbound without authenticator", NewOutbound, Config{Upstream: "https://agent.example.com"}},
+		{"inbound without verifier", NewInbound, Config{Upstream: "http://localhost:8080"}},
+		{"relative upstream", NewInbound, Config{Upstream: "/api", Verifier: &anp_auth.DidWbaVerifier{}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := tt.new(tt.cfg); err == nil {
+				t.Error("expected error")
+			}
+		})
+	}
+}
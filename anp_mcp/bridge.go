@@ -0,0 +1,120 @@
+package anp_mcp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/bytedance/sonic"
+	"github.com/openanp/anp-go/anp_crawler"
+	"github.com/openanp/anp-go/anp_server"
+	"github.com/openanp/anp-go/session"
+)
+
+const (
+	// InterfaceType is the anp_crawler.InterfaceEntry type of bridged MCP tools.
+	InterfaceType = "mcp_tool"
+	// Protocol is the anp_crawler.InterfaceEntry protocol of bridged MCP tools.
+	Protocol = "MCP"
+)
+
+// Interfaces lists the server's tools as interface entries. Each entry's Params
+// holds the tool's JSON Schema input.
+func (c *Client) Interfaces(ctx context.Context) ([]anp_crawler.InterfaceEntry, error) {
+	tools, err := c.ListTools(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	server := anp_crawler.Server{Name: c.ServerInfo().Name, URL: c.endpoint}
+	entries := make([]anp_crawler.InterfaceEntry, 0, len(tools))
+	for _, tool := range tools {
+		entries = append(entries, anp_crawler.InterfaceEntry{
+			Type:        InterfaceType,
+			Protocol:    Protocol,
+			MethodName:  tool.Name,
+			Summary:     tool.Title,
+			Description: tool.Description,
+			Params:      tool.InputSchema,
+			Servers:     []anp_crawler.Server{server},
+			Source:      c.endpoint,
+			URL:         c.endpoint,
+		})
+	}
+	return entries, nil
+}
+
+// Document materializes the server's tools as a session.Document. Its Interfaces
+// execute through this Client, so session.ExecuteTool works on MCP tools exactly
+// as on tools fetched from an agent description.
+func (c *Client) Document(ctx context.Context) (*session.Document, error) {
+	entries, err := c.Interfaces(ctx)
+	if err != nil {
+		return nil, err
+	}
+	raw, err := sonic.Marshal(entries)
+	if err != nil {
+		return nil, fmt.Errorf("encode interfaces: %w", err)
+	}
+
+	doc := &session.Document{
+		URL:         c.endpoint,
+		StatusCode:  http.StatusOK,
+		ContentType: "application/json",
+		Raw:         raw,
+		Result:      &anp_crawler.ParseResult{Interfaces: entries},
+	}
+
+	converter := anp_crawler.NewANPInterfaceConverter()
+	client := toolClient{mcp: c}
+	for _, entry := range entries {
+		tool, err := converter.ConvertToANPTool(entry)
+		if err != nil {
+			c.logger.Debug("mcp tool conversion failed", "tool", entry.MethodName, "error", err)
+			continue
+		}
+		doc.Tools = append(doc.Tools, tool)
+		doc.Interfaces = append(doc.Interfaces, anp_crawler.NewANPInterface(tool.Function.Name, entry, client))
+	}
+	return doc, nil
+}
+
+// toolClient adapts the JSON-RPC requests issued by anp_crawler.ANPInterface
+// into MCP tools/call requests.
+type toolClient struct {
+	mcp *Client
+}
+
+func (t toolClient) Fetch(ctx context.Context, method, target string, headers map[string]string, body any) (*anp_crawler.Response, error) {
+	req, ok := body.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("anp_mcp: unexpected request body %T", body)
+	}
+	name, _ := req["method"].(string)
+	args, _ := req["params"].(map[string]any)
+
+	reply := map[string]any{"jsonrpc": "2.0", "id": req["id"]}
+	result, err := t.mcp.CallTool(ctx, name, args)
+	var rpcErr *anp_server.Error
+	switch {
+	case errors.As(err, &rpcErr):
+		reply["error"] = rpcErr
+	case err != nil:
+		return nil, err
+	default:
+		reply["result"] = result
+	}
+
+	encoded, err := sonic.Marshal(reply)
+	if err != nil {
+		return nil, fmt.Errorf("encode tool result: %w", err)
+	}
+	return &anp_crawler.Response{
+		StatusCode:  http.StatusOK,
+		URL:         target,
+		ContentType: "application/json",
+		Header:      http.Header{},
+		Body:        encoded,
+	}, nil
+}
@@ -0,0 +1,193 @@
+package anp_mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/bytedance/sonic"
+	"github.com/openanp/anp-go/session"
+)
+
+// fakeServer is a minimal Streamable HTTP MCP server.
+type fakeServer struct {
+	mu      sync.Mutex
+	calls   []string
+	deleted bool
+}
+
+func (f *fakeServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodDelete {
+		f.mu.Lock()
+		f.deleted = r.Header.Get(SessionIDHeader) == "sess-1"
+		f.mu.Unlock()
+		return
+	}
+
+	var req struct {
+		ID     json.RawMessage `json:"id"`
+		Method string          `json:"method"`
+		Params struct {
+			Name      string         `json:"name"`
+			Cursor    string         `json:"cursor"`
+			Arguments map[string]any `json:"arguments"`
+		} `json:"params"`
+	}
+	if err := sonic.ConfigDefault.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if req.Method != "initialize" && r.Header.Get(SessionIDHeader) != "sess-1" {
+		http.Error(w, "missing session", http.StatusBadRequest)
+		return
+	}
+
+	reply := func(result any) {
+		w.Header().Set("Content-Type", "application/json")
+		body, _ := sonic.Marshal(map[string]any{"jsonrpc": "2.0", "id": req.ID, "result": result})
+		w.Write(body)
+	}
+
+	switch req.Method {
+	case "initialize":
+		w.Header().Set(SessionIDHeader, "sess-1")
+		reply(map[string]any{
+			"protocolVersion": ProtocolVersion,
+			"serverInfo":      map[string]string{"name": "fake-mcp", "version": "0.1"},
+			"capabilities":    map[string]any{"tools": map[string]any{}},
+		})
+	case "notifications/initialized":
+		w.WriteHeader(http.StatusAccepted)
+	case "tools/list":
+		if req.Params.Cursor == "" {
+			reply(map[string]any{
+				"tools": []map[string]any{{
+					"name":        "web.search",
+					"description": "Search the web",
+					"inputSchema": map[string]any{
+						"type":       "object",
+						"properties": map[string]any{"q": map[string]any{"type": "string"}},
+						"required":   []string{"q"},
+					},
+				}},
+				"nextCursor": "page-2",
+			})
+			return
+		}
+		reply(map[string]any{"tools": []map[string]any{{"name": "flaky", "inputSchema": map[string]any{"type": "object"}}}})
+	case "tools/call":
+		f.mu.Lock()
+		f.calls = append(f.calls, req.Params.Name)
+		f.mu.Unlock()
+		switch req.Params.Name {
+		case "web.search":
+			// Answer over SSE, preceded by a progress notification.
+			w.Header().Set("Content-Type", "text/event-stream")
+			fmt.Fprint(w, "event: message\ndata: {\"jsonrpc\":\"2.0\",\"method\":\"notifications/progress\",\"params\":{}}\n\n")
+			result, _ := sonic.Marshal(map[string]any{
+				"jsonrpc": "2.0",
+				"id":      req.ID,
+				"result":  map[string]any{"content": []map[string]any{{"type": "text", "text": "results for " + fmt.Sprint(req.Params.Arguments["q"])}}},
+			})
+			fmt.Fprintf(w, "event: message\ndata: %s\n\n", result)
+		case "flaky":
+			reply(map[string]any{"content": []map[string]any{{"type": "text", "text": "upstream down"}}, "isError": true})
+		default:
+			w.Header().Set("Content-Type", "application/json")
+			body, _ := sonic.Marshal(map[string]any{"jsonrpc": "2.0", "id": req.ID, "error": map[string]any{"code": -32602, "message": "unknown tool"}})
+			w.Write(body)
+		}
+	default:
+		http.Error(w, "unexpected method "+req.Method, http.StatusBadRequest)
+	}
+}
+
+func TestClient_DocumentExecutesThroughSession(t *testing.T) {
+	fake := &fakeServer{}
+	srv := httptest.NewServer(fake)
+	defer srv.Close()
+
+	ctx := context.Background()
+	client, err := Connect(ctx, srv.URL, WithClientInfo("test", "1.0"))
+	if err != nil {
+		t.Fatalf("Connect() error = %v", err)
+	}
+	if got := client.ServerInfo().Name; got != "fake-mcp" {
+		t.Errorf("ServerInfo().Name = %q", got)
+	}
+
+	doc, err := client.Document(ctx)
+	if err != nil {
+		t.Fatalf("Document() error = %v", err)
+	}
+	entries := session.ListInterfaces(doc)
+	if len(entries) != 2 || entries[0].Type != InterfaceType || entries[0].Protocol != Protocol {
+		t.Fatalf("ListInterfaces() = %+v", entries)
+	}
+	if len(doc.Tools) != 2 {
+		t.Fatalf("len(Tools) = %d, want 2", len(doc.Tools))
+	}
+	search := doc.Tools[0].Function
+	if search.Name != "web_search" || len(search.Parameters.Required) != 1 || search.Parameters.Required[0] != "q" {
+		t.Errorf("tool = %+v", search)
+	}
+
+	resp, err := session.ExecuteTool(ctx, doc, "web.search", map[string]any{"q": "hotels"})
+	if err != nil {
+		t.Fatalf("ExecuteTool() error = %v", err)
+	}
+	result, _ := resp["result"].(map[string]any)
+	content, _ := result["content"].([]any)
+	if len(content) != 1 || !strings.Contains(fmt.Sprint(content[0]), "results for hotels") {
+		t.Errorf("ExecuteTool() = %v", resp)
+	}
+
+	// Tool-level failures are results, not errors.
+	resp, err = session.ExecuteTool(ctx, doc, "flaky", nil)
+	if err != nil {
+		t.Fatalf("ExecuteTool(flaky) error = %v", err)
+	}
+	if result, _ := resp["result"].(map[string]any); result["isError"] != true {
+		t.Errorf("ExecuteTool(flaky) = %v, want isError", resp)
+	}
+
+	if err := client.Close(ctx); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	fake.mu.Lock()
+	defer fake.mu.Unlock()
+	if !fake.deleted {
+		t.Error("expected session to be deleted")
+	}
+	if strings.Join(fake.calls, ",") != "web.search,flaky" {
+		t.Errorf("calls = %v", fake.calls)
+	}
+}
+
+func TestClient_CallToolProtocolError(t *testing.T) {
+	srv := httptest.NewServer(&fakeServer{})
+	defer srv.Close()
+
+	ctx := context.Background()
+	client, err := Connect(ctx, srv.URL)
+	if err != nil {
+		t.Fatalf("Connect() error = %v", err)
+	}
+	if _, err := client.CallTool(ctx, "missing", nil); err == nil || !strings.Contains(err.Error(), "unknown tool") {
+		t.Errorf("CallTool() error = %v, want unknown tool", err)
+	}
+
+	doc, err := client.Document(ctx)
+	if err != nil {
+		t.Fatalf("Document() error = %v", err)
+	}
+	doc.Interfaces[0].Method = "missing"
+	if _, err := session.ExecuteTool(ctx, doc, "missing", map[string]any{"q": "hotels"}); err == nil || !strings.Contains(err.Error(), "JSON-RPC error") {
+		t.Errorf("ExecuteTool() error = %v, want JSON-RPC error", err)
+	}
+}
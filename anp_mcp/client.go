@@ -0,0 +1,389 @@
+// Package anp_mcp connects to Model Context Protocol (MCP) servers over the
+// Streamable HTTP transport and exposes their tools to ANP agent code.
+//
+// Client speaks the MCP lifecycle (initialize, tools/list, tools/call). Document
+// materializes the server's tools as anp_crawler interface entries and tools, so
+// they can be listed and executed with the same session helpers as tools
+// discovered from ANP agent descriptions:
+//
+//	mcp, _ := anp_mcp.Connect(ctx, "https://tools.example.com/mcp")
+//	doc, _ := mcp.Document(ctx)
+//	result, _ := session.ExecuteTool(ctx, doc, "search", map[string]any{"q": "hotels"})
+package anp_mcp
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/bytedance/sonic"
+	"github.com/openanp/anp-go/anp_auth"
+	"github.com/openanp/anp-go/anp_server"
+)
+
+const (
+	// ProtocolVersion is the MCP revision requested during initialization.
+	ProtocolVersion = "2025-06-18"
+
+	// SessionIDHeader carries the session assigned by the server.
+	SessionIDHeader = "Mcp-Session-Id"
+	// ProtocolVersionHeader carries the negotiated protocol version.
+	ProtocolVersionHeader = "MCP-Protocol-Version"
+
+	defaultTimeout = 30 * time.Second
+)
+
+// Implementation identifies an MCP client or server.
+type Implementation struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+// Tool is a tool advertised by an MCP server.
+type Tool struct {
+	Name        string          `json:"name"`
+	Title       string          `json:"title,omitempty"`
+	Description string          `json:"description,omitempty"`
+	InputSchema json.RawMessage `json:"inputSchema,omitempty"`
+}
+
+// Content is one item of a tool result.
+type Content struct {
+	Type     string `json:"type"`
+	Text     string `json:"text,omitempty"`
+	Data     string `json:"data,omitempty"`
+	MimeType string `json:"mimeType,omitempty"`
+}
+
+// CallToolResult is the result of tools/call. IsError reports a failure inside
+// the tool, as opposed to a protocol error.
+type CallToolResult struct {
+	Content           []Content      `json:"content"`
+	StructuredContent map[string]any `json:"structuredContent,omitempty"`
+	IsError           bool           `json:"isError,omitempty"`
+}
+
+// Option configures a Client.
+type Option func(*Client)
+
+// WithHTTPClient sets the HTTP client used for requests.
+func WithHTTPClient(h *http.Client) Option {
+	return func(c *Client) {
+		if h != nil {
+			c.httpClient = h
+		}
+	}
+}
+
+// WithAuthenticator signs requests with DID-WBA, for MCP servers hosted behind
+// ANP authentication.
+func WithAuthenticator(auth *anp_auth.Authenticator) Option {
+	return func(c *Client) { c.authenticator = auth }
+}
+
+// WithHeader adds a static header to every request, e.g. an API key.
+func WithHeader(key, value string) Option {
+	return func(c *Client) { c.headers.Set(key, value) }
+}
+
+// WithClientInfo sets the implementation reported to the server.
+func WithClientInfo(name, version string) Option {
+	return func(c *Client) { c.info = Implementation{Name: name, Version: version} }
+}
+
+// WithLogger sets the logger used for diagnostics.
+func WithLogger(logger *slog.Logger) Option {
+	return func(c *Client) {
+		if logger != nil {
+			c.logger = logger
+		}
+	}
+}
+
+// Client is a connection to one MCP server. It is safe for concurrent use.
+type Client struct {
+	endpoint      string
+	httpClient    *http.Client
+	authenticator *anp_auth.Authenticator
+	headers       http.Header
+	info          Implementation
+	logger        *slog.Logger
+
+	nextID atomic.Int64
+
+	mu              sync.RWMutex
+	sessionID       string
+	protocolVersion string
+	server          Implementation
+	instructions    string
+}
+
+// Connect initializes an MCP session with the server at endpoint.
+func Connect(ctx context.Context, endpoint string, opts ...Option) (*Client, error) {
+	c := &Client{
+		endpoint:   endpoint,
+		httpClient: &http.Client{Timeout: defaultTimeout},
+		headers:    http.Header{},
+		info:       Implementation{Name: "anp-go", Version: "1.0"},
+		logger:     slog.Default(),
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	if c.authenticator != nil {
+		c.httpClient = &http.Client{
+			Timeout:   c.httpClient.Timeout,
+			Transport: &anp_auth.Transport{Base: c.httpClient.Transport, Authenticator: c.authenticator},
+		}
+	}
+
+	var init struct {
+		ProtocolVersion string         `json:"protocolVersion"`
+		ServerInfo      Implementation `json:"serverInfo"`
+		Instructions    string         `json:"instructions"`
+	}
+	params := map[string]any{
+		"protocolVersion": ProtocolVersion,
+		"capabilities":    map[string]any{},
+		"clientInfo":      c.info,
+	}
+	if err := c.call(ctx, "initialize", params, &init); err != nil {
+		return nil, fmt.Errorf("initialize %s: %w", endpoint, err)
+	}
+
+	c.mu.Lock()
+	c.protocolVersion = init.ProtocolVersion
+	c.server = init.ServerInfo
+	c.instructions = init.Instructions
+	c.mu.Unlock()
+
+	if err := c.notify(ctx, "notifications/initialized"); err != nil {
+		return nil, fmt.Errorf("initialize %s: %w", endpoint, err)
+	}
+	return c, nil
+}
+
+// Endpoint returns the server URL.
+func (c *Client) Endpoint() string { return c.endpoint }
+
+// ServerInfo returns the implementation reported by the server.
+func (c *Client) ServerInfo() Implementation {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.server
+}
+
+// Instructions returns the usage hints the server sent during initialization.
+func (c *Client) Instructions() string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.instructions
+}
+
+// ListTools returns every tool the server offers, following pagination.
+func (c *Client) ListTools(ctx context.Context) ([]Tool, error) {
+	var (
+		tools  []Tool
+		cursor string
+	)
+	for {
+		params := map[string]any{}
+		if cursor != "" {
+			params["cursor"] = cursor
+		}
+		var page struct {
+			Tools      []Tool `json:"tools"`
+			NextCursor string `json:"nextCursor"`
+		}
+		if err := c.call(ctx, "tools/list", params, &page); err != nil {
+			return nil, fmt.Errorf("list tools: %w", err)
+		}
+		tools = append(tools, page.Tools...)
+		if page.NextCursor == "" || page.NextCursor == cursor {
+			return tools, nil
+		}
+		cursor = page.NextCursor
+	}
+}
+
+// CallTool invokes a tool. A tool-level failure is reported through
+// CallToolResult.IsError rather than err.
+func (c *Client) CallTool(ctx context.Context, name string, arguments map[string]any) (*CallToolResult, error) {
+	if arguments == nil {
+		arguments = map[string]any{}
+	}
+	var result CallToolResult
+	if err := c.call(ctx, "tools/call", map[string]any{"name": name, "arguments": arguments}, &result); err != nil {
+		return nil, fmt.Errorf("call tool %s: %w", name, err)
+	}
+	return &result, nil
+}
+
+// Close terminates the server session, if the server assigned one.
+func (c *Client) Close(ctx context.Context) error {
+	c.mu.RLock()
+	sessionID := c.sessionID
+	c.mu.RUnlock()
+	if sessionID == "" {
+		return nil
+	}
+
+	req, err := c.newRequest(ctx, http.MethodDelete, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("close session: %w", err)
+	}
+	resp.Body.Close()
+	// 405 means the server does not allow clients to end sessions.
+	if resp.StatusCode >= 300 && resp.StatusCode != http.StatusMethodNotAllowed {
+		return fmt.Errorf("close session: status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+type rpcMessage struct {
+	JSONRPC string            `json:"jsonrpc"`
+	ID      json.RawMessage   `json:"id,omitempty"`
+	Method  string            `json:"method,omitempty"`
+	Params  any               `json:"params,omitempty"`
+	Result  json.RawMessage   `json:"result,omitempty"`
+	Error   *anp_server.Error `json:"error,omitempty"`
+}
+
+func (c *Client) call(ctx context.Context, method string, params, result any) error {
+	id := json.RawMessage(strconv.FormatInt(c.nextID.Add(1), 10))
+	resp, err := c.post(ctx, &rpcMessage{JSONRPC: "2.0", ID: id, Method: method, Params: params})
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if sessionID := resp.Header.Get(SessionIDHeader); sessionID != "" && method == "initialize" {
+		c.mu.Lock()
+		c.sessionID = sessionID
+		c.mu.Unlock()
+	}
+
+	msg, err := readResponse(resp, id)
+	if err != nil {
+		return err
+	}
+	if msg.Error != nil {
+		return msg.Error
+	}
+	if result != nil && len(msg.Result) > 0 {
+		if err := sonic.Unmarshal(msg.Result, result); err != nil {
+			return fmt.Errorf("decode %s result: %w", method, err)
+		}
+	}
+	return nil
+}
+
+func (c *Client) notify(ctx context.Context, method string) error {
+	resp, err := c.post(ctx, &rpcMessage{JSONRPC: "2.0", Method: method})
+	if err != nil {
+		return err
+	}
+	io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+	return nil
+}
+
+func (c *Client) post(ctx context.Context, msg *rpcMessage) (*http.Response, error) {
+	body, err := sonic.Marshal(msg)
+	if err != nil {
+		return nil, fmt.Errorf("encode request: %w", err)
+	}
+	req, err := c.newRequest(ctx, http.MethodPost, body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json, text/event-stream")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", msg.Method, err)
+	}
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		snippet, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		resp.Body.Close()
+		return nil, fmt.Errorf("%s: status %d: %s", msg.Method, resp.StatusCode, strings.TrimSpace(string(snippet)))
+	}
+	return resp, nil
+}
+
+func (c *Client) newRequest(ctx context.Context, method string, body []byte) (*http.Request, error) {
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, c.endpoint, reader)
+	if err != nil {
+		return nil, fmt.Errorf("create request: %w", err)
+	}
+	for k, v := range c.headers {
+		req.Header[k] = v
+	}
+
+	c.mu.RLock()
+	if c.sessionID != "" {
+		req.Header.Set(SessionIDHeader, c.sessionID)
+	}
+	if c.protocolVersion != "" {
+		req.Header.Set(ProtocolVersionHeader, c.protocolVersion)
+	}
+	c.mu.RUnlock()
+	return req, nil
+}
+
+// readResponse extracts the response to request id from a JSON body or an SSE
+// stream. Server requests and notifications interleaved in the stream are skipped.
+func readResponse(resp *http.Response, id json.RawMessage) (*rpcMessage, error) {
+	if !strings.HasPrefix(resp.Header.Get("Content-Type"), "text/event-stream") {
+		var msg rpcMessage
+		if err := sonic.ConfigDefault.NewDecoder(resp.Body).Decode(&msg); err != nil {
+			return nil, fmt.Errorf("decode response: %w", err)
+		}
+		return &msg, nil
+	}
+
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 64<<10), 10<<20)
+	var data []string
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, "data:") {
+			data = append(data, strings.TrimPrefix(strings.TrimPrefix(line, "data:"), " "))
+			continue
+		}
+		if line != "" || len(data) == 0 {
+			continue
+		}
+
+		var msg rpcMessage
+		err := sonic.UnmarshalString(strings.Join(data, "\n"), &msg)
+		data = nil
+		if err == nil && msg.Method == "" && bytes.Equal(msg.ID, id) {
+			return &msg, nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read event stream: %w", err)
+	}
+	return nil, errors.New("event stream ended without a response")
+}
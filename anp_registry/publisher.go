@@ -0,0 +1,188 @@
+// Package anp_registry lets agents publish themselves to ANP directory services,
+// the navigation services whose agentList documents anp_crawler consumes.
+//
+// A Publisher sends DID-authenticated requests whose bodies are Submissions signed
+// with the agent's DID key:
+//
+//	POST   {endpoint}                register a new listing
+//	PUT    {endpoint}/{escaped DID}  update an existing listing
+//	DELETE {endpoint}/{escaped DID}  remove a listing
+package anp_registry
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/bytedance/sonic"
+	"github.com/openanp/anp-go/anp_auth"
+	"github.com/openanp/anp-go/anp_crawler"
+)
+
+// Config describes how a Publisher should be built.
+type Config struct {
+	// Endpoint is the directory service's agent collection URL.
+	Endpoint string
+	// DIDDocument and PrivateKey identify the publishing agent.
+	DIDDocument *anp_auth.DIDWBADocument
+	PrivateKey  *ecdsa.PrivateKey
+	// VerificationMethod overrides the key used for submission proofs. Defaults to
+	// the document's first authentication method.
+	VerificationMethod string
+	// Client overrides the transport. Defaults to a DID-authenticated anp_crawler client.
+	Client     anp_crawler.Client
+	HTTPClient *http.Client
+	Logger     *slog.Logger
+	// Now returns the current time (for tests).
+	Now func() time.Time
+}
+
+// Result is the directory service's reply to a submission.
+type Result struct {
+	ID      string `json:"id,omitempty"`
+	Status  string `json:"status,omitempty"`
+	Message string `json:"message,omitempty"`
+	// Raw holds the undecoded response body.
+	Raw []byte `json:"-"`
+}
+
+// StatusError is returned when the directory service rejects a submission.
+type StatusError struct {
+	StatusCode int
+	Body       []byte
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("registry returned status %d: %s", e.StatusCode, strings.TrimSpace(string(e.Body)))
+}
+
+// Publisher registers, updates and deletes an agent's directory listing.
+type Publisher struct {
+	endpoint           string
+	did                string
+	privateKey         *ecdsa.PrivateKey
+	verificationMethod string
+	client             anp_crawler.Client
+	logger             *slog.Logger
+	now                func() time.Time
+}
+
+// NewPublisher creates a Publisher.
+func NewPublisher(cfg Config) (*Publisher, error) {
+	if cfg.Endpoint == "" {
+		return nil, errors.New("registry endpoint is required")
+	}
+	if cfg.DIDDocument == nil {
+		return nil, errors.New("DID document is required")
+	}
+	if cfg.PrivateKey == nil {
+		return nil, errors.New("private key is required")
+	}
+
+	vm := cfg.VerificationMethod
+	if vm == "" {
+		if len(cfg.DIDDocument.Authentication) == 0 {
+			return nil, fmt.Errorf("%w: DID document has no authentication method", anp_auth.ErrVerificationMethodNotFound)
+		}
+		vm = cfg.DIDDocument.Authentication[0]
+	}
+	if strings.HasPrefix(vm, "#") {
+		vm = cfg.DIDDocument.ID + vm
+	}
+
+	client := cfg.Client
+	if client == nil {
+		auth, err := anp_auth.NewAuthenticator(anp_auth.WithDIDMaterial(cfg.DIDDocument, cfg.PrivateKey))
+		if err != nil {
+			return nil, fmt.Errorf("create authenticator: %w", err)
+		}
+		var opts []anp_crawler.ClientOption
+		if cfg.HTTPClient != nil {
+			opts = append(opts, anp_crawler.WithHTTPClient(cfg.HTTPClient))
+		}
+		client = anp_crawler.NewClient(auth, opts...)
+	}
+
+	logger := cfg.Logger
+	if logger == nil {
+		logger = slog.Default()
+	}
+	now := cfg.Now
+	if now == nil {
+		now = time.Now
+	}
+
+	return &Publisher{
+		endpoint:           strings.TrimRight(cfg.Endpoint, "/"),
+		did:                cfg.DIDDocument.ID,
+		privateKey:         cfg.PrivateKey,
+		verificationMethod: vm,
+		client:             client,
+		logger:             logger,
+		now:                now,
+	}, nil
+}
+
+// Register adds listing to the directory. An empty listing.DID defaults to the
+// publisher's DID.
+func (p *Publisher) Register(ctx context.Context, listing Listing) (*Result, error) {
+	return p.submit(ctx, http.MethodPost, p.endpoint, ActionRegister, listing)
+}
+
+// Update replaces the directory's listing for the publisher's DID.
+func (p *Publisher) Update(ctx context.Context, listing Listing) (*Result, error) {
+	return p.submit(ctx, http.MethodPut, p.entryURL(), ActionUpdate, listing)
+}
+
+// Delete removes the publisher's listing from the directory.
+func (p *Publisher) Delete(ctx context.Context) (*Result, error) {
+	return p.submit(ctx, http.MethodDelete, p.entryURL(), ActionDelete, Listing{})
+}
+
+func (p *Publisher) entryURL() string {
+	return p.endpoint + "/" + url.PathEscape(p.did)
+}
+
+func (p *Publisher) submit(ctx context.Context, method, target string, action Action, listing Listing) (*Result, error) {
+	if listing.DID == "" {
+		listing.DID = p.did
+	}
+	if listing.DID != p.did {
+		return nil, fmt.Errorf("listing DID %s does not match publisher DID %s", listing.DID, p.did)
+	}
+	if action != ActionDelete && (listing.Name == "" || listing.URL == "") {
+		return nil, errors.New("listing name and url are required")
+	}
+
+	sub := NewSubmission(action, listing, p.now())
+	if err := sub.Sign(p.privateKey, p.verificationMethod); err != nil {
+		return nil, err
+	}
+	body, err := sonic.Marshal(sub)
+	if err != nil {
+		return nil, fmt.Errorf("marshal submission: %w", err)
+	}
+
+	resp, err := p.client.Fetch(ctx, method, target, map[string]string{"Accept": "application/json"}, body)
+	if err != nil {
+		return nil, fmt.Errorf("%s agent: %w", action, err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, &StatusError{StatusCode: resp.StatusCode, Body: resp.Body}
+	}
+	p.logger.Debug("registry submission accepted", "action", action, "did", p.did, "status", resp.StatusCode)
+
+	result := &Result{Raw: resp.Body}
+	if len(resp.Body) > 0 {
+		if err := sonic.Unmarshal(resp.Body, result); err != nil {
+			return nil, fmt.Errorf("decode registry response: %w", err)
+		}
+	}
+	return result, nil
+}
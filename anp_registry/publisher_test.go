@@ -0,0 +1,169 @@
+package anp_registry
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bytedance/sonic"
+	"github.com/openanp/anp-go/anp_auth"
+)
+
+// fakeDirectory verifies submissions and keeps listings in memory.
+type fakeDirectory struct {
+	doc *anp_auth.DIDWBADocument
+
+	mu       sync.Mutex
+	listings map[string]Listing
+}
+
+func (d *fakeDirectory) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !strings.HasPrefix(r.Header.Get("Authorization"), "DIDWba ") {
+		http.Error(w, "missing DID auth", http.StatusUnauthorized)
+		return
+	}
+
+	body, _ := io.ReadAll(r.Body)
+	var sub Submission
+	if err := sonic.Unmarshal(body, &sub); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := sub.Verify(d.doc); err != nil {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	switch r.Method {
+	case http.MethodPost:
+		d.listings[sub.Agent.DID] = sub.Agent
+	case http.MethodPut, http.MethodDelete:
+		did, _ := url.PathUnescape(strings.TrimPrefix(r.URL.EscapedPath(), "/agents/"))
+		if _, ok := d.listings[did]; !ok || did != sub.Agent.DID {
+			http.Error(w, "not found", http.StatusNotFound)
+			return
+		}
+		if r.Method == http.MethodDelete {
+			delete(d.listings, did)
+		} else {
+			d.listings[did] = sub.Agent
+		}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write([]byte(`{"id":"` + sub.Agent.DID + `","status":"ok"}`))
+}
+
+func TestPublisher_Lifecycle(t *testing.T) {
+	doc, key, err := anp_auth.CreateDIDWBADocument("agent.example.com", nil, nil, nil)
+	if err != nil {
+		t.Fatalf("CreateDIDWBADocument() error = %v", err)
+	}
+	dir := &fakeDirectory{doc: doc, listings: map[string]Listing{}}
+	srv := httptest.NewServer(dir)
+	defer srv.Close()
+
+	p, err := NewPublisher(Config{Endpoint: srv.URL + "/agents/", DIDDocument: doc, PrivateKey: key})
+	if err != nil {
+		t.Fatalf("NewPublisher() error = %v", err)
+	}
+	ctx := context.Background()
+
+	res, err := p.Register(ctx, Listing{Name: "Hotel Agent", URL: "https://agent.example.com/ad.json"})
+	if err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+	if res.ID != doc.ID || res.Status != "ok" {
+		t.Errorf("unexpected register result: %+v", res)
+	}
+
+	if _, err := p.Update(ctx, Listing{Name: "Hotel Agent", Description: "Books rooms", URL: "https://agent.example.com/ad.json"}); err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+	if got := dir.listings[doc.ID].Description; got != "Books rooms" {
+		t.Errorf("Expected updated description, got %q", got)
+	}
+
+	if _, err := p.Delete(ctx); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if len(dir.listings) != 0 {
+		t.Errorf("Expected listing to be deleted, got %v", dir.listings)
+	}
+
+	_, err = p.Delete(ctx)
+	var statusErr *StatusError
+	if !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusNotFound {
+		t.Errorf("Expected 404 StatusError, got %v", err)
+	}
+}
+
+func TestSubmission_VerifyRejectsTampering(t *testing.T) {
+	doc, key, err := anp_auth.CreateDIDWBADocument("agent.example.com", nil, nil, nil)
+	if err != nil {
+		t.Fatalf("CreateDIDWBADocument() error = %v", err)
+	}
+	other, _, err := anp_auth.CreateDIDWBADocument("other.example.com", nil, nil, nil)
+	if err != nil {
+		t.Fatalf("CreateDIDWBADocument() error = %v", err)
+	}
+
+	newSigned := func() *Submission {
+		sub := NewSubmission(ActionRegister, Listing{DID: doc.ID, Name: "a", URL: "https://agent.example.com/ad.json"}, fixedNow())
+		if err := sub.Sign(key, doc.Authentication[0]); err != nil {
+			t.Fatalf("Sign() error = %v", err)
+		}
+		return sub
+	}
+
+	if err := newSigned().Verify(doc); err != nil {
+		t.Fatalf("Verify() error = %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		mutate  func(*Submission)
+		doc     *anp_auth.DIDWBADocument
+		wantErr error
+	}{
+		{"tampered listing", func(s *Submission) { s.Agent.URL = "https://evil.example.com/ad.json" }, doc, ErrSubmissionInvalid},
+		{"tampered action", func(s *Submission) { s.Action = ActionDelete }, doc, ErrSubmissionInvalid},
+		{"unsigned", func(s *Submission) { s.Proof = nil }, doc, ErrSubmissionUnsigned},
+		{"wrong DID document", func(s *Submission) {}, other, ErrSubmissionInvalid},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sub := newSigned()
+			tt.mutate(sub)
+			if err := sub.Verify(tt.doc); !errors.Is(err, tt.wantErr) {
+				t.Errorf("Verify() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestPublisher_RejectsForeignListing(t *testing.T) {
+	doc, key, err := anp_auth.CreateDIDWBADocument("agent.example.com", nil, nil, nil)
+	if err != nil {
+		t.Fatalf("CreateDIDWBADocument() error = %v", err)
+	}
+	p, err := NewPublisher(Config{Endpoint: "https://dir.example.com/agents", DIDDocument: doc, PrivateKey: key})
+	if err != nil {
+		t.Fatalf("NewPublisher() error = %v", err)
+	}
+	if _, err := p.Register(context.Background(), Listing{DID: "did:wba:other.example.com", Name: "x", URL: "https://x"}); err == nil {
+		t.Error("Expected error for listing with a different DID")
+	}
+}
+
+func fixedNow() time.Time {
+	return time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+}
@@ -0,0 +1,151 @@
+package anp_registry
+
+import (
+	"crypto/ecdsa"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/bytedance/sonic"
+	"github.com/cyberphone/json-canonicalization/go/src/webpki.org/jsoncanonicalizer"
+	"github.com/google/uuid"
+	"github.com/openanp/anp-go/anp_ad"
+	"github.com/openanp/anp-go/anp_auth"
+)
+
+// Action identifies the operation a Submission requests.
+type Action string
+
+const (
+	ActionRegister Action = "register"
+	ActionUpdate   Action = "update"
+	ActionDelete   Action = "delete"
+)
+
+// ProofPurposeAuthentication is the proof purpose used for registry submissions.
+const ProofPurposeAuthentication = "authentication"
+
+var (
+	// ErrSubmissionUnsigned is returned when verifying a submission without a proof.
+	ErrSubmissionUnsigned = errors.New("registry submission has no proof")
+
+	// ErrSubmissionInvalid is returned when a submission's proof does not verify.
+	ErrSubmissionInvalid = errors.New("registry submission proof verification failed")
+)
+
+// Listing is an agent's entry in a directory, mirroring the fields of
+// anp_crawler.AgentEntry that the agent controls.
+type Listing struct {
+	DID         string   `json:"did"`
+	Name        string   `json:"name"`
+	Description string   `json:"description,omitempty"`
+	URL         string   `json:"url"`
+	Tags        []string `json:"tags,omitempty"`
+}
+
+// ListingFromAgentDescription builds a Listing from ad, published at adURL.
+func ListingFromAgentDescription(ad *anp_ad.AgentDescription, adURL string) Listing {
+	listing := Listing{URL: adURL}
+	if ad != nil {
+		listing.DID = ad.DID
+		listing.Name = ad.Name
+		listing.Description = ad.Description
+		if listing.URL == "" {
+			listing.URL = ad.URL
+		}
+	}
+	return listing
+}
+
+// Submission is the signed body sent to a directory service.
+type Submission struct {
+	Action    Action        `json:"action"`
+	Agent     Listing       `json:"agent"`
+	Timestamp string        `json:"timestamp"`
+	Nonce     string        `json:"nonce"`
+	Proof     *anp_ad.Proof `json:"proof,omitempty"`
+}
+
+// NewSubmission creates an unsigned submission with a fresh nonce and timestamp.
+func NewSubmission(action Action, listing Listing, now time.Time) *Submission {
+	return &Submission{
+		Action:    action,
+		Agent:     listing,
+		Timestamp: now.UTC().Format(time.RFC3339),
+		Nonce:     uuid.NewString(),
+	}
+}
+
+// Sign attaches a proof to sub, signed with privateKey and referencing
+// verificationMethod (a full DID URL such as "did:wba:example.com#key-1").
+func (sub *Submission) Sign(privateKey *ecdsa.PrivateKey, verificationMethod string) error {
+	if privateKey == nil {
+		return errors.New("private key is required")
+	}
+	if verificationMethod == "" {
+		return errors.New("verification method is required")
+	}
+
+	sub.Proof = &anp_ad.Proof{
+		Type:               anp_ad.ProofTypeSecp256k1,
+		Created:            sub.Timestamp,
+		ProofPurpose:       ProofPurposeAuthentication,
+		VerificationMethod: verificationMethod,
+	}
+
+	content, err := sub.signingInput()
+	if err != nil {
+		return err
+	}
+	signature, err := anp_auth.SignContent(privateKey, content)
+	if err != nil {
+		return fmt.Errorf("sign submission: %w", err)
+	}
+	sub.Proof.ProofValue = signature
+	return nil
+}
+
+// Verify checks the submission's proof against didDoc. Directory services call
+// this after resolving the DID named in sub.Agent.DID; replay protection based on
+// Timestamp and Nonce is left to the caller.
+func (sub *Submission) Verify(didDoc *anp_auth.DIDWBADocument) error {
+	if sub.Proof == nil || sub.Proof.ProofValue == "" {
+		return ErrSubmissionUnsigned
+	}
+	if didDoc == nil {
+		return errors.New("DID document is required")
+	}
+	if sub.Agent.DID != didDoc.ID {
+		return fmt.Errorf("%w: agent DID %s does not match DID document %s", ErrSubmissionInvalid, sub.Agent.DID, didDoc.ID)
+	}
+
+	content, err := sub.signingInput()
+	if err != nil {
+		return err
+	}
+	err = anp_auth.VerifyContent(didDoc, sub.Proof.VerificationMethod, content, sub.Proof.ProofValue)
+	if errors.Is(err, anp_auth.ErrInvalidSignature) {
+		return ErrSubmissionInvalid
+	}
+	return err
+}
+
+// signingInput returns the JCS-canonical submission without proof.proofValue.
+func (sub *Submission) signingInput() ([]byte, error) {
+	unsigned := *sub
+	if sub.Proof != nil {
+		proof := *sub.Proof
+		proof.ProofValue = ""
+		unsigned.Proof = &proof
+	}
+
+	raw, err := sonic.Marshal(&unsigned)
+	if err != nil {
+		return nil, fmt.Errorf("marshal submission: %w", err)
+	}
+	canonical, err := jsoncanonicalizer.Transform(raw)
+	if err != nil {
+		return nil, fmt.Errorf("canonicalize submission: %w", err)
+	}
+	return canonical, nil
+}
@@ -0,0 +1,108 @@
+// Package anp_schema embeds the JSON Schemas for ANP documents and validates
+// documents against them.
+//
+// Clients can check what they fetched before trusting it, and servers can check
+// what they are about to publish:
+//
+//	raw, _ := ad.JSON()
+//	if err := anp_schema.Validate(anp_schema.AgentDescription, raw); err != nil {
+//		log.Fatal(err) // lists every violation with its JSON Pointer
+//	}
+//
+// Schema returns the raw schema so it can be served alongside the documents it
+// describes.
+package anp_schema
+
+import (
+	"embed"
+	"fmt"
+	"sync"
+
+	"github.com/bytedance/sonic"
+)
+
+// Names of the bundled schemas.
+const (
+	// AgentDescription describes ad.json documents.
+	AgentDescription = "agent-description"
+	// Directory describes agent directory documents listing agents in agentList.
+	Directory = "directory"
+	// AuthPayload describes the JSON form of a DID-WBA authentication payload.
+	AuthPayload = "auth-payload"
+)
+
+//go:embed schemas/*.json
+var files embed.FS
+
+var validators sync.Map // name -> *validator
+
+// Names lists the bundled schemas.
+func Names() []string {
+	return []string{AgentDescription, Directory, AuthPayload}
+}
+
+// Schema returns the JSON Schema document called name.
+func Schema(name string) ([]byte, error) {
+	raw, err := files.ReadFile("schemas/" + name + ".json")
+	if err != nil {
+		return nil, fmt.Errorf("anp_schema: unknown schema %q", name)
+	}
+	return raw, nil
+}
+
+// Validate checks the JSON document data against the schema called name. A
+// document that violates the schema yields an Errors value.
+func Validate(name string, data []byte) error {
+	v, err := load(name)
+	if err != nil {
+		return err
+	}
+	var doc any
+	if err := sonic.Unmarshal(data, &doc); err != nil {
+		return fmt.Errorf("decode document: %w", err)
+	}
+	return v.validate(doc)
+}
+
+// ValidateValue validates the JSON encoding of value, e.g. an
+// *anp_ad.AgentDescription about to be published.
+func ValidateValue(name string, value any) error {
+	data, err := sonic.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("encode document: %w", err)
+	}
+	return Validate(name, data)
+}
+
+// ValidateAgainst checks the JSON document data against an arbitrary JSON
+// Schema, such as the params schema an OpenRPC method declares. Only the
+// keywords the bundled schemas use are enforced (type, enum, properties,
+// required, items, pattern, format, anyOf and the like), and $ref must point
+// into schema itself.
+func ValidateAgainst(schema, data []byte) error {
+	v, err := newValidator(schema)
+	if err != nil {
+		return fmt.Errorf("anp_schema: compile schema: %w", err)
+	}
+	var doc any
+	if err := sonic.Unmarshal(data, &doc); err != nil {
+		return fmt.Errorf("decode document: %w", err)
+	}
+	return v.validate(doc)
+}
+
+func load(name string) (*validator, error) {
+	if v, ok := validators.Load(name); ok {
+		return v.(*validator), nil
+	}
+	raw, err := Schema(name)
+	if err != nil {
+		return nil, err
+	}
+	v, err := newValidator(raw)
+	if err != nil {
+		return nil, fmt.Errorf("anp_schema: compile %s: %w", name, err)
+	}
+	actual, _ := validators.LoadOrStore(name, v)
+	return actual.(*validator), nil
+}
@@ -0,0 +1,149 @@
+package anp_schema
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/openanp/anp-go/anp_ad"
+	"github.com/openanp/anp-go/anp_auth"
+	"github.com/openanp/anp-go/anptest"
+)
+
+func TestValidPublishedDocuments(t *testing.T) {
+	doc, key, err := anp_auth.CreateDIDWBADocument("example.com", nil, []string{"hotel"}, nil)
+	if err != nil {
+		t.Fatalf("CreateDIDWBADocument() error = %v", err)
+	}
+	ad, err := anp_ad.NewBuilder("Hotel Assistant").
+		DID(doc.ID).
+		URL("https://example.com/hotel/ad.json").
+		Owner("Organization", "Example Inc.", "https://example.com").
+		AddInterface(anp_ad.InterfaceTypeStructured, anp_ad.ProtocolOpenRPC, "https://example.com/hotel/api.json", "Booking API").
+		SignWith(key, doc.ID+"#key-1").
+		Build()
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+	if err := ValidateValue(AgentDescription, ad); err != nil {
+		t.Errorf("ValidateValue(builder output) error = %v", err)
+	}
+
+	authJSON, err := anp_auth.GenerateAuthJSON(key, doc, "example.com")
+	if err != nil {
+		t.Fatalf("GenerateAuthJSON() error = %v", err)
+	}
+	if err := ValidateValue(AuthPayload, authJSON); err != nil {
+		t.Errorf("ValidateValue(auth payload) error = %v", err)
+	}
+
+	directory := `{"agentList": [{"name": "Hotel", "url": "https://example.com/hotel/ad.json", "rating": 4.5, "usage_count": 12}]}`
+	if err := Validate(Directory, []byte(directory)); err != nil {
+		t.Errorf("Validate(directory) error = %v", err)
+	}
+}
+
+func TestValidFetchedDocument(t *testing.T) {
+	srv := anptest.NewServer(t, anptest.WithMethod("ping", func() error { return nil }))
+	resp, err := http.Get(srv.ADURL())
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	if err := Validate(AgentDescription, body); err != nil {
+		t.Errorf("Validate(anptest ad.json) error = %v", err)
+	}
+}
+
+func TestViolations(t *testing.T) {
+	tests := []struct {
+		name, schema, doc string
+		want              []string
+	}{
+		{
+			name:   "missing fields",
+			schema: AgentDescription,
+			doc:    `{"protocolType": "ANP", "type": "AgentDescription", "interfaces": []}`,
+			want:   []string{`missing required property "protocolVersion"`, `missing required property "name"`},
+		},
+		{
+			name:   "wrong constants and formats",
+			schema: AgentDescription,
+			doc: `{"protocolType": "MCP", "protocolVersion": "1.0.0", "type": "AgentDescription", "name": "a",
+				"did": "example.com", "interfaces": [{"type": "StructuredInterface", "protocol": "openrpc", "url": "/api.json"}]}`,
+			want: []string{"/protocolType: must be ANP", "/did: invalid did", "/interfaces/0/url: invalid uri"},
+		},
+		{
+			name:   "interface without url or content",
+			schema: AgentDescription,
+			doc:    `{"protocolType": "ANP", "protocolVersion": "1.0", "type": "AgentDescription", "name": "a", "interfaces": [{"type": "StructuredInterface", "protocol": "openrpc"}]}`,
+			want:   []string{"/interfaces/0: does not match any of the allowed shapes"},
+		},
+		{
+			name:   "directory types",
+			schema: Directory,
+			doc:    `{"agentList": [{"name": "a", "url": "https://a.example/ad.json", "usage_count": 1.5}, "b"]}`,
+			want:   []string{"/agentList/0/usage_count: expected integer, got number", "/agentList/1: expected object, got string"},
+		},
+		{
+			name:   "auth payload",
+			schema: AuthPayload,
+			doc:    `{"did": "did:web:a", "nonce": "", "timestamp": "yesterday", "verification_method": "key-1", "signature": "abc", "extra": 1}`,
+			want:   []string{"/did: does not match pattern", "/nonce: must be at least 1 characters", "/timestamp: invalid date-time", `unexpected property "extra"`},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Validate(tt.schema, []byte(tt.doc))
+			var errs Errors
+			if !errors.As(err, &errs) {
+				t.Fatalf("Validate() error = %v, want Errors", err)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("Validate() error = %v, want %q", err, want)
+				}
+			}
+		})
+	}
+}
+
+func TestSchemaLookup(t *testing.T) {
+	for _, name := range Names() {
+		if _, err := Schema(name); err != nil {
+			t.Errorf("Schema(%q) error = %v", name, err)
+		}
+	}
+	if err := Validate("openapi", []byte(`{}`)); err == nil {
+		t.Error("Validate(unknown schema) succeeded")
+	}
+	if err := Validate(Directory, []byte(`{`)); err == nil || errors.As(err, new(Errors)) {
+		t.Errorf("Validate(malformed JSON) error = %v, want decode error", err)
+	}
+}
+
+func TestValidateAgainst(t *testing.T) {
+	schema := []byte(`{
+		"type": "object",
+		"properties": {
+			"city": {"type": "string"},
+			"room": {"$ref": "#/components/schemas/Room"}
+		},
+		"required": ["city"],
+		"components": {"schemas": {"Room": {"type": "string", "enum": ["single", "double"]}}}
+	}`)
+	if err := ValidateAgainst(schema, []byte(`{"city":"Paris","room":"double"}`)); err != nil {
+		t.Errorf("ValidateAgainst(valid) error = %v", err)
+	}
+	err := ValidateAgainst(schema, []byte(`{"room":"suite"}`))
+	var errs Errors
+	if !errors.As(err, &errs) || len(errs) != 2 {
+		t.Fatalf("ValidateAgainst(invalid) error = %v, want 2 violations", err)
+	}
+	if err := ValidateAgainst([]byte(`[`), []byte(`{}`)); err == nil {
+		t.Error("ValidateAgainst(malformed schema) succeeded")
+	}
+}
@@ -0,0 +1,318 @@
+package anp_schema
+
+import (
+	"fmt"
+	"math"
+	"net/url"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bytedance/sonic"
+)
+
+// ValidationError reports one schema violation. Path is a JSON Pointer to the
+// offending value ("" for the document root).
+type ValidationError struct {
+	Path    string
+	Message string
+}
+
+func (e ValidationError) Error() string {
+	if e.Path == "" {
+		return e.Message
+	}
+	return e.Path + ": " + e.Message
+}
+
+// Errors lists every violation found in a document.
+type Errors []ValidationError
+
+func (e Errors) Error() string {
+	msgs := make([]string, len(e))
+	for i, err := range e {
+		msgs[i] = err.Error()
+	}
+	return "schema validation failed: " + strings.Join(msgs, "; ")
+}
+
+// validator checks documents against one compiled schema. It understands the
+// keywords used by the bundled schemas: type, const, enum, properties,
+// required, additionalProperties, items, minItems, minLength, minimum,
+// pattern, format, anyOf, oneOf, allOf and local $ref.
+type validator struct {
+	root     map[string]any
+	patterns sync.Map // string -> *regexp.Regexp
+}
+
+func newValidator(raw []byte) (*validator, error) {
+	var root map[string]any
+	if err := sonic.Unmarshal(raw, &root); err != nil {
+		return nil, err
+	}
+	return &validator{root: root}, nil
+}
+
+func (v *validator) validate(doc any) error {
+	var errs Errors
+	v.check(v.root, doc, "", &errs)
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+func (v *validator) check(schema map[string]any, value any, path string, errs *Errors) {
+	fail := func(format string, args ...any) {
+		*errs = append(*errs, ValidationError{Path: path, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if ref, ok := schema["$ref"].(string); ok {
+		target, err := v.resolve(ref)
+		if err != nil {
+			fail("%v", err)
+			return
+		}
+		v.check(target, value, path, errs)
+	}
+
+	if t, ok := schema["type"]; ok && !matchesType(t, value) {
+		fail("expected %s, got %s", typeList(t), typeOf(value))
+		return
+	}
+	if c, ok := schema["const"]; ok && !equal(c, value) {
+		fail("must be %v", c)
+	}
+	if enum, ok := schema["enum"].([]any); ok && !slices.ContainsFunc(enum, func(e any) bool { return equal(e, value) }) {
+		fail("must be one of %v", enum)
+	}
+
+	switch val := value.(type) {
+	case string:
+		if n, ok := number(schema["minLength"]); ok && float64(len([]rune(val))) < n {
+			fail("must be at least %v characters", n)
+		}
+		if p, ok := schema["pattern"].(string); ok {
+			if re, err := v.pattern(p); err != nil || !re.MatchString(val) {
+				fail("does not match pattern %s", p)
+			}
+		}
+		if f, ok := schema["format"].(string); ok {
+			if err := checkFormat(f, val); err != nil {
+				fail("invalid %s: %v", f, err)
+			}
+		}
+	case float64:
+		if n, ok := number(schema["minimum"]); ok && val < n {
+			fail("must be >= %v", n)
+		}
+	case []any:
+		if n, ok := number(schema["minItems"]); ok && float64(len(val)) < n {
+			fail("must have at least %v items", n)
+		}
+		if items, ok := schema["items"].(map[string]any); ok {
+			for i, item := range val {
+				v.check(items, item, path+"/"+strconv.Itoa(i), errs)
+			}
+		}
+	case map[string]any:
+		if required, ok := schema["required"].([]any); ok {
+			for _, r := range required {
+				if name, _ := r.(string); name != "" {
+					if _, ok := val[name]; !ok {
+						fail("missing required property %q", name)
+					}
+				}
+			}
+		}
+		props, _ := schema["properties"].(map[string]any)
+		for _, name := range sortedKeys(val) {
+			child := path + "/" + escapePointer(name)
+			if sub, ok := props[name].(map[string]any); ok {
+				v.check(sub, val[name], child, errs)
+				continue
+			}
+			switch extra := schema["additionalProperties"].(type) {
+			case bool:
+				if !extra {
+					fail("unexpected property %q", name)
+				}
+			case map[string]any:
+				v.check(extra, val[name], child, errs)
+			}
+		}
+	}
+
+	if all, ok := schema["allOf"].([]any); ok {
+		for _, s := range all {
+			if sub, ok := s.(map[string]any); ok {
+				v.check(sub, value, path, errs)
+			}
+		}
+	}
+	if anyOf, ok := schema["anyOf"].([]any); ok && v.countMatches(anyOf, value) == 0 {
+		fail("does not match any of the allowed shapes")
+	}
+	if oneOf, ok := schema["oneOf"].([]any); ok {
+		if n := v.countMatches(oneOf, value); n != 1 {
+			fail("must match exactly one allowed shape, matched %d", n)
+		}
+	}
+}
+
+func (v *validator) countMatches(schemas []any, value any) int {
+	n := 0
+	for _, s := range schemas {
+		sub, ok := s.(map[string]any)
+		if !ok {
+			continue
+		}
+		var errs Errors
+		v.check(sub, value, "", &errs)
+		if len(errs) == 0 {
+			n++
+		}
+	}
+	return n
+}
+
+// resolve follows a local reference such as "#/$defs/interface".
+func (v *validator) resolve(ref string) (map[string]any, error) {
+	if !strings.HasPrefix(ref, "#") {
+		return nil, fmt.Errorf("unsupported $ref %q", ref)
+	}
+	var node any = v.root
+	for _, part := range strings.Split(strings.TrimPrefix(ref, "#/"), "/") {
+		if part == "" {
+			continue
+		}
+		m, ok := node.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("unresolved $ref %q", ref)
+		}
+		node = m[strings.NewReplacer("~1", "/", "~0", "~").Replace(part)]
+	}
+	target, ok := node.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("unresolved $ref %q", ref)
+	}
+	return target, nil
+}
+
+func (v *validator) pattern(p string) (*regexp.Regexp, error) {
+	if re, ok := v.patterns.Load(p); ok {
+		return re.(*regexp.Regexp), nil
+	}
+	re, err := regexp.Compile(p)
+	if err != nil {
+		return nil, err
+	}
+	v.patterns.Store(p, re)
+	return re, nil
+}
+
+var didPattern = regexp.MustCompile(`^did:[a-z0-9]+:\S+$`)
+
+func checkFormat(format, s string) error {
+	switch format {
+	case "uri":
+		u, err := url.Parse(s)
+		if err != nil {
+			return err
+		}
+		if u.Scheme == "" || u.Host == "" {
+			return fmt.Errorf("%q is not an absolute URL", s)
+		}
+	case "date-time":
+		if _, err := time.Parse(time.RFC3339, s); err != nil {
+			return err
+		}
+	case "did":
+		if !didPattern.MatchString(s) {
+			return fmt.Errorf("%q is not a DID", s)
+		}
+	}
+	return nil
+}
+
+func matchesType(t, value any) bool {
+	switch t := t.(type) {
+	case string:
+		return isType(t, value)
+	case []any:
+		return slices.ContainsFunc(t, func(name any) bool {
+			s, _ := name.(string)
+			return isType(s, value)
+		})
+	}
+	return true
+}
+
+func isType(name string, value any) bool {
+	switch name {
+	case "integer":
+		f, ok := value.(float64)
+		return ok && f == math.Trunc(f)
+	case "number":
+		_, ok := value.(float64)
+		return ok
+	default:
+		return typeOf(value) == name
+	}
+}
+
+func typeOf(value any) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case float64:
+		return "number"
+	case string:
+		return "string"
+	case []any:
+		return "array"
+	case map[string]any:
+		return "object"
+	}
+	return fmt.Sprintf("%T", value)
+}
+
+func typeList(t any) string {
+	if list, ok := t.([]any); ok {
+		names := make([]string, len(list))
+		for i, n := range list {
+			names[i] = fmt.Sprint(n)
+		}
+		return strings.Join(names, " or ")
+	}
+	return fmt.Sprint(t)
+}
+
+func number(v any) (float64, bool) {
+	f, ok := v.(float64)
+	return f, ok
+}
+
+func equal(a, b any) bool {
+	ra, errA := sonic.ConfigStd.Marshal(a)
+	rb, errB := sonic.ConfigStd.Marshal(b)
+	return errA == nil && errB == nil && string(ra) == string(rb)
+}
+
+func sortedKeys(m map[string]any) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	return keys
+}
+
+func escapePointer(s string) string {
+	return strings.NewReplacer("~", "~0", "/", "~1").Replace(s)
+}
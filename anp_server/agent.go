@@ -0,0 +1,205 @@
+package anp_server
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"errors"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/bytedance/sonic"
+	"github.com/openanp/anp-go/anp_ad"
+	"github.com/openanp/anp-go/anp_auth"
+	"github.com/openanp/anp-go/anp_debug"
+	"github.com/openanp/anp-go/openrpc"
+)
+
+// Paths served by an Agent, relative to its BaseURL.
+const (
+	PathAgentDescription = "/ad.json"
+	PathOpenRPC          = "/openrpc.json"
+	PathRPC              = "/rpc"
+)
+
+const shutdownTimeout = 5 * time.Second
+
+// AgentConfig describes an agent hosted by NewAgent.
+type AgentConfig struct {
+	Name        string
+	Description string
+	// BaseURL is the public URL the agent is reachable at, e.g.
+	// "https://example.com/hotel". The documents link to each other through it.
+	BaseURL string
+	// DIDDocument and PrivateKey are the agent's identity; ad.json is signed
+	// with the key of the document's first authentication method.
+	DIDDocument *anp_auth.DIDWBADocument
+	PrivateKey  *ecdsa.PrivateKey
+	// Verifier requires DID-WBA authentication for everything but the DID
+	// document. Nil serves every path publicly.
+	Verifier *anp_auth.DidWbaVerifier
+	Logger   *slog.Logger
+	// Debug records every request; see Config.Debug.
+	Debug *anp_debug.Recorder
+}
+
+// Agent hosts a complete ANP agent on one handler: the DID document, a signed
+// ad.json, the generated OpenRPC document and the JSON-RPC endpoint.
+//
+//	agent, err := anp_server.NewAgent(anp_server.AgentConfig{
+//		Name:        "Calculator",
+//		BaseURL:     "https://calc.example.com",
+//		DIDDocument: doc,
+//		PrivateKey:  key,
+//		Verifier:    verifier,
+//	})
+//	agent.Register("add", func(p AddParams) (int, error) { return p.A + p.B, nil })
+//	log.Fatal(anp_server.ServeAgent(ctx, ":8080", agent))
+type Agent struct {
+	// RPC dispatches the JSON-RPC endpoint; methods may be registered at any time.
+	RPC *Server
+
+	handler http.Handler
+}
+
+// NewAgent builds and signs the agent's documents and routes.
+func NewAgent(cfg AgentConfig) (*Agent, error) {
+	if cfg.Name == "" {
+		return nil, errors.New("anp_server: agent name is required")
+	}
+	if cfg.DIDDocument == nil || cfg.PrivateKey == nil {
+		return nil, errors.New("anp_server: agent DID document and private key are required")
+	}
+	if len(cfg.DIDDocument.Authentication) == 0 {
+		return nil, errors.New("anp_server: agent DID document has no authentication method")
+	}
+	base, err := url.Parse(strings.TrimSuffix(cfg.BaseURL, "/"))
+	if err != nil || base.Scheme == "" || base.Host == "" {
+		return nil, errors.New("anp_server: agent BaseURL must be an absolute URL")
+	}
+
+	rpc := New(Config{
+		Info:     openrpc.Info{Title: cfg.Name, Description: cfg.Description},
+		Verifier: cfg.Verifier,
+		Logger:   cfg.Logger,
+		Debug:    cfg.Debug,
+	})
+	link := func(path string) string { return base.String() + path }
+	rpc.Generator().AddServer(openrpc.Server{Name: cfg.Name, URL: link(PathRPC)})
+
+	ad, err := anp_ad.NewBuilder(cfg.Name).
+		DID(cfg.DIDDocument.ID).
+		URL(link(PathAgentDescription)).
+		Description(cfg.Description).
+		AddInterface(anp_ad.InterfaceTypeStructured, anp_ad.ProtocolOpenRPC, link(PathOpenRPC), cfg.Description).
+		SignWith(cfg.PrivateKey, cfg.DIDDocument.Authentication[0]).
+		Build()
+	if err != nil {
+		return nil, err
+	}
+	adJSON, err := ad.JSON()
+	if err != nil {
+		return nil, err
+	}
+	a := &Agent{RPC: rpc}
+
+	protect := func(h http.Handler) http.Handler {
+		if cfg.Verifier == nil {
+			return h
+		}
+		return anp_auth.Middleware(cfg.Verifier)(h)
+	}
+	mux := http.NewServeMux()
+	mux.Handle(base.Path+PathAgentDescription, protect(staticJSON(adJSON)))
+	mux.Handle(base.Path+PathOpenRPC, protect(rpc.OpenRPCHandler()))
+	mux.Handle(base.Path+PathRPC, protect(rpc))
+
+	// The DID document is only served when it resolves to this host. Ports are
+	// ignored since the agent usually listens behind a TLS terminator.
+	if docURL, err := anp_auth.DIDDocumentURL(cfg.DIDDocument.ID); err == nil {
+		if u, err := url.Parse(docURL); err == nil && u.Hostname() == base.Hostname() {
+			didJSON, err := sonic.Marshal(cfg.DIDDocument)
+			if err != nil {
+				return nil, err
+			}
+			mux.Handle(u.Path, staticJSON(didJSON))
+		}
+	}
+
+	a.handler = rpc.debug.Middleware(mux)
+	return a, nil
+}
+
+// Register exposes fn as a JSON-RPC method; see Server.Register.
+func (a *Agent) Register(name string, fn any, opts ...openrpc.MethodOption) error {
+	return a.RPC.Register(name, fn, opts...)
+}
+
+// RegisterRaw exposes handler as a JSON-RPC method described by desc; see
+// Server.RegisterRaw.
+func (a *Agent) RegisterRaw(desc openrpc.Method, handler RawHandler) error {
+	return a.RPC.RegisterRaw(desc, handler)
+}
+
+// RegisterService registers every handler-shaped method of receiver; see
+// Server.RegisterService.
+func (a *Agent) RegisterService(receiver any) error {
+	return a.RPC.RegisterService(receiver)
+}
+
+// Handler returns the handler serving every agent path.
+func (a *Agent) Handler() http.Handler {
+	return a.handler
+}
+
+// Serve serves the agent on ln until ctx is done, then shuts down gracefully.
+func (a *Agent) Serve(ctx context.Context, ln net.Listener) error {
+	srv := &http.Server{
+		Handler:           a.handler,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	errCh := make(chan error, 1)
+	go func() { errCh <- srv.Serve(ln) }()
+
+	select {
+	case err := <-errCh:
+		return err
+	case <-ctx.Done():
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+		if err := srv.Shutdown(shutdownCtx); err != nil {
+			return err
+		}
+		if err := <-errCh; !errors.Is(err, http.ErrServerClosed) {
+			return err
+		}
+		return nil
+	}
+}
+
+// ServeAgent listens on addr and serves agent until ctx is done. TLS is
+// expected to be terminated in front of it, as did:wba resolvers use HTTPS.
+func ServeAgent(ctx context.Context, addr string, agent *Agent) error {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	return agent.Serve(ctx, ln)
+}
+
+func staticJSON(body []byte) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			w.Header().Set("Allow", "GET, HEAD")
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if r.Method == http.MethodGet {
+			w.Write(body)
+		}
+	})
+}
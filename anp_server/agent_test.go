@@ -0,0 +1,169 @@
+package anp_server
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/bytedance/sonic"
+	"github.com/openanp/anp-go/anp_ad"
+	"github.com/openanp/anp-go/anp_auth"
+	"github.com/openanp/anp-go/session"
+)
+
+type addParams struct {
+	A int `json:"a"`
+	B int `json:"b"`
+}
+
+func TestServeAgent(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen() error = %v", err)
+	}
+	port := ln.Addr().(*net.TCPAddr).Port
+	base := fmt.Sprintf("http://localhost:%d/calc", port)
+
+	agentDoc, agentKey, err := anp_auth.CreateDIDWBADocument("localhost", nil, []string{"calc"}, nil)
+	if err != nil {
+		t.Fatalf("CreateDIDWBADocument() error = %v", err)
+	}
+	callerDoc, callerKey, err := anp_auth.CreateDIDWBADocument("client.example.com", nil, nil, nil)
+	if err != nil {
+		t.Fatalf("CreateDIDWBADocument() error = %v", err)
+	}
+	raw, _ := sonic.Marshal(callerDoc)
+	var resolved anp_auth.DIDWBADocument
+	if err := sonic.Unmarshal(raw, &resolved); err != nil {
+		t.Fatalf("decode caller document: %v", err)
+	}
+	jwtKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("GenerateKey() error = %v", err)
+	}
+	verifier, err := anp_auth.NewDidWbaVerifier(anp_auth.DidWbaVerifierConfig{
+		JWTPrivateKey:  jwtKey,
+		JWTPublicKey:   &jwtKey.PublicKey,
+		NonceValidator: anp_auth.NewMemoryNonceValidator(time.Minute),
+		ResolveDIDDocument: func(_ context.Context, did string) (*anp_auth.DIDWBADocument, error) {
+			if did != resolved.ID {
+				return nil, fmt.Errorf("unknown DID %s", did)
+			}
+			return &resolved, nil
+		},
+	})
+	if err != nil {
+		t.Fatalf("NewDidWbaVerifier() error = %v", err)
+	}
+
+	agent, err := NewAgent(AgentConfig{
+		Name:        "Calculator",
+		Description: "Adds numbers",
+		BaseURL:     base + "/",
+		DIDDocument: agentDoc,
+		PrivateKey:  agentKey,
+		Verifier:    verifier,
+	})
+	if err != nil {
+		t.Fatalf("NewAgent() error = %v", err)
+	}
+	if err := agent.Register("add", func(p addParams) (int, error) { return p.A + p.B, nil }); err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- agent.Serve(ctx, ln) }()
+
+	// The DID document is public and served at the path derived from did:wba:localhost:calc.
+	resp, err := http.Get(base + "/did.json")
+	if err != nil {
+		t.Fatalf("Get(did.json) error = %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	var served anp_auth.DIDWBADocument
+	if resp.StatusCode != http.StatusOK || sonic.Unmarshal(body, &served) != nil || served.ID != agentDoc.ID {
+		t.Fatalf("did.json = %d %s", resp.StatusCode, body)
+	}
+
+	resp, err = http.Get(base + PathAgentDescription)
+	if err != nil {
+		t.Fatalf("Get(ad.json) error = %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("unauthenticated ad.json status = %d, want 401", resp.StatusCode)
+	}
+
+	auth, err := anp_auth.NewAuthenticator(anp_auth.WithDIDMaterial(callerDoc, callerKey))
+	if err != nil {
+		t.Fatalf("NewAuthenticator() error = %v", err)
+	}
+	sess, err := session.New(session.Config{Authenticator: auth})
+	if err != nil {
+		t.Fatalf("session.New() error = %v", err)
+	}
+	adDoc, err := sess.Fetch(ctx, base+PathAgentDescription)
+	if err != nil {
+		t.Fatalf("Fetch(ad.json) error = %v", err)
+	}
+	ad, err := anp_ad.Parse(adDoc.Raw)
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if err := anp_ad.VerifyProof(ad, &served); err != nil {
+		t.Errorf("VerifyProof() error = %v", err)
+	}
+	if ad.Name != "Calculator" || len(ad.Interfaces) != 1 || ad.Interfaces[0].URL != base+PathOpenRPC {
+		t.Errorf("ad.json = %+v", ad)
+	}
+
+	rpcDoc, err := sess.Fetch(ctx, ad.Interfaces[0].URL)
+	if err != nil {
+		t.Fatalf("Fetch(openrpc.json) error = %v", err)
+	}
+	if len(rpcDoc.Interfaces) != 1 {
+		t.Fatalf("interfaces = %d, want 1", len(rpcDoc.Interfaces))
+	}
+	result, err := rpcDoc.Interfaces[0].Execute(ctx, map[string]any{"a": 2, "b": 3})
+	if err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	if fmt.Sprint(result["result"]) != "5" {
+		t.Errorf("Execute() = %v, want 5", result)
+	}
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Errorf("Serve() error = %v", err)
+	}
+}
+
+func TestNewAgentValidation(t *testing.T) {
+	doc, key, err := anp_auth.CreateDIDWBADocument("example.com", nil, nil, nil)
+	if err != nil {
+		t.Fatalf("CreateDIDWBADocument() error = %v", err)
+	}
+	tests := []struct {
+		name string
+		cfg  AgentConfig
+	}{
+		{"missing name", AgentConfig{BaseURL: "https://example.com", DIDDocument: doc, PrivateKey: key}},
+		{"missing key", AgentConfig{Name: "a", BaseURL: "https://example.com", DIDDocument: doc}},
+		{"relative base URL", AgentConfig{Name: "a", BaseURL: "/agent", DIDDocument: doc, PrivateKey: key}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewAgent(tt.cfg); err == nil {
+				t.Error("NewAgent() succeeded")
+			}
+		})
+	}
+}
@@ -0,0 +1,52 @@
+package anp_server
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Standard JSON-RPC 2.0 error codes.
+const (
+	CodeParseError     = -32700
+	CodeInvalidRequest = -32600
+	CodeMethodNotFound = -32601
+	CodeInvalidParams  = -32602
+	CodeInternalError  = -32603
+
+	// CodeServerError is used for handler errors that are not an *Error.
+	CodeServerError = -32000
+)
+
+// Error is a JSON-RPC error object. Handlers may return an *Error to control the
+// code and data sent to the caller; any other error is reported as CodeServerError.
+type Error struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+	Data    any    `json:"data,omitempty"`
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("jsonrpc error %d: %s", e.Code, e.Message)
+}
+
+// NewError creates a JSON-RPC error with the given code and message.
+func NewError(code int, message string, data ...any) *Error {
+	e := &Error{Code: code, Message: message}
+	if len(data) > 0 {
+		e.Data = data[0]
+	}
+	return e
+}
+
+// InvalidParams returns a CodeInvalidParams error with a formatted message.
+func InvalidParams(format string, args ...any) *Error {
+	return &Error{Code: CodeInvalidParams, Message: fmt.Sprintf(format, args...)}
+}
+
+func toRPCError(err error) *Error {
+	var rpcErr *Error
+	if errors.As(err, &rpcErr) {
+		return rpcErr
+	}
+	return &Error{Code: CodeServerError, Message: err.Error()}
+}
@@ -0,0 +1,424 @@
+// Package anp_server implements the server side of ANP JSON-RPC interfaces: typed
+// method registration, JSON-RPC 2.0 envelope handling, and DID-WBA authentication.
+// It is the counterpart to anp_crawler.ANPInterface.Execute.
+package anp_server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"reflect"
+	"runtime/debug"
+	"sync"
+
+	"github.com/bytedance/sonic"
+	"github.com/openanp/anp-go/anp_auth"
+	"github.com/openanp/anp-go/anp_debug"
+	"github.com/openanp/anp-go/openrpc"
+)
+
+const defaultMaxBodyBytes = 10 << 20
+
+// Config describes how a Server should be built.
+type Config struct {
+	// Info is published in the generated OpenRPC document.
+	Info openrpc.Info
+	// Verifier enables DID-WBA authentication in Handler. Nil disables authentication.
+	Verifier *anp_auth.DidWbaVerifier
+	// MaxBodyBytes limits the request body size (default 10 MiB).
+	MaxBodyBytes int64
+	Logger       *slog.Logger
+	// Debug records every request served by Handler. When nil, the recorder for
+	// $ANP_DEBUG_DIR is used if that variable is set.
+	Debug *anp_debug.Recorder
+}
+
+// Server dispatches JSON-RPC 2.0 requests to registered Go handlers.
+type Server struct {
+	verifier     *anp_auth.DidWbaVerifier
+	maxBodyBytes int64
+	logger       *slog.Logger
+	debug        *anp_debug.Recorder
+	generator    *openrpc.Generator
+
+	mu      sync.RWMutex
+	methods map[string]*method
+}
+
+type method struct {
+	fn     reflect.Value
+	sig    openrpc.HandlerSignature
+	raw    RawHandler
+	params []*openrpc.ContentDescriptor
+}
+
+// RawHandler handles a method registered with RegisterRaw. params is a JSON
+// object keyed by param name; positional params are mapped to the declared
+// names first.
+type RawHandler func(ctx context.Context, params json.RawMessage) (any, error)
+
+// New creates a Server.
+func New(cfg Config) *Server {
+	logger := cfg.Logger
+	if logger == nil {
+		logger = slog.Default()
+	}
+	maxBody := cfg.MaxBodyBytes
+	if maxBody <= 0 {
+		maxBody = defaultMaxBodyBytes
+	}
+	debug := cfg.Debug
+	if debug == nil {
+		rec, err := anp_debug.FromEnv()
+		if err != nil {
+			logger.Warn("anp_server: traffic recording disabled", "error", err)
+		}
+		debug = rec
+	}
+
+	return &Server{
+		verifier:     cfg.Verifier,
+		maxBodyBytes: maxBody,
+		logger:       logger,
+		debug:        debug,
+		generator:    openrpc.NewGenerator(cfg.Info),
+		methods:      make(map[string]*method),
+	}
+}
+
+// Register exposes fn as the JSON-RPC method name.
+//
+// fn must have the shape func([context.Context,] [P]) ([R,] error) where P is a struct
+// (or pointer to struct) decoded from the request params.
+func (s *Server) Register(name string, fn any, opts ...openrpc.MethodOption) error {
+	if err := s.generator.Register(name, fn, opts...); err != nil {
+		return err
+	}
+	desc, _ := s.generator.Method(name)
+	sig, _ := openrpc.InspectHandler(fn)
+
+	s.mu.Lock()
+	s.methods[name] = &method{fn: reflect.ValueOf(fn), sig: sig, params: desc.Params}
+	s.mu.Unlock()
+	return nil
+}
+
+// RegisterRaw exposes handler as the JSON-RPC method desc.Name, described by
+// desc instead of by reflection. Use it when the params schema is only known
+// at run time, e.g. when proxying another service. Required params are
+// checked before handler is called.
+func (s *Server) RegisterRaw(desc openrpc.Method, handler RawHandler) error {
+	if handler == nil {
+		return fmt.Errorf("anp_server: method %q has no handler", desc.Name)
+	}
+	if err := s.generator.AddMethod(desc); err != nil {
+		return err
+	}
+	desc, _ = s.generator.Method(desc.Name)
+
+	s.mu.Lock()
+	s.methods[desc.Name] = &method{raw: handler, params: desc.Params}
+	s.mu.Unlock()
+	return nil
+}
+
+// RegisterService registers every exported handler-shaped method of receiver,
+// using lowerCamelCase method names.
+func (s *Server) RegisterService(receiver any) error {
+	v := reflect.ValueOf(receiver)
+	registered := 0
+	for i := 0; i < v.NumMethod(); i++ {
+		m := v.Type().Method(i)
+		fn := v.Method(i).Interface()
+		if _, err := openrpc.InspectHandler(fn); err != nil {
+			continue
+		}
+		if err := s.Register(openrpc.LowerCamel(m.Name), fn); err != nil {
+			return err
+		}
+		registered++
+	}
+	if registered == 0 {
+		return fmt.Errorf("anp_server: %T has no methods with a handler signature", receiver)
+	}
+	return nil
+}
+
+// OpenRPC returns the OpenRPC document describing the registered methods.
+func (s *Server) OpenRPC() *openrpc.Document {
+	return s.generator.Document()
+}
+
+// Generator exposes the underlying OpenRPC generator, e.g. to add servers.
+func (s *Server) Generator() *openrpc.Generator {
+	return s.generator
+}
+
+// Handler returns the JSON-RPC endpoint, wrapped in anp_auth.Middleware when a
+// Verifier is configured.
+func (s *Server) Handler() http.Handler {
+	if s.verifier == nil {
+		return s.debug.Middleware(s)
+	}
+	return s.debug.Middleware(anp_auth.Middleware(s.verifier)(s))
+}
+
+// OpenRPCHandler serves the generated OpenRPC document.
+func (s *Server) OpenRPCHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := s.OpenRPC().JSON()
+		if err != nil {
+			http.Error(w, "encode OpenRPC document", http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(body)
+	})
+}
+
+// request is a single JSON-RPC request object.
+type request struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+// response is a single JSON-RPC response object.
+type response struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *Error          `json:"error,omitempty"`
+}
+
+var nullID = json.RawMessage("null")
+
+// ServeHTTP handles JSON-RPC requests without authentication. Use Handler to
+// enforce DID-WBA.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, s.maxBodyBytes))
+	if err != nil {
+		writeJSON(w, response{JSONRPC: "2.0", ID: nullID, Error: NewError(CodeInvalidRequest, "request body too large or unreadable")})
+		return
+	}
+
+	trimmed := bytes.TrimLeft(body, " \t\r\n")
+	if len(trimmed) > 0 && trimmed[0] == '[' {
+		s.serveBatch(w, r.Context(), trimmed)
+		return
+	}
+
+	var req request
+	if err := sonic.Unmarshal(body, &req); err != nil {
+		writeJSON(w, response{JSONRPC: "2.0", ID: nullID, Error: NewError(CodeParseError, "parse error")})
+		return
+	}
+
+	resp, ok := s.dispatch(r.Context(), &req)
+	if !ok {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	writeJSON(w, resp)
+}
+
+func (s *Server) serveBatch(w http.ResponseWriter, ctx context.Context, body []byte) {
+	var raws []json.RawMessage
+	if err := sonic.Unmarshal(body, &raws); err != nil {
+		writeJSON(w, response{JSONRPC: "2.0", ID: nullID, Error: NewError(CodeParseError, "parse error")})
+		return
+	}
+	if len(raws) == 0 {
+		writeJSON(w, response{JSONRPC: "2.0", ID: nullID, Error: NewError(CodeInvalidRequest, "empty batch")})
+		return
+	}
+
+	responses := make([]response, 0, len(raws))
+	for _, raw := range raws {
+		var req request
+		if err := sonic.Unmarshal(raw, &req); err != nil {
+			responses = append(responses, response{JSONRPC: "2.0", ID: nullID, Error: NewError(CodeInvalidRequest, "invalid request")})
+			continue
+		}
+		if resp, ok := s.dispatch(ctx, &req); ok {
+			responses = append(responses, resp)
+		}
+	}
+
+	if len(responses) == 0 {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	writeJSON(w, responses)
+}
+
+// dispatch executes req. The boolean is false for notifications, which get no response.
+func (s *Server) dispatch(ctx context.Context, req *request) (response, bool) {
+	isNotification := len(req.ID) == 0
+	id := req.ID
+	if isNotification {
+		id = nullID
+	}
+
+	reply := func(result any, rpcErr *Error) (response, bool) {
+		if isNotification {
+			return response{}, false
+		}
+		if rpcErr != nil {
+			return response{JSONRPC: "2.0", ID: id, Error: rpcErr}, true
+		}
+		encoded, err := sonic.Marshal(result)
+		if err != nil {
+			return response{JSONRPC: "2.0", ID: id, Error: NewError(CodeInternalError, "encode result")}, true
+		}
+		return response{JSONRPC: "2.0", ID: id, Result: encoded}, true
+	}
+
+	if req.JSONRPC != "2.0" || req.Method == "" {
+		return reply(nil, NewError(CodeInvalidRequest, "invalid request"))
+	}
+
+	s.mu.RLock()
+	m, ok := s.methods[req.Method]
+	s.mu.RUnlock()
+	if !ok {
+		return reply(nil, NewError(CodeMethodNotFound, fmt.Sprintf("method %s not found", req.Method)))
+	}
+
+	result, rpcErr := s.call(ctx, m, req.Params)
+	if rpcErr != nil {
+		s.logger.Debug("json-rpc call failed", "method", req.Method, "code", rpcErr.Code, "error", rpcErr.Message)
+	}
+	return reply(result, rpcErr)
+}
+
+// call runs the handler of m. A panicking handler is reported to the caller as
+// CodeInternalError so that one bad request cannot take the server down.
+func (s *Server) call(ctx context.Context, m *method, rawParams json.RawMessage) (result any, rpcErr *Error) {
+	defer func() {
+		if p := recover(); p != nil {
+			s.logger.ErrorContext(ctx, "json-rpc handler panicked", "panic", p, "stack", string(debug.Stack()))
+			result, rpcErr = nil, NewError(CodeInternalError, "internal error")
+		}
+	}()
+
+	if m.raw != nil {
+		params, rpcErr := normalizeParams(m.params, rawParams)
+		if rpcErr != nil {
+			return nil, rpcErr
+		}
+		result, err := m.raw(ctx, params)
+		if err != nil {
+			return nil, toRPCError(err)
+		}
+		return result, nil
+	}
+
+	var args []reflect.Value
+	if m.sig.HasContext {
+		args = append(args, reflect.ValueOf(ctx))
+	}
+
+	if m.sig.Params != nil {
+		params, rpcErr := decodeParams(m, rawParams)
+		if rpcErr != nil {
+			return nil, rpcErr
+		}
+		args = append(args, params)
+	}
+
+	out := m.fn.Call(args)
+	if errVal := out[len(out)-1]; !errVal.IsNil() {
+		return nil, toRPCError(errVal.Interface().(error))
+	}
+	if m.sig.Result == nil {
+		return nil, nil
+	}
+	return out[0].Interface(), nil
+}
+
+// decodeParams decodes by-name (object) or by-position (array) params into the
+// handler's params struct, checking that required params are present.
+func decodeParams(m *method, raw json.RawMessage) (reflect.Value, *Error) {
+	normalized, rpcErr := normalizeParams(m.params, raw)
+	if rpcErr != nil {
+		return reflect.Value{}, rpcErr
+	}
+
+	paramsType := m.sig.Params
+	isPtr := paramsType.Kind() == reflect.Pointer
+	if isPtr {
+		paramsType = paramsType.Elem()
+	}
+	target := reflect.New(paramsType)
+	if err := sonic.Unmarshal(normalized, target.Interface()); err != nil {
+		return reflect.Value{}, InvalidParams("invalid params: %v", err)
+	}
+
+	if isPtr {
+		return target, nil
+	}
+	return target.Elem(), nil
+}
+
+// normalizeParams converts by-name (object) or by-position (array) params to
+// a JSON object keyed by the declared param names, checking that required
+// params are present.
+func normalizeParams(params []*openrpc.ContentDescriptor, raw json.RawMessage) ([]byte, *Error) {
+	object := map[string]json.RawMessage{}
+
+	trimmed := bytes.TrimSpace(raw)
+	switch {
+	case len(trimmed) == 0 || bytes.Equal(trimmed, []byte("null")):
+	case trimmed[0] == '{':
+		if err := sonic.Unmarshal(trimmed, &object); err != nil {
+			return nil, InvalidParams("invalid params: %v", err)
+		}
+	case trimmed[0] == '[':
+		var positional []json.RawMessage
+		if err := sonic.Unmarshal(trimmed, &positional); err != nil {
+			return nil, InvalidParams("invalid params: %v", err)
+		}
+		if len(positional) > len(params) {
+			return nil, InvalidParams("too many params: got %d, want at most %d", len(positional), len(params))
+		}
+		for i, value := range positional {
+			object[params[i].Name] = value
+		}
+	default:
+		return nil, InvalidParams("params must be an object or array")
+	}
+
+	for _, p := range params {
+		if _, ok := object[p.Name]; p.Required && !ok {
+			return nil, InvalidParams("missing required param %q", p.Name)
+		}
+	}
+
+	normalized, err := sonic.Marshal(object)
+	if err != nil {
+		return nil, NewError(CodeInternalError, "encode params")
+	}
+	return normalized, nil
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	body, err := sonic.Marshal(v)
+	if err != nil {
+		http.Error(w, "encode response", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(body)
+}
@@ -0,0 +1,256 @@
+package anp_server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/bytedance/sonic"
+	"github.com/openanp/anp-go/anp_crawler"
+	"github.com/openanp/anp-go/openrpc"
+)
+
+type greetParams struct {
+	Name     string `json:"name"`
+	Greeting string `json:"greeting,omitempty"`
+}
+
+type greetResult struct {
+	Message string `json:"message"`
+}
+
+func newTestServer(t *testing.T) *Server {
+	t.Helper()
+	s := New(Config{Logger: slog.New(slog.NewTextHandler(io.Discard, nil))})
+	if err := s.Register("greet", func(ctx context.Context, p greetParams) (greetResult, error) {
+		greeting := p.Greeting
+		if greeting == "" {
+			greeting = "Hello"
+		}
+		return greetResult{Message: greeting + ", " + p.Name}, nil
+	}); err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+	if err := s.Register("fail", func(ctx context.Context) error {
+		return NewError(4001, "room unavailable", map[string]any{"room": "deluxe"})
+	}); err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+	if err := s.Register("boom", func() error { return errors.New("exploded") }); err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+	if err := s.Register("panic", func(ctx context.Context, p greetParams) (greetResult, error) {
+		panic("handler bug")
+	}); err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+	return s
+}
+
+func post(t *testing.T, h http.Handler, body string) (int, map[string]any, []any) {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodPost, "/rpc", strings.NewReader(body))
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+
+	raw, _ := io.ReadAll(rec.Body)
+	if len(raw) == 0 {
+		return rec.Code, nil, nil
+	}
+	if raw[0] == '[' {
+		var batch []any
+		if err := sonic.Unmarshal(raw, &batch); err != nil {
+			t.Fatalf("decode batch: %v (%s)", err, raw)
+		}
+		return rec.Code, nil, batch
+	}
+	var single map[string]any
+	if err := sonic.Unmarshal(raw, &single); err != nil {
+		t.Fatalf("decode response: %v (%s)", err, raw)
+	}
+	return rec.Code, single, nil
+}
+
+func errorCode(resp map[string]any) int {
+	errObj, ok := resp["error"].(map[string]any)
+	if !ok {
+		return 0
+	}
+	code, _ := errObj["code"].(float64)
+	return int(code)
+}
+
+func TestServer_Dispatch(t *testing.T) {
+	s := newTestServer(t)
+
+	tests := []struct {
+		name     string
+		body     string
+		wantCode int
+		wantMsg  string
+	}{
+		{"by-name params", `{"jsonrpc":"2.0","id":1,"method":"greet","params":{"name":"Ada"}}`, 0, "Hello, Ada"},
+		{"by-position params", `{"jsonrpc":"2.0","id":"a","method":"greet","params":["Ada","Hi"]}`, 0, "Hi, Ada"},
+		{"missing required param", `{"jsonrpc":"2.0","id":2,"method":"greet","params":{}}`, CodeInvalidParams, ""},
+		{"unknown method", `{"jsonrpc":"2.0","id":3,"method":"nope"}`, CodeMethodNotFound, ""},
+		{"invalid version", `{"jsonrpc":"1.0","id":4,"method":"greet"}`, CodeInvalidRequest, ""},
+		{"parse error", `{"jsonrpc":`, CodeParseError, ""},
+		{"typed error", `{"jsonrpc":"2.0","id":5,"method":"fail"}`, 4001, ""},
+		{"plain error", `{"jsonrpc":"2.0","id":6,"method":"boom"}`, CodeServerError, ""},
+		{"panic", `{"jsonrpc":"2.0","id":7,"method":"panic","params":{"name":"Ada"}}`, CodeInternalError, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, resp, _ := post(t, s, tt.body)
+			if got := errorCode(resp); got != tt.wantCode {
+				t.Fatalf("error code = %d, want %d (%v)", got, tt.wantCode, resp)
+			}
+			if tt.wantMsg != "" {
+				result, _ := resp["result"].(map[string]any)
+				if result["message"] != tt.wantMsg {
+					t.Errorf("message = %v, want %q", result["message"], tt.wantMsg)
+				}
+			}
+		})
+	}
+}
+
+func TestServer_BatchAndNotification(t *testing.T) {
+	s := newTestServer(t)
+
+	code, _, _ := post(t, s, `{"jsonrpc":"2.0","method":"greet","params":{"name":"Ada"}}`)
+	if code != http.StatusNoContent {
+		t.Errorf("notification status = %d, want %d", code, http.StatusNoContent)
+	}
+
+	_, _, batch := post(t, s, `[
+		{"jsonrpc":"2.0","id":1,"method":"greet","params":{"name":"Ada"}},
+		{"jsonrpc":"2.0","method":"greet","params":{"name":"silent"}},
+		{"jsonrpc":"2.0","id":2,"method":"nope"},
+		{"jsonrpc":"2.0","id":3,"method":"panic","params":{"name":"Ada"}},
+		42
+	]`)
+	if len(batch) != 4 {
+		t.Fatalf("batch responses = %d, want 4: %v", len(batch), batch)
+	}
+	if got := errorCode(batch[2].(map[string]any)); got != CodeInternalError {
+		t.Errorf("panicking batch entry code = %d, want %d", got, CodeInternalError)
+	}
+	if got := errorCode(batch[3].(map[string]any)); got != CodeInvalidRequest {
+		t.Errorf("invalid batch entry code = %d, want %d", got, CodeInvalidRequest)
+	}
+
+	_, resp, _ := post(t, s, `[]`)
+	if got := errorCode(resp); got != CodeInvalidRequest {
+		t.Errorf("empty batch code = %d, want %d", got, CodeInvalidRequest)
+	}
+}
+
+func TestServer_OpenRPCDocument(t *testing.T) {
+	s := newTestServer(t)
+	doc := s.OpenRPC()
+	if len(doc.Methods) != 4 {
+		t.Fatalf("methods = %d, want 4", len(doc.Methods))
+	}
+	if doc.Methods[2].Name != "greet" || len(doc.Methods[2].Params) != 2 {
+		t.Errorf("unexpected greet method: %+v", doc.Methods[2])
+	}
+}
+
+func TestServer_RegisterRaw(t *testing.T) {
+	s := New(Config{})
+	desc := openrpc.Method{
+		Name:        "echo",
+		Description: "Echo the text param",
+		Params: []*openrpc.ContentDescriptor{
+			{Name: "text", Required: true, Schema: &openrpc.Schema{Type: "string"}},
+			{Name: "times", Schema: &openrpc.Schema{Type: "integer"}},
+		},
+	}
+	err := s.RegisterRaw(desc, func(ctx context.Context, params json.RawMessage) (any, error) {
+		var p struct {
+			Text  string `json:"text"`
+			Times int    `json:"times"`
+		}
+		if err := sonic.Unmarshal(params, &p); err != nil {
+			return nil, InvalidParams("invalid params: %v", err)
+		}
+		return strings.Repeat(p.Text, max(p.Times, 1)), nil
+	})
+	if err != nil {
+		t.Fatalf("RegisterRaw() error = %v", err)
+	}
+	if err := s.RegisterRaw(desc, nil); err == nil {
+		t.Error("RegisterRaw(nil handler) error = nil, want error")
+	}
+	if err := s.RegisterRaw(openrpc.Method{Name: "echo"}, func(context.Context, json.RawMessage) (any, error) { return nil, nil }); err == nil {
+		t.Error("RegisterRaw(duplicate) error = nil, want error")
+	}
+
+	tests := []struct {
+		name       string
+		body       string
+		wantCode   int
+		wantResult string
+	}{
+		{"by-name params", `{"jsonrpc":"2.0","id":1,"method":"echo","params":{"text":"ab","times":2}}`, 0, "abab"},
+		{"by-position params", `{"jsonrpc":"2.0","id":2,"method":"echo","params":["ab",3]}`, 0, "ababab"},
+		{"missing required param", `{"jsonrpc":"2.0","id":3,"method":"echo","params":{"times":2}}`, CodeInvalidParams, ""},
+		{"handler error", `{"jsonrpc":"2.0","id":4,"method":"echo","params":{"text":1}}`, CodeInvalidParams, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, resp, _ := post(t, s, tt.body)
+			if got := errorCode(resp); got != tt.wantCode {
+				t.Fatalf("error code = %d, want %d (%v)", got, tt.wantCode, resp)
+			}
+			if tt.wantResult != "" && resp["result"] != tt.wantResult {
+				t.Errorf("result = %v, want %q", resp["result"], tt.wantResult)
+			}
+		})
+	}
+
+	method, ok := s.Generator().Method("echo")
+	if !ok || method.Description != desc.Description || len(method.Params) != 2 {
+		t.Errorf("OpenRPC method = %+v, want the registered description", method)
+	}
+}
+
+// fakeClient routes anp_crawler requests to an in-process handler.
+type fakeClient struct{ handler http.Handler }
+
+func (c fakeClient) Fetch(ctx context.Context, method, target string, headers map[string]string, body any) (*anp_crawler.Response, error) {
+	payload, err := sonic.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+	req := httptest.NewRequest(method, target, strings.NewReader(string(payload)))
+	rec := httptest.NewRecorder()
+	c.handler.ServeHTTP(rec, req)
+	return &anp_crawler.Response{StatusCode: rec.Code, Header: rec.Header(), Body: rec.Body.Bytes()}, nil
+}
+
+func TestServer_ANPInterfaceRoundTrip(t *testing.T) {
+	s := newTestServer(t)
+	entry := anp_crawler.InterfaceEntry{
+		MethodName: "greet",
+		Servers:    []anp_crawler.Server{{URL: "https://agent.example.com/rpc"}},
+	}
+	iface := anp_crawler.NewANPInterface("greet", entry, fakeClient{handler: s.Handler()})
+
+	resp, err := iface.Execute(context.Background(), map[string]any{"name": "Ada"})
+	if err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	result, _ := resp["result"].(map[string]any)
+	if result["message"] != "Hello, Ada" {
+		t.Errorf("unexpected result: %v", resp)
+	}
+}
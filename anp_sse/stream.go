@@ -0,0 +1,301 @@
+// Package anp_sse helps agents publish Server-Sent Event streams, e.g. progress
+// updates for long-running tasks, to authenticated peers.
+//
+// Browsers' EventSource cannot set an Authorization header, so NewHandler also
+// accepts the bearer token issued by the DID-WBA verifier in a query parameter
+// (access_token by default). Event IDs are assigned sequentially and resume after
+// the Last-Event-ID sent by a reconnecting client.
+//
+//	http.Handle("/tasks/events", anp_sse.NewHandler(verifier, func(ctx context.Context, s *anp_sse.Stream) error {
+//		for p := range progress {
+//			if err := s.SendJSON("progress", p); err != nil {
+//				return err
+//			}
+//		}
+//		return nil
+//	}))
+package anp_sse
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bytedance/sonic"
+	"github.com/openanp/anp-go/anp_auth"
+)
+
+const (
+	// DefaultTokenParam is the query parameter that may carry the bearer token.
+	DefaultTokenParam = "access_token"
+	// LastEventIDHeader is sent by reconnecting EventSource clients.
+	LastEventIDHeader = "Last-Event-ID"
+
+	defaultHeartbeat = 15 * time.Second
+)
+
+// ErrStreamClosed is returned by Send after the client has gone away.
+var ErrStreamClosed = errors.New("anp_sse: stream closed")
+
+// Event is a single server-sent event.
+type Event struct {
+	// ID is assigned automatically when empty.
+	ID string
+	// Event is the event type; empty means "message".
+	Event string
+	Data  string
+	// Retry tells the client how long to wait before reconnecting.
+	Retry time.Duration
+}
+
+// Stream writes events to one subscriber. It is safe for concurrent use.
+type Stream struct {
+	w    http.ResponseWriter
+	rc   *http.ResponseController
+	ctx  context.Context
+	last string
+
+	mu     sync.Mutex
+	nextID uint64
+	err    error
+}
+
+func newStream(ctx context.Context, w http.ResponseWriter, r *http.Request) *Stream {
+	last := r.Header.Get(LastEventIDHeader)
+	s := &Stream{w: w, rc: http.NewResponseController(w), ctx: ctx, last: last, nextID: 1}
+	if n, err := strconv.ParseUint(last, 10, 64); err == nil {
+		s.nextID = n + 1
+	}
+	return s
+}
+
+// Context is canceled when the client disconnects or the handler returns.
+func (s *Stream) Context() context.Context { return s.ctx }
+
+// LastEventID returns the Last-Event-ID sent by a reconnecting client, or "".
+func (s *Stream) LastEventID() string { return s.last }
+
+// Send writes ev and flushes it to the client.
+func (s *Stream) Send(ev Event) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if ev.ID == "" {
+		ev.ID = strconv.FormatUint(s.nextID, 10)
+		s.nextID++
+	}
+
+	var b strings.Builder
+	if ev.Retry > 0 {
+		fmt.Fprintf(&b, "retry: %d\n", ev.Retry.Milliseconds())
+	}
+	fmt.Fprintf(&b, "id: %s\n", sanitize(ev.ID))
+	if ev.Event != "" {
+		fmt.Fprintf(&b, "event: %s\n", sanitize(ev.Event))
+	}
+	for _, line := range strings.Split(strings.ReplaceAll(ev.Data, "\r\n", "\n"), "\n") {
+		fmt.Fprintf(&b, "data: %s\n", line)
+	}
+	b.WriteByte('\n')
+	return s.write(b.String())
+}
+
+// SendJSON sends v encoded as JSON in an event of the given type.
+func (s *Stream) SendJSON(event string, v any) error {
+	data, err := sonic.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("encode event: %w", err)
+	}
+	return s.Send(Event{Event: event, Data: string(data)})
+}
+
+// close rejects further writes once the handler has returned.
+func (s *Stream) close() {
+	s.mu.Lock()
+	s.err = ErrStreamClosed
+	s.mu.Unlock()
+}
+
+// comment writes an SSE comment line, which clients ignore. Used for heartbeats.
+func (s *Stream) comment(text string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.write(": " + text + "\n\n")
+}
+
+// write sends raw and flushes. The caller must hold s.mu.
+func (s *Stream) write(raw string) error {
+	if s.err != nil {
+		return s.err
+	}
+	if s.ctx.Err() != nil {
+		s.err = ErrStreamClosed
+		return s.err
+	}
+	if _, err := s.w.Write([]byte(raw)); err != nil {
+		s.err = fmt.Errorf("%w: %v", ErrStreamClosed, err)
+		return s.err
+	}
+	if err := s.rc.Flush(); err != nil {
+		s.err = fmt.Errorf("%w: flush: %v", ErrStreamClosed, err)
+		return s.err
+	}
+	return nil
+}
+
+func sanitize(field string) string {
+	return strings.NewReplacer("\r", "", "\n", "").Replace(field)
+}
+
+// HandlerFunc produces events for one subscriber. The stream ends when it returns.
+type HandlerFunc func(ctx context.Context, s *Stream) error
+
+// Option configures NewHandler.
+type Option func(*handler)
+
+// WithHeartbeat sets how often a comment is sent to keep idle connections and
+// proxies alive. Zero disables heartbeats.
+func WithHeartbeat(d time.Duration) Option {
+	return func(h *handler) { h.heartbeat = d }
+}
+
+// WithRetry advertises the client reconnection delay when the stream opens.
+func WithRetry(d time.Duration) Option {
+	return func(h *handler) { h.retry = d }
+}
+
+// WithTokenParam changes the query parameter that may carry the bearer token.
+// An empty name disables query tokens.
+func WithTokenParam(name string) Option {
+	return func(h *handler) { h.tokenParam = name }
+}
+
+// WithLogger sets the logger used for stream diagnostics.
+func WithLogger(logger *slog.Logger) Option {
+	return func(h *handler) {
+		if logger != nil {
+			h.logger = logger
+		}
+	}
+}
+
+type handler struct {
+	fn         HandlerFunc
+	heartbeat  time.Duration
+	retry      time.Duration
+	tokenParam string
+	logger     *slog.Logger
+}
+
+// NewHandler returns an http.Handler that streams events produced by fn. When
+// verifier is non-nil the request must pass DID-WBA authentication, either via the
+// Authorization header or a bearer token in the query string; the caller's DID is
+// available from the context with anp_auth.DIDFromContext.
+func NewHandler(verifier *anp_auth.DidWbaVerifier, fn HandlerFunc, opts ...Option) http.Handler {
+	h := &handler{
+		fn:         fn,
+		heartbeat:  defaultHeartbeat,
+		tokenParam: DefaultTokenParam,
+		logger:     slog.Default(),
+	}
+	for _, opt := range opts {
+		opt(h)
+	}
+
+	if verifier == nil {
+		return h
+	}
+	protected := anp_auth.Middleware(verifier)(h)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		protected.ServeHTTP(w, h.promoteQueryToken(r))
+	})
+}
+
+// promoteQueryToken moves a query-string bearer token into the Authorization
+// header so anp_auth.Middleware can verify it.
+func (h *handler) promoteQueryToken(r *http.Request) *http.Request {
+	if h.tokenParam == "" || r.Header.Get(anp_auth.AuthorizationHeader) != "" {
+		return r
+	}
+	query := r.URL.Query()
+	token := query.Get(h.tokenParam)
+	if token == "" {
+		return r
+	}
+
+	r = r.Clone(r.Context())
+	r.Header.Set(anp_auth.AuthorizationHeader, anp_auth.BearerScheme+token)
+	// Keep the token out of anything downstream that logs the URL.
+	query.Del(h.tokenParam)
+	r.URL.RawQuery = query.Encode()
+	return r
+}
+
+func (h *handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", http.MethodGet)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+
+	s := newStream(ctx, w, r)
+	opening := ": connected\n\n"
+	if h.retry > 0 {
+		opening = fmt.Sprintf("retry: %d\n\n", h.retry.Milliseconds())
+	}
+	s.mu.Lock()
+	err := s.write(opening)
+	s.mu.Unlock()
+	if err != nil {
+		return
+	}
+
+	var wg sync.WaitGroup
+	if h.heartbeat > 0 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			h.keepAlive(ctx, s)
+		}()
+	}
+
+	err = h.fn(ctx, s)
+	if err != nil && !errors.Is(err, ErrStreamClosed) && ctx.Err() == nil {
+		h.logger.Debug("event stream ended with error", "path", r.URL.Path, "error", err)
+	}
+
+	// The ResponseWriter must not be touched after ServeHTTP returns.
+	cancel()
+	wg.Wait()
+	s.close()
+}
+
+func (h *handler) keepAlive(ctx context.Context, s *Stream) {
+	ticker := time.NewTicker(h.heartbeat)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := s.comment("heartbeat"); err != nil {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,157 @@
+package anp_sse
+
+import (
+	"bufio"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/openanp/anp-go/anp_auth"
+	"github.com/openanp/anp-go/anptest"
+)
+
+type sseEvent struct {
+	id, event, data string
+}
+
+// readEvents parses n events from body, skipping comments and retry fields.
+func readEvents(t *testing.T, resp *http.Response, n int) []sseEvent {
+	t.Helper()
+	var (
+		events  []sseEvent
+		current sseEvent
+		data    []string
+	)
+	scanner := bufio.NewScanner(resp.Body)
+	for len(events) < n && scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case line == "":
+			if current.id != "" || len(data) > 0 {
+				current.data = strings.Join(data, "\n")
+				events = append(events, current)
+			}
+			current, data = sseEvent{}, nil
+		case strings.HasPrefix(line, "id: "):
+			current.id = strings.TrimPrefix(line, "id: ")
+		case strings.HasPrefix(line, "event: "):
+			current.event = strings.TrimPrefix(line, "event: ")
+		case strings.HasPrefix(line, "data: "):
+			data = append(data, strings.TrimPrefix(line, "data: "))
+		}
+	}
+	if len(events) < n {
+		t.Fatalf("read %d events, want %d (scan error: %v)", len(events), n, scanner.Err())
+	}
+	return events
+}
+
+func TestHandler_DIDAuthAndQueryToken(t *testing.T) {
+	caller := anptest.NewIdentity(t, "client.example.com")
+
+	srv := httptest.NewServer(NewHandler(anptest.NewVerifier(t, caller), func(ctx context.Context, s *Stream) error {
+		did, _ := anp_auth.DIDFromContext(ctx)
+		if err := s.SendJSON("hello", map[string]string{"did": did}); err != nil {
+			return err
+		}
+		return s.Send(Event{Event: "progress", Data: "line one\nline two"})
+	}))
+	defer srv.Close()
+
+	// First connection authenticates with a DID-WBA header and receives a token.
+	req, _ := http.NewRequest(http.MethodGet, srv.URL, nil)
+	headers, err := caller.Authenticator.GenerateHeader(srv.URL)
+	if err != nil {
+		t.Fatalf("GenerateHeader() error = %v", err)
+	}
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("Do() error = %v", err)
+	}
+	defer resp.Body.Close()
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Fatalf("Content-Type = %q", ct)
+	}
+
+	events := readEvents(t, resp, 2)
+	if events[0].id != "1" || events[0].event != "hello" || !strings.Contains(events[0].data, caller.Document.ID) {
+		t.Errorf("first event = %+v", events[0])
+	}
+	if events[1].id != "2" || events[1].data != "line one\nline two" {
+		t.Errorf("second event = %+v", events[1])
+	}
+
+	token := strings.TrimPrefix(resp.Header.Get(anp_auth.AuthorizationHeader), anp_auth.BearerScheme)
+	if token == "" {
+		t.Fatal("expected bearer token in response")
+	}
+
+	// Reconnect EventSource-style: token in the query string, resuming after ID 7.
+	req, _ = http.NewRequest(http.MethodGet, srv.URL+"?"+url.Values{DefaultTokenParam: {token}}.Encode(), nil)
+	req.Header.Set(LastEventIDHeader, "7")
+	resumed, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("Do() error = %v", err)
+	}
+	defer resumed.Body.Close()
+	if resumed.StatusCode != http.StatusOK {
+		t.Fatalf("status = %d", resumed.StatusCode)
+	}
+	if events := readEvents(t, resumed, 1); events[0].id != "8" {
+		t.Errorf("resumed event id = %q, want 8", events[0].id)
+	}
+
+	// No credentials at all.
+	anon, err := http.Get(srv.URL)
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	anon.Body.Close()
+	if anon.StatusCode != http.StatusUnauthorized {
+		t.Errorf("anonymous status = %d, want 401", anon.StatusCode)
+	}
+}
+
+func TestHandler_Heartbeat(t *testing.T) {
+	srv := httptest.NewServer(NewHandler(nil, func(ctx context.Context, s *Stream) error {
+		<-ctx.Done()
+		return nil
+	}, WithHeartbeat(10*time.Millisecond), WithRetry(time.Second)))
+	defer srv.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL, nil)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("Do() error = %v", err)
+	}
+	defer resp.Body.Close()
+
+	scanner := bufio.NewScanner(resp.Body)
+	var lines []string
+	for len(lines) < 4 && scanner.Scan() {
+		if line := scanner.Text(); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	if len(lines) < 4 || lines[0] != "retry: 1000" || lines[1] != ": heartbeat" {
+		t.Errorf("stream lines = %q", lines)
+	}
+}
+
+func TestHandler_MethodNotAllowed(t *testing.T) {
+	rec := httptest.NewRecorder()
+	NewHandler(nil, func(context.Context, *Stream) error { return nil }).
+		ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/", nil))
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("status = %d, want 405", rec.Code)
+	}
+}
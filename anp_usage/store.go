@@ -0,0 +1,60 @@
+package anp_usage
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+var _ Store = (*MemoryStore)(nil)
+
+type recordKey struct {
+	did   string
+	start int64 // Unix nanoseconds
+}
+
+// MemoryStore is an in-memory Store. Its records are lost on restart and are
+// not shared between processes.
+type MemoryStore struct {
+	mu      sync.Mutex
+	records map[recordKey]Counters
+}
+
+// NewMemoryStore creates an empty MemoryStore.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{records: make(map[recordKey]Counters)}
+}
+
+// Add implements Store.
+func (s *MemoryStore) Add(_ context.Context, did string, start time.Time, delta Counters) error {
+	key := recordKey{did: did, start: start.UnixNano()}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.records[key] = s.records[key].Add(delta)
+	return nil
+}
+
+// Query implements Store.
+func (s *MemoryStore) Query(_ context.Context, q Query) ([]Record, error) {
+	s.mu.Lock()
+	var out []Record
+	for key, c := range s.records {
+		start := time.Unix(0, key.start).UTC()
+		if (q.DID != "" && key.did != q.DID) ||
+			(!q.Since.IsZero() && start.Before(q.Since)) ||
+			(!q.Until.IsZero() && !start.Before(q.Until)) {
+			continue
+		}
+		out = append(out, Record{DID: key.did, Start: start, Counters: c})
+	}
+	s.mu.Unlock()
+
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].DID != out[j].DID {
+			return out[i].DID < out[j].DID
+		}
+		return out[i].Start.Before(out[j].Start)
+	})
+	return out, nil
+}
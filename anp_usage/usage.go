@@ -0,0 +1,195 @@
+// Package anp_usage accounts requests and bytes per authenticated DID, so ANP
+// service operators can bill callers or enforce quotas.
+//
+// A Tracker aggregates usage into fixed time buckets held by a Store;
+// Middleware records every request that passed anp_auth.Middleware:
+//
+//	tracker, _ := anp_usage.NewTracker(anp_usage.Config{})
+//	http.Handle("/rpc", anp_auth.Middleware(verifier)(tracker.Middleware(rpcHandler)))
+//
+//	// Later, for billing or a quota check:
+//	used, _ := tracker.Total(ctx, did, monthStart, time.Now())
+package anp_usage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/openanp/anp-go/anp_auth"
+)
+
+// DefaultBucket is the aggregation period used when Config.Bucket is zero.
+const DefaultBucket = time.Hour
+
+// Counters is an amount of usage.
+type Counters struct {
+	Requests int64 `json:"requests"`
+	BytesIn  int64 `json:"bytes_in"`
+	BytesOut int64 `json:"bytes_out"`
+}
+
+// Add returns the sum of c and o.
+func (c Counters) Add(o Counters) Counters {
+	return Counters{
+		Requests: c.Requests + o.Requests,
+		BytesIn:  c.BytesIn + o.BytesIn,
+		BytesOut: c.BytesOut + o.BytesOut,
+	}
+}
+
+// Record is the usage of one DID in the bucket starting at Start.
+type Record struct {
+	DID   string    `json:"did"`
+	Start time.Time `json:"start"`
+	Counters
+}
+
+// Query selects records. Zero-valued fields do not filter.
+type Query struct {
+	DID string
+	// Since and Until bound the bucket start times, Since inclusive and Until
+	// exclusive.
+	Since time.Time
+	Until time.Time
+}
+
+// Store aggregates usage records. Implementations must be safe for
+// concurrent use; see MemoryStore.
+type Store interface {
+	// Add adds delta to the record of did for the bucket starting at start,
+	// creating it if needed.
+	Add(ctx context.Context, did string, start time.Time, delta Counters) error
+	// Query returns the matching records ordered by DID, then start time.
+	Query(ctx context.Context, q Query) ([]Record, error)
+}
+
+// Config configures a Tracker.
+type Config struct {
+	// Store holds the records (default a new MemoryStore).
+	Store Store
+	// Bucket is the aggregation period (default DefaultBucket). Bucket
+	// boundaries are aligned to the Unix epoch in UTC.
+	Bucket time.Duration
+	// Logger receives store failures from Middleware. Nil uses slog.Default.
+	Logger *slog.Logger
+	// Now returns the current time (default time.Now).
+	Now func() time.Time
+}
+
+// Tracker records usage per DID.
+type Tracker struct {
+	store  Store
+	bucket time.Duration
+	logger *slog.Logger
+	now    func() time.Time
+}
+
+// NewTracker creates a Tracker.
+func NewTracker(cfg Config) (*Tracker, error) {
+	if cfg.Bucket < 0 {
+		return nil, errors.New("anp_usage: bucket must not be negative")
+	}
+	if cfg.Store == nil {
+		cfg.Store = NewMemoryStore()
+	}
+	if cfg.Bucket == 0 {
+		cfg.Bucket = DefaultBucket
+	}
+	if cfg.Logger == nil {
+		cfg.Logger = slog.Default()
+	}
+	if cfg.Now == nil {
+		cfg.Now = time.Now
+	}
+	return &Tracker{store: cfg.Store, bucket: cfg.Bucket, logger: cfg.Logger, now: cfg.Now}, nil
+}
+
+// Record adds one request of did with the given body sizes to the current
+// bucket.
+func (t *Tracker) Record(ctx context.Context, did string, bytesIn, bytesOut int64) error {
+	if did == "" {
+		return errors.New("anp_usage: DID is empty")
+	}
+	start := t.now().UTC().Truncate(t.bucket)
+	return t.store.Add(ctx, did, start, Counters{Requests: 1, BytesIn: bytesIn, BytesOut: bytesOut})
+}
+
+// Query returns the bucketed records matching q.
+func (t *Tracker) Query(ctx context.Context, q Query) ([]Record, error) {
+	return t.store.Query(ctx, q)
+}
+
+// Total sums the usage of did in the buckets starting in [since, until).
+// Usage is attributed to whole buckets, so since and until are effectively
+// rounded down to bucket boundaries.
+func (t *Tracker) Total(ctx context.Context, did string, since, until time.Time) (Counters, error) {
+	if !since.IsZero() {
+		since = since.UTC().Truncate(t.bucket)
+	}
+	if !until.IsZero() {
+		until = until.UTC().Truncate(t.bucket).Add(t.bucket)
+	}
+	records, err := t.store.Query(ctx, Query{DID: did, Since: since, Until: until})
+	if err != nil {
+		return Counters{}, err
+	}
+	var total Counters
+	for _, r := range records {
+		total = total.Add(r.Counters)
+	}
+	return total, nil
+}
+
+// Middleware records each request authenticated by anp_auth.Middleware,
+// counting the request body bytes read by next and the response body bytes
+// it wrote. Requests without an authenticated DID are not recorded. Store
+// failures are logged and do not affect the response.
+func (t *Tracker) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		did, ok := anp_auth.DIDFromContext(r.Context())
+		if !ok {
+			next.ServeHTTP(w, r)
+			return
+		}
+		body := &countingReader{ReadCloser: r.Body}
+		if r.Body != nil && r.Body != http.NoBody {
+			r.Body = body
+		}
+		cw := &countingWriter{ResponseWriter: w}
+		next.ServeHTTP(cw, r)
+
+		if err := t.Record(r.Context(), did, body.n, cw.n); err != nil {
+			t.logger.WarnContext(r.Context(), "record usage", "did", did, "error", err)
+		}
+	})
+}
+
+type countingReader struct {
+	io.ReadCloser
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.ReadCloser.Read(p)
+	c.n += int64(n)
+	return n, err
+}
+
+type countingWriter struct {
+	http.ResponseWriter
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.ResponseWriter.Write(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer, e.g. to
+// flush streamed responses.
+func (c *countingWriter) Unwrap() http.ResponseWriter { return c.ResponseWriter }
@@ -0,0 +1,202 @@
+package anp_usage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/openanp/anp-go/anp_auth"
+)
+
+// testClock is a settable clock for Config.Now.
+type testClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *testClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *testClock) Set(t time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = t
+}
+
+func newTestTracker(t *testing.T, clock *testClock) *Tracker {
+	t.Helper()
+	tracker, err := NewTracker(Config{Now: clock.Now})
+	if err != nil {
+		t.Fatalf("NewTracker() error = %v", err)
+	}
+	return tracker
+}
+
+func TestTracker_RecordAndQuery(t *testing.T) {
+	ctx := context.Background()
+	base := time.Date(2025, 3, 1, 10, 15, 0, 0, time.UTC)
+	clock := &testClock{now: base}
+	tracker := newTestTracker(t, clock)
+
+	const alice, bob = "did:wba:example.com:alice", "did:wba:example.com:bob"
+	record := func(did string, in, out int64) {
+		t.Helper()
+		if err := tracker.Record(ctx, did, in, out); err != nil {
+			t.Fatalf("Record() error = %v", err)
+		}
+	}
+	record(alice, 10, 100)
+	record(alice, 5, 50)
+	record(bob, 1, 1)
+	clock.Set(base.Add(time.Hour))
+	record(alice, 7, 70)
+
+	records, err := tracker.Query(ctx, Query{DID: alice})
+	if err != nil {
+		t.Fatalf("Query() error = %v", err)
+	}
+	want := []Record{
+		{DID: alice, Start: base.Truncate(time.Hour), Counters: Counters{Requests: 2, BytesIn: 15, BytesOut: 150}},
+		{DID: alice, Start: base.Truncate(time.Hour).Add(time.Hour), Counters: Counters{Requests: 1, BytesIn: 7, BytesOut: 70}},
+	}
+	if len(records) != len(want) {
+		t.Fatalf("Query() = %+v, want %+v", records, want)
+	}
+	for i := range want {
+		if !records[i].Start.Equal(want[i].Start) || records[i].DID != want[i].DID || records[i].Counters != want[i].Counters {
+			t.Errorf("Query()[%d] = %+v, want %+v", i, records[i], want[i])
+		}
+	}
+
+	all, err := tracker.Query(ctx, Query{})
+	if err != nil {
+		t.Fatalf("Query() error = %v", err)
+	}
+	if len(all) != 3 || all[2].DID != bob {
+		t.Errorf("Query(all) = %+v, want 3 records ending with %s", all, bob)
+	}
+
+	tests := []struct {
+		name         string
+		since, until time.Time
+		want         Counters
+	}{
+		{"all time", time.Time{}, time.Time{}, Counters{Requests: 3, BytesIn: 22, BytesOut: 220}},
+		{"first bucket", base, base, Counters{Requests: 2, BytesIn: 15, BytesOut: 150}},
+		{"second bucket onwards", base.Add(time.Hour), time.Time{}, Counters{Requests: 1, BytesIn: 7, BytesOut: 70}},
+		{"before any usage", base.Add(-48 * time.Hour), base.Add(-24 * time.Hour), Counters{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tracker.Total(ctx, alice, tt.since, tt.until)
+			if err != nil {
+				t.Fatalf("Total() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Total() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTracker_RecordEmptyDID(t *testing.T) {
+	tracker := newTestTracker(t, &testClock{now: time.Now()})
+	if err := tracker.Record(context.Background(), "", 0, 0); err == nil {
+		t.Error("Record() error = nil, want error for empty DID")
+	}
+}
+
+func TestNewTracker_NegativeBucket(t *testing.T) {
+	if _, err := NewTracker(Config{Bucket: -time.Minute}); err == nil {
+		t.Error("NewTracker() error = nil, want error for negative bucket")
+	}
+}
+
+func TestTracker_Middleware(t *testing.T) {
+	clock := &testClock{now: time.Date(2025, 3, 1, 10, 0, 0, 0, time.UTC)}
+	tracker := newTestTracker(t, clock)
+	handler := tracker.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		_, _ = w.Write([]byte("echo:"))
+		_, _ = w.Write(body)
+	}))
+
+	const did = "did:wba:example.com:alice"
+	serve := func(ctx context.Context, body string) {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodPost, "/rpc", strings.NewReader(body)).WithContext(ctx)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		if got, want := rec.Body.String(), "echo:"+body; got != want {
+			t.Fatalf("response body = %q, want %q", got, want)
+		}
+	}
+	serve(context.WithValue(context.Background(), anp_auth.ContextKeyDID, did), "hello")
+	// Unauthenticated requests pass through without being recorded.
+	serve(context.Background(), "anonymous")
+
+	got, err := tracker.Total(context.Background(), did, time.Time{}, time.Time{})
+	if err != nil {
+		t.Fatalf("Total() error = %v", err)
+	}
+	if want := (Counters{Requests: 1, BytesIn: 5, BytesOut: 10}); got != want {
+		t.Errorf("Total() = %+v, want %+v", got, want)
+	}
+	all, _ := tracker.Query(context.Background(), Query{})
+	if len(all) != 1 {
+		t.Errorf("Query() = %+v, want only the authenticated request", all)
+	}
+}
+
+// failingStore rejects every write.
+type failingStore struct{ *MemoryStore }
+
+func (failingStore) Add(context.Context, string, time.Time, Counters) error {
+	return errors.New("store unavailable")
+}
+
+func TestTracker_MiddlewareStoreFailure(t *testing.T) {
+	tracker, err := NewTracker(Config{Store: failingStore{NewMemoryStore()}})
+	if err != nil {
+		t.Fatalf("NewTracker() error = %v", err)
+	}
+	handler := tracker.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	ctx := context.WithValue(context.Background(), anp_auth.ContextKeyDID, "did:wba:example.com:alice")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil).WithContext(ctx))
+	if rec.Code != http.StatusAccepted {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusAccepted)
+	}
+}
+
+func TestMemoryStore_Concurrent(t *testing.T) {
+	store := NewMemoryStore()
+	start := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_ = store.Add(context.Background(), "did:wba:example.com:alice", start, Counters{Requests: 1, BytesIn: 2})
+		}()
+	}
+	wg.Wait()
+	records, err := store.Query(context.Background(), Query{})
+	if err != nil {
+		t.Fatalf("Query() error = %v", err)
+	}
+	if len(records) != 1 || records[0].Requests != 50 || records[0].BytesIn != 100 {
+		t.Errorf("Query() = %+v, want one record with 50 requests", records)
+	}
+}
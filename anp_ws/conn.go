@@ -0,0 +1,387 @@
+// Package anp_ws provides persistent, bidirectional agent-to-agent messaging over
+// WebSocket. Connections are authenticated with DID-WBA during the HTTP upgrade and
+// carry JSON-RPC 2.0 frames in both directions, so either peer can issue requests,
+// answer them, or send notifications for the lifetime of the conversation.
+//
+//	conn, _ := anp_ws.Dial(ctx, "wss://agent.example.com/ws", auth,
+//		anp_ws.WithHandler(func(ctx context.Context, req *anp_ws.Request) (any, error) {
+//			return "pong", nil
+//		}))
+//	defer conn.Close()
+//	var reply Quote
+//	err := conn.Call(ctx, "getQuote", map[string]any{"sku": "A1"}, &reply)
+//
+// The server side is an http.Handler created with NewHandler.
+package anp_ws
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/bytedance/sonic"
+	"github.com/coder/websocket"
+	"github.com/openanp/anp-go/anp_server"
+)
+
+const (
+	defaultReadLimit        = 10 << 20
+	defaultHeartbeat        = 30 * time.Second
+	defaultHeartbeatTimeout = 10 * time.Second
+)
+
+var (
+	// ErrClosed is returned for calls on, or pending on, a closed connection.
+	ErrClosed = errors.New("anp_ws: connection closed")
+	// ErrHeartbeatTimeout is the close cause when the peer stops answering pings.
+	ErrHeartbeatTimeout = errors.New("anp_ws: heartbeat timeout")
+)
+
+// Request is an incoming request or notification.
+type Request struct {
+	Method string
+	Params json.RawMessage
+	// Notification is true when the peer does not expect a response.
+	Notification bool
+	Conn         *Conn
+}
+
+// Bind decodes the request params into v.
+func (r *Request) Bind(v any) error {
+	if len(r.Params) == 0 {
+		return nil
+	}
+	if err := sonic.Unmarshal(r.Params, v); err != nil {
+		return anp_server.InvalidParams("invalid params: %v", err)
+	}
+	return nil
+}
+
+// Handler answers requests sent by the peer. The result is encoded as the
+// JSON-RPC result; a returned *anp_server.Error controls the error code. Results
+// of notifications are discarded.
+type Handler func(ctx context.Context, req *Request) (any, error)
+
+// Option configures a Conn.
+type Option func(*options)
+
+type options struct {
+	handler          Handler
+	heartbeat        time.Duration
+	heartbeatTimeout time.Duration
+	readLimit        int64
+	logger           *slog.Logger
+	dial             websocket.DialOptions
+	accept           websocket.AcceptOptions
+}
+
+func newOptions(opts []Option) *options {
+	o := &options{
+		heartbeat:        defaultHeartbeat,
+		heartbeatTimeout: defaultHeartbeatTimeout,
+		readLimit:        defaultReadLimit,
+		logger:           slog.Default(),
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// WithHandler sets the handler for requests initiated by the peer. Without a
+// handler every request is answered with "method not found".
+func WithHandler(h Handler) Option {
+	return func(o *options) { o.handler = h }
+}
+
+// WithHeartbeat sets the ping interval and how long to wait for the pong before
+// the connection is closed. A zero interval disables heartbeats.
+func WithHeartbeat(interval, timeout time.Duration) Option {
+	return func(o *options) {
+		o.heartbeat = interval
+		if timeout > 0 {
+			o.heartbeatTimeout = timeout
+		}
+	}
+}
+
+// WithReadLimit caps the size of a single incoming message (default 10 MiB).
+func WithReadLimit(n int64) Option {
+	return func(o *options) {
+		if n > 0 {
+			o.readLimit = n
+		}
+	}
+}
+
+// WithLogger sets the logger used for connection diagnostics.
+func WithLogger(logger *slog.Logger) Option {
+	return func(o *options) {
+		if logger != nil {
+			o.logger = logger
+		}
+	}
+}
+
+// frame is a JSON-RPC 2.0 request, notification or response.
+type frame struct {
+	JSONRPC string            `json:"jsonrpc"`
+	ID      json.RawMessage   `json:"id,omitempty"`
+	Method  string            `json:"method,omitempty"`
+	Params  json.RawMessage   `json:"params,omitempty"`
+	Result  json.RawMessage   `json:"result,omitempty"`
+	Error   *anp_server.Error `json:"error,omitempty"`
+}
+
+// Conn is an established WebSocket conversation with a peer agent. It is safe for
+// concurrent use.
+type Conn struct {
+	ws      *websocket.Conn
+	peerDID string
+	opts    *options
+	logger  *slog.Logger
+
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	nextID  atomic.Uint64
+	mu      sync.Mutex
+	pending map[string]chan *frame
+
+	closeOnce sync.Once
+	err       error
+}
+
+func newConn(ctx context.Context, ws *websocket.Conn, peerDID string, o *options) *Conn {
+	ws.SetReadLimit(o.readLimit)
+	ctx, cancel := context.WithCancel(ctx)
+	c := &Conn{
+		ws:      ws,
+		peerDID: peerDID,
+		opts:    o,
+		logger:  o.logger.With("peer", peerDID),
+		ctx:     ctx,
+		cancel:  cancel,
+		pending: make(map[string]chan *frame),
+	}
+	go c.readLoop()
+	if o.heartbeat > 0 {
+		go c.heartbeatLoop()
+	}
+	return c
+}
+
+// PeerDID returns the authenticated DID of the remote agent. It is empty on the
+// dialing side, which authenticates itself but not the server.
+func (c *Conn) PeerDID() string { return c.peerDID }
+
+// Done is closed when the connection terminates.
+func (c *Conn) Done() <-chan struct{} { return c.ctx.Done() }
+
+// Err returns why the connection terminated, or nil while it is open.
+func (c *Conn) Err() error {
+	select {
+	case <-c.ctx.Done():
+		return c.err
+	default:
+		return nil
+	}
+}
+
+// Close ends the conversation with a normal closure.
+func (c *Conn) Close() error {
+	c.shutdown(ErrClosed, websocket.StatusNormalClosure, "")
+	return nil
+}
+
+// Call sends a request and decodes the peer's result into result, which may be nil.
+func (c *Conn) Call(ctx context.Context, method string, params, result any) error {
+	id := json.RawMessage(strconv.Quote(strconv.FormatUint(c.nextID.Add(1), 10)))
+	reply := make(chan *frame, 1)
+
+	c.mu.Lock()
+	if c.pending == nil {
+		c.mu.Unlock()
+		return c.closedErr()
+	}
+	c.pending[string(id)] = reply
+	c.mu.Unlock()
+	defer func() {
+		c.mu.Lock()
+		delete(c.pending, string(id))
+		c.mu.Unlock()
+	}()
+
+	if err := c.send(ctx, &frame{ID: id, Method: method}, params); err != nil {
+		return err
+	}
+
+	select {
+	case resp := <-reply:
+		if resp.Error != nil {
+			return resp.Error
+		}
+		if result == nil || len(resp.Result) == 0 {
+			return nil
+		}
+		if err := sonic.Unmarshal(resp.Result, result); err != nil {
+			return fmt.Errorf("decode result: %w", err)
+		}
+		return nil
+	case <-c.ctx.Done():
+		return c.closedErr()
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Notify sends a request that expects no response.
+func (c *Conn) Notify(ctx context.Context, method string, params any) error {
+	return c.send(ctx, &frame{Method: method}, params)
+}
+
+func (c *Conn) send(ctx context.Context, f *frame, params any) error {
+	f.JSONRPC = "2.0"
+	if params != nil {
+		encoded, err := sonic.Marshal(params)
+		if err != nil {
+			return fmt.Errorf("encode params: %w", err)
+		}
+		f.Params = encoded
+	}
+	return c.write(ctx, f)
+}
+
+func (c *Conn) write(ctx context.Context, f *frame) error {
+	data, err := sonic.Marshal(f)
+	if err != nil {
+		return fmt.Errorf("encode frame: %w", err)
+	}
+	if err := c.ws.Write(ctx, websocket.MessageText, data); err != nil {
+		if c.Err() != nil {
+			return c.closedErr()
+		}
+		return fmt.Errorf("write frame: %w", err)
+	}
+	return nil
+}
+
+func (c *Conn) readLoop() {
+	for {
+		_, data, err := c.ws.Read(c.ctx)
+		if err != nil {
+			cause := ErrClosed
+			if status := websocket.CloseStatus(err); status != websocket.StatusNormalClosure && status != websocket.StatusGoingAway && c.ctx.Err() == nil {
+				cause = fmt.Errorf("%w: %v", ErrClosed, err)
+			}
+			c.shutdown(cause, websocket.StatusInternalError, "read failed")
+			return
+		}
+
+		var f frame
+		if err := sonic.Unmarshal(data, &f); err != nil || f.JSONRPC != "2.0" {
+			c.logger.Debug("dropping malformed frame", "error", err)
+			continue
+		}
+
+		switch {
+		case f.Method != "":
+			go c.handle(&f)
+		case len(f.ID) > 0:
+			c.mu.Lock()
+			reply, ok := c.pending[string(f.ID)]
+			c.mu.Unlock()
+			if ok {
+				// reply is buffered and each ID is answered at most once.
+				select {
+				case reply <- &f:
+				default:
+				}
+			} else {
+				c.logger.Debug("dropping response for unknown request", "id", string(f.ID))
+			}
+		}
+	}
+}
+
+func (c *Conn) handle(f *frame) {
+	req := &Request{Method: f.Method, Params: f.Params, Notification: len(f.ID) == 0, Conn: c}
+
+	var (
+		result any
+		err    error
+	)
+	if c.opts.handler == nil {
+		err = anp_server.NewError(anp_server.CodeMethodNotFound, fmt.Sprintf("method %s not found", f.Method))
+	} else {
+		result, err = c.opts.handler(c.ctx, req)
+	}
+	if req.Notification {
+		if err != nil {
+			c.logger.Debug("notification handler failed", "method", f.Method, "error", err)
+		}
+		return
+	}
+
+	resp := &frame{JSONRPC: "2.0", ID: f.ID}
+	if err != nil {
+		var rpcErr *anp_server.Error
+		if !errors.As(err, &rpcErr) {
+			rpcErr = anp_server.NewError(anp_server.CodeServerError, err.Error())
+		}
+		resp.Error = rpcErr
+	} else if resp.Result, err = sonic.Marshal(result); err != nil {
+		resp.Result = nil
+		resp.Error = anp_server.NewError(anp_server.CodeInternalError, "encode result")
+	}
+
+	if err := c.write(c.ctx, resp); err != nil {
+		c.logger.Debug("write response failed", "method", f.Method, "error", err)
+	}
+}
+
+func (c *Conn) heartbeatLoop() {
+	ticker := time.NewTicker(c.opts.heartbeat)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-c.ctx.Done():
+			return
+		case <-ticker.C:
+			ctx, cancel := context.WithTimeout(c.ctx, c.opts.heartbeatTimeout)
+			err := c.ws.Ping(ctx)
+			cancel()
+			if err != nil && c.ctx.Err() == nil {
+				c.logger.Warn("heartbeat failed, closing connection", "error", err)
+				c.shutdown(ErrHeartbeatTimeout, websocket.StatusGoingAway, "heartbeat timeout")
+				return
+			}
+		}
+	}
+}
+
+// shutdown records cause, closes the socket and releases pending calls exactly once.
+func (c *Conn) shutdown(cause error, status websocket.StatusCode, reason string) {
+	c.closeOnce.Do(func() {
+		c.err = cause
+		c.ws.Close(status, reason)
+		c.cancel()
+
+		c.mu.Lock()
+		c.pending = nil
+		c.mu.Unlock()
+	})
+}
+
+func (c *Conn) closedErr() error {
+	if c.err != nil {
+		return c.err
+	}
+	return ErrClosed
+}
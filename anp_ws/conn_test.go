@@ -0,0 +1,142 @@
+package anp_ws
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/openanp/anp-go/anp_server"
+	"github.com/openanp/anp-go/anptest"
+)
+
+func wsURL(srv *httptest.Server) string {
+	return "ws" + strings.TrimPrefix(srv.URL, "http")
+}
+
+func TestConn_AuthenticatedConversation(t *testing.T) {
+	caller := anptest.NewIdentity(t, "client.example.com")
+	connected := make(chan *Conn, 1)
+
+	handler := NewHandler(anptest.NewVerifier(t, caller), func(ctx context.Context, conn *Conn) {
+		connected <- conn
+	}, WithHandler(func(ctx context.Context, req *Request) (any, error) {
+		switch req.Method {
+		case "echo":
+			var p struct {
+				Text string `json:"text"`
+			}
+			if err := req.Bind(&p); err != nil {
+				return nil, err
+			}
+			return map[string]string{"text": p.Text, "from": req.Conn.PeerDID()}, nil
+		case "strict":
+			return nil, anp_server.InvalidParams("nope")
+		}
+		return nil, anp_server.NewError(anp_server.CodeMethodNotFound, "unknown")
+	}))
+	srv := httptest.NewServer(handler)
+	defer srv.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	client, err := Dial(ctx, wsURL(srv), caller.Authenticator, WithHandler(func(ctx context.Context, req *Request) (any, error) {
+		return "client:" + req.Method, nil
+	}))
+	if err != nil {
+		t.Fatalf("Dial() error = %v", err)
+	}
+	defer client.Close()
+
+	var got map[string]string
+	if err := client.Call(ctx, "echo", map[string]string{"text": "hi"}, &got); err != nil {
+		t.Fatalf("Call() error = %v", err)
+	}
+	if got["text"] != "hi" || got["from"] != caller.Document.ID {
+		t.Errorf("Call() = %v", got)
+	}
+
+	var rpcErr *anp_server.Error
+	if err := client.Call(ctx, "strict", nil, nil); !errors.As(err, &rpcErr) || rpcErr.Code != anp_server.CodeInvalidParams {
+		t.Errorf("Call(strict) error = %v, want invalid params", err)
+	}
+
+	// The server can call back over the same connection.
+	server := <-connected
+	if server.PeerDID() != caller.Document.ID {
+		t.Errorf("PeerDID() = %q, want %q", server.PeerDID(), caller.Document.ID)
+	}
+	var reply string
+	if err := server.Call(ctx, "ping", nil, &reply); err != nil || reply != "client:ping" {
+		t.Errorf("server Call() = %q, %v", reply, err)
+	}
+
+	// Closing on one side terminates the other.
+	server.Close()
+	select {
+	case <-client.Done():
+	case <-ctx.Done():
+		t.Fatal("client did not observe server close")
+	}
+	if err := client.Call(ctx, "echo", nil, nil); !errors.Is(err, ErrClosed) {
+		t.Errorf("Call() after close error = %v, want ErrClosed", err)
+	}
+}
+
+func TestDial_RejectsUnknownCaller(t *testing.T) {
+	known := anptest.NewIdentity(t, "client.example.com")
+	stranger := anptest.NewIdentity(t, "stranger.example.com")
+
+	srv := httptest.NewServer(NewHandler(anptest.NewVerifier(t, known), nil))
+	defer srv.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if _, err := Dial(ctx, wsURL(srv), stranger.Authenticator); err == nil {
+		t.Fatal("Dial() expected error for unknown caller")
+	}
+	if _, err := Dial(ctx, wsURL(srv), nil); !isUnauthorized(err) {
+		t.Errorf("Dial() without auth error = %v, want 401", err)
+	}
+}
+
+func TestConn_Notify(t *testing.T) {
+	received := make(chan string, 1)
+	srv := httptest.NewServer(NewHandler(nil, nil, WithHandler(func(ctx context.Context, req *Request) (any, error) {
+		if req.Notification {
+			received <- req.Method
+		}
+		return nil, nil
+	}), WithHeartbeat(20*time.Millisecond, time.Second)))
+	defer srv.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	client, err := Dial(ctx, wsURL(srv), nil, WithHeartbeat(20*time.Millisecond, time.Second))
+	if err != nil {
+		t.Fatalf("Dial() error = %v", err)
+	}
+	defer client.Close()
+
+	// Let a few heartbeats pass; the connection must stay healthy.
+	time.Sleep(100 * time.Millisecond)
+	if err := client.Notify(ctx, "status.update", map[string]int{"progress": 50}); err != nil {
+		t.Fatalf("Notify() error = %v", err)
+	}
+	select {
+	case method := <-received:
+		if method != "status.update" {
+			t.Errorf("received %q", method)
+		}
+	case <-ctx.Done():
+		t.Fatal("notification not delivered")
+	}
+	if err := client.Err(); err != nil {
+		t.Errorf("Err() = %v, want nil", err)
+	}
+}
@@ -0,0 +1,113 @@
+package anp_ws
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/coder/websocket"
+	"github.com/openanp/anp-go/anp_auth"
+)
+
+// WithHTTPClient sets the client used for the upgrade request when dialing.
+func WithHTTPClient(client *http.Client) Option {
+	return func(o *options) { o.dial.HTTPClient = client }
+}
+
+// WithOriginPatterns allows cross-origin upgrades from the given host patterns
+// when accepting. Agents do not send Origin, so this only matters for browsers.
+func WithOriginPatterns(patterns ...string) Option {
+	return func(o *options) { o.accept.OriginPatterns = append(o.accept.OriginPatterns, patterns...) }
+}
+
+// Dial opens a conversation with the agent at target (a ws:// or wss:// URL). When
+// auth is non-nil the upgrade request carries a DID-WBA Authorization header, and a
+// bearer token returned by the server is cached for later dials.
+func Dial(ctx context.Context, target string, auth *anp_auth.Authenticator, opts ...Option) (*Conn, error) {
+	o := newOptions(opts)
+
+	ws, err := dial(ctx, target, auth, o, false)
+	if err != nil && auth != nil && isUnauthorized(err) {
+		// A cached token may have expired; retry once with a fresh DID-WBA header.
+		auth.ClearToken(target)
+		ws, err = dial(ctx, target, auth, o, true)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return newConn(context.Background(), ws, "", o), nil
+}
+
+type statusError struct {
+	code int
+}
+
+func (e *statusError) Error() string {
+	return fmt.Sprintf("anp_ws: upgrade rejected with status %d", e.code)
+}
+
+func isUnauthorized(err error) bool {
+	se, ok := err.(*statusError)
+	return ok && se.code == http.StatusUnauthorized
+}
+
+func dial(ctx context.Context, target string, auth *anp_auth.Authenticator, o *options, force bool) (*websocket.Conn, error) {
+	dialOpts := o.dial
+	dialOpts.HTTPHeader = http.Header{}
+
+	if auth != nil {
+		generate := auth.GenerateHeader
+		if force {
+			generate = auth.GenerateHeaderForce
+		}
+		headers, err := generate(target)
+		if err != nil {
+			return nil, fmt.Errorf("generate auth header: %w", err)
+		}
+		for k, v := range headers {
+			dialOpts.HTTPHeader.Set(k, v)
+		}
+	}
+
+	ws, resp, err := websocket.Dial(ctx, target, &dialOpts)
+	if err != nil {
+		if resp != nil && resp.StatusCode != http.StatusSwitchingProtocols {
+			return nil, &statusError{code: resp.StatusCode}
+		}
+		return nil, fmt.Errorf("dial %s: %w", target, err)
+	}
+	if auth != nil {
+		auth.UpdateFromResponse(target, resp.Header)
+	}
+	return ws, nil
+}
+
+// NewHandler returns an http.Handler that upgrades requests to WebSocket
+// conversations. When verifier is non-nil the upgrade request must pass DID-WBA
+// authentication and Conn.PeerDID reports the caller. serve (which may be nil) is
+// invoked once per connection; the connection stays open after it returns, until
+// either side closes it.
+func NewHandler(verifier *anp_auth.DidWbaVerifier, serve func(ctx context.Context, conn *Conn), opts ...Option) http.Handler {
+	o := newOptions(opts)
+
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		peerDID, _ := anp_auth.DIDFromContext(r.Context())
+
+		ws, err := websocket.Accept(w, r, &o.accept)
+		if err != nil {
+			o.logger.Debug("websocket upgrade failed", "remote", r.RemoteAddr, "error", err)
+			return
+		}
+
+		conn := newConn(r.Context(), ws, peerDID, o)
+		if serve != nil {
+			serve(conn.ctx, conn)
+		}
+		<-conn.Done()
+	})
+
+	if verifier == nil {
+		return h
+	}
+	return anp_auth.Middleware(verifier)(h)
+}
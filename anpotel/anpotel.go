@@ -0,0 +1,143 @@
+// Package anpotel instruments anp-go components with OpenTelemetry traces and
+// metrics.
+//
+// It is a separate module so the core SDK does not depend on OpenTelemetry.
+// Each constructor wraps an existing component and records spans and metrics
+// with the attribute names defined below:
+//
+//	client := anpotel.NewClient(anp_crawler.NewClient(auth))
+//	sess := anpotel.WrapSession(rawSession)
+//	verifier := anpotel.WrapVerifier(rawVerifier)
+//	http.Handle("/rpc", verifier.Middleware(rpcHandler))
+//
+// Providers default to the global ones registered with the otel package.
+package anpotel
+
+import (
+	"context"
+	"errors"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ScopeName is the instrumentation scope of every tracer and meter.
+const ScopeName = "github.com/openanp/anp-go/anpotel"
+
+// Attribute keys shared by spans and metrics. HTTP attributes follow the
+// OpenTelemetry semantic conventions; ANP-specific ones use the "anp." prefix.
+const (
+	AttrHTTPMethod     = attribute.Key("http.request.method")
+	AttrHTTPStatusCode = attribute.Key("http.response.status_code")
+	AttrServerAddress  = attribute.Key("server.address")
+	AttrURL            = attribute.Key("url.full")
+	AttrErrorType      = attribute.Key("error.type")
+
+	AttrDID            = attribute.Key("anp.did")
+	AttrActorDID       = attribute.Key("anp.actor_did")
+	AttrDomain         = attribute.Key("anp.domain")
+	AttrAuthScheme     = attribute.Key("anp.auth.scheme")
+	AttrAuthOutcome    = attribute.Key("anp.auth.outcome")
+	AttrAuthForced     = attribute.Key("anp.auth.forced")
+	AttrToolMethod     = attribute.Key("anp.tool.method")
+	AttrDocumentURL    = attribute.Key("anp.document.url")
+	AttrToolCount      = attribute.Key("anp.document.tools")
+	AttrInterfaceCount = attribute.Key("anp.document.interfaces")
+	AttrBatchSize      = attribute.Key("anp.batch.size")
+)
+
+// Auth outcomes recorded under AttrAuthOutcome.
+const (
+	OutcomeSuccess = "success"
+	OutcomeFailure = "failure"
+)
+
+// Option configures instrumentation.
+type Option func(*config)
+
+type config struct {
+	tracerProvider trace.TracerProvider
+	meterProvider  metric.MeterProvider
+	propagator     propagation.TextMapPropagator
+}
+
+// WithTracerProvider sets the TracerProvider (default otel.GetTracerProvider()).
+func WithTracerProvider(tp trace.TracerProvider) Option {
+	return func(c *config) {
+		if tp != nil {
+			c.tracerProvider = tp
+		}
+	}
+}
+
+// WithMeterProvider sets the MeterProvider (default otel.GetMeterProvider()).
+func WithMeterProvider(mp metric.MeterProvider) Option {
+	return func(c *config) {
+		if mp != nil {
+			c.meterProvider = mp
+		}
+	}
+}
+
+// WithPropagator sets the propagator used to inject trace context into
+// outgoing requests (default otel.GetTextMapPropagator()).
+func WithPropagator(p propagation.TextMapPropagator) Option {
+	return func(c *config) {
+		if p != nil {
+			c.propagator = p
+		}
+	}
+}
+
+func newConfig(opts []Option) *config {
+	c := &config{
+		tracerProvider: otel.GetTracerProvider(),
+		meterProvider:  otel.GetMeterProvider(),
+		propagator:     otel.GetTextMapPropagator(),
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+func (c *config) tracer() trace.Tracer { return c.tracerProvider.Tracer(ScopeName) }
+
+func (c *config) meter() metric.Meter { return c.meterProvider.Meter(ScopeName) }
+
+// durationBuckets suit network calls, from 5ms to 30s.
+var durationBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30}
+
+func (c *config) durationHistogram(name, desc string) metric.Float64Histogram {
+	h, err := c.meter().Float64Histogram(name,
+		metric.WithDescription(desc),
+		metric.WithUnit("s"),
+		metric.WithExplicitBucketBoundaries(durationBuckets...),
+	)
+	if err != nil {
+		otel.Handle(err)
+	}
+	return h
+}
+
+func (c *config) counter(name, desc string) metric.Int64Counter {
+	ctr, err := c.meter().Int64Counter(name, metric.WithDescription(desc))
+	if err != nil {
+		otel.Handle(err)
+	}
+	return ctr
+}
+
+// errorType classifies err for AttrErrorType, keeping metric cardinality low.
+func errorType(err error) string {
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		return "timeout"
+	case errors.Is(err, context.Canceled):
+		return "canceled"
+	}
+	return "_OTHER"
+}
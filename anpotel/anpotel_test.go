@@ -0,0 +1,198 @@
+package anpotel
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/openanp/anp-go/anp_crawler"
+	"github.com/openanp/anp-go/anptest"
+	"github.com/openanp/anp-go/session"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+type telemetry struct {
+	spans  *tracetest.SpanRecorder
+	reader *sdkmetric.ManualReader
+	opts   []Option
+}
+
+func newTelemetry() *telemetry {
+	spans := tracetest.NewSpanRecorder()
+	reader := sdkmetric.NewManualReader()
+	return &telemetry{
+		spans:  spans,
+		reader: reader,
+		opts: []Option{
+			WithTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(spans))),
+			WithMeterProvider(sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))),
+			WithPropagator(propagation.TraceContext{}),
+		},
+	}
+}
+
+func (tel *telemetry) span(t *testing.T, name string) sdktrace.ReadOnlySpan {
+	t.Helper()
+	for _, s := range tel.spans.Ended() {
+		if s.Name() == name {
+			return s
+		}
+	}
+	t.Fatalf("no span named %q", name)
+	return nil
+}
+
+// histogramCount returns the number of recordings in a histogram whose points
+// carry attr.
+func (tel *telemetry) histogramCount(t *testing.T, name string, attr attribute.KeyValue) uint64 {
+	t.Helper()
+	var rm metricdata.ResourceMetrics
+	if err := tel.reader.Collect(context.Background(), &rm); err != nil {
+		t.Fatalf("Collect() error = %v", err)
+	}
+	var n uint64
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			hist, ok := m.Data.(metricdata.Histogram[float64])
+			if m.Name != name || !ok {
+				continue
+			}
+			for _, dp := range hist.DataPoints {
+				if v, ok := dp.Attributes.Value(attr.Key); ok && v == attr.Value {
+					n += dp.Count
+				}
+			}
+		}
+	}
+	return n
+}
+
+func stringAttr(s sdktrace.ReadOnlySpan, key attribute.Key) string {
+	for _, kv := range s.Attributes() {
+		if kv.Key == key {
+			return kv.Value.Emit()
+		}
+	}
+	return ""
+}
+
+type addParams struct {
+	A int `json:"a"`
+	B int `json:"b"`
+}
+
+func TestSessionAndClient(t *testing.T) {
+	tel := newTelemetry()
+	caller := anptest.NewIdentity(t, "client.example.com")
+	srv := anptest.NewServer(t,
+		anptest.WithMethod("add", func(p addParams) (int, error) { return p.A + p.B, nil }),
+		anptest.WithDIDAuth(caller),
+	)
+	ctx := context.Background()
+
+	raw, err := session.New(session.Config{Authenticator: caller.Authenticator})
+	if err != nil {
+		t.Fatalf("session.New() error = %v", err)
+	}
+	sess := WrapSession(raw, tel.opts...)
+	doc, err := sess.Fetch(ctx, srv.OpenRPCURL())
+	if err != nil {
+		t.Fatalf("Fetch() error = %v", err)
+	}
+	if _, err := sess.ExecuteTool(ctx, doc, "add", map[string]any{"a": 1, "b": 2}); err != nil {
+		t.Fatalf("ExecuteTool() error = %v", err)
+	}
+	if _, err := sess.ExecuteTool(ctx, doc, "missing", nil); err == nil {
+		t.Fatal("ExecuteTool(missing) succeeded")
+	}
+
+	if got := stringAttr(tel.span(t, "ANP fetch"), AttrToolCount); got != "1" {
+		t.Errorf("fetch span %s = %q, want 1", AttrToolCount, got)
+	}
+	if got := stringAttr(tel.span(t, "ANP tool add"), AttrToolMethod); got != "add" {
+		t.Errorf("tool span %s = %q", AttrToolMethod, got)
+	}
+	if n := tel.histogramCount(t, "anp.tool.call.duration", AttrErrorType.String("_OTHER")); n != 1 {
+		t.Errorf("failed tool calls = %d, want 1", n)
+	}
+
+	client := NewClient(anp_crawler.NewClient(caller.Authenticator), tel.opts...)
+	resp, err := client.Fetch(ctx, http.MethodGet, srv.ADURL(), nil, nil)
+	if err != nil || resp.StatusCode != http.StatusOK {
+		t.Fatalf("Fetch() = %v, %v", resp, err)
+	}
+	requests := srv.Requests()
+	last := requests[len(requests)-1]
+	span := tel.span(t, "ANP GET")
+	if want := span.SpanContext().TraceID().String(); len(last.Header.Get("Traceparent")) < 35 || last.Header.Get("Traceparent")[3:35] != want {
+		t.Errorf("traceparent = %q, want trace %s", last.Header.Get("Traceparent"), want)
+	}
+	if n := tel.histogramCount(t, "anp.client.request.duration", AttrHTTPStatusCode.Int(http.StatusOK)); n != 1 {
+		t.Errorf("client requests = %d, want 1", n)
+	}
+}
+
+func TestVerifierMiddlewareAndTransport(t *testing.T) {
+	tel := newTelemetry()
+	caller := anptest.NewIdentity(t, "client.example.com")
+	verifier := WrapVerifier(anptest.NewVerifier(t, caller), tel.opts...)
+
+	srv := httptest.NewServer(verifier.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})))
+	defer srv.Close()
+
+	auth := WrapAuthenticator(caller.Authenticator, tel.opts...)
+	client := &http.Client{Transport: auth.Transport(nil)}
+	resp, err := client.Get(srv.URL + "/rpc")
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNoContent {
+		t.Fatalf("status = %d", resp.StatusCode)
+	}
+
+	resp, err = http.Get(srv.URL + "/rpc")
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	resp.Body.Close()
+
+	clientSpan := tel.span(t, "ANP GET")
+	var serverSpans []sdktrace.ReadOnlySpan
+	for _, s := range tel.spans.Ended() {
+		if s.Name() == "ANP GET /rpc" {
+			serverSpans = append(serverSpans, s)
+		}
+	}
+	if len(serverSpans) != 2 {
+		t.Fatalf("server spans = %d, want 2", len(serverSpans))
+	}
+	if serverSpans[0].SpanContext().TraceID() != clientSpan.SpanContext().TraceID() {
+		t.Error("server span does not continue the client trace")
+	}
+	if got := stringAttr(serverSpans[0], AttrDID); got != caller.Document.ID {
+		t.Errorf("server span %s = %q, want %q", AttrDID, got, caller.Document.ID)
+	}
+
+	if n := tel.histogramCount(t, "anp.auth.verification.duration", AttrAuthOutcome.String(OutcomeSuccess)); n != 1 {
+		t.Errorf("successful verifications = %d, want 1", n)
+	}
+	if n := tel.histogramCount(t, "anp.auth.verification.duration", AttrAuthOutcome.String(OutcomeFailure)); n != 1 {
+		t.Errorf("failed verifications = %d, want 1", n)
+	}
+
+	if _, err := auth.GenerateHeaderContext(context.Background(), srv.URL); err != nil {
+		t.Fatalf("GenerateHeaderContext() error = %v", err)
+	}
+	if got := stringAttr(tel.span(t, "ANP auth header"), AttrAuthScheme); got != "bearer" {
+		t.Errorf("auth header scheme = %q, want bearer after token exchange", got)
+	}
+}
@@ -0,0 +1,225 @@
+package anpotel
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/openanp/anp-go/anp_auth"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Authenticator wraps an anp_auth.Authenticator, tracing header generation.
+type Authenticator struct {
+	*anp_auth.Authenticator
+
+	tracer     trace.Tracer
+	propagator propagation.TextMapPropagator
+	headers    metric.Int64Counter
+}
+
+// WrapAuthenticator instruments a. Each generated header is counted in
+// anp.auth.headers, labelled with the scheme used.
+func WrapAuthenticator(a *anp_auth.Authenticator, opts ...Option) *Authenticator {
+	cfg := newConfig(opts)
+	return &Authenticator{
+		Authenticator: a,
+		tracer:        cfg.tracer(),
+		propagator:    cfg.propagator,
+		headers:       cfg.counter("anp.auth.headers", "Authorization headers produced by the authenticator."),
+	}
+}
+
+// GenerateHeaderContext is GenerateHeader inside an "ANP auth header" span.
+func (a *Authenticator) GenerateHeaderContext(ctx context.Context, target string) (map[string]string, error) {
+	return a.generate(ctx, target, false)
+}
+
+// GenerateHeaderForceContext is GenerateHeaderForce inside an "ANP auth header" span.
+func (a *Authenticator) GenerateHeaderForceContext(ctx context.Context, target string) (map[string]string, error) {
+	return a.generate(ctx, target, true)
+}
+
+func (a *Authenticator) generate(ctx context.Context, target string, force bool) (map[string]string, error) {
+	attrs := []attribute.KeyValue{AttrDomain.String(hostname(target)), AttrAuthForced.Bool(force)}
+	ctx, span := a.tracer.Start(ctx, "ANP auth header", trace.WithAttributes(attrs...))
+	defer span.End()
+
+	var (
+		header map[string]string
+		err    error
+	)
+	if force {
+		header, err = a.Authenticator.GenerateHeaderForce(target)
+	} else {
+		header, err = a.Authenticator.GenerateHeader(target)
+	}
+	if err != nil {
+		a.headers.Add(ctx, 1, metric.WithAttributes(append(outcome(span, err), attrs...)...))
+		return nil, err
+	}
+
+	scheme := authScheme(header[anp_auth.AuthorizationHeader])
+	span.SetAttributes(AttrAuthScheme.String(scheme))
+	a.headers.Add(ctx, 1, metric.WithAttributes(append(attrs, AttrAuthScheme.String(scheme))...))
+	return header, nil
+}
+
+// Transport returns an http.RoundTripper that authenticates requests like
+// anp_auth.Transport, wrapping each request in a client span whose context is
+// propagated to the server.
+func (a *Authenticator) Transport(base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &transport{auth: a, next: &anp_auth.Transport{Base: base, Authenticator: a.Authenticator}}
+}
+
+type transport struct {
+	auth *Authenticator
+	next http.RoundTripper
+}
+
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx, span := t.auth.tracer.Start(req.Context(), "ANP "+req.Method,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			AttrHTTPMethod.String(req.Method),
+			AttrURL.String(req.URL.String()),
+			AttrServerAddress.String(req.URL.Hostname()),
+		),
+	)
+	defer span.End()
+
+	req = req.Clone(ctx)
+	t.auth.propagator.Inject(ctx, propagation.HeaderCarrier(req.Header))
+
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		outcome(span, err)
+		return nil, err
+	}
+	span.SetAttributes(AttrHTTPStatusCode.Int(resp.StatusCode))
+	if resp.StatusCode >= http.StatusBadRequest {
+		span.SetStatus(codes.Error, http.StatusText(resp.StatusCode))
+	}
+	return resp, nil
+}
+
+// Verifier wraps an anp_auth.DidWbaVerifier, tracing verifications and
+// recording them in the anp.auth.verification.duration histogram.
+type Verifier struct {
+	*anp_auth.DidWbaVerifier
+
+	tracer     trace.Tracer
+	propagator propagation.TextMapPropagator
+	duration   metric.Float64Histogram
+}
+
+// WrapVerifier instruments v.
+func WrapVerifier(v *anp_auth.DidWbaVerifier, opts ...Option) *Verifier {
+	cfg := newConfig(opts)
+	return &Verifier{
+		DidWbaVerifier: v,
+		tracer:         cfg.tracer(),
+		propagator:     cfg.propagator,
+		duration:       cfg.durationHistogram("anp.auth.verification.duration", "Duration of DID-WBA authorization checks."),
+	}
+}
+
+// VerifyAuthHeaderContext verifies an Authorization header inside an
+// "ANP verify" span.
+func (v *Verifier) VerifyAuthHeaderContext(ctx context.Context, authorization, domain string) (map[string]any, error) {
+	ctx, span := v.tracer.Start(ctx, "ANP verify", trace.WithAttributes(AttrDomain.String(domain)))
+	defer span.End()
+
+	start := time.Now()
+	result, err := v.DidWbaVerifier.VerifyAuthHeaderContext(ctx, authorization, domain)
+	attrs := []attribute.KeyValue{AttrAuthScheme.String(authScheme(authorization))}
+	if err != nil {
+		attrs = append(attrs, AttrAuthOutcome.String(OutcomeFailure))
+		outcome(span, err)
+	} else {
+		attrs = append(attrs, AttrAuthOutcome.String(OutcomeSuccess))
+		if did, ok := result["did"].(string); ok {
+			span.SetAttributes(AttrDID.String(did))
+		}
+		if actor, ok := result["actor_did"].(string); ok && actor != "" {
+			span.SetAttributes(AttrActorDID.String(actor))
+		}
+	}
+	span.SetAttributes(attrs...)
+	v.duration.Record(ctx, time.Since(start).Seconds(), metric.WithAttributes(attrs...))
+	return result, err
+}
+
+// Middleware behaves like anp_auth.Middleware and additionally starts a server
+// span for each request, continuing the caller's trace, plus an "ANP verify"
+// child span that ends once authentication has succeeded or failed.
+func (v *Verifier) Middleware(next http.Handler) http.Handler {
+	authed := anp_auth.Middleware(v.DidWbaVerifier)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := v.propagator.Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+		ctx, server := v.tracer.Start(ctx, "ANP "+r.Method+" "+r.URL.Path,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(AttrHTTPMethod.String(r.Method), AttrURL.String(r.URL.String())),
+		)
+		defer server.End()
+
+		scheme := AttrAuthScheme.String(authScheme(r.Header.Get(anp_auth.AuthorizationHeader)))
+		verifyCtx, verify := v.tracer.Start(ctx, "ANP verify", trace.WithAttributes(AttrDomain.String(hostname("//"+r.Host)), scheme))
+		start := time.Now()
+		passed := false
+
+		authed(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			passed = true
+			attrs := []attribute.KeyValue{scheme, AttrAuthOutcome.String(OutcomeSuccess)}
+			v.duration.Record(verifyCtx, time.Since(start).Seconds(), metric.WithAttributes(attrs...))
+			verify.SetAttributes(attrs...)
+			if did, ok := anp_auth.DIDFromContext(r.Context()); ok {
+				verify.SetAttributes(AttrDID.String(did))
+				server.SetAttributes(AttrDID.String(did))
+			}
+			if actor, ok := anp_auth.ActorDIDFromContext(r.Context()); ok {
+				server.SetAttributes(AttrActorDID.String(actor))
+			}
+			verify.End()
+			next.ServeHTTP(w, r)
+		})).ServeHTTP(w, r.WithContext(ctx))
+
+		if !passed {
+			attrs := []attribute.KeyValue{scheme, AttrAuthOutcome.String(OutcomeFailure)}
+			v.duration.Record(verifyCtx, time.Since(start).Seconds(), metric.WithAttributes(attrs...))
+			verify.SetAttributes(attrs...)
+			verify.SetStatus(codes.Error, "authentication failed")
+			verify.End()
+			server.SetStatus(codes.Error, "authentication failed")
+		}
+	})
+}
+
+// authScheme returns the scheme of an Authorization header value.
+func authScheme(authorization string) string {
+	switch {
+	case authorization == "":
+		return "none"
+	case strings.HasPrefix(authorization, anp_auth.BearerScheme):
+		return "bearer"
+	case strings.HasPrefix(authorization, anp_auth.DIDWbaScheme):
+		return "didwba"
+	}
+	return "unknown"
+}
+
+func hostname(target string) string {
+	if u, err := url.Parse(target); err == nil {
+		return u.Hostname()
+	}
+	return ""
+}
@@ -0,0 +1,76 @@
+package anpotel
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/openanp/anp-go/anp_crawler"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type client struct {
+	next       anp_crawler.Client
+	tracer     trace.Tracer
+	propagator propagation.TextMapPropagator
+	duration   metric.Float64Histogram
+}
+
+// NewClient wraps an anp_crawler.Client. Every Fetch becomes a client span,
+// carries the trace context in its request headers and is recorded in the
+// anp.client.request.duration histogram.
+func NewClient(next anp_crawler.Client, opts ...Option) anp_crawler.Client {
+	cfg := newConfig(opts)
+	return &client{
+		next:       next,
+		tracer:     cfg.tracer(),
+		propagator: cfg.propagator,
+		duration:   cfg.durationHistogram("anp.client.request.duration", "Duration of ANP client requests."),
+	}
+}
+
+func (c *client) Fetch(ctx context.Context, method, target string, headers map[string]string, body any) (*anp_crawler.Response, error) {
+	if method == "" {
+		method = http.MethodGet
+	}
+	attrs := []attribute.KeyValue{AttrHTTPMethod.String(method)}
+	if u, err := url.Parse(target); err == nil {
+		attrs = append(attrs, AttrServerAddress.String(u.Hostname()))
+	}
+
+	ctx, span := c.tracer.Start(ctx, "ANP "+method,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(append(attrs, AttrURL.String(target))...),
+	)
+	defer span.End()
+
+	carrier := propagation.MapCarrier{}
+	for k, v := range headers {
+		carrier[k] = v
+	}
+	c.propagator.Inject(ctx, carrier)
+
+	start := time.Now()
+	resp, err := c.next.Fetch(ctx, method, target, carrier, body)
+	switch {
+	case err != nil:
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		attrs = append(attrs, AttrErrorType.String(errorType(err)))
+	default:
+		span.SetAttributes(AttrHTTPStatusCode.Int(resp.StatusCode))
+		attrs = append(attrs, AttrHTTPStatusCode.Int(resp.StatusCode))
+		if resp.StatusCode >= http.StatusBadRequest {
+			span.SetStatus(codes.Error, http.StatusText(resp.StatusCode))
+			attrs = append(attrs, AttrErrorType.String(fmt.Sprint(resp.StatusCode)))
+		}
+	}
+	c.duration.Record(ctx, time.Since(start).Seconds(), metric.WithAttributes(attrs...))
+	return resp, err
+}
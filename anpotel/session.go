@@ -0,0 +1,110 @@
+package anpotel
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/openanp/anp-go/anp_crawler"
+	"github.com/openanp/anp-go/session"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Session wraps a session.Session, tracing document fetches and tool calls.
+type Session struct {
+	*session.Session
+
+	tracer        trace.Tracer
+	fetchDuration metric.Float64Histogram
+	toolDuration  metric.Float64Histogram
+}
+
+// WrapSession instruments s. Methods not overridden here are promoted from the
+// embedded session unchanged.
+func WrapSession(s *session.Session, opts ...Option) *Session {
+	cfg := newConfig(opts)
+	return &Session{
+		Session:       s,
+		tracer:        cfg.tracer(),
+		fetchDuration: cfg.durationHistogram("anp.session.fetch.duration", "Duration of fetching and parsing ANP documents."),
+		toolDuration:  cfg.durationHistogram("anp.tool.call.duration", "Duration of ANP tool calls."),
+	}
+}
+
+// Fetch retrieves and parses a single document inside an "ANP fetch" span.
+func (s *Session) Fetch(ctx context.Context, url string) (*session.Document, error) {
+	ctx, span := s.tracer.Start(ctx, "ANP fetch", trace.WithAttributes(AttrDocumentURL.String(url)))
+	defer span.End()
+
+	start := time.Now()
+	doc, err := s.Session.Fetch(ctx, url)
+	attrs := outcome(span, err)
+	if err == nil {
+		span.SetAttributes(
+			AttrToolCount.Int(len(doc.Tools)),
+			AttrInterfaceCount.Int(len(doc.Interfaces)),
+		)
+	}
+	s.fetchDuration.Record(ctx, time.Since(start).Seconds(), metric.WithAttributes(attrs...))
+	return doc, err
+}
+
+// FetchBatch fetches documents concurrently under one "ANP fetch batch" span.
+// Individual fetches are not traced separately.
+func (s *Session) FetchBatch(ctx context.Context, urls []string) ([]*session.Document, error) {
+	ctx, span := s.tracer.Start(ctx, "ANP fetch batch", trace.WithAttributes(AttrBatchSize.Int(len(urls))))
+	defer span.End()
+
+	docs, err := s.Session.FetchBatch(ctx, urls)
+	outcome(span, err)
+	return docs, err
+}
+
+// Invoke performs an authenticated request inside a client span.
+func (s *Session) Invoke(ctx context.Context, method, target string, headers map[string]string, body any) (*anp_crawler.Response, error) {
+	if method == "" {
+		method = http.MethodGet
+	}
+	ctx, span := s.tracer.Start(ctx, "ANP "+method,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(AttrHTTPMethod.String(method), AttrURL.String(target)),
+	)
+	defer span.End()
+
+	resp, err := s.Session.Invoke(ctx, method, target, headers, body)
+	outcome(span, err)
+	if err == nil {
+		span.SetAttributes(AttrHTTPStatusCode.Int(resp.StatusCode))
+	}
+	return resp, err
+}
+
+// ExecuteTool runs session.ExecuteTool inside an "ANP tool <method>" span and
+// records its duration in anp.tool.call.duration.
+func (s *Session) ExecuteTool(ctx context.Context, doc *session.Document, method string, params map[string]any) (map[string]any, error) {
+	ctx, span := s.tracer.Start(ctx, "ANP tool "+method,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(AttrToolMethod.String(method), AttrDocumentURL.String(doc.URL)),
+	)
+	defer span.End()
+
+	start := time.Now()
+	result, err := session.ExecuteTool(ctx, doc, method, params)
+	attrs := append(outcome(span, err), AttrToolMethod.String(method))
+	s.toolDuration.Record(ctx, time.Since(start).Seconds(), metric.WithAttributes(attrs...))
+	return result, err
+}
+
+// outcome marks span as failed when err is set and returns the matching
+// metric attributes.
+func outcome(span trace.Span, err error) []attribute.KeyValue {
+	if err == nil {
+		return nil
+	}
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+	return []attribute.KeyValue{AttrErrorType.String(errorType(err))}
+}
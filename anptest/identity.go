@@ -0,0 +1,46 @@
+package anptest
+
+import (
+	"crypto/ecdsa"
+	"testing"
+
+	"github.com/openanp/anp-go/anp_auth"
+)
+
+// Identity is a freshly generated DID with an Authenticator that signs as it.
+type Identity struct {
+	Document      *anp_auth.DIDWBADocument
+	PrivateKey    *ecdsa.PrivateKey
+	Authenticator *anp_auth.Authenticator
+}
+
+// NewIdentity creates a caller identity for hostname (e.g. "client.example.com").
+// The DID is never resolved over the network; pass the identity to WithDIDAuth.
+func NewIdentity(t testing.TB, hostname string) *Identity {
+	t.Helper()
+
+	doc, key, err := anp_auth.CreateDIDWBADocument(hostname, nil, nil, nil)
+	if err != nil {
+		t.Fatalf("anptest: create identity: %v", err)
+	}
+	auth, err := anp_auth.NewAuthenticator(anp_auth.WithDIDMaterial(doc, key))
+	if err != nil {
+		t.Fatalf("anptest: create authenticator: %v", err)
+	}
+	return &Identity{Document: doc, PrivateKey: key, Authenticator: auth}
+}
+
+// NewVerifier returns a DID-WBA verifier that authenticates only the given
+// identities, configured as a Server with WithDIDAuth. Use it to test handlers
+// that take a verifier directly.
+func NewVerifier(t testing.TB, callers ...*Identity) *anp_auth.DidWbaVerifier {
+	t.Helper()
+
+	docs := make(map[string]*anp_auth.DIDWBADocument)
+	addCallers(t, docs, callers)
+	verifier, err := newVerifier(docs)
+	if err != nil {
+		t.Fatalf("anptest: %v", err)
+	}
+	return verifier
+}
@@ -0,0 +1,324 @@
+// Package anptest provides an in-process ANP agent for integration tests.
+//
+// A Server publishes a signed ad.json, an OpenRPC document and a JSON-RPC endpoint
+// backed by anp_server, and can require DID-WBA authentication from callers:
+//
+//	caller := anptest.NewIdentity(t, "client.example.com")
+//	srv := anptest.NewServer(t,
+//		anptest.WithMethod("echo", func(p EchoParams) (EchoParams, error) { return p, nil }),
+//		anptest.WithDIDAuth(caller),
+//	)
+//	sess, _ := session.New(session.Config{Authenticator: caller.Authenticator})
+//	doc, _ := sess.Fetch(ctx, srv.ADURL())
+package anptest
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/rsa"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bytedance/sonic"
+	"github.com/openanp/anp-go/anp_ad"
+	"github.com/openanp/anp-go/anp_auth"
+	"github.com/openanp/anp-go/anp_server"
+	"github.com/openanp/anp-go/openrpc"
+)
+
+// Paths served by a Server.
+const (
+	PathAgentDescription = "/ad.json"
+	PathOpenRPC          = "/openrpc.json"
+	PathRPC              = "/rpc"
+	PathDIDDocument      = "/.well-known/did.json"
+)
+
+// Request is a request observed by a Server.
+type Request struct {
+	Method string
+	Path   string
+	// DID is the authenticated caller, empty when DID auth is disabled.
+	DID    string
+	Header http.Header
+}
+
+// Option configures a Server.
+type Option func(*Server)
+
+// WithName sets the agent name published in ad.json (default "Test Agent").
+func WithName(name string) Option {
+	return func(s *Server) { s.name = name }
+}
+
+// WithDescription sets the agent description published in ad.json.
+func WithDescription(description string) Option {
+	return func(s *Server) { s.description = description }
+}
+
+// WithMethod registers a JSON-RPC method; see anp_server.Server.Register.
+func WithMethod(name string, fn any, opts ...openrpc.MethodOption) Option {
+	return func(s *Server) {
+		if err := s.RPC.Register(name, fn, opts...); err != nil {
+			s.t.Fatalf("anptest: register %s: %v", name, err)
+		}
+	}
+}
+
+// WithService registers every handler-shaped method of receiver.
+func WithService(receiver any) Option {
+	return func(s *Server) {
+		if err := s.RPC.RegisterService(receiver); err != nil {
+			s.t.Fatalf("anptest: register service: %v", err)
+		}
+	}
+}
+
+// WithDocument serves body at path, replacing the generated document if path is
+// PathAgentDescription or PathOpenRPC. A []byte or string body is served verbatim;
+// any other value is encoded as JSON.
+func WithDocument(path string, body any) Option {
+	return func(s *Server) {
+		var raw []byte
+		switch v := body.(type) {
+		case []byte:
+			raw = v
+		case string:
+			raw = []byte(v)
+		default:
+			encoded, err := sonic.Marshal(v)
+			if err != nil {
+				s.t.Fatalf("anptest: encode document %s: %v", path, err)
+			}
+			raw = encoded
+		}
+		s.documents[path] = raw
+	}
+}
+
+// WithDIDAuth requires DID-WBA authentication on every path except
+// PathDIDDocument. Only the given identities can authenticate.
+func WithDIDAuth(callers ...*Identity) Option {
+	return func(s *Server) {
+		s.requireAuth = true
+		addCallers(s.t, s.callers, callers)
+	}
+}
+
+// WithTLS serves over HTTPS; use Server.Client for a client trusting its certificate.
+func WithTLS() Option {
+	return func(s *Server) { s.tls = true }
+}
+
+// Server is an in-process ANP agent backed by httptest.Server.
+type Server struct {
+	*httptest.Server
+
+	// RPC is the JSON-RPC server; methods may also be registered after start.
+	RPC *anp_server.Server
+	// DIDDocument and PrivateKey are the agent's own identity, used to sign ad.json.
+	DIDDocument *anp_auth.DIDWBADocument
+	PrivateKey  *ecdsa.PrivateKey
+
+	t           testing.TB
+	name        string
+	description string
+	tls         bool
+	requireAuth bool
+	callers     map[string]*anp_auth.DIDWBADocument
+	documents   map[string][]byte
+	mux         *http.ServeMux
+	verifier    *anp_auth.DidWbaVerifier
+
+	mu       sync.Mutex
+	requests []Request
+}
+
+// NewServer starts a Server and registers its shutdown with t.Cleanup.
+func NewServer(t testing.TB, opts ...Option) *Server {
+	t.Helper()
+
+	s := &Server{
+		RPC:       anp_server.New(anp_server.Config{Info: openrpc.Info{Title: "Test Agent"}}),
+		t:         t,
+		name:      "Test Agent",
+		callers:   make(map[string]*anp_auth.DIDWBADocument),
+		documents: make(map[string][]byte),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	s.mux = http.NewServeMux()
+	s.Server = httptest.NewUnstartedServer(s.mux)
+	if s.tls {
+		s.StartTLS()
+	} else {
+		s.Start()
+	}
+	t.Cleanup(s.Close)
+
+	if err := s.init(); err != nil {
+		t.Fatalf("anptest: %v", err)
+	}
+	return s
+}
+
+// ADURL returns the URL of the agent description.
+func (s *Server) ADURL() string { return s.URL + PathAgentDescription }
+
+// OpenRPCURL returns the URL of the OpenRPC document.
+func (s *Server) OpenRPCURL() string { return s.URL + PathOpenRPC }
+
+// RPCURL returns the URL of the JSON-RPC endpoint.
+func (s *Server) RPCURL() string { return s.URL + PathRPC }
+
+// Requests returns the requests observed so far.
+func (s *Server) Requests() []Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Request(nil), s.requests...)
+}
+
+// AgentDescription builds the ad.json served at PathAgentDescription.
+func (s *Server) AgentDescription() (*anp_ad.AgentDescription, error) {
+	return anp_ad.NewBuilder(s.name).
+		DID(s.DIDDocument.ID).
+		URL(s.ADURL()).
+		Description(s.description).
+		AddInterface(anp_ad.InterfaceTypeStructured, anp_ad.ProtocolOpenRPC, s.OpenRPCURL(), s.description).
+		SignWith(s.PrivateKey, s.DIDDocument.Authentication[0]).
+		Build()
+}
+
+// init creates the agent identity and the HTTP routes once the listener address is known.
+func (s *Server) init() error {
+	u, err := url.Parse(s.URL)
+	if err != nil {
+		return err
+	}
+	port, err := strconv.Atoi(u.Port())
+	if err != nil {
+		return fmt.Errorf("parse server port: %w", err)
+	}
+
+	adURL := s.ADURL()
+	doc, key, err := anp_auth.CreateDIDWBADocument("localhost", &port, nil, &adURL)
+	if err != nil {
+		return fmt.Errorf("create agent DID: %w", err)
+	}
+	s.DIDDocument = doc
+	s.PrivateKey = key
+	s.RPC.Generator().AddServer(openrpc.Server{Name: s.name, URL: s.RPCURL()})
+
+	if s.requireAuth {
+		if s.verifier, err = newVerifier(s.callers); err != nil {
+			return err
+		}
+	}
+
+	s.mux.Handle(PathDIDDocument, s.record(s.serveJSON(func() (any, error) { return s.DIDDocument, nil })))
+	s.mux.Handle(PathAgentDescription, s.protect(s.serveDocument(PathAgentDescription, func() (any, error) { return s.AgentDescription() })))
+	s.mux.Handle(PathOpenRPC, s.protect(s.serveDocument(PathOpenRPC, func() (any, error) { return s.RPC.OpenRPC(), nil })))
+	s.mux.Handle(PathRPC, s.protect(s.RPC))
+	for path := range s.documents {
+		if path != PathAgentDescription && path != PathOpenRPC {
+			s.mux.Handle(path, s.protect(s.serveDocument(path, nil)))
+		}
+	}
+	return nil
+}
+
+// addCallers registers the caller documents as a resolver would return them,
+// decoded from JSON.
+func addCallers(t testing.TB, docs map[string]*anp_auth.DIDWBADocument, callers []*Identity) {
+	t.Helper()
+	for _, caller := range callers {
+		raw, err := sonic.Marshal(caller.Document)
+		if err != nil {
+			t.Fatalf("anptest: encode caller DID document: %v", err)
+		}
+		var doc anp_auth.DIDWBADocument
+		if err := sonic.Unmarshal(raw, &doc); err != nil {
+			t.Fatalf("anptest: decode caller DID document: %v", err)
+		}
+		docs[doc.ID] = &doc
+	}
+}
+
+// newVerifier creates a verifier that resolves only the given caller DIDs.
+func newVerifier(callers map[string]*anp_auth.DIDWBADocument) (*anp_auth.DidWbaVerifier, error) {
+	jwtKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return nil, fmt.Errorf("generate JWT key: %w", err)
+	}
+	verifier, err := anp_auth.NewDidWbaVerifier(anp_auth.DidWbaVerifierConfig{
+		JWTPrivateKey:  jwtKey,
+		JWTPublicKey:   &jwtKey.PublicKey,
+		NonceValidator: anp_auth.NewMemoryNonceValidator(6 * time.Minute),
+		ResolveDIDDocument: func(_ context.Context, did string) (*anp_auth.DIDWBADocument, error) {
+			if doc, ok := callers[did]; ok {
+				return doc, nil
+			}
+			return nil, fmt.Errorf("anptest: unknown caller DID %s", did)
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("create verifier: %w", err)
+	}
+	return verifier, nil
+}
+
+// protect records the request and, when DID auth is enabled, authenticates it.
+func (s *Server) protect(next http.Handler) http.Handler {
+	next = s.record(next)
+	if s.verifier == nil {
+		return next
+	}
+	return anp_auth.Middleware(s.verifier)(next)
+}
+
+func (s *Server) record(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		did, _ := anp_auth.DIDFromContext(r.Context())
+		s.mu.Lock()
+		s.requests = append(s.requests, Request{Method: r.Method, Path: r.URL.Path, DID: did, Header: r.Header.Clone()})
+		s.mu.Unlock()
+		next.ServeHTTP(w, r)
+	})
+}
+
+// serveDocument serves the WithDocument override for path, falling back to build.
+func (s *Server) serveDocument(path string, build func() (any, error)) http.Handler {
+	if raw, ok := s.documents[path]; ok {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			w.Write(raw)
+		})
+	}
+	return s.serveJSON(build)
+}
+
+func (s *Server) serveJSON(build func() (any, error)) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		doc, err := build()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		body, err := sonic.Marshal(doc)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(body)
+	})
+}
@@ -0,0 +1,101 @@
+package anptest
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/openanp/anp-go/anp_ad"
+	"github.com/openanp/anp-go/anp_crawler"
+	"github.com/openanp/anp-go/session"
+)
+
+type echoParams struct {
+	Text string `json:"text"`
+}
+
+func TestServer_SessionRoundTrip(t *testing.T) {
+	caller := NewIdentity(t, "client.example.com")
+	srv := NewServer(t,
+		WithName("Echo Agent"),
+		WithDescription("Echoes text back"),
+		WithMethod("echo", func(p echoParams) (echoParams, error) { return p, nil }),
+		WithDIDAuth(caller),
+	)
+
+	sess, err := session.New(session.Config{Authenticator: caller.Authenticator})
+	if err != nil {
+		t.Fatalf("session.New() error = %v", err)
+	}
+	ctx := context.Background()
+
+	doc, err := sess.Fetch(ctx, srv.ADURL())
+	if err != nil {
+		t.Fatalf("Fetch() error = %v", err)
+	}
+	ad, err := anp_ad.Parse(doc.Raw)
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if err := anp_ad.VerifyProof(ad, srv.DIDDocument); err != nil {
+		t.Errorf("VerifyProof() error = %v", err)
+	}
+
+	rpcDoc, err := sess.Fetch(ctx, srv.OpenRPCURL())
+	if err != nil {
+		t.Fatalf("Fetch() error = %v", err)
+	}
+	result, err := session.ExecuteTool(ctx, rpcDoc, "echo", map[string]any{"text": "hi"})
+	if err != nil {
+		t.Fatalf("ExecuteTool() error = %v", err)
+	}
+	if got, _ := result["result"].(map[string]any); got["text"] != "hi" {
+		t.Errorf("unexpected result: %v", result)
+	}
+
+	for _, req := range srv.Requests() {
+		if req.DID != caller.Document.ID {
+			t.Errorf("request %s %s authenticated as %q, want %q", req.Method, req.Path, req.DID, caller.Document.ID)
+		}
+	}
+}
+
+func TestServer_RejectsUnknownCaller(t *testing.T) {
+	srv := NewServer(t, WithDIDAuth(NewIdentity(t, "client.example.com")))
+	stranger := NewIdentity(t, "stranger.example.com")
+
+	resp, err := anp_crawler.NewClient(stranger.Authenticator).Fetch(context.Background(), http.MethodGet, srv.ADURL(), nil, nil)
+	if err != nil {
+		t.Fatalf("Fetch() error = %v", err)
+	}
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("status = %d, want %d", resp.StatusCode, http.StatusUnauthorized)
+	}
+}
+
+func TestServer_CustomDocumentAndTLS(t *testing.T) {
+	srv := NewServer(t,
+		WithTLS(),
+		WithDocument("/agents.json", map[string]any{
+			"agentList": []map[string]any{{"name": "Hotel", "url": "https://hotel.example.com/ad.json"}},
+		}),
+	)
+
+	caller := NewIdentity(t, "client.example.com")
+	sess, err := session.New(session.Config{
+		Authenticator: caller.Authenticator,
+		HTTP:          session.HTTPConfig{Client: srv.Client()},
+	})
+	if err != nil {
+		t.Fatalf("session.New() error = %v", err)
+	}
+
+	doc, err := sess.Fetch(context.Background(), srv.URL+"/agents.json")
+	if err != nil {
+		t.Fatalf("Fetch() error = %v", err)
+	}
+	agents := session.ListAgents(doc)
+	if len(agents) != 1 || agents[0].Name != "Hotel" {
+		t.Errorf("unexpected agents: %+v", agents)
+	}
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/openanp/anp-go/session"
+)
+
+func runCrawl(args []string, stdout io.Writer) error {
+	fs := flag.NewFlagSet("crawl", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: anp crawl [flags] <url>")
+		fs.PrintDefaults()
+	}
+
+	var (
+		sf       sessionFlags
+		of       outputFlags
+		depth    int
+		maxPages int
+	)
+	sf.register(fs)
+	of.register(fs)
+	fs.IntVar(&depth, "depth", 1, "how many link hops to follow from the start URL")
+	fs.IntVar(&maxPages, "max", 100, "maximum number of documents to fetch")
+
+	urls, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(urls) != 1 {
+		fs.Usage()
+		return fmt.Errorf("crawl expects exactly one URL")
+	}
+	if depth < 0 {
+		return fmt.Errorf("depth must be non-negative")
+	}
+	if maxPages <= 0 {
+		return fmt.Errorf("max must be positive")
+	}
+	if err := of.validate(); err != nil {
+		return err
+	}
+
+	sess, err := sf.newSession()
+	if err != nil {
+		return err
+	}
+
+	reports, err := crawl(context.Background(), sess, urls[0], depth, maxPages, sf.maxConcurrent, of.dumpDir)
+	if err != nil {
+		return err
+	}
+	return renderReports(stdout, of.format, reports)
+}
+
+// crawl runs session.Crawl from start, following interface and agent URLs up to
+// maxDepth hops, and reports the documents in the order they were discovered.
+// Fetch failures are recorded in the report instead of aborting the crawl.
+func crawl(ctx context.Context, sess *session.Session, start string, maxDepth, maxPages, concurrency int, dumpDir string) ([]documentReport, error) {
+	cfg := session.CrawlConfig{
+		Scheduler: session.SchedulerConfig{MaxConcurrent: concurrency},
+		MaxDepth:  maxDepth,
+		MaxURLs:   maxPages,
+	}
+	if maxDepth == 0 {
+		// Crawl reads a zero MaxDepth as its default; capping the URLs at the
+		// seed keeps -depth 0 meaning the start document alone.
+		cfg.MaxURLs = 1
+	}
+	graph, err := sess.Crawl(ctx, cfg, start)
+	if err != nil {
+		return nil, err
+	}
+
+	reports := make([]documentReport, 0, len(graph.Nodes))
+	for _, node := range graph.Nodes {
+		if node.Err != nil {
+			reports = append(reports, documentReport{URL: node.URL, Depth: node.Depth, Error: node.Error})
+			continue
+		}
+		reports = append(reports, newDocumentReport(node.Document, node.Depth))
+
+		if dumpDir != "" {
+			path, err := dumpDocument(dumpDir, node.Document)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, "warning:", err)
+			} else {
+				fmt.Fprintln(os.Stderr, "wrote", path)
+			}
+		}
+	}
+	return reports, nil
+}
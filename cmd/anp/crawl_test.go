@@ -0,0 +1,114 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/openanp/anp-go/anptest"
+	"github.com/openanp/anp-go/session"
+)
+
+func TestRunCrawlFlags(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		wantErr string
+	}{
+		{"no url", nil, "exactly one URL"},
+		{"negative depth", []string{"-depth", "-1", "https://example.com/ad.json"}, "depth must be non-negative"},
+		{"flag after url", []string{"https://example.com/ad.json", "-depth", "-1"}, "depth must be non-negative"},
+		{"two urls", []string{"https://example.com/a.json", "-depth", "2", "https://example.com/b.json"}, "exactly one URL"},
+		{"zero max", []string{"-max", "0", "https://example.com/ad.json"}, "max must be positive"},
+		{"negative max", []string{"-max", "-3", "https://example.com/ad.json"}, "max must be positive"},
+		{"bad format", []string{"-format", "xml", "https://example.com/ad.json"}, "unsupported format"},
+		{"no identity", []string{"-did-doc", "", "-key", "", "https://example.com/ad.json"}, "-did-doc and -key are required"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args := append([]string{"crawl"}, tt.args...)
+			err := run(args, io.Discard, io.Discard)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("run(%q) error = %v, want %q", args, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCrawl(t *testing.T) {
+	// ad.json links to a.json and b.json; a.json links on to c.json.
+	links := map[string][]string{
+		"/ad.json": {"/a.json", "/b.json"},
+		"/a.json":  {"/c.json"},
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var interfaces []string
+		for _, link := range links[r.URL.Path] {
+			interfaces = append(interfaces, fmt.Sprintf(`{"type":"StructuredInterface","protocol":"openrpc","url":%q}`, link))
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"protocolType":"ANP","type":"AgentDescription","name":"Crawl","interfaces":[%s]}`, strings.Join(interfaces, ","))
+	}))
+	defer srv.Close()
+	sess, err := session.New(session.Config{Authenticator: anptest.NewIdentity(t, "client.example.com").Authenticator})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	tests := []struct {
+		depth, max int
+		want       []string
+	}{
+		{0, 100, []string{"/ad.json"}},
+		{1, 100, []string{"/ad.json", "/a.json", "/b.json"}},
+		{2, 100, []string{"/ad.json", "/a.json", "/b.json", "/c.json"}},
+		{2, 2, []string{"/ad.json", "/a.json"}},
+	}
+	for _, tt := range tests {
+		reports, err := crawl(context.Background(), sess, srv.URL+"/ad.json", tt.depth, tt.max, 2, "")
+		if err != nil {
+			t.Fatalf("crawl() error = %v", err)
+		}
+		var got []string
+		for _, r := range reports {
+			if r.Error != "" {
+				t.Errorf("crawl(depth %d) report %s error = %s", tt.depth, r.URL, r.Error)
+			}
+			got = append(got, strings.TrimPrefix(r.URL, srv.URL))
+		}
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("crawl(depth %d, max %d) = %v, want %v", tt.depth, tt.max, got, tt.want)
+		}
+	}
+}
+
+func TestParseArgs(t *testing.T) {
+	tests := []struct {
+		args      []string
+		wantDepth int
+		wantArgs  []string
+	}{
+		{[]string{"-depth", "2", "u"}, 2, []string{"u"}},
+		{[]string{"u", "-depth", "2"}, 2, []string{"u"}},
+		{[]string{"u", "--depth=2", "v"}, 2, []string{"u", "v"}},
+		{[]string{"u", "--", "-depth", "2"}, 1, []string{"u", "-depth", "2"}},
+		{nil, 1, nil},
+	}
+	for _, tt := range tests {
+		fs := flag.NewFlagSet("test", flag.ContinueOnError)
+		depth := fs.Int("depth", 1, "")
+		got, err := parseArgs(fs, tt.args)
+		if err != nil {
+			t.Fatalf("parseArgs(%q) error = %v", tt.args, err)
+		}
+		if *depth != tt.wantDepth || !slices.Equal(got, tt.wantArgs) {
+			t.Errorf("parseArgs(%q) = %q, depth %d; want %q, depth %d", tt.args, got, *depth, tt.wantArgs, tt.wantDepth)
+		}
+	}
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+)
+
+func runFetch(args []string, stdout io.Writer) error {
+	fs := flag.NewFlagSet("fetch", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: anp fetch [flags] <url>")
+		fs.PrintDefaults()
+	}
+
+	var (
+		sf sessionFlags
+		of outputFlags
+	)
+	sf.register(fs)
+	of.register(fs)
+
+	urls, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(urls) != 1 {
+		fs.Usage()
+		return fmt.Errorf("fetch expects exactly one URL")
+	}
+	if err := of.validate(); err != nil {
+		return err
+	}
+
+	sess, err := sf.newSession()
+	if err != nil {
+		return err
+	}
+
+	doc, err := sess.Fetch(context.Background(), urls[0])
+	if err != nil {
+		return err
+	}
+
+	if of.dumpDir != "" {
+		path, err := dumpDocument(of.dumpDir, doc)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(os.Stderr, "wrote", path)
+	}
+
+	return renderReports(stdout, of.format, []documentReport{newDocumentReport(doc, 0)})
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/openanp/anp-go/session"
+)
+
+const (
+	envDIDDocument = "ANP_DID_DOCUMENT"
+	envPrivateKey  = "ANP_PRIVATE_KEY"
+)
+
+// parseArgs parses args with fs and returns the positional arguments. Unlike
+// fs.Parse it keeps reading flags after a positional argument, so both
+// "anp crawl -depth 2 <url>" and "anp crawl <url> -depth 2" work. Arguments
+// after "--" are always positional.
+func parseArgs(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		rest := fs.Args()
+		if n := len(args) - len(rest); n > 0 && args[n-1] == "--" {
+			return append(positional, rest...), nil
+		}
+		if len(rest) == 0 {
+			return positional, nil
+		}
+		positional = append(positional, rest[0])
+		args = rest[1:]
+	}
+}
+
+// sessionFlags holds the flags shared by commands that need an authenticated session.
+type sessionFlags struct {
+	didDocPath    string
+	keyPath       string
+	timeout       time.Duration
+	maxConcurrent int
+}
+
+func (f *sessionFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&f.didDocPath, "did-doc", os.Getenv(envDIDDocument), "path to the DID document JSON (env "+envDIDDocument+")")
+	fs.StringVar(&f.keyPath, "key", os.Getenv(envPrivateKey), "path to the PEM encoded private key (env "+envPrivateKey+")")
+	fs.DurationVar(&f.timeout, "timeout", 30*time.Second, "HTTP timeout per request")
+	fs.IntVar(&f.maxConcurrent, "concurrency", 5, "maximum concurrent requests")
+}
+
+func (f *sessionFlags) newSession() (*session.Session, error) {
+	if f.didDocPath == "" || f.keyPath == "" {
+		return nil, fmt.Errorf("-did-doc and -key are required (or set %s and %s)", envDIDDocument, envPrivateKey)
+	}
+
+	sess, err := session.New(session.Config{
+		DIDDocumentPath: f.didDocPath,
+		PrivateKeyPath:  f.keyPath,
+		HTTP:            session.HTTPConfig{Timeout: f.timeout},
+		MaxConcurrent:   f.maxConcurrent,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("create session: %w", err)
+	}
+	return sess, nil
+}
+
+// outputFlags holds the flags controlling how documents are rendered.
+type outputFlags struct {
+	format  string
+	dumpDir string
+}
+
+func (f *outputFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&f.format, "format", "table", "output format: table or json")
+	fs.StringVar(&f.dumpDir, "dump", "", "directory to write raw documents to (optional)")
+}
+
+func (f *outputFlags) validate() error {
+	switch f.format {
+	case formatTable, formatJSON:
+		return nil
+	default:
+		return fmt.Errorf("unsupported format %q (use %q or %q)", f.format, formatTable, formatJSON)
+	}
+}
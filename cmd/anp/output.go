@@ -0,0 +1,172 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/openanp/anp-go/session"
+)
+
+const (
+	formatTable = "table"
+	formatJSON  = "json"
+)
+
+// documentReport is the serialisable summary of a fetched document.
+type documentReport struct {
+	URL         string            `json:"url"`
+	Depth       int               `json:"depth"`
+	StatusCode  int               `json:"status_code"`
+	ContentType string            `json:"content_type"`
+	Interfaces  []interfaceReport `json:"interfaces"`
+	Agents      []agentReport     `json:"agents"`
+	Tools       []string          `json:"tools"`
+	Error       string            `json:"error,omitempty"`
+}
+
+type interfaceReport struct {
+	Type        string `json:"type"`
+	Protocol    string `json:"protocol"`
+	Method      string `json:"method,omitempty"`
+	URL         string `json:"url,omitempty"`
+	Description string `json:"description,omitempty"`
+}
+
+type agentReport struct {
+	Name        string  `json:"name"`
+	URL         string  `json:"url"`
+	Description string  `json:"description,omitempty"`
+	Rating      float64 `json:"rating,omitempty"`
+}
+
+func newDocumentReport(doc *session.Document, depth int) documentReport {
+	report := documentReport{
+		URL:         doc.URL,
+		Depth:       depth,
+		StatusCode:  doc.StatusCode,
+		ContentType: doc.ContentType,
+	}
+
+	for _, entry := range session.ListInterfaces(doc) {
+		report.Interfaces = append(report.Interfaces, interfaceReport{
+			Type:        entry.Type,
+			Protocol:    entry.Protocol,
+			Method:      entry.MethodName,
+			URL:         entry.URL,
+			Description: entry.Description,
+		})
+	}
+
+	for _, agent := range session.ListAgents(doc) {
+		report.Agents = append(report.Agents, agentReport{
+			Name:        agent.Name,
+			URL:         agent.URL,
+			Description: agent.Description,
+			Rating:      agent.Rating,
+		})
+	}
+
+	for _, tool := range doc.Tools {
+		report.Tools = append(report.Tools, tool.Function.Name)
+	}
+
+	return report
+}
+
+func renderReports(w io.Writer, format string, reports []documentReport) error {
+	if format == formatJSON {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(reports)
+	}
+
+	for idx, report := range reports {
+		if idx > 0 {
+			fmt.Fprintln(w)
+		}
+		if err := renderTable(w, report); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func renderTable(w io.Writer, report documentReport) error {
+	fmt.Fprintf(w, "== %s (depth %d)\n", report.URL, report.Depth)
+	if report.Error != "" {
+		fmt.Fprintf(w, "error: %s\n", report.Error)
+		return nil
+	}
+	fmt.Fprintf(w, "status=%d content-type=%q\n", report.StatusCode, report.ContentType)
+
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+
+	fmt.Fprintf(tw, "\nINTERFACES (%d)\n", len(report.Interfaces))
+	if len(report.Interfaces) > 0 {
+		fmt.Fprintln(tw, "TYPE\tPROTOCOL\tMETHOD\tURL\tDESCRIPTION")
+		for _, iface := range report.Interfaces {
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", iface.Type, iface.Protocol, iface.Method, iface.URL, truncate(iface.Description, 60))
+		}
+	}
+
+	fmt.Fprintf(tw, "\nAGENTS (%d)\n", len(report.Agents))
+	if len(report.Agents) > 0 {
+		fmt.Fprintln(tw, "NAME\tURL\tRATING\tDESCRIPTION")
+		for _, agent := range report.Agents {
+			fmt.Fprintf(tw, "%s\t%s\t%.2f\t%s\n", agent.Name, agent.URL, agent.Rating, truncate(agent.Description, 60))
+		}
+	}
+
+	fmt.Fprintf(tw, "\nTOOLS (%d)\n", len(report.Tools))
+	for _, tool := range report.Tools {
+		fmt.Fprintf(tw, "%s\n", tool)
+	}
+
+	return tw.Flush()
+}
+
+func truncate(s string, max int) string {
+	s = strings.Join(strings.Fields(s), " ")
+	runes := []rune(s)
+	if len(runes) <= max {
+		return s
+	}
+	return string(runes[:max-1]) + "…"
+}
+
+var unsafeFilenameChars = regexp.MustCompile(`[^a-zA-Z0-9._-]+`)
+
+// dumpDocument writes the raw document body into dir using a filename derived from its URL.
+func dumpDocument(dir string, doc *session.Document) (string, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", fmt.Errorf("create dump directory: %w", err)
+	}
+
+	name := doc.URL
+	if u, err := url.Parse(doc.URL); err == nil {
+		name = u.Host + u.Path
+		if u.RawQuery != "" {
+			name += "_" + u.RawQuery
+		}
+	}
+	name = strings.Trim(unsafeFilenameChars.ReplaceAllString(name, "_"), "_")
+	if name == "" {
+		name = "document"
+	}
+	if filepath.Ext(name) == "" {
+		name += ".json"
+	}
+
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, doc.Raw, 0o644); err != nil {
+		return "", fmt.Errorf("write %s: %w", path, err)
+	}
+	return path, nil
+}
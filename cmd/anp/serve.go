@@ -0,0 +1,145 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/openanp/anp-go/anp_auth"
+)
+
+func runServe(args []string, stdout io.Writer) error {
+	if len(args) == 0 {
+		return fmt.Errorf("serve expects a target, e.g. \"anp serve did\"")
+	}
+
+	switch args[0] {
+	case "did":
+		return runServeDID(args[1:], stdout)
+	default:
+		return fmt.Errorf("unknown serve target %q", args[0])
+	}
+}
+
+func runServeDID(args []string, stdout io.Writer) error {
+	fs := flag.NewFlagSet("serve did", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: anp serve did [flags]")
+		fs.PrintDefaults()
+	}
+
+	var (
+		docPath  string
+		addr     string
+		certFile string
+		keyFile  string
+	)
+	fs.StringVar(&docPath, "doc", "did.json", "path to the DID document to host")
+	fs.StringVar(&addr, "addr", ":8443", "listen address")
+	fs.StringVar(&certFile, "tls-cert", "", "TLS certificate file (PEM)")
+	fs.StringVar(&keyFile, "tls-key", "", "TLS private key file (PEM)")
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if (certFile == "") != (keyFile == "") {
+		return fmt.Errorf("-tls-cert and -tls-key must be provided together")
+	}
+
+	host, err := loadDIDHost(docPath)
+	if err != nil {
+		return err
+	}
+
+	srv := &http.Server{
+		Addr:              addr,
+		Handler:           host.handler(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	errCh := make(chan error, 1)
+	go func() {
+		if certFile != "" {
+			errCh <- srv.ListenAndServeTLS(certFile, keyFile)
+			return
+		}
+		errCh <- srv.ListenAndServe()
+	}()
+
+	fmt.Fprintf(stdout, "serving %s at %s on %s\n", host.did, host.path, addr)
+	fmt.Fprintf(stdout, "resolvers will fetch %s\n", host.url)
+	if certFile == "" {
+		fmt.Fprintln(os.Stderr, "warning: TLS disabled; did:wba resolvers require HTTPS, so terminate TLS in front of this server")
+	}
+
+	select {
+	case err := <-errCh:
+		if errors.Is(err, http.ErrServerClosed) {
+			return nil
+		}
+		return err
+	case <-ctx.Done():
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		return srv.Shutdown(shutdownCtx)
+	}
+}
+
+// didHost serves a DID document at the path did:wba resolvers derive from its id.
+type didHost struct {
+	did  string
+	url  string // URL resolvers fetch the document from
+	path string
+	raw  []byte
+}
+
+func loadDIDHost(docPath string) (*didHost, error) {
+	raw, err := os.ReadFile(docPath)
+	if err != nil {
+		return nil, fmt.Errorf("read DID document: %w", err)
+	}
+
+	var doc anp_auth.DIDWBADocument
+	if err := json.Unmarshal(raw, &doc); err != nil {
+		return nil, fmt.Errorf("parse DID document: %w", err)
+	}
+
+	docURL, err := anp_auth.DIDDocumentURL(doc.ID)
+	if err != nil {
+		return nil, fmt.Errorf("derive DID document path: %w", err)
+	}
+	parsed, err := url.Parse(docURL)
+	if err != nil {
+		return nil, fmt.Errorf("parse DID document URL: %w", err)
+	}
+	return &didHost{did: doc.ID, url: docURL, path: parsed.Path, raw: raw}, nil
+}
+
+func (h *didHost) handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc(h.path, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			w.Header().Set("Allow", "GET, HEAD")
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "public, max-age=300")
+		if r.Method == http.MethodGet {
+			w.Write(h.raw)
+		}
+	})
+	return mux
+}
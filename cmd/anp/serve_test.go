@@ -0,0 +1,110 @@
+package main
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeDIDDocument(t *testing.T, body string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "did.json")
+	if err := os.WriteFile(path, []byte(body), 0o600); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+	return path
+}
+
+func TestDIDHost(t *testing.T) {
+	tests := []struct {
+		name       string
+		did        string
+		method     string
+		path       string
+		wantURL    string
+		wantStatus int
+	}{
+		{"domain", "did:wba:example.com", http.MethodGet, "/.well-known/did.json", "https://example.com/.well-known/did.json", http.StatusOK},
+		{"domain head", "did:wba:example.com", http.MethodHead, "/.well-known/did.json", "https://example.com/.well-known/did.json", http.StatusOK},
+		{"domain wrong path", "did:wba:example.com", http.MethodGet, "/user/alice/did.json", "https://example.com/.well-known/did.json", http.StatusNotFound},
+		{"domain post", "did:wba:example.com", http.MethodPost, "/.well-known/did.json", "https://example.com/.well-known/did.json", http.StatusMethodNotAllowed},
+		{"port", "did:wba:example.com%3A8443", http.MethodGet, "/.well-known/did.json", "https://example.com:8443/.well-known/did.json", http.StatusOK},
+		{"user path", "did:wba:example.com:user:alice", http.MethodGet, "/user/alice/did.json", "https://example.com/user/alice/did.json", http.StatusOK},
+		{"user path well-known", "did:wba:example.com:user:alice", http.MethodGet, "/.well-known/did.json", "https://example.com/user/alice/did.json", http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body := `{"@context":["https://www.w3.org/ns/did/v1"],"id":"` + tt.did + `"}`
+			host, err := loadDIDHost(writeDIDDocument(t, body))
+			if err != nil {
+				t.Fatalf("loadDIDHost() error = %v", err)
+			}
+			if host.url != tt.wantURL {
+				t.Errorf("url = %s, want %s", host.url, tt.wantURL)
+			}
+
+			srv := httptest.NewServer(host.handler())
+			defer srv.Close()
+			req, _ := http.NewRequest(tt.method, srv.URL+tt.path, nil)
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatalf("Do() error = %v", err)
+			}
+			got, _ := io.ReadAll(resp.Body)
+			resp.Body.Close()
+
+			if resp.StatusCode != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", resp.StatusCode, tt.wantStatus)
+			}
+			switch {
+			case tt.wantStatus == http.StatusMethodNotAllowed:
+				if allow := resp.Header.Get("Allow"); allow != "GET, HEAD" {
+					t.Errorf("Allow = %q", allow)
+				}
+			case tt.wantStatus != http.StatusOK:
+			case tt.method == http.MethodHead:
+				if len(got) != 0 {
+					t.Errorf("HEAD body = %q, want empty", got)
+				}
+			default:
+				if string(got) != body {
+					t.Errorf("body = %s, want %s", got, body)
+				}
+				if ct := resp.Header.Get("Content-Type"); ct != "application/json" {
+					t.Errorf("Content-Type = %q", ct)
+				}
+			}
+		})
+	}
+}
+
+func TestRunServeDIDErrors(t *testing.T) {
+	valid := writeDIDDocument(t, `{"id":"did:wba:example.com"}`)
+	missingCert := filepath.Join(t.TempDir(), "cert.pem")
+
+	tests := []struct {
+		name    string
+		args    []string
+		wantErr string
+	}{
+		{"cert without key", []string{"-doc", valid, "-tls-cert", "cert.pem"}, "-tls-cert and -tls-key must be provided together"},
+		{"key without cert", []string{"-doc", valid, "-tls-key", "key.pem"}, "-tls-cert and -tls-key must be provided together"},
+		{"unreadable TLS files", []string{"-doc", valid, "-addr", "127.0.0.1:0", "-tls-cert", missingCert, "-tls-key", missingCert}, "cert.pem"},
+		{"missing doc", []string{"-doc", filepath.Join(t.TempDir(), "none.json")}, "read DID document"},
+		{"invalid doc", []string{"-doc", writeDIDDocument(t, "not json")}, "parse DID document"},
+		{"non-DID id", []string{"-doc", writeDIDDocument(t, `{"id":"https://example.com"}`)}, "derive DID document path"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args := append([]string{"serve", "did"}, tt.args...)
+			err := run(args, io.Discard, io.Discard)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("run(%q) error = %v, want %q", args, err, tt.wantErr)
+			}
+		})
+	}
+}
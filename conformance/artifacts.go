@@ -0,0 +1,199 @@
+// Package conformance checks that DID-WBA artifacts are interchangeable between
+// the Go, Python and TypeScript ANP SDKs.
+//
+// A fixture directory holds the signer's DID document and, for each producing SDK
+// (identified by a file prefix such as "go", "py" or "ts"), the intermediate
+// artifacts of one authentication flow:
+//
+//	did_document.json             signer DID document
+//	jwt_public.pem                verifies <prefix>_step5_token.json (optional)
+//	<prefix>_step1_params.json    Params: DID, nonce, timestamp, service domain
+//	<prefix>_step2_payload.json   Payload: canonical signing payload (optional)
+//	<prefix>_step4_header.json    Header: the DIDWba Authorization header
+//	<prefix>_step5_token.json     Token: an access token issued for the DID (optional)
+//
+// Generate and Artifacts.Write produce Go artifacts; LoadFixture and Verify check
+// artifacts produced by any SDK; RunFixtures wraps both for go test.
+package conformance
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/openanp/anp-go/anp_auth"
+)
+
+// File names used in fixture directories.
+const (
+	DIDDocumentFile  = "did_document.json"
+	JWTPublicKeyFile = "jwt_public.pem"
+
+	paramsSuffix  = "_step1_params.json"
+	payloadSuffix = "_step2_payload.json"
+	headerSuffix  = "_step4_header.json"
+	tokenSuffix   = "_step5_token.json"
+)
+
+// Params are the inputs of a DID-WBA signature (step 1).
+type Params struct {
+	DID                  string `json:"did"`
+	Nonce                string `json:"nonce"`
+	Timestamp            string `json:"timestamp"`
+	VerificationMethod   string `json:"verification_method"`
+	VerificationMethodID string `json:"verification_method_id,omitempty"`
+	ServiceDomain        string `json:"service_domain"`
+}
+
+// Payload is the canonical signing payload (step 2).
+type Payload struct {
+	PayloadJSON     string `json:"payload_json"`
+	PayloadBytesHex string `json:"payload_bytes_hex,omitempty"`
+	PayloadHashHex  string `json:"payload_hash_hex,omitempty"`
+}
+
+// Header is the assembled Authorization header (step 4).
+type Header struct {
+	AuthHeader string `json:"auth_header"`
+}
+
+// Token is an access token issued after DID-WBA authentication (step 5).
+type Token struct {
+	AccessToken string `json:"access_token"`
+	Algorithm   string `json:"algorithm"`
+	DID         string `json:"did"`
+}
+
+// Artifacts is one SDK's output for a single authentication flow.
+type Artifacts struct {
+	Prefix  string
+	Params  Params
+	Payload *Payload
+	Header  Header
+	Token   *Token
+}
+
+// Fixture is a directory of artifacts sharing one signer.
+type Fixture struct {
+	Dir          string
+	DIDDocument  *anp_auth.DIDWBADocument
+	JWTPublicKey any
+	Artifacts    []*Artifacts
+}
+
+// LoadFixture reads the DID document and every artifact set in dir.
+func LoadFixture(dir string) (*Fixture, error) {
+	var doc anp_auth.DIDWBADocument
+	if err := readJSON(filepath.Join(dir, DIDDocumentFile), &doc); err != nil {
+		return nil, err
+	}
+	fixture := &Fixture{Dir: dir, DIDDocument: &doc}
+
+	pem, err := os.ReadFile(filepath.Join(dir, JWTPublicKeyFile))
+	switch {
+	case err == nil:
+		key, err := anp_auth.LoadJWTPublicKeyFromPEM(pem)
+		if err != nil {
+			return nil, fmt.Errorf("load %s: %w", JWTPublicKeyFile, err)
+		}
+		fixture.JWTPublicKey = key
+	case !errors.Is(err, fs.ErrNotExist):
+		return nil, err
+	}
+
+	matches, err := filepath.Glob(filepath.Join(dir, "*"+paramsSuffix))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(matches)
+	for _, match := range matches {
+		prefix := strings.TrimSuffix(filepath.Base(match), paramsSuffix)
+		artifacts, err := LoadArtifacts(dir, prefix)
+		if err != nil {
+			return nil, err
+		}
+		fixture.Artifacts = append(fixture.Artifacts, artifacts)
+	}
+	if len(fixture.Artifacts) == 0 {
+		return nil, fmt.Errorf("no *%s files in %s", paramsSuffix, dir)
+	}
+	return fixture, nil
+}
+
+// LoadArtifacts reads the artifacts written with prefix in dir. The payload and
+// token artifacts are optional.
+func LoadArtifacts(dir, prefix string) (*Artifacts, error) {
+	a := &Artifacts{Prefix: prefix}
+	if err := readJSON(filepath.Join(dir, prefix+paramsSuffix), &a.Params); err != nil {
+		return nil, err
+	}
+	if err := readJSON(filepath.Join(dir, prefix+headerSuffix), &a.Header); err != nil {
+		return nil, err
+	}
+
+	var payload Payload
+	if err := readJSON(filepath.Join(dir, prefix+payloadSuffix), &payload); err == nil {
+		a.Payload = &payload
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+
+	var token Token
+	if err := readJSON(filepath.Join(dir, prefix+tokenSuffix), &token); err == nil {
+		a.Token = &token
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+	return a, nil
+}
+
+// Write stores the artifacts in dir using their prefix, creating dir if needed.
+func (a *Artifacts) Write(dir string) error {
+	if a.Prefix == "" {
+		return errors.New("artifacts prefix is required")
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+
+	files := map[string]any{
+		paramsSuffix: a.Params,
+		headerSuffix: a.Header,
+	}
+	if a.Payload != nil {
+		files[payloadSuffix] = a.Payload
+	}
+	if a.Token != nil {
+		files[tokenSuffix] = a.Token
+	}
+	for suffix, v := range files {
+		if err := writeJSON(filepath.Join(dir, a.Prefix+suffix), v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func readJSON(path string, v any) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("decode %s: %w", path, err)
+	}
+	return nil
+}
+
+func writeJSON(path string, v any) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encode %s: %w", path, err)
+	}
+	return os.WriteFile(path, append(data, '\n'), 0o644)
+}
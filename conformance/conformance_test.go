@@ -0,0 +1,104 @@
+package conformance
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/bytedance/sonic"
+	"github.com/openanp/anp-go/anp_auth"
+)
+
+// TestFixtures verifies the checked-in fixtures plus any directories listed in
+// ANP_CONFORMANCE_FIXTURES (separated by the OS path list separator), e.g. output
+// from the Python or TypeScript generators.
+func TestFixtures(t *testing.T) {
+	dirs, err := filepath.Glob(filepath.Join("testdata", "*"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if extra := os.Getenv("ANP_CONFORMANCE_FIXTURES"); extra != "" {
+		dirs = append(dirs, filepath.SplitList(extra)...)
+	}
+	RunFixtures(t, dirs...)
+}
+
+func TestGenerate_RoundTrip(t *testing.T) {
+	doc, key, err := anp_auth.CreateDIDWBADocument("conformance.example.com", nil, []string{"agents", "go"}, nil)
+	if err != nil {
+		t.Fatalf("CreateDIDWBADocument() error = %v", err)
+	}
+	jwtKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("GenerateKey() error = %v", err)
+	}
+
+	a, err := Generate(doc, key, Options{ServiceDomain: "test.example.com", JWTPrivateKey: jwtKey})
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+
+	dir := t.TempDir()
+	if err := a.Write(dir); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	docJSON, err := sonic.Marshal(doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, DIDDocumentFile), docJSON, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	pubDER, err := x509.MarshalPKIXPublicKey(&jwtKey.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pubPEM := pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: pubDER})
+	if err := os.WriteFile(filepath.Join(dir, JWTPublicKeyFile), pubPEM, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	fixture, err := LoadFixture(dir)
+	if err != nil {
+		t.Fatalf("LoadFixture() error = %v", err)
+	}
+	if len(fixture.Artifacts) != 1 || fixture.Artifacts[0].Payload == nil || fixture.Artifacts[0].Token == nil {
+		t.Fatalf("unexpected fixture contents: %+v", fixture.Artifacts)
+	}
+	if err := Verify(fixture.Artifacts[0], fixture.DIDDocument, fixture.JWTPublicKey); err != nil {
+		t.Errorf("Verify() error = %v", err)
+	}
+}
+
+func TestVerify_DetectsMismatches(t *testing.T) {
+	fixture, err := LoadFixture(filepath.Join("testdata", "cross_verify"))
+	if err != nil {
+		t.Fatalf("LoadFixture() error = %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		mutate  func(*Artifacts)
+		wantErr string
+	}{
+		{"service domain", func(a *Artifacts) { a.Params.ServiceDomain = "other.example.com" }, "signature"},
+		{"nonce", func(a *Artifacts) { a.Params.Nonce = "different" }, "header nonce"},
+		{"payload", func(a *Artifacts) { a.Payload = &Payload{PayloadJSON: `{"did":"x"}`} }, "payload_json"},
+		{"token without key", func(a *Artifacts) { a.Token = &Token{AccessToken: "x", Algorithm: "RS256"} }, "no jwt_public.pem"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := *fixture.Artifacts[0]
+			tt.mutate(&a)
+			err := Verify(&a, fixture.DIDDocument, nil)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Verify() error = %v, want mention of %q", err, tt.wantErr)
+			}
+		})
+	}
+}
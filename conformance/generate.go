@@ -0,0 +1,90 @@
+package conformance
+
+import (
+	"crypto/ecdsa"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/openanp/anp-go/anp_auth"
+)
+
+// Options controls Generate. Empty Nonce and Timestamp are filled with fresh values;
+// fix them to compare payloads across SDKs.
+type Options struct {
+	Prefix        string
+	ServiceDomain string
+	Nonce         string
+	Timestamp     string
+
+	// JWTPrivateKey, when set, also produces a step 5 access token.
+	JWTPrivateKey any
+	JWTAlgorithm  string
+}
+
+// Generate runs the Go DID-WBA flow for doc and privateKey and records every step.
+func Generate(doc *anp_auth.DIDWBADocument, privateKey *ecdsa.PrivateKey, opts Options) (*Artifacts, error) {
+	if doc == nil {
+		return nil, errors.New("DID document is required")
+	}
+	if opts.ServiceDomain == "" {
+		return nil, errors.New("service domain is required")
+	}
+	if opts.Prefix == "" {
+		opts.Prefix = "go"
+	}
+	if opts.Nonce == "" {
+		opts.Nonce = uuid.NewString()
+	}
+	if opts.Timestamp == "" {
+		opts.Timestamp = time.Now().UTC().Format(time.RFC3339)
+	}
+
+	header, err := anp_auth.NewAuthHeader(privateKey, doc, opts.ServiceDomain, opts.Nonce, opts.Timestamp)
+	if err != nil {
+		return nil, fmt.Errorf("generate header: %w", err)
+	}
+	payload, err := anp_auth.CanonicalAuthPayload(doc.ID, opts.Nonce, opts.Timestamp, opts.ServiceDomain)
+	if err != nil {
+		return nil, fmt.Errorf("canonicalize payload: %w", err)
+	}
+
+	a := &Artifacts{
+		Prefix: opts.Prefix,
+		Params: Params{
+			DID:                  doc.ID,
+			Nonce:                opts.Nonce,
+			Timestamp:            opts.Timestamp,
+			VerificationMethod:   header.VerificationMethod,
+			VerificationMethodID: doc.ID + "#" + header.VerificationMethod,
+			ServiceDomain:        opts.ServiceDomain,
+		},
+		Payload: newPayload(payload),
+		Header:  Header{AuthHeader: header.String()},
+	}
+
+	if opts.JWTPrivateKey != nil {
+		alg := opts.JWTAlgorithm
+		if alg == "" {
+			alg = anp_auth.DefaultJWTAlgorithm
+		}
+		token, err := anp_auth.CreateAccessToken(doc.ID, opts.JWTPrivateKey, alg, anp_auth.DefaultAccessTokenExpiration)
+		if err != nil {
+			return nil, fmt.Errorf("create access token: %w", err)
+		}
+		a.Token = &Token{AccessToken: token, Algorithm: alg, DID: doc.ID}
+	}
+	return a, nil
+}
+
+func newPayload(canonical []byte) *Payload {
+	digest := sha256.Sum256(canonical)
+	return &Payload{
+		PayloadJSON:     string(canonical),
+		PayloadBytesHex: hex.EncodeToString(canonical),
+		PayloadHashHex:  hex.EncodeToString(digest[:]),
+	}
+}
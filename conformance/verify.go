@@ -0,0 +1,93 @@
+package conformance
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/openanp/anp-go/anp_auth"
+)
+
+// Verify checks a's internal consistency and its signature against doc, and, when
+// a carries a token, the token against jwtPublicKey. All failures are reported.
+func Verify(a *Artifacts, doc *anp_auth.DIDWBADocument, jwtPublicKey any) error {
+	var errs []error
+	fail := func(format string, args ...any) {
+		errs = append(errs, fmt.Errorf(format, args...))
+	}
+
+	header, err := anp_auth.ParseAuthHeader(a.Header.AuthHeader)
+	if err != nil {
+		return fmt.Errorf("parse header: %w", err)
+	}
+	for _, field := range []struct{ name, header, param string }{
+		{"did", header.DID, a.Params.DID},
+		{"nonce", header.Nonce, a.Params.Nonce},
+		{"timestamp", header.Timestamp, a.Params.Timestamp},
+		{"verification_method", header.VerificationMethod, a.Params.VerificationMethod},
+	} {
+		if field.header != field.param {
+			fail("header %s %q does not match params %q", field.name, field.header, field.param)
+		}
+	}
+
+	if a.Payload != nil {
+		canonical, err := anp_auth.CanonicalDelegatedAuthPayload(a.Params.DID, a.Params.Nonce, a.Params.Timestamp, a.Params.ServiceDomain, header.Delegation)
+		if err != nil {
+			return fmt.Errorf("canonicalize payload: %w", err)
+		}
+		want := newPayload(canonical)
+		if a.Payload.PayloadJSON != want.PayloadJSON {
+			fail("payload_json %s differs from Go canonical payload %s", a.Payload.PayloadJSON, want.PayloadJSON)
+		}
+		if a.Payload.PayloadBytesHex != "" && a.Payload.PayloadBytesHex != want.PayloadBytesHex {
+			fail("payload_bytes_hex differs from Go canonical payload")
+		}
+		if a.Payload.PayloadHashHex != "" && a.Payload.PayloadHashHex != want.PayloadHashHex {
+			fail("payload_hash_hex %s differs from Go digest %s", a.Payload.PayloadHashHex, want.PayloadHashHex)
+		}
+	}
+
+	authJSON := &anp_auth.AuthJSON{
+		DID:                header.DID,
+		Nonce:              header.Nonce,
+		Timestamp:          header.Timestamp,
+		VerificationMethod: header.VerificationMethod,
+		Signature:          header.Signature,
+		Delegation:         header.Delegation,
+	}
+	if ok, message := anp_auth.VerifyAuthJSON(authJSON, doc, a.Params.ServiceDomain); !ok {
+		fail("signature: %s", message)
+	}
+
+	if a.Token != nil {
+		if jwtPublicKey == nil {
+			fail("token present but no %s to verify it", JWTPublicKeyFile)
+		} else if did, err := anp_auth.VerifyAccessToken(a.Token.AccessToken, jwtPublicKey, a.Token.Algorithm); err != nil {
+			fail("token: %v", err)
+		} else if did != a.Params.DID {
+			fail("token subject %q does not match DID %q", did, a.Params.DID)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// RunFixtures verifies every artifact set in each fixture directory as a subtest.
+func RunFixtures(t *testing.T, dirs ...string) {
+	t.Helper()
+	for _, dir := range dirs {
+		fixture, err := LoadFixture(dir)
+		if err != nil {
+			t.Errorf("LoadFixture(%s) error = %v", dir, err)
+			continue
+		}
+		for _, a := range fixture.Artifacts {
+			t.Run(dir+"/"+a.Prefix, func(t *testing.T) {
+				if err := Verify(a, fixture.DIDDocument, fixture.JWTPublicKey); err != nil {
+					t.Error(err)
+				}
+			})
+		}
+	}
+}
@@ -12,6 +12,7 @@ import (
 	"crypto/ecdsa"
 	"crypto/elliptic"
 	"crypto/rand"
+	"crypto/x509"
 	"encoding/asn1"
 	"encoding/pem"
 	"errors"
@@ -27,6 +28,12 @@ func Secp256k1() elliptic.Curve {
 	return ethsecp256k1.S256()
 }
 
+// P256 returns the NIST P-256 (secp256r1) curve, for agents on platforms
+// without secp256k1.
+func P256() elliptic.Curve {
+	return elliptic.P256()
+}
+
 // GenerateECKeyPair generates an ECDSA private key using the specified curve.
 func GenerateECKeyPair(curve elliptic.Curve) (*ecdsa.PrivateKey, error) {
 	return ecdsa.GenerateKey(curve, rand.Reader)
@@ -38,6 +45,7 @@ func GenerateECKeyPair(curve elliptic.Curve) (*ecdsa.PrivateKey, error) {
 var (
 	oidPublicKeyECDSA      = asn1.ObjectIdentifier{1, 2, 840, 10045, 2, 1}
 	oidNamedCurveSecp256k1 = asn1.ObjectIdentifier{1, 3, 132, 0, 10}
+	oidNamedCurveP256      = asn1.ObjectIdentifier{1, 2, 840, 10045, 3, 1, 7}
 )
 
 type pkcs8AlgorithmIdentifier struct {
@@ -95,6 +103,13 @@ func PrivateKeyToPEM(privateKey *ecdsa.PrivateKey) ([]byte, error) {
 		return nil, errors.New("private key is nil")
 	}
 
+	if privateKey.Curve == elliptic.P256() {
+		der, err := x509.MarshalPKCS8PrivateKey(privateKey)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal PKCS#8 key: %w", err)
+		}
+		return pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), nil
+	}
 	if privateKey.Curve != Secp256k1() {
 		return nil, fmt.Errorf("unsupported curve for PKCS#8 export: %T", privateKey.Curve)
 	}
@@ -134,6 +149,9 @@ func parseECPrivateKeyDER(der []byte) (*ecdsa.PrivateKey, error) {
 		return nil, fmt.Errorf("failed to parse EC private key: %w", err)
 	}
 
+	if ecKey.NamedCurveOID.Equal(oidNamedCurveP256) {
+		return x509.ParseECPrivateKey(der)
+	}
 	if len(ecKey.NamedCurveOID) > 0 && !ecKey.NamedCurveOID.Equal(oidNamedCurveSecp256k1) {
 		return nil, fmt.Errorf("unexpected curve OID: %v", ecKey.NamedCurveOID)
 	}
@@ -174,6 +192,14 @@ func parsePKCS8PrivateKey(der []byte) (*ecdsa.PrivateKey, error) {
 		curveOID = oidNamedCurveSecp256k1
 	}
 
+	if curveOID.Equal(oidNamedCurveP256) {
+		key, err := x509.ParsePKCS8PrivateKey(der)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse P-256 private key: %w", err)
+		}
+		return key.(*ecdsa.PrivateKey), nil
+	}
+
 	if !curveOID.Equal(oidNamedCurveSecp256k1) {
 		return nil, fmt.Errorf("unexpected curve parameters OID: %v", curveOID)
 	}
@@ -204,3 +230,104 @@ func PrivateKeyFromPEM(pemBytes []byte) (*ecdsa.PrivateKey, error) {
 		return nil, fmt.Errorf("unsupported PEM block type: %s", block.Type)
 	}
 }
+
+type subjectPublicKeyInfo struct {
+	Algo      pkcs8AlgorithmIdentifier
+	PublicKey asn1.BitString
+}
+
+// PublicKeyToDER encodes a secp256k1 or P-256 public key as a DER
+// SubjectPublicKeyInfo, the format KMS and HSM APIs exchange public keys in.
+func PublicKeyToDER(publicKey *ecdsa.PublicKey) ([]byte, error) {
+	if publicKey == nil {
+		return nil, errors.New("public key is nil")
+	}
+
+	var curveOID asn1.ObjectIdentifier
+	switch publicKey.Curve {
+	case Secp256k1():
+		curveOID = oidNamedCurveSecp256k1
+	case elliptic.P256():
+		curveOID = oidNamedCurveP256
+	default:
+		return nil, fmt.Errorf("unsupported curve for public key export: %T", publicKey.Curve)
+	}
+
+	params, err := asn1.Marshal(curveOID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal curve oid: %w", err)
+	}
+	point := elliptic.Marshal(publicKey.Curve, publicKey.X, publicKey.Y)
+	return asn1.Marshal(subjectPublicKeyInfo{
+		Algo: pkcs8AlgorithmIdentifier{
+			Algorithm:  oidPublicKeyECDSA,
+			Parameters: asn1.RawValue{FullBytes: params},
+		},
+		PublicKey: asn1.BitString{Bytes: point, BitLength: len(point) * 8},
+	})
+}
+
+// PublicKeyFromDER parses a DER SubjectPublicKeyInfo holding a secp256k1 or
+// P-256 public key. Unlike x509.ParsePKIXPublicKey it accepts secp256k1.
+func PublicKeyFromDER(der []byte) (*ecdsa.PublicKey, error) {
+	var spki subjectPublicKeyInfo
+	if rest, err := asn1.Unmarshal(der, &spki); err != nil {
+		return nil, fmt.Errorf("failed to parse public key: %w", err)
+	} else if len(rest) != 0 {
+		return nil, errors.New("trailing data after public key")
+	}
+	if !spki.Algo.Algorithm.Equal(oidPublicKeyECDSA) {
+		return nil, fmt.Errorf("unexpected algorithm OID: %v", spki.Algo.Algorithm)
+	}
+
+	var curveOID asn1.ObjectIdentifier
+	if _, err := asn1.Unmarshal(spki.Algo.Parameters.FullBytes, &curveOID); err != nil {
+		return nil, fmt.Errorf("failed to parse curve parameters: %w", err)
+	}
+
+	switch {
+	case curveOID.Equal(oidNamedCurveP256):
+		key, err := x509.ParsePKIXPublicKey(der)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse P-256 public key: %w", err)
+		}
+		return key.(*ecdsa.PublicKey), nil
+	case curveOID.Equal(oidNamedCurveSecp256k1):
+		return PublicKeyFromBytes(Secp256k1(), spki.PublicKey.RightAlign())
+	default:
+		return nil, fmt.Errorf("unexpected curve parameters OID: %v", curveOID)
+	}
+}
+
+// PublicKeyFromBytes parses a SEC 1 encoded point, compressed (33 bytes) or
+// uncompressed (65 bytes), on secp256k1 or P-256.
+func PublicKeyFromBytes(curve elliptic.Curve, point []byte) (*ecdsa.PublicKey, error) {
+	switch curve {
+	case Secp256k1():
+		if len(point) == 33 {
+			key, err := ethcrypto.DecompressPubkey(point)
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse secp256k1 public key: %w", err)
+			}
+			return key, nil
+		}
+		key, err := ethcrypto.UnmarshalPubkey(point)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse secp256k1 public key: %w", err)
+		}
+		return key, nil
+	case elliptic.P256():
+		var x, y *big.Int
+		if len(point) == 33 {
+			x, y = elliptic.UnmarshalCompressed(curve, point)
+		} else {
+			x, y = elliptic.Unmarshal(curve, point)
+		}
+		if x == nil {
+			return nil, errors.New("failed to parse P-256 public key")
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	default:
+		return nil, fmt.Errorf("unsupported curve for public key: %T", curve)
+	}
+}
@@ -0,0 +1,46 @@
+package crypto
+
+import (
+	"crypto/ecdsa"
+	"crypto/x509"
+	"testing"
+)
+
+func TestPublicKeyDER(t *testing.T) {
+	for _, curve := range []struct {
+		name string
+		key  func() (*ecdsa.PrivateKey, error)
+	}{
+		{"secp256k1", func() (*ecdsa.PrivateKey, error) { return GenerateECKeyPair(Secp256k1()) }},
+		{"P-256", func() (*ecdsa.PrivateKey, error) { return GenerateECKeyPair(P256()) }},
+	} {
+		t.Run(curve.name, func(t *testing.T) {
+			key, err := curve.key()
+			if err != nil {
+				t.Fatalf("GenerateECKeyPair() error = %v", err)
+			}
+			der, err := PublicKeyToDER(&key.PublicKey)
+			if err != nil {
+				t.Fatalf("PublicKeyToDER() error = %v", err)
+			}
+			parsed, err := PublicKeyFromDER(der)
+			if err != nil {
+				t.Fatalf("PublicKeyFromDER() error = %v", err)
+			}
+			if parsed.Curve != key.Curve || parsed.X.Cmp(key.X) != 0 || parsed.Y.Cmp(key.Y) != 0 {
+				t.Error("round trip changed the public key")
+			}
+		})
+	}
+
+	// P-256 keys match the standard library encoding.
+	key, _ := GenerateECKeyPair(P256())
+	std, _ := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	if parsed, err := PublicKeyFromDER(std); err != nil || !parsed.Equal(&key.PublicKey) {
+		t.Errorf("PublicKeyFromDER(x509) = %v, %v", parsed, err)
+	}
+
+	if _, err := PublicKeyFromDER([]byte{0x30, 0x00}); err == nil {
+		t.Error("PublicKeyFromDER(garbage) error = nil, want error")
+	}
+}
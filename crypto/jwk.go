@@ -0,0 +1,228 @@
+package crypto
+
+import (
+	gocrypto "crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/bytedance/sonic"
+)
+
+// JWK key types and curve names (RFC 7518, RFC 8037 and RFC 8812).
+const (
+	jwkTypeEC         = "EC"
+	jwkTypeOKP        = "OKP"
+	jwkCurveSecp256k1 = "secp256k1"
+	jwkCurveP256      = "P-256"
+	jwkCurveEd25519   = "Ed25519"
+)
+
+// jsonWebKey is the subset of RFC 7517 used for EC and OKP keys.
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y,omitempty"`
+	D   string `json:"d,omitempty"`
+}
+
+// PrivateKeyToJWK encodes a secp256k1, P-256 or Ed25519 private key as a JWK,
+// the format other ANP SDKs exchange keys in.
+func PrivateKeyToJWK(privateKey gocrypto.Signer) ([]byte, error) {
+	var jwk *jsonWebKey
+	switch key := privateKey.(type) {
+	case *ecdsa.PrivateKey:
+		if key == nil {
+			return nil, errors.New("private key is nil")
+		}
+		var err error
+		if jwk, err = newJSONWebKey(&key.PublicKey); err != nil {
+			return nil, err
+		}
+		jwk.D = encodeCoordinate(key.D, curveByteSize(key.Curve))
+	case ed25519.PrivateKey:
+		if len(key) != ed25519.PrivateKeySize {
+			return nil, fmt.Errorf("invalid Ed25519 private key length: %d", len(key))
+		}
+		jwk = newOKPJSONWebKey(key.Public().(ed25519.PublicKey))
+		jwk.D = base64.RawURLEncoding.EncodeToString(key.Seed())
+	case nil:
+		return nil, errors.New("private key is nil")
+	default:
+		return nil, fmt.Errorf("unsupported private key type for JWK export: %T", privateKey)
+	}
+	return sonic.Marshal(jwk)
+}
+
+// PublicKeyToJWK encodes a secp256k1, P-256 or Ed25519 public key as a JWK.
+func PublicKeyToJWK(publicKey gocrypto.PublicKey) ([]byte, error) {
+	var jwk *jsonWebKey
+	switch key := publicKey.(type) {
+	case *ecdsa.PublicKey:
+		if key == nil {
+			return nil, errors.New("public key is nil")
+		}
+		var err error
+		if jwk, err = newJSONWebKey(key); err != nil {
+			return nil, err
+		}
+	case ed25519.PublicKey:
+		if len(key) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("invalid Ed25519 public key length: %d", len(key))
+		}
+		jwk = newOKPJSONWebKey(key)
+	case nil:
+		return nil, errors.New("public key is nil")
+	default:
+		return nil, fmt.Errorf("unsupported public key type for JWK export: %T", publicKey)
+	}
+	return sonic.Marshal(jwk)
+}
+
+// PrivateKeyFromJWK parses a JWK holding a secp256k1, P-256 or Ed25519 private
+// key and checks that its public part belongs to the private key. It returns
+// an *ecdsa.PrivateKey or an ed25519.PrivateKey.
+func PrivateKeyFromJWK(data []byte) (gocrypto.Signer, error) {
+	jwk, publicKey, err := parseJSONWebKey(data)
+	if err != nil {
+		return nil, err
+	}
+	if jwk.D == "" {
+		return nil, errors.New("JWK has no private key 'd'")
+	}
+	if edPublic, ok := publicKey.(ed25519.PublicKey); ok {
+		seed, err := base64.RawURLEncoding.DecodeString(jwk.D)
+		if err != nil {
+			return nil, fmt.Errorf("invalid JWK 'd': %w", err)
+		}
+		if len(seed) != ed25519.SeedSize {
+			return nil, fmt.Errorf("invalid JWK 'd': got %d bytes, want %d", len(seed), ed25519.SeedSize)
+		}
+		key := ed25519.NewKeyFromSeed(seed)
+		if !edPublic.Equal(key.Public()) {
+			return nil, errors.New("JWK public key does not match private key")
+		}
+		return key, nil
+	}
+
+	ecPublic := publicKey.(*ecdsa.PublicKey)
+	size := curveByteSize(ecPublic.Curve)
+	d, err := decodeCoordinate(jwk.D, size)
+	if err != nil {
+		return nil, fmt.Errorf("invalid JWK 'd': %w", err)
+	}
+	if d.Sign() <= 0 || d.Cmp(ecPublic.Curve.Params().N) >= 0 {
+		return nil, errors.New("invalid private key scalar")
+	}
+	x, y := ecPublic.Curve.ScalarBaseMult(d.FillBytes(make([]byte, size)))
+	if x.Cmp(ecPublic.X) != 0 || y.Cmp(ecPublic.Y) != 0 {
+		return nil, errors.New("JWK public key does not match private key")
+	}
+	return &ecdsa.PrivateKey{PublicKey: *ecPublic, D: d}, nil
+}
+
+// PublicKeyFromJWK parses a JWK holding a secp256k1, P-256 or Ed25519 public
+// key, returning an *ecdsa.PublicKey or an ed25519.PublicKey. A private JWK is
+// accepted and its private part ignored.
+func PublicKeyFromJWK(data []byte) (gocrypto.PublicKey, error) {
+	_, publicKey, err := parseJSONWebKey(data)
+	if err != nil {
+		return nil, err
+	}
+	return publicKey, nil
+}
+
+func newJSONWebKey(publicKey *ecdsa.PublicKey) (*jsonWebKey, error) {
+	var crv string
+	switch publicKey.Curve {
+	case Secp256k1():
+		crv = jwkCurveSecp256k1
+	case elliptic.P256():
+		crv = jwkCurveP256
+	default:
+		return nil, fmt.Errorf("unsupported curve for JWK export: %T", publicKey.Curve)
+	}
+	size := curveByteSize(publicKey.Curve)
+	return &jsonWebKey{
+		Kty: jwkTypeEC,
+		Crv: crv,
+		X:   encodeCoordinate(publicKey.X, size),
+		Y:   encodeCoordinate(publicKey.Y, size),
+	}, nil
+}
+
+func newOKPJSONWebKey(publicKey ed25519.PublicKey) *jsonWebKey {
+	return &jsonWebKey{
+		Kty: jwkTypeOKP,
+		Crv: jwkCurveEd25519,
+		X:   base64.RawURLEncoding.EncodeToString(publicKey),
+	}
+}
+
+// parseJSONWebKey decodes an EC or OKP JWK and its public key, an
+// *ecdsa.PublicKey or an ed25519.PublicKey.
+func parseJSONWebKey(data []byte) (*jsonWebKey, gocrypto.PublicKey, error) {
+	var jwk jsonWebKey
+	if err := sonic.Unmarshal(data, &jwk); err != nil {
+		return nil, nil, fmt.Errorf("failed to parse JWK: %w", err)
+	}
+	switch jwk.Kty {
+	case jwkTypeEC:
+	case jwkTypeOKP:
+		if jwk.Crv != jwkCurveEd25519 {
+			return nil, nil, fmt.Errorf("unsupported JWK curve: %q", jwk.Crv)
+		}
+		x, err := base64.RawURLEncoding.DecodeString(jwk.X)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid JWK 'x': %w", err)
+		}
+		if len(x) != ed25519.PublicKeySize {
+			return nil, nil, fmt.Errorf("invalid JWK 'x': got %d bytes, want %d", len(x), ed25519.PublicKeySize)
+		}
+		return &jwk, ed25519.PublicKey(x), nil
+	default:
+		return nil, nil, fmt.Errorf("unsupported JWK key type: %q", jwk.Kty)
+	}
+	var curve elliptic.Curve
+	switch jwk.Crv {
+	case jwkCurveSecp256k1:
+		curve = Secp256k1()
+	case jwkCurveP256:
+		curve = elliptic.P256()
+	default:
+		return nil, nil, fmt.Errorf("unsupported JWK curve: %q", jwk.Crv)
+	}
+	size := curveByteSize(curve)
+	x, err := decodeCoordinate(jwk.X, size)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid JWK 'x': %w", err)
+	}
+	y, err := decodeCoordinate(jwk.Y, size)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid JWK 'y': %w", err)
+	}
+	if !curve.IsOnCurve(x, y) {
+		return nil, nil, fmt.Errorf("JWK point is not on %s", jwk.Crv)
+	}
+	return &jwk, &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+}
+
+func encodeCoordinate(v *big.Int, size int) string {
+	return base64.RawURLEncoding.EncodeToString(v.FillBytes(make([]byte, size)))
+}
+
+func decodeCoordinate(s string, size int) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	if len(b) != size {
+		return nil, fmt.Errorf("got %d bytes, want %d", len(b), size)
+	}
+	return new(big.Int).SetBytes(b), nil
+}
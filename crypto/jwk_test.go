@@ -0,0 +1,166 @@
+package crypto
+
+import (
+	"bytes"
+	gocrypto "crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"testing"
+)
+
+func TestJWKRoundTrip(t *testing.T) {
+	for _, curve := range []struct {
+		name string
+		key  func() (*ecdsa.PrivateKey, error)
+	}{
+		{"secp256k1", func() (*ecdsa.PrivateKey, error) { return GenerateECKeyPair(Secp256k1()) }},
+		{"P-256", func() (*ecdsa.PrivateKey, error) { return GenerateECKeyPair(P256()) }},
+	} {
+		t.Run(curve.name, func(t *testing.T) {
+			key, err := curve.key()
+			if err != nil {
+				t.Fatalf("GenerateECKeyPair() error = %v", err)
+			}
+			privJWK, err := PrivateKeyToJWK(key)
+			if err != nil {
+				t.Fatalf("PrivateKeyToJWK() error = %v", err)
+			}
+			signer, err := PrivateKeyFromJWK(privJWK)
+			if err != nil {
+				t.Fatalf("PrivateKeyFromJWK() error = %v", err)
+			}
+			parsed, ok := signer.(*ecdsa.PrivateKey)
+			if !ok {
+				t.Fatalf("PrivateKeyFromJWK() = %T, want *ecdsa.PrivateKey", signer)
+			}
+			if parsed.Curve != key.Curve || parsed.D.Cmp(key.D) != 0 || !parsed.PublicKey.Equal(&key.PublicKey) {
+				t.Error("private round trip changed the key")
+			}
+
+			pubJWK, err := PublicKeyToJWK(&key.PublicKey)
+			if err != nil {
+				t.Fatalf("PublicKeyToJWK() error = %v", err)
+			}
+			public, err := PublicKeyFromJWK(pubJWK)
+			if err != nil {
+				t.Fatalf("PublicKeyFromJWK() error = %v", err)
+			}
+			pub, ok := public.(*ecdsa.PublicKey)
+			if !ok {
+				t.Fatalf("PublicKeyFromJWK() = %T, want *ecdsa.PublicKey", public)
+			}
+			if pub.Curve != key.Curve || pub.X.Cmp(key.X) != 0 || pub.Y.Cmp(key.Y) != 0 {
+				t.Error("public round trip changed the key")
+			}
+			if _, err := PrivateKeyFromJWK(pubJWK); err == nil {
+				t.Error("PrivateKeyFromJWK(public JWK) error = nil, want error")
+			}
+		})
+	}
+}
+
+func TestJWKRoundTrip_Ed25519(t *testing.T) {
+	publicKey, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey() error = %v", err)
+	}
+	privJWK, err := PrivateKeyToJWK(key)
+	if err != nil {
+		t.Fatalf("PrivateKeyToJWK() error = %v", err)
+	}
+	signer, err := PrivateKeyFromJWK(privJWK)
+	if err != nil {
+		t.Fatalf("PrivateKeyFromJWK() error = %v", err)
+	}
+	if parsed, ok := signer.(ed25519.PrivateKey); !ok || !parsed.Equal(key) {
+		t.Errorf("PrivateKeyFromJWK() = %T, want the original ed25519.PrivateKey", signer)
+	}
+
+	pubJWK, err := PublicKeyToJWK(publicKey)
+	if err != nil {
+		t.Fatalf("PublicKeyToJWK() error = %v", err)
+	}
+	if bytes.Contains(pubJWK, []byte(`"y"`)) || bytes.Contains(pubJWK, []byte(`"d"`)) {
+		t.Errorf("PublicKeyToJWK() = %s, want only kty, crv and x", pubJWK)
+	}
+	public, err := PublicKeyFromJWK(pubJWK)
+	if err != nil {
+		t.Fatalf("PublicKeyFromJWK() error = %v", err)
+	}
+	if parsed, ok := public.(ed25519.PublicKey); !ok || !parsed.Equal(publicKey) {
+		t.Errorf("PublicKeyFromJWK() = %T, want the original ed25519.PublicKey", public)
+	}
+	if _, err := PrivateKeyFromJWK(pubJWK); err == nil {
+		t.Error("PrivateKeyFromJWK(public JWK) error = nil, want error")
+	}
+}
+
+func TestPrivateKeyFromJWK_RFC8037(t *testing.T) {
+	// RFC 8037 Appendix A.1.
+	jwk := []byte(`{"kty":"OKP","crv":"Ed25519",
+		"d":"nWGxne_9WmC6hEr0kuwsxERJxWl7MmkZcDusAxyuf2A",
+		"x":"11qYAYKxCrfVS_7TyWQHOg7hcvPapiMlrwIaaPcHURo"}`)
+	signer, err := PrivateKeyFromJWK(jwk)
+	if err != nil {
+		t.Fatalf("PrivateKeyFromJWK() error = %v", err)
+	}
+	// RFC 8037 Appendix A.4: the signature over "eyJhbGciOiJFZERTQSJ9.RXhhbXBsZSBvZiBFZDI1NTE5IHNpZ25pbmc".
+	sig, err := signer.Sign(nil, []byte("eyJhbGciOiJFZERTQSJ9.RXhhbXBsZSBvZiBFZDI1NTE5IHNpZ25pbmc"), gocrypto.Hash(0))
+	if err != nil {
+		t.Fatalf("Sign() error = %v", err)
+	}
+	if got := base64.RawURLEncoding.EncodeToString(sig); got != "hgyY0il_MGCjP0JzlnLWG1PPOt7-09PGcvMg3AIbQR6dWbhijcNR4ki4iylGjg5BhVsPt9g7sVvpAr_MuM0KAg" {
+		t.Errorf("signature = %s", got)
+	}
+}
+
+func TestPrivateKeyFromJWK_RFC7517(t *testing.T) {
+	// RFC 7517 Appendix A.2.
+	key, err := PrivateKeyFromJWK([]byte(`{"kty":"EC","crv":"P-256",
+		"x":"MKBCTNIcKUSDii11ySs3526iDZ8AiTo7Tu6KPAqv7D4",
+		"y":"4Etl6SRW2YiLUrN5vfvVHuhp7x8PxltmWWlbbM4IFyM",
+		"d":"870MB6gfuTJ4HtUnUvYMyJpr5eUZNP4Bk43bVdj3eAE",
+		"use":"enc","kid":"1"}`))
+	if err != nil {
+		t.Fatalf("PrivateKeyFromJWK() error = %v", err)
+	}
+	if ec, ok := key.(*ecdsa.PrivateKey); !ok || ec.Curve != P256() {
+		t.Errorf("PrivateKeyFromJWK() = %T, want a P-256 *ecdsa.PrivateKey", key)
+	}
+}
+
+func TestJWK_Invalid(t *testing.T) {
+	tests := []struct {
+		name string
+		jwk  string
+	}{
+		{"not json", `not json`},
+		{"RSA", `{"kty":"RSA","n":"AQAB","e":"AQAB"}`},
+		{"unknown curve", `{"kty":"EC","crv":"P-384","x":"","y":""}`},
+		{"short x", `{"kty":"EC","crv":"P-256","x":"AAAA","y":"4Etl6SRW2YiLUrN5vfvVHuhp7x8PxltmWWlbbM4IFyM"}`},
+		{"off curve", `{"kty":"EC","crv":"P-256","x":"MKBCTNIcKUSDii11ySs3526iDZ8AiTo7Tu6KPAqv7D4","y":"MKBCTNIcKUSDii11ySs3526iDZ8AiTo7Tu6KPAqv7D4"}`},
+		{"unknown OKP curve", `{"kty":"OKP","crv":"X25519","x":"11qYAYKxCrfVS_7TyWQHOg7hcvPapiMlrwIaaPcHURo","d":"nWGxne_9WmC6hEr0kuwsxERJxWl7MmkZcDusAxyuf2A"}`},
+		{"short Ed25519 x", `{"kty":"OKP","crv":"Ed25519","x":"AAAA","d":"nWGxne_9WmC6hEr0kuwsxERJxWl7MmkZcDusAxyuf2A"}`},
+		{"short Ed25519 d", `{"kty":"OKP","crv":"Ed25519","x":"11qYAYKxCrfVS_7TyWQHOg7hcvPapiMlrwIaaPcHURo","d":"AAAA"}`},
+		{"mismatched Ed25519 d", `{"kty":"OKP","crv":"Ed25519","x":"11qYAYKxCrfVS_7TyWQHOg7hcvPapiMlrwIaaPcHURo","d":"AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAE"}`},
+		{"mismatched d", `{"kty":"EC","crv":"P-256","x":"MKBCTNIcKUSDii11ySs3526iDZ8AiTo7Tu6KPAqv7D4","y":"4Etl6SRW2YiLUrN5vfvVHuhp7x8PxltmWWlbbM4IFyM","d":"AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAE"}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := PrivateKeyFromJWK([]byte(tt.jwk)); err == nil {
+				t.Error("PrivateKeyFromJWK() error = nil, want error")
+			}
+		})
+	}
+	if _, err := PublicKeyToJWK(nil); err == nil {
+		t.Error("PublicKeyToJWK(nil) error = nil, want error")
+	}
+	if _, err := PublicKeyToJWK((*ecdsa.PublicKey)(nil)); err == nil {
+		t.Error("PublicKeyToJWK(nil *ecdsa.PublicKey) error = nil, want error")
+	}
+	if _, err := PrivateKeyToJWK(nil); err == nil {
+		t.Error("PrivateKeyToJWK(nil) error = nil, want error")
+	}
+}
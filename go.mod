@@ -3,17 +3,20 @@ module github.com/openanp/anp-go
 go 1.25.3
 
 require (
+	github.com/andybalholm/brotli v1.2.0
 	github.com/bytedance/sonic v1.14.2
+	github.com/coder/websocket v1.8.14
 	github.com/cyberphone/json-canonicalization v0.0.0-20241213102144-19d51d7fe467
 	github.com/golang-jwt/jwt/v5 v5.3.0
 	github.com/google/uuid v1.6.0
+	golang.org/x/crypto v0.43.0
 	golang.org/x/sync v0.17.0
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
 	github.com/decred/dcrd/dcrec/secp256k1/v4 v4.4.0 // indirect
 	github.com/holiman/uint256 v1.3.2 // indirect
-	golang.org/x/crypto v0.43.0 // indirect
 )
 
 require (
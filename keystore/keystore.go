@@ -0,0 +1,346 @@
+// Package keystore stores DID private keys in passphrase-encrypted JSON files,
+// in the spirit of Ethereum keystores: the key is sealed with AES-256-GCM
+// under a key derived from the passphrase with scrypt, so a copied keystore
+// file is useless without the passphrase.
+//
+//	ks := keystore.New("~/.anp/keys")
+//	_, _ = ks.Create("alice", privateKey, passphrase)
+//	key, _ := ks.Unlock("alice", passphrase)
+package keystore
+
+import (
+	"crypto"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/bytedance/sonic"
+	anpcrypto "github.com/openanp/anp-go/crypto"
+	"golang.org/x/crypto/scrypt"
+)
+
+const (
+	// StandardScryptN and StandardScryptP are the default scrypt cost
+	// parameters, taking about a second and 256MB of memory to unlock.
+	StandardScryptN = 1 << 18
+	StandardScryptP = 1
+
+	// LightScryptN and LightScryptP trade strength for speed, for tests and
+	// constrained devices.
+	LightScryptN = 1 << 12
+	LightScryptP = 6
+
+	scryptR     = 8
+	scryptDKLen = 32
+
+	version    = 1
+	fileSuffix = ".json"
+)
+
+// Key types reported in Entry.KeyType.
+const (
+	KeyTypeSecp256k1 = "secp256k1"
+	KeyTypeP256      = "P-256"
+	KeyTypeEd25519   = "Ed25519"
+)
+
+var (
+	// ErrNotFound is returned when no key is stored under a name.
+	ErrNotFound = errors.New("keystore: key not found")
+	// ErrExists is returned by Create when a key is already stored under a name.
+	ErrExists = errors.New("keystore: key already exists")
+	// ErrWrongPassphrase is returned by Unlock when the passphrase does not
+	// decrypt the key.
+	ErrWrongPassphrase = errors.New("keystore: could not decrypt key with given passphrase")
+)
+
+// Entry describes a stored key without decrypting it.
+type Entry struct {
+	Name      string
+	KeyType   string
+	Path      string
+	CreatedAt time.Time
+}
+
+// Keystore is a directory of encrypted key files, one per name.
+type Keystore struct {
+	dir     string
+	scryptN int
+	scryptP int
+}
+
+// Option configures a Keystore.
+type Option func(*Keystore)
+
+// WithScrypt sets the scrypt cost parameters for keys created from now on.
+// Existing files keep the parameters they were written with.
+func WithScrypt(n, p int) Option {
+	return func(ks *Keystore) {
+		ks.scryptN, ks.scryptP = n, p
+	}
+}
+
+// New returns the keystore in dir. The directory is created on the first
+// Create.
+func New(dir string, opts ...Option) *Keystore {
+	ks := &Keystore{dir: dir, scryptN: StandardScryptN, scryptP: StandardScryptP}
+	for _, opt := range opts {
+		opt(ks)
+	}
+	return ks
+}
+
+// keyFile is the JSON layout of a key file.
+type keyFile struct {
+	Version   int        `json:"version"`
+	Name      string     `json:"name"`
+	KeyType   string     `json:"key_type"`
+	CreatedAt time.Time  `json:"created_at"`
+	Crypto    cryptoJSON `json:"crypto"`
+}
+
+type cryptoJSON struct {
+	Cipher     string     `json:"cipher"`
+	CipherText string     `json:"ciphertext"`
+	Nonce      string     `json:"nonce"`
+	KDF        string     `json:"kdf"`
+	KDFParams  scryptJSON `json:"kdfparams"`
+}
+
+type scryptJSON struct {
+	N     int    `json:"n"`
+	R     int    `json:"r"`
+	P     int    `json:"p"`
+	DKLen int    `json:"dklen"`
+	Salt  string `json:"salt"`
+}
+
+// Create encrypts key under passphrase and stores it as name. key is an
+// *ecdsa.PrivateKey on secp256k1 or P-256, or an ed25519.PrivateKey.
+func (ks *Keystore) Create(name string, key crypto.Signer, passphrase string) (Entry, error) {
+	path, err := ks.path(name)
+	if err != nil {
+		return Entry{}, err
+	}
+	plaintext, keyType, err := marshalKey(key)
+	if err != nil {
+		return Entry{}, err
+	}
+
+	salt := make([]byte, 32)
+	if _, err := rand.Read(salt); err != nil {
+		return Entry{}, err
+	}
+	aead, err := newAEAD(passphrase, salt, ks.scryptN, scryptR, ks.scryptP)
+	if err != nil {
+		return Entry{}, err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return Entry{}, err
+	}
+
+	kf := keyFile{
+		Version:   version,
+		Name:      name,
+		KeyType:   keyType,
+		CreatedAt: time.Now().UTC().Truncate(time.Second),
+		Crypto: cryptoJSON{
+			Cipher:     "aes-256-gcm",
+			CipherText: hex.EncodeToString(aead.Seal(nil, nonce, plaintext, []byte(name))),
+			Nonce:      hex.EncodeToString(nonce),
+			KDF:        "scrypt",
+			KDFParams:  scryptJSON{N: ks.scryptN, R: scryptR, P: ks.scryptP, DKLen: scryptDKLen, Salt: hex.EncodeToString(salt)},
+		},
+	}
+	data, err := sonic.ConfigStd.MarshalIndent(kf, "", "  ")
+	if err != nil {
+		return Entry{}, fmt.Errorf("encode key file: %w", err)
+	}
+	if err := writeNew(path, data); err != nil {
+		return Entry{}, err
+	}
+	return kf.entry(path), nil
+}
+
+// Unlock decrypts the key stored as name.
+func (ks *Keystore) Unlock(name, passphrase string) (crypto.Signer, error) {
+	kf, path, err := ks.read(name)
+	if err != nil {
+		return nil, err
+	}
+	c := kf.Crypto
+	if kf.Version != version || c.Cipher != "aes-256-gcm" || c.KDF != "scrypt" || c.KDFParams.DKLen != scryptDKLen {
+		return nil, fmt.Errorf("keystore: unsupported key file %s", path)
+	}
+	salt, errSalt := hex.DecodeString(c.KDFParams.Salt)
+	nonce, errNonce := hex.DecodeString(c.Nonce)
+	ciphertext, errText := hex.DecodeString(c.CipherText)
+	if err := errors.Join(errSalt, errNonce, errText); err != nil {
+		return nil, fmt.Errorf("keystore: corrupt key file %s: %w", path, err)
+	}
+	aead, err := newAEAD(passphrase, salt, c.KDFParams.N, c.KDFParams.R, c.KDFParams.P)
+	if err != nil {
+		return nil, err
+	}
+	if len(nonce) != aead.NonceSize() {
+		return nil, fmt.Errorf("keystore: corrupt key file %s: bad nonce", path)
+	}
+	plaintext, err := aead.Open(nil, nonce, ciphertext, []byte(kf.Name))
+	if err != nil {
+		return nil, ErrWrongPassphrase
+	}
+	return parseKey(plaintext)
+}
+
+// List returns the stored keys sorted by name.
+func (ks *Keystore) List() ([]Entry, error) {
+	files, err := os.ReadDir(ks.dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("keystore: %w", err)
+	}
+	var entries []Entry
+	for _, f := range files {
+		name, ok := strings.CutSuffix(f.Name(), fileSuffix)
+		if !ok || f.IsDir() {
+			continue
+		}
+		kf, path, err := ks.read(name)
+		if err != nil {
+			continue // not a key file
+		}
+		entries = append(entries, kf.entry(path))
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name < entries[j].Name })
+	return entries, nil
+}
+
+func (kf *keyFile) entry(path string) Entry {
+	return Entry{Name: kf.Name, KeyType: kf.KeyType, Path: path, CreatedAt: kf.CreatedAt}
+}
+
+func (ks *Keystore) path(name string) (string, error) {
+	if name == "" || name != filepath.Base(name) || strings.HasPrefix(name, ".") {
+		return "", fmt.Errorf("keystore: invalid key name %q", name)
+	}
+	return filepath.Join(ks.dir, name+fileSuffix), nil
+}
+
+func (ks *Keystore) read(name string) (*keyFile, string, error) {
+	path, err := ks.path(name)
+	if err != nil {
+		return nil, "", err
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, path, fmt.Errorf("%w: %s", ErrNotFound, name)
+	}
+	if err != nil {
+		return nil, path, fmt.Errorf("keystore: %w", err)
+	}
+	var kf keyFile
+	if err := sonic.Unmarshal(data, &kf); err != nil {
+		return nil, path, fmt.Errorf("keystore: decode %s: %w", path, err)
+	}
+	if kf.Name != name {
+		return nil, path, fmt.Errorf("keystore: %s holds key %q", path, kf.Name)
+	}
+	return &kf, path, nil
+}
+
+// writeNew writes data to path through a temporary file, failing if path
+// already exists.
+func writeNew(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return fmt.Errorf("keystore: %w", err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".tmp-*")
+	if err != nil {
+		return fmt.Errorf("keystore: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("keystore: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("keystore: %w", err)
+	}
+	// Link rather than rename so an existing key is never replaced.
+	if err := os.Link(tmp.Name(), path); err != nil {
+		if errors.Is(err, os.ErrExist) {
+			return fmt.Errorf("%w: %s", ErrExists, filepath.Base(path))
+		}
+		return fmt.Errorf("keystore: %w", err)
+	}
+	return nil
+}
+
+func newAEAD(passphrase string, salt []byte, n, r, p int) (cipher.AEAD, error) {
+	derived, err := scrypt.Key([]byte(passphrase), salt, n, r, p, scryptDKLen)
+	if err != nil {
+		return nil, fmt.Errorf("keystore: derive key: %w", err)
+	}
+	block, err := aes.NewCipher(derived)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// marshalKey encodes key as PKCS#8 DER.
+func marshalKey(key crypto.Signer) ([]byte, string, error) {
+	switch k := key.(type) {
+	case *ecdsa.PrivateKey:
+		if k == nil {
+			return nil, "", errors.New("keystore: private key is nil")
+		}
+		keyType := KeyTypeSecp256k1
+		if k.Curve == anpcrypto.P256() {
+			keyType = KeyTypeP256
+		}
+		pemBytes, err := anpcrypto.PrivateKeyToPEM(k)
+		if err != nil {
+			return nil, "", fmt.Errorf("keystore: %w", err)
+		}
+		block, _ := pem.Decode(pemBytes)
+		return block.Bytes, keyType, nil
+	case ed25519.PrivateKey:
+		der, err := x509.MarshalPKCS8PrivateKey(k)
+		if err != nil {
+			return nil, "", fmt.Errorf("keystore: %w", err)
+		}
+		return der, KeyTypeEd25519, nil
+	}
+	return nil, "", fmt.Errorf("keystore: unsupported private key type %T", key)
+}
+
+// parseKey decodes a PKCS#8 DER key written by marshalKey.
+func parseKey(der []byte) (crypto.Signer, error) {
+	if key, err := x509.ParsePKCS8PrivateKey(der); err == nil {
+		if signer, ok := key.(crypto.Signer); ok {
+			return signer, nil
+		}
+	}
+	// x509 does not know secp256k1.
+	key, err := anpcrypto.PrivateKeyFromPEM(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}))
+	if err != nil {
+		return nil, fmt.Errorf("keystore: decode key: %w", err)
+	}
+	return key, nil
+}
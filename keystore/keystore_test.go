@@ -0,0 +1,107 @@
+package keystore
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	anpcrypto "github.com/openanp/anp-go/crypto"
+)
+
+func newTestKeystore(t *testing.T) *Keystore {
+	return New(filepath.Join(t.TempDir(), "keys"), WithScrypt(LightScryptN, LightScryptP))
+}
+
+type publicKey interface {
+	Equal(crypto.PublicKey) bool
+}
+
+func TestCreateUnlock(t *testing.T) {
+	secp256k1, _ := anpcrypto.GenerateECKeyPair(anpcrypto.Secp256k1())
+	p256, _ := anpcrypto.GenerateECKeyPair(anpcrypto.P256())
+	_, edKey, _ := ed25519.GenerateKey(rand.Reader)
+
+	tests := []struct {
+		name    string
+		key     crypto.Signer
+		keyType string
+	}{
+		{"secp256k1", secp256k1, KeyTypeSecp256k1},
+		{"p256", p256, KeyTypeP256},
+		{"ed25519", edKey, KeyTypeEd25519},
+	}
+
+	ks := newTestKeystore(t)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entry, err := ks.Create(tt.name, tt.key, "correct horse")
+			if err != nil {
+				t.Fatalf("Create() error = %v", err)
+			}
+			if entry.KeyType != tt.keyType {
+				t.Errorf("Create() KeyType = %q, want %q", entry.KeyType, tt.keyType)
+			}
+			info, err := os.Stat(entry.Path)
+			if err != nil {
+				t.Fatalf("Stat() error = %v", err)
+			}
+			if perm := info.Mode().Perm(); perm != 0o600 {
+				t.Errorf("key file mode = %v, want 0600", perm)
+			}
+
+			key, err := ks.Unlock(tt.name, "correct horse")
+			if err != nil {
+				t.Fatalf("Unlock() error = %v", err)
+			}
+			if !key.Public().(publicKey).Equal(tt.key.Public()) {
+				t.Error("Unlock() returned a different key")
+			}
+
+			if _, err := ks.Unlock(tt.name, "wrong"); !errors.Is(err, ErrWrongPassphrase) {
+				t.Errorf("Unlock(wrong passphrase) error = %v, want ErrWrongPassphrase", err)
+			}
+		})
+	}
+
+	entries, err := ks.List()
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	var names []string
+	for _, e := range entries {
+		names = append(names, e.Name)
+	}
+	if want := []string{"ed25519", "p256", "secp256k1"}; len(names) != len(want) || names[0] != want[0] || names[1] != want[1] || names[2] != want[2] {
+		t.Errorf("List() names = %v, want %v", names, want)
+	}
+}
+
+func TestCreate_Errors(t *testing.T) {
+	ks := newTestKeystore(t)
+	key, _ := anpcrypto.GenerateECKeyPair(anpcrypto.Secp256k1())
+	if _, err := ks.Create("agent", key, "pw"); err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	if _, err := ks.Create("agent", key, "pw"); !errors.Is(err, ErrExists) {
+		t.Errorf("Create(duplicate) error = %v, want ErrExists", err)
+	}
+	for _, name := range []string{"", "../agent", "a/b", ".hidden"} {
+		if _, err := ks.Create(name, key, "pw"); err == nil {
+			t.Errorf("Create(%q) error = nil, want error", name)
+		}
+	}
+	if _, err := ks.Unlock("missing", "pw"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Unlock(missing) error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestList_Empty(t *testing.T) {
+	entries, err := newTestKeystore(t).List()
+	if err != nil || len(entries) != 0 {
+		t.Errorf("List() = %v, %v, want empty", entries, err)
+	}
+}
@@ -0,0 +1,159 @@
+package metrics
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/openanp/anp-go/anp_auth"
+	"github.com/openanp/anp-go/anp_crawler"
+	"github.com/openanp/anp-go/session"
+)
+
+// Transport returns an http.RoundTripper that records every request in the
+// client request metrics. A nil base uses http.DefaultTransport.
+func (m *Metrics) Transport(base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &transport{m: m, next: base}
+}
+
+// HTTPClient returns a copy of c (or a new client when c is nil) whose
+// transport is instrumented, for session.HTTPConfig.Client or
+// anp_crawler.WithHTTPClient.
+func (m *Metrics) HTTPClient(c *http.Client) *http.Client {
+	out := &http.Client{}
+	if c != nil {
+		*out = *c
+	}
+	out.Transport = m.Transport(out.Transport)
+	return out
+}
+
+type transport struct {
+	m    *Metrics
+	next http.RoundTripper
+}
+
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	host := req.URL.Hostname()
+	start := time.Now()
+	resp, err := t.next.RoundTrip(req)
+	t.m.requestDuration.WithLabelValues(host, req.Method).Observe(time.Since(start).Seconds())
+
+	code := "error"
+	if err == nil {
+		code = strconv.Itoa(resp.StatusCode)
+	}
+	t.m.requests.WithLabelValues(host, req.Method, code).Inc()
+	return resp, err
+}
+
+// ExecuteTool runs session.ExecuteTool and records the call.
+func (m *Metrics) ExecuteTool(ctx context.Context, doc *session.Document, method string, params map[string]any) (map[string]any, error) {
+	start := time.Now()
+	result, err := session.ExecuteTool(ctx, doc, method, params)
+	m.toolDuration.WithLabelValues(method).Observe(time.Since(start).Seconds())
+	m.toolCalls.WithLabelValues(method, outcome(err)).Inc()
+	return result, err
+}
+
+// CrawlerRecorder returns an anp_crawler.MetricsRecorder that records
+// crawler requests, latency and retries per host, for anp_crawler.WithMetrics.
+func (m *Metrics) CrawlerRecorder() anp_crawler.MetricsRecorder {
+	return crawlerRecorder{m}
+}
+
+type crawlerRecorder struct{ m *Metrics }
+
+func (r crawlerRecorder) ObserveRequest(host, method string, code int, elapsed time.Duration) {
+	label := "error"
+	if code != 0 {
+		label = strconv.Itoa(code)
+	}
+	r.m.crawlerRequests.WithLabelValues(host, method, label).Inc()
+	r.m.crawlerDuration.WithLabelValues(host, method).Observe(elapsed.Seconds())
+}
+
+func (r crawlerRecorder) ObserveRetry(host, reason string) {
+	r.m.crawlerRetries.WithLabelValues(host, reason).Inc()
+}
+
+// Middleware behaves like anp_auth.Middleware and records each authorization
+// check with its scheme, outcome and the status returned on failure.
+func (m *Metrics) Middleware(verifier *anp_auth.DidWbaVerifier) func(http.Handler) http.Handler {
+	authenticate := anp_auth.Middleware(verifier)
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			scheme := authScheme(r.Header.Get(anp_auth.AuthorizationHeader))
+			start := time.Now()
+			passed := false
+			rec := &statusRecorder{ResponseWriter: w}
+
+			authenticate(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				passed = true
+				m.verifyDuration.WithLabelValues(scheme, OutcomeSuccess).Observe(time.Since(start).Seconds())
+				m.verifications.WithLabelValues(scheme, OutcomeSuccess, "").Inc()
+				next.ServeHTTP(rec.ResponseWriter, r)
+			})).ServeHTTP(rec, r)
+
+			if !passed {
+				m.verifyDuration.WithLabelValues(scheme, OutcomeFailure).Observe(time.Since(start).Seconds())
+				m.verifications.WithLabelValues(scheme, OutcomeFailure, strconv.Itoa(rec.status)).Inc()
+			}
+		})
+	}
+}
+
+// ObserveVerification records an authorization check made outside Middleware,
+// e.g. with DidWbaVerifier.VerifyAuthHeaderContext.
+func (m *Metrics) ObserveVerification(authorization string, err error, elapsed time.Duration) {
+	scheme, result := authScheme(authorization), outcome(err)
+	m.verifyDuration.WithLabelValues(scheme, result).Observe(elapsed.Seconds())
+	code := ""
+	if err != nil {
+		code = strconv.Itoa(anp_auth.GetStatusCode(err, http.StatusUnauthorized))
+	}
+	m.verifications.WithLabelValues(scheme, result, code).Inc()
+}
+
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(code int) {
+	if r.status == 0 {
+		r.status = code
+	}
+	r.ResponseWriter.WriteHeader(code)
+}
+
+func (r *statusRecorder) Write(b []byte) (int, error) {
+	if r.status == 0 {
+		r.status = http.StatusOK
+	}
+	return r.ResponseWriter.Write(b)
+}
+
+func outcome(err error) string {
+	if err != nil {
+		return OutcomeFailure
+	}
+	return OutcomeSuccess
+}
+
+func authScheme(authorization string) string {
+	switch {
+	case authorization == "":
+		return "none"
+	case strings.HasPrefix(authorization, anp_auth.BearerScheme):
+		return "bearer"
+	case strings.HasPrefix(authorization, anp_auth.DIDWbaScheme):
+		return "didwba"
+	}
+	return "unknown"
+}
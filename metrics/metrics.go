@@ -0,0 +1,189 @@
+// Package metrics collects Prometheus metrics for anp-go components.
+//
+// It is a separate module so the core SDK does not depend on the Prometheus
+// client. A Metrics value owns a registry and the collectors for outbound
+// requests, cache lookups, authentication and tool calls; Handler serves them in
+// the Prometheus exposition format. A crawler client reports to the same
+// registry through CrawlerRecorder:
+//
+//	m := metrics.Default()
+//	sess, _ := session.New(session.Config{
+//		Authenticator: auth,
+//		HTTP:          session.HTTPConfig{Client: m.HTTPClient(nil)},
+//	})
+//	http.Handle("/metrics", m.Handler())
+//	http.Handle("/rpc", m.Middleware(verifier)(rpcHandler))
+//	crawler := anp_crawler.NewClient(auth, anp_crawler.WithMetrics(m.CrawlerRecorder()))
+package metrics
+
+import (
+	"net/http"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// DefaultNamespace prefixes every metric name.
+const DefaultNamespace = "anp"
+
+// Label values for outcome labels.
+const (
+	OutcomeSuccess = "success"
+	OutcomeFailure = "failure"
+)
+
+// Option configures Metrics.
+type Option func(*config)
+
+type config struct {
+	registry  *prometheus.Registry
+	namespace string
+	buckets   []float64
+}
+
+// WithRegistry registers the collectors with reg instead of a new registry,
+// e.g. to share one registry with application metrics.
+func WithRegistry(reg *prometheus.Registry) Option {
+	return func(c *config) {
+		if reg != nil {
+			c.registry = reg
+		}
+	}
+}
+
+// WithNamespace overrides the metric name prefix (default "anp").
+func WithNamespace(namespace string) Option {
+	return func(c *config) { c.namespace = namespace }
+}
+
+// WithBuckets sets the latency histogram buckets in seconds.
+func WithBuckets(buckets ...float64) Option {
+	return func(c *config) {
+		if len(buckets) > 0 {
+			c.buckets = buckets
+		}
+	}
+}
+
+// Metrics holds the collectors for all components. Its methods are safe for
+// concurrent use.
+type Metrics struct {
+	registry *prometheus.Registry
+
+	requests        *prometheus.CounterVec
+	requestDuration *prometheus.HistogramVec
+	cacheLookups    *prometheus.CounterVec
+	verifications   *prometheus.CounterVec
+	verifyDuration  *prometheus.HistogramVec
+	toolCalls       *prometheus.CounterVec
+	toolDuration    *prometheus.HistogramVec
+
+	crawlerRequests *prometheus.CounterVec
+	crawlerDuration *prometheus.HistogramVec
+	crawlerRetries  *prometheus.CounterVec
+}
+
+var (
+	defaultOnce    sync.Once
+	defaultMetrics *Metrics
+)
+
+// Default returns the process-wide Metrics, whose registry also carries the
+// Go runtime and process collectors.
+func Default() *Metrics {
+	defaultOnce.Do(func() {
+		reg := prometheus.NewRegistry()
+		reg.MustRegister(
+			collectors.NewGoCollector(),
+			collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		)
+		defaultMetrics = New(WithRegistry(reg))
+	})
+	return defaultMetrics
+}
+
+// New creates and registers a set of collectors. It panics if the registry
+// already holds collectors with the same names, like prometheus.MustRegister.
+func New(opts ...Option) *Metrics {
+	cfg := &config{namespace: DefaultNamespace, buckets: prometheus.DefBuckets}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	if cfg.registry == nil {
+		cfg.registry = prometheus.NewRegistry()
+	}
+	ns := cfg.namespace
+
+	m := &Metrics{
+		registry: cfg.registry,
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: ns, Subsystem: "client", Name: "requests_total",
+			Help: "Outbound HTTP requests by host, method and status code (\"error\" for transport failures).",
+		}, []string{"host", "method", "code"}),
+		requestDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: ns, Subsystem: "client", Name: "request_duration_seconds",
+			Help: "Latency of outbound HTTP requests.", Buckets: cfg.buckets,
+		}, []string{"host", "method"}),
+		cacheLookups: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: ns, Subsystem: "session", Name: "cache_lookups_total",
+			Help: "Session cache lookups by cache name and result (hit or miss).",
+		}, []string{"cache", "result"}),
+		verifications: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: ns, Subsystem: "auth", Name: "verifications_total",
+			Help: "Authorization checks by scheme, outcome and HTTP status.",
+		}, []string{"scheme", "outcome", "code"}),
+		verifyDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: ns, Subsystem: "auth", Name: "verification_duration_seconds",
+			Help: "Latency of authorization checks.", Buckets: cfg.buckets,
+		}, []string{"scheme", "outcome"}),
+		toolCalls: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: ns, Subsystem: "tool", Name: "calls_total",
+			Help: "Tool calls by method and outcome.",
+		}, []string{"method", "outcome"}),
+		toolDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: ns, Subsystem: "tool", Name: "call_duration_seconds",
+			Help: "Latency of tool calls.", Buckets: cfg.buckets,
+		}, []string{"method"}),
+		crawlerRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: ns, Subsystem: "crawler", Name: "requests_total",
+			Help: "Crawler HTTP attempts by host, method and status code (\"error\" for transport failures).",
+		}, []string{"host", "method", "code"}),
+		crawlerDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: ns, Subsystem: "crawler", Name: "request_duration_seconds",
+			Help: "Latency of crawler HTTP attempts.", Buckets: cfg.buckets,
+		}, []string{"host", "method"}),
+		crawlerRetries: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: ns, Subsystem: "crawler", Name: "retries_total",
+			Help: "Crawler request retries by host and reason.",
+		}, []string{"host", "reason"}),
+	}
+	m.registry.MustRegister(
+		m.requests, m.requestDuration,
+		m.cacheLookups,
+		m.verifications, m.verifyDuration,
+		m.toolCalls, m.toolDuration,
+		m.crawlerRequests, m.crawlerDuration, m.crawlerRetries,
+	)
+	return m
+}
+
+// Registry returns the registry holding the collectors.
+func (m *Metrics) Registry() *prometheus.Registry { return m.registry }
+
+// Handler serves the registry in the Prometheus exposition format, ready to be
+// mounted at /metrics.
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{Registry: m.registry})
+}
+
+// ObserveCacheLookup records a lookup in the named cache. Session caches call
+// it for every lookup; custom caches may too.
+func (m *Metrics) ObserveCacheLookup(cache string, hit bool) {
+	result := "miss"
+	if hit {
+		result = "hit"
+	}
+	m.cacheLookups.WithLabelValues(cache, result).Inc()
+}
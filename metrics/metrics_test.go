@@ -0,0 +1,156 @@
+package metrics
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/openanp/anp-go/anp_auth"
+	"github.com/openanp/anp-go/anp_crawler"
+	"github.com/openanp/anp-go/anptest"
+	"github.com/openanp/anp-go/session"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+type addParams struct {
+	A int `json:"a"`
+	B int `json:"b"`
+}
+
+func TestSessionRequestsAndToolCalls(t *testing.T) {
+	m := New()
+	caller := anptest.NewIdentity(t, "client.example.com")
+	srv := anptest.NewServer(t,
+		anptest.WithMethod("add", func(p addParams) (int, error) { return p.A + p.B, nil }),
+		anptest.WithDIDAuth(caller),
+	)
+
+	sess, err := session.New(session.Config{
+		Authenticator: caller.Authenticator,
+		HTTP:          session.HTTPConfig{Client: m.HTTPClient(nil)},
+	})
+	if err != nil {
+		t.Fatalf("session.New() error = %v", err)
+	}
+	ctx := context.Background()
+	doc, err := sess.Fetch(ctx, srv.OpenRPCURL())
+	if err != nil {
+		t.Fatalf("Fetch() error = %v", err)
+	}
+	if _, err := m.ExecuteTool(ctx, doc, "add", map[string]any{"a": 1, "b": 2}); err != nil {
+		t.Fatalf("ExecuteTool() error = %v", err)
+	}
+	if _, err := m.ExecuteTool(ctx, doc, "missing", nil); err == nil {
+		t.Fatal("ExecuteTool(missing) succeeded")
+	}
+	m.ObserveCacheLookup("documents", true)
+
+	host := "127.0.0.1"
+	if got := testutil.ToFloat64(m.requests.WithLabelValues(host, http.MethodGet, "200")); got != 1 {
+		t.Errorf("GET 200 requests = %v, want 1", got)
+	}
+	if got := testutil.ToFloat64(m.requests.WithLabelValues(host, http.MethodPost, "200")); got != 1 {
+		t.Errorf("POST 200 requests = %v, want 1", got)
+	}
+	if got := testutil.ToFloat64(m.toolCalls.WithLabelValues("add", OutcomeSuccess)); got != 1 {
+		t.Errorf("successful add calls = %v, want 1", got)
+	}
+	if got := testutil.ToFloat64(m.toolCalls.WithLabelValues("missing", OutcomeFailure)); got != 1 {
+		t.Errorf("failed missing calls = %v, want 1", got)
+	}
+
+	rec := httptest.NewRecorder()
+	m.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	for _, name := range []string{
+		"anp_client_requests_total",
+		"anp_client_request_duration_seconds_bucket",
+		"anp_session_cache_lookups_total{cache=\"documents\",result=\"hit\"} 1",
+		"anp_tool_calls_total",
+		"anp_tool_call_duration_seconds_count",
+	} {
+		if !strings.Contains(rec.Body.String(), name) {
+			t.Errorf("/metrics missing %s", name)
+		}
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	m := New(WithNamespace("test"))
+	caller := anptest.NewIdentity(t, "client.example.com")
+	srv := httptest.NewServer(m.Middleware(anptest.NewVerifier(t, caller))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "ok")
+	})))
+	defer srv.Close()
+
+	client := anp_auth.NewClient(caller.Authenticator)
+	for range 2 {
+		resp, err := client.Get(srv.URL)
+		if err != nil {
+			t.Fatalf("Get() error = %v", err)
+		}
+		resp.Body.Close()
+	}
+	resp, err := http.Get(srv.URL)
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	resp.Body.Close()
+
+	tests := []struct {
+		scheme, outcome, code string
+		want                  float64
+	}{
+		{"didwba", OutcomeSuccess, "", 1},
+		{"bearer", OutcomeSuccess, "", 1},
+		{"none", OutcomeFailure, "401", 1},
+	}
+	for _, tt := range tests {
+		if got := testutil.ToFloat64(m.verifications.WithLabelValues(tt.scheme, tt.outcome, tt.code)); got != tt.want {
+			t.Errorf("verifications{%s,%s,%s} = %v, want %v", tt.scheme, tt.outcome, tt.code, got, tt.want)
+		}
+	}
+	if n := testutil.CollectAndCount(m.verifyDuration, "test_auth_verification_duration_seconds"); n != 3 {
+		t.Errorf("duration series = %d, want 3", n)
+	}
+}
+
+func TestCrawlerRecorder(t *testing.T) {
+	m := New()
+	caller := anptest.NewIdentity(t, "client.example.com")
+	var calls int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls == 1 {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		io.WriteString(w, `{}`)
+	}))
+	defer srv.Close()
+
+	crawler := anp_crawler.NewClient(caller.Authenticator, anp_crawler.WithMetrics(m.CrawlerRecorder()))
+	resp, err := crawler.Fetch(context.Background(), http.MethodGet, srv.URL+"/ad.json", nil, nil)
+	if err != nil {
+		t.Fatalf("Fetch() error = %v", err)
+	}
+	resp.Release()
+
+	host := "127.0.0.1"
+	for code, want := range map[string]float64{"401": 1, "200": 1} {
+		if got := testutil.ToFloat64(m.crawlerRequests.WithLabelValues(host, http.MethodGet, code)); got != want {
+			t.Errorf("crawler requests{%s} = %v, want %v", code, got, want)
+		}
+	}
+	if got := testutil.ToFloat64(m.crawlerRetries.WithLabelValues(host, anp_crawler.RetryReasonUnauthorized)); got != 1 {
+		t.Errorf("crawler retries = %v, want 1", got)
+	}
+
+	rec := httptest.NewRecorder()
+	m.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if !strings.Contains(rec.Body.String(), "anp_crawler_request_duration_seconds_count") {
+		t.Error("/metrics missing anp_crawler_request_duration_seconds")
+	}
+}
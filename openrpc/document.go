@@ -0,0 +1,87 @@
+// Package openrpc generates OpenRPC documents from Go handler functions so that
+// published ANP interfaces stay in sync with the server code implementing them.
+package openrpc
+
+import "github.com/bytedance/sonic"
+
+// Version is the OpenRPC specification version emitted by the generator.
+const Version = "1.2.6"
+
+// Document is an OpenRPC document.
+type Document struct {
+	OpenRPC    string      `json:"openrpc"`
+	Info       Info        `json:"info"`
+	Servers    []Server    `json:"servers,omitempty"`
+	Methods    []Method    `json:"methods"`
+	Components *Components `json:"components,omitempty"`
+}
+
+// Info carries the document metadata.
+type Info struct {
+	Title       string `json:"title"`
+	Version     string `json:"version"`
+	Description string `json:"description,omitempty"`
+}
+
+// Server is an endpoint serving the methods of the document.
+type Server struct {
+	Name        string `json:"name,omitempty"`
+	URL         string `json:"url"`
+	Description string `json:"description,omitempty"`
+}
+
+// Method describes a single JSON-RPC method.
+type Method struct {
+	Name           string               `json:"name"`
+	Summary        string               `json:"summary,omitempty"`
+	Description    string               `json:"description,omitempty"`
+	ParamStructure string               `json:"paramStructure,omitempty"`
+	Params         []*ContentDescriptor `json:"params"`
+	Result         *ContentDescriptor   `json:"result,omitempty"`
+}
+
+// ContentDescriptor describes a named parameter or result.
+type ContentDescriptor struct {
+	Name        string  `json:"name"`
+	Description string  `json:"description,omitempty"`
+	Required    bool    `json:"required,omitempty"`
+	Schema      *Schema `json:"schema"`
+}
+
+// Components holds reusable schemas referenced from methods.
+type Components struct {
+	Schemas map[string]*Schema `json:"schemas,omitempty"`
+}
+
+// Schema is the subset of JSON Schema used to describe Go types.
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+	Enum                 []any              `json:"enum,omitempty"`
+	Minimum              *float64           `json:"minimum,omitempty"`
+}
+
+// JSON serialises the document.
+func (d *Document) JSON() ([]byte, error) {
+	return sonic.Marshal(d)
+}
+
+// Map returns the document as a generic map, suitable for embedding as inline
+// Agent Description interface content.
+func (d *Document) Map() (map[string]any, error) {
+	raw, err := d.JSON()
+	if err != nil {
+		return nil, err
+	}
+	var out map[string]any
+	if err := sonic.Unmarshal(raw, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
@@ -0,0 +1,367 @@
+package openrpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"sync"
+	"unicode"
+
+	"github.com/openanp/anp-go/anp_ad"
+)
+
+var (
+	contextType = reflect.TypeOf((*context.Context)(nil)).Elem()
+	errorType   = reflect.TypeOf((*error)(nil)).Elem()
+)
+
+// MethodOption customises a registered method.
+type MethodOption func(*Method)
+
+// WithSummary sets the method summary.
+func WithSummary(summary string) MethodOption {
+	return func(m *Method) { m.Summary = summary }
+}
+
+// WithDescription sets the method description.
+func WithDescription(description string) MethodOption {
+	return func(m *Method) { m.Description = description }
+}
+
+// WithResultName overrides the result descriptor name (default "result").
+func WithResultName(name string) MethodOption {
+	return func(m *Method) {
+		if m.Result != nil {
+			m.Result.Name = name
+		}
+	}
+}
+
+// Generator collects handler registrations and emits an OpenRPC document.
+type Generator struct {
+	info    Info
+	servers []Server
+
+	mu      sync.Mutex
+	methods map[string]Method
+}
+
+// NewGenerator creates a Generator with the given document metadata.
+func NewGenerator(info Info) *Generator {
+	if info.Version == "" {
+		info.Version = "1.0.0"
+	}
+	return &Generator{
+		info:    info,
+		methods: make(map[string]Method),
+	}
+}
+
+// AddServer adds an endpoint to the generated document.
+func (g *Generator) AddServer(server Server) *Generator {
+	g.servers = append(g.servers, server)
+	return g
+}
+
+// Register describes fn under the given method name.
+//
+// fn must have the shape func([context.Context,] [P]) ([R,] error) where P, if present,
+// is a struct whose fields become the named params of the method.
+func (g *Generator) Register(name string, fn any, opts ...MethodOption) error {
+	method, err := Describe(name, fn, opts...)
+	if err != nil {
+		return err
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if _, exists := g.methods[name]; exists {
+		return fmt.Errorf("openrpc: method %q already registered", name)
+	}
+	g.methods[name] = method
+	return nil
+}
+
+// AddMethod adds a hand-written method description, for methods whose params
+// are not described by a Go struct.
+func (g *Generator) AddMethod(method Method) error {
+	if method.Name == "" {
+		return errors.New("openrpc: method name is required")
+	}
+	if method.Params == nil {
+		method.Params = []*ContentDescriptor{}
+	}
+	for _, p := range method.Params {
+		if p == nil || p.Name == "" {
+			return fmt.Errorf("openrpc: method %q has an unnamed param", method.Name)
+		}
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if _, exists := g.methods[method.Name]; exists {
+		return fmt.Errorf("openrpc: method %q already registered", method.Name)
+	}
+	g.methods[method.Name] = method
+	return nil
+}
+
+// RegisterService registers every exported method of receiver that matches the
+// handler shape accepted by Register. Method names are converted to lowerCamelCase.
+func (g *Generator) RegisterService(receiver any) error {
+	v := reflect.ValueOf(receiver)
+	t := v.Type()
+
+	registered := 0
+	for i := 0; i < t.NumMethod(); i++ {
+		m := t.Method(i)
+		if !m.IsExported() {
+			continue
+		}
+		fn := v.Method(i).Interface()
+		if _, err := inspectHandler(reflect.TypeOf(fn)); err != nil {
+			continue
+		}
+		if err := g.Register(LowerCamel(m.Name), fn); err != nil {
+			return err
+		}
+		registered++
+	}
+
+	if registered == 0 {
+		return fmt.Errorf("openrpc: %T has no methods with a handler signature", receiver)
+	}
+	return nil
+}
+
+// Method returns the description of a registered method.
+func (g *Generator) Method(name string) (Method, bool) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	m, ok := g.methods[name]
+	return m, ok
+}
+
+// Document builds the OpenRPC document with methods sorted by name.
+func (g *Generator) Document() *Document {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	methods := make([]Method, 0, len(g.methods))
+	for _, m := range g.methods {
+		methods = append(methods, m)
+	}
+	sort.Slice(methods, func(i, j int) bool { return methods[i].Name < methods[j].Name })
+
+	return &Document{
+		OpenRPC: Version,
+		Info:    g.info,
+		Servers: append([]Server(nil), g.servers...),
+		Methods: methods,
+	}
+}
+
+// Interface returns the Agent Description interface entry pointing at the document
+// published at url.
+func (g *Generator) Interface(url, description string) anp_ad.Interface {
+	if description == "" {
+		description = g.info.Description
+	}
+	return anp_ad.Interface{
+		Type:        anp_ad.InterfaceTypeStructured,
+		Protocol:    anp_ad.ProtocolOpenRPC,
+		URL:         url,
+		Description: description,
+	}
+}
+
+// InlineInterface returns an Agent Description interface entry embedding the document.
+func (g *Generator) InlineInterface(description string) (anp_ad.Interface, error) {
+	content, err := g.Document().Map()
+	if err != nil {
+		return anp_ad.Interface{}, fmt.Errorf("openrpc: encode document: %w", err)
+	}
+	if description == "" {
+		description = g.info.Description
+	}
+	return anp_ad.Interface{
+		Type:        anp_ad.InterfaceTypeStructured,
+		Protocol:    anp_ad.ProtocolOpenRPC,
+		Description: description,
+		Content:     content,
+	}, nil
+}
+
+// Describe builds the OpenRPC method description for fn without registering it.
+func Describe(name string, fn any, opts ...MethodOption) (Method, error) {
+	if name == "" {
+		return Method{}, errors.New("openrpc: method name cannot be empty")
+	}
+	if fn == nil {
+		return Method{}, fmt.Errorf("openrpc: handler for %q is nil", name)
+	}
+
+	sig, err := inspectHandler(reflect.TypeOf(fn))
+	if err != nil {
+		return Method{}, fmt.Errorf("openrpc: method %q: %w", name, err)
+	}
+
+	method := Method{
+		Name:           name,
+		ParamStructure: "by-name",
+		Params:         []*ContentDescriptor{},
+	}
+
+	if sig.Params != nil {
+		schema := SchemaFor(sig.Params)
+		required := make(map[string]bool, len(schema.Required))
+		for _, r := range schema.Required {
+			required[r] = true
+		}
+		names := make([]string, 0, len(schema.Properties))
+		for propName := range schema.Properties {
+			names = append(names, propName)
+		}
+		// Preserve struct field order for readability.
+		order := fieldOrder(sig.Params)
+		sort.SliceStable(names, func(i, j int) bool { return order[names[i]] < order[names[j]] })
+
+		for _, propName := range names {
+			prop := schema.Properties[propName]
+			method.Params = append(method.Params, &ContentDescriptor{
+				Name:        propName,
+				Description: prop.Description,
+				Required:    required[propName],
+				Schema:      prop,
+			})
+		}
+	}
+
+	if sig.Result != nil {
+		method.Result = &ContentDescriptor{Name: "result", Schema: SchemaFor(sig.Result)}
+	}
+
+	for _, opt := range opts {
+		opt(&method)
+	}
+	return method, nil
+}
+
+// HandlerSignature is the parsed shape of a handler function.
+type HandlerSignature struct {
+	// HasContext reports whether the first argument is a context.Context.
+	HasContext bool
+	// Params is the params struct type, or nil if the handler takes no params.
+	Params reflect.Type
+	// Result is the result type, or nil if the handler only returns an error.
+	Result reflect.Type
+}
+
+// InspectHandler validates fn's signature and returns its parsed shape.
+func InspectHandler(fn any) (HandlerSignature, error) {
+	if fn == nil {
+		return HandlerSignature{}, errors.New("handler is nil")
+	}
+	return inspectHandler(reflect.TypeOf(fn))
+}
+
+func inspectHandler(t reflect.Type) (HandlerSignature, error) {
+	var sig HandlerSignature
+	if t.Kind() != reflect.Func {
+		return sig, fmt.Errorf("handler must be a function, got %s", t)
+	}
+	if t.IsVariadic() {
+		return sig, errors.New("handler must not be variadic")
+	}
+
+	in := 0
+	if t.NumIn() > in && t.In(in) == contextType {
+		sig.HasContext = true
+		in++
+	}
+	switch t.NumIn() - in {
+	case 0:
+	case 1:
+		params := t.In(in)
+		base := params
+		if base.Kind() == reflect.Pointer {
+			base = base.Elem()
+		}
+		if base.Kind() != reflect.Struct {
+			return sig, fmt.Errorf("params must be a struct or pointer to struct, got %s", params)
+		}
+		sig.Params = params
+	default:
+		return sig, errors.New("handler accepts at most a context and one params struct")
+	}
+
+	switch t.NumOut() {
+	case 1:
+		if t.Out(0) != errorType {
+			return sig, errors.New("single return value must be error")
+		}
+	case 2:
+		if t.Out(1) != errorType {
+			return sig, errors.New("second return value must be error")
+		}
+		sig.Result = t.Out(0)
+	default:
+		return sig, errors.New("handler must return (result, error) or error")
+	}
+
+	return sig, nil
+}
+
+func fieldOrder(t reflect.Type) map[string]int {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	order := make(map[string]int)
+	var walk func(reflect.Type)
+	walk = func(st reflect.Type) {
+		for i := 0; i < st.NumField(); i++ {
+			f := st.Field(i)
+			name, _, skip := jsonFieldName(f)
+			if skip {
+				continue
+			}
+			if f.Anonymous && name == "" {
+				et := f.Type
+				if et.Kind() == reflect.Pointer {
+					et = et.Elem()
+				}
+				if et.Kind() == reflect.Struct {
+					walk(et)
+					continue
+				}
+			}
+			if name == "" {
+				name = f.Name
+			}
+			if _, seen := order[name]; !seen {
+				order[name] = len(order)
+			}
+		}
+	}
+	walk(t)
+	return order
+}
+
+// LowerCamel converts a Go identifier such as "SearchHotels" into "searchHotels".
+func LowerCamel(name string) string {
+	if name == "" {
+		return name
+	}
+	runes := []rune(name)
+	// Lower the leading run of upper-case letters, keeping the last one of an
+	// acronym followed by a lower-case letter ("HTTPServer" -> "httpServer").
+	for i := 0; i < len(runes) && unicode.IsUpper(runes[i]); i++ {
+		if i > 0 && i+1 < len(runes) && unicode.IsLower(runes[i+1]) {
+			break
+		}
+		runes[i] = unicode.ToLower(runes[i])
+	}
+	return string(runes)
+}
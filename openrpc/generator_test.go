@@ -0,0 +1,184 @@
+package openrpc
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type searchParams struct {
+	City     string    `json:"cityName" description:"City to search in"`
+	CheckIn  time.Time `json:"checkInDate"`
+	PageSize int       `json:"pageSize,omitempty"`
+	Brands   []string  `json:"brands,omitempty"`
+	Internal string    `json:"-"`
+}
+
+type hotel struct {
+	ID     uint64            `json:"id"`
+	Name   string            `json:"name" description:"Display name"`
+	Rating *float64          `json:"rating"`
+	Extra  map[string]string `json:"extra,omitempty"`
+}
+
+type hotelService struct{}
+
+func (hotelService) SearchHotels(ctx context.Context, p searchParams) ([]hotel, error) {
+	return nil, nil
+}
+
+func (hotelService) Ping(ctx context.Context) error { return nil }
+
+// NotAHandler must be skipped by RegisterService.
+func (hotelService) NotAHandler(a, b int) int { return a + b }
+
+func TestSchemaFor(t *testing.T) {
+	s := SchemaOf(hotel{})
+	if s.Type != "object" {
+		t.Fatalf("Expected object schema, got %q", s.Type)
+	}
+	if got := s.Properties["id"]; got.Type != "integer" || got.Minimum == nil {
+		t.Errorf("Expected non-negative integer for uint64, got %+v", got)
+	}
+	if got := s.Properties["extra"]; got.Type != "object" || got.AdditionalProperties.Type != "string" {
+		t.Errorf("Unexpected map schema: %+v", got)
+	}
+	if !reflect.DeepEqual(s.Required, []string{"id", "name"}) {
+		t.Errorf("Expected required [id name], got %v", s.Required)
+	}
+	if got := s.Properties["name"].Description; got != "Display name" {
+		t.Errorf("Expected description from struct tag, got %q", got)
+	}
+}
+
+func TestGenerator_Register(t *testing.T) {
+	g := NewGenerator(Info{Title: "Hotel", Description: "Hotel booking"})
+	g.AddServer(Server{Name: "prod", URL: "https://example.com/rpc"})
+
+	err := g.Register("searchHotels", func(ctx context.Context, p searchParams) ([]hotel, error) {
+		return nil, nil
+	}, WithSummary("Search hotels"))
+	if err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+
+	if err := g.Register("searchHotels", func() error { return nil }); err == nil {
+		t.Error("Expected duplicate registration to fail")
+	}
+
+	doc := g.Document()
+	if doc.OpenRPC != Version || len(doc.Methods) != 1 || len(doc.Servers) != 1 {
+		t.Fatalf("Unexpected document: %+v", doc)
+	}
+
+	method := doc.Methods[0]
+	if method.Summary != "Search hotels" {
+		t.Errorf("Expected summary, got %q", method.Summary)
+	}
+
+	var names []string
+	for _, p := range method.Params {
+		names = append(names, p.Name)
+	}
+	if !reflect.DeepEqual(names, []string{"cityName", "checkInDate", "pageSize", "brands"}) {
+		t.Errorf("Unexpected params order: %v", names)
+	}
+	if method.Params[0].Description != "City to search in" || method.Params[0].Schema.Description != "City to search in" {
+		t.Errorf("Expected cityName description from struct tag, got %+v", method.Params[0])
+	}
+	if !method.Params[0].Required || method.Params[2].Required {
+		t.Error("Expected cityName required and pageSize optional")
+	}
+	if method.Params[1].Schema.Format != "date-time" {
+		t.Errorf("Expected date-time format, got %q", method.Params[1].Schema.Format)
+	}
+	if method.Result == nil || method.Result.Schema.Type != "array" {
+		t.Errorf("Expected array result, got %+v", method.Result)
+	}
+
+	iface, err := g.InlineInterface("")
+	if err != nil {
+		t.Fatalf("InlineInterface() error = %v", err)
+	}
+	if iface.Protocol != "openrpc" || iface.Content == nil || iface.Description != "Hotel booking" {
+		t.Errorf("Unexpected interface: %+v", iface)
+	}
+}
+
+func TestGenerator_RegisterService(t *testing.T) {
+	g := NewGenerator(Info{Title: "Hotel"})
+	if err := g.RegisterService(hotelService{}); err != nil {
+		t.Fatalf("RegisterService() error = %v", err)
+	}
+
+	if _, ok := g.Method("searchHotels"); !ok {
+		t.Error("Expected searchHotels to be registered")
+	}
+	if m, ok := g.Method("ping"); !ok || m.Result != nil {
+		t.Errorf("Expected ping without result, got %+v", m)
+	}
+	if _, ok := g.Method("notAHandler"); ok {
+		t.Error("Expected NotAHandler to be skipped")
+	}
+}
+
+func TestGenerator_AddMethod(t *testing.T) {
+	g := NewGenerator(Info{Title: "Proxy"})
+	if err := g.AddMethod(Method{Name: "status"}); err != nil {
+		t.Fatalf("AddMethod() error = %v", err)
+	}
+	if m, ok := g.Method("status"); !ok || m.Params == nil {
+		t.Errorf("Expected status with empty params, got %+v", m)
+	}
+
+	invalid := []Method{
+		{},
+		{Name: "status"},
+		{Name: "search", Params: []*ContentDescriptor{{Schema: &Schema{Type: "string"}}}},
+	}
+	for _, m := range invalid {
+		if err := g.AddMethod(m); err == nil {
+			t.Errorf("AddMethod(%+v) error = nil, want error", m)
+		}
+	}
+}
+
+func TestInspectHandler_Invalid(t *testing.T) {
+	tests := []struct {
+		name string
+		fn   any
+	}{
+		{"not a function", 42},
+		{"scalar params", func(ctx context.Context, s string) error { return nil }},
+		{"missing error", func(ctx context.Context) int { return 0 }},
+		{"too many params", func(a, b searchParams) error { return nil }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := InspectHandler(tt.fn); err == nil {
+				t.Error("Expected error")
+			}
+		})
+	}
+
+	if _, err := InspectHandler(func(*searchParams) (hotel, error) { return hotel{}, errors.New("x") }); err != nil {
+		t.Errorf("Expected pointer params to be accepted, got %v", err)
+	}
+}
+
+func TestLowerCamel(t *testing.T) {
+	cases := map[string]string{
+		"SearchHotels": "searchHotels",
+		"HTTPServer":   "httpServer",
+		"ID":           "id",
+		"ping":         "ping",
+	}
+	for in, want := range cases {
+		if got := LowerCamel(in); got != want {
+			t.Errorf("LowerCamel(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
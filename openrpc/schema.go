@@ -0,0 +1,133 @@
+package openrpc
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"time"
+)
+
+var (
+	timeType       = reflect.TypeOf(time.Time{})
+	rawMessageType = reflect.TypeOf(json.RawMessage{})
+	byteSliceType  = reflect.TypeOf([]byte(nil))
+)
+
+// SchemaOf returns the JSON Schema describing the Go type of v.
+func SchemaOf(v any) *Schema {
+	return SchemaFor(reflect.TypeOf(v))
+}
+
+// SchemaFor returns the JSON Schema describing t.
+// Struct fields follow encoding/json naming rules; fields that are pointers or
+// tagged omitempty are optional, all others are required. A description
+// struct tag becomes the property's description:
+//
+//	type SearchParams struct {
+//		Query string `json:"query" description:"Full-text search terms"`
+//	}
+func SchemaFor(t reflect.Type) *Schema {
+	return schemaFor(t, map[reflect.Type]bool{})
+}
+
+func schemaFor(t reflect.Type, visiting map[reflect.Type]bool) *Schema {
+	if t == nil {
+		return &Schema{}
+	}
+
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	switch t {
+	case timeType:
+		return &Schema{Type: "string", Format: "date-time"}
+	case rawMessageType:
+		return &Schema{}
+	case byteSliceType:
+		return &Schema{Type: "string", Format: "byte"}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return &Schema{Type: "integer"}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		zero := 0.0
+		return &Schema{Type: "integer", Minimum: &zero}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		return &Schema{Type: "array", Items: schemaFor(t.Elem(), visiting)}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: schemaFor(t.Elem(), visiting)}
+	case reflect.Struct:
+		if visiting[t] {
+			// Recursive type: stop expanding to keep the schema finite.
+			return &Schema{Type: "object"}
+		}
+		visiting[t] = true
+		defer delete(visiting, t)
+
+		s := &Schema{Type: "object", Properties: map[string]*Schema{}}
+		addStructFields(s, t, visiting)
+		return s
+	default:
+		// interface{} and anything else accept any JSON value.
+		return &Schema{}
+	}
+}
+
+func addStructFields(s *Schema, t reflect.Type, visiting map[reflect.Type]bool) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, omitEmpty, skip := jsonFieldName(field)
+		if skip {
+			continue
+		}
+
+		if field.Anonymous && name == "" {
+			embedded := field.Type
+			if embedded.Kind() == reflect.Pointer {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				addStructFields(s, embedded, visiting)
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		prop := schemaFor(field.Type, visiting)
+		if desc := field.Tag.Get("description"); desc != "" {
+			prop.Description = desc
+		}
+		s.Properties[name] = prop
+		if !omitEmpty && field.Type.Kind() != reflect.Pointer {
+			s.Required = append(s.Required, name)
+		}
+	}
+}
+
+// jsonFieldName parses the json struct tag of field.
+func jsonFieldName(field reflect.StructField) (name string, omitEmpty, skip bool) {
+	tag := field.Tag.Get("json")
+	if tag == "-" {
+		return "", false, true
+	}
+	parts := strings.Split(tag, ",")
+	for _, opt := range parts[1:] {
+		if opt == "omitempty" || opt == "omitzero" {
+			omitEmpty = true
+		}
+	}
+	return parts[0], omitEmpty, false
+}
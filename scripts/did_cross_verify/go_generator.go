@@ -1,49 +1,24 @@
+//go:build ignore
+
+// Command go_generator writes Go DID-WBA artifacts for cross-language comparison.
+// It is a thin wrapper around the conformance package:
+//
+//	go run scripts/did_cross_verify/go_generator.go -output artifacts
 package main
 
 import (
-	"crypto/ecdsa"
-	"crypto/elliptic"
-	"crypto/rand"
-	"crypto/sha256"
-	"encoding/base64"
 	"encoding/json"
 	"flag"
 	"fmt"
 	"log"
-	"math/big"
+	"net/url"
 	"os"
-	"path/filepath"
-	"strings"
-	"time"
 
 	"github.com/openanp/anp-go/anp_auth"
+	"github.com/openanp/anp-go/conformance"
 	"github.com/openanp/anp-go/crypto"
-
-	"github.com/bytedance/sonic"
-	"github.com/cyberphone/json-canonicalization/go/src/webpki.org/jsoncanonicalizer"
-	"github.com/google/uuid"
 )
 
-type step1Params struct {
-	DID                  string `json:"did"`
-	Nonce                string `json:"nonce"`
-	Timestamp            string `json:"timestamp"`
-	VerificationMethod   string `json:"verification_method"`
-	VerificationMethodID string `json:"verification_method_id"`
-	ServiceDomain        string `json:"service_domain"`
-}
-
-type step4Header struct {
-	AuthHeader string `json:"auth_header"`
-}
-
-type authPayload struct {
-	Nonce   string `json:"nonce"`
-	Time    string `json:"timestamp"`
-	Service string `json:"service"`
-	DID     string `json:"did"`
-}
-
 func main() {
 	var (
 		didDocPath     string
@@ -62,81 +37,42 @@ func main() {
 	flag.StringVar(&fixedTimestamp, "timestamp", "", "Fixed timestamp (for reproducible tests)")
 	flag.Parse()
 
-	if err := os.MkdirAll(outputDir, 0o755); err != nil {
-		log.Fatalf("failed to create output directory: %v", err)
-	}
-
 	didDoc, err := loadDIDDocument(didDocPath)
 	if err != nil {
 		log.Fatalf("failed to load DID document: %v", err)
 	}
 
-	privateKey, err := loadPrivateKey(privateKeyPath)
+	keyPEM, err := os.ReadFile(privateKeyPath)
 	if err != nil {
 		log.Fatalf("failed to load private key: %v", err)
 	}
-
-	serviceDomain := extractDomain(targetURL)
-	verificationFragment, err := selectVerificationFragment(didDoc)
+	privateKey, err := crypto.PrivateKeyFromPEM(keyPEM)
 	if err != nil {
-		log.Fatalf("failed to determine verification method fragment: %v", err)
-	}
-
-	nonce := fixedNonce
-	if nonce == "" {
-		nonce = uuid.NewString()
-	}
-
-	timestamp := fixedTimestamp
-	if timestamp == "" {
-		timestamp = time.Now().UTC().Format(time.RFC3339)
-	}
-
-	step1 := step1Params{
-		DID:                  didDoc.ID,
-		Nonce:                nonce,
-		Timestamp:            timestamp,
-		VerificationMethod:   verificationFragment,
-		VerificationMethodID: fmt.Sprintf("%s#%s", didDoc.ID, verificationFragment),
-		ServiceDomain:        serviceDomain,
-	}
-
-	saveJSON(filepath.Join(outputDir, "go_step1_params.json"), step1)
-	fmt.Println("✓ Step 1: Parameters generated")
-
-	payload := authPayload{
-		Nonce:   step1.Nonce,
-		Time:    step1.Timestamp,
-		Service: step1.ServiceDomain,
-		DID:     step1.DID,
+		log.Fatalf("failed to load private key: %v", err)
 	}
 
-	payloadBytes, err := marshalCanonicalPayload(&payload)
+	target, err := url.Parse(targetURL)
 	if err != nil {
-		log.Fatalf("failed to marshal payload: %v", err)
+		log.Fatalf("invalid target URL: %v", err)
 	}
-	fmt.Println("✓ Step 2: Payload canonicalized in memory")
 
-	signature, err := signPayload(privateKey, payloadBytes)
+	artifacts, err := conformance.Generate(didDoc, privateKey, conformance.Options{
+		Prefix:        "go",
+		ServiceDomain: target.Hostname(),
+		Nonce:         fixedNonce,
+		Timestamp:     fixedTimestamp,
+	})
 	if err != nil {
-		log.Fatalf("failed to sign payload: %v", err)
+		log.Fatalf("failed to generate artifacts: %v", err)
+	}
+	if err := artifacts.Write(outputDir); err != nil {
+		log.Fatalf("failed to write artifacts: %v", err)
 	}
-	fmt.Println("✓ Step 3: Signature calculated in memory")
-
-	authHeader := fmt.Sprintf(
-		`DIDWba did="%s", nonce="%s", timestamp="%s", verification_method="%s", signature="%s"`,
-		step1.DID,
-		step1.Nonce,
-		step1.Timestamp,
-		step1.VerificationMethod,
-		signature,
-	)
 
-	saveJSON(filepath.Join(outputDir, "go_step4_header.json"), step4Header{
-		AuthHeader: authHeader,
-	})
+	fmt.Println("✓ Step 1: Parameters generated")
+	fmt.Println("✓ Step 2: Payload canonicalized")
+	fmt.Println("✓ Step 3: Signature calculated")
 	fmt.Println("✓ Step 4: Auth header assembled")
-
 	fmt.Println("\n✅ Go artifacts generated successfully")
 }
 
@@ -151,95 +87,3 @@ func loadDIDDocument(path string) (*anp_auth.DIDWBADocument, error) {
 	}
 	return &doc, nil
 }
-
-func loadPrivateKey(path string) (*ecdsa.PrivateKey, error) {
-	data, err := os.ReadFile(path)
-	if err != nil {
-		return nil, err
-	}
-	return crypto.PrivateKeyFromPEM(data)
-}
-
-func selectVerificationFragment(doc *anp_auth.DIDWBADocument) (string, error) {
-	if len(doc.Authentication) == 0 {
-		return "", fmt.Errorf("DID document missing authentication methods")
-	}
-	reference := doc.Authentication[0]
-	fragment := reference
-	if idx := strings.Index(reference, "#"); idx >= 0 && idx+1 < len(reference) {
-		fragment = reference[idx+1:]
-	}
-	if fragment == "" {
-		return "", fmt.Errorf("invalid authentication reference: %s", reference)
-	}
-	return fragment, nil
-}
-
-func marshalCanonicalPayload(payload *authPayload) ([]byte, error) {
-	jsonBytes, err := sonic.Marshal(payload)
-	if err != nil {
-		return nil, err
-	}
-	return jsoncanonicalizer.Transform(jsonBytes)
-}
-
-func signPayload(privateKey *ecdsa.PrivateKey, canonical []byte) (string, error) {
-	digest := sha256.Sum256(canonical)
-	finalDigest := sha256.Sum256(digest[:])
-
-	r, s, err := ecdsa.Sign(rand.Reader, privateKey, finalDigest[:])
-	if err != nil {
-		return "", err
-	}
-
-	sigBytes, err := marshalSignature(privateKey.Curve, r, s)
-	if err != nil {
-		return "", err
-	}
-
-	return base64.RawURLEncoding.EncodeToString(sigBytes), nil
-}
-
-func marshalSignature(curve elliptic.Curve, r, s *big.Int) ([]byte, error) {
-	if curve == nil {
-		return nil, fmt.Errorf("elliptic curve is required")
-	}
-
-	size := (curve.Params().BitSize + 7) / 8
-	rBytes := r.Bytes()
-	sBytes := s.Bytes()
-
-	if len(rBytes) > size || len(sBytes) > size {
-		return nil, fmt.Errorf("signature component larger than curve size")
-	}
-
-	sig := make([]byte, size*2)
-	copy(sig[size-len(rBytes):size], rBytes)
-	copy(sig[2*size-len(sBytes):], sBytes)
-	return sig, nil
-}
-
-func saveJSON(path string, data any) {
-	file, err := os.Create(path)
-	if err != nil {
-		log.Fatalf("failed to create file %s: %v", path, err)
-	}
-	defer file.Close()
-
-	encoder := json.NewEncoder(file)
-	encoder.SetIndent("", "  ")
-	if err := encoder.Encode(data); err != nil {
-		log.Fatalf("failed to encode JSON to %s: %v", path, err)
-	}
-}
-
-func extractDomain(url string) string {
-	url = strings.TrimPrefix(url, "https://")
-	url = strings.TrimPrefix(url, "http://")
-	for i, c := range url {
-		if c == '/' || c == ':' {
-			return url[:i]
-		}
-	}
-	return url
-}
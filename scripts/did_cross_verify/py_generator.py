@@ -3,7 +3,6 @@
 
 import argparse
 import base64
-import hashlib
 import json
 import sys
 import uuid
@@ -39,9 +38,9 @@ def base64url_encode(data: bytes) -> str:
 
 def sign_payload(private_key: ec.EllipticCurvePrivateKey, payload: dict) -> str:
     canonical = jcs.canonicalize(payload)
-    digest = hashlib.sha256(canonical).digest()
-
-    signature_der = private_key.sign(digest, ec.ECDSA(hashes.SHA256()))
+    # ECDSA-SHA256 hashes the canonical payload itself; hashing it first
+    # would sign a digest of the digest.
+    signature_der = private_key.sign(canonical, ec.ECDSA(hashes.SHA256()))
     r, s = utils.decode_dss_signature(signature_der)
     size = (private_key.curve.key_size + 7) // 8
     signature = r.to_bytes(size, "big") + s.to_bytes(size, "big")
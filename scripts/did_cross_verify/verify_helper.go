@@ -1,3 +1,9 @@
+//go:build ignore
+
+// Command verify_helper verifies a DID-WBA header artifact produced by any SDK.
+// It is a thin wrapper around the conformance package:
+//
+//	go run scripts/did_cross_verify/verify_helper.go -params p.json -header h.json -did-doc did.json
 package main
 
 import (
@@ -6,31 +12,11 @@ import (
 	"fmt"
 	"log"
 	"os"
-	"regexp"
 
 	"github.com/openanp/anp-go/anp_auth"
+	"github.com/openanp/anp-go/conformance"
 )
 
-type headerArtifact struct {
-	AuthHeader string `json:"auth_header"`
-}
-
-type paramsArtifact struct {
-	ServiceDomain      string `json:"service_domain"`
-	DID                string `json:"did"`
-	VerificationMethod string `json:"verification_method"`
-}
-
-var headerPattern = regexp.MustCompile(`(did|nonce|timestamp|verification_method|signature)="([^"]*)"`)
-
-type parsedHeader struct {
-	DID                string
-	Nonce              string
-	Timestamp          string
-	VerificationMethod string
-	Signature          string
-}
-
 func main() {
 	log.SetFlags(0)
 
@@ -44,126 +30,35 @@ func main() {
 		log.Fatalf("header, params, and did-doc arguments are required")
 	}
 
-	headerData, err := loadHeader(*headerPath)
-	if err != nil {
-		log.Fatalf("failed to load header artifact: %v", err)
-	}
-
-	params, err := loadParams(*paramsPath)
-	if err != nil {
+	var artifacts conformance.Artifacts
+	if err := readJSON(*paramsPath, &artifacts.Params); err != nil {
 		log.Fatalf("failed to load parameter artifact: %v", err)
 	}
-
-	serviceDomain := params.ServiceDomain
+	if err := readJSON(*headerPath, &artifacts.Header); err != nil {
+		log.Fatalf("failed to load header artifact: %v", err)
+	}
 	if *overrideDomain != "" {
-		serviceDomain = *overrideDomain
+		artifacts.Params.ServiceDomain = *overrideDomain
 	}
-	if serviceDomain == "" {
+	if artifacts.Params.ServiceDomain == "" {
 		log.Fatalf("service domain not provided in params and no override specified")
 	}
 
-	doc, err := loadDidDocument(*didDocPath)
-	if err != nil {
+	var doc anp_auth.DIDWBADocument
+	if err := readJSON(*didDocPath, &doc); err != nil {
 		log.Fatalf("failed to load DID document: %v", err)
 	}
 
-	headerParts, err := parseHeader(headerData.AuthHeader)
-	if err != nil {
-		log.Fatalf("invalid auth header: %v", err)
-	}
-
-	authJSON := anp_auth.AuthJSON{
-		DID:                headerParts.DID,
-		Nonce:              headerParts.Nonce,
-		Timestamp:          headerParts.Timestamp,
-		VerificationMethod: headerParts.VerificationMethod,
-		Signature:          headerParts.Signature,
-	}
-
-	ok, message := anp_auth.VerifyAuthJSON(&authJSON, doc, serviceDomain)
-	if !ok {
-		log.Fatalf("verification failed: %s", message)
-	}
-
-	if message != "" {
-		fmt.Println(message)
-	} else {
-		fmt.Println("Verification succeeded")
-	}
-}
-
-func loadHeader(path string) (*headerArtifact, error) {
-	data, err := os.ReadFile(path)
-	if err != nil {
-		return nil, err
+	if err := conformance.Verify(&artifacts, &doc, nil); err != nil {
+		log.Fatalf("verification failed: %v", err)
 	}
-	var artifact headerArtifact
-	if err := json.Unmarshal(data, &artifact); err != nil {
-		return nil, err
-	}
-	if artifact.AuthHeader == "" {
-		return nil, fmt.Errorf("auth_header is empty")
-	}
-	return &artifact, nil
+	fmt.Println("Verification successful")
 }
 
-func loadParams(path string) (*paramsArtifact, error) {
+func readJSON(path string, v any) error {
 	data, err := os.ReadFile(path)
 	if err != nil {
-		return nil, err
-	}
-	var params paramsArtifact
-	if err := json.Unmarshal(data, &params); err != nil {
-		return nil, err
-	}
-	return &params, nil
-}
-
-func loadDidDocument(path string) (*anp_auth.DIDWBADocument, error) {
-	data, err := os.ReadFile(path)
-	if err != nil {
-		return nil, err
-	}
-	var doc anp_auth.DIDWBADocument
-	if err := json.Unmarshal(data, &doc); err != nil {
-		return nil, err
-	}
-	return &doc, nil
-}
-
-func parseHeader(header string) (*parsedHeader, error) {
-	if header == "" {
-		return nil, fmt.Errorf("authorization header cannot be empty")
+		return err
 	}
-
-	if len(header) < 7 || header[:6] != "DIDWba" {
-		return nil, fmt.Errorf("authorization header must start with 'DIDWba'")
-	}
-
-	matches := headerPattern.FindAllStringSubmatch(header, -1)
-	if len(matches) == 0 {
-		return nil, fmt.Errorf("authorization header has unexpected format")
-	}
-
-	result := &parsedHeader{}
-	for _, match := range matches {
-		switch match[1] {
-		case "did":
-			result.DID = match[2]
-		case "nonce":
-			result.Nonce = match[2]
-		case "timestamp":
-			result.Timestamp = match[2]
-		case "verification_method":
-			result.VerificationMethod = match[2]
-		case "signature":
-			result.Signature = match[2]
-		}
-	}
-
-	if result.DID == "" || result.Nonce == "" || result.Timestamp == "" || result.VerificationMethod == "" || result.Signature == "" {
-		return nil, fmt.Errorf("authorization header missing required fields")
-	}
-
-	return result, nil
+	return json.Unmarshal(data, v)
 }
@@ -0,0 +1,32 @@
+#!/bin/bash
+# ANP-Go 模糊测试 - 依次运行所有 fuzz 目标
+# 用法: ./fuzz.sh [目标名...]   环境变量 FUZZTIME 控制每个目标的时长（默认 30s）
+# 发现的崩溃输入会写入对应包的 testdata/fuzz/<目标名>/，提交后即成为回归用例。
+
+set -e
+
+SCRIPT_DIR="$(cd "$(dirname "${BASH_SOURCE[0]}")" && pwd)"
+PROJECT_ROOT="$(cd "$SCRIPT_DIR/.." && pwd)"
+FUZZTIME="${FUZZTIME:-30s}"
+
+# 目标名 包路径
+TARGETS=(
+    "FuzzJSONParserParse ./anp_crawler"
+    "FuzzParseAuthHeader ./anp_auth"
+    "FuzzParseAuthJSON ./anp_auth"
+    "FuzzPrivateKeyFromPEM ./crypto"
+)
+
+cd "$PROJECT_ROOT"
+
+for entry in "${TARGETS[@]}"; do
+    read -r name pkg <<< "$entry"
+    if [ $# -gt 0 ] && [[ ! " $* " =~ " $name " ]]; then
+        continue
+    fi
+    echo "==> $name ($pkg, $FUZZTIME)"
+    go test -run '^$' -fuzz "^${name}\$" -fuzztime "$FUZZTIME" "$pkg"
+done
+
+echo ""
+echo "✅ Fuzzing finished"
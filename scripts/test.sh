@@ -1,6 +1,6 @@
 #!/bin/bash
 # ANP-Go 测试脚本 - 简单、快速、实用
-# 用法: ./test.sh [--ci|--examples|--verify|--all]
+# 用法: ./test.sh [--ci|--examples|--verify|--fuzz|--all]
 
 set -e
 
@@ -82,5 +82,11 @@ if [ "$MODE" = "--verify" ] || [ "$MODE" = "--all" ]; then
     fi
 fi
 
+# 模糊测试（耗时较长，不包含在 --all 中）
+if [ "$MODE" = "--fuzz" ]; then
+    echo ""
+    FUZZTIME="${FUZZTIME:-30s}" bash "$SCRIPT_DIR/fuzz.sh"
+fi
+
 echo ""
 echo "✅ All checks passed"
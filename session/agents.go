@@ -0,0 +1,39 @@
+package session
+
+import (
+	"context"
+	"fmt"
+	"iter"
+
+	"github.com/openanp/anp-go/anp_crawler"
+)
+
+// ListAllAgents iterates over the agents of the directory at url, fetching
+// further pages as the iteration reaches them by following the next link or
+// cursor of each page (see anp_crawler.Pagination). A fetch error is yielded
+// once and ends the iteration, as does a page linking back to one already
+// read.
+func (s *Session) ListAllAgents(ctx context.Context, url string) iter.Seq2[anp_crawler.AgentEntry, error] {
+	return func(yield func(anp_crawler.AgentEntry, error) bool) {
+		seen := make(map[string]bool)
+		for page := url; page != ""; {
+			if seen[page] {
+				yield(anp_crawler.AgentEntry{}, fmt.Errorf("anp/session: directory %s pages loop at %s", url, page))
+				return
+			}
+			seen[page] = true
+
+			doc, err := s.Fetch(ctx, page)
+			if err != nil {
+				yield(anp_crawler.AgentEntry{}, err)
+				return
+			}
+			for _, agent := range doc.Result.Agents {
+				if !yield(agent, nil) {
+					return
+				}
+			}
+			page = doc.Result.Pagination.NextURL(page)
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package session
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/openanp/anp-go/anptest"
+)
+
+func TestListAllAgents(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.URL.Path == "/loop.json":
+			fmt.Fprint(w, `{"agentList": [{"name": "x"}], "next": "/loop.json"}`)
+		case r.URL.Query().Get("cursor") == "c2":
+			fmt.Fprint(w, `{"agentList": [{"name": "d"}]}`)
+		case r.URL.Query().Get("page") == "2":
+			fmt.Fprint(w, `{"agentList": [{"name": "c"}], "pagination": {"nextCursor": "c2"}}`)
+		default:
+			fmt.Fprint(w, `{"agentList": [{"name": "a"}, {"name": "b"}], "next": "?page=2"}`)
+		}
+	}))
+	defer srv.Close()
+	caller := anptest.NewIdentity(t, "client.example.com")
+	sess, err := New(Config{Authenticator: caller.Authenticator})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	ctx := context.Background()
+
+	var names []string
+	for agent, err := range sess.ListAllAgents(ctx, srv.URL+"/agents") {
+		if err != nil {
+			t.Fatalf("ListAllAgents() error = %v", err)
+		}
+		names = append(names, agent.Name)
+	}
+	if fmt.Sprint(names) != "[a b c d]" {
+		t.Errorf("ListAllAgents() = %v, want [a b c d]", names)
+	}
+
+	// Breaking out of the loop ends the iteration cleanly.
+	for agent := range sess.ListAllAgents(ctx, srv.URL+"/agents") {
+		if agent.Name != "a" {
+			t.Errorf("first agent = %q, want a", agent.Name)
+		}
+		break
+	}
+
+	var loopErr error
+	for _, err := range sess.ListAllAgents(ctx, srv.URL+"/loop.json") {
+		loopErr = err
+	}
+	if loopErr == nil {
+		t.Error("ListAllAgents(loop) error = nil, want loop error")
+	}
+}
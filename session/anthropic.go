@@ -0,0 +1,85 @@
+package session
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/openanp/anp-go/anp_crawler"
+)
+
+// AnthropicTool is an entry of the tools array of an Anthropic Messages API
+// request.
+type AnthropicTool struct {
+	Name        string         `json:"name"`
+	Description string         `json:"description,omitempty"`
+	InputSchema map[string]any `json:"input_schema"`
+}
+
+// AnthropicToolUse is a tool_use content block of an assistant message, and
+// decodes from the API response as is.
+type AnthropicToolUse struct {
+	Type  string          `json:"type"`
+	ID    string          `json:"id"`
+	Name  string          `json:"name"`
+	Input json.RawMessage `json:"input"`
+}
+
+// AnthropicToolResult is the tool_result content block that answers a
+// tool_use block in the next user message.
+type AnthropicToolResult struct {
+	Type      string `json:"type"`
+	ToolUseID string `json:"tool_use_id"`
+	Content   string `json:"content"`
+	IsError   bool   `json:"is_error,omitempty"`
+}
+
+// AnthropicTools converts tools, e.g. Document.Tools or ToolRegistry.Tools,
+// to the tools array of an Anthropic Messages API request.
+func AnthropicTools(tools []*anp_crawler.ANPTool) []AnthropicTool {
+	out := make([]AnthropicTool, 0, len(tools))
+	for _, tool := range tools {
+		out = append(out, AnthropicTool{
+			Name:        tool.Function.Name,
+			Description: tool.Function.Description,
+			InputSchema: toolParameters(tool),
+		})
+	}
+	return out
+}
+
+// AnthropicResult formats the outcome of executing the tool_use block with
+// the given ID, as returned by ExecuteTool or ToolRegistry.Execute, as a
+// tool_result block: the JSON-RPC result, or the error with IsError set.
+func AnthropicResult(toolUseID string, resp map[string]any, err error) AnthropicToolResult {
+	content, failed := toolOutcome(resp, err)
+	return AnthropicToolResult{Type: "tool_result", ToolUseID: toolUseID, Content: content, IsError: failed}
+}
+
+// DispatchAnthropic executes the tool_use blocks of an assistant message
+// with the registry's tools and returns the tool_result blocks answering
+// them, in order. Other content blocks are not passed in; a call that fails
+// is answered with IsError set so the model can recover.
+func (r *ToolRegistry) DispatchAnthropic(ctx context.Context, uses ...AnthropicToolUse) []AnthropicToolResult {
+	return dispatchAnthropic(ctx, r.Execute, uses)
+}
+
+// DispatchAnthropic is ToolRegistry.DispatchAnthropic for the tools of a
+// single document.
+func DispatchAnthropic(ctx context.Context, doc *Document, uses ...AnthropicToolUse) []AnthropicToolResult {
+	return dispatchAnthropic(ctx, func(ctx context.Context, name string, args map[string]any) (map[string]any, error) {
+		return ExecuteTool(ctx, doc, name, args)
+	}, uses)
+}
+
+func dispatchAnthropic(ctx context.Context, execute executeFunc, uses []AnthropicToolUse) []AnthropicToolResult {
+	results := make([]AnthropicToolResult, len(uses))
+	for i, use := range uses {
+		args, err := toolArguments(use.Name, use.Input)
+		var resp map[string]any
+		if err == nil {
+			resp, err = execute(ctx, use.Name, args)
+		}
+		results[i] = AnthropicResult(use.ID, resp, err)
+	}
+	return results
+}
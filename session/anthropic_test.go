@@ -0,0 +1,97 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/bytedance/sonic"
+	"github.com/openanp/anp-go/anp_crawler"
+	"github.com/openanp/anp-go/anptest"
+)
+
+func TestAnthropicTools(t *testing.T) {
+	tools := AnthropicTools([]*anp_crawler.ANPTool{{Type: "function", Function: anp_crawler.Function{
+		Name:        "add",
+		Description: "Adds two numbers",
+		Parameters: anp_crawler.Parameters{
+			Type:       "object",
+			Properties: map[string]any{"a": map[string]any{"type": "integer"}},
+		},
+	}}})
+	raw, err := sonic.MarshalString(tools)
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	var got []map[string]any
+	if err := sonic.UnmarshalString(raw, &got); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	if len(got) != 1 || got[0]["name"] != "add" || got[0]["description"] != "Adds two numbers" {
+		t.Fatalf("AnthropicTools() = %s", raw)
+	}
+	schema, _ := got[0]["input_schema"].(map[string]any)
+	if schema["type"] != "object" || schema["properties"] == nil || schema["required"] == nil {
+		t.Errorf("AnthropicTools() input_schema = %v", schema)
+	}
+}
+
+func TestAnthropicResult(t *testing.T) {
+	tests := []struct {
+		name    string
+		resp    map[string]any
+		err     error
+		content string
+		isError bool
+	}{
+		{"result", map[string]any{"jsonrpc": "2.0", "result": map[string]any{"ok": true}}, nil, `{"ok":true}`, false},
+		{"rpc error", map[string]any{"error": map[string]any{"code": -32601}}, nil, `{"error":{"code":-32601}}`, true},
+		{"call error", nil, errors.New("boom"), `{"error":"boom"}`, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := AnthropicResult("toolu_1", tt.resp, tt.err)
+			if got.Type != "tool_result" || got.ToolUseID != "toolu_1" || got.Content != tt.content || got.IsError != tt.isError {
+				t.Errorf("AnthropicResult() = %+v", got)
+			}
+		})
+	}
+}
+
+func TestDispatchAnthropic(t *testing.T) {
+	caller := anptest.NewIdentity(t, "client.example.com")
+	srv := anptest.NewServer(t,
+		anptest.WithMethod("add", func(p addParams) (int, error) { return p.A + p.B, nil }),
+		anptest.WithDIDAuth(caller),
+	)
+	sess, err := New(Config{Authenticator: caller.Authenticator})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	ctx := context.Background()
+	if _, err := sess.Fetch(ctx, srv.OpenRPCURL()); err != nil {
+		t.Fatalf("Fetch() error = %v", err)
+	}
+
+	// The content of an assistant message, as the API returns it.
+	var message struct {
+		Content []AnthropicToolUse `json:"content"`
+	}
+	if err := sonic.UnmarshalString(`{"role": "assistant", "content": [
+		{"type": "tool_use", "id": "toolu_1", "name": "add", "input": {"a": 2, "b": 3}},
+		{"type": "tool_use", "id": "toolu_2", "name": "missing", "input": {}}
+	]}`, &message); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	results := sess.Tools().DispatchAnthropic(ctx, message.Content...)
+	if len(results) != 2 {
+		t.Fatalf("DispatchAnthropic() = %+v", results)
+	}
+	if r := results[0]; r.ToolUseID != "toolu_1" || r.Content != "5" || r.IsError {
+		t.Errorf("result 1 = %+v, want content 5", r)
+	}
+	if r := results[1]; r.ToolUseID != "toolu_2" || !r.IsError || !strings.Contains(r.Content, "missing") {
+		t.Errorf("result 2 = %+v, want error", r)
+	}
+}
@@ -0,0 +1,60 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// ErrToolDenied is returned for tool calls an ApprovalFunc denies.
+var ErrToolDenied = errors.New("anp/session: tool call denied")
+
+// Decision is the verdict of an ApprovalFunc.
+type Decision int
+
+const (
+	// Allow sends the call as it is.
+	Allow Decision = iota
+	// Deny fails the call with ErrToolDenied without sending it.
+	Deny
+	// Modify sends the call with Approval.Arguments instead.
+	Modify
+)
+
+// Approval is an ApprovalFunc's answer for one call.
+type Approval struct {
+	Decision Decision
+	// Arguments replace the call's arguments when Decision is Modify.
+	Arguments map[string]any
+	// Reason is included in the error of a denied call.
+	Reason string
+}
+
+// ApprovalFunc decides whether a tool call may be sent, e.g. by asking a
+// human to confirm a booking. call carries the tool name, arguments and
+// target URL; an error fails the call.
+type ApprovalFunc func(ctx context.Context, call *ToolCall) (Approval, error)
+
+// RequireApproval returns middleware that asks approve before sending each
+// call.
+func RequireApproval(approve ApprovalFunc) ToolMiddleware {
+	return func(next ToolHandler) ToolHandler {
+		return func(ctx context.Context, call *ToolCall) (map[string]any, error) {
+			approval, err := approve(ctx, call)
+			if err != nil {
+				return nil, err
+			}
+			switch approval.Decision {
+			case Allow:
+			case Modify:
+				call.Arguments = approval.Arguments
+			default:
+				if approval.Reason != "" {
+					return nil, fmt.Errorf("%w: %s: %s", ErrToolDenied, call.Name, approval.Reason)
+				}
+				return nil, fmt.Errorf("%w: %s", ErrToolDenied, call.Name)
+			}
+			return next(ctx, call)
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+
+	"github.com/openanp/anp-go/anptest"
+)
+
+func TestApprove(t *testing.T) {
+	caller := anptest.NewIdentity(t, "client.example.com")
+	var served atomic.Int32
+	srv := anptest.NewServer(t,
+		anptest.WithMethod("add", func(p addParams) (int, error) { served.Add(1); return p.A + p.B, nil }),
+		anptest.WithDIDAuth(caller),
+	)
+	errOffline := errors.New("approver offline")
+	var asked []string
+	sess, err := New(Config{
+		Authenticator: caller.Authenticator,
+		// Middleware runs first, so approval sees the rewritten arguments.
+		ToolMiddleware: []ToolMiddleware{BeforeTool(func(ctx context.Context, call *ToolCall) error {
+			call.Arguments["b"] = 1
+			return nil
+		})},
+		Approve: func(ctx context.Context, call *ToolCall) (Approval, error) {
+			asked = append(asked, call.URL)
+			if call.Arguments["b"] != 1 {
+				t.Errorf("approval saw arguments %v before middleware", call.Arguments)
+			}
+			switch call.Arguments["a"] {
+			case 1.0:
+				return Approval{Decision: Allow}, nil
+			case 2.0:
+				return Approval{Decision: Modify, Arguments: map[string]any{"a": 40, "b": 2}}, nil
+			case 3.0:
+				return Approval{Decision: Deny, Reason: "over budget"}, nil
+			}
+			return Approval{}, errOffline
+		},
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	ctx := context.Background()
+	doc, err := sess.Fetch(ctx, srv.OpenRPCURL())
+	if err != nil {
+		t.Fatalf("Fetch() error = %v", err)
+	}
+
+	tests := []struct {
+		a       float64
+		want    int
+		wantErr error
+	}{
+		{a: 1, want: 2},
+		{a: 2, want: 42},
+		{a: 3, wantErr: ErrToolDenied},
+		{a: 4, wantErr: errOffline},
+	}
+	for _, tt := range tests {
+		sum, err := ExecuteToolAs[int](ctx, doc, "add", map[string]any{"a": tt.a})
+		if tt.wantErr != nil {
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("add(a=%v) error = %v, want %v", tt.a, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil || sum != tt.want {
+			t.Errorf("add(a=%v) = %d, %v, want %d", tt.a, sum, err, tt.want)
+		}
+	}
+	if n := served.Load(); n != 2 {
+		t.Errorf("server calls = %d, want 2", n)
+	}
+	if len(asked) != 4 || asked[0] != srv.RPCURL() {
+		t.Errorf("approval requests = %v", asked)
+	}
+}
@@ -0,0 +1,237 @@
+package session
+
+import (
+	"context"
+	"iter"
+	"net/url"
+	"slices"
+)
+
+// CrawlConfig bounds a Crawl. Zero values select the defaults.
+type CrawlConfig struct {
+	// Scheduler sets the politeness limits of the crawl; its OnResult is not
+	// used.
+	Scheduler SchedulerConfig
+	// MaxDepth is how many links are followed from the seeds (default 2).
+	MaxDepth int
+	// MaxURLs caps the URLs fetched, seeds included (default 1000).
+	MaxURLs int
+	// MaxPerHost caps the URLs fetched from one host; 0 means no limit.
+	MaxPerHost int
+	// AllowedHosts restricts the links followed to these hosts, given as a
+	// host name or host:port. Seeds are always fetched. Empty allows any host.
+	AllowedHosts []string
+	// SkipInterfaces follows only agentList entries, not interface URLs.
+	SkipInterfaces bool
+}
+
+// CrawlNode is a URL the crawl fetched.
+type CrawlNode struct {
+	URL string `json:"url"`
+	// Depth is the number of links from the nearest seed; seeds are 0.
+	Depth int `json:"depth"`
+	// Parent is the URL whose link first led to this node, empty for seeds.
+	Parent   string `json:"parent,omitempty"`
+	Attempts int    `json:"attempts"`
+	// Error is Err's message, for serialised graphs.
+	Error    string    `json:"error,omitempty"`
+	Err      error     `json:"-"`
+	Document *Document `json:"-"`
+}
+
+// CrawlGraph is the result of Crawl. It encodes to JSON as its nodes and
+// links.
+type CrawlGraph struct {
+	Seeds []string `json:"seeds"`
+	// Nodes are the fetched URLs in the order they were discovered.
+	Nodes []*CrawlNode `json:"nodes"`
+	// Links holds every link found, including links to URLs already visited
+	// and to URLs the limits excluded, which have no node.
+	Links []Link `json:"links"`
+
+	index map[string]*CrawlNode
+}
+
+// Node returns the node for url, or nil if it was not fetched.
+func (g *CrawlGraph) Node(url string) *CrawlNode {
+	if g.index == nil {
+		g.index = make(map[string]*CrawlNode, len(g.Nodes))
+		for _, n := range g.Nodes {
+			g.index[n.URL] = n
+		}
+	}
+	return g.index[url]
+}
+
+// Walk iterates over the nodes breadth-first from the seeds along the links,
+// yielding each node once.
+func (g *CrawlGraph) Walk() iter.Seq[*CrawlNode] {
+	return func(yield func(*CrawlNode) bool) {
+		out := g.outgoing()
+		seen := make(map[string]bool)
+		var queue []string
+		for _, seed := range g.Seeds {
+			if !seen[seed] {
+				seen[seed] = true
+				queue = append(queue, seed)
+			}
+		}
+		for len(queue) > 0 {
+			u := queue[0]
+			queue = queue[1:]
+			node := g.Node(u)
+			if node == nil {
+				continue
+			}
+			if !yield(node) {
+				return
+			}
+			for _, link := range out[u] {
+				if !seen[link.To] {
+					seen[link.To] = true
+					queue = append(queue, link.To)
+				}
+			}
+		}
+	}
+}
+
+// Cycles returns the links that close a cycle: following them from the seeds
+// leads back to a URL on the current path, such as a directory listing an
+// agent whose description links back to the directory.
+func (g *CrawlGraph) Cycles() []Link {
+	out := g.outgoing()
+	const (
+		unvisited = iota
+		onPath
+		done
+	)
+	state := make(map[string]int)
+	var back []Link
+	var visit func(u string)
+	visit = func(u string) {
+		state[u] = onPath
+		for _, link := range out[u] {
+			switch state[link.To] {
+			case onPath:
+				back = append(back, link)
+			case unvisited:
+				visit(link.To)
+			}
+		}
+		state[u] = done
+	}
+	for _, seed := range g.Seeds {
+		if state[seed] == unvisited {
+			visit(seed)
+		}
+	}
+	return back
+}
+
+// outgoing groups the links between fetched nodes by source.
+func (g *CrawlGraph) outgoing() map[string][]Link {
+	out := make(map[string][]Link)
+	for _, link := range g.Links {
+		if g.Node(link.To) != nil {
+			out[link.From] = append(out[link.From], link)
+		}
+	}
+	return out
+}
+
+// Crawl fetches seeds and follows the agentList entries and interface URLs of
+// every document breadth-first within cfg's limits, through a Scheduler so
+// hosts are fetched politely. Each URL is fetched once, so directories that
+// list each other do not loop; such links still appear in the graph and are
+// reported by CrawlGraph.Cycles. If ctx ends the crawl early, the partial
+// graph is returned with ctx's error.
+func (s *Session) Crawl(ctx context.Context, cfg CrawlConfig, seeds ...string) (*CrawlGraph, error) {
+	if cfg.MaxDepth <= 0 {
+		cfg.MaxDepth = 2
+	}
+	if cfg.MaxURLs <= 0 {
+		cfg.MaxURLs = 1000
+	}
+
+	graph := &CrawlGraph{index: make(map[string]*CrawlNode)}
+	perHost := make(map[string]int)
+	add := func(u, parent string, depth int) bool {
+		if graph.index[u] != nil || len(graph.Nodes) >= cfg.MaxURLs {
+			return false
+		}
+		host := hostOf(u)
+		if cfg.MaxPerHost > 0 && perHost[host] >= cfg.MaxPerHost {
+			return false
+		}
+		perHost[host]++
+		node := &CrawlNode{URL: u, Depth: depth, Parent: parent}
+		graph.Nodes = append(graph.Nodes, node)
+		graph.index[u] = node
+		return true
+	}
+
+	schedCfg := cfg.Scheduler
+	var sched *Scheduler
+	// OnResult calls are serialised, so the graph needs no lock.
+	schedCfg.OnResult = func(r CrawlResult) {
+		node := graph.index[r.URL]
+		node.Attempts, node.Document, node.Err = r.Attempts, r.Document, r.Err
+		if r.Err != nil {
+			node.Error = r.Err.Error()
+			return
+		}
+		for _, link := range documentLinks(r.Document, false) {
+			if cfg.SkipInterfaces && link.Kind == LinkInterface {
+				continue
+			}
+			link.To = crawlURL(link.To)
+			if link.To == r.URL {
+				continue
+			}
+			graph.Links = append(graph.Links, link)
+			if node.Depth >= cfg.MaxDepth || !cfg.allowed(link.To) {
+				continue
+			}
+			if add(link.To, r.URL, node.Depth+1) {
+				sched.Add(link.To)
+			}
+		}
+	}
+	sched = s.NewScheduler(schedCfg)
+
+	for _, seed := range seeds {
+		seed = crawlURL(seed)
+		if slices.Contains(graph.Seeds, seed) {
+			continue
+		}
+		graph.Seeds = append(graph.Seeds, seed)
+		if add(seed, "", 0) {
+			sched.Add(seed)
+		}
+	}
+	err := sched.Run(ctx)
+	return graph, err
+}
+
+func (cfg CrawlConfig) allowed(raw string) bool {
+	if len(cfg.AllowedHosts) == 0 {
+		return true
+	}
+	u, err := url.Parse(raw)
+	if err != nil {
+		return false
+	}
+	return slices.Contains(cfg.AllowedHosts, u.Host) || slices.Contains(cfg.AllowedHosts, u.Hostname())
+}
+
+// crawlURL drops the fragment, which names a part of a document rather than
+// a different one, so both spellings count as one visit.
+func crawlURL(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil || u.Fragment == "" {
+		return raw
+	}
+	u.Fragment, u.RawFragment = "", ""
+	return u.String()
+}
@@ -0,0 +1,161 @@
+package session
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/bytedance/sonic"
+	"github.com/openanp/anp-go/anptest"
+)
+
+// directoryHost serves /dir.json listing the agents returned by agents, and
+// an empty agent description at every other .json path except /missing.json.
+func directoryHost(t *testing.T, agents func() []string) *httptest.Server {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/dir.json":
+			var list []string
+			for _, u := range agents() {
+				list = append(list, fmt.Sprintf(`{"name": %q, "url": %q}`, u, u))
+			}
+			fmt.Fprintf(w, `{"name": "Directory", "agentList": [%s]}`, strings.Join(list, ","))
+		case "/missing.json":
+			http.NotFound(w, r)
+		default:
+			fmt.Fprintf(w, `{"name": %q}`, r.URL.Path)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestCrawl(t *testing.T) {
+	caller := anptest.NewIdentity(t, "client.example.com")
+	sess, err := New(Config{Authenticator: caller.Authenticator})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	// a and b are directories that list each other.
+	var a, b *httptest.Server
+	a = directoryHost(t, func() []string {
+		return []string{"one.json", "two.json", b.URL + "/dir.json"}
+	})
+	b = directoryHost(t, func() []string {
+		return []string{a.URL + "/dir.json#top", "/missing.json", "deep.json"}
+	})
+	ctx := context.Background()
+	seed := a.URL + "/dir.json"
+
+	graph, err := sess.Crawl(ctx, CrawlConfig{}, seed, seed+"#dup")
+	if err != nil {
+		t.Fatalf("Crawl() error = %v", err)
+	}
+	if len(graph.Seeds) != 1 || graph.Seeds[0] != seed {
+		t.Errorf("Crawl() seeds = %v, want [%s]", graph.Seeds, seed)
+	}
+	wantDepth := map[string]int{
+		seed:                    0,
+		a.URL + "/one.json":     1,
+		a.URL + "/two.json":     1,
+		b.URL + "/dir.json":     1,
+		b.URL + "/missing.json": 2,
+		b.URL + "/deep.json":    2,
+	}
+	if len(graph.Nodes) != len(wantDepth) {
+		t.Errorf("Crawl() nodes = %d, want %d", len(graph.Nodes), len(wantDepth))
+	}
+	for u, depth := range wantDepth {
+		node := graph.Node(u)
+		if node == nil {
+			t.Errorf("Crawl() missing node %s", u)
+			continue
+		}
+		if node.Depth != depth {
+			t.Errorf("node %s depth = %d, want %d", u, node.Depth, depth)
+		}
+	}
+	if n := graph.Node(b.URL + "/missing.json"); n == nil || n.Err == nil || n.Error == "" {
+		t.Errorf("missing.json node = %+v, want fetch error", n)
+	}
+	if n := graph.Node(b.URL + "/deep.json"); n == nil || n.Parent != b.URL+"/dir.json" || n.Document == nil {
+		t.Errorf("deep.json node = %+v, want document with parent b", n)
+	}
+
+	cycles := graph.Cycles()
+	if len(cycles) != 1 || cycles[0].From != b.URL+"/dir.json" || cycles[0].To != seed {
+		t.Errorf("Cycles() = %+v, want b -> a", cycles)
+	}
+
+	var walked []string
+	for node := range graph.Walk() {
+		walked = append(walked, node.URL)
+	}
+	if len(walked) != len(wantDepth) || walked[0] != seed {
+		t.Errorf("Walk() = %v", walked)
+	}
+	for i := 1; i < len(walked); i++ {
+		if graph.Node(walked[i]).Depth < graph.Node(walked[i-1]).Depth {
+			t.Errorf("Walk() is not breadth-first: %v", walked)
+		}
+	}
+
+	raw, err := sonic.Marshal(graph)
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	var decoded CrawlGraph
+	if err := sonic.Unmarshal(raw, &decoded); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	if len(decoded.Nodes) != len(graph.Nodes) || len(decoded.Links) != len(graph.Links) ||
+		len(decoded.Cycles()) != 1 || decoded.Node(b.URL+"/missing.json").Error == "" {
+		t.Errorf("decoded graph = %s", raw)
+	}
+}
+
+func TestCrawlLimits(t *testing.T) {
+	caller := anptest.NewIdentity(t, "client.example.com")
+	sess, err := New(Config{Authenticator: caller.Authenticator})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	var a, b *httptest.Server
+	a = directoryHost(t, func() []string {
+		return []string{"one.json", "two.json", "three.json", b.URL + "/dir.json"}
+	})
+	b = directoryHost(t, func() []string { return []string{"deep.json"} })
+	ctx := context.Background()
+	seed := a.URL + "/dir.json"
+
+	tests := []struct {
+		name string
+		cfg  CrawlConfig
+		want int
+	}{
+		{"depth", CrawlConfig{MaxDepth: 1}, 5},
+		{"urls", CrawlConfig{MaxURLs: 3}, 3},
+		{"per host", CrawlConfig{MaxPerHost: 2}, 4},
+		{"allowed hosts", CrawlConfig{AllowedHosts: []string{hostOf(a.URL)}}, 4},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			graph, err := sess.Crawl(ctx, tt.cfg, seed)
+			if err != nil {
+				t.Fatalf("Crawl() error = %v", err)
+			}
+			if len(graph.Nodes) != tt.want {
+				t.Errorf("Crawl() nodes = %d, want %d", len(graph.Nodes), tt.want)
+			}
+			// Every link is recorded even when the limits skip its target.
+			if len(graph.Links) < len(graph.Nodes)-1 {
+				t.Errorf("Crawl() links = %d, want at least %d", len(graph.Links), len(graph.Nodes)-1)
+			}
+		})
+	}
+}
@@ -0,0 +1,34 @@
+package session
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+
+	"github.com/openanp/anp-go/anp_crawler"
+)
+
+// parsedDocument is what Config.DedupeDocuments shares between documents
+// with the same content.
+type parsedDocument struct {
+	raw        []byte
+	result     *anp_crawler.ParseResult
+	tools      []*anp_crawler.ANPTool
+	interfaces []*anp_crawler.ANPInterface
+}
+
+func contentHash(body []byte) string {
+	sum := sha256.Sum256(body)
+	return hex.EncodeToString(sum[:])
+}
+
+// duplicateDocument builds the Document for a response whose body was
+// already parsed, sharing the earlier copy's content and releasing this one's.
+func (s *Session) duplicateDocument(url string, resp *anp_crawler.Response, hash string, parsed *parsedDocument) *Document {
+	doc := s.newDocument(url, resp, hash)
+	if doc.Raw != nil {
+		resp.Release()
+		doc.Raw = parsed.raw
+	}
+	doc.Result, doc.Tools, doc.Interfaces = parsed.result, parsed.tools, parsed.interfaces
+	return doc
+}
@@ -0,0 +1,78 @@
+package session
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/openanp/anp-go/anp_crawler"
+	"github.com/openanp/anp-go/anptest"
+)
+
+// countingParser counts the documents it parses.
+type countingParser struct {
+	anp_crawler.Parser
+	calls atomic.Int32
+}
+
+func (p *countingParser) Parse(ctx context.Context, content []byte, contentType, sourceURL string) (*anp_crawler.ParseResult, error) {
+	p.calls.Add(1)
+	return p.Parser.Parse(ctx, content, contentType, sourceURL)
+}
+
+func TestDedupeDocuments(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		method := "search"
+		if r.URL.Path == "/other.json" {
+			method = "book"
+		}
+		fmt.Fprintf(w, `{"openrpc": "1.3.2", "servers": [{"url": "https://agent.example.com/rpc"}], "methods": [{"name": %q, "description": "Method under test", "params": [{"name": "q", "schema": {"type": "string"}}]}]}`, method)
+	}))
+	defer srv.Close()
+	caller := anptest.NewIdentity(t, "client.example.com")
+
+	for _, dedupe := range []bool{false, true} {
+		t.Run(fmt.Sprint("dedupe=", dedupe), func(t *testing.T) {
+			parser := &countingParser{Parser: anp_crawler.NewJSONParser()}
+			sess, err := New(Config{
+				Authenticator:   caller.Authenticator,
+				Parser:          ParserConfig{Parser: parser},
+				DedupeDocuments: dedupe,
+			})
+			if err != nil {
+				t.Fatalf("New() error = %v", err)
+			}
+			urls := []string{srv.URL + "/a.json", srv.URL + "/b.json", srv.URL + "/other.json"}
+			var docs []*Document
+			for _, u := range urls {
+				doc, err := sess.Fetch(context.Background(), u)
+				if err != nil {
+					t.Fatalf("Fetch(%s) error = %v", u, err)
+				}
+				docs = append(docs, doc)
+			}
+
+			want := int32(3)
+			if dedupe {
+				want = 2
+			}
+			if n := parser.calls.Load(); n != want {
+				t.Errorf("parses = %d, want %d", n, want)
+			}
+			a, b, other := docs[0], docs[1], docs[2]
+			if a.ContentHash == "" || a.ContentHash != b.ContentHash || a.ContentHash == other.ContentHash {
+				t.Errorf("ContentHash = %q, %q, %q", a.ContentHash, b.ContentHash, other.ContentHash)
+			}
+			if b.URL != urls[1] || len(b.Interfaces) != 1 || b.Interfaces[0].Method != "search" || string(b.Raw) != string(a.Raw) {
+				t.Errorf("duplicate document = %+v", b)
+			}
+			if len(other.Tools) != 1 || other.Tools[0].Function.Name != "book" {
+				t.Errorf("other document tools = %+v", other.Tools)
+			}
+		})
+	}
+}
@@ -0,0 +1,195 @@
+package session
+
+import (
+	"context"
+	"net/url"
+	"sync"
+)
+
+// Link kinds reported in DocumentGraph.Links.
+const (
+	// LinkInterface points from an agent description to an interface
+	// document it declares by URL.
+	LinkInterface = "interface"
+	// LinkAgent points from a directory to an agent listed in agentList.
+	LinkAgent = "agent"
+)
+
+// DeepOptions controls FetchDeep. Zero values select the defaults.
+type DeepOptions struct {
+	// MaxDepth is how many links are followed from the root (default 1):
+	// 1 fetches the documents the root links to, 2 also their links, and
+	// so on.
+	MaxDepth int
+	// MaxDocuments caps the documents fetched, including the root
+	// (default 100).
+	MaxDocuments int
+	// SkipAgents follows only interface URLs, not agentList entries.
+	SkipAgents bool
+}
+
+// Link is an edge of a DocumentGraph from the document at From to the one at
+// To.
+type Link struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+	Kind string `json:"kind"`
+}
+
+// DocumentGraph is the result of FetchDeep: every document reached from the
+// root, the links between them, and the fetches that failed.
+type DocumentGraph struct {
+	Root      *Document
+	Documents map[string]*Document
+	Links     []Link
+	// Errors holds the fetch error of every linked URL that failed; the
+	// rest of the graph is still returned.
+	Errors map[string]error
+	// Merged combines the tools and interfaces of all documents, in
+	// breadth-first order, so ExecuteTool can call any of them. A tool name
+	// declared by several documents resolves to the one nearest the root.
+	Merged *Document
+}
+
+// FetchDeep fetches the document at url and follows the interface URLs it
+// declares and, for directories, the agents it lists, up to opts.MaxDepth
+// links away. It replaces fetching ad.json and then each interface document
+// by hand. Only the root fetch failing is an error; failures further down are
+// collected in DocumentGraph.Errors. Each URL is fetched once, so cyclic links
+// terminate.
+func (s *Session) FetchDeep(ctx context.Context, url string, opts DeepOptions) (*DocumentGraph, error) {
+	if opts.MaxDepth <= 0 {
+		opts.MaxDepth = 1
+	}
+	if opts.MaxDocuments <= 0 {
+		opts.MaxDocuments = 100
+	}
+
+	root, err := s.Fetch(ctx, url)
+	if err != nil {
+		return nil, err
+	}
+	graph := &DocumentGraph{
+		Root:      root,
+		Documents: map[string]*Document{url: root},
+		Errors:    make(map[string]error),
+	}
+	order := []*Document{root}
+	seen := map[string]bool{url: true}
+
+	level := []*Document{root}
+	for depth := 1; depth <= opts.MaxDepth && len(level) > 0; depth++ {
+		var next []string
+		for _, doc := range level {
+			for _, link := range documentLinks(doc, opts.SkipAgents) {
+				graph.Links = append(graph.Links, link)
+				if seen[link.To] || len(seen) >= opts.MaxDocuments {
+					continue
+				}
+				seen[link.To] = true
+				next = append(next, link.To)
+			}
+		}
+
+		docs, errs := s.fetchAll(ctx, next)
+		level = level[:0]
+		for i, u := range next {
+			if errs[i] != nil {
+				graph.Errors[u] = errs[i]
+				continue
+			}
+			graph.Documents[u] = docs[i]
+			level = append(level, docs[i])
+			order = append(order, docs[i])
+		}
+		if ctx.Err() != nil {
+			break
+		}
+	}
+
+	graph.Merged = mergeDocuments(root, order)
+	return graph, nil
+}
+
+// fetchAll fetches urls concurrently within the session's concurrency limit,
+// or politely when the session has a Politeness policy, returning each URL's
+// document or error at its index.
+func (s *Session) fetchAll(ctx context.Context, urls []string) ([]*Document, []error) {
+	if s.politeness != nil {
+		return s.fetchPolitely(ctx, urls)
+	}
+	docs := make([]*Document, len(urls))
+	errs := make([]error, len(urls))
+	var wg sync.WaitGroup
+	for i, u := range urls {
+		if err := s.sem.Acquire(ctx, 1); err != nil {
+			errs[i] = err
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer s.sem.Release(1)
+			docs[i], errs[i] = s.Fetch(ctx, u)
+		}()
+	}
+	wg.Wait()
+	return docs, errs
+}
+
+// documentLinks returns the outgoing links of doc: the URLs of interfaces it
+// declares by reference and, unless skipAgents, of the agents it lists,
+// resolved against doc.URL.
+func documentLinks(doc *Document, skipAgents bool) []Link {
+	if doc == nil || doc.Result == nil {
+		return nil
+	}
+	base, _ := url.Parse(doc.URL)
+	var links []Link
+	add := func(raw, kind string) {
+		if raw == "" {
+			return
+		}
+		if base != nil {
+			if ref, err := url.Parse(raw); err == nil {
+				raw = base.ResolveReference(ref).String()
+			}
+		}
+		if raw != doc.URL {
+			links = append(links, Link{From: doc.URL, To: raw, Kind: kind})
+		}
+	}
+	for _, entry := range doc.Result.Interfaces {
+		add(entry.URL, LinkInterface)
+	}
+	if !skipAgents {
+		for _, agent := range doc.Result.Agents {
+			add(agent.URL, LinkAgent)
+		}
+	}
+	return links
+}
+
+// mergeDocuments returns a document carrying the tools and interfaces of
+// docs, keeping the first of any duplicated tool name.
+func mergeDocuments(root *Document, docs []*Document) *Document {
+	merged := *root
+	merged.Tools, merged.Interfaces = nil, nil
+	seenTools := make(map[string]bool)
+	seenIfaces := make(map[string]bool)
+	for _, doc := range docs {
+		for _, tool := range doc.Tools {
+			if !seenTools[tool.Function.Name] {
+				seenTools[tool.Function.Name] = true
+				merged.Tools = append(merged.Tools, tool)
+			}
+		}
+		for _, iface := range doc.Interfaces {
+			if !seenIfaces[iface.ToolName] {
+				seenIfaces[iface.ToolName] = true
+				merged.Interfaces = append(merged.Interfaces, iface)
+			}
+		}
+	}
+	return &merged
+}
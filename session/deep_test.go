@@ -0,0 +1,83 @@
+package session
+
+import (
+	"context"
+	"testing"
+
+	"github.com/openanp/anp-go/anptest"
+)
+
+func TestFetchDeep(t *testing.T) {
+	caller := anptest.NewIdentity(t, "client.example.com")
+	srv := anptest.NewServer(t,
+		anptest.WithMethod("add", func(p addParams) (int, error) { return p.A + p.B, nil }),
+		// The directory lists the agent, a broken link and itself.
+		anptest.WithDocument("/directory.json", `{"name": "Directory", "agentList": [
+			{"name": "adder", "url": "ad.json"},
+			{"name": "gone", "url": "/missing.json"},
+			{"name": "self", "url": "/directory.json"}
+		]}`),
+		anptest.WithDIDAuth(caller),
+	)
+	sess, err := New(Config{Authenticator: caller.Authenticator})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	ctx := context.Background()
+	root := srv.URL + "/directory.json"
+
+	graph, err := sess.FetchDeep(ctx, root, DeepOptions{MaxDepth: 2})
+	if err != nil {
+		t.Fatalf("FetchDeep() error = %v", err)
+	}
+	for _, u := range []string{root, srv.ADURL(), srv.OpenRPCURL()} {
+		if graph.Documents[u] == nil {
+			t.Errorf("FetchDeep() missing document %s", u)
+		}
+	}
+	if len(graph.Documents) != 3 {
+		t.Errorf("FetchDeep() documents = %d, want 3", len(graph.Documents))
+	}
+	if graph.Errors[srv.URL+"/missing.json"] == nil || len(graph.Errors) != 1 {
+		t.Errorf("FetchDeep() errors = %v, want only missing.json", graph.Errors)
+	}
+	want := []Link{
+		{From: root, To: srv.ADURL(), Kind: LinkAgent},
+		{From: root, To: srv.URL + "/missing.json", Kind: LinkAgent},
+		{From: srv.ADURL(), To: srv.OpenRPCURL(), Kind: LinkInterface},
+	}
+	if len(graph.Links) != len(want) {
+		t.Fatalf("FetchDeep() links = %+v, want %+v", graph.Links, want)
+	}
+	for i := range want {
+		if graph.Links[i] != want[i] {
+			t.Errorf("FetchDeep() link %d = %+v, want %+v", i, graph.Links[i], want[i])
+		}
+	}
+
+	result, err := ExecuteToolAs[int](ctx, graph.Merged, "add", map[string]any{"a": 2, "b": 3})
+	if err != nil || result != 5 {
+		t.Errorf("ExecuteToolAs(merged, add) = %d, %v, want 5", result, err)
+	}
+
+	shallow, err := sess.FetchDeep(ctx, root, DeepOptions{})
+	if err != nil {
+		t.Fatalf("FetchDeep(depth 1) error = %v", err)
+	}
+	if shallow.Documents[srv.OpenRPCURL()] != nil || shallow.Documents[srv.ADURL()] == nil {
+		t.Errorf("FetchDeep(depth 1) documents = %d, want root and ad.json", len(shallow.Documents))
+	}
+
+	agentsSkipped, err := sess.FetchDeep(ctx, root, DeepOptions{MaxDepth: 2, SkipAgents: true})
+	if err != nil {
+		t.Fatalf("FetchDeep(SkipAgents) error = %v", err)
+	}
+	if len(agentsSkipped.Documents) != 1 || len(agentsSkipped.Links) != 0 {
+		t.Errorf("FetchDeep(SkipAgents) = %d documents, %d links, want root only",
+			len(agentsSkipped.Documents), len(agentsSkipped.Links))
+	}
+
+	if _, err := sess.FetchDeep(ctx, srv.URL+"/missing.json", DeepOptions{}); err == nil {
+		t.Error("FetchDeep(missing root) error = nil, want error")
+	}
+}
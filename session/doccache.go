@@ -0,0 +1,128 @@
+package session
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// documentCacheName labels the session document cache in
+// DocumentCacheConfig.Observe, e.g. metrics.Metrics.ObserveCacheLookup.
+const documentCacheName = "documents"
+
+// DocumentCacheConfig enables the in-session document cache: Session.Fetch
+// serves repeated fetches of a URL from memory, without downloading or
+// parsing the document again, until the entry expires.
+type DocumentCacheConfig struct {
+	// TTL is how long a fetched document is served from the cache. Zero
+	// keeps it while it is fresh (see Document.Stale), which honours the
+	// server's Cache-Control max-age and Config.DocumentMaxAge.
+	TTL time.Duration
+	// MaxEntries caps the number of cached documents; the least recently
+	// used are evicted first. Zero means no limit.
+	MaxEntries int
+	// Observe, if set, is called for every lookup with the cache name
+	// "documents" and whether it hit, e.g. metrics.Metrics.ObserveCacheLookup.
+	Observe func(cache string, hit bool)
+}
+
+// documentCache is an LRU of fetched documents keyed by URL.
+type documentCache struct {
+	cfg DocumentCacheConfig
+
+	mu      sync.Mutex
+	entries map[string]*list.Element // of *cachedDocument, most recent first
+	lru     list.List
+}
+
+type cachedDocument struct {
+	doc       *Document
+	expiresAt time.Time
+}
+
+func newDocumentCache(cfg *DocumentCacheConfig) *documentCache {
+	if cfg == nil {
+		return nil
+	}
+	return &documentCache{cfg: *cfg, entries: make(map[string]*list.Element)}
+}
+
+func (c *documentCache) get(url string) (*Document, bool) {
+	if c == nil {
+		return nil, false
+	}
+	doc, ok := c.lookup(url)
+	if c.cfg.Observe != nil {
+		c.cfg.Observe(documentCacheName, ok)
+	}
+	return doc, ok
+}
+
+func (c *documentCache) lookup(url string) (*Document, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	el, ok := c.entries[url]
+	if !ok {
+		return nil, false
+	}
+	entry := el.Value.(*cachedDocument)
+	if !time.Now().Before(entry.expiresAt) {
+		c.removeLocked(el)
+		return nil, false
+	}
+	c.lru.MoveToFront(el)
+	return entry.doc, true
+}
+
+func (c *documentCache) put(doc *Document) {
+	if c == nil {
+		return
+	}
+	expiresAt := doc.ExpiresAt
+	if c.cfg.TTL > 0 {
+		expiresAt = doc.FetchedAt.Add(c.cfg.TTL)
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if el, ok := c.entries[doc.URL]; ok {
+		c.removeLocked(el)
+	}
+	if !doc.FetchedAt.Before(expiresAt) {
+		return
+	}
+	c.entries[doc.URL] = c.lru.PushFront(&cachedDocument{doc: doc, expiresAt: expiresAt})
+	for c.cfg.MaxEntries > 0 && c.lru.Len() > c.cfg.MaxEntries {
+		c.removeLocked(c.lru.Back())
+	}
+}
+
+// forget drops the entries for urls, or every entry when urls is empty.
+func (c *documentCache) forget(urls []string) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(urls) == 0 {
+		clear(c.entries)
+		c.lru.Init()
+		return
+	}
+	for _, url := range urls {
+		if el, ok := c.entries[url]; ok {
+			c.removeLocked(el)
+		}
+	}
+}
+
+func (c *documentCache) removeLocked(el *list.Element) {
+	entry := c.lru.Remove(el).(*cachedDocument)
+	delete(c.entries, entry.doc.URL)
+}
+
+// ForgetDocuments drops the given URLs from the document cache, or the whole
+// cache when none are given, so the next Fetch downloads them again. It does
+// nothing without Config.DocumentCache.
+func (s *Session) ForgetDocuments(urls ...string) {
+	s.docs.forget(urls)
+}
@@ -0,0 +1,93 @@
+package session
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/openanp/anp-go/anptest"
+)
+
+func TestDocumentCache(t *testing.T) {
+	var fetches atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetches.Add(1)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(benchmarkAD(1))
+	}))
+	defer srv.Close()
+	caller := anptest.NewIdentity(t, "client.example.com")
+	ctx := context.Background()
+
+	lookups := map[bool]int{}
+	sess, err := New(Config{
+		Authenticator: caller.Authenticator,
+		DocumentCache: &DocumentCacheConfig{
+			TTL:        50 * time.Millisecond,
+			MaxEntries: 1,
+			Observe: func(cache string, hit bool) {
+				if cache != "documents" {
+					t.Errorf("Observe() cache = %q", cache)
+				}
+				lookups[hit]++
+			},
+		},
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	a := srv.URL + "/a.json"
+	first, err := sess.Fetch(ctx, a)
+	if err != nil {
+		t.Fatalf("Fetch() error = %v", err)
+	}
+	if again, err := sess.Fetch(ctx, a); err != nil || again != first {
+		t.Errorf("Fetch(cached) = %p, %v, want the cached document", again, err)
+	}
+	if n := fetches.Load(); n != 1 {
+		t.Errorf("server fetches = %d, want 1", n)
+	}
+
+	// MaxEntries evicts the least recently used URL.
+	if _, err := sess.Fetch(ctx, srv.URL+"/b.json"); err != nil {
+		t.Fatalf("Fetch(b) error = %v", err)
+	}
+	if _, err := sess.Fetch(ctx, a); err != nil || fetches.Load() != 3 {
+		t.Errorf("Fetch(evicted) fetches = %d, err = %v, want 3", fetches.Load(), err)
+	}
+
+	// Entries expire after TTL, and ForgetDocuments drops them early.
+	time.Sleep(60 * time.Millisecond)
+	if _, err := sess.Fetch(ctx, a); err != nil || fetches.Load() != 4 {
+		t.Errorf("Fetch(expired) fetches = %d, err = %v, want 4", fetches.Load(), err)
+	}
+	sess.ForgetDocuments(a)
+	if _, err := sess.Fetch(ctx, a); err != nil || fetches.Load() != 5 {
+		t.Errorf("Fetch(forgotten) fetches = %d, err = %v, want 5", fetches.Load(), err)
+	}
+	if lookups[true] != 1 || lookups[false] != 5 {
+		t.Errorf("lookups = %v, want 1 hit and 5 misses", lookups)
+	}
+
+	// Without a TTL, entries live as long as the document is fresh.
+	fresh, err := New(Config{Authenticator: caller.Authenticator, DocumentMaxAge: time.Hour, DocumentCache: &DocumentCacheConfig{}})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	stale, err := New(Config{Authenticator: caller.Authenticator, DocumentCache: &DocumentCacheConfig{}})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	for _, s := range []*Session{fresh, stale, fresh, stale} {
+		if _, err := s.Fetch(ctx, a); err != nil {
+			t.Fatalf("Fetch() error = %v", err)
+		}
+	}
+	if n := fetches.Load(); n != 8 {
+		t.Errorf("server fetches = %d, want 8", n)
+	}
+}
@@ -0,0 +1,158 @@
+package session
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/bytedance/sonic"
+	"github.com/openanp/anp-go/anp_debug"
+)
+
+// DryRunConfig enables dry-run mode: tool calls and other writes are built and
+// signed as usual but never sent. Document fetches (GET and HEAD) still reach
+// the network so plans can be made against live interfaces.
+type DryRunConfig struct {
+	// Mock, when set, serves intercepted requests instead of the synthetic
+	// reply, e.g. an anp_server.Server with stubbed methods.
+	Mock http.Handler
+	// Passthrough lists JSON-RPC methods that are still sent, such as
+	// read-only searches.
+	Passthrough []string
+}
+
+// PlannedRequest is a request intercepted in dry-run mode.
+type PlannedRequest struct {
+	Method string
+	URL    string
+	Header http.Header
+	Body   []byte
+	// RPCMethod is the JSON-RPC method when the body is a JSON-RPC request.
+	RPCMethod string
+}
+
+// PlannedRequests returns the requests intercepted so far in dry-run mode.
+func (s *Session) PlannedRequests() []PlannedRequest {
+	if s.dryRun == nil {
+		return nil
+	}
+	s.dryRun.mu.Lock()
+	defer s.dryRun.mu.Unlock()
+	return slices.Clone(s.dryRun.planned)
+}
+
+// dryRunTransport intercepts writes before they reach base.
+type dryRunTransport struct {
+	base http.RoundTripper
+	cfg  DryRunConfig
+
+	mu      sync.Mutex
+	planned []PlannedRequest
+}
+
+type rpcEnvelope struct {
+	JSONRPC string `json:"jsonrpc"`
+	ID      any    `json:"id,omitempty"`
+	Method  string `json:"method"`
+}
+
+func (t *dryRunTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Method == http.MethodGet || req.Method == http.MethodHead {
+		return t.transport().RoundTrip(req)
+	}
+
+	var body []byte
+	if req.Body != nil {
+		var err error
+		body, err = io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		req.Body = io.NopCloser(bytes.NewReader(body))
+	}
+	var rpc rpcEnvelope
+	if sonic.Unmarshal(body, &rpc) != nil || rpc.JSONRPC == "" {
+		rpc = rpcEnvelope{}
+	}
+	if rpc.Method != "" && slices.Contains(t.cfg.Passthrough, rpc.Method) {
+		return t.transport().RoundTrip(req)
+	}
+
+	planned := PlannedRequest{
+		Method:    req.Method,
+		URL:       req.URL.String(),
+		Header:    req.Header.Clone(),
+		Body:      body,
+		RPCMethod: rpc.Method,
+	}
+	t.mu.Lock()
+	t.planned = append(t.planned, planned)
+	t.mu.Unlock()
+
+	if t.cfg.Mock != nil {
+		rec := httptest.NewRecorder()
+		t.cfg.Mock.ServeHTTP(rec, req)
+		resp := rec.Result()
+		resp.Request = req
+		return resp, nil
+	}
+	return syntheticResponse(req, planned, rpc)
+}
+
+func (t *dryRunTransport) transport() http.RoundTripper {
+	if t.base != nil {
+		return t.base
+	}
+	return http.DefaultTransport
+}
+
+// syntheticResponse describes planned as a successful reply: a JSON-RPC
+// result echoing the request ID for tool calls, a plain object otherwise.
+// Credentials are redacted since the reply may be shown to a model.
+func syntheticResponse(req *http.Request, planned PlannedRequest, rpc rpcEnvelope) (*http.Response, error) {
+	headers := make(map[string]string, len(planned.Header))
+	for name, values := range planned.Header {
+		value := strings.Join(values, ", ")
+		if strings.EqualFold(name, "Authorization") {
+			value = anp_debug.RedactAuthorization(value)
+		}
+		headers[name] = value
+	}
+	var decoded any
+	if len(planned.Body) > 0 && sonic.Unmarshal(planned.Body, &decoded) != nil {
+		decoded = string(planned.Body)
+	}
+	reply := map[string]any{
+		"dryRun": true,
+		"request": map[string]any{
+			"method":  planned.Method,
+			"url":     planned.URL,
+			"headers": headers,
+			"body":    decoded,
+		},
+	}
+	var out any = reply
+	if rpc.Method != "" {
+		out = map[string]any{"jsonrpc": "2.0", "id": rpc.ID, "result": reply}
+	}
+	raw, err := sonic.ConfigStd.Marshal(out)
+	if err != nil {
+		return nil, err
+	}
+	return &http.Response{
+		Status:        "200 OK",
+		StatusCode:    http.StatusOK,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        http.Header{"Content-Type": {"application/json"}},
+		Body:          io.NopCloser(bytes.NewReader(raw)),
+		ContentLength: int64(len(raw)),
+		Request:       req,
+	}, nil
+}
@@ -0,0 +1,100 @@
+package session
+
+import (
+	"context"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/openanp/anp-go/anp_server"
+	"github.com/openanp/anp-go/anptest"
+)
+
+func TestDryRun(t *testing.T) {
+	caller := anptest.NewIdentity(t, "client.example.com")
+	var booked, searched atomic.Int32
+	srv := anptest.NewServer(t,
+		anptest.WithMethod("book", func(p addParams) (string, error) { booked.Add(1); return "booked", nil }),
+		anptest.WithMethod("search", func() ([]string, error) { searched.Add(1); return []string{"room"}, nil }),
+		anptest.WithDIDAuth(caller),
+	)
+
+	sess, err := New(Config{
+		Authenticator: caller.Authenticator,
+		DryRun:        &DryRunConfig{Passthrough: []string{"search"}},
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	ctx := context.Background()
+	doc, err := sess.Fetch(ctx, srv.OpenRPCURL())
+	if err != nil {
+		t.Fatalf("Fetch() error = %v", err)
+	}
+
+	result, err := ExecuteTool(ctx, doc, "book", map[string]any{"a": 1, "b": 2})
+	if err != nil {
+		t.Fatalf("ExecuteTool(book) error = %v", err)
+	}
+	if booked.Load() != 0 {
+		t.Error("dry-run call reached the server")
+	}
+	reply, _ := result["result"].(map[string]any)
+	req, _ := reply["request"].(map[string]any)
+	if reply["dryRun"] != true || req["url"] != srv.RPCURL() {
+		t.Fatalf("ExecuteTool(book) = %v", result)
+	}
+	headers, _ := req["headers"].(map[string]any)
+	if auth, _ := headers["Authorization"].(string); !strings.Contains(auth, "[REDACTED]") {
+		t.Errorf("Authorization = %q, want redacted", auth)
+	}
+	if body, _ := req["body"].(map[string]any); body["method"] != "book" {
+		t.Errorf("body = %v", req["body"])
+	}
+
+	if _, err := ExecuteTool(ctx, doc, "search", nil); err != nil {
+		t.Fatalf("ExecuteTool(search) error = %v", err)
+	}
+	if searched.Load() != 1 {
+		t.Errorf("passthrough calls = %d, want 1", searched.Load())
+	}
+
+	planned := sess.PlannedRequests()
+	if len(planned) != 1 || planned[0].RPCMethod != "book" || planned[0].Method != "POST" {
+		t.Fatalf("PlannedRequests() = %+v", planned)
+	}
+	// The token issued while fetching the document is reused, as it would be live.
+	if !strings.HasPrefix(planned[0].Header.Get("Authorization"), "Bearer ") {
+		t.Errorf("planned Authorization = %q, want bearer token", planned[0].Header.Get("Authorization"))
+	}
+}
+
+func TestDryRunMock(t *testing.T) {
+	caller := anptest.NewIdentity(t, "client.example.com")
+	var booked atomic.Int32
+	srv := anptest.NewServer(t,
+		anptest.WithMethod("book", func(p addParams) (string, error) { booked.Add(1); return "booked", nil }),
+		anptest.WithDIDAuth(caller),
+	)
+	mock := anp_server.New(anp_server.Config{})
+	if err := mock.Register("book", func(p addParams) (string, error) { return "mock booking", nil }); err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+
+	sess, err := New(Config{Authenticator: caller.Authenticator, DryRun: &DryRunConfig{Mock: mock}})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	ctx := context.Background()
+	doc, err := sess.Fetch(ctx, srv.OpenRPCURL())
+	if err != nil {
+		t.Fatalf("Fetch() error = %v", err)
+	}
+	result, err := ExecuteTool(ctx, doc, "book", map[string]any{"a": 1, "b": 2})
+	if err != nil {
+		t.Fatalf("ExecuteTool() error = %v", err)
+	}
+	if result["result"] != "mock booking" || booked.Load() != 0 {
+		t.Errorf("ExecuteTool() = %v, server calls = %d", result, booked.Load())
+	}
+}
@@ -0,0 +1,63 @@
+package session
+
+import (
+	"context"
+	"testing"
+
+	"github.com/openanp/anp-go/anptest"
+)
+
+type sumResult struct {
+	Sum   int      `json:"sum"`
+	Terms []string `json:"terms"`
+}
+
+func TestExecuteToolAs(t *testing.T) {
+	caller := anptest.NewIdentity(t, "client.example.com")
+	srv := anptest.NewServer(t,
+		anptest.WithMethod("add", func(p addParams) (sumResult, error) {
+			return sumResult{Sum: p.A + p.B, Terms: []string{"a", "b"}}, nil
+		}),
+		anptest.WithMethod("count", func() (int, error) { return 3, nil }),
+		anptest.WithDIDAuth(caller),
+	)
+	sess, err := New(Config{Authenticator: caller.Authenticator})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	ctx := context.Background()
+	doc, err := sess.Fetch(ctx, srv.OpenRPCURL())
+	if err != nil {
+		t.Fatalf("Fetch() error = %v", err)
+	}
+
+	sum, err := ExecuteToolAs[sumResult](ctx, doc, "add", map[string]any{"a": 2, "b": 3})
+	if err != nil {
+		t.Fatalf("ExecuteToolAs(add) error = %v", err)
+	}
+	if sum.Sum != 5 || len(sum.Terms) != 2 {
+		t.Errorf("ExecuteToolAs(add) = %+v", sum)
+	}
+
+	count, err := ExecuteToolAs[int](ctx, doc, "count", nil)
+	if err != nil || count != 3 {
+		t.Errorf("ExecuteToolAs(count) = %d, %v, want 3", count, err)
+	}
+
+	if _, err := ExecuteToolAs[sumResult](ctx, doc, "count", nil); err == nil {
+		t.Error("ExecuteToolAs(count) into a struct error = nil, want decode error")
+	}
+	if _, err := ExecuteToolAs[int](ctx, doc, "missing", nil); err == nil {
+		t.Error("ExecuteToolAs(missing) error = nil, want error")
+	}
+}
+
+func TestDecodeResult(t *testing.T) {
+	var out []string
+	if err := DecodeResult(map[string]any{"result": []any{"x", "y"}}, &out); err != nil || len(out) != 2 {
+		t.Errorf("DecodeResult() = %v, %v", out, err)
+	}
+	if err := DecodeResult(map[string]any{"jsonrpc": "2.0"}, &out); err == nil {
+		t.Error("DecodeResult(no result) error = nil, want error")
+	}
+}
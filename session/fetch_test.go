@@ -0,0 +1,138 @@
+package session
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/openanp/anp-go/anp_debug"
+	"github.com/openanp/anp-go/anptest"
+)
+
+// benchmarkAD renders an agent description embedding an OpenRPC document with
+// n methods, roughly the size of a real hotel or map agent.
+func benchmarkAD(n int) []byte {
+	var methods []string
+	for i := range n {
+		methods = append(methods, fmt.Sprintf(`{"name":"method_%d","summary":"Method %d","description":"Looks up records for method %d","params":[{"name":"query","required":true,"schema":{"type":"string"}},{"name":"limit","schema":{"type":"integer"}}],"result":{"name":"result","schema":{"type":"object","properties":{"items":{"type":"array","items":{"type":"string"}}}}}}`, i, i, i))
+	}
+	return []byte(`{"protocolType":"ANP","protocolVersion":"1.0.0","type":"AgentDescription","name":"Bench","interfaces":[{"type":"StructuredInterface","protocol":"openrpc","content":{"openrpc":"1.3.2","info":{"title":"Bench","version":"1.0.0"},"servers":[{"name":"rpc","url":"https://bench.example.com/rpc"}],"methods":[` +
+		strings.Join(methods, ",") + `]}}]}`)
+}
+
+// bodyTransport answers every request with body, without a network round trip.
+type bodyTransport []byte
+
+func (b bodyTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	return &http.Response{
+		StatusCode:    http.StatusOK,
+		Header:        http.Header{"Content-Type": {"application/json"}},
+		Body:          io.NopCloser(bytes.NewReader(b)),
+		ContentLength: int64(len(b)),
+		Request:       req,
+	}, nil
+}
+
+func newBodySession(tb testing.TB, body []byte, dropRaw bool) *Session {
+	tb.Helper()
+	caller := anptest.NewIdentity(tb, "client.example.com")
+	sess, err := New(Config{
+		Authenticator: caller.Authenticator,
+		HTTP:          HTTPConfig{Client: &http.Client{Transport: bodyTransport(body)}},
+		DropRaw:       dropRaw,
+	})
+	if err != nil {
+		tb.Fatalf("New() error = %v", err)
+	}
+	return sess
+}
+
+func TestFetchDropRaw(t *testing.T) {
+	sess := newBodySession(t, benchmarkAD(3), true)
+	ctx := context.Background()
+	first, err := sess.Fetch(ctx, "https://bench.example.com/ad.json")
+	if err != nil {
+		t.Fatalf("Fetch() error = %v", err)
+	}
+	// A second fetch reuses the released buffer; the first document must not
+	// have kept references into it.
+	if _, err := sess.Fetch(ctx, "https://bench.example.com/ad.json"); err != nil {
+		t.Fatalf("Fetch() error = %v", err)
+	}
+	if first.Raw != nil || first.ContentString() != "" {
+		t.Errorf("Raw = %d bytes, want dropped", len(first.Raw))
+	}
+	if len(first.Interfaces) != 3 || first.ProtocolVersion != "1.0.0" {
+		t.Fatalf("document = %d interfaces, version %q", len(first.Interfaces), first.ProtocolVersion)
+	}
+	for i, entry := range first.Result.Interfaces {
+		if want := "method_" + strconv.Itoa(i); entry.MethodName != want {
+			t.Errorf("interface %d = %q, want %q", i, entry.MethodName, want)
+		}
+	}
+}
+
+// BenchmarkFetch parses a 40-method agent description per iteration and
+// reports throughput in documents per minute; a registry crawl needs about
+// 10k/min. Compare allocations of keep-raw and drop-raw with -benchmem.
+func BenchmarkFetch(b *testing.B) {
+	body := benchmarkAD(40)
+	for _, tc := range []struct {
+		name    string
+		dropRaw bool
+	}{{"keep-raw", false}, {"drop-raw", true}} {
+		b.Run(tc.name, func(b *testing.B) {
+			sess := newBodySession(b, body, tc.dropRaw)
+			ctx := context.Background()
+			b.SetBytes(int64(len(body)))
+			b.ReportAllocs()
+			b.ResetTimer()
+			for b.Loop() {
+				if _, err := sess.Fetch(ctx, "https://bench.example.com/ad.json"); err != nil {
+					b.Fatalf("Fetch() error = %v", err)
+				}
+			}
+			b.ReportMetric(float64(b.N)/b.Elapsed().Minutes(), "docs/min")
+		})
+	}
+}
+
+func TestFetchTranscript(t *testing.T) {
+	caller := anptest.NewIdentity(t, "client.example.com")
+	srv := anptest.NewServer(t,
+		anptest.WithMethod("add", func(p addParams) (int, error) { return p.A + p.B, nil }),
+		anptest.WithDIDAuth(caller),
+	)
+	transcript := &anp_debug.Transcript{}
+	sess, err := New(Config{Authenticator: caller.Authenticator, Transcript: transcript})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	ctx := context.Background()
+	doc, err := sess.Fetch(ctx, srv.OpenRPCURL())
+	if err != nil {
+		t.Fatalf("Fetch() error = %v", err)
+	}
+	if _, err := ExecuteTool(ctx, doc, "add", map[string]any{"a": 1, "b": 2}); err != nil {
+		t.Fatalf("ExecuteTool() error = %v", err)
+	}
+
+	exchanges := transcript.Exchanges()
+	if len(exchanges) < 2 {
+		t.Fatalf("Exchanges() = %d, want the fetch and the tool call", len(exchanges))
+	}
+	last := exchanges[len(exchanges)-1]
+	if last.Request.Method != http.MethodPost || last.Request.URL != srv.RPCURL() || last.Response.Status != http.StatusOK {
+		t.Errorf("tool call exchange = %+v", last)
+	}
+	for _, ex := range exchanges {
+		if auth := ex.Request.Header.Get("Authorization"); auth != "" && !strings.Contains(auth, "[REDACTED]") {
+			t.Errorf("recorded Authorization = %q, want it redacted", auth)
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Stale reports whether doc has outlived its freshness lifetime and should be
+// refetched before its tools are trusted; see Config.DocumentMaxAge.
+func (d *Document) Stale() bool {
+	return d == nil || !time.Now().Before(d.ExpiresAt)
+}
+
+// Refresh returns doc unchanged while it is fresh and otherwise fetches its URL
+// again, so long-lived processes can call it before every tool lookup without
+// refetching each time. The refreshed document is a new value; doc itself is
+// not modified. With a response cache configured (see
+// anp_crawler.WithResponseCache) an unchanged document costs only a 304.
+// A stale document is refetched even if the session's document cache still
+// holds it, and replaces the cached entry.
+func (s *Session) Refresh(ctx context.Context, doc *Document) (*Document, error) {
+	if doc == nil {
+		return nil, errors.New("anp/session: document is nil")
+	}
+	if !doc.Stale() {
+		return doc, nil
+	}
+	start := time.Now()
+	fresh, err := s.fetch(ctx, doc.URL)
+	s.observeFetch(ctx, FetchEvent{URL: doc.URL, Document: fresh, Duration: time.Since(start), Err: err})
+	if err != nil {
+		return nil, err
+	}
+	s.docs.put(fresh)
+	return fresh, nil
+}
+
+// freshness returns how long a document fetched with header stays fresh:
+// the server's Cache-Control max-age when it sends one, otherwise fallback.
+func freshness(header http.Header, fallback time.Duration) time.Duration {
+	maxAge := fallback
+	for _, directive := range strings.Split(header.Get("Cache-Control"), ",") {
+		name, value, _ := strings.Cut(strings.TrimSpace(directive), "=")
+		switch strings.ToLower(name) {
+		case "no-cache", "no-store":
+			return 0
+		case "max-age":
+			if secs, err := strconv.Atoi(strings.Trim(value, `"`)); err == nil && secs >= 0 {
+				maxAge = time.Duration(secs) * time.Second
+			}
+		}
+	}
+	return maxAge
+}
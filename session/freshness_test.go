@@ -0,0 +1,91 @@
+package session
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/openanp/anp-go/anptest"
+)
+
+func TestFreshness(t *testing.T) {
+	tests := []struct {
+		cacheControl string
+		want         time.Duration
+	}{
+		{"", time.Minute},
+		{"public, max-age=300", 5 * time.Minute},
+		{"max-age=0", 0},
+		{"max-age=60, no-cache", 0},
+		{"no-store", 0},
+		{"max-age=soon", time.Minute},
+	}
+	for _, tt := range tests {
+		header := http.Header{}
+		if tt.cacheControl != "" {
+			header.Set("Cache-Control", tt.cacheControl)
+		}
+		if got := freshness(header, time.Minute); got != tt.want {
+			t.Errorf("freshness(%q) = %v, want %v", tt.cacheControl, got, tt.want)
+		}
+	}
+}
+
+func TestRefresh(t *testing.T) {
+	var fetches atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetches.Add(1)
+		if cc := r.URL.Query().Get("cc"); cc != "" {
+			w.Header().Set("Cache-Control", cc)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(benchmarkAD(1))
+	}))
+	defer srv.Close()
+	caller := anptest.NewIdentity(t, "client.example.com")
+	ctx := context.Background()
+
+	tests := []struct {
+		name        string
+		maxAge      time.Duration
+		query       string
+		wantRefetch bool
+	}{
+		{"fresh by config", time.Hour, "", false},
+		{"stale by default", 0, "", true},
+		{"server max-age wins", 0, "?cc=max-age%3D3600", false},
+		{"server no-cache wins", time.Hour, "?cc=no-cache", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sess, err := New(Config{Authenticator: caller.Authenticator, DocumentMaxAge: tt.maxAge})
+			if err != nil {
+				t.Fatalf("New() error = %v", err)
+			}
+			doc, err := sess.Fetch(ctx, srv.URL+"/ad.json"+tt.query)
+			if err != nil {
+				t.Fatalf("Fetch() error = %v", err)
+			}
+			before := fetches.Load()
+			refreshed, err := sess.Refresh(ctx, doc)
+			if err != nil {
+				t.Fatalf("Refresh() error = %v", err)
+			}
+			refetched := fetches.Load() != before
+			if refetched != tt.wantRefetch || (refreshed == doc) == tt.wantRefetch {
+				t.Errorf("Refresh() refetched = %v, want %v", refetched, tt.wantRefetch)
+			}
+			if len(refreshed.Tools) != 1 || refreshed.FetchedAt.Before(doc.FetchedAt) {
+				t.Errorf("Refresh() = %d tools fetched at %v", len(refreshed.Tools), refreshed.FetchedAt)
+			}
+		})
+	}
+
+	sess, _ := New(Config{Authenticator: caller.Authenticator})
+	if _, err := sess.Refresh(ctx, nil); err == nil {
+		t.Error("Refresh(nil) error = nil, want error")
+	}
+}
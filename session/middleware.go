@@ -0,0 +1,84 @@
+package session
+
+import (
+	"context"
+
+	"github.com/openanp/anp-go/anp_crawler"
+)
+
+// ToolCall is a tool execution passing through the session's ToolMiddleware.
+type ToolCall struct {
+	// Name is the tool name and Method the JSON-RPC method or OpenAPI
+	// operation it calls.
+	Name   string
+	Method string
+	// URL is the endpoint the call goes to: the JSON-RPC server, or the
+	// server and path template of an OpenAPI operation.
+	URL string
+	// Arguments are sent as the call's params; middleware may replace or
+	// edit them before calling the next handler.
+	Arguments map[string]any
+	Interface *anp_crawler.ANPInterface
+}
+
+// ToolHandler executes a tool call.
+type ToolHandler func(ctx context.Context, call *ToolCall) (map[string]any, error)
+
+// ToolMiddleware wraps the execution of every tool call made through
+// interfaces the session created, for logging, argument rewriting, redaction
+// or approval. It returns a handler that calls next to proceed.
+type ToolMiddleware func(next ToolHandler) ToolHandler
+
+// BeforeTool returns middleware that runs fn before each call. fn may edit
+// call.Arguments; an error from it fails the call without sending it.
+func BeforeTool(fn func(ctx context.Context, call *ToolCall) error) ToolMiddleware {
+	return func(next ToolHandler) ToolHandler {
+		return func(ctx context.Context, call *ToolCall) (map[string]any, error) {
+			if err := fn(ctx, call); err != nil {
+				return nil, err
+			}
+			return next(ctx, call)
+		}
+	}
+}
+
+// AfterTool returns middleware that passes the outcome of each call through
+// fn, which returns the outcome to report instead.
+func AfterTool(fn func(ctx context.Context, call *ToolCall, resp map[string]any, err error) (map[string]any, error)) ToolMiddleware {
+	return func(next ToolHandler) ToolHandler {
+		return func(ctx context.Context, call *ToolCall) (map[string]any, error) {
+			resp, err := next(ctx, call)
+			return fn(ctx, call, resp, err)
+		}
+	}
+}
+
+// toolIntercept composes chain, the first middleware outermost, into an
+// ANPInterface.Intercept hook, or returns nil for an empty chain.
+func toolIntercept(chain []ToolMiddleware) func(context.Context, *anp_crawler.ANPInterface, map[string]any, anp_crawler.ExecuteFunc) (map[string]any, error) {
+	if len(chain) == 0 {
+		return nil
+	}
+	return func(ctx context.Context, iface *anp_crawler.ANPInterface, args map[string]any, next anp_crawler.ExecuteFunc) (map[string]any, error) {
+		h := ToolHandler(func(ctx context.Context, call *ToolCall) (map[string]any, error) {
+			return next(ctx, call.Arguments)
+		})
+		for i := len(chain) - 1; i >= 0; i-- {
+			h = chain[i](h)
+		}
+		return h(ctx, &ToolCall{
+			Name:      iface.ToolName,
+			Method:    iface.Method,
+			URL:       toolURL(iface),
+			Arguments: args,
+			Interface: iface,
+		})
+	}
+}
+
+func toolURL(iface *anp_crawler.ANPInterface) string {
+	if len(iface.Servers) == 0 {
+		return ""
+	}
+	return iface.Servers[0].URL + iface.Entry.Path
+}
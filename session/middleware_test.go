@@ -0,0 +1,89 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+
+	"github.com/openanp/anp-go/anptest"
+)
+
+func TestToolMiddleware(t *testing.T) {
+	caller := anptest.NewIdentity(t, "client.example.com")
+	var served atomic.Int32
+	srv := anptest.NewServer(t,
+		anptest.WithMethod("add", func(p addParams) (int, error) { served.Add(1); return p.A + p.B, nil }),
+		anptest.WithDIDAuth(caller),
+	)
+
+	var order []string
+	errBlocked := errors.New("blocked")
+	trace := func(name string) ToolMiddleware {
+		return func(next ToolHandler) ToolHandler {
+			return func(ctx context.Context, call *ToolCall) (map[string]any, error) {
+				order = append(order, name+">")
+				resp, err := next(ctx, call)
+				order = append(order, "<"+name)
+				return resp, err
+			}
+		}
+	}
+	sess, err := New(Config{
+		Authenticator: caller.Authenticator,
+		ToolMiddleware: []ToolMiddleware{
+			trace("outer"),
+			BeforeTool(func(ctx context.Context, call *ToolCall) error {
+				if call.Name != "add" || call.Method != "add" || call.URL != srv.RPCURL() {
+					t.Errorf("ToolCall = %+v", call)
+				}
+				if call.Arguments["a"] == 13.0 {
+					return errBlocked
+				}
+				// Rewrite: b defaults to 10.
+				if _, ok := call.Arguments["b"]; !ok {
+					call.Arguments["b"] = 10
+				}
+				return nil
+			}),
+			AfterTool(func(ctx context.Context, call *ToolCall, resp map[string]any, err error) (map[string]any, error) {
+				if err == nil {
+					resp["redacted"] = true
+				}
+				return resp, err
+			}),
+			trace("inner"),
+		},
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	ctx := context.Background()
+	doc, err := sess.Fetch(ctx, srv.OpenRPCURL())
+	if err != nil {
+		t.Fatalf("Fetch() error = %v", err)
+	}
+
+	resp, err := ExecuteTool(ctx, doc, "add", map[string]any{"a": 2.0})
+	if err != nil {
+		t.Fatalf("ExecuteTool() error = %v", err)
+	}
+	var sum int
+	if err := DecodeResult(resp, &sum); err != nil || sum != 12 || resp["redacted"] != true {
+		t.Errorf("ExecuteTool() = %v, want rewritten sum 12 and redacted", resp)
+	}
+	if got := len(order); got != 4 || order[0] != "outer>" || order[1] != "inner>" || order[2] != "<inner" || order[3] != "<outer" {
+		t.Errorf("middleware order = %v", order)
+	}
+
+	// Registry and streaming calls go through the chain too.
+	if _, err := sess.Tools().Execute(ctx, "add", map[string]any{"a": 13.0}); !errors.Is(err, errBlocked) {
+		t.Errorf("Execute(blocked) error = %v, want errBlocked", err)
+	}
+	if _, err := doc.Interfaces[0].ExecuteStream(ctx, map[string]any{"a": 13.0}, nil); !errors.Is(err, errBlocked) {
+		t.Errorf("ExecuteStream(blocked) error = %v, want errBlocked", err)
+	}
+	if n := served.Load(); n != 1 {
+		t.Errorf("server calls = %d, want 1", n)
+	}
+}
@@ -0,0 +1,94 @@
+package session
+
+import (
+	"context"
+	"time"
+)
+
+// Observer receives lifecycle events of a Session for telemetry or progress
+// display, without wrapping every call site. Methods are called
+// synchronously on the caller's goroutine, possibly from several at once,
+// so they must be quick and safe for concurrent use. Embed NopObserver to
+// implement only some of them.
+type Observer interface {
+	// OnFetch is called after every Fetch and Refresh, including those made
+	// by FetchBatch, FetchDeep and Crawl.
+	OnFetch(ctx context.Context, ev FetchEvent)
+	// OnToolCall is called after every tool call made through the
+	// interfaces of fetched documents, including calls blocked by
+	// middleware or approval.
+	OnToolCall(ctx context.Context, ev ToolCallEvent)
+	// OnError is called for every fetch or tool call that failed, after
+	// OnFetch or OnToolCall.
+	OnError(ctx context.Context, ev ErrorEvent)
+}
+
+// FetchEvent describes a completed document fetch.
+type FetchEvent struct {
+	URL string
+	// Document is nil when the fetch failed.
+	Document *Document
+	// Cached reports that the document came from the session's document
+	// cache without a request.
+	Cached   bool
+	Duration time.Duration
+	Err      error
+}
+
+// ToolCallEvent describes a completed tool call.
+type ToolCallEvent struct {
+	Call     *ToolCall
+	Response map[string]any
+	Duration time.Duration
+	Err      error
+}
+
+// Operations reported in ErrorEvent.Op.
+const (
+	OpFetch    = "fetch"
+	OpToolCall = "tool_call"
+)
+
+// ErrorEvent describes a failed fetch or tool call.
+type ErrorEvent struct {
+	// Op is OpFetch or OpToolCall.
+	Op string
+	// URL is the document URL or the tool call's endpoint, and Tool the
+	// name of the tool called.
+	URL  string
+	Tool string
+	Err  error
+}
+
+// NopObserver is an Observer that ignores every event.
+type NopObserver struct{}
+
+func (NopObserver) OnFetch(context.Context, FetchEvent)       {}
+func (NopObserver) OnToolCall(context.Context, ToolCallEvent) {}
+func (NopObserver) OnError(context.Context, ErrorEvent)       {}
+
+func (s *Session) observeFetch(ctx context.Context, ev FetchEvent) {
+	if s.observer == nil {
+		return
+	}
+	s.observer.OnFetch(ctx, ev)
+	if ev.Err != nil {
+		s.observer.OnError(ctx, ErrorEvent{Op: OpFetch, URL: ev.URL, Err: ev.Err})
+	}
+}
+
+// observeTools returns the outermost tool middleware, which reports every
+// call to o.
+func observeTools(o Observer) ToolMiddleware {
+	return func(next ToolHandler) ToolHandler {
+		return func(ctx context.Context, call *ToolCall) (map[string]any, error) {
+			start := time.Now()
+			resp, err := next(ctx, call)
+			o.OnToolCall(ctx, ToolCallEvent{Call: call, Response: resp, Duration: time.Since(start), Err: err})
+			if err != nil {
+				o.OnError(ctx, ErrorEvent{Op: OpToolCall, URL: call.URL, Tool: call.Name, Err: err})
+			}
+			return resp, err
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/openanp/anp-go/anptest"
+)
+
+type recordingObserver struct {
+	mu     sync.Mutex
+	fetch  []FetchEvent
+	calls  []ToolCallEvent
+	errors []ErrorEvent
+}
+
+func (o *recordingObserver) OnFetch(_ context.Context, ev FetchEvent) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.fetch = append(o.fetch, ev)
+}
+
+func (o *recordingObserver) OnToolCall(_ context.Context, ev ToolCallEvent) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.calls = append(o.calls, ev)
+}
+
+func (o *recordingObserver) OnError(_ context.Context, ev ErrorEvent) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.errors = append(o.errors, ev)
+}
+
+func TestObserver(t *testing.T) {
+	caller := anptest.NewIdentity(t, "client.example.com")
+	srv := anptest.NewServer(t,
+		anptest.WithMethod("add", func(p addParams) (int, error) { return p.A + p.B, nil }),
+		anptest.WithDIDAuth(caller),
+	)
+	obs := &recordingObserver{}
+	sess, err := New(Config{
+		Authenticator:  caller.Authenticator,
+		Observer:       obs,
+		DocumentCache:  &DocumentCacheConfig{},
+		DocumentMaxAge: time.Hour,
+		Approve: func(ctx context.Context, call *ToolCall) (Approval, error) {
+			if call.Arguments["a"] == 13.0 {
+				return Approval{Decision: Deny}, nil
+			}
+			return Approval{Decision: Allow}, nil
+		},
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	ctx := context.Background()
+	doc, err := sess.Fetch(ctx, srv.OpenRPCURL())
+	if err != nil {
+		t.Fatalf("Fetch() error = %v", err)
+	}
+	if _, err := sess.Fetch(ctx, srv.OpenRPCURL()); err != nil {
+		t.Fatalf("Fetch(cached) error = %v", err)
+	}
+	missing := srv.OpenRPCURL() + ".missing"
+	if _, err := sess.Fetch(ctx, missing); err == nil {
+		t.Fatal("Fetch(missing) error = nil")
+	}
+	if _, err := ExecuteTool(ctx, doc, "add", map[string]any{"a": 1.0, "b": 2.0}); err != nil {
+		t.Fatalf("ExecuteTool() error = %v", err)
+	}
+	if _, err := ExecuteTool(ctx, doc, "add", map[string]any{"a": 13.0, "b": 2.0}); !errors.Is(err, ErrToolDenied) {
+		t.Fatalf("ExecuteTool(denied) error = %v", err)
+	}
+
+	if len(obs.fetch) != 3 || obs.fetch[0].Document != doc || obs.fetch[0].Cached || !obs.fetch[1].Cached || obs.fetch[2].Err == nil {
+		t.Errorf("fetch events = %+v", obs.fetch)
+	}
+	if len(obs.calls) != 2 || obs.calls[0].Call.Name != "add" || obs.calls[0].Err != nil || obs.calls[0].Response == nil || !errors.Is(obs.calls[1].Err, ErrToolDenied) {
+		t.Errorf("tool call events = %+v", obs.calls)
+	}
+	if len(obs.errors) != 2 || obs.errors[0].Op != OpFetch || obs.errors[0].URL != missing ||
+		obs.errors[1].Op != OpToolCall || obs.errors[1].Tool != "add" || obs.errors[1].URL != srv.RPCURL() {
+		t.Errorf("error events = %+v", obs.errors)
+	}
+}
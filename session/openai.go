@@ -0,0 +1,149 @@
+package session
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/bytedance/sonic"
+	"github.com/openanp/anp-go/anp_crawler"
+)
+
+// OpenAITool is an entry of the tools array of an OpenAI chat completions
+// request.
+type OpenAITool struct {
+	Type     string         `json:"type"`
+	Function OpenAIFunction `json:"function"`
+}
+
+// OpenAIFunction describes a function tool to OpenAI.
+type OpenAIFunction struct {
+	Name        string         `json:"name"`
+	Description string         `json:"description,omitempty"`
+	Parameters  map[string]any `json:"parameters"`
+}
+
+// OpenAIToolCall is an entry of the tool_calls of a chat completion message,
+// and decodes from the API response as is.
+type OpenAIToolCall struct {
+	ID       string `json:"id"`
+	Type     string `json:"type"`
+	Function struct {
+		Name string `json:"name"`
+		// Arguments is the JSON object of arguments, encoded as a string.
+		Arguments string `json:"arguments"`
+	} `json:"function"`
+}
+
+// OpenAIToolMessage is the "tool" message that answers a tool call in the
+// next chat completions request.
+type OpenAIToolMessage struct {
+	Role       string `json:"role"`
+	ToolCallID string `json:"tool_call_id"`
+	Content    string `json:"content"`
+}
+
+// OpenAITools converts tools, e.g. Document.Tools or ToolRegistry.Tools, to
+// the tools array of an OpenAI chat completions request:
+//
+//	body := map[string]any{"model": model, "messages": msgs, "tools": session.OpenAITools(sess.Tools().Tools())}
+func OpenAITools(tools []*anp_crawler.ANPTool) []OpenAITool {
+	out := make([]OpenAITool, 0, len(tools))
+	for _, tool := range tools {
+		out = append(out, OpenAITool{
+			Type: "function",
+			Function: OpenAIFunction{
+				Name:        tool.Function.Name,
+				Description: tool.Function.Description,
+				Parameters:  toolParameters(tool),
+			},
+		})
+	}
+	return out
+}
+
+// toolParameters returns the JSON schema of a tool's arguments, with the
+// empty properties and required lists the APIs expect rather than nulls.
+func toolParameters(tool *anp_crawler.ANPTool) map[string]any {
+	p := tool.Function.Parameters
+	typ := p.Type
+	if typ == "" {
+		typ = "object"
+	}
+	properties := p.Properties
+	if properties == nil {
+		properties = map[string]any{}
+	}
+	required := p.Required
+	if required == nil {
+		required = []string{}
+	}
+	return map[string]any{"type": typ, "properties": properties, "required": required}
+}
+
+// DispatchOpenAI executes the tool calls of a chat completion message with
+// the registry's tools and returns the tool messages answering them, in
+// order. A call that fails, such as one naming an unknown tool, is answered
+// with {"error": "..."} so the model can recover.
+func (r *ToolRegistry) DispatchOpenAI(ctx context.Context, calls ...OpenAIToolCall) []OpenAIToolMessage {
+	return dispatchOpenAI(ctx, r.Execute, calls)
+}
+
+// DispatchOpenAI is ToolRegistry.DispatchOpenAI for the tools of a single
+// document.
+func DispatchOpenAI(ctx context.Context, doc *Document, calls ...OpenAIToolCall) []OpenAIToolMessage {
+	return dispatchOpenAI(ctx, func(ctx context.Context, name string, args map[string]any) (map[string]any, error) {
+		return ExecuteTool(ctx, doc, name, args)
+	}, calls)
+}
+
+type executeFunc func(ctx context.Context, name string, args map[string]any) (map[string]any, error)
+
+func dispatchOpenAI(ctx context.Context, execute executeFunc, calls []OpenAIToolCall) []OpenAIToolMessage {
+	messages := make([]OpenAIToolMessage, len(calls))
+	for i, call := range calls {
+		args, err := toolArguments(call.Function.Name, []byte(call.Function.Arguments))
+		var resp map[string]any
+		if err == nil {
+			resp, err = execute(ctx, call.Function.Name, args)
+		}
+		content, _ := toolOutcome(resp, err)
+		messages[i] = OpenAIToolMessage{Role: "tool", ToolCallID: call.ID, Content: content}
+	}
+	return messages
+}
+
+// toolArguments decodes the JSON arguments of a tool call; empty means none.
+func toolArguments(name string, raw []byte) (map[string]any, error) {
+	args := map[string]any{}
+	if len(raw) == 0 {
+		return args, nil
+	}
+	if err := sonic.Unmarshal(raw, &args); err != nil {
+		return nil, fmt.Errorf("invalid arguments for %s: %v", name, err)
+	}
+	return args, nil
+}
+
+// toolOutcome encodes the outcome of a tool call for a model: the JSON-RPC
+// result, the whole response when it has none, or the call's error. failed
+// reports an error or a JSON-RPC error response.
+func toolOutcome(resp map[string]any, err error) (content string, failed bool) {
+	if err != nil {
+		return renderToolError(err), true
+	}
+	payload := any(resp)
+	result, ok := resp["result"]
+	if ok {
+		payload = result
+	}
+	encoded, err := sonic.MarshalString(payload)
+	if err != nil {
+		return renderToolError(fmt.Errorf("encode tool result: %v", err)), true
+	}
+	return encoded, !ok && resp["error"] != nil
+}
+
+func renderToolError(err error) string {
+	encoded, _ := sonic.MarshalString(map[string]string{"error": err.Error()})
+	return encoded
+}
@@ -0,0 +1,93 @@
+package session
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/bytedance/sonic"
+	"github.com/openanp/anp-go/anp_crawler"
+	"github.com/openanp/anp-go/anptest"
+)
+
+func TestOpenAITools(t *testing.T) {
+	tools := OpenAITools([]*anp_crawler.ANPTool{
+		{Type: "function", Function: anp_crawler.Function{
+			Name:        "add",
+			Description: "Adds two numbers",
+			Parameters: anp_crawler.Parameters{
+				Type:       "object",
+				Properties: map[string]any{"a": map[string]any{"type": "integer"}},
+				Required:   []string{"a"},
+			},
+		}},
+		{Type: "function", Function: anp_crawler.Function{Name: "ping"}},
+	})
+	raw, err := sonic.MarshalString(tools)
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	var got []map[string]any
+	if err := sonic.UnmarshalString(raw, &got); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	if len(got) != 2 || got[0]["type"] != "function" {
+		t.Fatalf("OpenAITools() = %s", raw)
+	}
+	fn := got[0]["function"].(map[string]any)
+	params := fn["parameters"].(map[string]any)
+	if fn["name"] != "add" || fn["description"] != "Adds two numbers" || params["type"] != "object" || len(params["required"].([]any)) != 1 {
+		t.Errorf("OpenAITools()[0] = %s", raw)
+	}
+	// A tool without parameters still declares an empty object schema.
+	empty := got[1]["function"].(map[string]any)["parameters"].(map[string]any)
+	if empty["type"] != "object" || empty["properties"] == nil || empty["required"] == nil {
+		t.Errorf("OpenAITools()[1] parameters = %v", empty)
+	}
+}
+
+func TestDispatchOpenAI(t *testing.T) {
+	caller := anptest.NewIdentity(t, "client.example.com")
+	srv := anptest.NewServer(t,
+		anptest.WithMethod("add", func(p addParams) (int, error) { return p.A + p.B, nil }),
+		anptest.WithDIDAuth(caller),
+	)
+	sess, err := New(Config{Authenticator: caller.Authenticator})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	ctx := context.Background()
+	doc, err := sess.Fetch(ctx, srv.OpenRPCURL())
+	if err != nil {
+		t.Fatalf("Fetch() error = %v", err)
+	}
+
+	// The tool_calls of an assistant message, as the API returns them.
+	var message struct {
+		ToolCalls []OpenAIToolCall `json:"tool_calls"`
+	}
+	if err := sonic.UnmarshalString(`{"role": "assistant", "content": null, "tool_calls": [
+		{"id": "call_1", "type": "function", "function": {"name": "add", "arguments": "{\"a\": 2, \"b\": 3}"}},
+		{"id": "call_2", "type": "function", "function": {"name": "missing", "arguments": "{}"}},
+		{"id": "call_3", "type": "function", "function": {"name": "add", "arguments": "not json"}}
+	]}`, &message); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+
+	for name, replies := range map[string][]OpenAIToolMessage{
+		"registry": sess.Tools().DispatchOpenAI(ctx, message.ToolCalls...),
+		"document": DispatchOpenAI(ctx, doc, message.ToolCalls...),
+	} {
+		if len(replies) != 3 {
+			t.Fatalf("%s: DispatchOpenAI() = %d messages, want 3", name, len(replies))
+		}
+		if r := replies[0]; r.Role != "tool" || r.ToolCallID != "call_1" || r.Content != "5" {
+			t.Errorf("%s: reply 1 = %+v, want content 5", name, r)
+		}
+		for _, r := range replies[1:] {
+			if !strings.HasPrefix(r.Content, `{"error":`) {
+				t.Errorf("%s: reply %s = %q, want error", name, r.ToolCallID, r.Content)
+			}
+		}
+	}
+}
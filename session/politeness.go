@@ -0,0 +1,81 @@
+package session
+
+import (
+	"context"
+	"time"
+)
+
+// Politeness limits the load FetchBatch and FetchDeep put on each host, so
+// directory operators see a well-behaved crawler rather than blocking its DID.
+// Fetches then go through a Scheduler; NewScheduler and Crawl also take their
+// unset limits from it. Zero values select the Scheduler defaults.
+type Politeness struct {
+	// CrawlDelay is the minimum delay between starting two fetches to the
+	// same host.
+	CrawlDelay time.Duration
+	// PerHostConcurrent caps fetches in flight to one host (default 1).
+	PerHostConcurrent int
+	// MaxRetries is how often a URL answered with 429 Too Many Requests is
+	// retried after waiting out its Retry-After (default 3).
+	MaxRetries int
+	// Backoff and MaxBackoff bound the wait after a 429 without Retry-After
+	// (defaults 1s and 1m).
+	Backoff    time.Duration
+	MaxBackoff time.Duration
+}
+
+// schedulerDefaults fills the unset limits of cfg from the session's
+// politeness policy.
+func (s *Session) schedulerDefaults(cfg SchedulerConfig) SchedulerConfig {
+	p := s.politeness
+	if p == nil {
+		return cfg
+	}
+	if cfg.PerHostConcurrent <= 0 {
+		cfg.PerHostConcurrent = p.PerHostConcurrent
+	}
+	if cfg.PerHostInterval <= 0 {
+		cfg.PerHostInterval = p.CrawlDelay
+	}
+	if cfg.MaxRetries <= 0 {
+		cfg.MaxRetries = p.MaxRetries
+	}
+	if cfg.Backoff <= 0 {
+		cfg.Backoff = p.Backoff
+	}
+	if cfg.MaxBackoff <= 0 {
+		cfg.MaxBackoff = p.MaxBackoff
+	}
+	return cfg
+}
+
+// fetchPolitely fetches urls through a Scheduler bounded by the session's
+// concurrency limit and politeness policy, returning each URL's document or
+// error at its index.
+func (s *Session) fetchPolitely(ctx context.Context, urls []string) ([]*Document, []error) {
+	docs := make([]*Document, len(urls))
+	errs := make([]error, len(urls))
+	at := make(map[string][]int, len(urls))
+	for i, u := range urls {
+		at[u] = append(at[u], i)
+	}
+
+	sched := s.NewScheduler(SchedulerConfig{
+		MaxConcurrent: s.maxConcurrent,
+		OnResult: func(r CrawlResult) {
+			for _, i := range at[r.URL] {
+				docs[i], errs[i] = r.Document, r.Err
+			}
+		},
+	})
+	sched.Add(urls...)
+	if err := sched.Run(ctx); err != nil {
+		// URLs the cancelled crawl never finished report ctx's error.
+		for i := range urls {
+			if docs[i] == nil && errs[i] == nil {
+				errs[i] = err
+			}
+		}
+	}
+	return docs, errs
+}
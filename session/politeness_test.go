@@ -0,0 +1,59 @@
+package session
+
+import (
+	"context"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/openanp/anp-go/anptest"
+)
+
+func TestFetchBatchPoliteness(t *testing.T) {
+	caller := anptest.NewIdentity(t, "client.example.com")
+	sess, err := New(Config{
+		Authenticator: caller.Authenticator,
+		MaxConcurrent: 8,
+		Politeness:    &Politeness{CrawlDelay: 20 * time.Millisecond, Backoff: 10 * time.Millisecond},
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	var limited atomic.Bool
+	host := newCountingHost(t, func(w http.ResponseWriter, r *http.Request) bool {
+		if r.URL.Path == "/c.json" && limited.CompareAndSwap(false, true) {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return true
+		}
+		return false
+	})
+	urls := []string{host.URL + "/a.json", host.URL + "/b.json", host.URL + "/c.json", host.URL + "/a.json"}
+
+	start := time.Now()
+	docs, err := sess.FetchBatch(context.Background(), urls)
+	if err != nil {
+		t.Fatalf("FetchBatch() error = %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < 3*20*time.Millisecond {
+		t.Errorf("FetchBatch() took %v, want crawl delay between fetches", elapsed)
+	}
+	if p := host.peak.Load(); p != 1 {
+		t.Errorf("peak per-host concurrency = %d, want 1", p)
+	}
+	// a.json is fetched once for both of its positions; c.json is retried.
+	if hits := host.hits.Load(); hits != 4 {
+		t.Errorf("requests = %d, want 4", hits)
+	}
+	for i, doc := range docs {
+		if doc == nil || doc.URL != urls[i] {
+			t.Errorf("FetchBatch()[%d] = %+v, want %s", i, doc, urls[i])
+		}
+	}
+
+	// Unset scheduler limits come from the session policy.
+	if cfg := sess.NewScheduler(SchedulerConfig{}).cfg; cfg.PerHostInterval != 20*time.Millisecond || cfg.PerHostConcurrent != 1 {
+		t.Errorf("NewScheduler() config = %+v", cfg)
+	}
+}
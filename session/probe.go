@@ -0,0 +1,153 @@
+package session
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/openanp/anp-go/anp_crawler"
+)
+
+const defaultProbeTimeout = 5 * time.Second
+
+// ProbeOptions controls Session.Probe.
+type ProbeOptions struct {
+	// PingMethod, when set, is invoked on every server that exposes it, so the
+	// report reflects whether the agent actually answers JSON-RPC calls.
+	PingMethod string
+	PingParams map[string]any
+	// Timeout bounds each reachability check and ping (default 5s).
+	Timeout time.Duration
+}
+
+// ServerHealth is the outcome of probing one interface server.
+type ServerHealth struct {
+	URL string
+	// Methods lists the document's interface methods served from URL.
+	Methods []string
+	// Reachable reports whether the server returned any HTTP response.
+	Reachable  bool
+	StatusCode int
+	Latency    time.Duration
+	// Pinged reports whether PingMethod was invoked; PingLatency and Err then
+	// describe the call.
+	Pinged      bool
+	PingLatency time.Duration
+	Err         error
+}
+
+// Healthy reports whether the server responded without a server error and, if
+// pinged, answered the ping.
+func (h ServerHealth) Healthy() bool {
+	return h.Reachable && h.StatusCode < http.StatusInternalServerError && h.Err == nil
+}
+
+// HealthReport summarises the servers behind a document's interfaces.
+type HealthReport struct {
+	URL       string
+	CheckedAt time.Time
+	Servers   []ServerHealth
+}
+
+// Healthy reports whether the document lists at least one server and every
+// server is healthy.
+func (r *HealthReport) Healthy() bool {
+	if r == nil || len(r.Servers) == 0 {
+		return false
+	}
+	for _, s := range r.Servers {
+		if !s.Healthy() {
+			return false
+		}
+	}
+	return true
+}
+
+// Probe checks that every server referenced by doc's interfaces is reachable,
+// and optionally answers opts.PingMethod, before real traffic is routed to the
+// agent. Servers are checked concurrently within the session's concurrency
+// limit; failures are reported per server rather than returned.
+func (s *Session) Probe(ctx context.Context, doc *Document, opts ProbeOptions) *HealthReport {
+	report := &HealthReport{CheckedAt: time.Now()}
+	if doc == nil {
+		return report
+	}
+	report.URL = doc.URL
+	if opts.Timeout <= 0 {
+		opts.Timeout = defaultProbeTimeout
+	}
+
+	type target struct {
+		health ServerHealth
+		ping   *anp_crawler.ANPInterface
+	}
+	var targets []*target
+	byURL := make(map[string]*target)
+	for _, iface := range doc.Interfaces {
+		for _, server := range iface.Servers {
+			if server.URL == "" {
+				continue
+			}
+			t, ok := byURL[server.URL]
+			if !ok {
+				t = &target{health: ServerHealth{URL: server.URL}}
+				byURL[server.URL] = t
+				targets = append(targets, t)
+			}
+			if iface.Method != "" {
+				t.health.Methods = append(t.health.Methods, iface.Method)
+			}
+			if opts.PingMethod != "" && iface.Method == opts.PingMethod && t.ping == nil {
+				pinned := *iface
+				pinned.Servers = []anp_crawler.Server{server}
+				t.ping = &pinned
+			}
+		}
+	}
+
+	var wg sync.WaitGroup
+	for _, t := range targets {
+		if err := s.sem.Acquire(ctx, 1); err != nil {
+			t.health.Err = err
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer s.sem.Release(1)
+			s.probeServer(ctx, &t.health, t.ping, opts)
+		}()
+	}
+	wg.Wait()
+
+	for _, t := range targets {
+		report.Servers = append(report.Servers, t.health)
+	}
+	return report
+}
+
+func (s *Session) probeServer(ctx context.Context, h *ServerHealth, ping *anp_crawler.ANPInterface, opts ProbeOptions) {
+	checkCtx, cancel := context.WithTimeout(ctx, opts.Timeout)
+	start := time.Now()
+	resp, err := s.client.Fetch(checkCtx, http.MethodGet, h.URL, nil, nil)
+	h.Latency = time.Since(start)
+	cancel()
+	if err != nil {
+		h.Err = err
+		s.logger.Debug("probe failed", "url", h.URL, "error", err)
+		return
+	}
+	h.Reachable = true
+	h.StatusCode = resp.StatusCode
+
+	if ping == nil {
+		return
+	}
+	pingCtx, cancel := context.WithTimeout(ctx, opts.Timeout)
+	defer cancel()
+	h.Pinged = true
+	start = time.Now()
+	_, h.Err = ping.Execute(pingCtx, opts.PingParams)
+	h.PingLatency = time.Since(start)
+}
@@ -0,0 +1,80 @@
+package session
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/openanp/anp-go/anp_crawler"
+	"github.com/openanp/anp-go/anptest"
+)
+
+type addParams struct {
+	A int `json:"a"`
+	B int `json:"b"`
+}
+
+func TestProbe(t *testing.T) {
+	caller := anptest.NewIdentity(t, "client.example.com")
+	pings := 0
+	srv := anptest.NewServer(t,
+		anptest.WithMethod("ping", func() (string, error) { pings++; return "pong", nil }),
+		anptest.WithMethod("add", func(p addParams) (int, error) { return p.A + p.B, nil }),
+		anptest.WithDIDAuth(caller),
+	)
+	sess, err := New(Config{Authenticator: caller.Authenticator})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	ctx := context.Background()
+	doc, err := sess.Fetch(ctx, srv.OpenRPCURL())
+	if err != nil {
+		t.Fatalf("Fetch() error = %v", err)
+	}
+
+	report := sess.Probe(ctx, doc, ProbeOptions{PingMethod: "ping"})
+	if !report.Healthy() {
+		t.Fatalf("Probe() = %+v, want healthy", report.Servers)
+	}
+	if len(report.Servers) != 1 {
+		t.Fatalf("servers = %d, want 1 shared RPC endpoint", len(report.Servers))
+	}
+	server := report.Servers[0]
+	if server.URL != srv.RPCURL() || len(server.Methods) != 2 || !server.Pinged || pings != 1 {
+		t.Errorf("server = %+v, pings = %d", server, pings)
+	}
+
+	report = sess.Probe(ctx, doc, ProbeOptions{PingMethod: "missing"})
+	if !report.Healthy() || report.Servers[0].Pinged {
+		t.Errorf("Probe(missing ping) = %+v, want healthy without ping", report.Servers)
+	}
+}
+
+func TestProbeUnreachable(t *testing.T) {
+	caller := anptest.NewIdentity(t, "client.example.com")
+	sess, err := New(Config{Authenticator: caller.Authenticator})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	closed := httptest.NewServer(nil)
+	closed.Close()
+
+	doc := &Document{
+		URL: "https://agent.example/ad.json",
+		Interfaces: []*anp_crawler.ANPInterface{
+			{Method: "ping", Servers: []anp_crawler.Server{{URL: closed.URL + "/rpc"}}},
+		},
+	}
+	report := sess.Probe(context.Background(), doc, ProbeOptions{PingMethod: "ping", Timeout: time.Second})
+	if report.Healthy() {
+		t.Fatal("Probe() reported a closed server as healthy")
+	}
+	if got := report.Servers[0]; got.Reachable || got.Err == nil || got.Pinged {
+		t.Errorf("server = %+v, want unreachable with error", got)
+	}
+
+	if (&HealthReport{}).Healthy() {
+		t.Error("empty report should not be healthy")
+	}
+}
@@ -0,0 +1,256 @@
+package session
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/openanp/anp-go/anp_crawler"
+)
+
+// RegisteredTool is a tool in a ToolRegistry together with the interface
+// that executes it.
+type RegisteredTool struct {
+	// Name is the registered name, after namespacing and collision
+	// resolution; Tool.Function.Name and Interface.ToolName match it.
+	Name      string
+	Tool      *anp_crawler.ANPTool
+	Interface *anp_crawler.ANPInterface
+	// Source is the URL of the document that declared the tool.
+	Source string
+	// Original is the tool name the converter produced, before the
+	// registry renamed it. Interface.Method is the method it calls.
+	Original string
+}
+
+// ToolNamespace derives the registered name of a tool from the document
+// declaring it and the tool's converted name.
+type ToolNamespace func(doc *Document, name string) string
+
+// NamespaceByHost prefixes tool names with the host of their document, e.g.
+// "hotel_example_com__search", so agents exposing the same tool names stay
+// apart.
+func NamespaceByHost(doc *Document, name string) string {
+	u, err := url.Parse(doc.URL)
+	if err != nil || u.Hostname() == "" {
+		return name
+	}
+	prefix := toolNameChars(u.Hostname()) + "__"
+	// Keep the tool name whole within the 64 character limit LLM APIs set.
+	if room := maxToolName - len(name); len(prefix) > room {
+		prefix = prefix[:max(room, 0)]
+	}
+	return prefix + name
+}
+
+// maxToolName is the longest function name the major LLM APIs accept.
+const maxToolName = 64
+
+// toolNameChars replaces the characters function names may not contain.
+func toolNameChars(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '_' || r == '-' || 'a' <= r && r <= 'z' || 'A' <= r && r <= 'Z' || '0' <= r && r <= '9' {
+			return r
+		}
+		return '_'
+	}, s)
+}
+
+// ToolRegistry collects the tools of many documents under their names, so an
+// agent runtime can route an LLM tool call by name alone. Every Session keeps
+// one, filled by Fetch (see Session.Tools). It is safe for concurrent use.
+//
+// Names are unique: when a tool's name is already held by a different tool,
+// it is registered with a suffix derived from its document URL and method,
+// e.g. "search_3fa2c1", which stays the same across runs.
+type ToolRegistry struct {
+	// Namespace, if set, renames every tool before collisions are
+	// resolved; see NamespaceByHost.
+	Namespace ToolNamespace
+
+	mu    sync.RWMutex
+	tools map[string]*RegisteredTool
+	order []string
+}
+
+// NewToolRegistry returns an empty registry.
+func NewToolRegistry() *ToolRegistry {
+	return &ToolRegistry{tools: make(map[string]*RegisteredTool)}
+}
+
+// Add registers the tools of doc and returns their names. Tools renamed by
+// Namespace or collision resolution are replaced in doc.Tools and
+// doc.Interfaces by renamed copies. A tool registered again from the same
+// document keeps its name, so refetching a document updates its tools.
+// Interfaces without a tool definition are skipped.
+func (r *ToolRegistry) Add(doc *Document) []string {
+	if doc == nil {
+		return nil
+	}
+	// Converted names can repeat within a document, so tools are paired
+	// with interfaces in order.
+	byName := make(map[string][]*anp_crawler.ANPTool, len(doc.Tools))
+	for _, tool := range doc.Tools {
+		byName[tool.Function.Name] = append(byName[tool.Function.Name], tool)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var (
+		names      []string
+		tools      []*anp_crawler.ANPTool
+		interfaces []*anp_crawler.ANPInterface
+		renamed    bool
+		used       = make(map[string]bool)
+	)
+	for _, iface := range doc.Interfaces {
+		pending := byName[iface.ToolName]
+		if len(pending) == 0 {
+			interfaces = append(interfaces, iface)
+			continue
+		}
+		tool := pending[0]
+		byName[iface.ToolName] = pending[1:]
+		original := iface.ToolName
+		name := original
+		if r.Namespace != nil {
+			name = r.Namespace(doc, name)
+		}
+		name = uniqueToolName(name, doc.URL, iface.Method, func(n string) bool {
+			held, ok := r.tools[n]
+			return used[n] || ok && (held.Source != doc.URL || held.Original != original)
+		})
+		used[name] = true
+
+		if name != original {
+			renamed = true
+			t, i := *tool, *iface
+			t.Function.Name, i.ToolName = name, name
+			tool, iface = &t, &i
+		}
+		tools = append(tools, tool)
+		interfaces = append(interfaces, iface)
+
+		if _, exists := r.tools[name]; !exists {
+			r.order = append(r.order, name)
+		}
+		r.tools[name] = &RegisteredTool{Name: name, Tool: tool, Interface: iface, Source: doc.URL, Original: original}
+		names = append(names, name)
+	}
+	// The slices may be shared with other documents, so they are replaced
+	// rather than edited.
+	if renamed {
+		doc.Tools, doc.Interfaces = tools, interfaces
+	}
+	return names
+}
+
+// uniqueToolName returns name, or when taken reports it in use, name with a
+// suffix hashed from the tool's source and method.
+func uniqueToolName(name, source, method string, taken func(string) bool) string {
+	if !taken(name) {
+		return name
+	}
+	sum := sha256.Sum256([]byte(source + "\x00" + method))
+	tag := hex.EncodeToString(sum[:3])
+	for i := 1; ; i++ {
+		suffix := "_" + tag
+		if i > 1 {
+			suffix += fmt.Sprint(i)
+		}
+		candidate := name
+		if len(candidate)+len(suffix) > maxToolName {
+			candidate = candidate[:max(maxToolName-len(suffix), 0)]
+		}
+		candidate += suffix
+		if !taken(candidate) {
+			return candidate
+		}
+	}
+}
+
+// Lookup returns the tool registered under name.
+func (r *ToolRegistry) Lookup(name string) (*RegisteredTool, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	t, ok := r.tools[name]
+	return t, ok
+}
+
+// List returns the registered tools in the order they were first added.
+func (r *ToolRegistry) List() []*RegisteredTool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	list := make([]*RegisteredTool, 0, len(r.order))
+	for _, name := range r.order {
+		list = append(list, r.tools[name])
+	}
+	return list
+}
+
+// Tools returns the tool definitions of List, e.g. to offer them to an LLM.
+func (r *ToolRegistry) Tools() []*anp_crawler.ANPTool {
+	list := r.List()
+	tools := make([]*anp_crawler.ANPTool, len(list))
+	for i, t := range list {
+		tools[i] = t.Tool
+	}
+	return tools
+}
+
+// Len returns the number of registered tools.
+func (r *ToolRegistry) Len() int {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return len(r.tools)
+}
+
+// Remove unregisters name and reports whether it was registered.
+func (r *ToolRegistry) Remove(name string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.remove(func(t *RegisteredTool) bool { return t.Name == name }) > 0
+}
+
+// RemoveSource unregisters every tool declared by the document at url and
+// returns how many there were.
+func (r *ToolRegistry) RemoveSource(url string) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.remove(func(t *RegisteredTool) bool { return t.Source == url })
+}
+
+// removeNames unregisters the tools among names that were declared by the
+// document at source and returns how many there were.
+func (r *ToolRegistry) removeNames(source string, names map[string]bool) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.remove(func(t *RegisteredTool) bool { return t.Source == source && names[t.Name] })
+}
+
+// remove deletes the tools matching del. Callers hold mu.
+func (r *ToolRegistry) remove(del func(*RegisteredTool) bool) int {
+	n := len(r.order)
+	r.order = slices.DeleteFunc(r.order, func(name string) bool {
+		if del(r.tools[name]) {
+			delete(r.tools, name)
+			return true
+		}
+		return false
+	})
+	return n - len(r.order)
+}
+
+// Execute calls the tool registered under name with args.
+func (r *ToolRegistry) Execute(ctx context.Context, name string, args map[string]any) (map[string]any, error) {
+	t, ok := r.Lookup(name)
+	if !ok {
+		return nil, fmt.Errorf("anp/session: tool %s not registered", name)
+	}
+	return t.Interface.Execute(ctx, args)
+}
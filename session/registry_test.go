@@ -0,0 +1,145 @@
+package session
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/openanp/anp-go/anp_crawler"
+	"github.com/openanp/anp-go/anptest"
+)
+
+func TestToolRegistry(t *testing.T) {
+	caller := anptest.NewIdentity(t, "client.example.com")
+	math := anptest.NewServer(t,
+		anptest.WithMethod("add", func(p addParams) (int, error) { return p.A + p.B, nil }),
+		anptest.WithMethod("mul", func(p addParams) (int, error) { return p.A * p.B, nil }),
+		anptest.WithDIDAuth(caller),
+	)
+	text := anptest.NewServer(t,
+		anptest.WithMethod("upper", func(p struct{ S string }) (string, error) { return p.S, nil }),
+		anptest.WithDIDAuth(caller),
+	)
+	sess, err := New(Config{Authenticator: caller.Authenticator})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	ctx := context.Background()
+	for _, u := range []string{math.OpenRPCURL(), text.OpenRPCURL()} {
+		if _, err := sess.Fetch(ctx, u); err != nil {
+			t.Fatalf("Fetch(%s) error = %v", u, err)
+		}
+	}
+
+	reg := sess.Tools()
+	var names []string
+	for _, tool := range reg.List() {
+		names = append(names, tool.Name)
+	}
+	if len(names) != 3 || names[0] != "add" || names[2] != "upper" {
+		t.Fatalf("List() = %v, want [add mul upper]", names)
+	}
+	if tool, ok := reg.Lookup("upper"); !ok || tool.Source != text.OpenRPCURL() || tool.Tool.Function.Name != "upper" {
+		t.Errorf("Lookup(upper) = %+v, %v", tool, ok)
+	}
+
+	resp, err := reg.Execute(ctx, "add", map[string]any{"a": 2, "b": 3})
+	if err != nil {
+		t.Fatalf("Execute(add) error = %v", err)
+	}
+	var sum int
+	if err := DecodeResult(resp, &sum); err != nil || sum != 5 {
+		t.Errorf("Execute(add) = %d, %v, want 5", sum, err)
+	}
+	if _, err := reg.Execute(ctx, "missing", nil); err == nil {
+		t.Error("Execute(missing) error = nil, want error")
+	}
+
+	// Refetching a document replaces its tools rather than duplicating them.
+	if _, err := sess.Fetch(ctx, math.OpenRPCURL()); err != nil {
+		t.Fatalf("Fetch() error = %v", err)
+	}
+	if reg.Len() != 3 {
+		t.Errorf("Len() after refetch = %d, want 3", reg.Len())
+	}
+
+	if !reg.Remove("upper") || reg.Remove("upper") {
+		t.Error("Remove(upper) should succeed once")
+	}
+	if n := reg.RemoveSource(math.OpenRPCURL()); n != 2 || reg.Len() != 0 || len(reg.Tools()) != 0 {
+		t.Errorf("RemoveSource() = %d, Len() = %d, want 2, 0", n, reg.Len())
+	}
+}
+
+func TestToolRegistryCollisions(t *testing.T) {
+	caller := anptest.NewIdentity(t, "client.example.com")
+	newAgent := func(answer string) *anptest.Server {
+		return anptest.NewServer(t,
+			anptest.WithMethod("search", func(p struct {
+				Q string `json:"q"`
+			}) (string, error) {
+				return answer, nil
+			}),
+			anptest.WithDIDAuth(caller),
+		)
+	}
+	first, second := newAgent("first"), newAgent("second")
+	sess, err := New(Config{Authenticator: caller.Authenticator})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	ctx := context.Background()
+	var names []string
+	for _, u := range []string{first.OpenRPCURL(), second.OpenRPCURL(), second.OpenRPCURL()} {
+		doc, err := sess.Fetch(ctx, u)
+		if err != nil {
+			t.Fatalf("Fetch(%s) error = %v", u, err)
+		}
+		names = append(names, doc.Tools[0].Function.Name)
+	}
+	if names[0] != "search" || names[1] == "search" || !strings.HasPrefix(names[1], "search_") || names[2] != names[1] {
+		t.Fatalf("tool names = %v, want search, then one stable suffixed name", names)
+	}
+
+	reg := sess.Tools()
+	if reg.Len() != 2 {
+		t.Errorf("Len() = %d, want 2", reg.Len())
+	}
+	renamed, ok := reg.Lookup(names[1])
+	if !ok || renamed.Original != "search" || renamed.Source != second.OpenRPCURL() || renamed.Interface.Method != "search" {
+		t.Errorf("Lookup(%s) = %+v", names[1], renamed)
+	}
+	for name, want := range map[string]string{"search": "first", names[1]: "second"} {
+		resp, err := reg.Execute(ctx, name, map[string]any{"q": "x"})
+		var got string
+		if err == nil {
+			err = DecodeResult(resp, &got)
+		}
+		if err != nil || got != want {
+			t.Errorf("Execute(%s) = %q, %v, want %q", name, got, err, want)
+		}
+	}
+}
+
+func TestNamespaceByHost(t *testing.T) {
+	doc := &Document{URL: "https://hotel.example.com:8443/ad.json"}
+	if got := NamespaceByHost(doc, "search"); got != "hotel_example_com__search" {
+		t.Errorf("NamespaceByHost() = %q", got)
+	}
+	long := strings.Repeat("x", 60)
+	if got := NamespaceByHost(doc, long); len(got) != 64 || !strings.HasSuffix(got, long) {
+		t.Errorf("NamespaceByHost(long) = %q, want 64 chars ending in the tool name", got)
+	}
+
+	reg := NewToolRegistry()
+	reg.Namespace = NamespaceByHost
+	tool := &anp_crawler.ANPTool{Type: "function", Function: anp_crawler.Function{Name: "search"}}
+	iface := anp_crawler.NewANPInterface("search", anp_crawler.InterfaceEntry{MethodName: "search"}, nil)
+	doc.Tools, doc.Interfaces = []*anp_crawler.ANPTool{tool}, []*anp_crawler.ANPInterface{iface}
+	if names := reg.Add(doc); len(names) != 1 || names[0] != "hotel_example_com__search" {
+		t.Errorf("Add() = %v", names)
+	}
+	if doc.Tools[0] == tool || doc.Tools[0].Function.Name != "hotel_example_com__search" || tool.Function.Name != "search" {
+		t.Errorf("Add() should rename a copy of the tool, got %+v", doc.Tools[0])
+	}
+}
@@ -0,0 +1,207 @@
+package session
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"io/fs"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+
+	"github.com/bytedance/sonic"
+	"github.com/openanp/anp-go/anp_crawler"
+)
+
+// ResultStore persists responses by request key so a restarted workflow can
+// replay tool calls and document fetches instead of repeating them. Keys are
+// hex SHA-256 digests from RequestKey. Implementations must be safe for
+// concurrent use; see FileStore and the session/sqlite module.
+type ResultStore interface {
+	// Get returns the value stored under key, or ok == false if there is none.
+	Get(ctx context.Context, key string) (value []byte, ok bool, err error)
+	// Put stores value under key, replacing any previous value.
+	Put(ctx context.Context, key string, value []byte) error
+}
+
+// RequestKey returns the content hash identifying a request: its method, URL
+// and body, with JSON bodies canonicalised (sorted keys) and the JSON-RPC "id"
+// dropped so a replayed call matches the original.
+func RequestKey(method, url string, body any) (string, error) {
+	canonical, err := canonicalBody(body)
+	if err != nil {
+		return "", err
+	}
+	h := sha256.New()
+	h.Write([]byte(method))
+	h.Write([]byte{0})
+	h.Write([]byte(url))
+	h.Write([]byte{0})
+	h.Write(canonical)
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+func canonicalBody(body any) ([]byte, error) {
+	var v any
+	switch b := body.(type) {
+	case nil:
+		return nil, nil
+	case []byte:
+		if sonic.Unmarshal(b, &v) != nil {
+			return b, nil
+		}
+	default:
+		raw, err := sonic.Marshal(b)
+		if err != nil {
+			return nil, err
+		}
+		if err := sonic.Unmarshal(raw, &v); err != nil {
+			return nil, err
+		}
+	}
+	if m, ok := v.(map[string]any); ok {
+		if _, rpc := m["jsonrpc"]; rpc {
+			delete(m, "id")
+		}
+	}
+	return sonic.ConfigStd.Marshal(v)
+}
+
+// storedResponse is the persisted form of a response.
+type storedResponse struct {
+	StatusCode  int         `json:"status"`
+	URL         string      `json:"url"`
+	ContentType string      `json:"content_type,omitempty"`
+	Encoding    string      `json:"encoding,omitempty"`
+	Header      http.Header `json:"header,omitempty"`
+	Body        []byte      `json:"body"`
+}
+
+// storeClient replays responses from a ResultStore and records successful
+// new ones. Failed calls and JSON-RPC errors are never stored.
+type storeClient struct {
+	next     anp_crawler.Client
+	store    ResultStore
+	readOnly bool
+	logger   *slog.Logger
+}
+
+func (c *storeClient) Fetch(ctx context.Context, method, target string, headers map[string]string, body any) (*anp_crawler.Response, error) {
+	if _, ok := body.(io.Reader); ok {
+		return c.next.Fetch(ctx, method, target, headers, body)
+	}
+	key, err := RequestKey(method, target, body)
+	if err != nil {
+		return c.next.Fetch(ctx, method, target, headers, body)
+	}
+
+	raw, ok, err := c.store.Get(ctx, key)
+	if err != nil {
+		c.logger.Warn("result store lookup failed", "url", target, "error", err)
+	} else if ok {
+		var stored storedResponse
+		if err := sonic.Unmarshal(raw, &stored); err == nil {
+			c.logger.Debug("replaying stored result", "url", target, "key", key)
+			return &anp_crawler.Response{
+				StatusCode:  stored.StatusCode,
+				URL:         stored.URL,
+				ContentType: stored.ContentType,
+				Encoding:    stored.Encoding,
+				Header:      stored.Header,
+				Body:        stored.Body,
+			}, nil
+		}
+	}
+
+	resp, err := c.next.Fetch(ctx, method, target, headers, body)
+	if err != nil || c.readOnly || resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices || isRPCError(resp.Body) {
+		return resp, err
+	}
+	encoded, err := sonic.Marshal(storedResponse{
+		StatusCode:  resp.StatusCode,
+		URL:         resp.URL,
+		ContentType: resp.ContentType,
+		Encoding:    resp.Encoding,
+		Header:      resp.Header,
+		Body:        resp.Body,
+	})
+	if err == nil {
+		err = c.store.Put(ctx, key, encoded)
+	}
+	if err != nil {
+		c.logger.Warn("result store write failed", "url", target, "error", err)
+	}
+	return resp, nil
+}
+
+func isRPCError(body []byte) bool {
+	var envelope struct {
+		Error any `json:"error"`
+	}
+	return sonic.Unmarshal(body, &envelope) == nil && envelope.Error != nil
+}
+
+// FileStore is a ResultStore keeping one file per key under a directory.
+type FileStore struct {
+	dir string
+}
+
+// NewFileStore creates a FileStore rooted at dir, creating it if needed.
+func NewFileStore(dir string) (*FileStore, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, err
+	}
+	return &FileStore{dir: dir}, nil
+}
+
+// path shards keys by their first two characters to keep directories small.
+func (f *FileStore) path(key string) (string, error) {
+	if len(key) < 3 || filepath.Base(key) != key {
+		return "", errors.New("session: invalid result store key")
+	}
+	return filepath.Join(f.dir, key[:2], key+".json"), nil
+}
+
+// Get reads the file for key.
+func (f *FileStore) Get(_ context.Context, key string) ([]byte, bool, error) {
+	path, err := f.path(key)
+	if err != nil {
+		return nil, false, err
+	}
+	raw, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return raw, true, nil
+}
+
+// Put writes the file for key atomically, so a crash never leaves a partial
+// result behind.
+func (f *FileStore) Put(_ context.Context, key string, value []byte) error {
+	path, err := f.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(value); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
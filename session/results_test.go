@@ -0,0 +1,97 @@
+package session
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+
+	"github.com/openanp/anp-go/anptest"
+)
+
+func TestRequestKey(t *testing.T) {
+	a, err := RequestKey("POST", "https://a.example.com/rpc", map[string]any{"jsonrpc": "2.0", "id": "1", "method": "add", "params": map[string]any{"a": 1, "b": 2}})
+	if err != nil {
+		t.Fatalf("RequestKey() error = %v", err)
+	}
+	b, err := RequestKey("POST", "https://a.example.com/rpc", []byte(`{"params":{"b":2,"a":1},"method":"add","id":"2","jsonrpc":"2.0"}`))
+	if err != nil {
+		t.Fatalf("RequestKey() error = %v", err)
+	}
+	if a != b {
+		t.Errorf("keys differ for the same call: %s != %s", a, b)
+	}
+	c, _ := RequestKey("POST", "https://a.example.com/rpc", map[string]any{"jsonrpc": "2.0", "id": "1", "method": "add", "params": map[string]any{"a": 1, "b": 3}})
+	d, _ := RequestKey("GET", "https://a.example.com/rpc", nil)
+	if c == a || d == a {
+		t.Error("different requests share a key")
+	}
+}
+
+func TestFileStore(t *testing.T) {
+	store, err := NewFileStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewFileStore() error = %v", err)
+	}
+	ctx := context.Background()
+	key, _ := RequestKey("GET", "https://a.example.com/ad.json", nil)
+	if _, ok, err := store.Get(ctx, key); ok || err != nil {
+		t.Fatalf("Get(missing) = %v, %v", ok, err)
+	}
+	if err := store.Put(ctx, key, []byte("v1")); err != nil {
+		t.Fatalf("Put() error = %v", err)
+	}
+	if err := store.Put(ctx, key, []byte("v2")); err != nil {
+		t.Fatalf("Put(replace) error = %v", err)
+	}
+	if v, ok, err := store.Get(ctx, key); !ok || err != nil || string(v) != "v2" {
+		t.Errorf("Get() = %q, %v, %v", v, ok, err)
+	}
+	if err := store.Put(ctx, "../escape", nil); err == nil {
+		t.Error("Put() accepted a key with a path separator")
+	}
+}
+
+func TestResultsReplay(t *testing.T) {
+	caller := anptest.NewIdentity(t, "client.example.com")
+	var calls, failures atomic.Int32
+	srv := anptest.NewServer(t,
+		anptest.WithMethod("add", func(p addParams) (int, error) { calls.Add(1); return p.A + p.B, nil }),
+		anptest.WithMethod("fail", func() (int, error) { failures.Add(1); return 0, context.DeadlineExceeded }),
+		anptest.WithDIDAuth(caller),
+	)
+	store, err := NewFileStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewFileStore() error = %v", err)
+	}
+	ctx := context.Background()
+
+	// Each run stands in for a restarted process sharing only the store.
+	run := func() map[string]any {
+		t.Helper()
+		sess, err := New(Config{Authenticator: caller.Authenticator, Results: store})
+		if err != nil {
+			t.Fatalf("New() error = %v", err)
+		}
+		doc, err := sess.Fetch(ctx, srv.OpenRPCURL())
+		if err != nil {
+			t.Fatalf("Fetch() error = %v", err)
+		}
+		result, err := ExecuteTool(ctx, doc, "add", map[string]any{"a": 2, "b": 3})
+		if err != nil {
+			t.Fatalf("ExecuteTool() error = %v", err)
+		}
+		ExecuteTool(ctx, doc, "fail", nil)
+		return result
+	}
+
+	first, second := run(), run()
+	if calls.Load() != 1 {
+		t.Errorf("server calls = %d, want 1 (second run replayed)", calls.Load())
+	}
+	if first["result"] != second["result"] || first["result"] != float64(5) {
+		t.Errorf("results = %v, %v", first["result"], second["result"])
+	}
+	if failures.Load() != 2 {
+		t.Errorf("failing calls = %d, want 2 (errors are not stored)", failures.Load())
+	}
+}
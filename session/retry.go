@@ -0,0 +1,172 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/openanp/anp-go/anp_crawler"
+)
+
+// DefaultRetryableStatus are the status codes RetryPolicy retries when
+// RetryableStatus is nil.
+var DefaultRetryableStatus = []int{
+	http.StatusTooManyRequests,
+	http.StatusBadGateway,
+	http.StatusServiceUnavailable,
+	http.StatusGatewayTimeout,
+}
+
+// RetryPolicy retries the session's requests, from Fetch, FetchBatch and
+// Invoke to tool calls, that fail with a transport error or a retryable
+// status, so callers need not wrap retries around the session. Only
+// idempotent requests are retried: GET, HEAD and OPTIONS, requests with an
+// Idempotency-Key header, and calls of the tools listed in Tools. Requests
+// whose body is an io.Reader are never retried, as the first attempt
+// consumes it. Zero values select the defaults.
+type RetryPolicy struct {
+	// Attempts is the total number of tries of a request, the first
+	// included (default 3).
+	Attempts int
+	// Backoff is the pause before the first retry; it doubles with each
+	// further retry up to MaxBackoff (defaults 500ms and 30s). A
+	// Retry-After header takes precedence, capped at MaxBackoff.
+	Backoff    time.Duration
+	MaxBackoff time.Duration
+	// RetryableStatus lists the status codes that are retried; nil uses
+	// DefaultRetryableStatus.
+	RetryableStatus []int
+	// Tools names the tools whose calls are retried even though they are
+	// POST requests, because calling them twice is harmless, e.g. lookups
+	// exposed over JSON-RPC.
+	Tools []string
+}
+
+func (p RetryPolicy) withDefaults() RetryPolicy {
+	if p.Attempts <= 0 {
+		p.Attempts = 3
+	}
+	if p.Backoff <= 0 {
+		p.Backoff = 500 * time.Millisecond
+	}
+	if p.MaxBackoff <= 0 {
+		p.MaxBackoff = 30 * time.Second
+	}
+	if p.RetryableStatus == nil {
+		p.RetryableStatus = DefaultRetryableStatus
+	}
+	return p
+}
+
+// retryClient applies a RetryPolicy to the requests of next. Streams are
+// passed through: a stream that fails once open cannot be replayed.
+type retryClient struct {
+	next   anp_crawler.Client
+	policy RetryPolicy
+	logger *slog.Logger
+}
+
+func (c *retryClient) Fetch(ctx context.Context, method, target string, headers map[string]string, body any) (*anp_crawler.Response, error) {
+	if !idempotent(ctx, method, headers) {
+		return c.next.Fetch(ctx, method, target, headers, body)
+	}
+	if _, ok := body.(io.Reader); ok {
+		// A reader is consumed by the first attempt.
+		return c.next.Fetch(ctx, method, target, headers, body)
+	}
+	for attempt := 1; ; attempt++ {
+		resp, err := c.next.Fetch(ctx, method, target, headers, body)
+		if attempt >= c.policy.Attempts || ctx.Err() != nil {
+			return resp, err
+		}
+		var header http.Header
+		switch {
+		case err != nil:
+		case slices.Contains(c.policy.RetryableStatus, resp.StatusCode):
+			header = resp.Header
+			resp.Release()
+		default:
+			return resp, nil
+		}
+		wait := retryBackoff(c.policy.Backoff, c.policy.MaxBackoff, attempt, header)
+		c.logger.Debug("retrying request", "url", target, "attempt", attempt, "wait", wait, "error", err)
+		if err := sleep(ctx, wait); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// idempotent reports whether a request may be sent again after a failure
+// without repeating its side effects.
+func idempotent(ctx context.Context, method string, headers map[string]string) bool {
+	switch strings.ToUpper(method) {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return true
+	}
+	for name, value := range headers {
+		if value != "" && strings.EqualFold(name, "Idempotency-Key") {
+			return true
+		}
+	}
+	retry, _ := ctx.Value(retryToolKey{}).(bool)
+	return retry
+}
+
+type retryToolKey struct{}
+
+// retryTools returns the tool middleware marking calls of the named tools
+// as safe to retry.
+func retryTools(names []string) ToolMiddleware {
+	return func(next ToolHandler) ToolHandler {
+		return func(ctx context.Context, call *ToolCall) (map[string]any, error) {
+			if slices.Contains(names, call.Name) {
+				ctx = context.WithValue(ctx, retryToolKey{}, true)
+			}
+			return next(ctx, call)
+		}
+	}
+}
+
+func (c *retryClient) FetchStream(ctx context.Context, method, target string, headers map[string]string, body any) (*anp_crawler.EventStream, error) {
+	stream, ok := c.next.(anp_crawler.StreamClient)
+	if !ok {
+		return nil, errors.New("anp/session: client does not support streaming")
+	}
+	return stream.FetchStream(ctx, method, target, headers, body)
+}
+
+// retryBackoff returns the pause after the attempt-th failure: the server's
+// Retry-After (seconds or HTTP date) if header has one, otherwise backoff
+// doubled per attempt, both capped at maxBackoff.
+func retryBackoff(backoff, maxBackoff time.Duration, attempt int, header http.Header) time.Duration {
+	if ra := header.Get("Retry-After"); ra != "" {
+		if secs, err := strconv.Atoi(ra); err == nil && secs >= 0 {
+			return min(time.Duration(secs)*time.Second, maxBackoff)
+		}
+		if at, err := http.ParseTime(ra); err == nil {
+			return min(max(time.Until(at), 0), maxBackoff)
+		}
+	}
+	d := backoff << (attempt - 1)
+	if d <= 0 || d > maxBackoff {
+		d = maxBackoff
+	}
+	return d
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,156 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bytedance/sonic"
+	"github.com/openanp/anp-go/anptest"
+)
+
+func TestRetryPolicy(t *testing.T) {
+	var mu sync.Mutex
+	hits := map[string]int{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		hits[r.URL.Path]++
+		n := hits[r.URL.Path]
+		mu.Unlock()
+		switch {
+		case r.URL.Path == "/missing.json":
+			w.WriteHeader(http.StatusNotFound)
+		case r.URL.Path == "/down.json" || n < 3:
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusServiceUnavailable)
+		default:
+			w.Header().Set("Content-Type", "application/json")
+			w.Write(benchmarkAD(1))
+		}
+	}))
+	defer srv.Close()
+	sess, err := New(Config{
+		Authenticator: anptest.NewIdentity(t, "client.example.com").Authenticator,
+		Retry:         &RetryPolicy{Attempts: 3, Backoff: time.Millisecond},
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	ctx := context.Background()
+
+	if _, err := sess.Fetch(ctx, srv.URL+"/flaky.json"); err != nil {
+		t.Errorf("Fetch(flaky) error = %v", err)
+	}
+	resp, err := sess.Invoke(ctx, http.MethodGet, srv.URL+"/invoke.json", nil, nil)
+	if err != nil || resp.StatusCode != http.StatusOK {
+		t.Errorf("Invoke(flaky) = %v, %v, want 200", resp, err)
+	}
+	var status *StatusError
+	if _, err := sess.Fetch(ctx, srv.URL+"/down.json"); !errors.As(err, &status) || status.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("Fetch(down) error = %v, want 503 StatusError", err)
+	}
+	if _, err := sess.Fetch(ctx, srv.URL+"/missing.json"); !errors.As(err, &status) || status.StatusCode != http.StatusNotFound {
+		t.Errorf("Fetch(missing) error = %v, want 404 StatusError", err)
+	}
+
+	want := map[string]int{"/flaky.json": 3, "/invoke.json": 3, "/down.json": 3, "/missing.json": 1}
+	mu.Lock()
+	defer mu.Unlock()
+	for path, n := range want {
+		if hits[path] != n {
+			t.Errorf("requests to %s = %d, want %d", path, hits[path], n)
+		}
+	}
+}
+
+func TestRetryPolicyIdempotency(t *testing.T) {
+	var mu sync.Mutex
+	hits := map[string]int{}
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/ad.json" {
+			w.Header().Set("Content-Type", "application/json")
+			fmt.Fprintf(w, `{"protocolType":"ANP","type":"AgentDescription","name":"Retry","interfaces":[{"type":"StructuredInterface","protocol":"openrpc","content":{"openrpc":"1.3.2","info":{"title":"Retry","version":"1.0.0"},"servers":[{"name":"rpc","url":"%s/rpc"}],"methods":[{"name":"lookup","params":[]},{"name":"order","params":[]}]}}]}`, srv.URL)
+			return
+		}
+		key := r.Method + " " + r.URL.Path
+		if r.URL.Path == "/rpc" {
+			var req struct{ Method string }
+			sonic.ConfigDefault.NewDecoder(r.Body).Decode(&req)
+			key += " " + req.Method
+		}
+		mu.Lock()
+		hits[key]++
+		mu.Unlock()
+		w.Header().Set("Retry-After", "0")
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+	sess, err := New(Config{
+		Authenticator: anptest.NewIdentity(t, "client.example.com").Authenticator,
+		Retry:         &RetryPolicy{Attempts: 3, Backoff: time.Millisecond, Tools: []string{"lookup"}},
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	ctx := context.Background()
+	doc, err := sess.Fetch(ctx, srv.URL+"/ad.json")
+	if err != nil {
+		t.Fatalf("Fetch() error = %v", err)
+	}
+
+	sess.Invoke(ctx, http.MethodPost, srv.URL+"/post", nil, map[string]any{})
+	sess.Invoke(ctx, http.MethodPost, srv.URL+"/keyed", map[string]string{"Idempotency-Key": "k1"}, map[string]any{})
+	sess.Invoke(ctx, http.MethodPost, srv.URL+"/reader", map[string]string{"Idempotency-Key": "k2"}, strings.NewReader("{}"))
+	sess.Invoke(ctx, http.MethodOptions, srv.URL+"/options", nil, nil)
+	ExecuteTool(ctx, doc, "lookup", map[string]any{})
+	ExecuteTool(ctx, doc, "order", map[string]any{})
+
+	want := map[string]int{
+		"POST /post":       1,
+		"POST /keyed":      3,
+		"POST /reader":     1,
+		"OPTIONS /options": 3,
+		"POST /rpc lookup": 3,
+		"POST /rpc order":  1,
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	for key, n := range want {
+		if hits[key] != n {
+			t.Errorf("requests %q = %d, want %d", key, hits[key], n)
+		}
+	}
+}
+
+func TestRetryBackoff(t *testing.T) {
+	tests := []struct {
+		name       string
+		attempt    int
+		retryAfter string
+		want       time.Duration
+	}{
+		{"first", 1, "", time.Second},
+		{"doubles", 3, "", 4 * time.Second},
+		{"capped", 10, "", 10 * time.Second},
+		{"retry-after seconds", 1, "2", 2 * time.Second},
+		{"retry-after capped", 1, "120", 10 * time.Second},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			header := http.Header{}
+			if tt.retryAfter != "" {
+				header.Set("Retry-After", tt.retryAfter)
+			}
+			if got := retryBackoff(time.Second, 10*time.Second, tt.attempt, header); got != tt.want {
+				t.Errorf("retryBackoff() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
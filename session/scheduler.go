@@ -0,0 +1,309 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// SchedulerConfig tunes a Scheduler. Zero values select the defaults.
+type SchedulerConfig struct {
+	// MaxConcurrent caps fetches in flight across all hosts (default 5).
+	MaxConcurrent int
+	// PerHostConcurrent caps fetches in flight to one host (default 1).
+	PerHostConcurrent int
+	// PerHostInterval is the minimum delay between starting two fetches to the
+	// same host.
+	PerHostInterval time.Duration
+	// MaxRetries is how often a URL answered with 429 is retried (default 3).
+	MaxRetries int
+	// Backoff is the first pause after a 429 without Retry-After; it doubles
+	// with each retry of the same URL up to MaxBackoff (defaults 1s and 1m).
+	Backoff    time.Duration
+	MaxBackoff time.Duration
+	// OnResult receives every finished URL. Calls are serialised, and it may
+	// call Scheduler.Add to extend the frontier.
+	OnResult func(CrawlResult)
+}
+
+// CrawlResult is the outcome of fetching one scheduled URL.
+type CrawlResult struct {
+	URL      string
+	Document *Document
+	Err      error
+	// Attempts counts fetches including retries after 429.
+	Attempts int
+}
+
+// CrawlProgress is a snapshot of a Scheduler's state.
+type CrawlProgress struct {
+	Queued    int
+	InFlight  int
+	Succeeded int
+	Failed    int
+	// Retried counts fetches rescheduled after a 429.
+	Retried int
+	Hosts   int
+	// PausedUntil is set while every host waits out a 429 backoff.
+	PausedUntil time.Time
+}
+
+// Scheduler fetches a large frontier of URLs politely: it queues URLs per
+// host, rotates between hosts so no single host is hammered, enforces per-host
+// limits, and pauses the whole crawl when any server answers 429 Too Many
+// Requests, honouring Retry-After.
+type Scheduler struct {
+	sess *Session
+	cfg  SchedulerConfig
+
+	mu          sync.Mutex
+	seen        map[string]bool
+	hosts       map[string]*hostQueue
+	order       []*hostQueue // round-robin rotation
+	cursor      int
+	queued      int
+	inFlight    int
+	succeeded   int
+	failed      int
+	retried     int
+	pausedUntil time.Time
+
+	wake       chan struct{}
+	callbackMu sync.Mutex
+}
+
+type hostQueue struct {
+	name     string
+	pending  []crawlJob
+	inFlight int
+	nextAt   time.Time
+}
+
+type crawlJob struct {
+	url      string
+	attempts int
+}
+
+// NewScheduler creates a Scheduler that fetches through s. Limits left unset
+// in cfg are taken from Config.Politeness, if any.
+func (s *Session) NewScheduler(cfg SchedulerConfig) *Scheduler {
+	cfg = s.schedulerDefaults(cfg)
+	if cfg.MaxConcurrent <= 0 {
+		cfg.MaxConcurrent = 5
+	}
+	if cfg.PerHostConcurrent <= 0 {
+		cfg.PerHostConcurrent = 1
+	}
+	if cfg.MaxRetries <= 0 {
+		cfg.MaxRetries = 3
+	}
+	if cfg.Backoff <= 0 {
+		cfg.Backoff = time.Second
+	}
+	if cfg.MaxBackoff <= 0 {
+		cfg.MaxBackoff = time.Minute
+	}
+	return &Scheduler{
+		sess:  s,
+		cfg:   cfg,
+		seen:  make(map[string]bool),
+		hosts: make(map[string]*hostQueue),
+		wake:  make(chan struct{}, 1),
+	}
+}
+
+// Add queues urls that have not been added before.
+func (sc *Scheduler) Add(urls ...string) {
+	sc.mu.Lock()
+	for _, u := range urls {
+		if u == "" || sc.seen[u] {
+			continue
+		}
+		sc.seen[u] = true
+		sc.enqueue(crawlJob{url: u}, false)
+	}
+	sc.mu.Unlock()
+	sc.signal()
+}
+
+// enqueue adds job to its host queue; retries go to the front. Callers hold mu.
+func (sc *Scheduler) enqueue(job crawlJob, front bool) {
+	name := hostOf(job.url)
+	q, ok := sc.hosts[name]
+	if !ok {
+		q = &hostQueue{name: name}
+		sc.hosts[name] = q
+		sc.order = append(sc.order, q)
+	}
+	if front {
+		q.pending = append([]crawlJob{job}, q.pending...)
+	} else {
+		q.pending = append(q.pending, job)
+	}
+	sc.queued++
+}
+
+// Progress returns a snapshot of the crawl.
+func (sc *Scheduler) Progress() CrawlProgress {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	p := CrawlProgress{
+		Queued:    sc.queued,
+		InFlight:  sc.inFlight,
+		Succeeded: sc.succeeded,
+		Failed:    sc.failed,
+		Retried:   sc.retried,
+		Hosts:     len(sc.hosts),
+	}
+	if time.Now().Before(sc.pausedUntil) {
+		p.PausedUntil = sc.pausedUntil
+	}
+	return p
+}
+
+// Run fetches queued URLs until the frontier is empty and nothing is in
+// flight, or ctx is done. It waits for in-flight fetches before returning.
+func (sc *Scheduler) Run(ctx context.Context) error {
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+
+	for {
+		sc.mu.Lock()
+		if sc.queued == 0 && sc.inFlight == 0 {
+			sc.mu.Unlock()
+			return nil
+		}
+		job, wait := sc.next(time.Now())
+		sc.mu.Unlock()
+
+		if job != nil {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				sc.fetch(ctx, *job)
+			}()
+			continue
+		}
+
+		if !timer.Stop() {
+			select {
+			case <-timer.C:
+			default:
+			}
+		}
+		if wait > 0 {
+			timer.Reset(wait)
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-sc.wake:
+		case <-timer.C:
+		}
+	}
+}
+
+// next picks the next job to start, rotating between hosts. When nothing can
+// start it returns how long to wait for a host to become ready (0 means wait
+// for a fetch to finish). Callers hold mu.
+func (sc *Scheduler) next(now time.Time) (*crawlJob, time.Duration) {
+	if now.Before(sc.pausedUntil) {
+		return nil, sc.pausedUntil.Sub(now)
+	}
+	if sc.inFlight >= sc.cfg.MaxConcurrent {
+		return nil, 0
+	}
+
+	var wait time.Duration
+	for i := range sc.order {
+		q := sc.order[(sc.cursor+i)%len(sc.order)]
+		if len(q.pending) == 0 || q.inFlight >= sc.cfg.PerHostConcurrent {
+			continue
+		}
+		if now.Before(q.nextAt) {
+			if d := q.nextAt.Sub(now); wait == 0 || d < wait {
+				wait = d
+			}
+			continue
+		}
+
+		job := q.pending[0]
+		q.pending = q.pending[1:]
+		q.inFlight++
+		q.nextAt = now.Add(sc.cfg.PerHostInterval)
+		sc.queued--
+		sc.inFlight++
+		sc.cursor = (sc.cursor + i + 1) % len(sc.order)
+		return &job, 0
+	}
+	return nil, wait
+}
+
+func (sc *Scheduler) fetch(ctx context.Context, job crawlJob) {
+	job.attempts++
+	doc, err := sc.sess.Fetch(ctx, job.url)
+
+	sc.mu.Lock()
+	q := sc.hosts[hostOf(job.url)]
+	q.inFlight--
+
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) && statusErr.StatusCode == http.StatusTooManyRequests && job.attempts <= sc.cfg.MaxRetries && ctx.Err() == nil {
+		delay := sc.backoff(job.attempts, statusErr.Header)
+		if until := time.Now().Add(delay); until.After(sc.pausedUntil) {
+			sc.pausedUntil = until
+		}
+		sc.retried++
+		sc.inFlight--
+		sc.enqueue(job, true)
+		sc.mu.Unlock()
+		sc.sess.logger.Debug("crawl paused after 429", "url", job.url, "delay", delay)
+		sc.signal()
+		return
+	}
+
+	if err != nil {
+		sc.failed++
+	} else {
+		sc.succeeded++
+	}
+	sc.mu.Unlock()
+
+	// The job stays in flight until OnResult returns, so Run cannot finish
+	// before URLs added by the callback are queued.
+	if sc.cfg.OnResult != nil {
+		sc.callbackMu.Lock()
+		sc.cfg.OnResult(CrawlResult{URL: job.url, Document: doc, Err: err, Attempts: job.attempts})
+		sc.callbackMu.Unlock()
+	}
+	sc.mu.Lock()
+	sc.inFlight--
+	sc.mu.Unlock()
+	sc.signal()
+}
+
+// backoff returns the pause after the attempt-th 429, preferring the server's
+// Retry-After.
+func (sc *Scheduler) backoff(attempt int, header http.Header) time.Duration {
+	return retryBackoff(sc.cfg.Backoff, sc.cfg.MaxBackoff, attempt, header)
+}
+
+func (sc *Scheduler) signal() {
+	select {
+	case sc.wake <- struct{}{}:
+	default:
+	}
+}
+
+func hostOf(raw string) string {
+	if u, err := url.Parse(raw); err == nil {
+		return u.Host
+	}
+	return ""
+}
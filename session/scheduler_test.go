@@ -0,0 +1,182 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/openanp/anp-go/anptest"
+)
+
+// countingHost serves JSON documents and records its peak concurrency.
+type countingHost struct {
+	*httptest.Server
+	active, peak, hits atomic.Int32
+}
+
+func newCountingHost(t *testing.T, handle func(w http.ResponseWriter, r *http.Request) bool) *countingHost {
+	h := &countingHost{}
+	h.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		h.hits.Add(1)
+		n := h.active.Add(1)
+		defer h.active.Add(-1)
+		for {
+			p := h.peak.Load()
+			if n <= p || h.peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+		if handle != nil && handle(w, r) {
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"name": %q}`, r.URL.Path)
+	}))
+	t.Cleanup(h.Close)
+	return h
+}
+
+func TestSchedulerPerHostLimits(t *testing.T) {
+	caller := anptest.NewIdentity(t, "client.example.com")
+	sess, err := New(Config{Authenticator: caller.Authenticator})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	a := newCountingHost(t, nil)
+	b := newCountingHost(t, nil)
+
+	var (
+		mu      sync.Mutex
+		results []CrawlResult
+	)
+	sched := sess.NewScheduler(SchedulerConfig{
+		MaxConcurrent: 4,
+		OnResult: func(r CrawlResult) {
+			mu.Lock()
+			results = append(results, r)
+			mu.Unlock()
+		},
+	})
+	for i := range 4 {
+		sched.Add(fmt.Sprintf("%s/a%d.json", a.URL, i), fmt.Sprintf("%s/b%d.json", b.URL, i))
+	}
+	sched.Add(a.URL + "/a0.json") // duplicate
+
+	if err := sched.Run(context.Background()); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if len(results) != 8 {
+		t.Fatalf("results = %d, want 8", len(results))
+	}
+	for _, r := range results {
+		if r.Err != nil || r.Document == nil || r.Attempts != 1 {
+			t.Errorf("result = %+v", r)
+		}
+	}
+	if a.peak.Load() != 1 || b.peak.Load() != 1 {
+		t.Errorf("peak concurrency = %d/%d, want 1 per host", a.peak.Load(), b.peak.Load())
+	}
+	p := sched.Progress()
+	if p.Succeeded != 8 || p.Failed != 0 || p.Queued != 0 || p.InFlight != 0 || p.Hosts != 2 {
+		t.Errorf("Progress() = %+v", p)
+	}
+}
+
+func TestSchedulerRetryAfter(t *testing.T) {
+	caller := anptest.NewIdentity(t, "client.example.com")
+	sess, err := New(Config{Authenticator: caller.Authenticator})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	var limited atomic.Bool
+	throttled := newCountingHost(t, func(w http.ResponseWriter, r *http.Request) bool {
+		if limited.CompareAndSwap(false, true) {
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return true
+		}
+		return false
+	})
+	broken := newCountingHost(t, func(w http.ResponseWriter, r *http.Request) bool {
+		w.WriteHeader(http.StatusTooManyRequests)
+		return true
+	})
+
+	var (
+		mu      sync.Mutex
+		results = map[string]CrawlResult{}
+	)
+	sched := sess.NewScheduler(SchedulerConfig{
+		MaxRetries: 2,
+		Backoff:    10 * time.Millisecond,
+		OnResult: func(r CrawlResult) {
+			mu.Lock()
+			results[r.URL] = r
+			mu.Unlock()
+		},
+	})
+	sched.Add(throttled.URL+"/ad.json", broken.URL+"/ad.json")
+
+	start := time.Now()
+	if err := sched.Run(context.Background()); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < time.Second {
+		t.Errorf("Run() took %v, want Retry-After pause of 1s", elapsed)
+	}
+
+	if r := results[throttled.URL+"/ad.json"]; r.Err != nil || r.Attempts != 2 {
+		t.Errorf("throttled result = %+v", r)
+	}
+	r := results[broken.URL+"/ad.json"]
+	var statusErr *StatusError
+	if !errors.As(r.Err, &statusErr) || statusErr.StatusCode != http.StatusTooManyRequests || r.Attempts != 3 {
+		t.Errorf("broken result = %+v, want 429 after 3 attempts", r)
+	}
+	if p := sched.Progress(); p.Succeeded != 1 || p.Failed != 1 || p.Retried != 3 {
+		t.Errorf("Progress() = %+v", p)
+	}
+}
+
+func TestSchedulerFrontierAndCancel(t *testing.T) {
+	caller := anptest.NewIdentity(t, "client.example.com")
+	sess, err := New(Config{Authenticator: caller.Authenticator})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	host := newCountingHost(t, nil)
+
+	var sched *Scheduler
+	sched = sess.NewScheduler(SchedulerConfig{
+		OnResult: func(r CrawlResult) {
+			if r.URL == host.URL+"/root.json" {
+				sched.Add(host.URL+"/child1.json", host.URL+"/child2.json", host.URL+"/root.json")
+			}
+		},
+	})
+	sched.Add(host.URL + "/root.json")
+	if err := sched.Run(context.Background()); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if got := host.hits.Load(); got != 3 {
+		t.Errorf("hits = %d, want 3", got)
+	}
+
+	slow := sess.NewScheduler(SchedulerConfig{PerHostInterval: time.Hour})
+	slow.Add(host.URL+"/x.json", host.URL+"/y.json")
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	if err := slow.Run(ctx); err != context.DeadlineExceeded {
+		t.Errorf("Run() error = %v, want deadline exceeded", err)
+	}
+	if p := slow.Progress(); p.Succeeded != 1 || p.Queued != 1 {
+		t.Errorf("Progress() = %+v, want one fetched and one waiting", p)
+	}
+}
@@ -7,10 +7,14 @@ import (
 	"fmt"
 	"log/slog"
 	"net/http"
+	"strings"
+	"sync"
 	"time"
 
+	"github.com/bytedance/sonic"
 	"github.com/openanp/anp-go/anp_auth"
 	"github.com/openanp/anp-go/anp_crawler"
+	"github.com/openanp/anp-go/anp_debug"
 
 	"golang.org/x/sync/errgroup"
 	"golang.org/x/sync/semaphore"
@@ -30,12 +34,95 @@ type Config struct {
 
 	MaxConcurrent int
 	Logger        *slog.Logger
+
+	// Debug records every HTTP exchange and parse result made by the session.
+	// When nil, the recorder for $ANP_DEBUG_DIR is used if that variable is set.
+	Debug *anp_debug.Recorder
+
+	// Transcript, when set, keeps every request the session sends and its
+	// response in memory, with Authorization headers redacted, for dumping as
+	// HAR or JSONL; see anp_debug.Transcript.
+	Transcript *anp_debug.Transcript
+
+	// Observer, if set, is told about every fetch, tool call and failure;
+	// see Observer.
+	Observer Observer
+
+	// DryRun, when set, intercepts tool calls and other writes instead of
+	// sending them; see DryRunConfig.
+	DryRun *DryRunConfig
+
+	// Results, when set, persists successful tool call results and fetched
+	// documents keyed by RequestKey; later calls with the same request replay
+	// the stored response. In dry-run mode the store is only read.
+	Results ResultStore
+
+	// Capabilities are advertised to servers in the ANP-Capabilities header
+	// along with ProtocolVersion. Nil uses DefaultCapabilities; an empty
+	// non-nil slice advertises none.
+	Capabilities []string
+
+	// DropRaw discards Document.Raw once a document is parsed and recycles
+	// the response buffer for later fetches, reducing GC pressure in large
+	// crawls. Custom parsers must then not retain the content they are given.
+	DropRaw bool
+
+	// DocumentMaxAge is how long a fetched document stays fresh for
+	// Session.Refresh when the server sends no Cache-Control max-age. Zero
+	// makes documents stale as soon as they are fetched.
+	DocumentMaxAge time.Duration
+
+	// DocumentCache, when set, serves repeated fetches of the same URL from
+	// memory; see DocumentCacheConfig and Session.ForgetDocuments.
+	DocumentCache *DocumentCacheConfig
+
+	// Politeness, when set, paces FetchBatch and FetchDeep per host and
+	// retries URLs answered with 429; see Politeness.
+	Politeness *Politeness
+
+	// Retry, when set, retries the session's idempotent requests on
+	// transport errors and retryable statuses; tool calls only when the
+	// tool is listed in RetryPolicy.Tools. See RetryPolicy.
+	Retry *RetryPolicy
+
+	// ToolMiddleware wraps every tool call made through the interfaces of
+	// fetched documents, the first entry outermost; see BeforeTool and
+	// AfterTool.
+	ToolMiddleware []ToolMiddleware
+
+	// Approve, if set, is asked before every tool call is sent, after
+	// ToolMiddleware has run, and may allow, deny or modify it; see
+	// RequireApproval.
+	Approve ApprovalFunc
+
+	// ToolTimeout bounds the request of each tool call, in place of the
+	// HTTP client timeout; WithToolTimeout overrides it per call. Zero
+	// leaves calls to the HTTP client timeout.
+	ToolTimeout time.Duration
+
+	// ToolNamespace, if set, renames the tools of every fetched document as
+	// they are added to the session's ToolRegistry, e.g. NamespaceByHost.
+	ToolNamespace ToolNamespace
+
+	// DedupeDocuments parses and converts each distinct document body once
+	// per session: a fetch whose body matches an earlier one by ContentHash
+	// reuses that document's parse result, tools and raw content. Parse
+	// results can depend on the URL through relative links, which then
+	// resolve against the URL the content was first fetched from.
+	DedupeDocuments bool
 }
 
 // HTTPConfig customises the HTTP transport used by the session.
 type HTTPConfig struct {
 	Client  *http.Client
 	Timeout time.Duration
+	// ClientOptions are applied to the crawler client after the session's
+	// own, e.g. anp_crawler.WithHostRateLimit.
+	ClientOptions []anp_crawler.ClientOption
+	// Proxy selects a proxy per request (see anp_crawler.ProxyByHost). It is
+	// applied to Client's transport, which must then be nil or an
+	// *http.Transport.
+	Proxy anp_crawler.ProxyFunc
 }
 
 // ParserConfig allows injecting custom parser/converter implementations.
@@ -48,10 +135,23 @@ type ParserConfig struct {
 type Session struct {
 	authenticator *anp_auth.Authenticator
 	client        anp_crawler.Client
+	calls         anp_crawler.Client // client, or a ResultStore replaying client
 	parser        anp_crawler.Parser
 	converter     *anp_crawler.ANPInterfaceConverter
 	logger        *slog.Logger
+	debug         *anp_debug.Recorder
+	dryRun        *dryRunTransport
+	dropRaw       bool
+	maxAge        time.Duration
 	sem           *semaphore.Weighted
+	maxConcurrent int
+	politeness    *Politeness
+	dedupe        bool
+	parsed        sync.Map // ContentHash -> *parsedDocument
+	docs          *documentCache
+	observer      Observer
+	tools         *ToolRegistry
+	intercept     func(context.Context, *anp_crawler.ANPInterface, map[string]any, anp_crawler.ExecuteFunc) (map[string]any, error)
 }
 
 // Document stores the result of fetching and parsing an ANP document.
@@ -59,10 +159,34 @@ type Document struct {
 	URL         string
 	StatusCode  int
 	ContentType string
+	// ContentHash is the hex SHA-256 of the response body, identifying
+	// documents served with identical content under different URLs.
+	ContentHash string
 	Raw         []byte
 	Result      *anp_crawler.ParseResult
 	Tools       []*anp_crawler.ANPTool
 	Interfaces  []*anp_crawler.ANPInterface
+
+	// ProtocolVersion and Capabilities are what the document (or, failing
+	// that, the response headers) declares; both are empty when unstated.
+	ProtocolVersion string
+	Capabilities    []string
+
+	// FetchedAt is when the document was fetched; it is stale from ExpiresAt
+	// on (see Stale and Session.Refresh).
+	FetchedAt time.Time
+	ExpiresAt time.Time
+}
+
+// StatusError is returned by Fetch when the server answers with a non-2xx status.
+type StatusError struct {
+	URL        string
+	StatusCode int
+	Header     http.Header
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("fetch %s: status %d", e.URL, e.StatusCode)
 }
 
 // New creates a Session with sensible defaults.
@@ -71,8 +195,6 @@ func New(cfg Config) (*Session, error) {
 	if logger == nil {
 		logger = slog.Default()
 	}
-	anp_crawler.SetLogger(logger)
-
 	authenticator := cfg.Authenticator
 	if authenticator == nil {
 		auth, err := anp_auth.NewAuthenticator(
@@ -93,17 +215,68 @@ func New(cfg Config) (*Session, error) {
 	} else if httpClient.Timeout == 0 {
 		httpClient.Timeout = defaultHTTPTimeout
 	}
+	if cfg.HTTP.Proxy != nil {
+		proxied, err := anp_crawler.ProxyClient(httpClient, cfg.HTTP.Proxy)
+		if err != nil {
+			return nil, fmt.Errorf("anp/session: %w", err)
+		}
+		httpClient = proxied
+	}
+
+	var dryRun *dryRunTransport
+	if cfg.DryRun != nil {
+		dryRun = &dryRunTransport{base: httpClient.Transport, cfg: *cfg.DryRun}
+		intercepted := *httpClient
+		intercepted.Transport = dryRun
+		httpClient = &intercepted
+		logger.Info("dry-run mode: writes will not be sent")
+	}
+
+	debug := cfg.Debug
+	if debug == nil {
+		rec, err := anp_debug.FromEnv()
+		if err != nil {
+			return nil, err
+		}
+		debug = rec
+	}
+	if debug != nil {
+		recorded := *httpClient
+		recorded.Transport = debug.Transport(httpClient.Transport)
+		httpClient = &recorded
+		logger.Info("recording ANP traffic", "dir", debug.Dir())
+	}
+	if cfg.Transcript != nil {
+		recorded := *httpClient
+		recorded.Transport = cfg.Transcript.Transport(httpClient.Transport)
+		httpClient = &recorded
+	}
 
-	client := anp_crawler.NewClient(authenticator, anp_crawler.WithHTTPClient(httpClient))
+	capabilities := cfg.Capabilities
+	if capabilities == nil {
+		capabilities = DefaultCapabilities
+	}
+	versioned := *httpClient
+	versioned.Transport = &versionTransport{base: httpClient.Transport, capabilities: strings.Join(capabilities, ", ")}
+	httpClient = &versioned
+
+	clientOpts := append([]anp_crawler.ClientOption{
+		anp_crawler.WithHTTPClient(httpClient),
+		anp_crawler.WithLogger(logger),
+	}, cfg.HTTP.ClientOptions...)
+	client := anp_crawler.NewClient(authenticator, clientOpts...)
+	if cfg.Retry != nil {
+		client = &retryClient{next: client, policy: cfg.Retry.withDefaults(), logger: logger}
+	}
 
 	parser := cfg.Parser.Parser
 	if parser == nil {
-		parser = anp_crawler.NewJSONParser()
+		parser = &anp_crawler.JSONParser{Logger: logger}
 	}
 
 	converter := cfg.Parser.Converter
 	if converter == nil {
-		converter = anp_crawler.NewANPInterfaceConverter()
+		converter = &anp_crawler.ANPInterfaceConverter{Logger: logger}
 	}
 
 	maxConc := cfg.MaxConcurrent
@@ -111,50 +284,119 @@ func New(cfg Config) (*Session, error) {
 		maxConc = 5
 	}
 
+	calls := client
+	if cfg.Results != nil {
+		calls = &storeClient{next: client, store: cfg.Results, readOnly: cfg.DryRun != nil, logger: logger}
+	}
+
 	return &Session{
 		authenticator: authenticator,
 		client:        client,
+		calls:         calls,
 		parser:        parser,
 		converter:     converter,
 		logger:        logger,
+		debug:         debug,
+		dryRun:        dryRun,
+		dropRaw:       cfg.DropRaw,
+		maxAge:        cfg.DocumentMaxAge,
 		sem:           semaphore.NewWeighted(int64(maxConc)),
+		maxConcurrent: maxConc,
+		politeness:    cfg.Politeness,
+		dedupe:        cfg.DedupeDocuments,
+		docs:          newDocumentCache(cfg.DocumentCache),
+		observer:      cfg.Observer,
+		tools:         &ToolRegistry{Namespace: cfg.ToolNamespace, tools: make(map[string]*RegisteredTool)},
+		intercept:     toolIntercept(toolChain(cfg)),
 	}, nil
 }
 
+// toolChain returns the tool middleware configured by cfg, with the observer
+// outermost so it sees every outcome, the approval gate inside the user's
+// middleware so it sees the arguments that will be sent, and the call
+// timeout innermost so waiting for approval does not count against it.
+func toolChain(cfg Config) []ToolMiddleware {
+	var chain []ToolMiddleware
+	if cfg.Observer != nil {
+		chain = append(chain, observeTools(cfg.Observer))
+	}
+	chain = append(chain, cfg.ToolMiddleware...)
+	if cfg.Approve != nil {
+		chain = append(chain, RequireApproval(cfg.Approve))
+	}
+	if cfg.Retry != nil && len(cfg.Retry.Tools) > 0 {
+		chain = append(chain, retryTools(cfg.Retry.Tools))
+	}
+	return append(chain, toolTimeout(cfg.ToolTimeout))
+}
+
 // Authenticator exposes the underlying authenticator for advanced use cases.
 func (s *Session) Authenticator() *anp_auth.Authenticator {
 	return s.authenticator
 }
 
+// Tools returns the registry of the tools of every document the session has
+// fetched.
+func (s *Session) Tools() *ToolRegistry {
+	return s.tools
+}
+
 // Client returns the low-level client used by the session.
 func (s *Session) Client() anp_crawler.Client {
 	return s.client
 }
 
-// Fetch retrieves and parses a single document.
+// Fetch retrieves and parses a single document and adds its tools to the
+// session's ToolRegistry. With Config.DocumentCache set, a document fetched
+// before is returned from the cache until its entry expires.
 func (s *Session) Fetch(ctx context.Context, url string) (*Document, error) {
-	resp, err := s.client.Fetch(ctx, http.MethodGet, url, nil, nil)
+	start := time.Now()
+	if doc, ok := s.docs.get(url); ok {
+		s.observeFetch(ctx, FetchEvent{URL: url, Document: doc, Cached: true, Duration: time.Since(start)})
+		return doc, nil
+	}
+	doc, err := s.fetch(ctx, url)
+	s.observeFetch(ctx, FetchEvent{URL: url, Document: doc, Duration: time.Since(start), Err: err})
+	if err != nil {
+		return nil, err
+	}
+	s.docs.put(doc)
+	return doc, nil
+}
+
+func (s *Session) fetch(ctx context.Context, url string) (*Document, error) {
+	resp, err := s.calls.Fetch(ctx, http.MethodGet, url, nil, nil)
 	if err != nil {
 		return nil, fmt.Errorf("fetch %s: %w", url, err)
 	}
 
 	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
-		return nil, fmt.Errorf("fetch %s: status %d", url, resp.StatusCode)
+		return nil, &StatusError{URL: url, StatusCode: resp.StatusCode, Header: resp.Header}
+	}
+	return s.document(ctx, url, resp)
+}
+
+// document parses the successful response resp to a GET of url and adds the
+// resulting document's tools to the registry.
+func (s *Session) document(ctx context.Context, url string, resp *anp_crawler.Response) (*Document, error) {
+	hash := contentHash(resp.Body)
+	if s.dedupe {
+		if v, ok := s.parsed.Load(hash); ok {
+			s.logger.Debug("reusing parsed duplicate document", "url", url, "hash", hash)
+			doc := s.duplicateDocument(url, resp, hash, v.(*parsedDocument))
+			s.tools.Add(doc)
+			return doc, nil
+		}
 	}
 
 	result, err := s.parser.Parse(ctx, resp.Body, resp.ContentType, url)
+	s.debug.RecordParse(url, resp.ContentType, result, err)
 	if err != nil {
 		return nil, fmt.Errorf("parse %s: %w", url, err)
 	}
 
-	doc := &Document{
-		URL:         url,
-		StatusCode:  resp.StatusCode,
-		ContentType: resp.ContentType,
-		Raw:         resp.Body,
-		Result:      result,
-	}
-
+	doc := s.newDocument(url, resp, hash)
+	doc.Result = result
 	for _, entry := range result.Interfaces {
 		var toolName string
 		if tool, err := s.converter.ConvertToANPTool(entry); err == nil && tool != nil {
@@ -171,20 +413,62 @@ func (s *Session) Fetch(ctx context.Context, url string) (*Document, error) {
 			}
 		}
 
-		iface := anp_crawler.NewANPInterface(toolName, entry, s.client)
+		iface := anp_crawler.NewANPInterface(toolName, entry, s.calls)
 		if iface != nil {
+			iface.Logger = s.logger
+			iface.Intercept = s.intercept
 			doc.Interfaces = append(doc.Interfaces, iface)
 		}
 	}
+	if s.dedupe {
+		s.parsed.LoadOrStore(hash, &parsedDocument{raw: doc.Raw, result: result, tools: doc.Tools, interfaces: doc.Interfaces})
+	}
+	s.tools.Add(doc)
 
 	return doc, nil
 }
 
-// FetchBatch fetches multiple documents concurrently.
+// newDocument builds the Document for resp without its parse results,
+// releasing the response buffer if the session drops raw content.
+func (s *Session) newDocument(url string, resp *anp_crawler.Response, hash string) *Document {
+	fetchedAt := time.Now()
+	doc := &Document{
+		URL:         url,
+		StatusCode:  resp.StatusCode,
+		ContentType: resp.ContentType,
+		ContentHash: hash,
+		Raw:         resp.Body,
+		FetchedAt:   fetchedAt,
+		ExpiresAt:   fetchedAt.Add(freshness(resp.Header, s.maxAge)),
+	}
+	doc.ProtocolVersion, doc.Capabilities = protocolInfo(resp.Body, resp.Header)
+	if !CompatibleVersion(doc.ProtocolVersion) {
+		s.logger.Warn("document uses a newer ANP major version; continuing with best effort",
+			"url", url, "version", doc.ProtocolVersion, "supported", ProtocolVersion)
+	}
+	if s.dropRaw {
+		resp.Release()
+		doc.Raw = nil
+	}
+	return doc
+}
+
+// FetchBatch fetches multiple documents concurrently. It fails with the
+// first error; with Config.Politeness set, the remaining URLs are still
+// fetched before it returns.
 func (s *Session) FetchBatch(ctx context.Context, urls []string) ([]*Document, error) {
 	if len(urls) == 0 {
 		return nil, nil
 	}
+	if s.politeness != nil {
+		docs, errs := s.fetchPolitely(ctx, urls)
+		for _, err := range errs {
+			if err != nil {
+				return nil, err
+			}
+		}
+		return docs, nil
+	}
 
 	results := make([]*Document, len(urls))
 	g, ctx := errgroup.WithContext(ctx)
@@ -237,7 +521,8 @@ func ListAgents(doc *Document) []anp_crawler.AgentEntry {
 	return doc.Result.Agents
 }
 
-// ContentString returns the document body as a UTF-8 string.
+// ContentString returns the document body as a UTF-8 string. It is empty
+// when the session was configured with DropRaw.
 func (d *Document) ContentString() string {
 	if d == nil {
 		return ""
@@ -254,6 +539,7 @@ func NewFromAuthenticator(auth *anp_auth.Authenticator) (*Session, error) {
 }
 
 // ExecuteTool searches for the specified method within the document interfaces and executes it.
+// method may also be a tool name as registered by the session's ToolRegistry.
 func ExecuteTool(ctx context.Context, doc *Document, method string, params map[string]any) (map[string]any, error) {
 	if doc == nil {
 		return nil, errors.New("document is nil")
@@ -263,5 +549,43 @@ func ExecuteTool(ctx context.Context, doc *Document, method string, params map[s
 			return iface.Execute(ctx, params)
 		}
 	}
+	for _, iface := range doc.Interfaces {
+		if iface.ToolName == method {
+			return iface.Execute(ctx, params)
+		}
+	}
 	return nil, fmt.Errorf("method %s not available", method)
 }
+
+// ExecuteToolAs executes method like ExecuteTool and decodes the JSON-RPC
+// result into a T:
+//
+//	hotels, err := session.ExecuteToolAs[[]Hotel](ctx, doc, "searchHotels", params)
+func ExecuteToolAs[T any](ctx context.Context, doc *Document, method string, params map[string]any) (T, error) {
+	var out T
+	resp, err := ExecuteTool(ctx, doc, method, params)
+	if err != nil {
+		return out, err
+	}
+	if err := DecodeResult(resp, &out); err != nil {
+		return out, fmt.Errorf("method %s: %w", method, err)
+	}
+	return out, nil
+}
+
+// DecodeResult unmarshals the result field of a JSON-RPC response, as
+// returned by ExecuteTool, into v.
+func DecodeResult(resp map[string]any, v any) error {
+	result, ok := resp["result"]
+	if !ok {
+		return errors.New("anp/session: response has no result")
+	}
+	raw, err := sonic.Marshal(result)
+	if err != nil {
+		return fmt.Errorf("anp/session: encode result: %w", err)
+	}
+	if err := sonic.Unmarshal(raw, v); err != nil {
+		return fmt.Errorf("anp/session: decode result: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,88 @@
+// Package sqlite provides a SQLite-backed session.ResultStore, so tool call
+// results and fetched documents survive a crash or restart.
+//
+// It is a separate module so the core SDK does not depend on a SQLite driver:
+//
+//	store, err := sqlite.Open("results.db")
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	defer store.Close()
+//	sess, _ := session.New(session.Config{Authenticator: auth, Results: store})
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/openanp/anp-go/session"
+	_ "modernc.org/sqlite"
+)
+
+const schema = `CREATE TABLE IF NOT EXISTS anp_results (
+	key   TEXT PRIMARY KEY,
+	value BLOB NOT NULL
+)`
+
+var _ session.ResultStore = (*Store)(nil)
+
+// Store keeps results in a SQLite database.
+type Store struct {
+	db    *sql.DB
+	owned bool
+}
+
+// Open opens (creating if needed) the SQLite database at path.
+func Open(path string) (*Store, error) {
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return nil, err
+	}
+	s, err := New(db)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	s.owned = true
+	return s, nil
+}
+
+// New uses an already opened SQLite database, creating the results table if it
+// does not exist.
+func New(db *sql.DB) (*Store, error) {
+	if _, err := db.Exec(schema); err != nil {
+		return nil, fmt.Errorf("sqlite: create schema: %w", err)
+	}
+	return &Store{db: db}, nil
+}
+
+// Close closes the database if it was opened by Open.
+func (s *Store) Close() error {
+	if !s.owned {
+		return nil
+	}
+	return s.db.Close()
+}
+
+// Get returns the value stored under key.
+func (s *Store) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	var value []byte
+	err := s.db.QueryRowContext(ctx, `SELECT value FROM anp_results WHERE key = ?`, key).Scan(&value)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return value, true, nil
+}
+
+// Put inserts or replaces the value stored under key.
+func (s *Store) Put(ctx context.Context, key string, value []byte) error {
+	_, err := s.db.ExecContext(ctx,
+		`INSERT INTO anp_results (key, value) VALUES (?, ?)
+		ON CONFLICT (key) DO UPDATE SET value = excluded.value`, key, value)
+	return err
+}
@@ -0,0 +1,37 @@
+package sqlite
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+)
+
+func TestStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "results.db")
+	store, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	ctx := context.Background()
+	if _, ok, err := store.Get(ctx, "k"); ok || err != nil {
+		t.Fatalf("Get(missing) = %v, %v", ok, err)
+	}
+	if err := store.Put(ctx, "k", []byte("v1")); err != nil {
+		t.Fatalf("Put() error = %v", err)
+	}
+	if err := store.Put(ctx, "k", []byte("v2")); err != nil {
+		t.Fatalf("Put(replace) error = %v", err)
+	}
+	if err := store.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	store, err = Open(path)
+	if err != nil {
+		t.Fatalf("reopen error = %v", err)
+	}
+	defer store.Close()
+	if v, ok, err := store.Get(ctx, "k"); !ok || err != nil || string(v) != "v2" {
+		t.Errorf("Get() = %q, %v, %v", v, ok, err)
+	}
+}
@@ -0,0 +1,45 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/openanp/anp-go/anp_crawler"
+)
+
+type toolTimeoutKey struct{}
+
+// WithToolTimeout returns a context that bounds tool calls made with it to
+// d, overriding Config.ToolTimeout, e.g. to give one slow tool more time
+// than the rest of an agent loop. Zero or negative removes the bound; ctx
+// cancellation still aborts the call.
+func WithToolTimeout(ctx context.Context, d time.Duration) context.Context {
+	return context.WithValue(ctx, toolTimeoutKey{}, d)
+}
+
+// toolTimeout returns the innermost middleware of the session's chain, which
+// bounds the request of each call, not the middleware or approval before it,
+// to the call's timeout. The timeout replaces the HTTP client's, so a call
+// may be given longer than a document fetch.
+func toolTimeout(fallback time.Duration) ToolMiddleware {
+	return func(next ToolHandler) ToolHandler {
+		return func(ctx context.Context, call *ToolCall) (map[string]any, error) {
+			d := fallback
+			if v, ok := ctx.Value(toolTimeoutKey{}).(time.Duration); ok {
+				d = v
+			}
+			if d <= 0 {
+				return next(ctx, call)
+			}
+			callCtx, cancel := context.WithTimeout(anp_crawler.ContextWithRequestTimeout(ctx, d), d)
+			defer cancel()
+			resp, err := next(callCtx, call)
+			if err != nil && ctx.Err() == nil && errors.Is(callCtx.Err(), context.DeadlineExceeded) {
+				return nil, fmt.Errorf("anp/session: tool %s timed out after %s: %w", call.Name, d, context.DeadlineExceeded)
+			}
+			return resp, err
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/openanp/anp-go/anptest"
+)
+
+type sleepParams struct {
+	MS int `json:"ms"`
+}
+
+func TestToolTimeout(t *testing.T) {
+	caller := anptest.NewIdentity(t, "client.example.com")
+	srv := anptest.NewServer(t,
+		anptest.WithMethod("sleep", func(p sleepParams) (int, error) {
+			time.Sleep(time.Duration(p.MS) * time.Millisecond)
+			return p.MS, nil
+		}),
+		anptest.WithDIDAuth(caller),
+	)
+	sess, err := New(Config{
+		Authenticator: caller.Authenticator,
+		HTTP:          HTTPConfig{Timeout: 100 * time.Millisecond},
+		ToolTimeout:   20 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	ctx := context.Background()
+	doc, err := sess.Fetch(ctx, srv.OpenRPCURL())
+	if err != nil {
+		t.Fatalf("Fetch() error = %v", err)
+	}
+
+	start := time.Now()
+	_, err = ExecuteTool(ctx, doc, "sleep", map[string]any{"ms": 200})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("ExecuteTool() error = %v, want context.DeadlineExceeded", err)
+	}
+	if elapsed := time.Since(start); elapsed > 150*time.Millisecond {
+		t.Errorf("ExecuteTool() took %v, want the 20ms tool timeout", elapsed)
+	}
+
+	// A per-call timeout may outlast the HTTP client timeout.
+	resp, err := ExecuteTool(WithToolTimeout(ctx, time.Second), doc, "sleep", map[string]any{"ms": 150})
+	if err != nil {
+		t.Fatalf("ExecuteTool(WithToolTimeout) error = %v", err)
+	}
+	var slept int
+	if err := DecodeResult(resp, &slept); err != nil || slept != 150 {
+		t.Errorf("ExecuteTool(WithToolTimeout) = %v", resp)
+	}
+
+	// Cancelling ctx aborts the call in flight.
+	cancelCtx, cancel := context.WithCancel(WithToolTimeout(ctx, 0))
+	time.AfterFunc(20*time.Millisecond, cancel)
+	start = time.Now()
+	if _, err := sess.Tools().Execute(cancelCtx, "sleep", map[string]any{"ms": 200}); !errors.Is(err, context.Canceled) {
+		t.Errorf("Execute(cancelled) error = %v, want context.Canceled", err)
+	}
+	if elapsed := time.Since(start); elapsed > 150*time.Millisecond {
+		t.Errorf("Execute(cancelled) took %v, want prompt return", elapsed)
+	}
+}
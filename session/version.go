@@ -0,0 +1,110 @@
+package session
+
+import (
+	"net/http"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/bytedance/sonic"
+)
+
+// ProtocolVersion is the ANP protocol version implemented by this SDK.
+// Documents with the same major version are fully supported; newer major
+// versions are used on a best-effort basis with a warning.
+const ProtocolVersion = "1.0.0"
+
+// Headers advertising the client's protocol support on every request.
+const (
+	HeaderProtocolVersion = "ANP-Protocol-Version"
+	HeaderCapabilities    = "ANP-Capabilities"
+)
+
+// DefaultCapabilities are the features advertised when Config.Capabilities
+// is nil.
+var DefaultCapabilities = []string{"did-wba", "delegation", "openrpc", "jsonrpc"}
+
+// CompatibleVersion reports whether documents of the given protocol version
+// can be handled without degradation: an empty or unparseable version is
+// assumed compatible, otherwise its major version must not exceed ours.
+func CompatibleVersion(version string) bool {
+	major, ok := majorVersion(version)
+	if !ok {
+		return true
+	}
+	ours, _ := majorVersion(ProtocolVersion)
+	return major <= ours
+}
+
+func majorVersion(version string) (int, bool) {
+	version = strings.TrimPrefix(strings.TrimSpace(version), "v")
+	head, _, _ := strings.Cut(version, ".")
+	major, err := strconv.Atoi(head)
+	return major, err == nil
+}
+
+// HasCapability reports whether the document advertises the named capability.
+func (d *Document) HasCapability(name string) bool {
+	return slices.ContainsFunc(d.Capabilities, func(c string) bool { return strings.EqualFold(c, name) })
+}
+
+// protocolInfo reads the protocol version and capabilities a document
+// declares, falling back to the version header of the response. Only the two
+// fields are decoded, and nothing returned aliases body.
+func protocolInfo(body []byte, header http.Header) (string, []string) {
+	var version string
+	if node, err := sonic.Get(body, "protocolVersion"); err == nil {
+		if v, err := node.String(); err == nil {
+			version = strings.Clone(v)
+		}
+	}
+	if version == "" {
+		version = header.Get(HeaderProtocolVersion)
+	}
+
+	var decl any
+	if node, err := sonic.Get(body, "capabilities"); err == nil {
+		decl, _ = node.Interface()
+	}
+	// Capabilities are either a list of names or an object of feature flags.
+	var caps []string
+	switch v := decl.(type) {
+	case []any:
+		for _, c := range v {
+			if s, ok := c.(string); ok {
+				caps = append(caps, strings.Clone(s))
+			}
+		}
+	case map[string]any:
+		for name, enabled := range v {
+			if enabled != false && enabled != nil {
+				caps = append(caps, strings.Clone(name))
+			}
+		}
+		sort.Strings(caps)
+	}
+	return version, caps
+}
+
+// versionTransport adds the protocol headers to requests that lack them.
+type versionTransport struct {
+	base         http.RoundTripper
+	capabilities string
+}
+
+func (t *versionTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	if req.Header.Get(HeaderProtocolVersion) != "" {
+		return base.RoundTrip(req)
+	}
+	req = req.Clone(req.Context())
+	req.Header.Set(HeaderProtocolVersion, ProtocolVersion)
+	if t.capabilities != "" {
+		req.Header.Set(HeaderCapabilities, t.capabilities)
+	}
+	return base.RoundTrip(req)
+}
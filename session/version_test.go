@@ -0,0 +1,85 @@
+package session
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/openanp/anp-go/anptest"
+)
+
+func TestCompatibleVersion(t *testing.T) {
+	tests := []struct {
+		version string
+		want    bool
+	}{
+		{"", true},
+		{"1.0.0", true},
+		{"0.9", true},
+		{"v1.2", true},
+		{"2.0.0", false},
+		{"draft", true},
+	}
+	for _, tt := range tests {
+		if got := CompatibleVersion(tt.version); got != tt.want {
+			t.Errorf("CompatibleVersion(%q) = %v, want %v", tt.version, got, tt.want)
+		}
+	}
+}
+
+func TestFetchProtocolNegotiation(t *testing.T) {
+	var gotVersion, gotCaps string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotVersion, gotCaps = r.Header.Get(HeaderProtocolVersion), r.Header.Get(HeaderCapabilities)
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/ad.json":
+			w.Write([]byte(`{"protocolType":"ANP","protocolVersion":"2.1.0","capabilities":{"streaming":true,"payments":false},"interfaces":[]}`))
+		default:
+			w.Header().Set(HeaderProtocolVersion, "1.1.0")
+			w.Write([]byte(`{"interfaces":[],"capabilities":["openrpc"]}`))
+		}
+	}))
+	defer srv.Close()
+
+	var logs bytes.Buffer
+	caller := anptest.NewIdentity(t, "client.example.com")
+	sess, err := New(Config{
+		Authenticator: caller.Authenticator,
+		Logger:        slog.New(slog.NewTextHandler(&logs, nil)),
+		Capabilities:  []string{"did-wba", "streaming"},
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	doc, err := sess.Fetch(context.Background(), srv.URL+"/ad.json")
+	if err != nil {
+		t.Fatalf("Fetch() error = %v", err)
+	}
+	if gotVersion != ProtocolVersion || gotCaps != "did-wba, streaming" {
+		t.Errorf("request headers = %q, %q", gotVersion, gotCaps)
+	}
+	if doc.ProtocolVersion != "2.1.0" || !doc.HasCapability("Streaming") || doc.HasCapability("payments") {
+		t.Errorf("document = %q %v", doc.ProtocolVersion, doc.Capabilities)
+	}
+	if !strings.Contains(logs.String(), "newer ANP major version") {
+		t.Errorf("no warning logged for major version 2: %s", logs.String())
+	}
+
+	logs.Reset()
+	doc, err = sess.Fetch(context.Background(), srv.URL+"/other.json")
+	if err != nil {
+		t.Fatalf("Fetch() error = %v", err)
+	}
+	if doc.ProtocolVersion != "1.1.0" || !doc.HasCapability("openrpc") {
+		t.Errorf("document = %q %v", doc.ProtocolVersion, doc.Capabilities)
+	}
+	if strings.Contains(logs.String(), "WARN") {
+		t.Errorf("unexpected warning: %s", logs.String())
+	}
+}
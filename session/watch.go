@@ -0,0 +1,193 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/bytedance/sonic"
+	"github.com/openanp/anp-go/anp_crawler"
+)
+
+// DocumentChange describes how a watched document changed. Tools are named
+// as in the session's ToolRegistry and agents by URL.
+type DocumentChange struct {
+	URL string
+	Old *Document
+	New *Document
+
+	AddedTools   []string
+	RemovedTools []string
+	ChangedTools []string
+
+	AddedAgents   []string
+	RemovedAgents []string
+	ChangedAgents []string
+}
+
+// Empty reports whether the tools and agents are unchanged, e.g. when only
+// a description outside them was edited.
+func (c DocumentChange) Empty() bool {
+	return len(c.AddedTools)+len(c.RemovedTools)+len(c.ChangedTools)+
+		len(c.AddedAgents)+len(c.RemovedAgents)+len(c.ChangedAgents) == 0
+}
+
+// DiffDocuments compares two versions of a document, such as the input and
+// output of Session.Refresh.
+func DiffDocuments(before, after *Document) DocumentChange {
+	change := DocumentChange{Old: before, New: after}
+	if after != nil {
+		change.URL = after.URL
+	} else if before != nil {
+		change.URL = before.URL
+	}
+	change.AddedTools, change.RemovedTools, change.ChangedTools = diffByKey(documentTools(before), documentTools(after), func(a, b *anp_crawler.ANPTool) bool {
+		// ConfigStd sorts map keys, so equal schemas encode equally.
+		x, errX := sonic.ConfigStd.Marshal(a)
+		y, errY := sonic.ConfigStd.Marshal(b)
+		return errX == nil && errY == nil && string(x) == string(y)
+	})
+	change.AddedAgents, change.RemovedAgents, change.ChangedAgents = diffByKey(documentAgents(before), documentAgents(after), func(a, b anp_crawler.AgentEntry) bool {
+		return a == b
+	})
+	return change
+}
+
+func documentTools(doc *Document) map[string]*anp_crawler.ANPTool {
+	out := make(map[string]*anp_crawler.ANPTool)
+	if doc != nil {
+		for _, tool := range doc.Tools {
+			out[tool.Function.Name] = tool
+		}
+	}
+	return out
+}
+
+func documentAgents(doc *Document) map[string]anp_crawler.AgentEntry {
+	out := make(map[string]anp_crawler.AgentEntry)
+	for _, agent := range ListAgents(doc) {
+		out[agent.URL] = agent
+	}
+	return out
+}
+
+// diffByKey returns the sorted keys only in after, only in before, and in
+// both but not equal.
+func diffByKey[V any](before, after map[string]V, equal func(a, b V) bool) (added, removed, changed []string) {
+	for k, v := range after {
+		if prev, ok := before[k]; !ok {
+			added = append(added, k)
+		} else if !equal(prev, v) {
+			changed = append(changed, k)
+		}
+	}
+	for k := range before {
+		if _, ok := after[k]; !ok {
+			removed = append(removed, k)
+		}
+	}
+	slices.Sort(added)
+	slices.Sort(removed)
+	slices.Sort(changed)
+	return added, removed, changed
+}
+
+// Watch fetches url and then polls it every interval until ctx is done,
+// calling fn with the change whenever the document's content differs from
+// the last version seen. Polls are conditional requests with the ETag and
+// Last-Modified validators of the previous response, so an unchanged
+// document usually costs a 304. The tool registry and document cache follow
+// the changes. A failed poll is logged and retried at the next interval;
+// only the first fetch failing makes Watch return early. Otherwise it
+// returns ctx.Err().
+func (s *Session) Watch(ctx context.Context, url string, interval time.Duration, fn func(DocumentChange)) error {
+	if interval <= 0 {
+		return errors.New("anp/session: watch interval must be positive")
+	}
+	w := &watch{s: s, url: url}
+	if _, err := w.poll(ctx); err != nil {
+		return err
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+		old := w.current
+		changed, err := w.poll(ctx)
+		switch {
+		case ctx.Err() != nil:
+			return ctx.Err()
+		case err != nil:
+			s.logger.Warn("watch poll failed", "url", url, "error", err)
+		case changed:
+			change := DiffDocuments(old, w.current)
+			removed := make(map[string]bool, len(change.RemovedTools))
+			for _, name := range change.RemovedTools {
+				removed[name] = true
+			}
+			s.tools.removeNames(url, removed)
+			fn(change)
+		}
+	}
+}
+
+// watch is the state of one Watch loop.
+type watch struct {
+	s          *Session
+	url        string
+	current    *Document
+	validators map[string]string
+}
+
+// poll fetches the document, reporting whether its content changed since
+// the last poll.
+func (w *watch) poll(ctx context.Context) (bool, error) {
+	start := time.Now()
+	changed, err := w.fetch(ctx)
+	w.s.observeFetch(ctx, FetchEvent{URL: w.url, Document: w.current, Duration: time.Since(start), Err: err})
+	return changed, err
+}
+
+func (w *watch) fetch(ctx context.Context) (bool, error) {
+	resp, err := w.s.calls.Fetch(ctx, http.MethodGet, w.url, w.validators, nil)
+	if err != nil {
+		return false, fmt.Errorf("fetch %s: %w", w.url, err)
+	}
+	if resp.StatusCode == http.StatusNotModified && w.current != nil {
+		return false, nil
+	}
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		return false, &StatusError{URL: w.url, StatusCode: resp.StatusCode, Header: resp.Header}
+	}
+	w.validators = conditionalHeaders(resp.Header)
+	if w.current != nil && contentHash(resp.Body) == w.current.ContentHash {
+		return false, nil
+	}
+	doc, err := w.s.document(ctx, w.url, resp)
+	if err != nil {
+		return false, err
+	}
+	w.s.docs.put(doc)
+	w.current = doc
+	return true, nil
+}
+
+// conditionalHeaders returns the request headers revalidating a response
+// with header.
+func conditionalHeaders(header http.Header) map[string]string {
+	out := make(map[string]string, 2)
+	if etag := header.Get("ETag"); etag != "" {
+		out["If-None-Match"] = etag
+	}
+	if modified := header.Get("Last-Modified"); modified != "" {
+		out["If-Modified-Since"] = modified
+	}
+	return out
+}
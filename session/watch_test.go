@@ -0,0 +1,142 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/openanp/anp-go/anp_crawler"
+	"github.com/openanp/anp-go/anptest"
+)
+
+func TestWatch(t *testing.T) {
+	var methods, notModified atomic.Int32
+	methods.Store(1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := methods.Load()
+		etag := fmt.Sprintf(`"v%d"`, n)
+		w.Header().Set("ETag", etag)
+		if r.Header.Get("If-None-Match") == etag {
+			notModified.Add(1)
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(benchmarkAD(int(n)))
+	}))
+	defer srv.Close()
+	sess, err := New(Config{Authenticator: anptest.NewIdentity(t, "client.example.com").Authenticator})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	changes := make(chan DocumentChange)
+	done := make(chan error, 1)
+	go func() {
+		done <- sess.Watch(ctx, srv.URL+"/ad.json", 5*time.Millisecond, func(c DocumentChange) { changes <- c })
+	}()
+	next := func() DocumentChange {
+		t.Helper()
+		select {
+		case c := <-changes:
+			return c
+		case <-time.After(5 * time.Second):
+			t.Fatal("no change reported")
+			return DocumentChange{}
+		}
+	}
+
+	// Let a few polls revalidate the first version.
+	for notModified.Load() < 2 {
+		time.Sleep(time.Millisecond)
+	}
+	methods.Store(3)
+	if c := next(); !slices.Equal(c.AddedTools, []string{"method_1", "method_2"}) || len(c.RemovedTools) != 0 || c.Old == nil || len(c.New.Tools) != 3 {
+		t.Errorf("change = %+v, want method_1 and method_2 added", c)
+	}
+	methods.Store(2)
+	c := next()
+	if !slices.Equal(c.RemovedTools, []string{"method_2"}) || len(c.AddedTools) != 0 || len(c.ChangedTools) != 0 {
+		t.Errorf("change = %+v, want method_2 removed", c)
+	}
+	if _, ok := sess.Tools().Lookup("method_2"); ok {
+		t.Error("removed tool still registered")
+	}
+	if _, ok := sess.Tools().Lookup("method_1"); !ok {
+		t.Error("remaining tool not registered")
+	}
+
+	cancel()
+	if err := <-done; !errors.Is(err, context.Canceled) {
+		t.Errorf("Watch() error = %v, want context.Canceled", err)
+	}
+}
+
+func TestDiffDocuments(t *testing.T) {
+	sess := newBodySession(t, benchmarkAD(2), false)
+	before, err := sess.Fetch(context.Background(), "https://bench.example.com/ad.json")
+	if err != nil {
+		t.Fatalf("Fetch() error = %v", err)
+	}
+	after := *before
+	edited := *before.Tools[1]
+	edited.Function.Description = "Edited"
+	after.Tools = []*anp_crawler.ANPTool{&edited}
+
+	c := DiffDocuments(before, &after)
+	if !slices.Equal(c.RemovedTools, []string{"method_0"}) || !slices.Equal(c.ChangedTools, []string{"method_1"}) || c.Empty() {
+		t.Errorf("DiffDocuments() = %+v", c)
+	}
+	if c := DiffDocuments(before, before); !c.Empty() {
+		t.Errorf("DiffDocuments(same) = %+v, want empty", c)
+	}
+}
+
+// TestWatchConcurrentRegistry is meant for -race: Watch edits the registry
+// while other goroutines read it.
+func TestWatchConcurrentRegistry(t *testing.T) {
+	var version atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		// Alternate between one and three methods so every poll removes or
+		// adds tools.
+		w.Write(benchmarkAD(1 + 2*int(version.Add(1)%2)))
+	}))
+	defer srv.Close()
+	sess, err := New(Config{Authenticator: anptest.NewIdentity(t, "client.example.com").Authenticator})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var changes atomic.Int32
+	done := make(chan error, 1)
+	go func() {
+		done <- sess.Watch(ctx, srv.URL+"/ad.json", time.Millisecond, func(DocumentChange) { changes.Add(1) })
+	}()
+	deadline := time.After(10 * time.Second)
+	for changes.Load() < 20 {
+		select {
+		case <-deadline:
+			t.Fatalf("%d changes reported, want 20", changes.Load())
+		default:
+		}
+		for _, tool := range sess.Tools().List() {
+			sess.Tools().Lookup(tool.Name)
+		}
+		if _, err := sess.Fetch(context.Background(), srv.URL+"/other.json"); err != nil {
+			t.Fatalf("Fetch() error = %v", err)
+		}
+	}
+	cancel()
+	if err := <-done; !errors.Is(err, context.Canceled) {
+		t.Errorf("Watch() error = %v, want context.Canceled", err)
+	}
+}